@@ -0,0 +1,732 @@
+// Code generated by cmd/tsclient from api/openapi.yaml. DO NOT EDIT.
+//
+// Typed client of the Test Task Manager API. Regenerate it with
+// "go generate ./api" after changing the OpenAPI document.
+
+/** 🔥 urgent and important, ⭐ important, ⚡ urgent, 💡 neither, 📋 unset */
+export type Priority = "🔥" | "⭐" | "⚡" | "💡" | "📋";
+
+/** A Priority, or a name for it in any case: urgent (🔥), high (⭐), low (💡), or p1 to p5 in the order of Priority. Tasks always show the emoticon. */
+export type PriorityInput = string;
+
+/** The name or a hex code, of either variant, of a color of the palette of GET /api/meta, in any case. Tasks are stored with the light hex code; new ones without a color are grey (#6c757d), or take the first color of palettes without grey. */
+export type ColorInput = string;
+
+export interface Task {
+  id: string;
+  /** Within the title lengths of GET /api/meta, by default 1 to 255 characters */
+  title: string;
+  completed: boolean;
+  createdAt: string;
+  /** Set while the task is completed */
+  completedAt?: string;
+  priority: Priority;
+  color: string;
+  dueDate?: string;
+  /** When the task was last changed, if it was after creation */
+  updatedAt?: string;
+  /** ID of the user who created the task, unless it was created anonymously */
+  createdBy?: string;
+  /** Name of the color in the palette; left out for colors since dropped from it */
+  colorName?: string;
+  /** Text color contrasting most with color, at least 4.5:1 */
+  textColor?: "#000000" | "#ffffff";
+}
+
+/** A Task in a task list, with its age */
+export interface ListedTask {
+  id: string;
+  title: string;
+  completed: boolean;
+  createdAt: string;
+  completedAt?: string;
+  priority: Priority;
+  color: string;
+  dueDate?: string;
+  updatedAt?: string;
+  createdBy?: string;
+  colorName?: string;
+  textColor: "#000000" | "#ffffff";
+  /** Whole days since the task was created */
+  ageDays: number;
+  /** Open and not changed (updatedAt, or else createdAt) for TTM_STALE_AFTER_DAYS days */
+  stale: boolean;
+}
+
+export interface NewTask {
+  /** Within the title lengths of GET /api/meta, by default 1 to 255 characters */
+  title: string;
+  priority?: PriorityInput;
+  color?: ColorInput;
+  dueDate?: string;
+}
+
+export interface ChangeSet {
+  changes: Array<{
+    seq: number;
+    type: "task.created" | "task.updated" | "task.priority_changed" | "task.completed" | "task.reopened" | "task.deleted";
+    task: Task;
+    at: string;
+    /** Of task.priority_changed */
+    previousPriority?: string;
+  }>;
+  cursor: string;
+  /** Changes after the cursor were missed, as it is from before a restart or too old; reload the tasks */
+  reset?: boolean;
+}
+
+/** The deletion of a task */
+export interface Tombstone {
+  id: string;
+  deletedAt: string;
+}
+
+/** A change of the priority of a task */
+export interface Transition {
+  field: "priority";
+  from: string;
+  to: string;
+  at: string;
+  /** ID of the user who made the change; left out for anonymous clients and escalations */
+  by?: string;
+}
+
+export interface Stats {
+  created: number;
+  completed: number;
+  deleted: number;
+  open: number;
+  completionLatency: {
+    count: number;
+    averageSeconds: number;
+  };
+  user?: UserUsage;
+}
+
+/** The open tasks a user created and the quota on them. In stats, left out for anonymous requests; in errors, only with code USER_QUOTA_EXCEEDED. */
+export interface UserUsage {
+  /** Open tasks the user created */
+  open: number;
+  /** TTM_USER_TASK_QUOTA; 0 means no quota */
+  limit: number;
+}
+
+export interface Meta {
+  /** Lengths in characters (Unicode code points), counted after sanitizing */
+  title: {
+    minLength: number;
+    maxLength: number;
+  };
+  priorities: Priority[];
+  /** Light hex codes of the palette, which tasks are stored with */
+  colors: string[];
+  palette: PaletteColor[];
+  /** Values of tasks created without them */
+  defaults: {
+    priority: Priority;
+    /** Light hex code */
+    color: string;
+    /** Due date of tasks created without one */
+    dueInSeconds?: number;
+  };
+  /** Page size of task lists without limit; 0 lists all tasks */
+  listLimit: number;
+  /** Largest limit; 0 means no cap */
+  maxListLimit: number;
+}
+
+export interface ActivityFeed {
+  activity: Array<{
+    seq: number;
+    kind: "created" | "updated" | "reassigned" | "completed" | "reopened" | "deleted";
+    taskId: string;
+    /** Of the task after the change */
+    title: string;
+    priority: Priority;
+    /** Of reassigned */
+    previousPriority?: string;
+    at: string;
+  }>;
+  /** The before of the next page; left out when the page is empty */
+  next?: number;
+  /** Whether older changes are kept */
+  more: boolean;
+}
+
+/** A color of the palette, with the text color readable on each variant */
+export interface PaletteColor {
+  name: string;
+  /** Shown on light backgrounds */
+  light: string;
+  /** Shown on dark backgrounds */
+  dark: string;
+  lightText: "#000000" | "#ffffff";
+  darkText: "#000000" | "#ffffff";
+}
+
+export interface Usage {
+  workspace: string;
+  /** Left out when the workspace has no plan */
+  plan?: string;
+  tasks: {
+    used: number;
+    /** Task quota; 0 is unlimited */
+    limit: number;
+  };
+  requests: {
+    /** Requests per second of all clients together; 0 is unlimited */
+    rate: number;
+    burst: number;
+    /** Requests that can be made at once; left out without rate limit */
+    remaining?: number;
+  };
+}
+
+export interface ImportResult {
+  imported: number;
+  /** Entries whose UID was imported before */
+  duplicates: number;
+  skipped: Array<{
+    uid?: string;
+    summary?: string;
+    reason: string;
+  }>;
+  tasks: Task[];
+}
+
+export interface Preferences {
+  theme: "light" | "dark";
+  /** Order of the task list page when it asks for none */
+  sort: "created" | "due" | "priority" | "title";
+  /** Tasks per page of the task list page; 0 for TTM_LIST_LIMIT, and at most TTM_MAX_LIST_LIMIT */
+  pageSize: number;
+}
+
+export interface NotificationPreferences {
+  /** all sends notifications through the channels and the daily digest, digest-only the digest only and mute nothing */
+  mode: "all" | "digest-only" | "mute";
+  /** Channels notifications about the tasks of the user go through */
+  channels: Array<"email" | "webhook" | "push">;
+  /** URL the webhook channel posts notifications to as JSON */
+  webhookUrl?: string;
+}
+
+/** The result of PushSubscription.toJSON() in the browser */
+export interface PushSubscription {
+  endpoint: string;
+  expirationTime?: number | null;
+  keys: {
+    p256dh: string;
+    auth: string;
+  };
+}
+
+export interface Lock {
+  taskId: string;
+  /** Lock-Token of anonymous clients */
+  token?: string;
+  /** Name of the user holding the lock */
+  holder?: string;
+  expiresAt: string;
+}
+
+export interface Webhook {
+  id: string;
+  /** Host and path of the URL, without its query */
+  name: string;
+}
+
+/** The delivery of a task event to a webhook that failed at least once */
+export interface Delivery {
+  /** Sequence number of the event */
+  seq: number;
+  /** retrying until nextAttemptAt, dead once out of attempts, delivered after a redelivery */
+  status: "retrying" | "dead" | "delivered";
+  attempts: number;
+  lastError?: string;
+  lastAttemptAt: string;
+  nextAttemptAt?: string;
+  event: TaskEvent;
+}
+
+export interface TaskEvent {
+  seq: number;
+  type: "task.created" | "task.updated" | "task.completed" | "task.reopened" | "task.deleted";
+  task: Task;
+  at: string;
+}
+
+export interface Message {
+  message: string;
+}
+
+export interface Error {
+  /** Message for people, in the language of the Accept-Language header (en, the default, or nl), as is the Content-Language header. */
+  error: string;
+  /** Stable, machine-readable kind of the error, such as TASK_NOT_FOUND. Besides those listed per response, any operation may answer 503 STORE_UNAVAILABLE, 504 STORE_TIMEOUT or 500 INTERNAL_SERVER_ERROR, and operations changing anything 403 READ_ONLY when TTM_READ_ONLY is set or 503 MAINTENANCE, with Retry-After, in maintenance mode. */
+  code: string;
+  requestId?: string;
+  usage?: UserUsage;
+  /** The field errors of a request body not matching its schema */
+  fields?: FieldError[];
+}
+
+export interface FieldError {
+  /** JSON Pointer to the value, such as /title; empty for the body itself */
+  field: string;
+  message: string;
+}
+
+export interface SchemaLink {
+  name: string;
+  url: string;
+}
+
+/** An error response of the API. */
+export class ApiError extends globalThis.Error {
+  constructor(
+    readonly status: number,
+    /** Stable, machine-readable kind of the error, such as TASK_NOT_FOUND */
+    readonly code: string,
+    message: string,
+  ) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
+export interface ClientOptions {
+  /** API key, sent as bearer token; needed when TTM_AUTH_REQUIRED is set */
+  apiKey?: string;
+  /** Workspace the requests are for, sent as X-Workspace; the default workspace when unset */
+  workspace?: string;
+  /** Replaces the global fetch, such as in tests */
+  fetch?: typeof fetch;
+}
+
+interface RequestOptions {
+  query?: Record<string, string | number | boolean | undefined>;
+  headers?: Record<string, string | undefined>;
+  json?: unknown;
+  body?: string | FormData;
+  contentType?: string;
+}
+
+/** Client of the API at baseUrl, such as "https://tasks.example.com". */
+export class Client {
+  constructor(
+    private readonly baseUrl = "",
+    private readonly options: ClientOptions = {},
+  ) {}
+
+  private async request(method: string, path: string, init: RequestOptions = {}): Promise<Response> {
+    const query = new URLSearchParams();
+    for (const [name, value] of Object.entries(init.query ?? {})) {
+      if (value !== undefined) query.set(name, String(value));
+    }
+    const headers = new Headers();
+    for (const [name, value] of Object.entries(init.headers ?? {})) {
+      if (value !== undefined) headers.set(name, value);
+    }
+    if (this.options.apiKey) headers.set("Authorization", `Bearer ${this.options.apiKey}`);
+    if (this.options.workspace) headers.set("X-Workspace", this.options.workspace);
+    let body = init.body;
+    if (init.json !== undefined) {
+      body = JSON.stringify(init.json);
+      headers.set("Content-Type", "application/json");
+    } else if (typeof body === "string" && init.contentType) {
+      headers.set("Content-Type", init.contentType);
+    }
+
+    const search = query.toString();
+    const url = this.baseUrl + path + (search ? "?" + search : "");
+    const response = await (this.options.fetch ?? fetch)(url, { method, headers, body });
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({}));
+      throw new ApiError(response.status, error.code ?? "", error.error ?? response.statusText);
+    }
+    return response;
+  }
+
+  /** List tasks, one page at a time */
+  async listTasks(query: {
+    priority?: PriorityInput;
+    status?: "open" | "completed";
+    /** Inclusive lower bound of the due date; tasks without one are left out */
+    dueAfter?: string;
+    /** Exclusive upper bound of the due date; tasks without one are left out */
+    dueBefore?: string;
+    /** Exclusive upper bound of the creation time */
+    createdBefore?: string;
+    /** Only the open tasks not changed for TTM_STALE_AFTER_DAYS days */
+    stale?: "true";
+    /** Order of the list: created (oldest first, the default), due (soonest first, tasks without due date last), priority (🔥, ⭐, ⚡, 💡, 📋) or title. Tasks that compare equal are listed in creation order. */
+    sort?: "created" | "due" | "priority" | "title";
+    /** Tasks per page, defaulting to TTM_LIST_LIMIT and at most TTM_MAX_LIST_LIMIT */
+    limit?: number;
+    offset?: number;
+  } = {}): Promise<ListedTask[]> {
+    const response = await this.request("GET", `/api/tasks`, { query });
+    return response.json();
+  }
+
+  /** Create a task */
+  async createTask(body: NewTask): Promise<Task> {
+    const response = await this.request("POST", `/api/tasks`, { json: body });
+    return response.json();
+  }
+
+  /**
+   * Set the priority or color of all tasks matching a filter at once
+   *
+   * The filters are those of listTasks; for example priority=🔥 with createdBefore 30 days ago demotes the old 🔥 tasks. Either all matching tasks change or, when one is locked by another client, none.
+   */
+  async reprioritizeTasks(body: {
+    priority?: PriorityInput;
+    color?: ColorInput;
+  }, query: {
+    priority?: PriorityInput;
+    status?: "open" | "completed";
+    dueAfter?: string;
+    dueBefore?: string;
+    createdBefore?: string;
+    stale?: "true";
+  } = {}): Promise<{
+    updated: number;
+    tasks: Task[];
+  }> {
+    const response = await this.request("POST", `/api/tasks/reprioritize`, { json: body, query });
+    return response.json();
+  }
+
+  /**
+   * Download the matching tasks as file
+   *
+   * Takes the filters and order of listTasks, but is not paged. Tasks are exported to iCalendar under the UIDs the CalDAV calendar serves them with, so importing the file again counts them as duplicates. NDJSON is streamed while the tasks are read, so large exports start at once; a failure halfway aborts the response rather than ending it early.
+   *
+   * Resolves to the response itself, as it is not always JSON.
+   */
+  async exportTasks(query: {
+    format?: "json" | "csv" | "ndjson" | "ics";
+    priority?: PriorityInput;
+    status?: "open" | "completed";
+    dueAfter?: string;
+    dueBefore?: string;
+    sort?: "created" | "due" | "priority" | "title";
+  } = {}): Promise<Response> {
+    return this.request("GET", `/api/tasks/export`, { query });
+  }
+
+  /**
+   * Merge a duplicate task into this one
+   *
+   * The task gets the more urgent priority and the earlier due date of both, and the source task is completed, so it stays linked to the UID it was imported with and importing it again counts as a duplicate. The priority history of the source is added to that of the task. Tasks have no descriptions, tags or comments to combine.
+   */
+  async mergeTask(id: string, body: {
+    /** ID of the task merged into this one */
+    source: string;
+  }, headers: {
+    /** Token of the lock an anonymous client holds on the task, as returned by lockTask */
+    "Lock-Token"?: string;
+  } = {}): Promise<{
+    target: Task;
+    source: Task;
+  }> {
+    const response = await this.request("POST", `/api/tasks/${encodeURIComponent(id)}/merge`, { json: body, headers });
+    return response.json();
+  }
+
+  /**
+   * Change only the priority of a task
+   *
+   * The change is recorded in the history of the task and reported as a task.priority_changed change, so priority churn can be told apart from other edits. Event webhooks and NATS get it as task.updated. Setting the priority the task already has changes nothing.
+   */
+  async changePriority(id: string, body: {
+    /** A priority emoticon, or urgent, high, low or p1 to p5 */
+    priority: string;
+  }, headers: {
+    /** Token of the lock an anonymous client holds on the task, as returned by lockTask */
+    "Lock-Token"?: string;
+  } = {}): Promise<Task> {
+    const response = await this.request("POST", `/api/tasks/${encodeURIComponent(id)}/priority`, { json: body, headers });
+    return response.json();
+  }
+
+  /**
+   * List the priority changes of a task
+   *
+   * The latest 100 changes made with POST /api/tasks/{id}/priority and by escalations, oldest first. The history is kept in memory per instance, so it starts empty after a restart.
+   */
+  async getTaskHistory(id: string): Promise<{
+    taskId: string;
+    transitions: Transition[];
+  }> {
+    const response = await this.request("GET", `/api/tasks/${encodeURIComponent(id)}/history`);
+    return response.json();
+  }
+
+  /**
+   * Lock a task while editing it, or renew the lock
+   *
+   * Other clients changing the task are answered 423 until the lock is released or expires, pages and CalDAV clients included. Authenticated clients hold the lock as their user; anonymous clients get a token to send in the Lock-Token header. Locks are kept per instance.
+   */
+  async lockTask(id: string, body?: {
+    /** Seconds the lock lasts, by default 300 */
+    ttl?: number;
+  }, headers: {
+    /** Token of the lock an anonymous client holds on the task, as returned by lockTask */
+    "Lock-Token"?: string;
+  } = {}): Promise<Lock> {
+    const response = await this.request("POST", `/api/tasks/${encodeURIComponent(id)}/lock`, { json: body, headers });
+    return response.json();
+  }
+
+  /** Release the lock of a task */
+  async unlockTask(id: string, headers: {
+    /** Token of the lock an anonymous client holds on the task, as returned by lockTask */
+    "Lock-Token"?: string;
+  } = {}): Promise<Message> {
+    const response = await this.request("DELETE", `/api/tasks/${encodeURIComponent(id)}/lock`, { headers });
+    return response.json();
+  }
+
+  /** Complete an open task or reopen a completed one */
+  async toggleTask(id: string, headers: {
+    /** Token of the lock an anonymous client holds on the task, as returned by lockTask */
+    "Lock-Token"?: string;
+  } = {}): Promise<Task> {
+    const response = await this.request("PATCH", `/api/tasks/${encodeURIComponent(id)}/toggle`, { headers });
+    return response.json();
+  }
+
+  /** Delete a task */
+  async deleteTask(id: string, headers: {
+    /** Token of the lock an anonymous client holds on the task, as returned by lockTask */
+    "Lock-Token"?: string;
+  } = {}): Promise<Message> {
+    const response = await this.request("DELETE", `/api/tasks/${encodeURIComponent(id)}`, { headers });
+    return response.json();
+  }
+
+  /**
+   * Long-poll for changes to tasks, for clients that cannot use push
+   *
+   * Returns the changes made after the since cursor, waiting up to wait for one when there are none yet. Without since it returns the cursor of the latest change at once: take it before loading the tasks, then poll with the cursor of every response. The changes are kept in memory per instance, so instances sharing a store each see only their own; reset tells clients to reload the tasks.
+   */
+  async getChanges(query: {
+    /** Cursor of an earlier response */
+    since?: string;
+    /** How long to wait for a change, as a Go duration; 30s by default, 60s at most */
+    wait?: string;
+  } = {}): Promise<ChangeSet> {
+    const response = await this.request("GET", `/api/changes`, { query });
+    return response.json();
+  }
+
+  /**
+   * List the latest changes made to tasks, newest first
+   *
+   * Returns the latest changes made to the tasks of the workspace, newest first, for recent activity widgets: tasks created, updated, reassigned to another priority, completed, reopened and deleted. Page back with the next of a response as before while more is set. Like the changes of GET /api/changes, the latest 1000 are kept in memory per instance.
+   */
+  async getActivity(query: {
+    /** The next of an earlier page; the latest changes when left out */
+    before?: number;
+    /** Most changes to return; 50 by default, 200 at most */
+    limit?: number;
+  } = {}): Promise<ActivityFeed> {
+    const response = await this.request("GET", `/api/activity`, { query });
+    return response.json();
+  }
+
+  /**
+   * List the tasks deleted since a time
+   *
+   * Returns the tombstones of the tasks deleted at or after since, so clients syncing tasks, offline or through webhooks, learn about deletions. Poll with the until of every response as the next since. Tombstones are kept for TTM_TOMBSTONE_RETENTION (30 days by default); asking for deletions before that is answered 410, and clients reload the tasks.
+   */
+  async getTombstones(query: {
+    /** RFC 3339 time, such as the until of an earlier response */
+    since: string;
+  } = {}): Promise<{
+    tombstones: Tombstone[];
+    /** The since of the next request */
+    until: string;
+  }> {
+    const response = await this.request("GET", `/api/tombstones`, { query });
+    return response.json();
+  }
+
+  /** Task activity since startup */
+  async getStats(): Promise<Stats> {
+    const response = await this.request("GET", `/api/stats`);
+    return response.json();
+  }
+
+  /** What tasks are validated against, for clients checking input before sending it */
+  async getMeta(): Promise<Meta> {
+    const response = await this.request("GET", `/api/meta`);
+    return response.json();
+  }
+
+  /** The plan of the workspace and its use of the request rate and task quota */
+  async getUsage(): Promise<Usage> {
+    const response = await this.request("GET", `/api/usage`);
+    return response.json();
+  }
+
+  /** The JSON Schemas request bodies are validated against, for client tooling */
+  async listSchemas(): Promise<{
+    schemas: SchemaLink[];
+  }> {
+    const response = await this.request("GET", `/api/schemas`);
+    return response.json();
+  }
+
+  /**
+   * A JSON Schema (draft 2020-12) of a request body
+   *
+   * Resolves to the response itself, as it is not always JSON.
+   */
+  async getSchema(name: string): Promise<Response> {
+    return this.request("GET", `/api/schemas/${encodeURIComponent(name)}`);
+  }
+
+  /** Import the VTODOs and VEVENTs of an iCalendar file */
+  async importICS(body: FormData | string, query: {
+    /** IANA time zone of floating times and all-day dates, defaulting to TTM_CALDAV_TIMEZONE */
+    timezone?: string;
+  } = {}): Promise<ImportResult> {
+    const response = await this.request("POST", `/api/import/ics`, { body, contentType: "text/calendar", query });
+    return response.json();
+  }
+
+  /**
+   * The former path of exportTasks, taking the same parameters
+   *
+   * Resolves to the response itself, as it is not always JSON.
+   *
+   * @deprecated
+   */
+  async exportTasksLegacy(query: {
+    format?: "json" | "csv" | "ndjson" | "ics";
+  } = {}): Promise<Response> {
+    return this.request("GET", `/api/export`, { query });
+  }
+
+  /**
+   * Preferences of the HTML UI, with the defaults of choices not made
+   *
+   * The preferences of the authenticated user or, without one, those kept in the ttm_prefs cookie of the browser.
+   */
+  async getPreferences(): Promise<Preferences> {
+    const response = await this.request("GET", `/api/preferences`);
+    return response.json();
+  }
+
+  /**
+   * Replace the preferences of the HTML UI
+   *
+   * Stored for the authenticated user, so they apply on every device, or without one in the ttm_prefs cookie of the browser. Choices left out are reset to their defaults.
+   */
+  async updatePreferences(body: Preferences): Promise<Preferences> {
+    const response = await this.request("PUT", `/api/preferences`, { json: body });
+    return response.json();
+  }
+
+  /**
+   * How the authenticated user hears about the tasks they created
+   *
+   * Users are told about the due dates, escalations and completion of the tasks they created through their channels, and get the daily digest unless they mute notifications. Requires an authenticated user.
+   */
+  async getNotificationPreferences(): Promise<NotificationPreferences> {
+    const response = await this.request("GET", `/api/users/me/preferences`);
+    return response.json();
+  }
+
+  /**
+   * Replace the notification preferences of the authenticated user
+   *
+   * Choices left out are reset to their defaults. The email channel needs the user to have an email address, and the webhook channel a webhookUrl.
+   */
+  async updateNotificationPreferences(body: NotificationPreferences): Promise<NotificationPreferences> {
+    const response = await this.request("PUT", `/api/users/me/preferences`, { json: body });
+    return response.json();
+  }
+
+  /** VAPID public key to subscribe with (only when web push is enabled) */
+  async getPushKey(): Promise<{
+    publicKey: string;
+  }> {
+    const response = await this.request("GET", `/api/push/key`);
+    return response.json();
+  }
+
+  /** Store a browser's push subscription, replacing one with the same endpoint */
+  async subscribePush(body: PushSubscription): Promise<Message> {
+    const response = await this.request("POST", `/api/push/subscriptions`, { json: body });
+    return response.json();
+  }
+
+  /** Remove a push subscription */
+  async unsubscribePush(body: {
+    endpoint: string;
+  }): Promise<Message> {
+    const response = await this.request("DELETE", `/api/push/subscriptions`, { json: body });
+    return response.json();
+  }
+
+  /** The webhooks task events are delivered to (TTM_EVENT_WEBHOOK_URLS) */
+  async listWebhooks(): Promise<{
+    webhooks: Webhook[];
+  }> {
+    const response = await this.request("GET", `/api/webhooks`);
+    return response.json();
+  }
+
+  /** The failed deliveries to a webhook, retrying or dead-lettered */
+  async listWebhookDeliveries(id: string, query: {
+    /** Only the deliveries with this status */
+    status?: "retrying" | "dead";
+  } = {}): Promise<{
+    webhook: Webhook;
+    deliveries: Delivery[];
+  }> {
+    const response = await this.request("GET", `/api/webhooks/${encodeURIComponent(id)}/deliveries`, { query });
+    return response.json();
+  }
+
+  /** Deliver a retrying or dead-lettered event to the webhook right away */
+  async redeliverWebhookDelivery(id: string, seq: string): Promise<Delivery> {
+    const response = await this.request("POST", `/api/webhooks/${encodeURIComponent(id)}/deliveries/${encodeURIComponent(seq)}/redeliver`);
+    return response.json();
+  }
+
+  /**
+   * The task events after a sequence number, to catch up on missed webhooks or NATS messages
+   *
+   * Only available while task events are relayed and kept (TTM_EVENT_HISTORY_SIZE). Events are returned by sequence number, oldest first; pass next as from to get the following page while more is true.
+   */
+  async replayEvents(query: {
+    /** Sequence number of the last event received, or 0 for all kept events */
+    from: number;
+    limit?: number;
+  } = {}): Promise<{
+    events: TaskEvent[];
+    /** The from of the next page: the sequence number of the last event, or from without events */
+    next: number;
+    more: boolean;
+  }> {
+    const response = await this.request("GET", `/api/events/replay`, { query });
+    return response.json();
+  }
+
+  /** Add sample tasks (dev environment only) */
+  async seedTasks(query: {
+    count?: number;
+  } = {}): Promise<{
+    created: number;
+    skipped: number;
+  }> {
+    const response = await this.request("POST", `/api/dev/seed`, { query });
+    return response.json();
+  }
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/tsclient"
+)
+
+// TestClient fails when client.ts is not what the OpenAPI document
+// generates, so changes to the API cannot leave the client behind.
+func TestClient(t *testing.T) {
+	spec, err := os.ReadFile("openapi.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := tsclient.Generate(spec)
+	if err != nil {
+		t.Fatalf("expected the document to generate a client, got %v", err)
+	}
+	got, err := os.ReadFile("client.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("client.ts is out of date with openapi.yaml; run go generate ./api")
+	}
+}
@@ -0,0 +1,6 @@
+// Package api holds the OpenAPI document of the API, api/openapi.yaml, and
+// the typed TypeScript client generated from it, api/client.ts. TestClient
+// fails when the client is out of date with the document.
+package api
+
+//go:generate go run ../cmd/tsclient -o client.ts openapi.yaml
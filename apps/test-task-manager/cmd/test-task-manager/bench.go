@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/bench"
+)
+
+var (
+	benchURL         string
+	benchToken       string
+	benchDuration    time.Duration
+	benchConcurrency int
+	benchMix         string
+)
+
+var benchCommand = &command{
+	name:    "bench",
+	summary: "Send a mix of API requests to a running server and report latencies",
+	flags: func(fs *flag.FlagSet) {
+		token, _ := app.LookupEnv("API_TOKEN")
+		fs.StringVar(&benchURL, "api-url", "", "Base URL of the server, e.g. http://localhost:8080 (derived from the listen address when empty)")
+		fs.StringVar(&benchToken, "token", token, "API key to authenticate with (TTM_API_TOKEN)")
+		fs.DurationVar(&benchDuration, "duration", 10*time.Second, "How long to send requests")
+		fs.IntVar(&benchConcurrency, "concurrency", 8, "Number of concurrent clients")
+		fs.StringVar(&benchMix, "mix", "create=1,list=4,toggle=2,delete=1", "Relative weight of each operation")
+	},
+	run: runBench,
+}
+
+func runBench(inv invocation) error {
+	mix, err := bench.ParseMix(benchMix)
+	if err != nil {
+		return err
+	}
+	if benchConcurrency < 1 || benchDuration <= 0 {
+		return fmt.Errorf("-concurrency and -duration must be positive")
+	}
+
+	baseURL := benchURL
+	if baseURL == "" {
+		if baseURL, err = publicURL(inv.config); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "sending requests to %s for %s with %d clients\n", baseURL, benchDuration, benchConcurrency)
+	result, err := bench.Run(context.Background(), bench.Config{
+		BaseURL:     baseURL,
+		Token:       benchToken,
+		Duration:    benchDuration,
+		Concurrency: benchConcurrency,
+		Mix:         mix,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "OPERATION\tREQUESTS\tERRORS\tP50\tP90\tP99\tMAX\t")
+	for _, s := range result.Stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n", s.Operation, s.Requests, s.Errors,
+			s.P50.Round(time.Microsecond), s.P90.Round(time.Microsecond), s.P99.Round(time.Microsecond), s.Max.Round(time.Microsecond))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%.0f requests/s over %s\n", result.Throughput(), result.Elapsed.Round(time.Millisecond))
+	return nil
+}
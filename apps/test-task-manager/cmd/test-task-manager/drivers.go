@@ -0,0 +1,8 @@
+package main
+
+// The database/sql drivers of the sqlite and postgres stores, registered
+// under the backend names the store opens them by.
+import (
+	_ "github.com/lib/pq"  // Registers "postgres"
+	_ "modernc.org/sqlite" // Registers "sqlite", without cgo
+)
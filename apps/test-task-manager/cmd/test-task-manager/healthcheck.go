@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+)
+
+var healthcheckTimeout time.Duration
+
+var healthcheckCommand = &command{
+	name:    "healthcheck",
+	summary: "Exit with status 0 when the running server is ready, 1 otherwise",
+	flags: func(fs *flag.FlagSet) {
+		fs.DurationVar(&healthcheckTimeout, "timeout", 5*time.Second, "Maximum time to wait for the server")
+	},
+	run: healthcheck,
+}
+
+// healthcheck requests /health/ready from the server configured by the same
+// configuration, on the admin listener when there is one. It needs nothing
+// but the binary, so it works as a container HEALTHCHECK or exec probe.
+func healthcheck(inv invocation) error {
+	network, addr, err := healthAddress(inv.config)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout: healthcheckTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		},
+	}
+
+	// The host is only used for the Host header; the transport dials addr.
+	resp, err := client.Get("http://localhost/health/ready")
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// healthAddress returns where the health endpoint is served. Wildcard
+// TCP addresses are dialed on the loopback interface.
+func healthAddress(c app.Configuration) (network, addr string, err error) {
+	if c.AdminListen != "" {
+		network, addr, err = app.ParseListen(c.AdminListen)
+		if err != nil {
+			return "", "", err
+		}
+	} else {
+		network, addr = c.ListenAddress()
+	}
+
+	if network == "tcp" {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return "", "", err
+		}
+		if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+			addr = net.JoinHostPort("localhost", port)
+		}
+	}
+	return network, addr, nil
+}
@@ -3,70 +3,298 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
-	"gitlab.com/btcdirect-api/test-task-manager/internal/http/server"
 )
 
-func main() {
-	c := app.Configuration{}
+// command is a subcommand of the binary. Every command accepts the
+// configuration flags, plus its own flags defined by flags.
+type command struct {
+	name    string
+	summary string
+	flags   func(fs *flag.FlagSet)
+	run     func(inv invocation) error
+}
 
-	var env string
-	flag.StringVar(&env, "env", getenv("APP_ENV", "dev"), "Environment")
+// invocation is the configuration a command runs with.
+type invocation struct {
+	config     app.Configuration
+	configFile string // empty when no configuration file is used
 
-	var err error
-	c.Environment, err = getEnvironment(env)
-	if err != nil {
-		panic(err)
-	}
+	// reload rebuilds the configuration from the same sources.
+	reload func() (app.Configuration, error)
+}
 
-	flag.StringVar(&c.LogLevel, "loglevel", getenv("LOG_LEVEL", "info"), "Log output level")
-	flag.StringVar(&c.HTTPPort, "port", getenv("HTTP_PORT", "8080"), "HTTP port")
+// commands lists the subcommands in the order they are shown in the usage.
+var commands = []*command{
+	serveCommand,
+	migrateCommand,
+	exportCommand,
+	importCommand,
+	seedCommand,
+	reportCommand,
+	healthcheckCommand,
+	tuiCommand,
+	benchCommand,
+	storebenchCommand,
+	usersCreateCommand,
+	usersDisableCommand,
+	usersUpdateCommand,
+	usersListCommand,
+	keysIssueCommand,
+	keysRevokeCommand,
+	keysListCommand,
+	sessionsListCommand,
+	vapidKeysCommand,
+}
 
-	flag.Parse()
+func main() {
+	name, args := commandName(os.Args[1:])
+	if name == "help" {
+		usage(os.Stdout)
+		return
+	}
 
-	application := app.Initialize(c)
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
 
-	run(application)
+	c, configFile, err := loadConfig(cmd, args)
+	if err == nil {
+		err = cmd.run(invocation{
+			config:     c,
+			configFile: configFile,
+			reload: func() (app.Configuration, error) {
+				next, _, err := loadConfig(cmd, args)
+				return next, err
+			},
+		})
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
-// Run the application daemon.
-func run(application *app.App) {
-	application.Logger().Info("Starting application")
+// commandName splits args into the name of the command and its flags.
+func commandName(args []string) (string, []string) {
+	// Without a subcommand the binary serves, as it always has.
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	// Grouped commands, such as "users create", take two words.
+	if findCommand(name) == nil && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if findCommand(name+" "+args[0]) != nil {
+			name, args = name+" "+args[0], args[1:]
+		}
+	}
+	return name, args
+}
 
-	server := server.Start(application)
-	application.Run()
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [command] [flags]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nThe command defaults to serve. Run \"%s <command> -h\" for its flags.\n", os.Args[0])
+}
 
-	application.Logger().Info("Shutting down application")
+// loadConfig builds the configuration for cmd from args. Precedence: flags, then
+// TTM_ environment variables, then the environment's profile in the
+// configuration file, then the file's base keys, then the built-in defaults
+// of the environment. It returns the path of the configuration file, if any.
+func loadConfig(cmd *command, args []string) (app.Configuration, string, error) {
+	// The environment selects the profiles, so it is resolved first.
+	c, _, err := buildConfig(cmd, args, app.Dev, "")
+	if err != nil {
+		return c, "", err
+	}
 
-	application.Shutdown()
-	server.Shutdown()
+	// Local development variables, never read outside dev.
+	if c.Environment == app.Dev {
+		if err := app.LoadDotEnv(app.DotEnvFile); err != nil {
+			return c, "", err
+		}
+	}
 
-	os.Exit(0)
+	return buildConfig(cmd, args, c.Environment, c.Environment)
 }
 
-func getenv(key string, fallback string) string {
-	value := os.Getenv(key)
-	if len(value) == 0 {
-		return fallback
+// buildConfig layers the configuration sources on top of the defaults of
+// defaultsEnv, applying the file profile of profileEnv (none when empty).
+func buildConfig(cmd *command, args []string, defaultsEnv, profileEnv app.Environment) (app.Configuration, string, error) {
+	c := app.DefaultConfiguration(defaultsEnv)
+	configFileEnv, _ := app.LookupEnv("CONFIG_FILE")
+	configFile := configFileArg(args, configFileEnv)
+	if configFile != "" {
+		if err := app.LoadConfigFile(configFile, profileEnv, &c); err != nil {
+			return c, "", err
+		}
 	}
-	return value
+	if err := app.LoadEnv(&c); err != nil {
+		return c, "", err
+	}
+
+	fs := flag.NewFlagSet(os.Args[0]+" "+cmd.name, flag.ExitOnError)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.String("config", configFile, "Path to a YAML configuration file")
+	var env string
+	fs.StringVar(&env, "env", string(c.Environment), "Environment")
+	fs.StringVar(&c.LogLevel, "loglevel", c.LogLevel, "Log output level")
+	fs.StringVar(&c.LogFormat, "log-format", c.LogFormat, "Log encoding: console or json")
+	fs.StringVar(&c.HTTPPort, "port", c.HTTPPort, "HTTP port")
+	fs.StringVar(&c.Listen, "listen", c.Listen, "Listen address as tcp:<host:port> or unix:<path> (overrides -port)")
+	fs.StringVar(&c.AdminListen, "admin-listen", c.AdminListen, "Separate listen address for health, metrics, admin and debug endpoints, e.g. tcp::9090 (public listener when empty)")
+	fs.DurationVar(&c.HTTPReadTimeout, "http-read-timeout", c.HTTPReadTimeout, "Maximum duration for reading an entire request, including the body")
+	fs.DurationVar(&c.HTTPReadHeaderTimeout, "http-read-header-timeout", c.HTTPReadHeaderTimeout, "Maximum duration for reading request headers")
+	fs.DurationVar(&c.HTTPWriteTimeout, "http-write-timeout", c.HTTPWriteTimeout, "Maximum duration before timing out writes of the response")
+	fs.DurationVar(&c.HTTPIdleTimeout, "http-idle-timeout", c.HTTPIdleTimeout, "Maximum time to wait for the next request on a keep-alive connection")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "How long in-flight requests may take to finish on shutdown (0 stops immediately)")
+	fs.StringVar(&c.Store, "store", c.Store, "Task store backend: memory, file, sqlite, postgres or redis")
+	fs.StringVar(&c.StoreDSN, "store-dsn", c.StoreDSN, "Task store connection string: file path for file and sqlite, URL for postgres and redis")
+	workspaces := fs.String("workspaces", strings.Join(c.Workspaces, ","), "Comma-separated workspaces served besides the default one, under /w/{workspace}/api or with the X-Workspace header")
+	plans := fs.String("plans", strings.Join(c.Plans, ","), "Comma-separated plans limiting workspaces, as <name>=<requests per second>/<burst>/<max tasks> (0 is unlimited), e.g. free=5/20/100")
+	workspacePlans := fs.String("workspace-plans", strings.Join(c.WorkspacePlans, ","), "Comma-separated plans of workspaces, as <workspace>=<plan>; workspaces without one are unlimited")
+	fs.StringVar(&c.IDStrategy, "id-strategy", c.IDStrategy, "How the memory and file stores assign task IDs: sequential, uuid or ulid")
+	fs.IntVar(&c.MemorySoftLimitMB, "memory-soft-limit", c.MemorySoftLimitMB, "Approximate task memory in MiB above which the memory store logs warnings (0 disables)")
+	fs.IntVar(&c.MemoryHardLimitMB, "memory-hard-limit", c.MemoryHardLimitMB, "Approximate task memory in MiB above which the memory store refuses new tasks (0 disables)")
+	fs.DurationVar(&c.TombstoneRetention, "tombstone-retention", c.TombstoneRetention, "How long stores keep the tombstones of deleted tasks, for clients syncing tasks (0 keeps none)")
+	fs.BoolVar(&c.StoreFailover, "store-failover", c.StoreFailover, "Serve reads from the last known tasks, and reject changes, while the store backend is unreachable")
+	fs.IntVar(&c.DBMaxOpenConns, "db-max-open-conns", c.DBMaxOpenConns, "Maximum open connections of the sqlite and postgres stores (0 means unlimited)")
+	fs.IntVar(&c.DBMaxIdleConns, "db-max-idle-conns", c.DBMaxIdleConns, "Maximum idle connections of the sqlite and postgres stores")
+	fs.DurationVar(&c.DBConnMaxLifetime, "db-conn-max-lifetime", c.DBConnMaxLifetime, "How long a database connection may be reused (0 means forever)")
+	fs.DurationVar(&c.DBConnMaxIdleTime, "db-conn-max-idle-time", c.DBConnMaxIdleTime, "How long a database connection may stay idle (0 means forever)")
+	fs.DurationVar(&c.ResponseCacheTTL, "response-cache-ttl", c.ResponseCacheTTL, "How long serialized task lists and the task list page are cached; local changes invalidate them immediately (0 disables)")
+	fs.IntVar(&c.ListLimit, "list-limit", c.ListLimit, "Default number of tasks returned by GET /api/tasks (0 lists all)")
+	fs.IntVar(&c.MaxListLimit, "max-list-limit", c.MaxListLimit, "Largest number of tasks a client may ask for with the limit parameter (0 means no cap)")
+	fs.IntVar(&c.TitleMinLength, "title-min-length", c.TitleMinLength, "Shortest task title, in characters")
+	fs.IntVar(&c.TitleMaxLength, "title-max-length", c.TitleMaxLength, "Longest task title, in characters")
+	colors := fs.String("palette", strings.Join(c.Palette, ","), "Comma-separated task colors as name=#light/#dark, e.g. red=#dc3545/#ea868f (default palette when empty)")
+	fs.StringVar(&c.DefaultPriority, "default-priority", c.DefaultPriority, "Priority (emoticon or alias) of tasks created without one (📋 when empty)")
+	fs.StringVar(&c.DefaultColor, "default-color", c.DefaultColor, "Color (name or hex code of the palette) of tasks created without one (grey, or the first color of the palette, when empty)")
+	fs.DurationVar(&c.DefaultDueIn, "default-due-in", c.DefaultDueIn, "How long after their creation tasks created without a due date are due (0 for none)")
+	fs.BoolVar(&c.UniqueTitles, "unique-titles", c.UniqueTitles, "Reject new tasks with the title of an open task, ignoring case")
+	wipLimits := fs.String("wip-limits", strings.Join(c.WIPLimits, ","), "Comma-separated limits on the open tasks, e.g. 🔥=5,open=20")
+	fs.StringVar(&c.WIPMode, "wip-mode", c.WIPMode, "What happens to changes going over a WIP limit: reject or warn")
+	fs.IntVar(&c.StaleAfterDays, "stale-after-days", c.StaleAfterDays, "Days an open task may go unchanged before it counts as stale")
+	fs.StringVar(&c.SentryDSN, "sentry-dsn", c.SentryDSN, "Sentry DSN for error reporting (disabled when empty)")
+	fs.StringVar(&c.AdminToken, "admin-token", c.AdminToken, "Bearer token required for /admin endpoints (disabled when empty, required outside dev and with -auth-required)")
+	fs.StringVar(&c.AuthFile, "auth-file", c.AuthFile, "JSON file holding users, API keys and sessions (in memory when empty)")
+	fs.BoolVar(&c.AuthRequired, "auth-required", c.AuthRequired, "Reject API requests without an API key or session token, and send pages without a signed-in user to the login page")
+	fs.DurationVar(&c.SessionTTL, "session-ttl", c.SessionTTL, "How long sessions signed in on the login page last")
+	fs.BoolVar(&c.CalDAVEnabled, "caldav", c.CalDAVEnabled, "Serve the tasks as a CalDAV calendar under /caldav/ (sign in with a user name and API key)")
+	fs.StringVar(&c.CalDAVLinkFile, "caldav-link-file", c.CalDAVLinkFile, "JSON file keeping the names CalDAV clients gave to their tasks (in memory when empty)")
+	fs.StringVar(&c.CalDAVTimezone, "caldav-timezone", c.CalDAVTimezone, "IANA time zone of CalDAV dates without one (system time zone when empty)")
+	fs.Float64Var(&c.RateLimit, "rate-limit", c.RateLimit, "Per-client API requests per second (0 disables)")
+	fs.IntVar(&c.RateBurst, "rate-burst", c.RateBurst, "Per-client API burst size")
+	fs.IntVar(&c.MaxConcurrentRequests, "max-concurrent-requests", c.MaxConcurrentRequests, "Maximum page and API requests handled concurrently before shedding load (0 disables)")
+	trustedProxies := fs.String("trusted-proxies", strings.Join(c.TrustedProxies, ","), "Comma-separated proxy CIDRs or IPs whose X-Forwarded-For/X-Real-IP headers are trusted")
+	corsOrigins := fs.String("cors-origins", strings.Join(c.CORSAllowedOrigins, ","), "Comma-separated origins allowed to call the API (* for any)")
+	fs.IntVar(&c.CompressionMinSize, "compress-min-size", c.CompressionMinSize, "Minimum response size in bytes for gzip compression (0 disables)")
+	fs.DurationVar(&c.SlowRequestThreshold, "slow-request-threshold", c.SlowRequestThreshold, "Log requests slower than this with a timing breakdown (0 disables)")
+	fs.StringVar(&c.SMTPHost, "smtp-host", c.SMTPHost, "SMTP server for email notifications (disabled when empty)")
+	fs.IntVar(&c.SMTPPort, "smtp-port", c.SMTPPort, "SMTP server port")
+	fs.StringVar(&c.SMTPUsername, "smtp-username", c.SMTPUsername, "SMTP username; set the password with TTM_SMTP_PASSWORD (no authentication when empty)")
+	fs.StringVar(&c.SMTPFrom, "smtp-from", c.SMTPFrom, "Sender address of email notifications")
+	notifyEmailTo := fs.String("notify-email-to", strings.Join(c.NotifyEmailTo, ","), "Comma-separated recipients of email notifications")
+	fs.StringVar(&c.NotifyTemplateDir, "notify-template-dir", c.NotifyTemplateDir, "Directory with <event>.tmpl email templates replacing the built-in ones")
+	fs.DurationVar(&c.NotifyDueSoon, "notify-due-soon", c.NotifyDueSoon, "How long before its due date a task counts as due soon")
+	fs.StringVar(&c.NotifySchedule, "notify-schedule", c.NotifySchedule, "Cron expression of when tasks are checked for notifications, e.g. */5 * * * * or @hourly")
+	fs.StringVar(&c.DigestTime, "digest-time", c.DigestTime, "Local time (15:04) the daily digest is emailed to users (disabled when empty)")
+	fs.StringVar(&c.DigestTimezone, "digest-timezone", c.DigestTimezone, "IANA time zone of the digest time, e.g. Europe/Amsterdam (system time zone when empty)")
+	escalationRules := fs.String("escalation-rules", strings.Join(c.EscalationRules, ","), "Comma-separated rules for overdue tasks, e.g. 24h:⭐>🔥,72h:notify")
+	fs.StringVar(&c.EscalationEmailTo, "escalation-email-to", c.EscalationEmailTo, "Email address notified by escalation rules (the notification recipients when empty)")
+	fs.StringVar(&c.NotifyStateFile, "notify-state-file", c.NotifyStateFile, "JSON file recording sent notifications, so restarts do not repeat them (in memory when empty)")
+	fs.StringVar(&c.VAPIDPublicKey, "vapid-public-key", c.VAPIDPublicKey, "VAPID public key for web push; set the private key with TTM_VAPID_PRIVATE_KEY (see the vapid-keys command)")
+	fs.StringVar(&c.VAPIDSubject, "vapid-subject", c.VAPIDSubject, "Contact for push services as a mailto: or https: URL")
+	fs.StringVar(&c.PushSubscriptionFile, "push-subscriptions-file", c.PushSubscriptionFile, "JSON file keeping web push subscriptions (in memory when empty)")
+	fs.StringVar(&c.NtfyURL, "ntfy-url", c.NtfyURL, "ntfy topic URL notifications are published to, e.g. https://ntfy.sh/team-tasks; set an access token with TTM_NTFY_TOKEN")
+	ntfyEvents := fs.String("ntfy-events", strings.Join(c.NtfyEvents, ","), "Comma-separated events published to ntfy: due_soon, overdue, escalated, created, completed")
+	discordEvents := fs.String("discord-events", strings.Join(c.DiscordEvents, ","), "Comma-separated events posted to Discord (set the webhook with TTM_DISCORD_WEBHOOK_URL): due_soon, overdue, escalated, created, completed")
+	teamsEvents := fs.String("teams-events", strings.Join(c.TeamsEvents, ","), "Comma-separated events posted to Teams (set the webhook with TTM_TEAMS_WEBHOOK_URL): due_soon, overdue, escalated, created, completed")
+	fs.IntVar(&c.JobWorkers, "job-workers", c.JobWorkers, "Workers running background jobs such as notifications")
+	fs.IntVar(&c.JobQueueSize, "job-queue-size", c.JobQueueSize, "Background jobs that may wait before new ones are refused")
+	fs.IntVar(&c.JobMaxAttempts, "job-max-attempts", c.JobMaxAttempts, "Attempts per background job before it is dead-lettered")
+	fs.DurationVar(&c.JobRetryBackoff, "job-retry-backoff", c.JobRetryBackoff, "Delay before retrying a failed background job, doubled for every next attempt")
+	fs.DurationVar(&c.JobMaxBackoff, "job-max-backoff", c.JobMaxBackoff, "Longest delay between attempts of a background job (0 means no cap)")
+	eventWebhookURLs := fs.String("event-webhook-urls", strings.Join(c.EventWebhookURLs, ","), "Comma-separated URLs receiving task events; set the signing secret with TTM_EVENT_WEBHOOK_SECRET")
+	fs.StringVar(&c.EventNATSURL, "event-nats-url", c.EventNATSURL, "NATS server task events are published to, e.g. nats://localhost:4222")
+	fs.StringVar(&c.EventNATSSubject, "event-nats-subject", c.EventNATSSubject, "Subject prefix of task events published to NATS")
+	fs.DurationVar(&c.EventRelayInterval, "event-relay-interval", c.EventRelayInterval, "How often the outbox is checked for task events to publish")
+	fs.IntVar(&c.EventWebhookMaxAttempts, "event-webhook-max-attempts", c.EventWebhookMaxAttempts, "Attempts per webhook delivery before it is dead-lettered")
+	fs.DurationVar(&c.EventWebhookRetryBackoff, "event-webhook-retry-backoff", c.EventWebhookRetryBackoff, "Delay before retrying a failed webhook delivery, doubled for every next attempt")
+	fs.DurationVar(&c.EventWebhookMaxBackoff, "event-webhook-max-backoff", c.EventWebhookMaxBackoff, "Longest delay between attempts of a webhook delivery (0 means no cap)")
+	fs.StringVar(&c.EventWebhookStateFile, "event-webhook-state-file", c.EventWebhookStateFile, "JSON file keeping the retry state and dead letters of webhook deliveries (in memory when empty)")
+	fs.IntVar(&c.EventHistorySize, "event-history-size", c.EventHistorySize, "Relayed task events kept for GET /api/events/replay (0 disables replays)")
+	fs.StringVar(&c.EventHistoryFile, "event-history-file", c.EventHistoryFile, "Newline-delimited JSON file keeping the relayed task events over restarts (in memory when empty)")
+	fs.IntVar(&c.ArchiveAfterDays, "archive-after-days", c.ArchiveAfterDays, "Archive tasks completed more than this many days ago (0 disables archival)")
+	fs.IntVar(&c.ArchiveRetentionDays, "archive-retention-days", c.ArchiveRetentionDays, "Purge archived tasks from the archive file after this many days (0 keeps them)")
+	fs.StringVar(&c.ArchiveSchedule, "archive-schedule", c.ArchiveSchedule, "Cron expression of when completed tasks are archived and archived tasks purged")
+	fs.StringVar(&c.ArchiveFile, "archive-file", c.ArchiveFile, "Newline-delimited JSON file archived tasks are appended to")
+	fs.DurationVar(&c.OutboundTimeout, "outbound-timeout", c.OutboundTimeout, "Timeout for calls to external systems")
+	fs.DurationVar(&c.FaultLatency, "fault-latency", c.FaultLatency, "Artificial latency injected into store calls (non-prod only)")
+	fs.Float64Var(&c.FaultErrorRate, "fault-error-rate", c.FaultErrorRate, "Probability (0-1) of failing store calls (non-prod only)")
+	fs.BoolVar(&c.CaptureRequests, "capture-requests", c.CaptureRequests, "Capture every API request and response for /admin/requests (non-prod only)")
+	fs.StringVar(&c.CaptureSecret, "capture-secret", c.CaptureSecret, "Secret signing the X-TTM-Capture header that has single API requests captured (disabled when empty)")
+	fs.IntVar(&c.CaptureBufferSize, "capture-buffer-size", c.CaptureBufferSize, "Captured API requests kept for /admin/requests")
+	fs.StringVar(&c.Fixtures, "fixtures", c.Fixtures, "Pattern of JSON fixture files whose tasks are added at startup, e.g. fixtures/*.json (dev only)")
+	fs.DurationVar(&c.ConfigReloadInterval, "config-reload-interval", c.ConfigReloadInterval, "How often the configuration file is checked for changes (0 disables reloading)")
+
+	fs.Parse(args)
+
+	c.Environment = app.Environment(env)
+	c.CORSAllowedOrigins = app.SplitList(*corsOrigins)
+	c.TrustedProxies = app.SplitList(*trustedProxies)
+	c.NotifyEmailTo = app.SplitList(*notifyEmailTo)
+	c.EventWebhookURLs = app.SplitList(*eventWebhookURLs)
+	c.EscalationRules = app.SplitList(*escalationRules)
+	c.WIPLimits = app.SplitList(*wipLimits)
+	c.Palette = app.SplitList(*colors)
+	c.Workspaces = app.SplitList(*workspaces)
+	c.Plans = app.SplitList(*plans)
+	c.WorkspacePlans = app.SplitList(*workspacePlans)
+	c.NtfyEvents = app.SplitList(*ntfyEvents)
+	c.DiscordEvents = app.SplitList(*discordEvents)
+	c.TeamsEvents = app.SplitList(*teamsEvents)
+
+	return c, configFile, nil
 }
 
-func getEnvironment(input string) (app.Environment, error) {
-	switch input {
-	case "dev":
-		return app.Dev, nil
-	case "stage":
-		return app.Stage, nil
-	case "acc":
-		return app.Acc, nil
-	case "sandbox":
-		return app.Sandbox, nil
-	case "prod":
-		return app.Prod, nil
-	default:
-		return "", fmt.Errorf("invalid environment: %s", input)
+// configFileArg returns the value of the -config flag in args, which must be
+// known before the other flags are defined, or fallback when it is absent.
+func configFileArg(args []string, fallback string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
 	}
+	return fallback
 }
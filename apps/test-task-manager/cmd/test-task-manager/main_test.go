@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/storebench"
+)
+
+func TestCommandName(t *testing.T) {
+	tests := []struct {
+		args []string
+		name string
+		rest []string
+	}{
+		{nil, "serve", nil},
+		{[]string{"-port", "9000"}, "serve", []string{"-port", "9000"}},
+		{[]string{"serve", "-port", "9000"}, "serve", []string{"-port", "9000"}},
+		{[]string{"migrate", "-workspace", "team"}, "migrate", []string{"-workspace", "team"}},
+		{[]string{"users", "create", "-name", "ann"}, "users create", []string{"-name", "ann"}},
+		{[]string{"keys", "list"}, "keys list", []string{}},
+		{[]string{"users", "-name", "ann"}, "users", []string{"-name", "ann"}},
+		{[]string{"users", "delete"}, "users", []string{"delete"}},
+		{[]string{"seed", "extra"}, "seed", []string{"extra"}},
+		{[]string{"help"}, "help", []string{}},
+		{[]string{"nope"}, "nope", []string{}},
+	}
+	for _, tt := range tests {
+		name, rest := commandName(tt.args)
+		if name != tt.name || !slices.Equal(rest, tt.rest) {
+			t.Errorf("commandName(%q) = %q, %q, want %q, %q", tt.args, name, rest, tt.name, tt.rest)
+		}
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	for _, cmd := range commands {
+		if found := findCommand(cmd.name); found != cmd {
+			t.Errorf("findCommand(%q) did not return the command", cmd.name)
+		}
+		if cmd.run == nil || cmd.summary == "" {
+			t.Errorf("%s: expected a run function and a summary", cmd.name)
+		}
+	}
+	for _, name := range []string{"", "help", "users", "create", "serve -port"} {
+		if findCommand(name) != nil {
+			t.Errorf("findCommand(%q): expected no command", name)
+		}
+	}
+}
+
+func TestConfigFileArg(t *testing.T) {
+	tests := []struct {
+		args     []string
+		fallback string
+		want     string
+	}{
+		{nil, "", ""},
+		{nil, "env.yaml", "env.yaml"},
+		{[]string{"-config", "a.yaml"}, "env.yaml", "a.yaml"},
+		{[]string{"--config", "a.yaml"}, "", "a.yaml"},
+		{[]string{"-config=a.yaml"}, "", "a.yaml"},
+		{[]string{"-port", "9000", "--config=a.yaml"}, "", "a.yaml"},
+		{[]string{"-config"}, "env.yaml", "env.yaml"},
+		{[]string{"-configs", "a.yaml"}, "", ""},
+		{[]string{"config", "a.yaml"}, "", ""},
+		{[]string{"--", "-config", "a.yaml"}, "", ""},
+	}
+	for _, tt := range tests {
+		if got := configFileArg(tt.args, tt.fallback); got != tt.want {
+			t.Errorf("configFileArg(%q, %q) = %q, want %q", tt.args, tt.fallback, got, tt.want)
+		}
+	}
+}
+
+func TestBuildConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "http_port: \"7000\"\nlog_level: warn\nprofiles:\n  prod:\n    log_level: error\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(backends string) { storebenchBackends = backends }(storebenchBackends)
+
+	tests := []struct {
+		name      string
+		args      []string
+		env       app.Environment
+		port, log string
+	}{
+		{"defaults", nil, app.Dev, "8080", "info"},
+		{"file", []string{"-config", path}, app.Dev, "7000", "warn"},
+		{"profile", []string{"-config", path}, app.Prod, "7000", "error"},
+		{"flags win", []string{"-config=" + path, "-port", "9000", "-loglevel", "debug"}, app.Prod, "9000", "debug"},
+	}
+	for _, tt := range tests {
+		c, configFile, err := buildConfig(storebenchCommand, tt.args, tt.env, tt.env)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if c.HTTPPort != tt.port || c.LogLevel != tt.log {
+			t.Errorf("%s: expected port %s and level %s, got %s and %s", tt.name, tt.port, tt.log, c.HTTPPort, c.LogLevel)
+		}
+		if want := configFileArg(tt.args, ""); configFile != want {
+			t.Errorf("%s: expected configuration file %q, got %q", tt.name, want, configFile)
+		}
+	}
+
+	if _, _, err := buildConfig(storebenchCommand, []string{"-backends", "memory", "-store", "file"}, app.Dev, ""); err != nil {
+		t.Fatal(err)
+	}
+	if storebenchBackends != "memory" {
+		t.Errorf("expected the command flags to be parsed, got backends %q", storebenchBackends)
+	}
+}
+
+func TestStorebenchBackend(t *testing.T) {
+	w := storebench.DefaultWorkload
+	w.Tasks, w.Operations, w.Concurrency = 20, 200, 4
+
+	for _, backend := range []string{store.BackendMemory, store.BackendFile, store.BackendSQLite} {
+		result, err := storebenchBackend(backend, t.TempDir(), w)
+		if err != nil {
+			t.Fatalf("%s: %v", backend, err)
+		}
+		if result.Errors != 0 {
+			t.Errorf("%s: expected no failed operations, got %d", backend, result.Errors)
+		}
+	}
+
+	defer func(dsn string) { storebenchPostgresDSN = dsn }(storebenchPostgresDSN)
+	storebenchPostgresDSN = ""
+	if _, err := storebenchBackend(store.BackendPostgres, t.TempDir(), w); err == nil {
+		t.Error("expected postgres to be skipped without -postgres-dsn")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+var migrateCommand = &command{
+	name:    "migrate",
+	summary: "Bring the store schema up to date",
+	run:     migrate,
+}
+
+// migrate brings the stores of the default and every other workspace up to
+// date.
+func migrate(inv invocation) error {
+	for _, workspace := range append([]string{store.DefaultWorkspace}, inv.config.Workspaces...) {
+		if err := migrateWorkspace(inv.config, workspace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateWorkspace(c app.Configuration, workspace string) error {
+	s, err := openStore(c, workspace)
+	if err != nil {
+		return err
+	}
+	defer store.Close(s)
+
+	m, ok := s.(store.Migrator)
+	if !ok {
+		fmt.Printf("the %s store has no schema to migrate\n", c.Store)
+		return nil
+	}
+
+	applied, err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("workspace %s: %w", workspace, err)
+	}
+	fmt.Printf("applied %d migration(s) to workspace %s\n", applied, workspace)
+	return nil
+}
+
+// openStore validates c and opens the store of workspace it configures. The
+// memory store is rejected: it lives inside the serving process and cannot
+// be reached from a separate command.
+func openStore(c app.Configuration, workspace string) (store.Store, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.Store == store.BackendMemory {
+		return nil, fmt.Errorf("the %s store is not shared between processes, select a persistent store with -store", c.Store)
+	}
+	s, err := store.OpenWorkspace(c.Store, c.StoreDSN, workspace)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := s.(store.Pooled); ok {
+		p.ConfigurePool(c.PoolConfig())
+	}
+	return s, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/report"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+var (
+	reportFormat string
+	reportDays   int
+)
+
+var reportCommand = &command{
+	name:    "report",
+	summary: "Summarize completed, open and overdue tasks",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&reportFormat, "format", report.FormatText, "Output format: "+strings.Join(report.Formats, ", "))
+		fs.IntVar(&reportDays, "days", 7, "Number of days to summarize, up to and including today")
+	},
+	run: runReport,
+}
+
+func runReport(inv invocation) error {
+	if !slices.Contains(report.Formats, reportFormat) {
+		return fmt.Errorf("unsupported format %q; use one of %s", reportFormat, strings.Join(report.Formats, ", "))
+	}
+	if reportDays < 1 {
+		return fmt.Errorf("-days must be at least 1")
+	}
+
+	s, err := openStore(inv.config, store.DefaultWorkspace)
+	if err != nil {
+		return err
+	}
+	defer store.Close(s)
+
+	tasks, err := service.NewTaskService(s).GetAll(context.Background())
+	if err != nil {
+		return err
+	}
+	return report.Build(tasks, time.Now(), reportDays).Write(os.Stdout, reportFormat)
+}
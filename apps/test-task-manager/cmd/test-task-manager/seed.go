@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/seed"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+var seedCount int
+
+var seedCommand = &command{
+	name:    "seed",
+	summary: "Add sample tasks, skipping those that already exist",
+	flags: func(fs *flag.FlagSet) {
+		fs.IntVar(&seedCount, "count", 20, fmt.Sprintf("Number of sample tasks (at most %d)", seed.MaxCount))
+	},
+	run: runSeed,
+}
+
+func runSeed(inv invocation) error {
+	s, err := openStore(inv.config, store.DefaultWorkspace)
+	if err != nil {
+		return err
+	}
+	defer store.Close(s)
+
+	result, err := seed.Run(context.Background(), service.NewTaskService(s), seedCount, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("added %d sample task(s), %d already existed\n", result.Created, result.Skipped)
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/http/server"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/version"
+)
+
+var serveCommand = &command{
+	name:    "serve",
+	summary: "Run the web application (default)",
+	run:     serve,
+}
+
+func serve(inv invocation) error {
+	application, err := app.Initialize(inv.config)
+	if err != nil {
+		return err
+	}
+
+	// The configuration file is watched until the process exits.
+	if inv.configFile != "" && inv.config.ConfigReloadInterval > 0 {
+		application.WatchConfig(inv.configFile, inv.config.ConfigReloadInterval, inv.reload)
+	}
+
+	run(application)
+	return nil
+}
+
+// Run the application daemon.
+func run(application *app.App) {
+	build := version.Get()
+	application.Logger().Infow("Starting application",
+		"version", build.Version,
+		"commit", build.Commit,
+		"buildTime", build.BuildTime,
+	)
+
+	server := server.Start(application)
+	application.Upgrader().Ready()
+	application.Run()
+
+	application.Logger().Info("Shutting down application")
+
+	// The servers stop first, so nothing queues background jobs while the
+	// application drains them.
+	server.Shutdown()
+	application.Shutdown()
+
+	os.Exit(0)
+}
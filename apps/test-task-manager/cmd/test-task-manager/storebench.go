@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/storebench"
+)
+
+var (
+	storebenchBackends    string
+	storebenchPostgresDSN string
+	storebenchWorkload    = storebench.DefaultWorkload
+)
+
+var storebenchCommand = &command{
+	name:    "storebench",
+	summary: "Run the same workload against store backends and compare them",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&storebenchBackends, "backends", "memory,file,sqlite,postgres", "Comma-separated store backends to compare")
+		fs.StringVar(&storebenchPostgresDSN, "postgres-dsn", "", "URL of a scratch postgres database (the postgres backend is skipped when empty)")
+		fs.IntVar(&storebenchWorkload.Tasks, "tasks", storebenchWorkload.Tasks, "Number of tasks created before measuring")
+		fs.IntVar(&storebenchWorkload.Operations, "operations", storebenchWorkload.Operations, "Number of operations measured per backend")
+		fs.IntVar(&storebenchWorkload.Concurrency, "workers", storebenchWorkload.Concurrency, "Number of concurrent workers")
+	},
+	run: runStorebench,
+}
+
+// runStorebench runs the workload against every selected backend in turn
+// and prints the comparison. The file and sqlite backends work in a
+// temporary directory. Backends that cannot be opened, such as postgres
+// without -postgres-dsn, are reported and skipped.
+func runStorebench(inv invocation) error {
+	w := storebenchWorkload
+	if w.Tasks < 0 || w.Operations < 1 || w.Concurrency < 1 {
+		return fmt.Errorf("-operations and -workers must be positive and -tasks may not be negative")
+	}
+	backends := strings.Split(storebenchBackends, ",")
+	for _, backend := range backends {
+		if !slices.Contains(store.Backends, backend) {
+			return fmt.Errorf("unknown store backend %q, expected one of %s", backend, strings.Join(store.Backends, ", "))
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "ttm-storebench-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Fprintf(os.Stderr, "running %d operations on %d tasks with %d workers\n", w.Operations, w.Tasks, w.Concurrency)
+	var results []storebench.Result
+	for _, backend := range backends {
+		result, err := storebenchBackend(backend, dir, w)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping %s: %v\n", backend, err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s: %.0f operations/s\n", backend, result.Throughput())
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("none of the backends could be benchmarked")
+	}
+
+	fmt.Println()
+	return storebench.WriteTable(os.Stdout, results)
+}
+
+func storebenchBackend(backend, dir string, w storebench.Workload) (storebench.Result, error) {
+	var dsn string
+	switch backend {
+	case store.BackendFile:
+		dsn = filepath.Join(dir, "tasks.json")
+	case store.BackendSQLite:
+		// Concurrent writers wait for the database lock instead of failing
+		// with SQLITE_BUSY.
+		dsn = filepath.Join(dir, "tasks.db") + "?_pragma=busy_timeout(5000)"
+	case store.BackendPostgres:
+		dsn = storebenchPostgresDSN
+		if dsn == "" {
+			return storebench.Result{}, fmt.Errorf("no -postgres-dsn given")
+		}
+	case store.BackendRedis:
+		return storebench.Result{}, fmt.Errorf("not part of the comparison")
+	}
+
+	s, err := store.Open(backend, dsn)
+	if err != nil {
+		return storebench.Result{}, err
+	}
+	defer store.Close(s)
+	if m, ok := s.(store.Migrator); ok {
+		if _, err := m.Migrate(); err != nil {
+			return storebench.Result{}, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	return storebench.Run(ctx, backend, s, w)
+}
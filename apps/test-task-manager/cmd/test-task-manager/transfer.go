@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/export"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+var (
+	exportFile, importFile string
+	exportFormat           string
+	exportFilter           export.Filter
+	importDryRun           bool
+)
+
+var exportCommand = &command{
+	name:    "export",
+	summary: "Write tasks as JSON, CSV or NDJSON",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&exportFile, "file", "", "File to write to (standard output when empty)")
+		fs.StringVar(&exportFormat, "format", export.FormatJSON, "Output format: "+strings.Join(export.Formats, ", "))
+		fs.StringVar(&exportFilter.Status, "status", "", "Only export open or completed tasks (all when empty)")
+		fs.StringVar(&exportFilter.Priority, "priority", "", "Only export tasks with this priority emoticon (all when empty)")
+	},
+	run: exportTasks,
+}
+
+var importCommand = &command{
+	name:    "import",
+	summary: "Create tasks from a JSON export",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&importFile, "file", "", "File to read from (standard input when empty)")
+		fs.BoolVar(&importDryRun, "dry-run", false, "Validate the tasks and list what would be created without changing the store")
+	},
+	run: importTasks,
+}
+
+// exportTasks writes the tasks matching the filter flags. Only -format json
+// can be read back by import.
+func exportTasks(inv invocation) error {
+	if !slices.Contains(export.Formats, exportFormat) {
+		return fmt.Errorf("unsupported format %q; use one of %s", exportFormat, strings.Join(export.Formats, ", "))
+	}
+	if exportFilter.Status != "" && exportFilter.Status != "open" && exportFilter.Status != "completed" {
+		return fmt.Errorf("unsupported status %q; use open or completed", exportFilter.Status)
+	}
+
+	s, err := openStore(inv.config, store.DefaultWorkspace)
+	if err != nil {
+		return err
+	}
+	defer store.Close(s)
+
+	tasks, err := service.NewTaskService(s).GetAll(context.Background())
+	if err != nil {
+		return err
+	}
+	tasks = exportFilter.Apply(tasks)
+
+	var w io.Writer = os.Stdout
+	if exportFile != "" {
+		f, err := os.Create(exportFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := export.Write(w, exportFormat, tasks); err != nil {
+		return err
+	}
+
+	if exportFile != "" {
+		fmt.Printf("exported %d task(s) to %s\n", len(tasks), exportFile)
+	}
+	return nil
+}
+
+// importTasks creates every task of an export. The store assigns new IDs
+// and creation times; completed tasks are completed again after creation.
+// All tasks are validated first, so an invalid export changes nothing.
+func importTasks(inv invocation) error {
+	var r io.Reader = os.Stdin
+	if importFile != "" {
+		f, err := os.Open(importFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var tasks []model.Task
+	if err := json.NewDecoder(r).Decode(&tasks); err != nil {
+		return fmt.Errorf("failed to parse tasks: %w", err)
+	}
+
+	var problems []string
+	for i, task := range tasks {
+		if _, err := service.NewTask(task.Title, task.Priority, task.Color, task.DueDate); err != nil {
+			problems = append(problems, fmt.Sprintf("task %d (%q): %v", i+1, task.Title, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d of %d task(s) are invalid:\n  %s", len(problems), len(tasks), strings.Join(problems, "\n  "))
+	}
+
+	if importDryRun {
+		return printImportPlan(tasks)
+	}
+
+	s, err := openStore(inv.config, store.DefaultWorkspace)
+	if err != nil {
+		return err
+	}
+	defer store.Close(s)
+
+	if _, err := service.NewTaskService(s).CreateMany(context.Background(), tasks); err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d task(s)\n", len(tasks))
+	return nil
+}
+
+// printImportPlan lists the tasks an import would create, as they would be
+// stored after applying defaults.
+func printImportPlan(tasks []model.Task) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tTITLE\tPRIORITY\tCOLOR\tDUE\tCOMPLETED")
+	for i, task := range tasks {
+		normalized, _ := service.NewTask(task.Title, task.Priority, task.Color, task.DueDate)
+		due := "-"
+		if normalized.DueDate != nil {
+			due = normalized.DueDate.Format(time.DateOnly)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%t\n", i+1, normalized.Title, normalized.Priority, normalized.Color, due, task.Completed)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("dry run: would import %d task(s); the store was not changed\n", len(tasks))
+	return nil
+}
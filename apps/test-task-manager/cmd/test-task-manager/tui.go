@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"net"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/tui"
+)
+
+var (
+	tuiURL   string
+	tuiToken string
+)
+
+var tuiCommand = &command{
+	name:    "tui",
+	summary: "Manage tasks of a running server in a terminal UI",
+	flags: func(fs *flag.FlagSet) {
+		token, _ := app.LookupEnv("API_TOKEN")
+		fs.StringVar(&tuiURL, "api-url", "", "Base URL of the server, e.g. http://localhost:8080 (derived from the listen address when empty)")
+		fs.StringVar(&tuiToken, "token", token, "API key to authenticate with (TTM_API_TOKEN)")
+	},
+	run: func(inv invocation) error {
+		baseURL := tuiURL
+		if baseURL == "" {
+			var err error
+			if baseURL, err = publicURL(inv.config); err != nil {
+				return err
+			}
+		}
+		return tui.Run(tui.NewClient(baseURL, tuiToken))
+	},
+}
+
+// publicURL returns the URL of the public listener of the configuration.
+// Wildcard addresses are reached on the loopback interface.
+func publicURL(c app.Configuration) (string, error) {
+	network, addr := c.ListenAddress()
+	if network != "tcp" {
+		return "", errors.New("the server listens on a unix socket; set -api-url")
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+)
+
+// authAdmin manages users and credentials, either directly in the auth file
+// or through the admin API of a running server.
+type authAdmin interface {
+	CreateUser(name string) (auth.User, error)
+	DisableUser(ref string) (auth.User, error)
+	SetPreferences(ref string, prefs auth.Preferences) (auth.User, error)
+	SetWorkspaces(ref string, workspaces []string) (auth.User, error)
+	Users() ([]auth.User, error)
+	IssueKey(userRef, name string) (auth.IssuedKey, error)
+	RevokeKey(id string) (auth.APIKey, error)
+	Keys(userRef string) ([]auth.APIKey, error)
+	Sessions() ([]auth.Session, error)
+}
+
+var (
+	adminURL  string
+	userName  string
+	userRef   string
+	keyID     string
+	userPrefs auth.Preferences
+	// userWorkspaces is nil unless -workspaces is given.
+	userWorkspaces []string
+)
+
+func adminURLFlag(fs *flag.FlagSet) {
+	fs.StringVar(&adminURL, "admin-url", "", "Base URL of a running server's admin API, e.g. http://localhost:9090 (edits the auth file directly when empty)")
+}
+
+var usersCreateCommand = &command{
+	name:    "users create",
+	summary: "Create a user",
+	flags: func(fs *flag.FlagSet) {
+		adminURLFlag(fs)
+		fs.StringVar(&userName, "name", "", "Name of the user")
+	},
+	run: func(inv invocation) error {
+		return withAuthAdmin(inv, func(a authAdmin) error {
+			user, err := a.CreateUser(userName)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("created user %s (%s)\n", user.Name, user.ID)
+			return nil
+		})
+	},
+}
+
+var usersDisableCommand = &command{
+	name:    "users disable",
+	summary: "Disable a user, ending their sessions and API key access",
+	flags: func(fs *flag.FlagSet) {
+		adminURLFlag(fs)
+		fs.StringVar(&userRef, "user", "", "ID or name of the user")
+	},
+	run: func(inv invocation) error {
+		return withAuthAdmin(inv, func(a authAdmin) error {
+			user, err := a.DisableUser(userRef)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("disabled user %s (%s)\n", user.Name, user.ID)
+			return nil
+		})
+	},
+}
+
+var usersUpdateCommand = &command{
+	name:    "users update",
+	summary: "Change the email address, daily digest preference and workspaces of a user",
+	flags: func(fs *flag.FlagSet) {
+		adminURLFlag(fs)
+		fs.StringVar(&userRef, "user", "", "ID or name of the user")
+		fs.Func("email", "Address the daily digest is sent to (empty removes it)", func(v string) error {
+			userPrefs.Email = &v
+			return nil
+		})
+		fs.Func("digest", "Whether the user receives the daily digest (true or false)", func(v string) error {
+			receive, err := strconv.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			optOut := !receive
+			userPrefs.DigestOptOut = &optOut
+			return nil
+		})
+		fs.Func("workspaces", "Comma-separated workspaces the user is a member of besides the default one (empty removes all)", func(v string) error {
+			userWorkspaces = strings.Split(v, ",")
+			return nil
+		})
+	},
+	run: func(inv invocation) error {
+		return withAuthAdmin(inv, func(a authAdmin) error {
+			user, err := a.SetPreferences(userRef, userPrefs)
+			if err != nil {
+				return err
+			}
+			if userWorkspaces != nil {
+				if user, err = a.SetWorkspaces(userRef, userWorkspaces); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("updated user %s (%s)\n", user.Name, user.ID)
+			return nil
+		})
+	},
+}
+
+var usersListCommand = &command{
+	name:    "users list",
+	summary: "List users",
+	flags:   adminURLFlag,
+	run: func(inv invocation) error {
+		return withAuthAdmin(inv, func(a authAdmin) error {
+			users, err := a.Users()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tEMAIL\tDIGEST\tWORKSPACES\tCREATED\tDISABLED")
+			for _, u := range users {
+				email, digest := u.Email, "on"
+				if email == "" {
+					email, digest = "-", "-"
+				} else if u.DigestOptOut {
+					digest = "off"
+				}
+				workspaces := strings.Join(u.Workspaces, ",")
+				if workspaces == "" {
+					workspaces = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", u.ID, u.Name, email, digest, workspaces, formatTime(&u.CreatedAt), formatTime(u.DisabledAt))
+			}
+			return w.Flush()
+		})
+	},
+}
+
+var keysIssueCommand = &command{
+	name:    "keys issue",
+	summary: "Issue an API key for a user",
+	flags: func(fs *flag.FlagSet) {
+		adminURLFlag(fs)
+		fs.StringVar(&userRef, "user", "", "ID or name of the user")
+		fs.StringVar(&userName, "name", "", "Description of the key, e.g. where it is used")
+	},
+	run: func(inv invocation) error {
+		return withAuthAdmin(inv, func(a authAdmin) error {
+			key, err := a.IssueKey(userRef, userName)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "issued key %s; store the token now, it cannot be shown again\n", key.ID)
+			fmt.Println(key.Token)
+			return nil
+		})
+	},
+}
+
+var keysRevokeCommand = &command{
+	name:    "keys revoke",
+	summary: "Revoke an API key",
+	flags: func(fs *flag.FlagSet) {
+		adminURLFlag(fs)
+		fs.StringVar(&keyID, "key", "", "ID of the API key")
+	},
+	run: func(inv invocation) error {
+		return withAuthAdmin(inv, func(a authAdmin) error {
+			key, err := a.RevokeKey(keyID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("revoked key %s\n", key.ID)
+			return nil
+		})
+	},
+}
+
+var keysListCommand = &command{
+	name:    "keys list",
+	summary: "List API keys",
+	flags: func(fs *flag.FlagSet) {
+		adminURLFlag(fs)
+		fs.StringVar(&userRef, "user", "", "Only list the keys of this user ID or name")
+	},
+	run: func(inv invocation) error {
+		return withAuthAdmin(inv, func(a authAdmin) error {
+			keys, err := a.Keys(userRef)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tUSER\tNAME\tCREATED\tREVOKED")
+			for _, k := range keys {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", k.ID, k.UserID, k.Name, formatTime(&k.CreatedAt), formatTime(k.RevokedAt))
+			}
+			return w.Flush()
+		})
+	},
+}
+
+var sessionsListCommand = &command{
+	name:    "sessions list",
+	summary: "List active sessions",
+	flags:   adminURLFlag,
+	run: func(inv invocation) error {
+		return withAuthAdmin(inv, func(a authAdmin) error {
+			sessions, err := a.Sessions()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tUSER\tCREATED\tEXPIRES")
+			for _, s := range sessions {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.ID, s.UserID, formatTime(&s.CreatedAt), formatTime(&s.ExpiresAt))
+			}
+			return w.Flush()
+		})
+	},
+}
+
+// withAuthAdmin calls fn with the admin API client when -admin-url is set,
+// and with the auth file of the configuration otherwise.
+func withAuthAdmin(inv invocation, fn func(a authAdmin) error) error {
+	if adminURL != "" {
+		return fn(&adminClient{baseURL: strings.TrimRight(adminURL, "/"), token: inv.config.AdminToken})
+	}
+
+	if inv.config.AuthFile == "" {
+		return errors.New("no auth file configured: set -auth-file or TTM_AUTH_FILE, or use -admin-url")
+	}
+	s, err := auth.NewStore(inv.config.AuthFile)
+	if err != nil {
+		return err
+	}
+	return fn(s)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// adminClient calls the /admin user endpoints of a running server.
+type adminClient struct {
+	baseURL string
+	token   string
+}
+
+func (c *adminClient) CreateUser(name string) (auth.User, error) {
+	var user auth.User
+	err := c.do(http.MethodPost, "/admin/users", map[string]string{"name": name}, &user)
+	return user, err
+}
+
+func (c *adminClient) DisableUser(ref string) (auth.User, error) {
+	var user auth.User
+	err := c.do(http.MethodPost, "/admin/users/"+url.PathEscape(ref)+"/disable", nil, &user)
+	return user, err
+}
+
+func (c *adminClient) SetPreferences(ref string, prefs auth.Preferences) (auth.User, error) {
+	var user auth.User
+	err := c.do(http.MethodPut, "/admin/users/"+url.PathEscape(ref)+"/preferences", prefs, &user)
+	return user, err
+}
+
+func (c *adminClient) SetWorkspaces(ref string, workspaces []string) (auth.User, error) {
+	var user auth.User
+	err := c.do(http.MethodPut, "/admin/users/"+url.PathEscape(ref)+"/workspaces", map[string][]string{"workspaces": workspaces}, &user)
+	return user, err
+}
+
+func (c *adminClient) Users() ([]auth.User, error) {
+	var users []auth.User
+	err := c.do(http.MethodGet, "/admin/users", nil, &users)
+	return users, err
+}
+
+func (c *adminClient) IssueKey(userRef, name string) (auth.IssuedKey, error) {
+	var key auth.IssuedKey
+	err := c.do(http.MethodPost, "/admin/users/"+url.PathEscape(userRef)+"/keys", map[string]string{"name": name}, &key)
+	return key, err
+}
+
+func (c *adminClient) RevokeKey(id string) (auth.APIKey, error) {
+	var key auth.APIKey
+	err := c.do(http.MethodDelete, "/admin/keys/"+url.PathEscape(id), nil, &key)
+	return key, err
+}
+
+func (c *adminClient) Keys(userRef string) ([]auth.APIKey, error) {
+	var keys []auth.APIKey
+	err := c.do(http.MethodGet, "/admin/keys?user="+url.QueryEscape(userRef), nil, &keys)
+	return keys, err
+}
+
+func (c *adminClient) Sessions() ([]auth.Session, error) {
+	var sessions []auth.Session
+	err := c.do(http.MethodGet, "/admin/sessions", nil, &sessions)
+	return sessions, err
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out. Error responses are returned with their message.
+func (c *adminClient) do(method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		content, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&failure) == nil && failure.Error != "" {
+			return fmt.Errorf("%s (status %d)", failure.Error, resp.StatusCode)
+		}
+		return fmt.Errorf("admin API returned status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+var _ authAdmin = (*auth.Store)(nil)
+var _ authAdmin = (*adminClient)(nil)
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/notify"
+)
+
+var vapidKeysCommand = &command{
+	name:    "vapid-keys",
+	summary: "Generate a VAPID key pair for web push notifications",
+	run:     vapidKeys,
+}
+
+// vapidKeys prints a new key pair as environment variables. Browsers
+// subscribe with the public key, so changing it invalidates every push
+// subscription.
+func vapidKeys(inv invocation) error {
+	keys, err := notify.GenerateVAPIDKeys()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("TTM_VAPID_PUBLIC_KEY=%s\n", keys.PublicKey())
+	fmt.Printf("TTM_VAPID_PRIVATE_KEY=%s\n", keys.PrivateKey())
+	return nil
+}
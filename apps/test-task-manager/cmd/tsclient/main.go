@@ -0,0 +1,43 @@
+// Command tsclient writes the TypeScript client of the API generated from
+// its OpenAPI document. It is run by "go generate ./api":
+//
+//	tsclient -o client.ts openapi.yaml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/tsclient"
+)
+
+func main() {
+	out := flag.String("o", "client.ts", "File to write the client to")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-o client.ts] openapi.yaml\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *out); err != nil {
+		fmt.Fprintf(os.Stderr, "tsclient: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(specFile, out string) error {
+	spec, err := os.ReadFile(specFile)
+	if err != nil {
+		return err
+	}
+	client, err := tsclient.Generate(spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", specFile, err)
+	}
+	return os.WriteFile(out, client, 0o644)
+}
@@ -0,0 +1,94 @@
+// Package activity counts the API requests per workspace and per user for
+// the admin overview. Counts are kept in memory, since the process started.
+package activity
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Requests holds the requests made to a workspace or by a user.
+type Requests struct {
+	Total int64 `json:"total"`
+	// Writes counts the requests that are not GET, HEAD or OPTIONS.
+	Writes int64      `json:"writes"`
+	LastAt *time.Time `json:"lastAt,omitempty"`
+}
+
+func (r *Requests) add(write bool, at time.Time) {
+	r.Total++
+	if write {
+		r.Writes++
+	}
+	if r.LastAt == nil || at.After(*r.LastAt) {
+		r.LastAt = &at
+	}
+}
+
+// Tracker counts requests. It is safe for concurrent use.
+type Tracker struct {
+	mu         sync.Mutex
+	workspaces map[string]*Requests
+	users      map[string]*Requests
+}
+
+// NewTracker returns a Tracker without requests.
+func NewTracker() *Tracker {
+	return &Tracker{workspaces: make(map[string]*Requests), users: make(map[string]*Requests)}
+}
+
+// Record counts a request to workspace at at; user is the ID of the user
+// who made it, empty for anonymous requests.
+func (t *Tracker) Record(workspace, user string, write bool, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	counted(t.workspaces, workspace).add(write, at)
+	if user != "" {
+		counted(t.users, user).add(write, at)
+	}
+}
+
+// Workspace returns the requests made to workspace.
+func (t *Tracker) Workspace(workspace string) Requests {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return copyOf(t.workspaces[workspace])
+}
+
+// User returns the requests made by the user with ID user.
+func (t *Tracker) User(user string) Requests {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return copyOf(t.users[user])
+}
+
+// IsWrite reports whether a request with method changes something.
+func IsWrite(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+func counted(m map[string]*Requests, key string) *Requests {
+	r, ok := m[key]
+	if !ok {
+		r = &Requests{}
+		m[key] = r
+	}
+	return r
+}
+
+func copyOf(r *Requests) Requests {
+	if r == nil {
+		return Requests{}
+	}
+	c := *r
+	if r.LastAt != nil {
+		at := *r.LastAt
+		c.LastAt = &at
+	}
+	return c
+}
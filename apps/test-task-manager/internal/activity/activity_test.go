@@ -0,0 +1,38 @@
+package activity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	first := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	last := first.Add(time.Hour)
+
+	tracker.Record("team", "u1", false, first)
+	tracker.Record("team", "u1", true, last)
+	tracker.Record("default", "", true, first)
+
+	team := tracker.Workspace("team")
+	if team.Total != 2 || team.Writes != 1 || team.LastAt == nil || !team.LastAt.Equal(last) {
+		t.Errorf("expected 2 requests with 1 write to team, last at %v, got %+v", last, team)
+	}
+	if user := tracker.User("u1"); user.Total != 2 || user.Writes != 1 {
+		t.Errorf("expected 2 requests with 1 write by u1, got %+v", user)
+	}
+	if user := tracker.User(""); user.Total != 0 {
+		t.Errorf("expected anonymous requests not to be counted per user, got %+v", user)
+	}
+	if none := tracker.Workspace("other"); none.Total != 0 || none.LastAt != nil {
+		t.Errorf("expected no requests to other, got %+v", none)
+	}
+}
+
+func TestIsWrite(t *testing.T) {
+	for method, want := range map[string]bool{"GET": false, "HEAD": false, "OPTIONS": false, "POST": true, "PUT": true, "PATCH": true, "DELETE": true} {
+		if got := IsWrite(method); got != want {
+			t.Errorf("IsWrite(%q) = %v, want %v", method, got, want)
+		}
+	}
+}
@@ -1,54 +1,222 @@
 package app
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
 	"gitlab.com/btcdirect-api/go-modules/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/capture"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/faults"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/health"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/http/middleware"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/jobs"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/maintenance"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/outbound"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/restart"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/version"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type App struct {
-	config Configuration
-	core   *app.App
+	mu      sync.RWMutex // Guards config
+	config  Configuration
+	core    *app.App
+	metrics *metrics.Registry
+	health  *health.Registry
+
+	logger   *zap.SugaredLogger
+	logLevel zap.AtomicLevel
+	reporter errorreport.Reporter
+	faults   *faults.Injector
+	outbound *outbound.Factory
+	upgrader *restart.Upgrader
+	auth     *auth.Store
+	jobs     *jobs.Queue
+
+	maintenance *maintenance.Mode
+	rateLimiter *middleware.RateLimiter
+	captures    *capture.Recorder
 }
 
 // Initialize the application.
-// This will also load the configuration.
-func Initialize(c Configuration) *App {
-	// In development mode, we set the shutdown timeout to 0 to allow for instant shutdowns.
-	// In production, we set it to 30 seconds to allow for graceful shutdowns.
-	shutdownTimeout := 30 * time.Second
-	if c.Environment == Dev {
-		shutdownTimeout = 0
+// The configuration is validated first; all problems are reported in a single ValidationError.
+func Initialize(c Configuration) (*App, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
 	}
 
 	core := app.Initialize(
 		app.WithLoggerForLevel(c.LogLevel),
-		app.WithShutdownTimeout(shutdownTimeout),
+		app.WithShutdownTimeout(c.ShutdownTimeout),
 	)
 
-	return &App{
-		config: c,
-		core:   &core,
+	logger, logLevel := newLogger(c)
+
+	build := version.Get()
+	registry := metrics.NewRegistry()
+	registry.GaugeVec("build_info", "Build information of the running binary.", "version", "commit", "build_time").
+		With(build.Version, build.Commit, build.BuildTime).Set(1)
+
+	clients := outbound.NewFactory(c.OutboundTimeout, registry)
+
+	var reporter errorreport.Reporter = errorreport.Nop{}
+	if c.SentryDSN != "" {
+		sentry, err := errorreport.NewSentry(errorreport.SentryOptions{
+			DSN:         c.SentryDSN,
+			Environment: string(c.Environment),
+			Release:     version.Version,
+			Client:      clients.Client("sentry", 0),
+		}, logger)
+		if err != nil {
+			return nil, err
+		}
+		reporter = sentry
 	}
+
+	authStore, err := auth.NewStore(c.AuthFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open auth file: %w", err)
+	}
+
+	upgrader := restart.New(logger)
+	if c.Store == store.BackendMemory {
+		upgrader.Refuse("the memory store keeps the tasks in this process, a new one would start without them")
+	}
+
+	return &App{
+		config:   c,
+		core:     &core,
+		metrics:  registry,
+		health:   health.NewRegistry(),
+		logger:   logger,
+		logLevel: logLevel,
+		reporter: reporter,
+		faults: faults.NewInjector(faults.Settings{
+			Latency:   c.FaultLatency,
+			ErrorRate: c.FaultErrorRate,
+		}, registry),
+		outbound: clients,
+		upgrader: upgrader,
+		auth:     authStore,
+		jobs:     jobs.New(jobs.NewMemory(c.JobQueueSize), c.JobWorkers, c.RetryPolicy(), logger, registry),
+
+		maintenance: maintenance.New(c.MaintenanceSettings()),
+		rateLimiter: middleware.NewRateLimiter(c.RateLimit, c.RateBurst),
+		captures:    capture.New(c.CaptureSettings()),
+	}, nil
 }
 
-// Run the application and its services.
+// Run the application and its services, including the background job workers.
+// A SIGHUP re-executes the binary and hands over the listeners; once the new
+// process is ready, this one shuts down gracefully.
 func (a *App) Run() {
+	stop := a.upgrader.Watch()
+	defer stop()
+
+	a.jobs.Start()
 	a.core.Run()
 }
 
-// Shutdown shuts down all services of the application.
+// Shutdown shuts down all services of the application. Background jobs
+// that are due get the shutdown timeout to finish.
 func (a *App) Shutdown() {
-	// No additional cleanup needed
+	a.jobs.Shutdown(a.ShutdownTimeout())
+	a.reporter.Flush(5 * time.Second)
 }
 
-// Config returns the application configuration.
+// Config returns the application configuration, including reloaded settings.
 func (a *App) Config() Configuration {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return a.config
 }
 
 // Logger exposes the shared structured logger.
 func (a *App) Logger() *zap.SugaredLogger {
-	return a.core.Log
+	return a.logger
+}
+
+// LogLevel returns the current log level.
+func (a *App) LogLevel() string {
+	return a.logLevel.String()
+}
+
+// SetLogLevel changes the log level at runtime.
+func (a *App) SetLogLevel(level string) error {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %s", level)
+	}
+
+	previous := a.logLevel.Level()
+	a.logLevel.SetLevel(parsed)
+	a.logger.Infow("log level changed", "from", previous.String(), "to", parsed.String())
+
+	return nil
+}
+
+// Metrics exposes the shared metrics registry.
+func (a *App) Metrics() *metrics.Registry {
+	return a.metrics
+}
+
+// Health exposes the component health registry.
+func (a *App) Health() *health.Registry {
+	return a.health
+}
+
+// ErrorReporter exposes the reporter for unexpected errors and panics.
+func (a *App) ErrorReporter() errorreport.Reporter {
+	return a.reporter
+}
+
+// Faults exposes the fault injector. It never injects anything in prod.
+func (a *App) Faults() *faults.Injector {
+	return a.faults
+}
+
+// HTTPClients exposes the factory for outbound HTTP clients.
+func (a *App) HTTPClients() *outbound.Factory {
+	return a.outbound
+}
+
+// ShutdownTimeout is how long services may take to shut down gracefully.
+func (a *App) ShutdownTimeout() time.Duration {
+	return a.Config().ShutdownTimeout
+}
+
+// Upgrader exposes the listener handoff used for zero-downtime restarts.
+func (a *App) Upgrader() *restart.Upgrader {
+	return a.upgrader
+}
+
+// Auth returns the users, API keys and sessions.
+func (a *App) Auth() *auth.Store {
+	return a.auth
+}
+
+// Jobs exposes the background job queue.
+func (a *App) Jobs() *jobs.Queue {
+	return a.jobs
+}
+
+// RateLimiter exposes the per-client API rate limiter, whose limits follow configuration reloads.
+func (a *App) RateLimiter() *middleware.RateLimiter {
+	return a.rateLimiter
+}
+
+// Captures exposes the API requests captured for /admin/requests.
+func (a *App) Captures() *capture.Recorder {
+	return a.captures
+}
+
+// Maintenance exposes the maintenance mode, in which changes are turned away.
+func (a *App) Maintenance() *maintenance.Mode {
+	return a.maintenance
 }
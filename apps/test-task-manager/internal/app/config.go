@@ -1,5 +1,30 @@
 package app
 
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/capture"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/cron"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/events"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/http/middleware"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/jobs"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/maintenance"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/notify"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/palette"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"go.uber.org/zap/zapcore"
+)
+
 const (
 	Dev     Environment = "dev"
 	Stage   Environment = "stage"
@@ -10,8 +35,848 @@ const (
 
 type Environment string
 
+// Valid reports whether e is one of the known environments.
+func (e Environment) Valid() bool {
+	switch e {
+	case Dev, Stage, Acc, Sandbox, Prod:
+		return true
+	default:
+		return false
+	}
+}
+
 type Configuration struct {
-	Environment Environment
-	LogLevel    string
-	HTTPPort    string
+	Environment Environment `yaml:"environment" env:"APP_ENV"`
+	LogLevel    string      `yaml:"log_level" env:"LOG_LEVEL"`
+	HTTPPort    string      `yaml:"http_port" env:"HTTP_PORT"`
+	SentryDSN   string      `yaml:"sentry_dsn" env:"SENTRY_DSN"`
+
+	// Log encoding: "console" (human-readable) or "json"
+	LogFormat string `yaml:"log_format" env:"LOG_FORMAT"`
+
+	// How long in-flight requests may take to finish on shutdown (0 stops immediately)
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+
+	// Listen address as "tcp:<host:port>" or "unix:<path>" (HTTPPort is used when empty)
+	Listen string `yaml:"http_listen" env:"HTTP_LISTEN"`
+
+	// Listen address for health, version, metrics, admin and debug endpoints
+	// (served on the public listener, without debug endpoints, when empty)
+	AdminListen string `yaml:"admin_listen" env:"ADMIN_LISTEN"`
+
+	// HTTP server timeouts (0 means no timeout)
+	HTTPReadTimeout       time.Duration `yaml:"http_read_timeout" env:"HTTP_READ_TIMEOUT"`
+	HTTPReadHeaderTimeout time.Duration `yaml:"http_read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	HTTPWriteTimeout      time.Duration `yaml:"http_write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	HTTPIdleTimeout       time.Duration `yaml:"http_idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+
+	// Task store backend (memory, file, sqlite, postgres or redis) and its
+	// connection string: a file path for file and sqlite, a URL otherwise
+	Store    string `yaml:"store" env:"STORE"`
+	StoreDSN string `yaml:"store_dsn" env:"STORE_DSN"`
+
+	// Workspaces served besides the default one, each with tasks of its own
+	// under /w/{workspace}/api or with the X-Workspace header; every store
+	// but postgres keeps them apart
+	Workspaces []string `yaml:"workspaces" env:"WORKSPACES"`
+
+	// Plans limiting the API requests and tasks of workspaces, as
+	// "<name>=<requests per second>/<burst>/<max tasks>" (0 is unlimited),
+	// and the plan of each workspace as "<workspace>=<plan>"; workspaces
+	// without one are unlimited
+	Plans          []string `yaml:"plans" env:"PLANS"`
+	WorkspacePlans []string `yaml:"workspace_plans" env:"WORKSPACE_PLANS"`
+
+	// How the memory and file stores assign task IDs: sequential, uuid or
+	// ulid; the other stores number tasks in the database
+	IDStrategy string `yaml:"id_strategy" env:"ID_STRATEGY"`
+
+	// Approximate memory the memory store may use for tasks, in MiB, before
+	// warnings are logged (soft) and new tasks are refused (hard); 0 disables
+	MemorySoftLimitMB int `yaml:"memory_soft_limit_mb" env:"MEMORY_SOFT_LIMIT_MB"`
+	MemoryHardLimitMB int `yaml:"memory_hard_limit_mb" env:"MEMORY_HARD_LIMIT_MB"`
+
+	// How long stores keep the tombstones of deleted tasks, for clients
+	// syncing tasks to learn about deletions (0 keeps none)
+	TombstoneRetention time.Duration `yaml:"tombstone_retention" env:"TOMBSTONE_RETENTION"`
+	// Serve reads from the last known tasks, and turn away changes, while
+	// the file, database or Redis of the store is unreachable
+	StoreFailover bool `yaml:"store_failover" env:"STORE_FAILOVER"`
+
+	// Connection pool of the sqlite and postgres stores: maximum open (0 means
+	// unlimited) and idle connections, and how long a connection may be used
+	// in total and stay idle (0 means forever)
+	DBMaxOpenConns    int           `yaml:"db_max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	DBMaxIdleConns    int           `yaml:"db_max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	DBConnMaxLifetime time.Duration `yaml:"db_conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	DBConnMaxIdleTime time.Duration `yaml:"db_conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+
+	// Proxies (CIDRs or IPs) whose X-Forwarded-For and X-Real-IP headers are trusted
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+
+	// Bearer token protecting /admin endpoints (disabled when empty), required
+	// outside dev and when authentication is required
+	AdminToken string `yaml:"admin_token" env:"ADMIN_TOKEN"`
+
+	// How long serialized task lists and the rendered task list page are
+	// cached; changes made through this instance invalidate them immediately
+	// (0 disables caching)
+	ResponseCacheTTL time.Duration `yaml:"response_cache_ttl" env:"RESPONSE_CACHE_TTL"`
+
+	// Default page size of GET /api/tasks (0 lists all tasks) and the largest
+	// page clients may ask for with the limit parameter (0 means no cap)
+	ListLimit    int `yaml:"list_limit" env:"LIST_LIMIT"`
+	MaxListLimit int `yaml:"max_list_limit" env:"MAX_LIST_LIMIT"`
+
+	// Shortest and longest task titles, in characters
+	TitleMinLength int `yaml:"title_min_length" env:"TITLE_MIN_LENGTH"`
+	TitleMaxLength int `yaml:"title_max_length" env:"TITLE_MAX_LENGTH"`
+
+	// Colors tasks can have, as name=#light/#dark hex codes of the variants
+	// shown on light and dark backgrounds, such as "red=#dc3545/#ea868f";
+	// empty for those of palette.Default
+	Palette []string `yaml:"palette" env:"PALETTE"`
+
+	// Priority (emoticon or alias) and color (name or hex code of the
+	// palette) of tasks created without one, empty for the built-in 📋 and
+	// grey, and how long after their creation tasks created without a due
+	// date are due (0 for none)
+	DefaultPriority string        `yaml:"default_priority" env:"DEFAULT_PRIORITY"`
+	DefaultColor    string        `yaml:"default_color" env:"DEFAULT_COLOR"`
+	DefaultDueIn    time.Duration `yaml:"default_due_in" env:"DEFAULT_DUE_IN"`
+
+	// Whether tasks may not be created with the title of an open task,
+	// compared ignoring case
+	UniqueTitles bool `yaml:"unique_titles" env:"UNIQUE_TITLES"`
+
+	// Work in progress limits on the open tasks, such as "🔥=5" for a
+	// priority or "open=20" for all of them, and whether changes going over
+	// them are rejected (reject) or only answered with a warning (warn)
+	WIPLimits []string `yaml:"wip_limits" env:"WIP_LIMITS"`
+	WIPMode   string   `yaml:"wip_mode" env:"WIP_MODE"`
+
+	// Open tasks each user may have created at a time; 0 means no quota
+	UserTaskQuota int `yaml:"user_task_quota" env:"USER_TASK_QUOTA"`
+
+	// Days an open task may go unchanged before it counts as stale
+	StaleAfterDays int `yaml:"stale_after_days" env:"STALE_AFTER_DAYS"`
+
+	// JSON file holding users, API keys and sessions (kept in memory when empty),
+	// whether API requests must carry an API key or session token and pages
+	// a signed-in user, and how long sessions signed in on the login page last
+	AuthFile     string        `yaml:"auth_file" env:"AUTH_FILE"`
+	AuthRequired bool          `yaml:"auth_required" env:"AUTH_REQUIRED"`
+	SessionTTL   time.Duration `yaml:"session_ttl" env:"SESSION_TTL"`
+
+	// Whether tasks are served as a CalDAV calendar under /caldav/, the JSON
+	// file keeping the names clients gave to the tasks they created and the
+	// UIDs of imported tasks (kept in memory when empty) and the IANA time
+	// zone of dates without one in those tasks (the system's when empty)
+	CalDAVEnabled  bool   `yaml:"caldav_enabled" env:"CALDAV_ENABLED"`
+	CalDAVLinkFile string `yaml:"caldav_link_file" env:"CALDAV_LINK_FILE"`
+	CalDAVTimezone string `yaml:"caldav_timezone" env:"CALDAV_TIMEZONE"`
+
+	// Per-client API rate limit in requests per second (0 disables) and burst size
+	RateLimit float64 `yaml:"rate_limit" env:"RATE_LIMIT"`
+	RateBurst int     `yaml:"rate_burst" env:"RATE_BURST"`
+
+	// Maximum number of page and API requests handled concurrently (0 disables)
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests" env:"MAX_CONCURRENT_REQUESTS"`
+
+	// Origins allowed to call the API cross-origin ("*" allows any)
+	CORSAllowedOrigins []string `yaml:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+
+	// Minimum response size in bytes for gzip compression (0 disables)
+	CompressionMinSize int `yaml:"compression_min_size" env:"COMPRESSION_MIN_SIZE"`
+
+	// Requests slower than this are logged with a timing breakdown (0 disables)
+	SlowRequestThreshold time.Duration `yaml:"slow_request_threshold" env:"SLOW_REQUEST_THRESHOLD"`
+
+	// SMTP server used for email notifications (disabled when SMTPHost is
+	// empty); credentials are optional
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+	SMTPFrom     string `yaml:"smtp_from" env:"SMTP_FROM"`
+
+	// Recipients of email notifications and the directory with templates
+	// overriding the built-in ones (<event>.tmpl, e.g. overdue.tmpl)
+	NotifyEmailTo     []string `yaml:"notify_email_to" env:"NOTIFY_EMAIL_TO"`
+	NotifyTemplateDir string   `yaml:"notify_template_dir" env:"NOTIFY_TEMPLATE_DIR"`
+
+	// How long before its due date a task counts as due soon and the cron
+	// expression of when tasks are checked
+	NotifyDueSoon  time.Duration `yaml:"notify_due_soon" env:"NOTIFY_DUE_SOON"`
+	NotifySchedule string        `yaml:"notify_schedule" env:"NOTIFY_SCHEDULE"`
+
+	// Local time ("15:04") at which the daily digest is emailed to every
+	// user with an email address who did not opt out (disabled when empty),
+	// in the IANA time zone DigestTimezone (the system's when empty)
+	DigestTime     string `yaml:"digest_time" env:"DIGEST_TIME"`
+	DigestTimezone string `yaml:"digest_timezone" env:"DIGEST_TIMEZONE"`
+
+	// Rules applied to tasks overdue for longer than a threshold, checked on
+	// the notification schedule: "24h:⭐>🔥" bumps the priority, "72h:notify"
+	// notifies EscalationEmailTo (every channel's recipients when empty)
+	EscalationRules   []string `yaml:"escalation_rules" env:"ESCALATION_RULES"`
+	EscalationEmailTo string   `yaml:"escalation_email_to" env:"ESCALATION_EMAIL_TO"`
+
+	// JSON file recording the notifications sent per task, so restarts do
+	// not send them again (in memory when empty)
+	NotifyStateFile string `yaml:"notify_state_file" env:"NOTIFY_STATE_FILE"`
+
+	// VAPID key pair (base64url, see the vapid-keys command) and contact
+	// (mailto: or https: URL) for web push notifications, disabled without
+	// keys, and the JSON file keeping the subscriptions (in memory when empty)
+	VAPIDPublicKey       string `yaml:"vapid_public_key" env:"VAPID_PUBLIC_KEY"`
+	VAPIDPrivateKey      string `yaml:"vapid_private_key" env:"VAPID_PRIVATE_KEY"`
+	VAPIDSubject         string `yaml:"vapid_subject" env:"VAPID_SUBJECT"`
+	PushSubscriptionFile string `yaml:"push_subscriptions_file" env:"PUSH_SUBSCRIPTIONS_FILE"`
+
+	// ntfy topic URL, or any endpoint accepting a plain text POST, that the
+	// events NtfyEvents are published to (disabled when empty), with an
+	// optional access token
+	NtfyURL    string   `yaml:"ntfy_url" env:"NTFY_URL"`
+	NtfyEvents []string `yaml:"ntfy_events" env:"NTFY_EVENTS"`
+	NtfyToken  string   `yaml:"ntfy_token" env:"NTFY_TOKEN"`
+
+	// Discord incoming webhook URL, which holds its token, that the events
+	// DiscordEvents are posted to (disabled when empty)
+	DiscordWebhookURL string   `yaml:"discord_webhook_url" env:"DISCORD_WEBHOOK_URL"`
+	DiscordEvents     []string `yaml:"discord_events" env:"DISCORD_EVENTS"`
+
+	// Microsoft Teams incoming webhook URL, which holds its signature, that
+	// the events TeamsEvents are posted to (disabled when empty)
+	TeamsWebhookURL string   `yaml:"teams_webhook_url" env:"TEAMS_WEBHOOK_URL"`
+	TeamsEvents     []string `yaml:"teams_events" env:"TEAMS_EVENTS"`
+
+	// Background jobs: workers running them, jobs that may wait before new
+	// ones are refused, and attempts per job before it is dead-lettered,
+	// with a backoff doubling from JobRetryBackoff up to JobMaxBackoff
+	JobWorkers      int           `yaml:"job_workers" env:"JOB_WORKERS"`
+	JobQueueSize    int           `yaml:"job_queue_size" env:"JOB_QUEUE_SIZE"`
+	JobMaxAttempts  int           `yaml:"job_max_attempts" env:"JOB_MAX_ATTEMPTS"`
+	JobRetryBackoff time.Duration `yaml:"job_retry_backoff" env:"JOB_RETRY_BACKOFF"`
+	JobMaxBackoff   time.Duration `yaml:"job_max_backoff" env:"JOB_MAX_BACKOFF"`
+
+	// Sinks task events are published to from the outbox of the file, sqlite
+	// or postgres store: webhooks receiving a POST per event, signed with
+	// EventWebhookSecret when set, and a NATS server publishing them under
+	// EventNATSSubject. The outbox is polled every EventRelayInterval.
+	EventWebhookURLs   []string      `yaml:"event_webhook_urls" env:"EVENT_WEBHOOK_URLS"`
+	EventWebhookSecret string        `yaml:"event_webhook_secret" env:"EVENT_WEBHOOK_SECRET"`
+	EventNATSURL       string        `yaml:"event_nats_url" env:"EVENT_NATS_URL"`
+	EventNATSSubject   string        `yaml:"event_nats_subject" env:"EVENT_NATS_SUBJECT"`
+	EventRelayInterval time.Duration `yaml:"event_relay_interval" env:"EVENT_RELAY_INTERVAL"`
+
+	// Webhook deliveries that fail are retried up to EventWebhookMaxAttempts
+	// attempts, with a backoff doubling from EventWebhookRetryBackoff up to
+	// EventWebhookMaxBackoff, and then dead-lettered. The retry state and
+	// dead letters are kept in EventWebhookStateFile (in memory when empty)
+	EventWebhookMaxAttempts  int           `yaml:"event_webhook_max_attempts" env:"EVENT_WEBHOOK_MAX_ATTEMPTS"`
+	EventWebhookRetryBackoff time.Duration `yaml:"event_webhook_retry_backoff" env:"EVENT_WEBHOOK_RETRY_BACKOFF"`
+	EventWebhookMaxBackoff   time.Duration `yaml:"event_webhook_max_backoff" env:"EVENT_WEBHOOK_MAX_BACKOFF"`
+	EventWebhookStateFile    string        `yaml:"event_webhook_state_file" env:"EVENT_WEBHOOK_STATE_FILE"`
+
+	// The last EventHistorySize relayed events are kept for replays (0
+	// disables replays), in EventHistoryFile (in memory when empty)
+	EventHistorySize int    `yaml:"event_history_size" env:"EVENT_HISTORY_SIZE"`
+	EventHistoryFile string `yaml:"event_history_file" env:"EVENT_HISTORY_FILE"`
+
+	// Tasks completed more than ArchiveAfterDays days ago are moved to
+	// ArchiveFile, and purged from it after ArchiveRetentionDays days, at the
+	// times of the cron expression ArchiveSchedule (0 disables either); set
+	// per environment with profiles
+	ArchiveAfterDays     int    `yaml:"archive_after_days" env:"ARCHIVE_AFTER_DAYS"`
+	ArchiveRetentionDays int    `yaml:"archive_retention_days" env:"ARCHIVE_RETENTION_DAYS"`
+	ArchiveSchedule      string `yaml:"archive_schedule" env:"ARCHIVE_SCHEDULE"`
+	ArchiveFile          string `yaml:"archive_file" env:"ARCHIVE_FILE"`
+
+	// Timeout for calls to external systems
+	OutboundTimeout time.Duration `yaml:"outbound_timeout" env:"OUTBOUND_TIMEOUT"`
+
+	// How often the configuration file is checked for changes (0 disables reloading)
+	ConfigReloadInterval time.Duration `yaml:"config_reload_interval" env:"CONFIG_RELOAD_INTERVAL"`
+
+	// Whether every change is turned away with a 403 and the pages hide the
+	// controls making them, such as for sandbox demos
+	ReadOnly bool `yaml:"read_only" env:"READ_ONLY"`
+
+	// Whether changes are turned away with a 503 while tasks can still be
+	// read, the message they are answered with (a default one when empty)
+	// and the Retry-After they get; also toggled with PUT /admin/maintenance
+	Maintenance           bool          `yaml:"maintenance" env:"MAINTENANCE"`
+	MaintenanceMessage    string        `yaml:"maintenance_message" env:"MAINTENANCE_MESSAGE"`
+	MaintenanceRetryAfter time.Duration `yaml:"maintenance_retry_after" env:"MAINTENANCE_RETRY_AFTER"`
+
+	// Fault injection (non-prod only)
+	FaultLatency   time.Duration `yaml:"fault_latency" env:"FAULT_LATENCY"`
+	FaultErrorRate float64       `yaml:"fault_error_rate" env:"FAULT_ERROR_RATE"`
+
+	// Whether every API request is captured with its response for
+	// /admin/requests (non-prod only), the secret signing the capture header
+	// that has single requests captured (disabled when empty) and the
+	// number of captured requests kept
+	CaptureRequests   bool   `yaml:"capture_requests" env:"CAPTURE_REQUESTS"`
+	CaptureSecret     string `yaml:"capture_secret" env:"CAPTURE_SECRET"`
+	CaptureBufferSize int    `yaml:"capture_buffer_size" env:"CAPTURE_BUFFER_SIZE"`
+
+	// Pattern of JSON fixture files, such as fixtures/*.json, whose tasks
+	// are added at startup (dev only; empty disables)
+	Fixtures string `yaml:"fixtures" env:"FIXTURES"`
+}
+
+// ValidationError lists every problem found in a Configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the full configuration and reports all problems at once.
+func (c Configuration) Validate() error {
+	var problems []string
+
+	if !c.Environment.Valid() {
+		problems = append(problems, fmt.Sprintf("environment %q is not one of dev, stage, acc, sandbox, prod", c.Environment))
+	}
+
+	if _, err := zapcore.ParseLevel(c.LogLevel); err != nil {
+		problems = append(problems, fmt.Sprintf("log level %q is not one of debug, info, warn, error, dpanic, panic, fatal", c.LogLevel))
+	}
+
+	if c.LogFormat != "console" && c.LogFormat != "json" {
+		problems = append(problems, fmt.Sprintf("log format %q is not one of console, json", c.LogFormat))
+	}
+
+	if c.ShutdownTimeout < 0 {
+		problems = append(problems, "shutdown timeout cannot be negative")
+	}
+	if c.ResponseCacheTTL < 0 {
+		problems = append(problems, "response cache TTL cannot be negative")
+	}
+	if c.SessionTTL <= 0 {
+		problems = append(problems, "session TTL must be positive")
+	}
+	if c.AdminToken == "" && (c.AuthRequired || c.Environment != Dev) {
+		problems = append(problems, "an admin token is required outside dev and when authentication is required")
+	}
+	if c.ListLimit < 0 || c.MaxListLimit < 0 {
+		problems = append(problems, "list limits cannot be negative")
+	} else if c.MaxListLimit > 0 && (c.ListLimit == 0 || c.ListLimit > c.MaxListLimit) {
+		problems = append(problems, fmt.Sprintf("list limit must be between 1 and the max list limit (%d)", c.MaxListLimit))
+	}
+	if c.TitleMinLength < 1 || c.TitleMaxLength < c.TitleMinLength {
+		problems = append(problems, "title lengths must be at least 1, and the maximum at least the minimum")
+	}
+	if len(c.Palette) > 0 {
+		if _, err := palette.Parse(c.Palette); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if _, err := service.NewTaskDefaults(c.DefaultPriority, c.DefaultColor, c.DefaultDueIn, c.Colors()); err != nil {
+		problems = append(problems, err.Error())
+	}
+	var wip service.WIPLimits
+	for _, s := range c.WIPLimits {
+		if err := service.ParseWIPLimit(&wip, s); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if c.UserTaskQuota < 0 {
+		problems = append(problems, "user task quota cannot be negative")
+	}
+	if c.StaleAfterDays < 1 {
+		problems = append(problems, "stale after days must be at least 1")
+	}
+	if len(c.WIPLimits) > 0 && c.WIPMode != "reject" && c.WIPMode != "warn" {
+		problems = append(problems, fmt.Sprintf("WIP mode %q is not one of reject, warn", c.WIPMode))
+	}
+
+	if c.Listen != "" {
+		if _, _, err := ParseListen(c.Listen); err != nil {
+			problems = append(problems, err.Error())
+		}
+	} else if port, err := strconv.Atoi(c.HTTPPort); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("HTTP port %q must be a number between 1 and 65535", c.HTTPPort))
+	}
+
+	if c.AdminListen != "" {
+		if network, address, err := ParseListen(c.AdminListen); err != nil {
+			problems = append(problems, err.Error())
+		} else if publicNetwork, publicAddress := c.ListenAddress(); network == publicNetwork && address == publicAddress {
+			problems = append(problems, fmt.Sprintf("admin listen address %q must differ from the public listen address", c.AdminListen))
+		}
+	}
+
+	timeouts := map[string]time.Duration{
+		"read":        c.HTTPReadTimeout,
+		"read header": c.HTTPReadHeaderTimeout,
+		"write":       c.HTTPWriteTimeout,
+		"idle":        c.HTTPIdleTimeout,
+	}
+	for _, name := range []string{"read", "read header", "write", "idle"} {
+		if timeouts[name] < 0 {
+			problems = append(problems, fmt.Sprintf("HTTP %s timeout cannot be negative", name))
+		}
+	}
+
+	if !slices.Contains(store.Backends, c.Store) {
+		problems = append(problems, fmt.Sprintf("store %q is not one of %s", c.Store, strings.Join(store.Backends, ", ")))
+	} else if c.Store != store.BackendMemory && c.StoreDSN == "" {
+		problems = append(problems, fmt.Sprintf("store DSN is required for the %s store", c.Store))
+	} else if err := store.ValidateDSN(c.Store, c.StoreDSN); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for i, name := range c.Workspaces {
+		switch {
+		case !store.ValidWorkspaceName(name):
+			problems = append(problems, fmt.Sprintf("workspace name %q must be up to 63 lowercase letters, digits and dashes, starting with a letter or digit", name))
+		case name == store.DefaultWorkspace:
+			problems = append(problems, fmt.Sprintf("workspace %q is always served and cannot be listed", name))
+		case slices.Contains(c.Workspaces[:i], name):
+			problems = append(problems, fmt.Sprintf("workspace %q is listed twice", name))
+		}
+	}
+	plans := make(map[string]bool)
+	for _, s := range c.Plans {
+		plan, err := service.ParsePlan(s)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if plans[plan.Name] {
+			problems = append(problems, fmt.Sprintf("plan %q is defined twice", plan.Name))
+		}
+		plans[plan.Name] = true
+	}
+	for _, s := range c.WorkspacePlans {
+		workspace, plan, _ := strings.Cut(s, "=")
+		if workspace != store.DefaultWorkspace && !slices.Contains(c.Workspaces, workspace) {
+			problems = append(problems, fmt.Sprintf("workspace plan %q is not for the default workspace or one of the workspaces", s))
+		} else if !plans[plan] {
+			problems = append(problems, fmt.Sprintf("workspace plan %q names an undefined plan", s))
+		}
+	}
+	if len(c.Workspaces) > 0 && c.Store == store.BackendPostgres {
+		problems = append(problems, fmt.Sprintf("workspaces need a store other than %s", c.Store))
+	}
+	if !slices.Contains(store.IDStrategies, c.IDStrategy) {
+		problems = append(problems, fmt.Sprintf("ID strategy %q is not one of %s", c.IDStrategy, strings.Join(store.IDStrategies, ", ")))
+	} else if c.IDStrategy != store.IDSequential && c.Store != store.BackendMemory && c.Store != store.BackendFile {
+		problems = append(problems, fmt.Sprintf("ID strategy %s needs the memory or file store, not %s", c.IDStrategy, c.Store))
+	}
+	if c.MemorySoftLimitMB < 0 || c.MemoryHardLimitMB < 0 {
+		problems = append(problems, "memory store limits cannot be negative")
+	} else if c.MemoryHardLimitMB > 0 && c.MemorySoftLimitMB > c.MemoryHardLimitMB {
+		problems = append(problems, fmt.Sprintf("memory store soft limit cannot exceed the hard limit (%d MiB)", c.MemoryHardLimitMB))
+	}
+	if c.TombstoneRetention < 0 {
+		problems = append(problems, "tombstone retention cannot be negative")
+	}
+	if c.DBMaxOpenConns < 0 || c.DBMaxIdleConns < 0 || c.DBConnMaxLifetime < 0 || c.DBConnMaxIdleTime < 0 {
+		problems = append(problems, "database pool settings cannot be negative")
+	} else if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		problems = append(problems, fmt.Sprintf("database max idle connections cannot exceed max open connections (%d)", c.DBMaxOpenConns))
+	}
+
+	if _, err := middleware.ParseTrustedProxies(c.TrustedProxies); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if c.SentryDSN != "" {
+		if err := errorreport.ValidateDSN(c.SentryDSN); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if c.RateLimit < 0 {
+		problems = append(problems, "rate limit cannot be negative")
+	}
+	if c.RateLimit > 0 && c.RateBurst < 1 {
+		problems = append(problems, "rate burst must be at least 1 when rate limiting is enabled")
+	}
+
+	if c.MaxConcurrentRequests < 0 {
+		problems = append(problems, "maximum concurrent requests cannot be negative")
+	}
+
+	if c.CompressionMinSize < 0 {
+		problems = append(problems, "compression minimum size cannot be negative")
+	}
+
+	if c.SlowRequestThreshold < 0 {
+		problems = append(problems, "slow request threshold cannot be negative")
+	}
+
+	if c.SMTPHost != "" {
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			problems = append(problems, fmt.Sprintf("SMTP port %d must be between 1 and 65535", c.SMTPPort))
+		}
+		if c.SMTPFrom == "" || len(c.NotifyEmailTo) == 0 {
+			problems = append(problems, "SMTP sender and notification recipients are required when an SMTP host is set")
+		}
+	}
+	if c.VAPIDPublicKey != "" || c.VAPIDPrivateKey != "" {
+		if c.VAPIDPublicKey == "" || c.VAPIDPrivateKey == "" {
+			problems = append(problems, "VAPID public and private keys must be set together")
+		} else if _, err := notify.ParseVAPIDKeys(c.VAPIDPublicKey, c.VAPIDPrivateKey); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if !strings.HasPrefix(c.VAPIDSubject, "mailto:") && !strings.HasPrefix(c.VAPIDSubject, "https://") {
+			problems = append(problems, fmt.Sprintf("VAPID subject %q must be a mailto: or https: URL", c.VAPIDSubject))
+		}
+	}
+	if c.NtfyURL != "" {
+		if _, err := notify.NewNtfy(c.NtfyURL, "", nil, nil); err != nil {
+			problems = append(problems, err.Error())
+		}
+		problems = append(problems, checkChannelEvents("ntfy", c.NtfyEvents)...)
+	}
+	if c.DiscordWebhookURL != "" {
+		if _, err := notify.NewDiscord(c.DiscordWebhookURL, nil, nil); err != nil {
+			problems = append(problems, err.Error())
+		}
+		problems = append(problems, checkChannelEvents("Discord", c.DiscordEvents)...)
+	}
+	if c.TeamsWebhookURL != "" {
+		if _, err := notify.NewTeams(c.TeamsWebhookURL, nil, nil); err != nil {
+			problems = append(problems, err.Error())
+		}
+		problems = append(problems, checkChannelEvents("Teams", c.TeamsEvents)...)
+	}
+	if c.NotificationsEnabled() || c.EscalationEnabled() {
+		if c.NotifyDueSoon < 0 {
+			problems = append(problems, "notification due soon window cannot be negative")
+		}
+		if _, err := cron.Parse(c.NotifySchedule); err != nil {
+			problems = append(problems, "notification schedule: "+err.Error())
+		}
+	}
+
+	for _, s := range c.EscalationRules {
+		rule, err := notify.ParseEscalationRule(s)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if rule.Notifies() && !c.NotificationsEnabled() {
+			problems = append(problems, fmt.Sprintf("escalation rule %q needs email or web push notifications", s))
+		}
+	}
+	if c.EscalationEmailTo != "" {
+		if _, err := mail.ParseAddress(c.EscalationEmailTo); err != nil {
+			problems = append(problems, fmt.Sprintf("escalation email address %q is invalid", c.EscalationEmailTo))
+		}
+		if c.SMTPHost == "" {
+			problems = append(problems, "the escalation email address needs an SMTP host")
+		}
+	}
+
+	if c.DigestTime != "" {
+		if _, err := time.Parse("15:04", c.DigestTime); err != nil {
+			problems = append(problems, fmt.Sprintf("digest time %q must look like 15:04", c.DigestTime))
+		}
+		if c.SMTPHost == "" {
+			problems = append(problems, "the daily digest needs an SMTP host")
+		}
+	}
+	if c.DigestTimezone != "" {
+		if _, err := time.LoadLocation(c.DigestTimezone); err != nil {
+			problems = append(problems, fmt.Sprintf("digest time zone %q is unknown", c.DigestTimezone))
+		}
+	}
+	if c.CalDAVTimezone != "" {
+		if _, err := time.LoadLocation(c.CalDAVTimezone); err != nil {
+			problems = append(problems, fmt.Sprintf("CalDAV time zone %q is unknown", c.CalDAVTimezone))
+		}
+	}
+
+	if c.JobWorkers < 1 || c.JobQueueSize < 1 || c.JobMaxAttempts < 1 {
+		problems = append(problems, "job workers, queue size and max attempts must be at least 1")
+	}
+	if c.JobRetryBackoff < 0 || c.JobMaxBackoff < 0 {
+		problems = append(problems, "job retry backoff cannot be negative")
+	}
+
+	if c.EventsEnabled() {
+		if c.Store != store.BackendFile && c.Store != store.BackendSQLite && c.Store != store.BackendPostgres {
+			problems = append(problems, fmt.Sprintf("event delivery needs the file, sqlite or postgres store, not %s", c.Store))
+		}
+		for _, hook := range c.EventWebhookURLs {
+			if u, err := url.Parse(hook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				problems = append(problems, fmt.Sprintf("event webhook %q must be an http or https URL", hook))
+			}
+		}
+		if c.EventNATSURL != "" {
+			if _, err := events.NewNATS(c.EventNATSURL, c.EventNATSSubject); err != nil {
+				problems = append(problems, "event NATS URL: "+err.Error())
+			}
+			if c.EventNATSSubject == "" || strings.ContainsAny(c.EventNATSSubject, " \t*>") {
+				problems = append(problems, fmt.Sprintf("event NATS subject %q must be a non-empty subject without spaces or wildcards", c.EventNATSSubject))
+			}
+		}
+		if c.EventRelayInterval <= 0 {
+			problems = append(problems, "event relay interval must be positive")
+		}
+		if len(c.EventWebhookURLs) > 0 && c.EventWebhookMaxAttempts < 1 {
+			problems = append(problems, "event webhook max attempts must be at least 1")
+		}
+		if c.EventWebhookRetryBackoff < 0 || c.EventWebhookMaxBackoff < 0 {
+			problems = append(problems, "event webhook retry backoff cannot be negative")
+		}
+		if c.EventHistorySize < 0 {
+			problems = append(problems, "event history size cannot be negative")
+		}
+	}
+
+	if c.ArchiveAfterDays < 0 || c.ArchiveRetentionDays < 0 {
+		problems = append(problems, "archive after and retention days cannot be negative")
+	}
+	if c.ArchiveEnabled() {
+		if c.ArchiveFile == "" {
+			problems = append(problems, "archive file is required when archival or retention is enabled")
+		}
+		if _, err := cron.Parse(c.ArchiveSchedule); err != nil {
+			problems = append(problems, "archive schedule: "+err.Error())
+		}
+	}
+
+	if c.OutboundTimeout <= 0 {
+		problems = append(problems, "outbound timeout must be positive")
+	}
+
+	if c.ConfigReloadInterval < 0 {
+		problems = append(problems, "configuration reload interval cannot be negative")
+	}
+
+	if c.MaintenanceRetryAfter < 0 {
+		problems = append(problems, "maintenance retry after cannot be negative")
+	}
+
+	if c.FaultLatency < 0 {
+		problems = append(problems, "fault latency cannot be negative")
+	}
+	if c.FaultErrorRate < 0 || c.FaultErrorRate > 1 {
+		problems = append(problems, fmt.Sprintf("fault error rate %v must be between 0 and 1", c.FaultErrorRate))
+	}
+	if c.Environment == Prod && (c.FaultLatency > 0 || c.FaultErrorRate > 0) {
+		problems = append(problems, "fault injection cannot be enabled in prod")
+	}
+	if c.CaptureRequests && c.Environment == Prod {
+		problems = append(problems, "capturing every request cannot be enabled in prod")
+	}
+	if c.CaptureSecret != "" && len(c.CaptureSecret) < 16 {
+		problems = append(problems, "capture secret must be at least 16 characters")
+	}
+	if c.CaptureBufferSize < 1 {
+		problems = append(problems, "capture buffer size must be at least 1")
+	}
+	if c.Fixtures != "" {
+		if c.Environment != Dev {
+			problems = append(problems, fmt.Sprintf("fixtures are only loaded in dev, not %s", c.Environment))
+		}
+		if _, err := filepath.Match(c.Fixtures, ""); err != nil {
+			problems = append(problems, fmt.Sprintf("fixture pattern %q is invalid", c.Fixtures))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ListenAddress returns the network and address the HTTP server listens on.
+func (c Configuration) ListenAddress() (network, address string) {
+	if c.Listen == "" {
+		return "tcp", net.JoinHostPort("", c.HTTPPort)
+	}
+	network, address, _ = ParseListen(c.Listen)
+	return network, address
+}
+
+// NotificationsEnabled reports whether any notification channel is configured.
+func (c Configuration) NotificationsEnabled() bool {
+	return c.SMTPHost != "" || c.VAPIDPrivateKey != "" || c.NtfyURL != "" || c.DiscordWebhookURL != "" || c.TeamsWebhookURL != ""
+}
+
+// checkChannelEvents returns the problems with the events selected for a
+// notification channel. The digest is left out, as it is emailed to each
+// user.
+func checkChannelEvents(channel string, names []string) []string {
+	events, err := notify.ParseEvents(names)
+	if err != nil {
+		return []string{channel + " events: " + err.Error()}
+	}
+	if slices.Contains(events, notify.EventDigest) {
+		return []string{channel + " events cannot include the digest, which is emailed to every user"}
+	}
+	return nil
+}
+
+// EscalationEnabled reports whether any escalation rule is configured.
+func (c Configuration) EscalationEnabled() bool {
+	return len(c.EscalationRules) > 0
+}
+
+// Colors returns the parsed palette, or palette.Default when none is
+// configured.
+func (c Configuration) Colors() *palette.Palette {
+	if len(c.Palette) == 0 {
+		return palette.Default()
+	}
+	p, err := palette.Parse(c.Palette)
+	if err != nil {
+		return palette.Default() // Errors are reported by Validate
+	}
+	return p
+}
+
+// TaskDefaults returns the canonical defaults of new tasks.
+func (c Configuration) TaskDefaults() service.TaskDefaults {
+	d, _ := service.NewTaskDefaults(c.DefaultPriority, c.DefaultColor, c.DefaultDueIn, c.Colors()) // Errors are reported by Validate
+	return d
+}
+
+// WIP returns the parsed WIP limits.
+func (c Configuration) WIP() service.WIPLimits {
+	var limits service.WIPLimits
+	for _, s := range c.WIPLimits {
+		service.ParseWIPLimit(&limits, s) // Errors are reported by Validate
+	}
+	return limits
+}
+
+// PlanOf returns the plan of workspace, which is unlimited when it has none.
+func (c Configuration) PlanOf(workspace string) service.Plan {
+	for _, s := range c.WorkspacePlans {
+		if ws, name, _ := strings.Cut(s, "="); ws == workspace {
+			for _, s := range c.Plans {
+				if plan, err := service.ParsePlan(s); err == nil && plan.Name == name {
+					return plan
+				}
+			}
+		}
+	}
+	return service.Plan{}
+}
+
+// Escalations returns the parsed escalation rules.
+func (c Configuration) Escalations() []notify.EscalationRule {
+	var rules []notify.EscalationRule
+	for _, s := range c.EscalationRules {
+		if rule, err := notify.ParseEscalationRule(s); err == nil { // Otherwise reported by Validate
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// ArchiveEnabled reports whether completed tasks are archived or archived
+// tasks purged.
+func (c Configuration) ArchiveEnabled() bool {
+	return c.ArchiveAfterDays > 0 || c.ArchiveRetentionDays > 0
+}
+
+// DigestLocation returns the time zone of the daily digest.
+func (c Configuration) DigestLocation() *time.Location {
+	return location(c.DigestTimezone)
+}
+
+// CalDAVLocation returns the time zone of dates without one in tasks
+// uploaded by CalDAV clients or imported.
+func (c Configuration) CalDAVLocation() *time.Location {
+	return location(c.CalDAVTimezone)
+}
+
+// location loads the IANA time zone name, falling back to the system's.
+func location(name string) *time.Location {
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local // Reported by Validate
+	}
+	return loc
+}
+
+// EventsEnabled reports whether any sink for task events is configured.
+func (c Configuration) EventsEnabled() bool {
+	return len(c.EventWebhookURLs) > 0 || c.EventNATSURL != ""
+}
+
+// RetryPolicy returns the default retry policy of background jobs.
+func (c Configuration) RetryPolicy() jobs.RetryPolicy {
+	return jobs.RetryPolicy{
+		MaxAttempts: c.JobMaxAttempts,
+		Backoff:     c.JobRetryBackoff,
+		MaxBackoff:  c.JobMaxBackoff,
+	}
+}
+
+// WebhookRetryPolicy returns the retry policy of webhook deliveries.
+func (c Configuration) WebhookRetryPolicy() jobs.RetryPolicy {
+	return jobs.RetryPolicy{
+		MaxAttempts: c.EventWebhookMaxAttempts,
+		Backoff:     c.EventWebhookRetryBackoff,
+		MaxBackoff:  c.EventWebhookMaxBackoff,
+	}
+}
+
+// MaintenanceSettings returns the maintenance mode the configuration starts in.
+func (c Configuration) MaintenanceSettings() maintenance.Settings {
+	return maintenance.Settings{
+		Enabled:    c.Maintenance,
+		Message:    c.MaintenanceMessage,
+		RetryAfter: c.MaintenanceRetryAfter,
+	}
+}
+
+// CaptureSettings returns the settings of the capture of API requests.
+func (c Configuration) CaptureSettings() capture.Settings {
+	return capture.Settings{
+		All:    c.CaptureRequests,
+		Secret: c.CaptureSecret,
+		Size:   c.CaptureBufferSize,
+	}
+}
+
+// CaptureEnabled reports whether any API request can be captured.
+func (c Configuration) CaptureEnabled() bool {
+	return c.CaptureRequests || c.CaptureSecret != ""
+}
+
+// PoolConfig returns the connection pool settings of SQL stores.
+func (c Configuration) PoolConfig() store.PoolConfig {
+	return store.PoolConfig{
+		MaxOpenConns:    c.DBMaxOpenConns,
+		MaxIdleConns:    c.DBMaxIdleConns,
+		ConnMaxLifetime: c.DBConnMaxLifetime,
+		ConnMaxIdleTime: c.DBConnMaxIdleTime,
+	}
+}
+
+// ParseListen splits a listen address of the form "tcp:<host:port>" or
+// "unix:<path>" into its network and address.
+func ParseListen(listen string) (network, address string, err error) {
+	network, address, ok := strings.Cut(listen, ":")
+	if !ok {
+		return "", "", fmt.Errorf("listen address %q must be tcp:<host:port> or unix:<path>", listen)
+	}
+
+	switch network {
+	case "tcp":
+		if _, port, err := net.SplitHostPort(address); err != nil || port == "" {
+			return "", "", fmt.Errorf("listen address %q must have the form tcp:<host:port>", listen)
+		}
+	case "unix":
+		if address == "" {
+			return "", "", fmt.Errorf("listen address %q must have the form unix:<path>", listen)
+		}
+	default:
+		return "", "", fmt.Errorf("listen address %q has unsupported network %q (use tcp or unix)", listen, network)
+	}
+
+	return network, address, nil
 }
@@ -0,0 +1,271 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfiguration_Validate(t *testing.T) {
+	valid := Configuration{Environment: Dev, LogLevel: "info", LogFormat: "json", HTTPPort: "8080", Store: "memory", Workspaces: []string{"team"}, IDStrategy: "ulid",
+		Plans: []string{"free=5/20/100"}, WorkspacePlans: []string{"team=free"}, OutboundTimeout: time.Second,
+		SessionTTL: time.Hour, TitleMinLength: 1, TitleMaxLength: 255, StaleAfterDays: 14, JobWorkers: 1, JobQueueSize: 1, JobMaxAttempts: 1, CaptureBufferSize: 1}
+
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid configuration, got %v", err)
+	}
+
+	invalid := Configuration{
+		Environment:    "production",
+		LogLevel:       "verbose",
+		LogFormat:      "json",
+		Store:          "memory",
+		Workspaces:     []string{"default"},
+		WorkspacePlans: []string{"team=free"},
+		IDStrategy:     "random",
+		HTTPPort:       "99999",
+		SentryDSN:      "not a dsn",
+		Palette:        []string{"red=#dc3545", "Blue=#0d6efd"},
+
+		OutboundTimeout: time.Second,
+		SessionTTL:      time.Hour,
+		TitleMinLength:  1,
+		TitleMaxLength:  255,
+		StaleAfterDays:  14,
+		JobWorkers:      1,
+		JobQueueSize:    1,
+		JobMaxAttempts:  1,
+
+		CaptureBufferSize: 1,
+	}
+
+	err := invalid.Validate()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(validationErr.Problems) != 9 {
+		t.Errorf("expected 9 problems, got %d: %v", len(validationErr.Problems), validationErr.Problems)
+	}
+}
+
+func TestConfiguration_ValidateAdminToken(t *testing.T) {
+	tests := []struct {
+		env          Environment
+		authRequired bool
+		token        string
+		wantErr      bool
+	}{
+		{Dev, false, "", false},
+		{Dev, true, "", true},
+		{Dev, true, "secret", false},
+		{Stage, false, "", true},
+		{Prod, false, "", true},
+		{Prod, false, "secret", false},
+	}
+	for _, tt := range tests {
+		c := DefaultConfiguration(tt.env)
+		c.AuthRequired = tt.authRequired
+		c.AdminToken = tt.token
+		if err := c.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s, auth required %v, token %q: expected error %v, got %v", tt.env, tt.authRequired, tt.token, tt.wantErr, err)
+		}
+	}
+}
+
+func TestConfiguration_ValidateStoreDSN(t *testing.T) {
+	tests := []struct {
+		store, dsn string
+		wantErr    bool
+	}{
+		{"postgres", "postgres://tasks@localhost/tasks", false},
+		{"postgres", "tasks@localhost", true},
+		{"redis", "redis://localhost:6379/0", false},
+		{"redis", "localhost:6379", true},
+		{"sqlite", "tasks.db?_pragma=%zz", true},
+	}
+	for _, tt := range tests {
+		c := DefaultConfiguration(Dev)
+		c.Store, c.StoreDSN = tt.store, tt.dsn
+		err := c.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s store with DSN %q: expected error %v, got %v", tt.store, tt.dsn, tt.wantErr, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), tt.store+" DSN") {
+			t.Errorf("%s store with DSN %q: expected the DSN to be reported, got %v", tt.store, tt.dsn, err)
+		}
+	}
+}
+
+func TestConfiguration_ValidatePool(t *testing.T) {
+	tests := []struct {
+		open, idle         int
+		lifetime, idleTime time.Duration
+		wantErr            bool
+	}{
+		{25, 10, 30 * time.Minute, 5 * time.Minute, false},
+		{0, 50, 0, 0, false}, // Unlimited open connections
+		{5, 5, 0, 0, false},
+		{5, 6, 0, 0, true},
+		{-1, 0, 0, 0, true},
+		{5, -1, 0, 0, true},
+		{5, 2, -time.Second, 0, true},
+		{5, 2, 0, -time.Second, true},
+	}
+	for _, tt := range tests {
+		c := DefaultConfiguration(Dev)
+		c.DBMaxOpenConns, c.DBMaxIdleConns = tt.open, tt.idle
+		c.DBConnMaxLifetime, c.DBConnMaxIdleTime = tt.lifetime, tt.idleTime
+		if err := c.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: expected error %v, got %v", tt, tt.wantErr, err)
+		}
+	}
+
+	c := DefaultConfiguration(Prod)
+	pool := c.PoolConfig()
+	if pool.MaxOpenConns != 25 || pool.MaxIdleConns != 10 || pool.ConnMaxLifetime != 30*time.Minute || pool.ConnMaxIdleTime != 5*time.Minute {
+		t.Errorf("unexpected prod pool %+v", pool)
+	}
+	if pool := DefaultConfiguration(Dev).PoolConfig(); pool.MaxOpenConns != 5 || pool.MaxIdleConns != 2 {
+		t.Errorf("expected a smaller pool in dev, got %+v", pool)
+	}
+}
+
+func TestParseListen(t *testing.T) {
+	tests := []struct {
+		listen  string
+		network string
+		address string
+		wantErr bool
+	}{
+		{listen: "tcp::8080", network: "tcp", address: ":8080"},
+		{listen: "tcp:127.0.0.1:9000", network: "tcp", address: "127.0.0.1:9000"},
+		{listen: "unix:/run/ttm.sock", network: "unix", address: "/run/ttm.sock"},
+		{listen: "tcp:8080", wantErr: true},
+		{listen: "unix:", wantErr: true},
+		{listen: "udp::53", wantErr: true},
+		{listen: "8080", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		network, address, err := ParseListen(tt.listen)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseListen(%q): expected error", tt.listen)
+			}
+			continue
+		}
+		if err != nil || network != tt.network || address != tt.address {
+			t.Errorf("ParseListen(%q) = %q, %q, %v; want %q, %q", tt.listen, network, address, err, tt.network, tt.address)
+		}
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "log_level: debug\nhttp_write_timeout: 45s\ncors_allowed_origins:\n  - https://example.com\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := DefaultConfiguration(Dev)
+	if err := LoadConfigFile(path, Dev, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.LogLevel != "debug" || c.HTTPWriteTimeout != 45*time.Second || len(c.CORSAllowedOrigins) != 1 {
+		t.Errorf("file values not applied: %+v", c)
+	}
+	if c.HTTPPort != "8080" {
+		t.Errorf("expected default port to be kept, got %q", c.HTTPPort)
+	}
+
+	content = "log_level: info\nprofiles:\n  prod:\n    log_level: warn\n    shutdown_timeout: 5s\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c = DefaultConfiguration(Prod)
+	if err := LoadConfigFile(path, Prod, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.LogLevel != "warn" || c.ShutdownTimeout != 5*time.Second || c.LogFormat != "json" {
+		t.Errorf("prod profile not applied: %+v", c)
+	}
+	c = DefaultConfiguration(Dev)
+	if err := LoadConfigFile(path, Dev, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.LogLevel != "info" || c.LogFormat != "console" {
+		t.Errorf("expected base keys and dev defaults without a dev profile, got %+v", c)
+	}
+
+	if err := os.WriteFile(path, []byte("unknown_key: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfigFile(path, Dev, &c); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "# local settings\nexport TTM_LOG_LEVEL=debug\nTTM_HTTP_PORT=9000 # comment\nTTM_ADMIN_TOKEN=\"a b\"\nTTM_APP_ENV=prod\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TTM_HTTP_PORT", "8081")
+	for _, name := range []string{"TTM_LOG_LEVEL", "TTM_ADMIN_TOKEN", "TTM_APP_ENV"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	if err := LoadDotEnv(path); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"TTM_LOG_LEVEL": "debug", "TTM_HTTP_PORT": "8081", "TTM_ADMIN_TOKEN": "a b"} {
+		if got := os.Getenv(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, ok := os.LookupEnv("TTM_APP_ENV"); ok {
+		t.Error("expected the environment selector to be ignored")
+	}
+
+	if err := LoadDotEnv(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("expected a missing file to be ignored, got %v", err)
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("TTM_LOG_LEVEL", "warn")
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("HTTP_PORT", "9000")
+	t.Setenv("TTM_HTTP_WRITE_TIMEOUT", "1m")
+	t.Setenv("TTM_CORS_ALLOWED_ORIGINS", "https://a.example, https://b.example")
+
+	c := DefaultConfiguration(Dev)
+	if err := LoadEnv(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.LogLevel != "warn" {
+		t.Errorf("expected prefixed variable to win, got %q", c.LogLevel)
+	}
+	if c.HTTPPort != "9000" {
+		t.Errorf("expected unprefixed variable as fallback, got %q", c.HTTPPort)
+	}
+	if c.HTTPWriteTimeout != time.Minute || len(c.CORSAllowedOrigins) != 2 {
+		t.Errorf("values not parsed: %+v", c)
+	}
+
+	t.Setenv("TTM_RATE_BURST", "many")
+	var validationErr *ValidationError
+	if err := LoadEnv(&c); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError for malformed value, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfiguration returns the configuration used for env when neither
+// a configuration file, environment variables nor flags set a value. It is
+// the base defaults with the built-in profile of env applied.
+func DefaultConfiguration(env Environment) Configuration {
+	c := Configuration{
+		Environment:           env,
+		LogLevel:              "info",
+		LogFormat:             "json",
+		HTTPPort:              "8080",
+		HTTPReadTimeout:       15 * time.Second,
+		HTTPReadHeaderTimeout: 5 * time.Second,
+		HTTPWriteTimeout:      30 * time.Second,
+		HTTPIdleTimeout:       120 * time.Second,
+		ShutdownTimeout:       30 * time.Second,
+		Store:                 "memory",
+		IDStrategy:            "sequential",
+		MemorySoftLimitMB:     256,
+		MemoryHardLimitMB:     512,
+		TombstoneRetention:    30 * 24 * time.Hour,
+		StoreFailover:         true,
+		DBMaxOpenConns:        25,
+		DBMaxIdleConns:        10,
+		DBConnMaxLifetime:     30 * time.Minute,
+		DBConnMaxIdleTime:     5 * time.Minute,
+		ResponseCacheTTL:      5 * time.Second,
+		ListLimit:             100,
+		MaxListLimit:          1000,
+		TitleMinLength:        1,
+		TitleMaxLength:        255,
+		WIPMode:               "reject",
+		StaleAfterDays:        14,
+		SessionTTL:            7 * 24 * time.Hour,
+		RateBurst:             20,
+		CompressionMinSize:    1024,
+		SlowRequestThreshold:  time.Second,
+		SMTPPort:              587,
+		NotifyDueSoon:         24 * time.Hour,
+		NotifySchedule:        "*/5 * * * *",
+		NtfyEvents:            []string{"due_soon", "overdue", "escalated", "completed"},
+		DiscordEvents:         []string{"overdue", "escalated", "created", "completed"},
+		TeamsEvents:           []string{"created", "completed", "overdue"},
+		JobWorkers:            4,
+		JobQueueSize:          1000,
+		JobMaxAttempts:        5,
+		JobRetryBackoff:       time.Second,
+		JobMaxBackoff:         5 * time.Minute,
+		EventNATSSubject:      "tasks",
+		EventRelayInterval:    time.Second,
+		ArchiveSchedule:       "0 3 * * *",
+		OutboundTimeout:       10 * time.Second,
+		ConfigReloadInterval:  10 * time.Second,
+		CaptureBufferSize:     100,
+
+		// Retries of webhook deliveries spread over about an hour and a half.
+		EventWebhookMaxAttempts:  10,
+		EventWebhookRetryBackoff: 10 * time.Second,
+		EventWebhookMaxBackoff:   time.Hour,
+
+		// Enough events to catch up on a few hours of busy use.
+		EventHistorySize: 10000,
+	}
+
+	if env == Dev {
+		// Readable logs and instant shutdowns while developing.
+		c.LogFormat = "console"
+		c.ShutdownTimeout = 0
+		// A local database needs few connections.
+		c.DBMaxOpenConns = 5
+		c.DBMaxIdleConns = 2
+	}
+
+	return c
+}
+
+// configFile is the layout of a configuration file: the base configuration
+// plus optional per-environment overlays.
+type configFile struct {
+	Configuration `yaml:",inline"`
+	Profiles      map[Environment]yaml.Node `yaml:"profiles"`
+}
+
+// LoadConfigFile reads a YAML configuration file into c. The base keys are
+// applied first, then the keys of the profile for env, if the file has one.
+// Only the keys present in the file are changed; unknown keys are an error.
+func LoadConfigFile(path string, env Environment, c *Configuration) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to open configuration file: %w", err)
+	}
+
+	file := configFile{Configuration: *c}
+	if err := decodeStrict(content, &file); err != nil {
+		return fmt.Errorf("failed to parse configuration file %s: %w", path, err)
+	}
+	*c = file.Configuration
+
+	for name := range file.Profiles {
+		if !name.Valid() {
+			return fmt.Errorf("configuration file %s has a profile for unknown environment %q", path, name)
+		}
+	}
+
+	profile, ok := file.Profiles[env]
+	if !ok {
+		return nil
+	}
+	overlay, err := yaml.Marshal(&profile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s profile of configuration file %s: %w", env, path, err)
+	}
+	if err := decodeStrict(overlay, c); err != nil {
+		return fmt.Errorf("failed to parse %s profile of configuration file %s: %w", env, path, err)
+	}
+	if c.Environment != file.Environment {
+		return fmt.Errorf("the %s profile of configuration file %s cannot change the environment", env, path)
+	}
+	return nil
+}
+
+// decodeStrict decodes YAML content into out, rejecting unknown keys.
+func decodeStrict(content []byte, out any) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DotEnvFile holds local development variables. It is only read in Dev.
+const DotEnvFile = ".env"
+
+// LoadDotEnv sets the variables defined in the file at path that are not
+// set in the process environment yet, so exported variables keep
+// precedence. Lines have the form KEY=value, optionally prefixed with
+// "export"; values may be single- or double-quoted and # starts a comment.
+// The environment selector (APP_ENV) is ignored, because the file is only
+// read once the environment is known. A missing file is not an error.
+func LoadDotEnv(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("%s:%d: expected KEY=value", path, n)
+		}
+		value, err := parseDotEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+
+		if key == "APP_ENV" || key == EnvPrefix+"APP_ENV" {
+			continue
+		}
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseDotEnvValue unquotes value or strips a trailing comment from it.
+// Double-quoted values support Go escape sequences such as \n.
+func parseDotEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		return strconv.Unquote(value)
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", errors.New("unterminated single-quoted value")
+		}
+		return value[1 : len(value)-1], nil
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value, nil
+	}
+}
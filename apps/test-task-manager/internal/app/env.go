@@ -0,0 +1,108 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is prepended to the env tag of every Configuration field.
+const EnvPrefix = "TTM_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// LookupEnv returns the value of the environment variable EnvPrefix+name.
+// The unprefixed name is still accepted for compatibility with older
+// deployments; the prefixed variable wins when both are set.
+func LookupEnv(name string) (string, bool) {
+	if value, ok := os.LookupEnv(EnvPrefix + name); ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+// LoadEnv overrides the fields of c that have an env tag with the value of
+// the matching environment variable (see LookupEnv). Lists are comma-separated
+// and durations use time.ParseDuration syntax. All malformed values are
+// reported in a single ValidationError.
+func LoadEnv(c *Configuration) error {
+	var problems []string
+
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, ok := LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(v.Field(i), value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s%s: %v", EnvPrefix, name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// setField parses value into field according to the field's type.
+func setField(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(i))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", field.Type())
+		}
+		field.Set(reflect.ValueOf(SplitList(value)))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// SplitList splits a comma-separated list, dropping empty entries.
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
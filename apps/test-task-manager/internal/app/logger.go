@@ -0,0 +1,32 @@
+package app
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newLogger builds the application logger around an atomic level,
+// so the level can be changed at runtime without a restart.
+// The configured level is expected to be validated already.
+func newLogger(c Configuration) (*zap.SugaredLogger, zap.AtomicLevel) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	if parsed, err := zapcore.ParseLevel(c.LogLevel); err == nil {
+		level.SetLevel(parsed)
+	}
+
+	cfg := zap.NewProductionConfig()
+	if c.LogFormat == "console" {
+		cfg = zap.NewDevelopmentConfig()
+	}
+	cfg.Level = level
+
+	logger, err := cfg.Build()
+	if err != nil {
+		// Only reachable with invalid output paths, which are not configurable.
+		panic(fmt.Sprintf("failed to build logger: %v", err))
+	}
+
+	return logger.Sugar(), level
+}
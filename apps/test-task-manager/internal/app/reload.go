@@ -0,0 +1,149 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+)
+
+// reloadable lists the configuration fields (by yaml key) that are applied
+// at runtime. Changes to any other field require a restart.
+var reloadable = map[string]bool{
+	"log_level":               true,
+	"rate_limit":              true,
+	"rate_burst":              true,
+	"fault_latency":           true,
+	"fault_error_rate":        true,
+	"maintenance":             true,
+	"maintenance_message":     true,
+	"maintenance_retry_after": true,
+}
+
+// WatchConfig polls the configuration file at path every interval and, when
+// it was modified, applies the configuration returned by load with Reload.
+// It returns a function that stops watching.
+func (a *App) WatchConfig(path string, interval time.Duration, load func() (Configuration, error)) (stop func()) {
+	done := make(chan struct{})
+	lastModified := modTime(path)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				modified := modTime(path)
+				if modified.Equal(lastModified) {
+					continue
+				}
+				lastModified = modified
+
+				next, err := load()
+				if err != nil {
+					a.logger.Errorw("failed to reload configuration, keeping current settings", "path", path, "error", err)
+					continue
+				}
+				if err := a.Reload(next); err != nil {
+					a.logger.Errorw("failed to reload configuration, keeping current settings", "path", path, "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// Reload validates next and applies the settings that can change at runtime:
+// log level, rate limits, maintenance mode and fault injection. Every change is logged; changes
+// to other settings are reported as requiring a restart.
+func (a *App) Reload(next Configuration) error {
+	if err := next.Validate(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	applied, pending := diffConfig(a.config, next)
+	if len(applied) == 0 && len(pending) == 0 {
+		return nil
+	}
+
+	if next.LogLevel != a.config.LogLevel {
+		if err := a.SetLogLevel(next.LogLevel); err != nil {
+			return err
+		}
+	}
+	a.rateLimiter.SetLimits(next.RateLimit, next.RateBurst)
+	// Changing the maintenance settings in the file overrides those of
+	// PUT /admin/maintenance; leaving them alone keeps them.
+	if next.MaintenanceSettings() != a.config.MaintenanceSettings() {
+		if err := a.maintenance.Update(next.MaintenanceSettings()); err != nil {
+			return err
+		}
+	}
+	faultsChanged := next.FaultLatency != a.config.FaultLatency || next.FaultErrorRate != a.config.FaultErrorRate
+	if faultsChanged && a.config.Environment != Prod {
+		settings := a.faults.Settings()
+		settings.Latency = next.FaultLatency
+		settings.ErrorRate = next.FaultErrorRate
+		if err := a.faults.Update(settings); err != nil {
+			return err
+		}
+	}
+
+	// Keep the settings that were not applied so later reloads still report them.
+	current := a.config
+	current.LogLevel = next.LogLevel
+	current.RateLimit = next.RateLimit
+	current.RateBurst = next.RateBurst
+	current.Maintenance = next.Maintenance
+	current.MaintenanceMessage = next.MaintenanceMessage
+	current.MaintenanceRetryAfter = next.MaintenanceRetryAfter
+	current.FaultLatency = next.FaultLatency
+	current.FaultErrorRate = next.FaultErrorRate
+	a.config = current
+
+	if len(applied) > 0 {
+		a.logger.Infow("configuration reloaded", "changes", applied)
+	}
+	if len(pending) > 0 {
+		a.logger.Warnw("configuration changes require a restart", "settings", pending)
+	}
+	return nil
+}
+
+// diffConfig describes the fields that differ between old and next, split
+// into changes that are applied at runtime and the names of those that are not.
+func diffConfig(old, next Configuration) (applied []string, pending []string) {
+	oldValue, nextValue := reflect.ValueOf(old), reflect.ValueOf(next)
+	t := oldValue.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		from, to := oldValue.Field(i).Interface(), nextValue.Field(i).Interface()
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+
+		name := t.Field(i).Tag.Get("yaml")
+		// Values of settings that need a restart are not logged, as they include secrets.
+		if reloadable[name] {
+			applied = append(applied, fmt.Sprintf("%s: %v -> %v", name, from, to))
+		} else {
+			pending = append(pending, name)
+		}
+	}
+	return applied, pending
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,19 @@
+package app
+
+import "testing"
+
+func TestDiffConfig(t *testing.T) {
+	old := DefaultConfiguration(Dev)
+	next := old
+	next.LogLevel = "debug"
+	next.AdminToken = "secret"
+
+	applied, pending := diffConfig(old, next)
+
+	if len(applied) != 1 || applied[0] != "log_level: info -> debug" {
+		t.Errorf("unexpected applied changes: %v", applied)
+	}
+	if len(pending) != 1 || pending[0] != "admin_token" {
+		t.Errorf("unexpected pending changes: %v", pending)
+	}
+}
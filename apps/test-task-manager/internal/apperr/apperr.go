@@ -0,0 +1,92 @@
+// Package apperr defines the errors of the application with stable,
+// machine-readable codes, so callers can tell them apart without knowing
+// which layer returned them or matching their messages.
+package apperr
+
+import "errors"
+
+// Code identifies a kind of error. Codes are part of the API and never
+// change once released.
+type Code string
+
+// Task errors.
+const (
+	TaskNotFound      Code = "TASK_NOT_FOUND"
+	TaskConflict      Code = "TASK_CONFLICT" // Modified concurrently too often to apply a change
+	TaskLocked        Code = "TASK_LOCKED"   // Locked by another client for editing
+	EmptyTitle        Code = "EMPTY_TITLE"
+	TitleTooShort     Code = "TITLE_TOO_SHORT"
+	TitleTooLong      Code = "TITLE_TOO_LONG"
+	InvalidTitle      Code = "INVALID_TITLE"
+	DuplicateTitle    Code = "DUPLICATE_TITLE"     // An open task has the title
+	WIPLimit          Code = "WIP_LIMIT_EXCEEDED"  // Too many open tasks, in total or of a priority
+	QuotaExceeded     Code = "QUOTA_EXCEEDED"      // Too many tasks for the plan of the workspace
+	UserQuotaExceeded Code = "USER_QUOTA_EXCEEDED" // Too many open tasks created by the user
+	InvalidPriority   Code = "INVALID_PRIORITY"
+	InvalidColor      Code = "INVALID_COLOR"
+	MergeWithSelf     Code = "MERGE_WITH_SELF"
+	InvalidCursor     Code = "INVALID_CURSOR"
+)
+
+// Store errors.
+const (
+	StoreFull         Code = "STORE_FULL"
+	StoreUnavailable  Code = "STORE_UNAVAILABLE"
+	StoreTimeout      Code = "STORE_TIMEOUT"      // The request was canceled or timed out before the store answered
+	TombstonesExpired Code = "TOMBSTONES_EXPIRED" // Deletions asked for are older than the tombstones kept
+	StoreReadOnly     Code = "STORE_READ_ONLY"    // The store is unreachable and tasks are read from a snapshot
+)
+
+// Internal is the code of errors without one.
+const Internal Code = "INTERNAL_ERROR"
+
+// Error is an error with a code. Message describes it in words that can be
+// shown to clients; the underlying error, if any, may hold details that
+// must not be.
+type Error struct {
+	Code    Code
+	Message string
+	Err     error
+}
+
+// New returns an error with code and message, to be declared once as a
+// sentinel that callers compare with errors.Is.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap returns an error with code and message caused by err.
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code of the first *Error in the chain of err, or
+// Internal when there is none.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return Internal
+}
+
+// MessageOf returns the message of the first *Error in the chain of err,
+// or "" when there is none.
+func MessageOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	return ""
+}
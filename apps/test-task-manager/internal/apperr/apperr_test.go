@@ -0,0 +1,34 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCodeOf(t *testing.T) {
+	notFound := New(TaskNotFound, "task not found")
+	cause := errors.New("connection refused")
+	unavailable := Wrap(StoreUnavailable, "task store is unavailable", cause)
+
+	tests := []struct {
+		err     error
+		code    Code
+		message string
+	}{
+		{notFound, TaskNotFound, "task not found"},
+		{fmt.Errorf("failed to get task: %w", notFound), TaskNotFound, "task not found"},
+		{fmt.Errorf("failed to list tasks: %w", unavailable), StoreUnavailable, "task store is unavailable"},
+		{cause, Internal, ""},
+		{nil, Internal, ""},
+	}
+	for _, tt := range tests {
+		if code, message := CodeOf(tt.err), MessageOf(tt.err); code != tt.code || message != tt.message {
+			t.Errorf("%v: expected %s %q, got %s %q", tt.err, tt.code, tt.message, code, message)
+		}
+	}
+
+	if !errors.Is(unavailable, cause) || unavailable.Error() != "task store is unavailable: connection refused" {
+		t.Errorf("expected the cause to be wrapped, got %v", unavailable)
+	}
+}
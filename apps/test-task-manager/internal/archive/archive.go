@@ -0,0 +1,159 @@
+// Package archive moves completed tasks out of the store into an archive
+// file on a schedule, and purges them from the archive once they have been
+// kept long enough.
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/atomicfile"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// Entry is an archived task.
+type Entry struct {
+	Task       model.Task `json:"task"`
+	ArchivedAt time.Time  `json:"archivedAt"`
+}
+
+// Archive is an append-only file of archived tasks, one JSON entry per
+// line, so archiving never rewrites what was archived before.
+type Archive struct {
+	path string
+
+	mu sync.Mutex
+	n  int // Entries in the file
+}
+
+// Open opens the archive at path, creating it when it does not exist yet.
+func Open(path string) (*Archive, error) {
+	if path == "" {
+		return nil, errors.New("an archive file path is required")
+	}
+
+	a := &Archive{path: path}
+	err := a.each(func(Entry) error {
+		a.n++
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Append adds entries to the end of the archive and syncs the file.
+func (a *Archive) Append(entries ...Entry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	a.n += len(entries)
+	return nil
+}
+
+// Purge removes the entries archived before cutoff from the file and
+// returns them. With dryRun, it only returns them. The remaining entries are
+// written to a temporary file that replaces the archive, so it is never
+// left half-written.
+func (a *Archive) Purge(cutoff time.Time, dryRun bool) ([]Entry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var kept, purged []Entry
+	err := a.each(func(entry Entry) error {
+		if entry.ArchivedAt.Before(cutoff) {
+			purged = append(purged, entry)
+		} else {
+			kept = append(kept, entry)
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil || dryRun || len(purged) == 0 {
+		return purged, err
+	}
+
+	if err := a.replace(kept); err != nil {
+		return nil, err
+	}
+	a.n = len(kept)
+	return purged, nil
+}
+
+// Len returns the number of archived tasks.
+func (a *Archive) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.n
+}
+
+// replace atomically replaces the file with entries. Callers must hold the lock.
+func (a *Archive) replace(entries []Entry) error {
+	return atomicfile.WriteFunc(a.path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// each calls fn for every entry in the file, in the order they were added.
+func (a *Archive) each(fn func(Entry) error) error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("%s:%d: %w", a.path, line, err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
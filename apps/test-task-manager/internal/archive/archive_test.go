@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"go.uber.org/zap"
+)
+
+func TestPolicy_ArchivesOldCompletedTasks(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	old, recent := now.Add(-31*24*time.Hour), now.Add(-time.Hour)
+
+	s := store.NewTaskStore()
+	done, _ := s.Create(t.Context(), model.Task{Title: "done long ago", Completed: true, CompletedAt: &old})
+	s.Create(t.Context(), model.Task{Title: "done recently", Completed: true, CompletedAt: &recent})
+	s.Create(t.Context(), model.Task{Title: "open"})
+
+	path := filepath.Join(t.TempDir(), "archive.ndjson")
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := NewPolicy(s, a, 30*24*time.Hour, "default", zap.NewNop().Sugar(), metrics.NewRegistry())
+
+	summary, err := policy.Apply(t.Context(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != (Summary{Completed: 2, Archived: 1}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if _, err := s.GetByID(t.Context(), done.ID); err == nil {
+		t.Error("expected the archived task to be deleted from the store")
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Len() != 1 {
+		t.Errorf("expected 1 archived task after reopening, got %d", reopened.Len())
+	}
+}
+
+func TestRetention_PurgesOldEntries(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	a, err := Open(filepath.Join(t.TempDir(), "archive.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Append(
+		Entry{Task: model.Task{ID: "1"}, ArchivedAt: now.Add(-400 * 24 * time.Hour)},
+		Entry{Task: model.Task{ID: "2"}, ArchivedAt: now.Add(-24 * time.Hour)},
+	)
+	retention := NewRetention(a, 365*24*time.Hour, zap.NewNop().Sugar(), metrics.NewRegistry())
+
+	preview, err := retention.Preview(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview) != 1 || preview[0].Task.ID != "1" || a.Len() != 2 {
+		t.Fatalf("expected a preview of task 1 without purging it, got %+v (%d left)", preview, a.Len())
+	}
+
+	purged, err := retention.Purge(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(purged) != 1 || a.Len() != 1 {
+		t.Errorf("expected task 1 to be purged, got %+v (%d left)", purged, a.Len())
+	}
+	if again, _ := retention.Preview(now); len(again) != 0 {
+		t.Errorf("expected nothing left to purge, got %+v", again)
+	}
+}
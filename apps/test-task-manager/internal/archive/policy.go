@@ -0,0 +1,133 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/cron"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/health"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"go.uber.org/zap"
+)
+
+// Tasks is the part of the task service the policy works with.
+type Tasks interface {
+	Find(ctx context.Context, q store.Query) ([]model.Task, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// Summary describes a run of a Policy.
+type Summary struct {
+	Completed int // Completed tasks that were checked
+	Archived  int
+	Failed    int // Tasks that were archived but could not be deleted
+}
+
+// Policy archives the tasks completed longer than a given age ago: they
+// are appended to the archive and then deleted from the store. A task that
+// cannot be deleted is archived again on the next run, so the archive may
+// hold it twice.
+type Policy struct {
+	tasks   Tasks
+	archive *Archive
+	after   time.Duration
+	logger  *zap.SugaredLogger
+	outcome health.Outcome
+
+	runs     *metrics.CounterVec
+	archived *metrics.Counter
+}
+
+// NewPolicy creates a policy archiving the tasks of workspace completed
+// more than after ago.
+func NewPolicy(tasks Tasks, archive *Archive, after time.Duration, workspace string, logger *zap.SugaredLogger, reg *metrics.Registry) *Policy {
+	reg.GaugeFuncVec("archived_tasks", "Tasks in the archive file.", "workspace").Set(func() float64 {
+		return float64(archive.Len())
+	}, workspace)
+	return &Policy{
+		tasks:    tasks,
+		archive:  archive,
+		after:    after,
+		logger:   logger,
+		runs:     reg.CounterVec("archive_runs_total", "Runs of the archival policy by result.", "result"),
+		archived: reg.Counter("tasks_archived_total", "Completed tasks moved to the archive."),
+	}
+}
+
+// Run applies the policy right away and then at every time of schedule
+// until ctx is done.
+func (p *Policy) Run(ctx context.Context, schedule cron.Schedule) {
+	p.run(ctx, time.Now())
+	cron.Run(ctx, schedule, func(now time.Time) { p.run(ctx, now) })
+}
+
+func (p *Policy) run(ctx context.Context, now time.Time) {
+	started := time.Now()
+	summary, err := p.Apply(ctx, now)
+	p.outcome.Record(err)
+	if err != nil {
+		p.runs.With("failure").Inc()
+		p.logger.Warnw("failed to archive completed tasks", "archived", summary.Archived, "error", err)
+		return
+	}
+
+	result := "success"
+	if summary.Failed > 0 {
+		result = "partial"
+	}
+	p.runs.With(result).Inc()
+	p.logger.Infow("archived completed tasks",
+		"completed", summary.Completed,
+		"archived", summary.Archived,
+		"failed", summary.Failed,
+		"completedBefore", now.Add(-p.after),
+		"duration", time.Since(started),
+	)
+}
+
+// Check is a health.Check reporting the error of the last run.
+func (p *Policy) Check(ctx context.Context) error {
+	return p.outcome.Check(ctx)
+}
+
+// Apply archives the tasks completed before now minus the policy age.
+func (p *Policy) Apply(ctx context.Context, now time.Time) (Summary, error) {
+	completed := true
+	tasks, err := p.tasks.Find(ctx, store.Query{Completed: &completed})
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary := Summary{Completed: len(tasks)}
+	cutoff := now.Add(-p.after)
+	var entries []Entry
+	for _, task := range tasks {
+		if task.CompletedAt != nil && task.CompletedAt.Before(cutoff) {
+			entries = append(entries, Entry{Task: task, ArchivedAt: now})
+		}
+	}
+	if len(entries) == 0 {
+		return summary, nil
+	}
+
+	// Archive first, so a crash in between leaves a task in both places
+	// rather than in neither.
+	if err := p.archive.Append(entries...); err != nil {
+		return summary, err
+	}
+	for _, entry := range entries {
+		// A task deleted meanwhile is gone from the store all the same.
+		err := p.tasks.Delete(ctx, entry.Task.ID)
+		if err != nil && !errors.Is(err, store.ErrTaskNotFound) {
+			summary.Failed++
+			p.logger.Warnw("failed to delete archived task", "task", entry.Task.ID, "error", err)
+			continue
+		}
+		summary.Archived++
+		p.archived.Inc()
+	}
+	return summary, nil
+}
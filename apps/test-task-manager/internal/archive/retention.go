@@ -0,0 +1,79 @@
+package archive
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/cron"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/health"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Retention permanently purges the tasks archived longer than a given age
+// ago from the archive.
+type Retention struct {
+	archive *Archive
+	keep    time.Duration
+	logger  *zap.SugaredLogger
+	outcome health.Outcome
+
+	runs   *metrics.CounterVec
+	purged *metrics.Counter
+}
+
+// NewRetention creates a retention policy keeping archived tasks for keep.
+func NewRetention(archive *Archive, keep time.Duration, logger *zap.SugaredLogger, reg *metrics.Registry) *Retention {
+	return &Retention{
+		archive: archive,
+		keep:    keep,
+		logger:  logger,
+		runs:    reg.CounterVec("retention_runs_total", "Runs of the retention policy by result.", "result"),
+		purged:  reg.Counter("tasks_purged_total", "Archived tasks purged by the retention policy."),
+	}
+}
+
+// Run purges right away and then at every time of schedule until ctx is
+// done.
+func (r *Retention) Run(ctx context.Context, schedule cron.Schedule) {
+	r.run(time.Now())
+	cron.Run(ctx, schedule, r.run)
+}
+
+func (r *Retention) run(now time.Time) {
+	purged, err := r.Purge(now)
+	r.outcome.Record(err)
+	if err != nil {
+		r.runs.With("failure").Inc()
+		r.logger.Warnw("failed to purge archived tasks", "error", err)
+		return
+	}
+	r.runs.With("success").Inc()
+	r.logger.Infow("purged archived tasks", "purged", len(purged), "archivedBefore", r.Cutoff(now))
+}
+
+// Check is a health.Check reporting the error of the last run.
+func (r *Retention) Check(ctx context.Context) error {
+	return r.outcome.Check(ctx)
+}
+
+// Cutoff returns the time before which archived tasks are purged at now.
+func (r *Retention) Cutoff(now time.Time) time.Time {
+	return now.Add(-r.keep)
+}
+
+// Preview returns the archived tasks Purge would remove at now.
+func (r *Retention) Preview(now time.Time) ([]Entry, error) {
+	return r.archive.Purge(r.Cutoff(now), true)
+}
+
+// Purge removes the tasks archived before the cutoff at now and returns
+// them.
+func (r *Retention) Purge(now time.Time) ([]Entry, error) {
+	purged, err := r.archive.Purge(r.Cutoff(now), false)
+	if err != nil {
+		return nil, err
+	}
+	r.purged.Add(float64(len(purged)))
+	return purged, nil
+}
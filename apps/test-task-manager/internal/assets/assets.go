@@ -0,0 +1,117 @@
+// Package assets serves static files under content-hashed names so they can
+// be cached by browsers indefinitely.
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// hashLength is the number of hex characters of the content hash used in file names.
+const hashLength = 12
+
+// hashedName matches "<name>.<hash>.<ext>" for hashes of hashLength characters.
+var hashedName = regexp.MustCompile(`^(.*)\.([0-9a-f]{12})(\.[^./]+)$`)
+
+// Assets maps static files to their content-hashed URLs.
+type Assets struct {
+	prefix string
+	fsys   fs.FS
+	hashed map[string]string // file name -> hashed file name
+	files  map[string]string // hashed file name -> file name
+}
+
+// New hashes every file in dir. URLs returned by Path start with prefix,
+// which must be the path the Handler is mounted on (e.g. "/static/").
+func New(dir, prefix string) (*Assets, error) {
+	a := &Assets{
+		prefix: prefix,
+		fsys:   os.DirFS(dir),
+		hashed: make(map[string]string),
+		files:  make(map[string]string),
+	}
+
+	err := fs.WalkDir(a.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		sum, err := hashFile(a.fsys, name)
+		if err != nil {
+			return err
+		}
+
+		ext := path.Ext(name)
+		hashed := strings.TrimSuffix(name, ext) + "." + sum + ext
+		a.hashed[name] = hashed
+		a.files[hashed] = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Path returns the URL of the named file, including its content hash.
+// Unknown files are returned unhashed.
+func (a *Assets) Path(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if hashed, ok := a.hashed[name]; ok {
+		return a.prefix + hashed
+	}
+	return a.prefix + name
+}
+
+// Handler serves the static files. It must be mounted with the prefix
+// stripped. Hashed URLs are cached for a year; all other URLs, including
+// those with an outdated hash, must be revalidated on every use.
+func (a *Assets) Handler() http.Handler {
+	files := http.FileServer(http.FS(a.fsys))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+
+		if file, ok := a.files[name]; ok {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			r = withPath(r, file)
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+			if m := hashedName.FindStringSubmatch(name); m != nil {
+				r = withPath(r, m[1]+m[3])
+			}
+		}
+
+		files.ServeHTTP(w, r)
+	})
+}
+
+// withPath returns a copy of r requesting the given file.
+func withPath(r *http.Request, name string) *http.Request {
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = "/" + name
+	r2.URL.RawPath = ""
+	return r2
+}
+
+func hashFile(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:hashLength], nil
+}
@@ -0,0 +1,53 @@
+package assets
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAssets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "css"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "css", "styles.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := New(dir, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := a.Path("css/styles.css")
+	if !hashedName.MatchString(strings.TrimPrefix(url, "/static/")) {
+		t.Fatalf("expected hashed URL, got %q", url)
+	}
+	if got := a.Path("missing.js"); got != "/static/missing.js" {
+		t.Errorf("expected unknown file to be unhashed, got %q", got)
+	}
+
+	tests := []struct {
+		path         string
+		cacheControl string
+	}{
+		{path: strings.TrimPrefix(url, "/static"), cacheControl: "public, max-age=31536000, immutable"},
+		{path: "/css/styles.css", cacheControl: "no-cache"},
+		{path: "/css/styles.0123456789ab.css", cacheControl: "no-cache"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+		if rec.Code != 200 || rec.Body.String() != "body{}" {
+			t.Errorf("%s: expected file contents, got %d %q", tt.path, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Cache-Control"); got != tt.cacheControl {
+			t.Errorf("%s: expected Cache-Control %q, got %q", tt.path, tt.cacheControl, got)
+		}
+	}
+}
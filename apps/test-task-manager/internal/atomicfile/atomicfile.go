@@ -0,0 +1,49 @@
+// Package atomicfile replaces files so readers never see a partial file:
+// the content is written to a temporary file next to the file and renamed
+// over it once it is on disk.
+package atomicfile
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Write replaces the file at path with content.
+func Write(path string, content []byte) error {
+	return WriteFunc(path, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+}
+
+// WriteFunc replaces the file at path with what write writes to a buffered
+// writer. The file is left as it was when write fails. The new file has
+// mode 0600, as os.CreateTemp creates it, which keeps credentials and
+// secrets in it private.
+func WriteFunc(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := write(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,61 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := Write(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := os.ReadFile(path); err != nil || string(got) != content {
+			t.Fatalf("expected %q, got %q (%v)", content, got, err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("expected mode 0600, got %v", mode)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left, got %v", entries)
+	}
+}
+
+func TestWriteFunc_Failed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	if err := Write(path, []byte("kept")); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("encoding failed")
+	err := WriteFunc(path, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of write, got %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "kept" {
+		t.Errorf("expected the file to be left alone, got %q", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left, got %v", entries)
+	}
+
+	if err := Write(filepath.Join(dir, "missing", "state.json"), []byte("x")); err == nil {
+		t.Error("expected writing into a missing directory to fail")
+	}
+}
@@ -0,0 +1,244 @@
+// Package auth manages users and the credentials they authenticate with:
+// API keys for programmatic access and sessions for the HTML UI.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/netguard"
+)
+
+var (
+	// ErrUserNotFound is returned when no user matches the given ID or name.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists is returned when creating a user with a name in use.
+	ErrUserExists = errors.New("user already exists")
+	// ErrKeyNotFound is returned when no API key matches the given ID.
+	ErrKeyNotFound = errors.New("API key not found")
+	// ErrSessionNotFound is returned when no session matches the given ID.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrInvalidCredentials is returned for unknown, revoked or expired
+	// credentials, and for credentials of disabled users.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Token prefixes tell API keys and session tokens apart.
+const (
+	keyTokenPrefix     = "ttm"
+	sessionTokenPrefix = "ttms"
+)
+
+// SessionCookie is the cookie browsers signed in on the login page keep
+// their session token in.
+const SessionCookie = "ttm_session"
+
+// User is someone who can access the application.
+type User struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	DisabledAt *time.Time `json:"disabledAt,omitempty"`
+
+	Email        string `json:"email,omitempty"`        // Address the daily digest is sent to
+	DigestOptOut bool   `json:"digestOptOut,omitempty"` // No daily digest, even with an email address
+
+	UI UIPreferences `json:"ui,omitzero"` // How the HTML UI shows tasks to the user
+
+	// How the user hears about the tasks they created
+	Notifications NotificationPreferences `json:"notifications,omitzero"`
+
+	// Workspaces the user is a member of besides the default workspace,
+	// which every user can access.
+	Workspaces []string `json:"workspaces,omitempty"`
+}
+
+// UIPreferences are the choices a user made in the HTML UI. Empty fields
+// leave the choice to the UI.
+type UIPreferences struct {
+	Theme    string `json:"theme,omitempty"`
+	Sort     string `json:"sort,omitempty"`
+	PageSize int    `json:"pageSize,omitempty"`
+}
+
+// Preferences changes the notification settings of a user. Nil fields are
+// left as they are; an empty email address removes it.
+type Preferences struct {
+	Email        *string `json:"email,omitempty"`
+	DigestOptOut *bool   `json:"digestOptOut,omitempty"`
+}
+
+// Notification channels users hear about their tasks through.
+const (
+	ChannelEmail   = "email"   // To the email address of the user
+	ChannelWebhook = "webhook" // Posted to the webhook URL of the preferences
+	ChannelPush    = "push"    // To the browsers the user subscribed to web push
+)
+
+// Notification modes.
+const (
+	NotifyAll        = "all"         // Notifications through the channels, and the daily digest
+	NotifyDigestOnly = "digest-only" // Only the daily digest
+	NotifyMute       = "mute"        // Nothing, not even the daily digest
+)
+
+// NotificationPreferences are how a user hears about the tasks they
+// created: of their due dates, escalations and completion by others. The
+// zero value is mode NotifyAll without channels, which only sends the
+// daily digest.
+type NotificationPreferences struct {
+	Mode       string   `json:"mode"`
+	Channels   []string `json:"channels"`
+	WebhookURL string   `json:"webhookUrl,omitempty"` // Where ChannelWebhook posts to
+}
+
+// Validate checks the mode and channels, and that ChannelWebhook has an
+// http or https URL of a host that does not resolve to a loopback, private
+// or link-local address.
+func (p NotificationPreferences) Validate() error {
+	switch p.Mode {
+	case "", NotifyAll, NotifyDigestOnly, NotifyMute:
+	default:
+		return fmt.Errorf("mode must be %s, %s or %s", NotifyAll, NotifyDigestOnly, NotifyMute)
+	}
+	for i, channel := range p.Channels {
+		if channel != ChannelEmail && channel != ChannelWebhook && channel != ChannelPush {
+			return fmt.Errorf("channel %q must be %s, %s or %s", channel, ChannelEmail, ChannelWebhook, ChannelPush)
+		}
+		if slices.Contains(p.Channels[:i], channel) {
+			return fmt.Errorf("channel %q is given twice", channel)
+		}
+	}
+	if slices.Contains(p.Channels, ChannelWebhook) {
+		u, err := url.Parse(p.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("webhookUrl must be an http or https URL for the webhook channel")
+		}
+		if err := netguard.CheckHost(context.Background(), u.Hostname()); err != nil {
+			return fmt.Errorf("webhookUrl may not point at a loopback, private or link-local address: %w", err)
+		}
+	}
+	return nil
+}
+
+// Uses reports whether notifications about their tasks are sent to the
+// user through channel.
+func (p NotificationPreferences) Uses(channel string) bool {
+	return (p.Mode == "" || p.Mode == NotifyAll) && slices.Contains(p.Channels, channel)
+}
+
+// Disabled reports whether the user can no longer authenticate.
+func (u User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
+// GetsDigest reports whether the daily digest is sent to the user: to
+// enabled users with an email address who neither opted out nor muted
+// notifications.
+func (u User) GetsDigest() bool {
+	return !u.Disabled() && u.Email != "" && !u.DigestOptOut && u.Notifications.Mode != NotifyMute
+}
+
+// MemberOf reports whether the user is a member of the named workspace
+// other than the default one.
+func (u User) MemberOf(workspace string) bool {
+	return slices.Contains(u.Workspaces, workspace)
+}
+
+// APIKey is a long-lived credential of a user. Only a hash of the token is kept.
+type APIKey struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"userId"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"hash,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// IssuedKey is a newly issued API key together with its token, which is
+// only available at this point.
+type IssuedKey struct {
+	APIKey
+	Token string `json:"token"`
+}
+
+// Session is a signed-in browser of a user. Only a hash of the token is kept.
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Hash      string    `json:"hash,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// IssuedSession is a newly created session together with its token.
+type IssuedSession struct {
+	Session
+	Token string `json:"token"`
+}
+
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user.
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user, if any.
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(contextKey{}).(User)
+	return user, ok
+}
+
+// newID returns a random identifier with the given prefix.
+func newID(prefix string) string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return prefix + "_" + hex.EncodeToString(b)
+}
+
+// newToken returns a token of the form <prefix>_<id>_<secret> and its hash.
+// Embedding the ID allows looking up the credential without scanning.
+func newToken(prefix, id string) (token, hash string) {
+	secret := make([]byte, 24)
+	rand.Read(secret)
+	token = prefix + "_" + id + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return token, hashToken(token)
+}
+
+// SessionID returns the ID of the session a session token belongs to.
+func SessionID(token string) (id string, ok bool) {
+	return parseToken(sessionTokenPrefix, token)
+}
+
+// parseToken returns the credential ID embedded in a token with prefix.
+func parseToken(prefix, token string) (id string, ok bool) {
+	rest, ok := strings.CutPrefix(token, prefix+"_")
+	if !ok {
+		return "", false
+	}
+	// IDs contain exactly one underscore, e.g. key_0123456789ab.
+	kind, rest, ok := strings.Cut(rest, "_")
+	if !ok {
+		return "", false
+	}
+	suffix, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return "", false
+	}
+	return kind + "_" + suffix, true
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,488 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/atomicfile"
+)
+
+// Store keeps users, API keys and sessions, optionally persisted to a JSON
+// file. The file is re-read when another process (such as the admin CLI)
+// changed it, so the server picks up changes without a restart.
+type Store struct {
+	path string // Empty when kept in memory only
+
+	mu      sync.Mutex
+	state   state
+	modTime time.Time
+	size    int64
+}
+
+// state is the persisted content of a Store.
+type state struct {
+	Users    []User    `json:"users"`
+	Keys     []APIKey  `json:"keys"`
+	Sessions []Session `json:"sessions"`
+}
+
+// NewStore opens the store persisted at path, creating the file when it
+// does not exist. With an empty path nothing is persisted.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+	if path == "" {
+		return s, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	if s.modTime.IsZero() {
+		if err := s.save(s.state); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Persistent reports whether the store is backed by a file.
+func (s *Store) Persistent() bool {
+	return s.path != ""
+}
+
+// CreateUser adds a user with a unique name.
+func (s *Store) CreateUser(name string) (User, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return User{}, errors.New("user name is required")
+	}
+
+	var user User
+	err := s.update(func(st *state) error {
+		for _, u := range st.Users {
+			if strings.EqualFold(u.Name, name) {
+				return ErrUserExists
+			}
+		}
+		user = User{ID: newID("usr"), Name: name, CreatedAt: time.Now()}
+		st.Users = append(st.Users, user)
+		return nil
+	})
+	return user, err
+}
+
+// DisableUser prevents the user, referenced by ID or name, from
+// authenticating and ends their sessions. API keys stay listed but stop working.
+func (s *Store) DisableUser(ref string) (User, error) {
+	var user User
+	err := s.update(func(st *state) error {
+		i := findUser(st.Users, ref)
+		if i < 0 {
+			return ErrUserNotFound
+		}
+		if st.Users[i].DisabledAt == nil {
+			now := time.Now()
+			st.Users[i].DisabledAt = &now
+		}
+		user = st.Users[i]
+
+		sessions := st.Sessions[:0]
+		for _, session := range st.Sessions {
+			if session.UserID != user.ID {
+				sessions = append(sessions, session)
+			}
+		}
+		st.Sessions = sessions
+		return nil
+	})
+	return user, err
+}
+
+// SetPreferences changes the notification settings of the user referenced
+// by ID or name.
+func (s *Store) SetPreferences(ref string, prefs Preferences) (User, error) {
+	if prefs.Email != nil && *prefs.Email != "" {
+		address, err := mail.ParseAddress(*prefs.Email)
+		if err != nil || address.Name != "" {
+			return User{}, fmt.Errorf("invalid email address %q", *prefs.Email)
+		}
+	}
+
+	var user User
+	err := s.update(func(st *state) error {
+		i := findUser(st.Users, ref)
+		if i < 0 {
+			return ErrUserNotFound
+		}
+		if prefs.Email != nil {
+			st.Users[i].Email = *prefs.Email
+		}
+		if prefs.DigestOptOut != nil {
+			st.Users[i].DigestOptOut = *prefs.DigestOptOut
+		}
+		user = st.Users[i]
+		return nil
+	})
+	return user, err
+}
+
+// SetWorkspaces replaces the workspaces the user referenced by ID or name
+// is a member of. Names are trimmed; empty and repeated ones are dropped.
+func (s *Store) SetWorkspaces(ref string, workspaces []string) (User, error) {
+	var names []string
+	for _, name := range workspaces {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var user User
+	err := s.update(func(st *state) error {
+		i := findUser(st.Users, ref)
+		if i < 0 {
+			return ErrUserNotFound
+		}
+		st.Users[i].Workspaces = names
+		user = st.Users[i]
+		return nil
+	})
+	return user, err
+}
+
+// SetUIPreferences replaces the HTML UI preferences of the user referenced
+// by ID or name. They are checked by the UI, which knows the choices.
+func (s *Store) SetUIPreferences(ref string, prefs UIPreferences) (User, error) {
+	var user User
+	err := s.update(func(st *state) error {
+		i := findUser(st.Users, ref)
+		if i < 0 {
+			return ErrUserNotFound
+		}
+		st.Users[i].UI = prefs
+		user = st.Users[i]
+		return nil
+	})
+	return user, err
+}
+
+// SetNotificationPreferences replaces the notification preferences of the
+// user referenced by ID or name, after checking them with Validate.
+func (s *Store) SetNotificationPreferences(ref string, prefs NotificationPreferences) (User, error) {
+	if err := prefs.Validate(); err != nil {
+		return User{}, err
+	}
+
+	var user User
+	err := s.update(func(st *state) error {
+		i := findUser(st.Users, ref)
+		if i < 0 {
+			return ErrUserNotFound
+		}
+		st.Users[i].Notifications = prefs
+		user = st.Users[i]
+		return nil
+	})
+	return user, err
+}
+
+// Users returns all users.
+func (s *Store) Users() ([]User, error) {
+	var users []User
+	err := s.read(func(st *state) {
+		users = append(make([]User, 0, len(st.Users)), st.Users...)
+	})
+	return users, err
+}
+
+// IssueKey creates an API key for the user referenced by ID or name.
+func (s *Store) IssueKey(userRef, name string) (IssuedKey, error) {
+	var issued IssuedKey
+	err := s.update(func(st *state) error {
+		i := findUser(st.Users, userRef)
+		if i < 0 {
+			return ErrUserNotFound
+		}
+		if st.Users[i].Disabled() {
+			return fmt.Errorf("user %s is disabled", st.Users[i].Name)
+		}
+
+		key := APIKey{ID: newID("key"), UserID: st.Users[i].ID, Name: strings.TrimSpace(name), CreatedAt: time.Now()}
+		var token string
+		token, key.Hash = newToken(keyTokenPrefix, key.ID)
+		st.Keys = append(st.Keys, key)
+
+		issued = IssuedKey{APIKey: key.redacted(), Token: token}
+		return nil
+	})
+	return issued, err
+}
+
+// RevokeKey permanently disables an API key.
+func (s *Store) RevokeKey(id string) (APIKey, error) {
+	var key APIKey
+	err := s.update(func(st *state) error {
+		for i := range st.Keys {
+			if st.Keys[i].ID != id {
+				continue
+			}
+			if st.Keys[i].RevokedAt == nil {
+				now := time.Now()
+				st.Keys[i].RevokedAt = &now
+			}
+			key = st.Keys[i].redacted()
+			return nil
+		}
+		return ErrKeyNotFound
+	})
+	return key, err
+}
+
+// Keys returns the API keys of the user referenced by ID or name, or of
+// all users when userRef is empty.
+func (s *Store) Keys(userRef string) ([]APIKey, error) {
+	var keys []APIKey
+	var err error
+	readErr := s.read(func(st *state) {
+		userID := ""
+		if userRef != "" {
+			i := findUser(st.Users, userRef)
+			if i < 0 {
+				err = ErrUserNotFound
+				return
+			}
+			userID = st.Users[i].ID
+		}
+
+		keys = make([]APIKey, 0, len(st.Keys))
+		for _, key := range st.Keys {
+			if userID == "" || key.UserID == userID {
+				keys = append(keys, key.redacted())
+			}
+		}
+	})
+	if readErr != nil {
+		return nil, readErr
+	}
+	return keys, err
+}
+
+// CreateSession signs in the user referenced by ID or name for ttl.
+func (s *Store) CreateSession(userRef string, ttl time.Duration) (IssuedSession, error) {
+	var issued IssuedSession
+	err := s.update(func(st *state) error {
+		i := findUser(st.Users, userRef)
+		if i < 0 || st.Users[i].Disabled() {
+			return ErrInvalidCredentials
+		}
+
+		now := time.Now()
+		session := Session{ID: newID("ses"), UserID: st.Users[i].ID, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+		var token string
+		token, session.Hash = newToken(sessionTokenPrefix, session.ID)
+		st.Sessions = append(dropExpired(st.Sessions, now), session)
+
+		issued = IssuedSession{Session: session.redacted(), Token: token}
+		return nil
+	})
+	return issued, err
+}
+
+// EndSession signs the session out.
+func (s *Store) EndSession(id string) error {
+	return s.update(func(st *state) error {
+		for i, session := range st.Sessions {
+			if session.ID == id {
+				st.Sessions = append(st.Sessions[:i], st.Sessions[i+1:]...)
+				return nil
+			}
+		}
+		return ErrSessionNotFound
+	})
+}
+
+// Sessions returns the sessions that have not expired.
+func (s *Store) Sessions() ([]Session, error) {
+	var sessions []Session
+	err := s.read(func(st *state) {
+		now := time.Now()
+		sessions = make([]Session, 0, len(st.Sessions))
+		for _, session := range st.Sessions {
+			if now.Before(session.ExpiresAt) {
+				sessions = append(sessions, session.redacted())
+			}
+		}
+	})
+	return sessions, err
+}
+
+// Authenticate returns the user owning an API key or session token.
+func (s *Store) Authenticate(token string) (User, error) {
+	var user User
+	err := ErrInvalidCredentials
+
+	readErr := s.read(func(st *state) {
+		var userID string
+		if id, ok := parseToken(keyTokenPrefix, token); ok {
+			for _, key := range st.Keys {
+				if key.ID == id && key.RevokedAt == nil && hashMatches(key.Hash, token) {
+					userID = key.UserID
+				}
+			}
+		} else if id, ok := parseToken(sessionTokenPrefix, token); ok {
+			for _, session := range st.Sessions {
+				if session.ID == id && time.Now().Before(session.ExpiresAt) && hashMatches(session.Hash, token) {
+					userID = session.UserID
+				}
+			}
+		}
+		if userID == "" {
+			return
+		}
+
+		if i := findUser(st.Users, userID); i >= 0 && !st.Users[i].Disabled() {
+			user, err = st.Users[i], nil
+		}
+	})
+	if readErr != nil {
+		return User{}, readErr
+	}
+	return user, err
+}
+
+// read calls fn with the current state.
+func (s *Store) read(fn func(st *state)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	fn(&s.state)
+	return nil
+}
+
+// update applies fn to a copy of the current state and persists the result.
+// The state is left untouched when fn or persisting fails.
+func (s *Store) update(fn func(st *state) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return err
+	}
+
+	next := state{
+		Users:    append([]User(nil), s.state.Users...),
+		Keys:     append([]APIKey(nil), s.state.Keys...),
+		Sessions: append([]Session(nil), s.state.Sessions...),
+	}
+	if err := fn(&next); err != nil {
+		return err
+	}
+	if err := s.save(next); err != nil {
+		return err
+	}
+	s.state = next
+	return nil
+}
+
+// refresh reloads the file when it changed since it was last read or
+// written. Callers must hold the lock.
+func (s *Store) refresh() error {
+	if s.path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var st state
+	if err := json.Unmarshal(content, &st); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+
+	s.state, s.modTime, s.size = st, info.ModTime(), info.Size()
+	return nil
+}
+
+// save atomically replaces the file with st. Callers must hold the lock.
+func (s *Store) save(st state) error {
+	if s.path == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The file has mode 0600, which keeps the credential hashes private.
+	if err := atomicfile.Write(s.path, content); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	s.modTime, s.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// findUser returns the index of the user with the given ID or name, or -1.
+func findUser(users []User, ref string) int {
+	for i, u := range users {
+		if u.ID == ref || strings.EqualFold(u.Name, ref) {
+			return i
+		}
+	}
+	return -1
+}
+
+func dropExpired(sessions []Session, now time.Time) []Session {
+	active := sessions[:0]
+	for _, session := range sessions {
+		if now.Before(session.ExpiresAt) {
+			active = append(active, session)
+		}
+	}
+	return active
+}
+
+func hashMatches(hash, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashToken(token))) == 1
+}
+
+func (k APIKey) redacted() APIKey {
+	k.Hash = ""
+	return k
+}
+
+func (s Session) redacted() Session {
+	s.Hash = ""
+	return s
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_KeyLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.json")
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := s.CreateUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateUser("Alice"); !errors.Is(err, ErrUserExists) {
+		t.Errorf("expected ErrUserExists for a duplicate name, got %v", err)
+	}
+
+	// A second store on the same file sees keys issued by the first, like
+	// the server sees keys issued by the CLI.
+	server, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := s.IssueKey("alice", "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := server.Authenticate(key.Token); err != nil || got.ID != user.ID {
+		t.Fatalf("expected the key to authenticate alice, got %+v, %v", got, err)
+	}
+	if _, err := server.Authenticate(key.Token + "x"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected a tampered token to be rejected, got %v", err)
+	}
+
+	if _, err := s.RevokeKey(key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.Authenticate(key.Token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected a revoked key to be rejected, got %v", err)
+	}
+}
+
+func TestStore_DisableUserEndsSessions(t *testing.T) {
+	s, _ := NewStore("")
+	s.CreateUser("bob")
+
+	session, err := s.CreateSession("bob", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(session.Token); err != nil {
+		t.Fatalf("expected the session to authenticate, got %v", err)
+	}
+
+	if _, err := s.DisableUser("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(session.Token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected the session to end with the user disabled, got %v", err)
+	}
+	if sessions, _ := s.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected no sessions, got %+v", sessions)
+	}
+	if _, err := s.IssueKey("bob", ""); err == nil {
+		t.Error("expected issuing a key for a disabled user to fail")
+	}
+}
+
+func TestStore_SetWorkspaces(t *testing.T) {
+	s, _ := NewStore("")
+	s.CreateUser("carol")
+
+	user, err := s.SetWorkspaces("carol", []string{" team ", "ops", "", "team"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(user.Workspaces) != 2 || user.Workspaces[0] != "ops" || user.Workspaces[1] != "team" {
+		t.Errorf("expected workspaces [ops team], got %v", user.Workspaces)
+	}
+	if !user.MemberOf("team") || user.MemberOf("sales") {
+		t.Errorf("unexpected membership of %v", user.Workspaces)
+	}
+	if _, err := s.SetWorkspaces("dave", nil); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestStore_SetNotificationPreferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.json")
+	s, _ := NewStore(path)
+	s.CreateUser("carol")
+
+	prefs := NotificationPreferences{Mode: NotifyAll, Channels: []string{ChannelPush, ChannelWebhook}, WebhookURL: "https://hooks.example.com/carol"}
+	if _, err := s.SetNotificationPreferences("carol", prefs); err != nil {
+		t.Fatal(err)
+	}
+	reopened, _ := NewStore(path)
+	users, _ := reopened.Users()
+	if got := users[0].Notifications; !got.Uses(ChannelWebhook) || got.Uses(ChannelEmail) || got.WebhookURL != prefs.WebhookURL {
+		t.Errorf("expected the preferences to be persisted, got %+v", got)
+	}
+
+	invalid := []NotificationPreferences{
+		{Mode: "loud"},
+		{Channels: []string{"pigeon"}},
+		{Channels: []string{ChannelPush, ChannelPush}},
+		{Channels: []string{ChannelWebhook}, WebhookURL: "ftp://hooks.example.com"},
+		{Channels: []string{ChannelWebhook}, WebhookURL: "http://127.0.0.1:8080/admin"},
+		{Channels: []string{ChannelWebhook}, WebhookURL: "http://localhost/hook"},
+		{Channels: []string{ChannelWebhook}, WebhookURL: "http://169.254.169.254/latest/meta-data/"},
+		{Channels: []string{ChannelWebhook}, WebhookURL: "https://10.0.0.5/hook"},
+		{Channels: []string{ChannelWebhook}, WebhookURL: "https://192.168.1.10/hook"},
+		{Channels: []string{ChannelWebhook}, WebhookURL: "http://[::1]/hook"},
+		{Channels: []string{ChannelWebhook}, WebhookURL: "http://0.0.0.0/hook"},
+	}
+	for _, prefs := range invalid {
+		if _, err := s.SetNotificationPreferences("carol", prefs); err == nil {
+			t.Errorf("expected %+v to be rejected", prefs)
+		}
+	}
+	if _, err := s.SetNotificationPreferences("dave", NotificationPreferences{}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestUser_GetsDigest(t *testing.T) {
+	tests := []struct {
+		user User
+		want bool
+	}{
+		{User{Email: "a@example.com"}, true},
+		{User{}, false},
+		{User{Email: "a@example.com", DigestOptOut: true}, false},
+		{User{Email: "a@example.com", Notifications: NotificationPreferences{Mode: NotifyDigestOnly}}, true},
+		{User{Email: "a@example.com", Notifications: NotificationPreferences{Mode: NotifyMute}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.user.GetsDigest(); got != tt.want {
+			t.Errorf("GetsDigest() of %+v = %v, want %v", tt.user, got, tt.want)
+		}
+	}
+}
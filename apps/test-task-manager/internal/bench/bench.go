@@ -0,0 +1,272 @@
+// Package bench generates load against the task API of a running server
+// and measures the latency of each operation.
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operations, in the order they are reported.
+const (
+	OpCreate = "create"
+	OpList   = "list"
+	OpToggle = "toggle"
+	OpDelete = "delete"
+)
+
+var operations = []string{OpCreate, OpList, OpToggle, OpDelete}
+
+// Config describes a load test.
+type Config struct {
+	BaseURL     string
+	Token       string         // API key; no authentication when empty
+	Duration    time.Duration  // How long requests are sent
+	Concurrency int            // Number of concurrent workers
+	Mix         map[string]int // Relative weight of each operation
+}
+
+// ParseMix parses a mix such as "create=1,list=4,toggle=2,delete=1".
+// Operations that are left out are not run.
+func ParseMix(s string) (map[string]int, error) {
+	mix := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || !slices.Contains(operations, name) {
+			return nil, fmt.Errorf("invalid mix entry %q; use <operation>=<weight> with operations %s", part, strings.Join(operations, ", "))
+		}
+		n, err := strconv.Atoi(weight)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid weight in %q", part)
+		}
+		mix[name] = n
+	}
+
+	total := 0
+	for _, n := range mix {
+		total += n
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("the mix needs at least one operation with a positive weight")
+	}
+	return mix, nil
+}
+
+// Stats are the measurements of one operation.
+type Stats struct {
+	Operation string
+	Requests  int
+	Errors    int
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+	Max       time.Duration
+}
+
+// Result is the outcome of a load test.
+type Result struct {
+	Elapsed time.Duration
+	Stats   []Stats
+}
+
+// Throughput returns the requests per second over all operations.
+func (r Result) Throughput() float64 {
+	total := 0
+	for _, s := range r.Stats {
+		total += s.Requests
+	}
+	return float64(total) / r.Elapsed.Seconds()
+}
+
+// Run sends requests until the duration passed. Toggle and
+// delete act on tasks created by the run; while none exist they create one
+// instead, so the run never touches existing data.
+func Run(ctx context.Context, c Config) (Result, error) {
+	r := &runner{
+		config: c,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{MaxIdleConnsPerHost: c.Concurrency},
+		},
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+	}
+	for _, op := range operations {
+		for range c.Mix[op] {
+			r.weighted = append(r.weighted, op)
+		}
+	}
+
+	// Fail early when the server cannot be reached at all.
+	if _, err := r.request(ctx, http.MethodGet, "/api/tasks", nil); err != nil {
+		return Result{}, err
+	}
+
+	// Requests in flight at the deadline are completed, so no task the run
+	// created goes unnoticed.
+	runCtx, cancel := context.WithTimeout(ctx, c.Duration)
+	defer cancel()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range max(c.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for runCtx.Err() == nil {
+				r.step(ctx)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	// Remove the tasks the run left behind.
+	for _, id := range r.ids {
+		r.request(context.Background(), http.MethodDelete, "/api/tasks/"+id, nil)
+	}
+
+	result := Result{Elapsed: elapsed}
+	for _, op := range operations {
+		if c.Mix[op] > 0 {
+			result.Stats = append(result.Stats, summarize(op, r.latencies[op], r.errors[op]))
+		}
+	}
+	return result, nil
+}
+
+type runner struct {
+	config   Config
+	client   *http.Client
+	weighted []string
+
+	mu        sync.Mutex
+	ids       []string // Tasks created by the run that no request is using
+	latencies map[string][]time.Duration
+	errors    map[string]int
+}
+
+// step runs one randomly chosen operation.
+func (r *runner) step(ctx context.Context) {
+	op := r.weighted[rand.IntN(len(r.weighted))]
+
+	// A task is taken out of the pool while it is toggled, so it cannot be
+	// deleted at the same time.
+	var id string
+	if op == OpToggle || op == OpDelete {
+		r.mu.Lock()
+		if len(r.ids) == 0 {
+			op = OpCreate
+		} else {
+			i := rand.IntN(len(r.ids))
+			id = r.ids[i]
+			r.ids = slices.Delete(r.ids, i, i+1)
+		}
+		r.mu.Unlock()
+	}
+
+	start := time.Now()
+	var body []byte
+	var err error
+	switch op {
+	case OpCreate:
+		body, err = r.request(ctx, http.MethodPost, "/api/tasks", map[string]string{"title": "bench " + strconv.Itoa(rand.IntN(1e6))})
+	case OpList:
+		_, err = r.request(ctx, http.MethodGet, "/api/tasks", nil)
+	case OpToggle:
+		_, err = r.request(ctx, http.MethodPatch, "/api/tasks/"+id+"/toggle", nil)
+	case OpDelete:
+		_, err = r.request(ctx, http.MethodDelete, "/api/tasks/"+id, nil)
+	}
+	latency := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies[op] = append(r.latencies[op], latency)
+	if op == OpToggle {
+		r.ids = append(r.ids, id)
+	}
+	if err != nil {
+		r.errors[op]++
+		return
+	}
+	if op == OpCreate {
+		var task struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(body, &task) == nil && task.ID != "" {
+			r.ids = append(r.ids, task.ID)
+		}
+	}
+}
+
+// request sends a request and returns the response body; status codes of
+// 400 and above are errors.
+func (r *runner) request(ctx context.Context, method, path string, in any) ([]byte, error) {
+	var body io.Reader
+	if in != nil {
+		content, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(r.config.BaseURL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.config.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("%s %s: status %d", method, path, resp.StatusCode)
+	}
+	return content, nil
+}
+
+func summarize(op string, latencies []time.Duration, errors int) Stats {
+	s := Stats{Operation: op, Requests: len(latencies), Errors: errors}
+	if len(latencies) == 0 {
+		return s
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	s.P50 = percentile(latencies, 0.50)
+	s.P90 = percentile(latencies, 0.90)
+	s.P99 = percentile(latencies, 0.99)
+	s.Max = latencies[len(latencies)-1]
+	return s
+}
+
+// percentile returns the nearest-rank percentile p (0-1) of sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
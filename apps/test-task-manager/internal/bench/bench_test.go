@@ -0,0 +1,39 @@
+package bench
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("create=1, list=4,delete=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mix[OpCreate] != 1 || mix[OpList] != 4 || mix[OpToggle] != 0 {
+		t.Errorf("unexpected mix %v", mix)
+	}
+
+	for _, invalid := range []string{"update=1", "list", "list=-1", "delete=0"} {
+		if _, err := ParseMix(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	if got := percentile(sorted, 0.5); got != 50*time.Millisecond {
+		t.Errorf("expected p50 of 50ms, got %v", got)
+	}
+	if got := percentile(sorted, 0.99); got != 99*time.Millisecond {
+		t.Errorf("expected p99 of 99ms, got %v", got)
+	}
+	if got := percentile(sorted[:1], 0.99); got != time.Millisecond {
+		t.Errorf("expected the only value, got %v", got)
+	}
+}
@@ -0,0 +1,151 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Namespaces of the properties served.
+const (
+	NSDAV    = "DAV:"
+	NSCalDAV = "urn:ietf:params:xml:ns:caldav"
+	NSCS     = "http://calendarserver.org/ns/" // getctag, for Apple clients
+)
+
+// prefixes are the namespace prefixes declared on every multistatus.
+var prefixes = map[string]string{NSDAV: "d", NSCalDAV: "c", NSCS: "cs"}
+
+// CalendarData is the property holding the iCalendar of a resource. It is
+// only returned when asked for by name.
+var CalendarData = xml.Name{Space: NSCalDAV, Local: "calendar-data"}
+
+// Request is a parsed PROPFIND or REPORT body.
+type Request struct {
+	Kind    string     // Local name of the root element, e.g. propfind or calendar-multiget
+	AllProp bool       // Every property is asked for
+	Props   []xml.Name // The properties asked for, unless AllProp
+	Hrefs   []string   // The resources a calendar-multiget asks for
+}
+
+// ParseRequest parses the body of a PROPFIND or REPORT request. An empty
+// body asks for all properties.
+func ParseRequest(r io.Reader) (Request, error) {
+	var req Request
+	decoder := xml.NewDecoder(r)
+	var path []xml.Name // Open elements
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Request{}, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case len(path) == 0:
+				req.Kind = t.Name.Local
+			case t.Name == xml.Name{Space: NSDAV, Local: "allprop"}:
+				req.AllProp = true
+			case path[len(path)-1] == xml.Name{Space: NSDAV, Local: "prop"} && len(path) == 2:
+				req.Props = append(req.Props, t.Name)
+			}
+			path = append(path, t.Name)
+		case xml.EndElement:
+			path = path[:len(path)-1]
+		case xml.CharData:
+			if len(path) == 2 && path[1] == (xml.Name{Space: NSDAV, Local: "href"}) {
+				req.Hrefs = append(req.Hrefs, strings.TrimSpace(string(t)))
+			}
+		}
+	}
+	if req.Kind == "" || req.Kind == "propfind" && len(req.Props) == 0 {
+		req.AllProp = true
+	}
+	return req, nil
+}
+
+// Response describes one resource of a multistatus response.
+type Response struct {
+	Href     string
+	Props    map[xml.Name]string // Inner XML of the properties of the resource
+	NotFound bool                // The resource does not exist
+}
+
+// WriteMultistatus answers req with the properties of responses: those
+// asked for that a resource has with 200, the others with 404.
+func WriteMultistatus(w http.ResponseWriter, req Request, responses []Response) {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="` + NSCalDAV + `" xmlns:cs="` + NSCS + `">`)
+	for _, resp := range responses {
+		b.WriteString("<d:response>" + Href(resp.Href))
+		if resp.NotFound {
+			b.WriteString("<d:status>HTTP/1.1 404 Not Found</d:status></d:response>")
+			continue
+		}
+
+		var found, missing strings.Builder
+		if req.AllProp {
+			for name, value := range resp.Props {
+				if name != CalendarData {
+					writeElement(&found, name, value)
+				}
+			}
+		}
+		for _, name := range req.Props {
+			if value, ok := resp.Props[name]; ok {
+				writeElement(&found, name, value)
+			} else if !req.AllProp {
+				writeElement(&missing, name, "")
+			}
+		}
+		if found.Len() > 0 {
+			b.WriteString("<d:propstat><d:prop>" + found.String() + "</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>")
+		}
+		if missing.Len() > 0 {
+			b.WriteString("<d:propstat><d:prop>" + missing.String() + "</d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat>")
+		}
+		b.WriteString("</d:response>")
+	}
+	b.WriteString("</d:multistatus>\n")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, b.String())
+}
+
+// writeElement writes an element with raw inner XML, declaring its
+// namespace when it has no prefix.
+func writeElement(b *strings.Builder, name xml.Name, inner string) {
+	tag := name.Local
+	decl := ""
+	if prefix, ok := prefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		tag = "x:" + name.Local
+		decl = ` xmlns:x="` + Escape(name.Space) + `"`
+	}
+	if inner == "" {
+		b.WriteString("<" + tag + decl + "/>")
+		return
+	}
+	b.WriteString("<" + tag + decl + ">" + inner + "</" + tag + ">")
+}
+
+// Href returns a DAV:href element for path.
+func Href(path string) string {
+	return "<d:href>" + Escape(path) + "</d:href>"
+}
+
+// Escape escapes s for use in XML text and attribute values.
+func Escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want Request
+	}{
+		{"empty", "", Request{AllProp: true}},
+		{"allprop", `<d:propfind xmlns:d="DAV:"><d:allprop/></d:propfind>`, Request{Kind: "propfind", AllProp: true}},
+		{"prop", `<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/><c:calendar-data/></d:prop></d:propfind>`,
+			Request{Kind: "propfind", Props: []xml.Name{{Space: NSDAV, Local: "getetag"}, CalendarData}}},
+		{"multiget", `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/></d:prop>` +
+			`<d:href> /caldav/tasks/1.ics </d:href><d:href>/caldav/tasks/2.ics</d:href></c:calendar-multiget>`,
+			Request{Kind: "calendar-multiget", Props: []xml.Name{{Space: NSDAV, Local: "getetag"}}, Hrefs: []string{"/caldav/tasks/1.ics", "/caldav/tasks/2.ics"}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRequest(strings.NewReader(tt.body))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got.Kind != tt.want.Kind || got.AllProp != tt.want.AllProp || !slices.Equal(got.Props, tt.want.Props) || !slices.Equal(got.Hrefs, tt.want.Hrefs) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, got)
+		}
+	}
+
+	if _, err := ParseRequest(strings.NewReader("<d:propfind")); err == nil {
+		t.Error("expected invalid XML to be refused")
+	}
+}
+
+func TestWriteMultistatus(t *testing.T) {
+	req := Request{Props: []xml.Name{{Space: NSDAV, Local: "getetag"}, {Space: "urn:other", Local: "color"}}}
+	w := httptest.NewRecorder()
+	WriteMultistatus(w, req, []Response{
+		{Href: "/caldav/tasks/a&b.ics", Props: map[xml.Name]string{{Space: NSDAV, Local: "getetag"}: "&quot;1&quot;"}},
+		{Href: "/caldav/tasks/gone.ics", NotFound: true},
+	})
+
+	body := w.Body.String()
+	if w.Code != http.StatusMultiStatus || w.Header().Get("Content-Type") != "application/xml; charset=utf-8" {
+		t.Fatalf("expected a multistatus, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		`<d:href>/caldav/tasks/a&amp;b.ics</d:href><d:propstat><d:prop><d:getetag>&quot;1&quot;</d:getetag></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>`,
+		`<d:propstat><d:prop><x:color xmlns:x="urn:other"/></d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat>`,
+		`<d:href>/caldav/tasks/gone.ics</d:href><d:status>HTTP/1.1 404 Not Found</d:status>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in\n%s", want, body)
+		}
+	}
+	var parsed struct{}
+	if err := xml.Unmarshal([]byte(body), &parsed); err != nil {
+		t.Errorf("expected well-formed XML, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package caldav implements the WebDAV and CalDAV (RFC 4791) parts calendar
+// applications need to keep a task list in sync: discovery, listing with
+// ETags, and the calendar-query and calendar-multiget reports.
+package caldav
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/atomicfile"
+)
+
+// Link records the resource name and UID a client gave to a task it
+// created, or the UID of an imported task. Tasks without a link are served
+// as "<id>.ics" with their ID as UID; clients expect their own names and
+// UIDs back.
+type Link struct {
+	Name   string `json:"name"`
+	UID    string `json:"uid"`
+	TaskID string `json:"taskId"`
+}
+
+// Links keeps the links, optionally persisted to a JSON file.
+type Links struct {
+	path string // Empty when kept in memory only
+
+	mu    sync.RWMutex
+	links []Link
+}
+
+// NewLinks opens the links persisted at path. The file is created on the
+// first change. With an empty path nothing is persisted.
+func NewLinks(path string) (*Links, error) {
+	l := &Links{path: path}
+	if path == "" {
+		return l, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &l.links); err != nil {
+		return nil, fmt.Errorf("failed to parse CalDAV links file %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// Add stores links, replacing earlier links with their names or tasks.
+func (l *Links) Add(links ...Link) error {
+	if len(links) == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	kept := slices.DeleteFunc(slices.Clone(l.links), func(old Link) bool {
+		return slices.ContainsFunc(links, func(link Link) bool {
+			return old.Name == link.Name || old.TaskID == link.TaskID
+		})
+	})
+	return l.replace(append(kept, links...))
+}
+
+// Task returns the ID of the task linked to uid, if any.
+func (l *Links) Task(uid string) (id string, ok bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, link := range l.links {
+		if link.UID == uid {
+			return link.TaskID, true
+		}
+	}
+	return "", false
+}
+
+// Resolve returns the ID of the task served under the resource name.
+func (l *Links) Resolve(name string) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, link := range l.links {
+		if link.Name == name {
+			return link.TaskID
+		}
+	}
+	return strings.TrimSuffix(name, ".ics")
+}
+
+// Of returns the resource name and UID of the task with id.
+func (l *Links) Of(id string) (name, uid string) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, link := range l.links {
+		if link.TaskID == id {
+			return link.Name, link.UID
+		}
+	}
+	return id + ".ics", id
+}
+
+// Retain forgets the links of the tasks for which keep returns false.
+func (l *Links) Retain(keep func(taskID string) bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	links := slices.DeleteFunc(slices.Clone(l.links), func(link Link) bool { return !keep(link.TaskID) })
+	if len(links) == len(l.links) {
+		return nil
+	}
+	return l.replace(links)
+}
+
+// replace persists links and makes them the current links. Callers must
+// hold the write lock.
+func (l *Links) replace(links []Link) error {
+	if l.path != "" {
+		content, err := json.MarshalIndent(links, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := atomicfile.Write(l.path, content); err != nil {
+			return err
+		}
+	}
+
+	l.links = links
+	return nil
+}
@@ -0,0 +1,83 @@
+package caldav
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinks_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.json")
+	links, err := NewLinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no file before the first change, got %v", err)
+	}
+
+	if err := links.Add(Link{Name: "a.ics", UID: "uid-a", TaskID: "1"}, Link{Name: "b.ics", UID: "uid-b", TaskID: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	// A new name for task 1 replaces its link.
+	if err := links.Add(Link{Name: "c.ics", UID: "uid-c", TaskID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewLinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := reopened.Resolve("c.ics"); id != "1" {
+		t.Errorf("expected c.ics to resolve to task 1, got %s", id)
+	}
+	if id := reopened.Resolve("a.ics"); id != "a" {
+		t.Errorf("expected the replaced name to resolve as a task ID, got %s", id)
+	}
+	if name, uid := reopened.Of("2"); name != "b.ics" || uid != "uid-b" {
+		t.Errorf("expected b.ics and uid-b for task 2, got %s and %s", name, uid)
+	}
+	if name, uid := reopened.Of("3"); name != "3.ics" || uid != "3" {
+		t.Errorf("expected unlinked tasks under their ID, got %s and %s", name, uid)
+	}
+	if id, ok := reopened.Task("uid-c"); !ok || id != "1" {
+		t.Errorf("expected uid-c to belong to task 1, got %s, %v", id, ok)
+	}
+
+	if err := reopened.Retain(func(id string) bool { return id != "2" }); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err = NewLinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reopened.Task("uid-b"); ok {
+		t.Error("expected the link of task 2 to be forgotten")
+	}
+	if _, ok := reopened.Task("uid-c"); !ok {
+		t.Error("expected the link of task 1 to be kept")
+	}
+}
+
+func TestLinks_InMemory(t *testing.T) {
+	links, err := NewLinks("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := links.Add(Link{Name: "a.ics", UID: "uid-a", TaskID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if id := links.Resolve("a.ics"); id != "1" {
+		t.Errorf("expected a.ics to resolve to task 1, got %s", id)
+	}
+}
+
+func TestNewLinks_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLinks(path); err == nil {
+		t.Error("expected an invalid file to be refused")
+	}
+}
@@ -0,0 +1,167 @@
+// Package capture records API requests and their responses, with headers
+// and bodies, in a ring buffer for diagnosing client integrations without
+// packet captures. Either every request is captured, or only those with a
+// Header signed with the secret of the Recorder, so support can have one
+// client capture its calls for a while.
+package capture
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Header is the request header asking for a request to be captured. Its
+// value is made by Recorder.Sign.
+const Header = "X-TTM-Capture"
+
+// MaxBodySize is the most bytes of a request or response body captured;
+// the rest is left out and the body marked truncated.
+const MaxBodySize = 64 << 10
+
+// ErrNoSecret is returned by Sign when the Recorder has no secret to sign
+// headers with.
+var ErrNoSecret = errors.New("no capture secret is configured")
+
+// redacted are the headers whose values are never captured, as they hold
+// credentials.
+var redacted = []string{"Authorization", "Cookie", "Set-Cookie", Header}
+
+// Settings configure a Recorder.
+type Settings struct {
+	All    bool   // Capture every request, rather than only signed ones
+	Secret string // Signs the values of Header; empty turns them away
+	Size   int    // Exchanges kept
+}
+
+// Exchange is a captured request and its response.
+type Exchange struct {
+	RequestID string    `json:"requestId"`
+	At        time.Time `json:"at"`
+	Method    string    `json:"method"`
+	URL       string    `json:"url"` // Path and query
+	ClientIP  string    `json:"clientIp"`
+
+	RequestHeader    http.Header `json:"requestHeader"`
+	RequestBody      string      `json:"requestBody"`
+	RequestTruncated bool        `json:"requestTruncated,omitempty"`
+
+	Status            int         `json:"status"`
+	DurationMs        int64       `json:"durationMs"`
+	ResponseHeader    http.Header `json:"responseHeader"`
+	ResponseBody      string      `json:"responseBody"`
+	ResponseTruncated bool        `json:"responseTruncated,omitempty"`
+}
+
+// Recorder keeps the latest captured exchanges. It is safe for concurrent
+// use.
+type Recorder struct {
+	settings Settings
+
+	mu        sync.Mutex
+	exchanges []Exchange // Ring of settings.Size
+	next      int        // Index the next exchange is put at
+}
+
+// New returns a Recorder without exchanges.
+func New(s Settings) *Recorder {
+	return &Recorder{settings: s, exchanges: make([]Exchange, 0, s.Size)}
+}
+
+// Wants reports whether r is to be captured: when every request is, or r
+// has a Header signed with the secret that has not expired.
+func (c *Recorder) Wants(r *http.Request) bool {
+	if c.settings.All {
+		return true
+	}
+	value := r.Header.Get(Header)
+	return value != "" && c.verify(value, time.Now())
+}
+
+// Add keeps e, dropping the oldest exchange once the buffer is full. The
+// credentials in its headers are redacted.
+func (c *Recorder) Add(e Exchange) {
+	e.RequestHeader, e.ResponseHeader = redact(e.RequestHeader), redact(e.ResponseHeader)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.exchanges) < c.settings.Size {
+		c.exchanges = append(c.exchanges, e)
+	} else {
+		c.exchanges[c.next] = e
+	}
+	c.next = (c.next + 1) % c.settings.Size
+}
+
+// List returns the kept exchanges, newest first.
+func (c *Recorder) List() []Exchange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := make([]Exchange, 0, len(c.exchanges))
+	for i := range len(c.exchanges) {
+		list = append(list, c.exchanges[(c.next-1-i+len(c.exchanges))%len(c.exchanges)])
+	}
+	return list
+}
+
+// Clear drops the kept exchanges.
+func (c *Recorder) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exchanges, c.next = c.exchanges[:0], 0
+}
+
+// Sign returns a value of Header that has requests captured until expires.
+func (c *Recorder) Sign(expires time.Time) (string, error) {
+	if c.settings.Secret == "" {
+		return "", ErrNoSecret
+	}
+	expiry := strconv.FormatInt(expires.Unix(), 10)
+	return expiry + "." + c.mac(expiry), nil
+}
+
+// verify reports whether value was made by Sign and has not expired at now.
+func (c *Recorder) verify(value string, now time.Time) bool {
+	if c.settings.Secret == "" {
+		return false
+	}
+	expiry, mac, ok := strings.Cut(value, ".")
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if !ok || err != nil || now.Unix() >= unix {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(c.mac(expiry)))
+}
+
+func (c *Recorder) mac(expiry string) string {
+	h := hmac.New(sha256.New, []byte(c.settings.Secret))
+	h.Write([]byte(expiry))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Body returns the text of a body captured up to MaxBodySize bytes, or a
+// note of its size when it is not text.
+func Body(b []byte) string {
+	if !utf8.Valid(b) {
+		return "(" + strconv.Itoa(len(b)) + " bytes of binary data)"
+	}
+	return string(b)
+}
+
+// redact returns a copy of h with the values of the redacted headers
+// replaced.
+func redact(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range redacted {
+		if _, ok := h[name]; ok {
+			h[name] = []string{"[redacted]"}
+		}
+	}
+	return h
+}
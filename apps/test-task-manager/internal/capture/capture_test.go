@@ -0,0 +1,83 @@
+package capture
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRecorder_Ring(t *testing.T) {
+	rec := New(Settings{Size: 2})
+	for i := range 3 {
+		rec.Add(Exchange{RequestID: strconv.Itoa(i)})
+	}
+	list := rec.List()
+	if len(list) != 2 || list[0].RequestID != "2" || list[1].RequestID != "1" {
+		t.Fatalf("expected the latest 2 exchanges, newest first, got %+v", list)
+	}
+	rec.Clear()
+	if list := rec.List(); len(list) != 0 {
+		t.Errorf("expected no exchanges after Clear, got %+v", list)
+	}
+	rec.Add(Exchange{RequestID: "3"})
+	if list := rec.List(); len(list) != 1 || list[0].RequestID != "3" {
+		t.Errorf("expected only the exchange added after Clear, got %+v", list)
+	}
+}
+
+func TestRecorder_Redacts(t *testing.T) {
+	rec := New(Settings{Size: 1})
+	header := http.Header{"Authorization": {"Bearer secret"}, "Content-Type": {"application/json"}}
+	rec.Add(Exchange{RequestHeader: header, ResponseHeader: http.Header{"Set-Cookie": {"session=secret"}}})
+	e := rec.List()[0]
+	if e.RequestHeader.Get("Authorization") != "[redacted]" || e.ResponseHeader.Get("Set-Cookie") != "[redacted]" {
+		t.Errorf("expected credentials to be redacted, got %v and %v", e.RequestHeader, e.ResponseHeader)
+	}
+	if e.RequestHeader.Get("Content-Type") != "application/json" {
+		t.Errorf("expected other headers to be kept, got %v", e.RequestHeader)
+	}
+	if header.Get("Authorization") != "Bearer secret" {
+		t.Error("expected the captured header not to be changed")
+	}
+}
+
+func TestRecorder_Wants(t *testing.T) {
+	rec := New(Settings{Secret: "0123456789abcdef", Size: 1})
+	request := func(value string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+		if value != "" {
+			r.Header.Set(Header, value)
+		}
+		return r
+	}
+
+	valid, err := rec.Sign(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _ := rec.Sign(time.Now().Add(-time.Second))
+	other, _ := New(Settings{Secret: "fedcba9876543210", Size: 1}).Sign(time.Now().Add(time.Hour))
+	for value, want := range map[string]bool{valid: true, expired: false, other: false, "": false, "garbage": false} {
+		if got := rec.Wants(request(value)); got != want {
+			t.Errorf("Wants with %s %q: expected %v, got %v", Header, value, want, got)
+		}
+	}
+
+	if !New(Settings{All: true, Size: 1}).Wants(request("")) {
+		t.Error("expected every request to be wanted when capturing all")
+	}
+	if _, err := New(Settings{Size: 1}).Sign(time.Now()); err != ErrNoSecret {
+		t.Errorf("expected ErrNoSecret without a secret, got %v", err)
+	}
+}
+
+func TestBody(t *testing.T) {
+	if got := Body([]byte(`{"title":"Plan"}`)); got != `{"title":"Plan"}` {
+		t.Errorf("expected text to be kept, got %q", got)
+	}
+	if got := Body([]byte{0xff, 0xfe, 0x00}); got != "(3 bytes of binary data)" {
+		t.Errorf("expected a note for binary data, got %q", got)
+	}
+}
@@ -0,0 +1,213 @@
+// Package cron parses cron expressions and runs functions on their schedule.
+package cron
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // Bit n is set when value n matches
+
+	// Whether the day of month and day of week fields were restricted. When
+	// both are, a day matching either of them matches, as in cron.
+	domRestricted, dowRestricted bool
+}
+
+// field describes the range of a field of a cron expression.
+type field struct {
+	name     string
+	min, max int
+	names    []string // Names of the values from min, if any
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	dowField    = field{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// descriptors are the shorthands for common schedules.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a standard five field cron expression (minute, hour, day of
+// month, month and day of week) or one of the descriptors such as @daily.
+// Fields hold *, values, ranges (1-5), steps (*/15, 1-10/2) and lists of
+// those (1,15,30); months and days of week may be named (jan, mon). Both 0
+// and 7 mean Sunday.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@") {
+		spec, ok := descriptors[strings.ToLower(expr)]
+		if !ok {
+			return Schedule{}, fmt.Errorf("cron expression %q: unknown descriptor", expr)
+		}
+		expr = spec
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+
+	var s Schedule
+	var err error
+	for i, target := range []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow} {
+		f := []field{minuteField, hourField, domField, monthField, dowField}[i]
+		if *target, err = f.parse(fields[i]); err != nil {
+			return Schedule{}, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is Sunday too
+	}
+	s.domRestricted = fields[2] != "*"
+	s.dowRestricted = fields[4] != "*"
+	return s, nil
+}
+
+// parse returns the bitset of the values matching spec.
+func (f field) parse(spec string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepSpec)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepSpec, f.name)
+			}
+			step = n
+		}
+
+		var from, to int
+		switch lo, hi, isRange := strings.Cut(rangeSpec, "-"); {
+		case rangeSpec == "*":
+			from, to = f.min, f.max
+		case isRange:
+			var err error
+			if from, err = f.value(lo); err != nil {
+				return 0, err
+			}
+			if to, err = f.value(hi); err != nil {
+				return 0, err
+			}
+			if from > to {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeSpec, f.name)
+			}
+		default:
+			v, err := f.value(rangeSpec)
+			if err != nil {
+				return 0, err
+			}
+			from, to = v, v
+			if hasStep {
+				to = f.max // 5/15 means from 5 on, every 15
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// value parses a single value or name of the field.
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s %q must be between %d and %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t matching the schedule, in the
+// location of t. It returns the zero time when nothing matches within five
+// years, as for February 30th.
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			// Jump straight to the next matching minute of this hour, if any.
+			rest := s.minute >> uint(t.Minute())
+			if rest == 0 {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			} else {
+				t = t.Add(time.Duration(bits.TrailingZeros64(rest)) * time.Minute)
+			}
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s Schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// Run calls fn at every time of the schedule in local time until ctx is
+// done. A call that runs past the next time delays it rather than
+// overlapping.
+func Run(ctx context.Context, s Schedule, fn func(now time.Time)) {
+	RunIn(ctx, s, time.Local, fn)
+}
+
+// RunIn is like Run, matching the schedule against the time in loc.
+func RunIn(ctx context.Context, s Schedule, loc *time.Location, fn func(now time.Time)) {
+	for {
+		next := s.Next(time.Now().In(loc))
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case now := <-timer.C:
+			fn(now)
+		}
+	}
+}
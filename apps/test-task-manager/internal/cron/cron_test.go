@@ -0,0 +1,46 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// A Wednesday.
+	from := time.Date(2024, 5, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 15, 10, 15, 0, 0, time.UTC)},
+		{"5 * * * *", time.Date(2024, 5, 15, 11, 5, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 5, 15, 13, 0, 0, 0, time.UTC)},
+		{"30 8 * * mon-fri", time.Date(2024, 5, 16, 8, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan,jul *", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * fri", time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)}, // Day of month or week
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): expected no error, got %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next of %q: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "@fortnightly"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): expected an error", expr)
+		}
+	}
+}
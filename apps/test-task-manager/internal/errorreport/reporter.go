@@ -0,0 +1,29 @@
+// Package errorreport sends unexpected errors and panics to an error tracker.
+package errorreport
+
+import (
+	"net/http"
+	"time"
+)
+
+// Reporter captures unexpected errors with their request context.
+type Reporter interface {
+	// CaptureError reports an error that produced a 5xx response.
+	CaptureError(r *http.Request, err error)
+	// CapturePanic reports a recovered panic and its stack.
+	CapturePanic(r *http.Request, value any, stack []byte)
+	// Flush waits up to timeout for queued reports to be delivered.
+	Flush(timeout time.Duration)
+}
+
+// Nop is a Reporter that discards everything. It is used when reporting is disabled.
+type Nop struct{}
+
+// CaptureError implements Reporter.
+func (Nop) CaptureError(*http.Request, error) {}
+
+// CapturePanic implements Reporter.
+func (Nop) CapturePanic(*http.Request, any, []byte) {}
+
+// Flush implements Reporter.
+func (Nop) Flush(time.Duration) {}
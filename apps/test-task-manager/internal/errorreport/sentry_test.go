@@ -0,0 +1,70 @@
+package errorreport
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestNewSentry_InvalidDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		dsn  string
+	}{
+		{"missing key", "https://sentry.example.com/42"},
+		{"missing project", "https://key@sentry.example.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSentry(SentryOptions{DSN: tt.dsn}, zap.NewNop().Sugar()); err == nil {
+				t.Errorf("expected error for DSN %q", tt.dsn)
+			}
+		})
+	}
+}
+
+func TestSentry_CaptureError(t *testing.T) {
+	events := make(chan sentryEvent, 1)
+	var auth, path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("X-Sentry-Auth")
+		path = r.URL.Path
+		var event sentryEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "http://", "http://public@", 1) + "/42"
+	reporter, err := NewSentry(SentryOptions{DSN: dsn, Environment: "stage"}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Request-ID", "req-1")
+	reporter.CaptureError(req, errors.New("store unavailable"))
+	reporter.Flush(time.Second)
+
+	event := <-events
+	if path != "/api/42/store/" {
+		t.Errorf("expected store endpoint, got %s", path)
+	}
+	if !strings.Contains(auth, "sentry_key=public") {
+		t.Errorf("expected auth header with public key, got %q", auth)
+	}
+	if event.Environment != "stage" || event.Tags["request_id"] != "req-1" {
+		t.Errorf("expected environment and request ID tags, got %+v", event)
+	}
+	if _, ok := event.Request.Headers["Authorization"]; ok {
+		t.Error("expected Authorization header to be stripped")
+	}
+}
@@ -0,0 +1,176 @@
+package events
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/atomicfile"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// maxDeadLetters is the number of dead-lettered deliveries kept per
+// webhook; the oldest are dropped.
+const maxDeadLetters = 1000
+
+// Statuses of a Delivery.
+const (
+	DeliveryRetrying  = "retrying"  // Failed, to be attempted again at NextAttemptAt
+	DeliveryDead      = "dead"      // Out of attempts, until it is redelivered
+	DeliveryDelivered = "delivered" // Delivered while another webhook still retries the event
+)
+
+// Delivery is the state of delivering an event to a webhook that failed at
+// least once.
+type Delivery struct {
+	Seq           int64       `json:"seq"` // Of the event
+	Status        string      `json:"status"`
+	Attempts      int         `json:"attempts"`
+	LastError     string      `json:"lastError,omitempty"`
+	LastAttemptAt time.Time   `json:"lastAttemptAt"`
+	NextAttemptAt *time.Time  `json:"nextAttemptAt,omitempty"` // While retrying
+	Event         store.Event `json:"event"`
+}
+
+// deliveryKey identifies the delivery of an event to a webhook.
+type deliveryKey struct {
+	webhook string // Webhook.key
+	seq     int64
+}
+
+// DeliveryLog keeps the deliveries of events to webhooks that failed,
+// optionally persisted to a JSON file, so retries and dead letters survive
+// restarts.
+type DeliveryLog struct {
+	path string // Empty when kept in memory only
+
+	mu         sync.Mutex
+	deliveries map[deliveryKey]Delivery
+}
+
+// storedDelivery is a Delivery as persisted, with its webhook.
+type storedDelivery struct {
+	Webhook string `json:"webhook"`
+	Delivery
+}
+
+// NewDeliveryLog opens the deliveries persisted at path. The file is
+// created on the first save. With an empty path nothing is persisted.
+func NewDeliveryLog(path string) (*DeliveryLog, error) {
+	l := &DeliveryLog{path: path, deliveries: make(map[deliveryKey]Delivery)}
+	if path == "" {
+		return l, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored []storedDelivery
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook deliveries file %s: %w", path, err)
+	}
+	for _, d := range stored {
+		l.deliveries[deliveryKey{d.Webhook, d.Seq}] = d.Delivery
+	}
+	return l, nil
+}
+
+// get returns the delivery of event seq to webhook.
+func (l *DeliveryLog) get(webhook string, seq int64) (Delivery, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	d, ok := l.deliveries[deliveryKey{webhook, seq}]
+	return d, ok
+}
+
+// put records d as the delivery of its event to webhook. Dead letters
+// beyond maxDeadLetters of the webhook drop the oldest.
+func (l *DeliveryLog) put(webhook string, d Delivery) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.deliveries[deliveryKey{webhook, d.Seq}] = d
+	if d.Status != DeliveryDead {
+		return
+	}
+
+	var dead []int64
+	for key, other := range l.deliveries {
+		if key.webhook == webhook && other.Status == DeliveryDead {
+			dead = append(dead, key.seq)
+		}
+	}
+	if len(dead) > maxDeadLetters {
+		slices.Sort(dead)
+		for _, seq := range dead[:len(dead)-maxDeadLetters] {
+			delete(l.deliveries, deliveryKey{webhook, seq})
+		}
+	}
+}
+
+// remove forgets the delivery of event seq to webhook.
+func (l *DeliveryLog) remove(webhook string, seq int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.deliveries, deliveryKey{webhook, seq})
+}
+
+// settle forgets the deliveries of event seq that are not dead letters,
+// once every webhook is done with it, and reports whether any were.
+func (l *DeliveryLog) settle(seq int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	settled := false
+	for key, d := range l.deliveries {
+		if key.seq == seq && d.Status != DeliveryDead {
+			delete(l.deliveries, key)
+			settled = true
+		}
+	}
+	return settled
+}
+
+// list returns the retrying and dead-lettered deliveries to webhook, by
+// event sequence number.
+func (l *DeliveryLog) list(webhook string) []Delivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var list []Delivery
+	for key, d := range l.deliveries {
+		if key.webhook == webhook && d.Status != DeliveryDelivered {
+			list = append(list, d)
+		}
+	}
+	slices.SortFunc(list, func(a, b Delivery) int { return cmp.Compare(a.Seq, b.Seq) })
+	return list
+}
+
+// Save persists the deliveries.
+func (l *DeliveryLog) Save() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.path == "" {
+		return nil
+	}
+
+	stored := make([]storedDelivery, 0, len(l.deliveries))
+	for key, d := range l.deliveries {
+		stored = append(stored, storedDelivery{Webhook: key.webhook, Delivery: d})
+	}
+	slices.SortFunc(stored, func(a, b storedDelivery) int {
+		return cmp.Or(cmp.Compare(a.Webhook, b.Webhook), cmp.Compare(a.Seq, b.Seq))
+	})
+	content, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(l.path, content)
+}
@@ -0,0 +1,244 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/health"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/jobs"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"go.uber.org/zap"
+)
+
+// Errors of the webhook deliveries.
+var (
+	// ErrDeferred is returned by sinks that publish an event later, so the
+	// relay keeps it pending without counting a failure.
+	ErrDeferred         = errors.New("event delivery deferred")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrRedeliveryFailed = errors.New("webhook redelivery failed")
+)
+
+// WebhookInfo identifies a webhook of a Dispatcher.
+type WebhookInfo struct {
+	ID   string `json:"id"`   // Position in the configured webhooks, from 1
+	Name string `json:"name"` // Host and path of the URL
+}
+
+// Dispatcher is the Sink delivering events to webhooks. Failed deliveries
+// are retried with the backoff of a retry policy and dead-lettered once
+// they are out of attempts, in a DeliveryLog. Until every webhook has
+// delivered or dead-lettered an event, Publish returns ErrDeferred, so the
+// relay keeps the later events of its task waiting.
+type Dispatcher struct {
+	hooks   []*Webhook
+	retry   jobs.RetryPolicy
+	log     *DeliveryLog
+	timeout time.Duration // Of redeliveries
+	logger  *zap.SugaredLogger
+	now     func() time.Time
+	outcome health.Outcome
+
+	attempts *metrics.CounterVec
+}
+
+// NewDispatcher creates a dispatcher delivering the events of workspace to
+// hooks, retried with retry and recorded in log. Redeliveries get up to
+// timeout.
+func NewDispatcher(hooks []*Webhook, retry jobs.RetryPolicy, log *DeliveryLog, timeout time.Duration, workspace string, logger *zap.SugaredLogger, reg *metrics.Registry) *Dispatcher {
+	d := &Dispatcher{
+		hooks:    hooks,
+		retry:    retry,
+		log:      log,
+		timeout:  timeout,
+		logger:   logger,
+		now:      time.Now,
+		attempts: reg.CounterVec("webhook_delivery_attempts_total", "Attempts to deliver task events to webhooks by webhook and result.", "webhook", "result"),
+	}
+	reg.GaugeFuncVec("webhook_dead_letters", "Webhook deliveries that ran out of attempts.", "workspace").Set(func() float64 {
+		n := 0
+		for _, hook := range d.hooks {
+			for _, delivery := range d.log.list(hook.key()) {
+				if delivery.Status == DeliveryDead {
+					n++
+				}
+			}
+		}
+		return float64(n)
+	}, workspace)
+	return d
+}
+
+// Name implements Sink.
+func (d *Dispatcher) Name() string {
+	return "webhooks"
+}
+
+// Publish implements Sink. It attempts the webhooks that have neither
+// delivered nor dead-lettered e, and whose next attempt is due, at once.
+func (d *Dispatcher) Publish(ctx context.Context, e store.Event) error {
+	now := d.now()
+	due := make([]*Webhook, 0, len(d.hooks))
+	previous := make([]Delivery, 0, len(d.hooks))
+	waiting := false
+	for _, hook := range d.hooks {
+		delivery, ok := d.log.get(hook.key(), e.Seq)
+		switch {
+		case !ok:
+			delivery = Delivery{Seq: e.Seq, Event: e}
+		case delivery.Status != DeliveryRetrying:
+			continue
+		case delivery.NextAttemptAt != nil && now.Before(*delivery.NextAttemptAt):
+			waiting = true
+			continue
+		}
+		due = append(due, hook)
+		previous = append(previous, delivery)
+	}
+
+	errs := make([]error, len(due))
+	var wg sync.WaitGroup
+	for i, hook := range due {
+		wg.Go(func() { errs[i] = hook.Publish(ctx, e) })
+	}
+	wg.Wait()
+
+	changed := false
+	var failure error   // Of the last webhook that failed
+	var delivered []int // Of due
+	for i, hook := range due {
+		delivery := previous[i]
+		delivery.Attempts++
+		delivery.LastAttemptAt = now
+		if errs[i] == nil {
+			d.attempts.With(hook.Name(), "success").Inc()
+			delivery.Status, delivery.LastError, delivery.NextAttemptAt = DeliveryDelivered, "", nil
+			previous[i] = delivery
+			delivered = append(delivered, i)
+			continue
+		}
+
+		changed = true
+		failure = fmt.Errorf("%s: %w", hook.Name(), errs[i])
+		delivery.LastError = errs[i].Error()
+		if delivery.Attempts >= d.retry.MaxAttempts {
+			delivery.Status, delivery.NextAttemptAt = DeliveryDead, nil
+			d.attempts.With(hook.Name(), "dead").Inc()
+			d.logger.Errorw("webhook delivery dead-lettered", "webhook", hook.Name(), "seq", e.Seq, "type", e.Type, "attempts", delivery.Attempts, "error", delivery.LastError)
+		} else {
+			next := now.Add(d.retry.Delay(delivery.Attempts))
+			delivery.Status, delivery.NextAttemptAt = DeliveryRetrying, &next
+			waiting = true
+			d.attempts.With(hook.Name(), "retry").Inc()
+			d.logger.Warnw("webhook delivery failed, retrying", "webhook", hook.Name(), "seq", e.Seq, "type", e.Type, "attempt", delivery.Attempts, "retryAt", next, "error", delivery.LastError)
+		}
+		d.log.put(hook.key(), delivery)
+	}
+
+	if waiting {
+		// Remember who has it, so the retries only go to the others.
+		for _, i := range delivered {
+			d.log.put(due[i].key(), previous[i])
+			changed = true
+		}
+	} else if d.log.settle(e.Seq) {
+		changed = true
+	}
+	if changed {
+		d.save()
+	}
+	if len(due) > 0 {
+		d.outcome.Record(failure)
+	}
+	if waiting {
+		return ErrDeferred
+	}
+	return nil
+}
+
+// Check is a health.Check reporting the error of the last failed attempt
+// of the latest events delivered, or nil when all of them succeeded.
+func (d *Dispatcher) Check(ctx context.Context) error {
+	return d.outcome.Check(ctx)
+}
+
+// Webhooks returns the webhooks deliveries are made to.
+func (d *Dispatcher) Webhooks() []WebhookInfo {
+	infos := make([]WebhookInfo, len(d.hooks))
+	for i, hook := range d.hooks {
+		infos[i] = WebhookInfo{ID: strconv.Itoa(i + 1), Name: hook.Name()}
+	}
+	return infos
+}
+
+// Deliveries returns the retrying and dead-lettered deliveries of the
+// webhook with id, by event sequence number.
+func (d *Dispatcher) Deliveries(id string) ([]Delivery, error) {
+	hook, err := d.webhook(id)
+	if err != nil {
+		return nil, err
+	}
+	return d.log.list(hook.key()), nil
+}
+
+// Redeliver attempts the retrying or dead-lettered delivery of event seq
+// to the webhook with id right away. A dead letter that is delivered is
+// removed; one that fails again stays, and the error wraps
+// ErrRedeliveryFailed.
+func (d *Dispatcher) Redeliver(ctx context.Context, id string, seq int64) (Delivery, error) {
+	hook, err := d.webhook(id)
+	if err != nil {
+		return Delivery{}, err
+	}
+	delivery, ok := d.log.get(hook.key(), seq)
+	if !ok || delivery.Status == DeliveryDelivered {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	err = hook.Publish(ctx, delivery.Event)
+	cancel()
+	delivery.Attempts++
+	delivery.LastAttemptAt = d.now()
+	if err != nil {
+		d.attempts.With(hook.Name(), "redelivery_failed").Inc()
+		delivery.LastError = err.Error()
+		d.log.put(hook.key(), delivery)
+		d.save()
+		return delivery, fmt.Errorf("%w: %v", ErrRedeliveryFailed, err)
+	}
+
+	d.attempts.With(hook.Name(), "redelivered").Inc()
+	wasDead := delivery.Status == DeliveryDead
+	delivery.Status, delivery.LastError, delivery.NextAttemptAt = DeliveryDelivered, "", nil
+	if wasDead {
+		d.log.remove(hook.key(), seq)
+	} else {
+		// The relay still has the event; it skips this webhook.
+		d.log.put(hook.key(), delivery)
+	}
+	d.save()
+	d.logger.Infow("webhook delivery redelivered", "webhook", hook.Name(), "seq", seq)
+	return delivery, nil
+}
+
+// webhook returns the webhook with id.
+func (d *Dispatcher) webhook(id string) (*Webhook, error) {
+	i, err := strconv.Atoi(id)
+	if err != nil || i < 1 || i > len(d.hooks) {
+		return nil, ErrWebhookNotFound
+	}
+	return d.hooks[i-1], nil
+}
+
+func (d *Dispatcher) save() {
+	if err := d.log.Save(); err != nil {
+		d.logger.Errorw("failed to save webhook deliveries", "error", err)
+	}
+}
@@ -0,0 +1,16 @@
+// Package events relays the task events recorded in the outbox of a store
+// to external sinks such as webhooks and NATS.
+package events
+
+import (
+	"context"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Sink publishes events. Events may be published more than once, so
+// receivers should deduplicate them by their sequence number.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, e store.Event) error
+}
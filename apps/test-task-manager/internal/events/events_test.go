@@ -0,0 +1,294 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/jobs"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"go.uber.org/zap"
+)
+
+type recordingSink struct {
+	name      string
+	failTask  string // Publishing events of this task fails
+	published []store.Event
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Publish(_ context.Context, e store.Event) error {
+	if e.Task.ID == s.failTask {
+		return errors.New("unavailable")
+	}
+	s.published = append(s.published, e)
+	return nil
+}
+
+func TestRelay_KeepsOrderPerTask(t *testing.T) {
+	s, err := store.NewFileStore(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.EnableOutbox()
+	first, _ := s.Create(t.Context(), model.Task{Title: "first"})
+	second, _ := s.Create(t.Context(), model.Task{Title: "second"})
+	s.Toggle(t.Context(), first.ID)
+	s.Toggle(t.Context(), second.ID)
+
+	good := &recordingSink{name: "good"}
+	flaky := &recordingSink{name: "flaky", failTask: first.ID}
+	relay := NewRelay(s, nil, time.Second, time.Second, zap.NewNop().Sugar(), metrics.NewRegistry(), good, flaky)
+
+	if _, err := relay.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	pending, _ := s.PendingEvents(10)
+	if len(pending) != 2 || pending[0].Task.ID != first.ID || pending[1].Task.ID != first.ID {
+		t.Fatalf("expected the events of the failing task to stay pending, got %+v", pending)
+	}
+	if len(good.published) != 3 || good.published[2].Task.ID != second.ID {
+		t.Fatalf("expected later events of the failing task to wait, got %+v", good.published)
+	}
+	if err := relay.Check(t.Context()); err == nil || !strings.Contains(err.Error(), "flaky") {
+		t.Errorf("expected the health check to report the failing sink, got %v", err)
+	}
+
+	flaky.failTask = ""
+	if _, err := relay.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := s.PendingEvents(10); len(pending) != 0 {
+		t.Fatalf("expected all events to be delivered, got %+v", pending)
+	}
+	if err := relay.Check(t.Context()); err != nil {
+		t.Errorf("expected the health check to recover, got %v", err)
+	}
+	if len(good.published) != 4 {
+		t.Errorf("expected a retry to skip sinks that already published, got %d events", len(good.published))
+	}
+	var types []string
+	for _, e := range flaky.published {
+		if e.Task.ID == first.ID {
+			types = append(types, e.Type)
+		}
+	}
+	if strings.Join(types, ",") != store.EventTaskCreated+","+store.EventTaskCompleted {
+		t.Errorf("expected the events of a task in order, got %v", types)
+	}
+}
+
+func TestWebhook_SignsBody(t *testing.T) {
+	var signature, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		body, signature = string(content), r.Header.Get("X-Signature-256")
+	}))
+	defer srv.Close()
+
+	hook := NewWebhook(srv.URL+"/hook?token=x", "secret", srv.Client())
+	if err := hook.Publish(context.Background(), store.Event{Seq: 1, Type: store.EventTaskCreated}); err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("expected signature %s, got %s", want, signature)
+	}
+	if strings.Contains(hook.Name(), "token") {
+		t.Errorf("expected the name to leave out the query, got %s", hook.Name())
+	}
+}
+
+func TestDispatcher_RetriesAndDeadLetters(t *testing.T) {
+	var goodHits, badHits atomic.Int32
+	var failing atomic.Bool
+	failing.Store(true)
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { goodHits.Add(1) }))
+	defer good.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		badHits.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer bad.Close()
+
+	path := filepath.Join(t.TempDir(), "deliveries.json")
+	log, err := NewDeliveryLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks := []*Webhook{NewWebhook(good.URL, "", good.Client()), NewWebhook(bad.URL, "", bad.Client())}
+	d := NewDispatcher(hooks, jobs.RetryPolicy{MaxAttempts: 2, Backoff: time.Minute}, log, time.Second, "default", zap.NewNop().Sugar(), metrics.NewRegistry())
+	clock := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return clock }
+	e := store.Event{Seq: 7, Type: store.EventTaskCreated, Task: model.Task{ID: "1"}}
+
+	if err := d.Publish(context.Background(), e); !errors.Is(err, ErrDeferred) {
+		t.Fatalf("expected the event to be deferred while a webhook retries, got %v", err)
+	}
+	deliveries, _ := d.Deliveries("2")
+	if len(deliveries) != 1 || deliveries[0].Status != DeliveryRetrying || !deliveries[0].NextAttemptAt.Equal(clock.Add(time.Minute)) {
+		t.Fatalf("expected a retry in a minute, got %+v", deliveries)
+	}
+	if reopened, _ := NewDeliveryLog(path); len(reopened.list(hooks[1].key())) != 1 {
+		t.Error("expected the retry state to be persisted")
+	}
+
+	// Before the backoff passed nothing is attempted
+	d.Publish(context.Background(), e)
+	if goodHits.Load() != 1 || badHits.Load() != 1 {
+		t.Fatalf("expected no attempts during the backoff, got %d and %d", goodHits.Load(), badHits.Load())
+	}
+
+	clock = clock.Add(time.Minute)
+	if err := d.Publish(context.Background(), e); err != nil {
+		t.Fatalf("expected the event to be done once dead-lettered, got %v", err)
+	}
+	if goodHits.Load() != 1 || badHits.Load() != 2 {
+		t.Errorf("expected only the failing webhook to be retried, got %d and %d", goodHits.Load(), badHits.Load())
+	}
+	deliveries, _ = d.Deliveries("2")
+	if len(deliveries) != 1 || deliveries[0].Status != DeliveryDead || deliveries[0].Attempts != 2 || deliveries[0].Event.Task.ID != "1" {
+		t.Fatalf("expected a dead letter after 2 attempts, got %+v", deliveries)
+	}
+
+	if _, err := d.Redeliver(context.Background(), "2", 7); !errors.Is(err, ErrRedeliveryFailed) {
+		t.Errorf("expected the redelivery to fail, got %v", err)
+	}
+	failing.Store(false)
+	if delivery, err := d.Redeliver(context.Background(), "2", 7); err != nil || delivery.Status != DeliveryDelivered {
+		t.Fatalf("expected the redelivery to succeed, got %+v %v", delivery, err)
+	}
+	if deliveries, _ := d.Deliveries("2"); len(deliveries) != 0 {
+		t.Errorf("expected the dead letter to be gone, got %+v", deliveries)
+	}
+
+	if _, err := d.Deliveries("3"); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected webhook 3 not to exist, got %v", err)
+	}
+	if _, err := d.Redeliver(context.Background(), "1", 99); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("expected no delivery 99, got %v", err)
+	}
+}
+
+func TestNATS_Publish(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "PUB "):
+				received <- strings.Fields(line)[1]
+				r.ReadString('\n') // Payload
+			case strings.HasPrefix(line, "PING"):
+				conn.Write([]byte("PONG\r\n"))
+			}
+		}
+	}()
+
+	sink, err := NewNATS("nats://"+ln.Addr().String(), "tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sink.Publish(ctx, store.Event{Seq: 1, Type: store.EventTaskDeleted}); err != nil {
+		t.Fatal(err)
+	}
+	if subject := <-received; subject != "tasks.task.deleted" {
+		t.Errorf("expected subject tasks.task.deleted, got %s", subject)
+	}
+}
+
+func TestHistory_ReplaysAndExpires(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	h, err := OpenHistory(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for seq := int64(1); seq <= 5; seq++ {
+		if err := h.Record(store.Event{Seq: seq, Type: "task.created"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Events already kept are not recorded again
+	h.Record(store.Event{Seq: 4}, store.Event{Seq: 6, Type: "task.deleted"})
+
+	seqs := func(events []store.Event) []int64 {
+		var seqs []int64
+		for _, e := range events {
+			seqs = append(seqs, e.Seq)
+		}
+		return seqs
+	}
+	for _, h := range []*History{h, must(OpenHistory(path, 3))} {
+		if _, _, err := h.Since(2, 10); !errors.Is(err, ErrHistoryExpired) {
+			t.Errorf("expected a replay from a dropped event to be expired, got %v", err)
+		}
+		events, more, err := h.Since(3, 2)
+		if err != nil || !more || len(events) != 2 || events[0].Seq != 4 || events[1].Seq != 5 {
+			t.Errorf("expected events 4 and 5 with more to follow, got %v %v %v", seqs(events), more, err)
+		}
+		events, more, err = h.Since(5, 2)
+		if err != nil || more || len(events) != 1 || events[0].Type != "task.deleted" {
+			t.Errorf("expected the last event, got %v %v %v", seqs(events), more, err)
+		}
+		if events, _, _ := h.Since(6, 2); len(events) != 0 {
+			t.Errorf("expected no events after the newest, got %v", seqs(events))
+		}
+	}
+
+	// Compacting the file keeps what can be replayed
+	h.Record(store.Event{Seq: 7}, store.Event{Seq: 8})
+	compacted := must(OpenHistory(path, 3))
+	if _, _, err := compacted.Since(4, 10); !errors.Is(err, ErrHistoryExpired) {
+		t.Errorf("expected a replay from a compacted event to be expired, got %v", err)
+	}
+	if events, _, err := compacted.Since(5, 10); err != nil || len(events) != 3 || events[2].Seq != 8 {
+		t.Errorf("expected events 6 to 8 after compacting, got %v %v", seqs(events), err)
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,187 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/atomicfile"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// ErrHistoryExpired is returned for replays from before the oldest event
+// the history still keeps.
+var ErrHistoryExpired = errors.New("events are no longer kept")
+
+// History keeps the most recent task events relayed from the outbox by
+// sequence number, so consumers that missed some can replay them. It is
+// optionally persisted to a file of one JSON event per line, appended to as
+// events are recorded and compacted once it holds twice the kept events.
+type History struct {
+	path string // Empty when kept in memory only
+	size int
+
+	mu      sync.Mutex
+	events  []store.Event // By sequence number
+	floor   int64         // Highest sequence number no longer kept
+	written int           // Lines in the file
+}
+
+// OpenHistory opens the history persisted at path, keeping the last size
+// events. The file is created when the first event is recorded. With an
+// empty path nothing is persisted.
+func OpenHistory(path string, size int) (*History, error) {
+	if size < 1 {
+		return nil, errors.New("the event history must keep at least one event")
+	}
+	h := &History{path: path, size: size}
+	if path == "" {
+		return h, nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e store.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse event history file %s line %d: %w", path, h.written+1, err)
+		}
+		h.written++
+		h.keep(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// A compacted file starts at the oldest kept event, so a full history
+	// may have dropped the ones before it.
+	if len(h.events) == size && h.floor == 0 {
+		h.floor = h.events[0].Seq - 1
+	}
+	return h, nil
+}
+
+// Record adds the events newer than the newest one kept, dropping the
+// oldest beyond the size of the history. Events are only ever added at the
+// end, so a replay never misses an event that is recorded after it.
+func (h *History) Record(events ...store.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var added []store.Event
+	for _, e := range events {
+		if e.Seq > h.last() {
+			h.keep(e)
+			added = append(added, e)
+		}
+	}
+	if len(added) == 0 || h.path == "" {
+		return nil
+	}
+	if h.written+len(added) > 2*h.size {
+		return h.compact()
+	}
+	return h.append(added)
+}
+
+// Since returns up to limit events after sequence number from, oldest
+// first, and whether more follow them. Replays from before the oldest kept
+// event return ErrHistoryExpired.
+func (h *History) Since(from int64, limit int) (events []store.Event, more bool, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if from < h.floor {
+		return nil, false, fmt.Errorf("%w: the oldest kept event follows %d", ErrHistoryExpired, h.floor)
+	}
+	i := sort.Search(len(h.events), func(i int) bool { return h.events[i].Seq > from })
+	rest := h.events[i:]
+	if len(rest) > limit {
+		return append([]store.Event(nil), rest[:limit]...), true, nil
+	}
+	return append([]store.Event(nil), rest...), false, nil
+}
+
+// Oldest returns the sequence number replays must start at or after:
+// events after it are kept.
+func (h *History) Oldest() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.floor
+}
+
+// last returns the sequence number of the newest kept event. Callers must
+// hold the lock.
+func (h *History) last() int64 {
+	if len(h.events) == 0 {
+		return h.floor
+	}
+	return h.events[len(h.events)-1].Seq
+}
+
+// keep adds e and drops the oldest events beyond the size. Callers must
+// hold the lock.
+func (h *History) keep(e store.Event) {
+	h.events = append(h.events, e)
+	if drop := len(h.events) - h.size; drop > 0 {
+		h.floor = h.events[drop-1].Seq
+		h.events = append(h.events[:0], h.events[drop:]...)
+	}
+}
+
+// append writes events to the end of the file. Callers must hold the lock.
+func (h *History) append(events []store.Event) error {
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, e := range events {
+		if err := encoder.Encode(e); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	h.written += len(events)
+	return nil
+}
+
+// compact replaces the file with the kept events. Callers must hold the
+// lock.
+func (h *History) compact() error {
+	err := atomicfile.WriteFunc(h.path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, e := range h.events {
+			if err := encoder.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	h.written = len(h.events)
+	return nil
+}
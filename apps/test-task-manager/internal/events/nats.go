@@ -0,0 +1,186 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// natsDialTimeout bounds connecting to the NATS server when the context has
+// no deadline.
+const natsDialTimeout = 10 * time.Second
+
+// NATS publishes events to a NATS server as "<subject>.<type>", for
+// example "tasks.task.created", speaking the plain text client protocol.
+// Every publish is confirmed with a PING, so a nil error means the server
+// has received the event. TLS is not supported.
+type NATS struct {
+	addr    string
+	user    string
+	pass    string
+	subject string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewNATS creates a sink publishing to the server at rawURL
+// ("nats://[user:pass@]host[:port]") under subject. It connects on the
+// first publish.
+func NewNATS(rawURL, subject string) (*NATS, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "nats" || u.Hostname() == "" {
+		return nil, fmt.Errorf("NATS URL must look like nats://host:port, got %q", rawURL)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	n := &NATS{addr: addr, subject: subject}
+	if u.User != nil {
+		n.user = u.User.Username()
+		n.pass, _ = u.User.Password()
+	}
+	return n, nil
+}
+
+// Name implements Sink.
+func (n *NATS) Name() string {
+	return "nats"
+}
+
+// Publish implements Sink. The connection is dropped after a failure and
+// made again on the next publish.
+func (n *NATS) Publish(ctx context.Context, e store.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return fmt.Errorf("failed to connect to NATS at %s: %w", n.addr, err)
+		}
+	}
+
+	err = n.publish(ctx, n.subject+"."+e.Type, payload)
+	if err != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+	return err
+}
+
+// Close closes the connection to the server.
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
+
+// connect dials the server and completes the handshake. Callers must hold
+// the lock.
+func (n *NATS) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: natsDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if !strings.HasPrefix(line, "INFO ") || json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info) != nil {
+		conn.Close()
+		return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+	if info.TLSRequired {
+		conn.Close()
+		return errors.New("the server requires TLS, which is not supported")
+	}
+
+	options, err := json.Marshal(map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "test-task-manager",
+		"lang":     "go",
+		"user":     n.user,
+		"pass":     n.pass,
+	})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\n", options); err != nil {
+		conn.Close()
+		return err
+	}
+
+	n.conn, n.r = conn, r
+	return nil
+}
+
+// publish sends a message and waits for the server to confirm it. Callers
+// must hold the lock.
+func (n *NATS) publish(ctx context.Context, subject string, payload []byte) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(natsDialTimeout)
+	}
+	n.conn.SetDeadline(deadline)
+
+	if _, err := fmt.Fprintf(n.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload); err != nil {
+		return err
+	}
+
+	// Errors in CONNECT or PUB arrive before the PONG.
+	for {
+		line, err := n.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// INFO updates and +OK are ignored.
+	}
+}
@@ -0,0 +1,168 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/health"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"go.uber.org/zap"
+)
+
+// relayBatchSize is the number of events read from the outbox at once.
+const relayBatchSize = 100
+
+// Relay drains the outbox of a store to the sinks. An event is removed
+// from the outbox once every sink has published it, so events survive
+// restarts and failing sinks until they are delivered at least once. The
+// events of a task are published in order: after a failure, the later
+// events of the task wait until the failed one has been delivered, while
+// the events of other tasks go ahead. Sinks retrying on their own terms,
+// such as the Dispatcher of webhooks, return ErrDeferred until they are
+// done with an event. Pending events are recorded in a History, when
+// given, before they are published.
+type Relay struct {
+	outbox   store.Outbox
+	history  *History
+	sinks    []Sink
+	interval time.Duration
+	timeout  time.Duration
+	logger   *zap.SugaredLogger
+
+	// published records the sinks that already published an event, so a
+	// retry only goes to the sinks that failed.
+	published map[int64]map[string]bool
+	outcome   health.Outcome
+
+	delivered *metrics.CounterVec
+	failed    *metrics.CounterVec
+}
+
+// NewRelay creates a relay polling outbox every interval and recording
+// the events in history, which may be nil. Sinks get up to timeout per
+// event.
+func NewRelay(outbox store.Outbox, history *History, interval, timeout time.Duration, logger *zap.SugaredLogger, reg *metrics.Registry, sinks ...Sink) *Relay {
+	return &Relay{
+		outbox:    outbox,
+		history:   history,
+		sinks:     sinks,
+		interval:  interval,
+		timeout:   timeout,
+		logger:    logger,
+		published: make(map[int64]map[string]bool),
+		delivered: reg.CounterVec("events_delivered_total", "Task events published by sink.", "sink"),
+		failed:    reg.CounterVec("event_delivery_failures_total", "Failed attempts to publish task events by sink.", "sink"),
+	}
+}
+
+// Run relays events until ctx is done. It polls the outbox every interval
+// and right away while there are more pending events than fit in a batch.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		more, err := r.Drain(ctx)
+		if err != nil {
+			r.logger.Warnw("failed to relay events", "error", err)
+		}
+		if more && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.interval):
+		}
+	}
+}
+
+// Check is a health.Check reporting the error of the last Drain, or of the
+// last sink that failed to publish during it.
+func (r *Relay) Check(ctx context.Context) error {
+	return r.outcome.Check(ctx)
+}
+
+// Drain publishes a batch of pending events and acknowledges the delivered
+// ones. more reports that the batch was full and fully delivered, so more
+// events may be waiting.
+func (r *Relay) Drain(ctx context.Context) (more bool, err error) {
+	var failure error // Of the last sink that failed to publish
+	defer func() { r.outcome.Record(errors.Join(err, failure)) }()
+
+	pending, err := r.outbox.PendingEvents(relayBatchSize)
+	if err != nil {
+		return false, err
+	}
+	if r.history != nil {
+		if err := r.history.Record(pending...); err != nil {
+			r.logger.Warnw("failed to record event history", "error", err)
+		}
+	}
+
+	blocked := make(map[string]bool) // Tasks with an undelivered event
+	delivered := make([]int64, 0, len(pending))
+	for _, e := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		if blocked[e.Task.ID] {
+			continue
+		}
+		ok, err := r.publish(ctx, e)
+		if err != nil {
+			failure = err
+		}
+		if !ok {
+			blocked[e.Task.ID] = true
+			continue
+		}
+		delivered = append(delivered, e.Seq)
+	}
+
+	if len(delivered) > 0 {
+		if err := r.outbox.AckEvents(delivered...); err != nil {
+			// Unacknowledged events are published again.
+			return false, err
+		}
+		for _, seq := range delivered {
+			delete(r.published, seq)
+		}
+	}
+	return len(pending) == relayBatchSize && len(delivered) == len(pending), nil
+}
+
+// publish sends e to the sinks that have not published it yet and reports
+// whether all of them have now, and the error of the last sink that failed.
+func (r *Relay) publish(ctx context.Context, e store.Event) (ok bool, failure error) {
+	done := r.published[e.Seq]
+	if done == nil {
+		done = make(map[string]bool, len(r.sinks))
+		r.published[e.Seq] = done
+	}
+
+	ok = true
+	for _, sink := range r.sinks {
+		if done[sink.Name()] {
+			continue
+		}
+
+		sinkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		err := sink.Publish(sinkCtx, e)
+		cancel()
+		if errors.Is(err, ErrDeferred) {
+			ok = false
+			continue
+		}
+		if err != nil {
+			r.failed.With(sink.Name()).Inc()
+			r.logger.Warnw("failed to publish event", "sink", sink.Name(), "seq", e.Seq, "type", e.Type, "task", e.Task.ID, "error", err)
+			ok, failure = false, fmt.Errorf("%s: %w", sink.Name(), err)
+			continue
+		}
+		r.delivered.With(sink.Name()).Inc()
+		done[sink.Name()] = true
+	}
+	return ok, failure
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Webhook posts events as JSON to a URL. With a secret, the body is signed
+// with HMAC-SHA256 in the X-Signature-256 header as "sha256=<hex>".
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhook creates a sink posting events to endpoint.
+func NewWebhook(endpoint, secret string, client *http.Client) *Webhook {
+	return &Webhook{url: endpoint, secret: []byte(secret), client: client}
+}
+
+// Name implements Sink. It identifies the webhook without its query,
+// which may hold a token.
+func (w *Webhook) Name() string {
+	u, err := url.Parse(w.url)
+	if err != nil {
+		return "webhook"
+	}
+	return "webhook:" + u.Host + u.Path
+}
+
+// key identifies the webhook in a DeliveryLog by its URL, so its
+// deliveries are kept when the webhooks are reordered.
+func (w *Webhook) key() string {
+	sum := sha256.Sum256([]byte(w.url))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Publish implements Sink. Any response other than 2xx is a failure.
+func (w *Webhook) Publish(ctx context.Context, e store.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", e.Type)
+	req.Header.Set("X-Event-Seq", strconv.FormatInt(e.Seq, 10))
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
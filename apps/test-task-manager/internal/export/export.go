@@ -0,0 +1,113 @@
+// Package export serializes task lists for backups and scripting.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// Supported formats.
+const (
+	FormatJSON   = "json"
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{FormatJSON, FormatCSV, FormatNDJSON}
+
+// csvHeader names the CSV columns, in the order written by writeCSV.
+var csvHeader = []string{"id", "title", "completed", "createdAt", "completedAt", "priority", "color", "dueDate"}
+
+// ContentType returns the media type of format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// Write serializes tasks to w in format: an indented JSON array, CSV with a
+// header row, or newline-delimited JSON with one task per line.
+func Write(w io.Writer, format string, tasks []model.Task) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(tasks)
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		for _, task := range tasks {
+			if err := encoder.Encode(task); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		return writeCSV(w, tasks)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+func writeCSV(w io.Writer, tasks []model.Task) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		record := []string{
+			task.ID,
+			task.Title,
+			strconv.FormatBool(task.Completed),
+			task.CreatedAt.Format(time.RFC3339),
+			formatTime(task.CompletedAt),
+			task.Priority,
+			task.Color,
+			formatTime(task.DueDate),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Filter selects the tasks to export. Zero values match every task.
+type Filter struct {
+	Status   string // "open" or "completed"
+	Priority string
+}
+
+// Apply returns the tasks matching f.
+func (f Filter) Apply(tasks []model.Task) []model.Task {
+	matched := make([]model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if f.Status == "open" && task.Completed || f.Status == "completed" && !task.Completed {
+			continue
+		}
+		if f.Priority != "" && task.Priority != f.Priority {
+			continue
+		}
+		matched = append(matched, task)
+	}
+	return matched
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+func TestWrite(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tasks := []model.Task{
+		{ID: "1", Title: "plain", CreatedAt: created, Priority: "🔥", Color: "#dc3545"},
+		{ID: "2", Title: `with "quotes", commas`, Completed: true, CreatedAt: created, CompletedAt: &created, Priority: "📋", Color: "#6c757d"},
+	}
+
+	var b bytes.Buffer
+	if err := Write(&b, FormatCSV, tasks); err != nil {
+		t.Fatal(err)
+	}
+	want := "id,title,completed,createdAt,completedAt,priority,color,dueDate\n" +
+		"1,plain,false,2026-01-02T03:04:05Z,,🔥,#dc3545,\n" +
+		`2,"with ""quotes"", commas",true,2026-01-02T03:04:05Z,2026-01-02T03:04:05Z,📋,#6c757d,` + "\n"
+	if b.String() != want {
+		t.Errorf("unexpected CSV:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := Write(&b, FormatNDJSON, tasks); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(b.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], `{"id":"1"`) {
+		t.Errorf("expected one task per line, got:\n%s", b.String())
+	}
+
+	if err := Write(&b, "xml", tasks); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tasks := []model.Task{
+		{ID: "1", Priority: "🔥"},
+		{ID: "2", Priority: "🔥", Completed: true},
+		{ID: "3", Priority: "💡"},
+	}
+
+	if got := (Filter{Status: "open", Priority: "🔥"}).Apply(tasks); len(got) != 1 || got[0].ID != "1" {
+		t.Errorf("expected only task 1, got %+v", got)
+	}
+	if got := (Filter{}).Apply(tasks); len(got) != 3 {
+		t.Errorf("expected all tasks, got %+v", got)
+	}
+}
@@ -0,0 +1,43 @@
+package failover
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type markerKey struct{}
+
+// Marker records whether a request was answered from the snapshot, and how
+// old the snapshot was. It is safe for concurrent use.
+type Marker struct {
+	mu     sync.Mutex
+	stale  bool
+	lastOK time.Time
+}
+
+// NewContext returns a copy of ctx carrying a new Marker, for the Store to
+// mark the reads made with the context stale.
+func NewContext(ctx context.Context) (context.Context, *Marker) {
+	m := &Marker{}
+	return context.WithValue(ctx, markerKey{}, m), m
+}
+
+// Stale reports whether a read was served from the snapshot and, if so,
+// when the backend last answered before; the zero time when it never did.
+func (m *Marker) Stale() (bool, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stale, m.lastOK
+}
+
+// markStale marks the Marker of ctx, if any, stale as of lastOK.
+func markStale(ctx context.Context, lastOK time.Time) {
+	m, ok := ctx.Value(markerKey{}).(*Marker)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	m.stale, m.lastOK = true, lastOK
+	m.mu.Unlock()
+}
@@ -0,0 +1,389 @@
+// Package failover keeps tasks readable while a persistent store backend is
+// unreachable: Store serves reads from the last known snapshot of the tasks
+// and rejects changes until the backend answers again.
+package failover
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/apperr"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// DefaultProbeInterval is how often an unreachable backend is pinged, at
+// most, to find out whether it is back.
+const DefaultProbeInterval = 5 * time.Second
+
+// ErrReadOnly is returned for changes while the backend is unreachable.
+var ErrReadOnly = apperr.New(apperr.StoreReadOnly, "the task store is unreachable, so tasks can only be read")
+
+// Store wraps a store backend. While the backend answers, calls go through
+// and the snapshot follows the tasks: it is replaced by every GetAll and
+// updated with every change made through the Store. Once a call fails
+// without an error code, such as a lost connection, and a ping fails too,
+// the backend counts as down: reads are served from the snapshot, marked
+// stale in the context of the call (see NewContext), and changes return
+// ErrReadOnly, including the change that found the backend down. The backend is pinged every probe interval at most, on the
+// calls made meanwhile, and used again once it answers.
+//
+// Changes other processes make to a shared store reach the snapshot with
+// the next GetAll only.
+type Store struct {
+	inner         store.Store
+	probeInterval time.Duration
+	onChange      func(down bool)
+
+	mu       sync.Mutex
+	tasks    []model.Task // Snapshot in creation order; nil until loaded
+	lastOK   time.Time    // Of the last call the backend answered
+	down     bool
+	probedAt time.Time
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithProbeInterval sets how often an unreachable backend is pinged, at
+// most; DefaultProbeInterval by default.
+func WithProbeInterval(d time.Duration) Option {
+	return func(s *Store) { s.probeInterval = d }
+}
+
+// OnChange makes the Store call fn when the backend goes down (true) or
+// comes back (false), for logs and metrics.
+func OnChange(fn func(down bool)) Option {
+	return func(s *Store) { s.onChange = fn }
+}
+
+// Wrap returns a Store failing over from inner. Load the snapshot before
+// serving requests, or the first GetAll does.
+func Wrap(inner store.Store, opts ...Option) *Store {
+	s := &Store{inner: inner, probeInterval: DefaultProbeInterval, onChange: func(bool) {}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Load replaces the snapshot with the tasks of the backend.
+func (s *Store) Load(ctx context.Context) error {
+	_, err := s.GetAll(ctx)
+	return err
+}
+
+// Down reports whether the backend is unreachable and reads are served from
+// the snapshot.
+func (s *Store) Down() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.down
+}
+
+// GetAll returns all tasks.
+func (s *Store) GetAll(ctx context.Context) ([]model.Task, error) {
+	if s.failedOver(ctx) {
+		return s.read(ctx, func(tasks []model.Task) []model.Task { return slices.Clone(tasks) })
+	}
+	tasks, err := s.inner.GetAll(ctx)
+	if s.failed(ctx, err) {
+		return s.read(ctx, func(tasks []model.Task) []model.Task { return slices.Clone(tasks) })
+	}
+	if err == nil {
+		s.mu.Lock()
+		s.tasks = slices.Clone(tasks)
+		s.mu.Unlock()
+	}
+	return tasks, err
+}
+
+// Find returns the tasks matching q in creation order.
+func (s *Store) Find(ctx context.Context, q store.Query) ([]model.Task, error) {
+	if s.failedOver(ctx) {
+		return s.read(ctx, matching(q))
+	}
+	tasks, err := s.inner.Find(ctx, q)
+	if s.failed(ctx, err) {
+		return s.read(ctx, matching(q))
+	}
+	return tasks, err
+}
+
+// GetByID returns a task by ID.
+func (s *Store) GetByID(ctx context.Context, id string) (model.Task, error) {
+	byID := func(tasks []model.Task) []model.Task {
+		i := slices.IndexFunc(tasks, func(t model.Task) bool { return t.ID == id })
+		if i < 0 {
+			return nil
+		}
+		return tasks[i : i+1]
+	}
+	found := func(tasks []model.Task, err error) (model.Task, error) {
+		if err != nil {
+			return model.Task{}, err
+		}
+		if len(tasks) == 0 {
+			return model.Task{}, store.ErrTaskNotFound
+		}
+		return tasks[0], nil
+	}
+
+	if s.failedOver(ctx) {
+		return found(s.read(ctx, byID))
+	}
+	task, err := s.inner.GetByID(ctx, id)
+	if s.failed(ctx, err) {
+		return found(s.read(ctx, byID))
+	}
+	return task, err
+}
+
+// Each calls fn with the tasks matching q in creation order, streaming
+// them from the backend when it is a store.Streamer.
+func (s *Store) Each(ctx context.Context, q store.Query, fn func(model.Task) error) error {
+	streamer, ok := s.inner.(store.Streamer)
+	if !ok || s.failedOver(ctx) {
+		tasks, err := s.Find(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// Tasks fn already got cannot be taken back, so streams fail as they
+	// are.
+	err := streamer.Each(ctx, q, fn)
+	s.failed(ctx, err)
+	return err
+}
+
+// Create adds a new task.
+func (s *Store) Create(ctx context.Context, task model.Task) (model.Task, error) {
+	if s.failedOver(ctx) {
+		return model.Task{}, ErrReadOnly
+	}
+	created, err := s.inner.Create(ctx, task)
+	if s.failed(ctx, err) {
+		return model.Task{}, ErrReadOnly
+	}
+	if err == nil {
+		s.apply(func(tasks []model.Task) []model.Task { return append(tasks, created) })
+	}
+	return created, err
+}
+
+// CreateMany adds tasks in one batch.
+func (s *Store) CreateMany(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	if s.failedOver(ctx) {
+		return nil, ErrReadOnly
+	}
+	created, err := s.inner.CreateMany(ctx, tasks)
+	if s.failed(ctx, err) {
+		return nil, ErrReadOnly
+	}
+	if err == nil {
+		s.apply(func(tasks []model.Task) []model.Task { return append(tasks, created...) })
+	}
+	return created, err
+}
+
+// Toggle completes an open task or reopens a completed one.
+func (s *Store) Toggle(ctx context.Context, id string) (model.Task, error) {
+	if s.failedOver(ctx) {
+		return model.Task{}, ErrReadOnly
+	}
+	task, err := s.inner.Toggle(ctx, id)
+	if s.failed(ctx, err) {
+		return model.Task{}, ErrReadOnly
+	}
+	if err == nil {
+		s.apply(replacing(task))
+	}
+	return task, err
+}
+
+// Update replaces the editable fields of a task.
+func (s *Store) Update(ctx context.Context, update model.Task) (model.Task, error) {
+	if s.failedOver(ctx) {
+		return model.Task{}, ErrReadOnly
+	}
+	task, err := s.inner.Update(ctx, update)
+	if s.failed(ctx, err) {
+		return model.Task{}, ErrReadOnly
+	}
+	if err == nil {
+		s.apply(replacing(task))
+	}
+	return task, err
+}
+
+// Reassign sets the priority and color of the tasks matching q.
+func (s *Store) Reassign(ctx context.Context, q store.Query, priority, color string) ([]model.Task, error) {
+	if s.failedOver(ctx) {
+		return nil, ErrReadOnly
+	}
+	changed, err := s.inner.Reassign(ctx, q, priority, color)
+	if s.failed(ctx, err) {
+		return nil, ErrReadOnly
+	}
+	if err == nil {
+		s.apply(replacing(changed...))
+	}
+	return changed, err
+}
+
+// Delete removes a task.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	if s.failedOver(ctx) {
+		return ErrReadOnly
+	}
+	err := s.inner.Delete(ctx, id)
+	if s.failed(ctx, err) {
+		return ErrReadOnly
+	}
+	if err == nil {
+		s.apply(func(tasks []model.Task) []model.Task {
+			return slices.DeleteFunc(tasks, func(t model.Task) bool { return t.ID == id })
+		})
+	}
+	return err
+}
+
+// Ping checks the backend, so health checks report it as it is.
+func (s *Store) Ping(ctx context.Context) error {
+	return s.inner.Ping(ctx)
+}
+
+// KeepTombstones makes the backend keep tombstones when it is a
+// store.Tombstoner.
+func (s *Store) KeepTombstones(retention time.Duration) {
+	if tombstoner, ok := s.inner.(store.Tombstoner); ok {
+		tombstoner.KeepTombstones(retention)
+	}
+}
+
+// Tombstones returns the tombstones of the backend, which the snapshot does
+// not hold, so they are unavailable while it is down.
+func (s *Store) Tombstones(ctx context.Context, since time.Time) ([]store.Tombstone, error) {
+	tombstoner, ok := s.inner.(store.Tombstoner)
+	if !ok {
+		return nil, store.ErrTombstonesExpired
+	}
+	if s.failedOver(ctx) {
+		return nil, ErrReadOnly
+	}
+	tombstones, err := tombstoner.Tombstones(ctx, since)
+	s.failed(ctx, err)
+	return tombstones, err
+}
+
+// failedOver reports whether the backend is down, pinging it first when
+// the probe interval passed since it was last pinged.
+func (s *Store) failedOver(ctx context.Context) bool {
+	s.mu.Lock()
+	if !s.down || time.Since(s.probedAt) < s.probeInterval {
+		down := s.down
+		s.mu.Unlock()
+		return down
+	}
+	s.probedAt = time.Now()
+	s.mu.Unlock()
+
+	if err := s.inner.Ping(ctx); err != nil {
+		return true
+	}
+	// Changes made meanwhile by other processes are missing from the
+	// snapshot; a failing reload leaves it as it was.
+	tasks, err := s.inner.GetAll(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.tasks = tasks
+	}
+	s.lastOK = time.Now()
+	if s.down {
+		s.down = false
+		s.onChange(false)
+	}
+	return false
+}
+
+// failed reports whether err, returned by the backend, means it is down:
+// when err has no code and was not caused by ctx ending, and pinging the
+// backend fails too. The backend only counts as down once the snapshot is
+// loaded; before, there is nothing to fail over to.
+func (s *Store) failed(ctx context.Context, err error) bool {
+	if err == nil || apperr.CodeOf(err) != apperr.Internal {
+		s.mu.Lock()
+		s.lastOK = time.Now()
+		s.mu.Unlock()
+		return false
+	}
+	if ctx.Err() != nil || s.inner.Ping(ctx) == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tasks == nil {
+		return false
+	}
+	s.probedAt = time.Now()
+	if !s.down {
+		s.down = true
+		s.onChange(true)
+	}
+	return true
+}
+
+// read returns pick of the snapshot, marking the call stale. The backend is
+// down, so the snapshot is loaded.
+func (s *Store) read(ctx context.Context, pick func(tasks []model.Task) []model.Task) ([]model.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	markStale(ctx, s.lastOK)
+	return pick(s.tasks), nil
+}
+
+// apply changes the snapshot, when loaded, by a change made to the backend.
+func (s *Store) apply(change func(tasks []model.Task) []model.Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tasks != nil {
+		s.tasks = change(s.tasks)
+	}
+}
+
+// matching returns a pick of the tasks matching q.
+func matching(q store.Query) func(tasks []model.Task) []model.Task {
+	return func(tasks []model.Task) []model.Task {
+		matched := make([]model.Task, 0)
+		for _, task := range tasks {
+			if q.Matches(task) {
+				matched = append(matched, task)
+			}
+		}
+		return matched
+	}
+}
+
+// replacing returns a change of the snapshot replacing the tasks with the
+// IDs of changed.
+func replacing(changed ...model.Task) func(tasks []model.Task) []model.Task {
+	return func(tasks []model.Task) []model.Task {
+		for _, task := range changed {
+			if i := slices.IndexFunc(tasks, func(t model.Task) bool { return t.ID == task.ID }); i >= 0 {
+				tasks[i] = task
+			}
+		}
+		return tasks
+	}
+}
@@ -0,0 +1,170 @@
+package failover
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/faults"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// newStore returns a Store over a memory store with one task, and the
+// injector taking its backend down.
+func newStore(t *testing.T) (*Store, *faults.Injector) {
+	t.Helper()
+	injector := faults.NewInjector(faults.Settings{}, metrics.NewRegistry())
+	s := Wrap(faults.WrapStore(store.NewTaskStore(), injector), WithProbeInterval(0))
+	if _, err := s.Create(t.Context(), model.Task{Title: "Write tests", Priority: "⭐"}); err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	return s, injector
+}
+
+func setDown(t *testing.T, injector *faults.Injector, down bool) {
+	t.Helper()
+	settings := faults.Settings{Targets: []string{faults.TargetStore}}
+	if down {
+		settings.ErrorRate = 1
+	}
+	if err := injector.Update(settings); err != nil {
+		t.Fatalf("failed to update faults: %v", err)
+	}
+}
+
+func TestStore_FailsOverToSnapshot(t *testing.T) {
+	s, injector := newStore(t)
+	if err := s.Load(t.Context()); err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+	created, err := s.Create(t.Context(), model.Task{Title: "Ship it", Priority: "🔥"})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	setDown(t, injector, true)
+	ctx, marker := NewContext(t.Context())
+
+	tasks, err := s.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("expected tasks from the snapshot, got %v", err)
+	}
+	if len(tasks) != 2 || tasks[1].ID != created.ID {
+		t.Errorf("expected the snapshot to hold both tasks, got %+v", tasks)
+	}
+	if !s.Down() {
+		t.Error("expected the store to be down")
+	}
+	if stale, lastOK := marker.Stale(); !stale || lastOK.IsZero() {
+		t.Errorf("expected the read to be marked stale with the last answer, got %v at %v", stale, lastOK)
+	}
+
+	found, err := s.Find(ctx, store.Query{Priority: "🔥"})
+	if err != nil || len(found) != 1 || found[0].ID != created.ID {
+		t.Errorf("expected to find the task in the snapshot, got %+v, %v", found, err)
+	}
+	if task, err := s.GetByID(ctx, created.ID); err != nil || task.Title != "Ship it" {
+		t.Errorf("expected to get the task from the snapshot, got %+v, %v", task, err)
+	}
+	if _, err := s.GetByID(ctx, "999"); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound for unknown tasks, got %v", err)
+	}
+
+	if _, err := s.Create(ctx, model.Task{Title: "Lost", Priority: "💡"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly for changes, got %v", err)
+	}
+	if err := s.Delete(ctx, created.ID); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly for deletions, got %v", err)
+	}
+}
+
+func TestStore_RejectsFailingChange(t *testing.T) {
+	s, injector := newStore(t)
+	if err := s.Load(t.Context()); err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+
+	// The change finding the backend down is rejected like those after it
+	setDown(t, injector, true)
+	if _, err := s.Create(t.Context(), model.Task{Title: "First", Priority: "⚡"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly for the first failing change, got %v", err)
+	}
+	if !s.Down() {
+		t.Error("expected the failing change to take the store down")
+	}
+}
+
+func TestStore_Recovers(t *testing.T) {
+	s, injector := newStore(t)
+	var changes []bool
+	OnChange(func(down bool) { changes = append(changes, down) })(s)
+	if err := s.Load(t.Context()); err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+
+	setDown(t, injector, true)
+	if _, err := s.GetAll(t.Context()); err != nil {
+		t.Fatalf("expected tasks from the snapshot, got %v", err)
+	}
+	setDown(t, injector, false)
+
+	ctx, marker := NewContext(t.Context())
+	if _, err := s.Create(ctx, model.Task{Title: "Back again", Priority: "⚡"}); err != nil {
+		t.Fatalf("expected changes once the backend is back, got %v", err)
+	}
+	if s.Down() {
+		t.Error("expected the store to be up again")
+	}
+	if stale, _ := marker.Stale(); stale {
+		t.Error("expected reads from the backend not to be marked stale")
+	}
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Errorf("expected to be told of going down and coming back, got %v", changes)
+	}
+}
+
+func TestStore_ProbesAtInterval(t *testing.T) {
+	s, injector := newStore(t)
+	s.probeInterval = time.Hour
+	if err := s.Load(t.Context()); err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+
+	setDown(t, injector, true)
+	if _, err := s.GetAll(t.Context()); err != nil {
+		t.Fatalf("expected tasks from the snapshot, got %v", err)
+	}
+	setDown(t, injector, false)
+
+	if _, err := s.Create(t.Context(), model.Task{Title: "Too soon", Priority: "⚡"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly until the next probe, got %v", err)
+	}
+}
+
+func TestStore_FailsWithoutSnapshot(t *testing.T) {
+	s, injector := newStore(t)
+
+	setDown(t, injector, true)
+	if _, err := s.GetAll(t.Context()); !errors.Is(err, faults.ErrInjected) {
+		t.Errorf("expected the error of the backend without a snapshot, got %v", err)
+	}
+	if s.Down() {
+		t.Error("expected the store not to fail over without a snapshot")
+	}
+}
+
+func TestStore_KeepsCodedErrors(t *testing.T) {
+	s, _ := newStore(t)
+	if err := s.Load(t.Context()); err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+
+	if _, err := s.GetByID(t.Context(), "999"); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+	if s.Down() {
+		t.Error("expected errors with a code not to take the store down")
+	}
+}
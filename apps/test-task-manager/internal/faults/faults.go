@@ -0,0 +1,118 @@
+// Package faults injects artificial latency and errors into outbound calls,
+// so client retry behavior and alerting can be verified outside production.
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+)
+
+const (
+	// TargetStore covers all task store operations.
+	TargetStore = "store"
+	// TargetWebhook covers outbound webhook deliveries.
+	TargetWebhook = "webhook"
+)
+
+// ErrInjected is returned for calls failed on purpose by the injector.
+var ErrInjected = errors.New("injected fault")
+
+// Settings describe which faults to inject.
+type Settings struct {
+	Latency   time.Duration `json:"-"`
+	LatencyMs int64         `json:"latencyMs"`
+	ErrorRate float64       `json:"errorRate"` // Probability between 0 and 1
+	Targets   []string      `json:"targets"`   // Empty means all targets
+}
+
+// Validate checks the settings for out-of-range values.
+func (s Settings) Validate() error {
+	if s.Latency < 0 {
+		return fmt.Errorf("fault latency cannot be negative")
+	}
+	if s.ErrorRate < 0 || s.ErrorRate > 1 {
+		return fmt.Errorf("fault error rate must be between 0 and 1")
+	}
+	for _, target := range s.Targets {
+		if target != TargetStore && target != TargetWebhook {
+			return fmt.Errorf("unknown fault target %q", target)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether the settings inject anything at all.
+func (s Settings) Enabled() bool {
+	return s.Latency > 0 || s.ErrorRate > 0
+}
+
+// Injector applies the current Settings to calls. It is safe for concurrent use.
+type Injector struct {
+	mu       sync.RWMutex
+	settings Settings
+	injected *metrics.CounterVec
+}
+
+// NewInjector creates an Injector with the initial settings.
+func NewInjector(settings Settings, reg *metrics.Registry) *Injector {
+	settings.LatencyMs = settings.Latency.Milliseconds()
+	return &Injector{
+		settings: settings,
+		injected: reg.CounterVec("faults_injected_total", "Total number of injected faults.", "target", "kind"),
+	}
+}
+
+// Settings returns the current settings.
+func (i *Injector) Settings() Settings {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.settings
+}
+
+// Update replaces the current settings after validating them.
+func (i *Injector) Update(settings Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	settings.LatencyMs = settings.Latency.Milliseconds()
+
+	i.mu.Lock()
+	i.settings = settings
+	i.mu.Unlock()
+	return nil
+}
+
+// Inject delays the call and possibly fails it according to the settings for
+// target. The delay ends early with the error of ctx when ctx is done.
+func (i *Injector) Inject(ctx context.Context, target string) error {
+	settings := i.Settings()
+	if !settings.Enabled() {
+		return nil
+	}
+	if len(settings.Targets) > 0 && !slices.Contains(settings.Targets, target) {
+		return nil
+	}
+
+	if settings.Latency > 0 {
+		i.injected.With(target, "latency").Inc()
+		timer := time.NewTimer(settings.Latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if settings.ErrorRate > 0 && rand.Float64() < settings.ErrorRate {
+		i.injected.With(target, "error").Inc()
+		return fmt.Errorf("%s: %w", target, ErrInjected)
+	}
+	return nil
+}
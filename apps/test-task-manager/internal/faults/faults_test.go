@@ -0,0 +1,47 @@
+package faults
+
+import (
+	"errors"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+)
+
+func TestInjector_Inject(t *testing.T) {
+	injector := NewInjector(Settings{}, metrics.NewRegistry())
+
+	if err := injector.Inject(t.Context(), TargetStore); err != nil {
+		t.Fatalf("expected no fault when disabled, got %v", err)
+	}
+
+	if err := injector.Update(Settings{ErrorRate: 1, Targets: []string{TargetWebhook}}); err != nil {
+		t.Fatalf("expected valid settings, got %v", err)
+	}
+	if err := injector.Inject(t.Context(), TargetStore); err != nil {
+		t.Errorf("expected store to be unaffected, got %v", err)
+	}
+	if err := injector.Inject(t.Context(), TargetWebhook); !errors.Is(err, ErrInjected) {
+		t.Errorf("expected ErrInjected for webhook, got %v", err)
+	}
+}
+
+func TestSettings_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		wantErr  bool
+	}{
+		{"disabled", Settings{}, false},
+		{"error rate above one", Settings{ErrorRate: 1.5}, true},
+		{"unknown target", Settings{Targets: []string{"database"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
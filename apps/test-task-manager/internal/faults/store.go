@@ -0,0 +1,132 @@
+package faults
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// faultyStore wraps a store and injects faults before every call.
+type faultyStore struct {
+	inner    store.Store
+	injector *Injector
+}
+
+// WrapStore returns a store that injects faults for TargetStore before delegating to inner.
+func WrapStore(inner store.Store, injector *Injector) store.Store {
+	return &faultyStore{inner: inner, injector: injector}
+}
+
+func (s *faultyStore) GetAll(ctx context.Context) ([]model.Task, error) {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return nil, err
+	}
+	return s.inner.GetAll(ctx)
+}
+
+func (s *faultyStore) Find(ctx context.Context, q store.Query) ([]model.Task, error) {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return nil, err
+	}
+	return s.inner.Find(ctx, q)
+}
+
+func (s *faultyStore) GetByID(ctx context.Context, id string) (model.Task, error) {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return model.Task{}, err
+	}
+	return s.inner.GetByID(ctx, id)
+}
+
+func (s *faultyStore) Create(ctx context.Context, task model.Task) (model.Task, error) {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return model.Task{}, err
+	}
+	return s.inner.Create(ctx, task)
+}
+
+func (s *faultyStore) CreateMany(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return nil, err
+	}
+	return s.inner.CreateMany(ctx, tasks)
+}
+
+func (s *faultyStore) Toggle(ctx context.Context, id string) (model.Task, error) {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return model.Task{}, err
+	}
+	return s.inner.Toggle(ctx, id)
+}
+
+func (s *faultyStore) Update(ctx context.Context, task model.Task) (model.Task, error) {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return model.Task{}, err
+	}
+	return s.inner.Update(ctx, task)
+}
+
+func (s *faultyStore) Reassign(ctx context.Context, q store.Query, priority, color string) ([]model.Task, error) {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return nil, err
+	}
+	return s.inner.Reassign(ctx, q, priority, color)
+}
+
+func (s *faultyStore) Delete(ctx context.Context, id string) error {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return err
+	}
+	return s.inner.Delete(ctx, id)
+}
+
+func (s *faultyStore) Ping(ctx context.Context) error {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return err
+	}
+	return s.inner.Ping(ctx)
+}
+
+// Each streams the tasks of inner when it is a store.Streamer, and goes
+// through the result of Find otherwise, so the wrapper is a Streamer either
+// way.
+func (s *faultyStore) Each(ctx context.Context, q store.Query, fn func(model.Task) error) error {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return err
+	}
+	if streamer, ok := s.inner.(store.Streamer); ok {
+		return streamer.Each(ctx, q, fn)
+	}
+	tasks, err := s.inner.Find(ctx, q)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KeepTombstones makes inner keep tombstones when it is a store.Tombstoner.
+func (s *faultyStore) KeepTombstones(retention time.Duration) {
+	if tombstoner, ok := s.inner.(store.Tombstoner); ok {
+		tombstoner.KeepTombstones(retention)
+	}
+}
+
+// Tombstones returns the tombstones of inner when it is a
+// store.Tombstoner, and store.ErrTombstonesExpired otherwise, as it keeps
+// none.
+func (s *faultyStore) Tombstones(ctx context.Context, since time.Time) ([]store.Tombstone, error) {
+	if err := s.injector.Inject(ctx, TargetStore); err != nil {
+		return nil, err
+	}
+	if tombstoner, ok := s.inner.(store.Tombstoner); ok {
+		return tombstoner.Tombstones(ctx, since)
+	}
+	return nil, store.ErrTombstonesExpired
+}
@@ -3,95 +3,714 @@ package handler
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"io"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/palette"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/schema"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/seed"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
 )
 
 // APIHandler handles JSON API requests.
 type APIHandler struct {
-	service *service.TaskService
+	service  *service.TaskService
+	reporter errorreport.Reporter
+	cache    *responseCache // nil when caching is disabled
+
+	listLimit    int // Default page size of task lists (0 lists all tasks)
+	maxListLimit int // Largest page size clients may ask for (0 means no cap)
+
+	workspace string       // Served by the handler
+	plan      service.Plan // Of the workspace
+	remaining func() int   // Requests the workspace may make at once; nil without rate limit
 }
 
-// NewAPIHandler creates a new APIHandler.
-func NewAPIHandler(service *service.TaskService) *APIHandler {
-	return &APIHandler{service: service}
+// APIOption configures optional APIHandler behavior.
+type APIOption func(*APIHandler)
+
+// WithResponseCache caches serialized task lists for up to ttl, until the
+// next change made through the service. Hits and misses are counted on reg.
+func WithResponseCache(ttl time.Duration, reg *metrics.Registry) APIOption {
+	return func(h *APIHandler) {
+		if ttl > 0 {
+			h.cache = newResponseCache(ttl, reg.CounterVec("api_response_cache_requests_total", "Task list requests by response cache result.", "result"))
+		}
+	}
 }
 
-// GetTasks returns all tasks as JSON.
-func (h *APIHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
-	tasks := h.service.GetAll()
-	respondJSON(w, tasks, http.StatusOK)
+// WithListLimit caps task lists at defaultLimit tasks unless the client asks
+// for a larger page, of at most maxLimit tasks (0 means no cap), with the
+// limit query parameter.
+func WithListLimit(defaultLimit, maxLimit int) APIOption {
+	return func(h *APIHandler) {
+		h.listLimit, h.maxListLimit = defaultLimit, maxLimit
+	}
 }
 
-// CreateTask creates a new task from JSON.
-func (h *APIHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Title    string `json:"title"`
-		Priority string `json:"priority"` // Optional: defaults to 📋
-		Color    string `json:"color"`    // Optional: defaults to #6c757d
+// WithPlan reports the plan of workspace, served by the handler, on
+// GetUsage, with the requests it may make at once from remaining, which is
+// nil when the plan has no rate limit.
+func WithPlan(workspace string, plan service.Plan, remaining func() int) APIOption {
+	return func(h *APIHandler) {
+		h.workspace, h.plan, h.remaining = workspace, plan, remaining
 	}
+}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", "INVALID_INPUT", http.StatusBadRequest)
-		return
+// NewAPIHandler creates a new APIHandler.
+func NewAPIHandler(service *service.TaskService, reporter errorreport.Reporter, opts ...APIOption) *APIHandler {
+	h := &APIHandler{service: service, reporter: reporter, workspace: store.DefaultWorkspace}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
+}
 
-	task, err := h.service.Create(req.Title, req.Priority, req.Color)
+// GetTasks returns all tasks as JSON, or XML when the client asks for it.
+// The priority, status (open or completed), dueAfter and dueBefore query
+// parameters narrow the list down, sort orders it; limit and offset select
+// a page of it.
+// X-Total-Count holds the number of matching tasks and Link points to the
+// next page.
+func (h *APIHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
+	q, err := parseTaskQuery(r.URL.Query(), h.service.StaleCutoff(time.Now()))
+	if err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+	order, err := parseSort(r.URL.Query())
+	if err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+	p, err := parsePage(r.URL.Query(), h.listLimit, h.maxListLimit)
 	if err != nil {
-		if errors.Is(err, service.ErrEmptyTitle) || errors.Is(err, service.ErrTitleTooLong) {
-			respondError(w, err.Error(), "INVALID_INPUT", http.StatusBadRequest)
+		respondInvalid(w, r, err)
+		return
+	}
+
+	// The generation is read first, so a change made while the list is
+	// read makes the entry stale rather than being missed.
+	key, generation := cacheKey(r), h.service.Generation()
+	if h.cache != nil {
+		if entry, ok := h.cache.get(key, generation); ok {
+			w.Header().Add("Vary", "Accept")
+			entry.write(w, "HIT")
 			return
 		}
-		if errors.Is(err, service.ErrInvalidPriority) {
-			respondError(w, "Invalid priority emoticon. Must be one of: 🔥, ⭐, ⚡, 💡, 📋", "INVALID_INPUT", http.StatusBadRequest)
+	}
+
+	var tasks []model.Task
+	inService(r, func() { tasks, err = h.service.Find(r.Context(), q) })
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to list tasks")
+		return
+	}
+
+	sortTasks(tasks, order)
+	header := p.header(r, len(tasks))
+	tasks = p.apply(tasks)
+
+	list := h.listed(tasks)
+
+	// Lists too large to stream are not cached either.
+	if h.cache != nil && len(tasks) <= streamThreshold {
+		if entry, err := encodeResponse(r, list); err == nil {
+			entry.generation, entry.header = generation, header
+			h.cache.put(key, entry)
+			w.Header().Add("Vary", "Accept")
+			entry.write(w, "MISS")
 			return
 		}
-		if errors.Is(err, service.ErrInvalidColor) {
-			respondError(w, "Invalid color code. Must be a valid hex code.", "INVALID_INPUT", http.StatusBadRequest)
+	}
+	for name, values := range header {
+		w.Header()[name] = values
+	}
+	respond(w, r, list, http.StatusOK)
+}
+
+// listed returns tasks with their colors, age and staleness, for task
+// lists.
+func (h *APIHandler) listed(tasks []model.Task) taskList {
+	now := time.Now()
+	colors := h.service.Palette()
+	list := make(taskList, len(tasks))
+	for i, task := range tasks {
+		list[i] = listedTask{taskResponse: colored(colors, task), AgeDays: int(now.Sub(task.CreatedAt) / (24 * time.Hour)), Stale: h.service.IsStale(task, now)}
+	}
+	return list
+}
+
+// maxTaskBodySize limits the bodies of task requests, which only hold a few
+// short fields.
+const maxTaskBodySize = 64 << 10
+
+// CreateTask creates a new task from a JSON (or XML) request body.
+func (h *APIHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Title    string     `json:"title" xml:"title"`
+		Priority string     `json:"priority" xml:"priority"` // Optional: defaults to 📋
+		Color    string     `json:"color" xml:"color"`       // Optional: defaults to #6c757d
+		DueDate  *time.Time `json:"dueDate" xml:"dueDate"`   // Optional: RFC 3339 timestamp
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxTaskBodySize)
+	decode := json.NewDecoder(r.Body).Decode
+	if isXML(r) {
+		decode = xml.NewDecoder(r.Body).Decode
+	}
+	if err := decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, r, "The request body is larger than 64 KiB", "INVALID_INPUT", http.StatusRequestEntityTooLarge)
 			return
 		}
-		respondError(w, "Failed to create task", "INTERNAL_SERVER_ERROR", http.StatusInternalServerError)
+		respondError(w, r, "Invalid request body", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+
+	var task model.Task
+	var err error
+	inService(r, func() {
+		task, err = h.service.CreateBy(r.Context(), userID(r), req.Title, req.Priority, req.Color, req.DueDate)
+	})
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to create task")
 		return
 	}
 
-	respondJSON(w, task, http.StatusCreated)
+	h.warnWIP(w, r, task)
+	respond(w, r, colored(h.service.Palette(), task), http.StatusCreated)
+}
+
+// warnWIP sets a Warning header when task is open and the open tasks, or
+// those of its priority, exceed a WIP limit, as services in warn mode let
+// changes go over the limits.
+func (h *APIHandler) warnWIP(w http.ResponseWriter, r *http.Request, task model.Task) {
+	if task.Completed {
+		return
+	}
+	if warning := h.service.WIPWarning(r.Context(), task.Priority); warning != "" {
+		w.Header().Set("Warning", `299 - "`+warning+`"`)
+	}
 }
 
 // ToggleTask toggles task completion status.
 func (h *APIHandler) ToggleTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
+	if err := h.service.CheckLock(id, lockOwner(r)); err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to toggle task")
+		return
+	}
 
-	task, err := h.service.Toggle(id)
+	var task model.Task
+	var err error
+	inService(r, func() { task, err = h.service.Toggle(r.Context(), id) })
 	if err != nil {
-		if errors.Is(err, store.ErrTaskNotFound) {
-			respondError(w, "Task not found", "NOT_FOUND", http.StatusNotFound)
-			return
-		}
-		respondError(w, "Failed to toggle task", "INTERNAL_SERVER_ERROR", http.StatusInternalServerError)
+		respondMappedError(w, r, h.reporter, err, "Failed to toggle task")
 		return
 	}
 
-	respondJSON(w, task, http.StatusOK)
+	h.warnWIP(w, r, task)
+	respond(w, r, colored(h.service.Palette(), task), http.StatusOK)
 }
 
 // DeleteTask deletes a task.
 func (h *APIHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
+	if err := h.service.CheckLock(id, lockOwner(r)); err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to delete task")
+		return
+	}
+
+	var err error
+	inService(r, func() { err = h.service.Delete(r.Context(), id) })
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to delete task")
+		return
+	}
+
+	respond(w, r, MessageResponse{Message: "Task deleted successfully"}, http.StatusOK)
+}
+
+// lockTokenHeader carries the token of the lock an anonymous client holds
+// on the task it changes, as returned by LockTask.
+const lockTokenHeader = "Lock-Token"
+
+// lockOwner identifies the client of r to task locks: as its user when it
+// is authenticated, else by the token in the Lock-Token header, if any.
+func lockOwner(r *http.Request) string {
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		return "user:" + user.ID
+	}
+	return r.Header.Get(lockTokenHeader)
+}
+
+// userID returns the ID of the authenticated user of r, or "" when r is
+// anonymous.
+func userID(r *http.Request) string {
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		return user.ID
+	}
+	return ""
+}
+
+// LockTask locks a task for the client editing it, so changes by other
+// clients are answered 423 until the lock is released or expires. The
+// optional JSON body {"ttl": seconds} sets how long the lock lasts, by
+// default service.DefaultLockTTL; locking again renews it. Anonymous
+// clients get a token to send in the Lock-Token header.
+func (h *APIHandler) LockTask(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TTL int `json:"ttl"` // Seconds
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxTaskBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, r, "Invalid request body", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+	ttl := time.Duration(req.TTL) * time.Second
+	if ttl < 0 || ttl > service.MaxLockTTL {
+		respondError(w, r, "ttl must be between 1 and %d seconds", "INVALID_INPUT", http.StatusBadRequest, int(service.MaxLockTTL.Seconds()))
+		return
+	}
 
-	if err := h.service.Delete(id); err != nil {
-		if errors.Is(err, store.ErrTaskNotFound) {
-			respondError(w, "Task not found", "NOT_FOUND", http.StatusNotFound)
+	var holder string
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		holder = user.Name
+	}
+	lock, err := h.service.Lock(r.Context(), mux.Vars(r)["id"], lockOwner(r), holder, ttl)
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to lock task")
+		return
+	}
+	respondJSON(w, lock, http.StatusOK)
+}
+
+// UnlockTask releases the lock the client holds on a task.
+func (h *APIHandler) UnlockTask(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Unlock(mux.Vars(r)["id"], lockOwner(r)); err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to unlock task")
+		return
+	}
+	respond(w, r, MessageResponse{Message: "Task unlocked"}, http.StatusOK)
+}
+
+// ReprioritizeResult reports the tasks Reprioritize changed.
+type ReprioritizeResult struct {
+	Updated int            `json:"updated"`
+	Tasks   []taskResponse `json:"tasks"`
+}
+
+// Reprioritize sets the priority and color of the tasks selected by the
+// filters of GetTasks at once, such as demoting all 🔥 tasks created before
+// a date to ⭐. The JSON body holds the new {"priority", "color"}; at least
+// one of them is required. Either all matching tasks change or, when one is
+// locked by another client, none.
+func (h *APIHandler) Reprioritize(w http.ResponseWriter, r *http.Request) {
+	q, err := parseTaskQuery(r.URL.Query(), h.service.StaleCutoff(time.Now()))
+	if err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+	var req struct {
+		Priority string `json:"priority"`
+		Color    string `json:"color"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxTaskBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, "Invalid request body", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+	if req.Priority == "" && req.Color == "" {
+		respondError(w, r, "A priority or color is required", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+
+	var tasks []model.Task
+	inService(r, func() { tasks, err = h.service.Reprioritize(r.Context(), q, req.Priority, req.Color, lockOwner(r)) })
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to reprioritize tasks")
+		return
+	}
+	if i := slices.IndexFunc(tasks, func(t model.Task) bool { return !t.Completed }); i >= 0 && req.Priority != "" {
+		h.warnWIP(w, r, tasks[i])
+	}
+	respondJSON(w, ReprioritizeResult{Updated: len(tasks), Tasks: coloredAll(h.service.Palette(), tasks)}, http.StatusOK)
+}
+
+// MergeResult holds the tasks MergeTask changed.
+type MergeResult struct {
+	Target taskResponse `json:"target"`
+	Source taskResponse `json:"source"` // Completed
+}
+
+// MergeTask merges the task whose ID is the source of the JSON body
+// {"source": "id"} into the task of the URL and completes it; see
+// service.TaskService.Merge.
+func (h *APIHandler) MergeTask(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Source string `json:"source"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxTaskBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Source == "" {
+		respondError(w, r, "The body must name the source task, like {\"source\": \"2\"}", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+
+	var target, source model.Task
+	var err error
+	inService(r, func() {
+		target, source, err = h.service.Merge(r.Context(), mux.Vars(r)["id"], req.Source, lockOwner(r))
+	})
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to merge tasks")
+		return
+	}
+	h.warnWIP(w, r, target)
+	respondJSON(w, MergeResult{Target: colored(h.service.Palette(), target), Source: colored(h.service.Palette(), source)}, http.StatusOK)
+}
+
+// ChangePriority changes only the priority of a task to the priority of
+// the JSON body {"priority": "🔥"}, recording the change in its history;
+// see service.TaskService.ChangePriority.
+func (h *APIHandler) ChangePriority(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Priority string `json:"priority"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxTaskBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Priority == "" {
+		respondError(w, r, "The body must hold the new priority, like {\"priority\": \"🔥\"}", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+
+	var task model.Task
+	var err error
+	inService(r, func() {
+		task, err = h.service.ChangePriority(r.Context(), mux.Vars(r)["id"], req.Priority, lockOwner(r), userID(r))
+	})
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to change priority")
+		return
+	}
+	h.warnWIP(w, r, task)
+	respond(w, r, colored(h.service.Palette(), task), http.StatusOK)
+}
+
+// TaskHistory holds the recorded transitions of a task, oldest first.
+type TaskHistory struct {
+	TaskID      string               `json:"taskId"`
+	Transitions []service.Transition `json:"transitions"`
+}
+
+// GetHistory returns the priority changes recorded for a task.
+func (h *APIHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	transitions, err := h.service.History(r.Context(), id)
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to load task history")
+		return
+	}
+	respondJSON(w, TaskHistory{TaskID: id, Transitions: transitions}, http.StatusOK)
+}
+
+const (
+	defaultChangesWait = 30 * time.Second
+	maxChangesWait     = time.Minute
+)
+
+// Page sizes of the activity feed.
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 200
+)
+
+// ActivityFeed holds a page of the activity feed, newest first. Next is the
+// before of the next page: the sequence number of the oldest activity on
+// this one.
+type ActivityFeed struct {
+	Activity []service.Activity `json:"activity"`
+	Next     int64              `json:"next,omitempty"`
+	More     bool               `json:"more"`
+}
+
+// GetActivity returns the latest changes made to the tasks of the
+// workspace, newest first, up to the limit parameter, for recent activity
+// widgets. The before parameter pages back through older changes; see
+// service.TaskService.Activity.
+func (h *APIHandler) GetActivity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var before int64
+	if v := query.Get("before"); v != "" {
+		var err error
+		if before, err = strconv.ParseInt(v, 10, 64); err != nil || before < 1 {
+			respondError(w, r, "before must be the next of an earlier page", "INVALID_INPUT", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultActivityLimit
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxActivityLimit {
+			respondError(w, r, "limit must be between 1 and %d", "INVALID_INPUT", http.StatusBadRequest, maxActivityLimit)
+			return
+		}
+	}
+
+	activity, more := h.service.Activity(before, limit)
+	feed := ActivityFeed{Activity: activity, More: more}
+	if len(activity) > 0 {
+		feed.Next = activity[len(activity)-1].Seq
+	}
+	respondJSON(w, feed, http.StatusOK)
+}
+
+// GetChanges long-polls for changes to tasks, for clients that cannot use
+// push: it returns the changes after the since cursor, waiting up to wait
+// (30s by default, 60s at most) for one. Without since it returns the
+// cursor to start from at once; see service.TaskService.Changes.
+func (h *APIHandler) GetChanges(w http.ResponseWriter, r *http.Request) {
+	wait := defaultChangesWait
+	if v := r.URL.Query().Get("wait"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 || d > maxChangesWait {
+			respondError(w, r, "wait must be a duration between 0s and %s, like 30s", "INVALID_INPUT", http.StatusBadRequest, maxChangesWait)
+			return
+		}
+		wait = d
+	}
+	// The wait may outlast the write timeout of the server
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second))
+
+	changes, err := h.service.Changes(r.Context(), r.URL.Query().Get("since"), wait)
+	if r.Context().Err() != nil {
+		return // The client is gone
+	}
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to get changes")
+		return
+	}
+	respondJSON(w, changes, http.StatusOK)
+}
+
+// TombstoneList holds the tombstones of deleted tasks. Until is the since
+// of the next request: the time the tombstones were read at.
+type TombstoneList struct {
+	Tombstones []store.Tombstone `json:"tombstones"`
+	Until      time.Time         `json:"until"`
+}
+
+// GetTombstones returns the tombstones of the tasks deleted at or after the
+// since query parameter, oldest first, so clients syncing tasks learn about
+// deletions. Deletions from before the tombstones kept are answered with
+// 410 TOMBSTONES_EXPIRED, as some would be missing.
+func (h *APIHandler) GetTombstones(w http.ResponseWriter, r *http.Request) {
+	since, err := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+	if err != nil {
+		respondError(w, r, "since must be an RFC 3339 time, like 2026-03-01T09:00:00Z", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+
+	until := time.Now()
+	tombstones, err := h.service.Tombstones(r.Context(), since)
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to get tombstones")
+		return
+	}
+	respondJSON(w, TombstoneList{Tombstones: tombstones, Until: until}, http.StatusOK)
+}
+
+// StatsResponse holds the task activity counters and, for authenticated
+// requests, the open tasks of the user.
+type StatsResponse struct {
+	XMLName xml.Name `json:"-" xml:"stats"`
+	service.Stats
+	User *UserUsage `json:"user,omitempty" xml:"user,omitempty"`
+}
+
+// UserUsage holds the open tasks a user created and the quota on them,
+// which is 0 without one.
+type UserUsage struct {
+	Open  int `json:"open" xml:"open"`
+	Limit int `json:"limit" xml:"limit"`
+}
+
+// GetStats returns task activity counters and completion latency, and the
+// usage of the per-user quota of the authenticated user.
+func (h *APIHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	var resp StatsResponse
+	var err error
+	inService(r, func() {
+		resp.Stats, err = h.service.Stats(r.Context())
+		if user := userID(r); err == nil && user != "" {
+			resp.User = &UserUsage{}
+			resp.User.Open, resp.User.Limit, err = h.service.UserTaskUsage(r.Context(), user)
+		}
+	})
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to compute stats")
+		return
+	}
+	respond(w, r, resp, http.StatusOK)
+}
+
+// Meta describes what the API accepts, so clients can validate input before
+// sending it.
+type Meta struct {
+	Title        service.TitleLimits `json:"title"`
+	Priorities   []string            `json:"priorities"`
+	Colors       []string            `json:"colors"`  // Hex codes tasks are stored with
+	Palette      []palette.Color     `json:"palette"` // Names, variants and text colors of Colors
+	Defaults     MetaDefaults        `json:"defaults"`
+	ListLimit    int                 `json:"listLimit"`    // Page size when no limit is given; 0 lists all tasks
+	MaxListLimit int                 `json:"maxListLimit"` // 0 means no cap
+}
+
+// MetaDefaults are the values tasks are created with when they are given
+// none.
+type MetaDefaults struct {
+	Priority     string `json:"priority"`
+	Color        string `json:"color"`
+	DueInSeconds int64  `json:"dueInSeconds,omitempty"` // From creation; left out when tasks get no due date
+}
+
+// GetMeta returns the limits and choices tasks are validated against.
+func (h *APIHandler) GetMeta(w http.ResponseWriter, r *http.Request) {
+	defaults := h.service.Defaults()
+	respondJSON(w, Meta{
+		Title:      h.service.TitleLimits(),
+		Priorities: service.Priorities,
+		Colors:     h.service.Palette().Codes(),
+		Palette:    h.service.Palette().Colors(),
+		Defaults: MetaDefaults{
+			Priority:     defaults.Priority,
+			Color:        defaults.Color,
+			DueInSeconds: int64(defaults.DueIn / time.Second),
+		},
+		ListLimit:    h.listLimit,
+		MaxListLimit: h.maxListLimit,
+	}, http.StatusOK)
+}
+
+// SchemaLink names a request body schema and where it is served.
+type SchemaLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// ListSchemas lists the JSON Schemas request bodies are validated against.
+func (h *APIHandler) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	links := make([]SchemaLink, 0, len(schema.Names()))
+	for _, name := range schema.Names() {
+		links = append(links, SchemaLink{Name: name, URL: strings.TrimSuffix(r.URL.Path, "/") + "/" + name})
+	}
+	respondJSON(w, map[string][]SchemaLink{"schemas": links}, http.StatusOK)
+}
+
+// GetSchema serves the JSON Schema named in the URL.
+func (h *APIHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	doc, ok := schema.Document(mux.Vars(r)["name"])
+	if !ok {
+		respondError(w, r, "Schema not found", "NOT_FOUND", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	w.Write(doc)
+}
+
+// Usage is the use a workspace makes of the limits of its plan. Limits of
+// 0 are unlimited.
+type Usage struct {
+	Workspace string        `json:"workspace"`
+	Plan      string        `json:"plan,omitempty"` // Empty without a plan
+	Tasks     TaskUsage     `json:"tasks"`
+	Requests  RequestsUsage `json:"requests"`
+}
+
+// TaskUsage is the number of tasks of a workspace and its quota.
+type TaskUsage struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"`
+}
+
+// RequestsUsage is the rate limit of a workspace and the requests it may
+// make at once, which is left out without a rate limit.
+type RequestsUsage struct {
+	Rate      float64 `json:"rate"`
+	Burst     int     `json:"burst"`
+	Remaining *int    `json:"remaining,omitempty"`
+}
+
+// GetUsage returns the limits of the plan of the workspace and its use of
+// them.
+func (h *APIHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	var used, quota int
+	var err error
+	inService(r, func() { used, quota, err = h.service.TaskUsage(r.Context()) })
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to compute usage")
+		return
+	}
+
+	usage := Usage{
+		Workspace: h.workspace,
+		Plan:      h.plan.Name,
+		Tasks:     TaskUsage{Used: used, Limit: quota},
+		Requests:  RequestsUsage{Rate: h.plan.RateLimit, Burst: h.plan.RateBurst},
+	}
+	if h.remaining != nil {
+		remaining := h.remaining()
+		usage.Requests.Remaining = &remaining
+	}
+	respondJSON(w, usage, http.StatusOK)
+}
+
+// SeedTasks adds sample tasks for development. The count query parameter
+// defaults to 20; samples that already exist are skipped, so repeated
+// calls are safe.
+func (h *APIHandler) SeedTasks(w http.ResponseWriter, r *http.Request) {
+	count := 20
+	if v := r.URL.Query().Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > seed.MaxCount {
+			respondError(w, r, "count must be a number between 1 and %d", "INVALID_INPUT", http.StatusBadRequest, seed.MaxCount)
 			return
 		}
-		respondError(w, "Failed to delete task", "INTERNAL_SERVER_ERROR", http.StatusInternalServerError)
+		count = n
+	}
+
+	var result seed.Result
+	var err error
+	inService(r, func() { result, err = seed.Run(r.Context(), h.service, count, time.Now()) })
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to seed tasks")
 		return
 	}
+	respondJSON(w, result, http.StatusOK)
+}
+
+// NotFound responds to requests for unknown API paths.
+func (h *APIHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, "Resource not found", "NOT_FOUND", http.StatusNotFound)
+}
 
-	respondJSON(w, MessageResponse{Message: "Task deleted successfully"}, http.StatusOK)
+// MethodNotAllowed responds to requests using a method the API path does not support.
+func (h *APIHandler) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, "Method not allowed", "METHOD_NOT_ALLOWED", http.StatusMethodNotAllowed)
 }
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func newBenchHandler(b *testing.B, n int, opts ...APIOption) *APIHandler {
+	b.Helper()
+	taskService := service.NewTaskService(store.NewTaskStore())
+	for i := range n {
+		if _, err := taskService.Create(b.Context(), "Benchmark task "+strconv.Itoa(i), "", "", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return NewAPIHandler(taskService, errorreport.Nop{}, opts...)
+}
+
+func BenchmarkGetTasks(b *testing.B) {
+	for _, n := range []int{1_000, 100_000} {
+		b.Run(fmt.Sprintf("json/%d", n), func(b *testing.B) {
+			h := newBenchHandler(b, n)
+			b.ResetTimer()
+			for range b.N {
+				h.GetTasks(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/tasks", nil))
+			}
+		})
+		b.Run(fmt.Sprintf("xml/%d", n), func(b *testing.B) {
+			h := newBenchHandler(b, n)
+			b.ResetTimer()
+			for range b.N {
+				r := httptest.NewRequest("GET", "/api/tasks", nil)
+				r.Header.Set("Accept", "application/xml")
+				h.GetTasks(httptest.NewRecorder(), r)
+			}
+		})
+	}
+}
+
+// BenchmarkGetTasksCached measures cache hits for the largest list that is
+// still cached rather than streamed.
+func BenchmarkGetTasksCached(b *testing.B) {
+	h := newBenchHandler(b, streamThreshold, WithResponseCache(time.Hour, metrics.NewRegistry()))
+	b.ResetTimer()
+	for range b.N {
+		h.GetTasks(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/tasks", nil))
+	}
+}
+
+func BenchmarkCreateTask(b *testing.B) {
+	h := newBenchHandler(b, 0)
+	b.ResetTimer()
+	for range b.N {
+		r := httptest.NewRequest("POST", "/api/tasks", strings.NewReader(`{"title":"Benchmark task","priority":"🔥","color":"#dc3545"}`))
+		h.CreateTask(httptest.NewRecorder(), r)
+	}
+}
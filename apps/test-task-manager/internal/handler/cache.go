@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+)
+
+// maxCacheEntries bounds the number of cached responses. The cache is
+// cleared when it is full, which also drops entries of one-off filters.
+const maxCacheEntries = 256
+
+// responseCache holds serialized responses: task lists keyed by format and
+// filters, and rendered pages. Entries are valid for the service generation
+// they were rendered at, and at most ttl, which bounds staleness when other
+// processes change a shared store.
+type responseCache struct {
+	ttl      time.Duration
+	requests *metrics.CounterVec
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	generation  uint64
+	expires     time.Time
+	contentType string
+	header      http.Header // Pagination headers
+	body        []byte
+}
+
+// newResponseCache creates a cache counting hits and misses on requests,
+// which has a result label.
+func newResponseCache(ttl time.Duration, requests *metrics.CounterVec) *responseCache {
+	return &responseCache{
+		ttl:      ttl,
+		requests: requests,
+		entries:  make(map[string]cachedResponse),
+	}
+}
+
+// get returns the entry for key if it is still valid at generation.
+func (c *responseCache) get(key string, generation uint64) (cachedResponse, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && entry.generation == generation && time.Now().Before(entry.expires) {
+		c.requests.With("hit").Inc()
+		return entry, true
+	}
+	c.requests.With("miss").Inc()
+	return cachedResponse{}, false
+}
+
+func (c *responseCache) put(key string, entry cachedResponse) {
+	entry.expires = time.Now().Add(c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCacheEntries {
+		clear(c.entries)
+	}
+	c.entries[key] = entry
+}
+
+// write sends a cached response.
+func (e cachedResponse) write(w http.ResponseWriter, cacheStatus string) {
+	for name, values := range e.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Content-Type", e.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(e.body)))
+	w.Header().Set("X-Cache", cacheStatus)
+	w.WriteHeader(http.StatusOK)
+	w.Write(e.body)
+}
+
+// encodeResponse serializes data like respond does, for caching.
+func encodeResponse(r *http.Request, data interface{}) (cachedResponse, error) {
+	var buf bytes.Buffer
+	if prefersXML(r) {
+		buf.WriteString(xml.Header)
+		if err := xml.NewEncoder(&buf).Encode(data); err != nil {
+			return cachedResponse{}, err
+		}
+		return cachedResponse{contentType: "application/xml; charset=utf-8", body: buf.Bytes()}, nil
+	}
+
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		return cachedResponse{}, err
+	}
+	return cachedResponse{contentType: "application/json", body: buf.Bytes()}, nil
+}
+
+// cacheKey identifies a task list response by format and query string.
+// Encode sorts the parameters, so their order does not matter.
+func cacheKey(r *http.Request) string {
+	format := "json"
+	if prefersXML(r) {
+		format = "xml"
+	}
+	return format + "?" + r.URL.Query().Encode()
+}
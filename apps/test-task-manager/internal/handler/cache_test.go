@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestGetTasks_ResponseCache(t *testing.T) {
+	taskService := service.NewTaskService(store.NewTaskStore())
+	h := NewAPIHandler(taskService, errorreport.Nop{}, WithResponseCache(time.Minute, metrics.NewRegistry()))
+
+	get := func(target string) (cacheStatus, body string) {
+		w := httptest.NewRecorder()
+		h.GetTasks(w, httptest.NewRequest("GET", target, nil))
+		return w.Header().Get("X-Cache"), w.Body.String()
+	}
+
+	taskService.Create(t.Context(), "first", "🔥", "", nil)
+	if status, _ := get("/api/tasks?status=open&priority=%F0%9F%94%A5"); status != "MISS" {
+		t.Errorf("expected a miss on the first request, got %q", status)
+	}
+	// Parameter order does not matter.
+	if status, _ := get("/api/tasks?priority=%F0%9F%94%A5&status=open"); status != "HIT" {
+		t.Errorf("expected a hit on the second request, got %q", status)
+	}
+
+	taskService.Create(t.Context(), "second", "🔥", "", nil)
+	status, body := get("/api/tasks?status=open&priority=%F0%9F%94%A5")
+	if status != "MISS" || !strings.Contains(body, "second") {
+		t.Errorf("expected a change to invalidate the cache, got %q: %s", status, body)
+	}
+}
@@ -0,0 +1,385 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/caldav"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/ical"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Paths of the CalDAV principal, which is also the calendar home, and of
+// the one calendar in it, holding every task.
+const (
+	CalDAVRoot     = "/caldav/"
+	CalDAVCalendar = CalDAVRoot + "tasks/"
+)
+
+// maxCalendarSize limits the iCalendar clients may PUT.
+const maxCalendarSize = 1 << 20
+
+// CalDAVHandler serves the tasks as a CalDAV calendar of VTODOs, so
+// calendar applications can list, create, edit, complete and delete them.
+type CalDAVHandler struct {
+	tasks    *service.TaskService
+	links    *caldav.Links
+	loc      *time.Location // Of floating dates in uploaded tasks
+	reporter errorreport.Reporter
+}
+
+// NewCalDAVHandler creates a new CalDAVHandler.
+func NewCalDAVHandler(tasks *service.TaskService, links *caldav.Links, loc *time.Location, reporter errorreport.Reporter) *CalDAVHandler {
+	return &CalDAVHandler{tasks: tasks, links: links, loc: loc, reporter: reporter}
+}
+
+// WellKnown redirects service discovery to the principal.
+func (h *CalDAVHandler) WellKnown(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, CalDAVRoot, http.StatusMovedPermanently)
+}
+
+// Options advertises CalDAV support.
+func (h *CalDAVHandler) Options(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	w.Header().Set("Allow", "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT")
+	w.WriteHeader(http.StatusOK)
+}
+
+// PropfindRoot describes the principal and, with Depth 1, its calendar.
+func (h *CalDAVHandler) PropfindRoot(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	responses := []caldav.Response{{Href: CalDAVRoot, Props: rootProps()}}
+	if r.Header.Get("Depth") == "1" {
+		entries, ok := h.entries(w, r)
+		if !ok {
+			return
+		}
+		responses = append(responses, caldav.Response{Href: CalDAVCalendar, Props: calendarProps(entries)})
+	}
+	caldav.WriteMultistatus(w, req, responses)
+}
+
+// PropfindCalendar describes the calendar and, with Depth 1, its tasks.
+func (h *CalDAVHandler) PropfindCalendar(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	entries, ok := h.entries(w, r)
+	if !ok {
+		return
+	}
+	responses := []caldav.Response{{Href: CalDAVCalendar, Props: calendarProps(entries)}}
+	if r.Header.Get("Depth") == "1" {
+		for _, e := range entries {
+			responses = append(responses, caldav.Response{Href: e.href(), Props: e.props(false)})
+		}
+	}
+	caldav.WriteMultistatus(w, req, responses)
+}
+
+// PropfindTask describes a single task.
+func (h *CalDAVHandler) PropfindTask(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	e, ok := h.entry(w, r, mux.Vars(r)["name"])
+	if !ok {
+		return
+	}
+	caldav.WriteMultistatus(w, req, []caldav.Response{{Href: e.href(), Props: e.props(true)}})
+}
+
+// Report answers calendar-query reports with every task and
+// calendar-multiget reports with the tasks asked for. Query filters are
+// not applied; clients filter the tasks themselves.
+func (h *CalDAVHandler) Report(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Kind != "calendar-query" && req.Kind != "calendar-multiget" {
+		http.Error(w, "Unsupported report "+req.Kind, http.StatusForbidden)
+		return
+	}
+	entries, ok := h.entries(w, r)
+	if !ok {
+		return
+	}
+
+	var responses []caldav.Response
+	if req.Kind == "calendar-query" {
+		for _, e := range entries {
+			responses = append(responses, caldav.Response{Href: e.href(), Props: e.props(true)})
+		}
+	}
+	for _, href := range req.Hrefs {
+		name, err := url.PathUnescape(path.Base(href))
+		i := slices.IndexFunc(entries, func(e calendarEntry) bool { return e.name == name })
+		if err != nil || i < 0 {
+			responses = append(responses, caldav.Response{Href: href, NotFound: true})
+			continue
+		}
+		responses = append(responses, caldav.Response{Href: entries[i].href(), Props: entries[i].props(true)})
+	}
+	caldav.WriteMultistatus(w, req, responses)
+}
+
+// GetTask returns a task as an iCalendar VTODO.
+func (h *CalDAVHandler) GetTask(w http.ResponseWriter, r *http.Request) {
+	e, ok := h.entry(w, r, mux.Vars(r)["name"])
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("ETag", e.etag)
+	w.Write(e.calendar())
+}
+
+// PutTask creates or replaces the task at the resource name from the VTODO
+// in the body. The color is kept when the VTODO has none, and a change of
+// the status completes or reopens the task. If-Match and If-None-Match
+// guard against overwriting changes made meanwhile.
+func (h *CalDAVHandler) PutTask(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	cal, err := ical.Parse(io.LimitReader(r.Body, maxCalendarSize))
+	if err != nil {
+		http.Error(w, "Invalid calendar data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	todos := cal.Find("VTODO")
+	if len(todos) != 1 {
+		http.Error(w, "Calendar data must hold exactly one VTODO", http.StatusBadRequest)
+		return
+	}
+	fields, err := ical.Task(todos[0], h.loc)
+	if err != nil {
+		http.Error(w, "Invalid task: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.tasks.Get(r.Context(), h.links.Resolve(name))
+	exists := err == nil
+	if err != nil && !errors.Is(err, store.ErrTaskNotFound) {
+		h.fail(w, r, err)
+		return
+	}
+	if !h.preconditions(w, r, exists, existing) {
+		return
+	}
+
+	var task model.Task
+	if exists {
+		if err := h.tasks.CheckLock(existing.ID, lockOwner(r)); err != nil {
+			h.fail(w, r, err)
+			return
+		}
+		if fields.Color == "" {
+			fields.Color = existing.Color
+		}
+		task, err = h.tasks.Update(r.Context(), existing.ID, fields.Title, fields.Priority, fields.Color, fields.DueDate)
+	} else {
+		task, err = h.tasks.CreateBy(r.Context(), userID(r), fields.Title, fields.Priority, fields.Color, fields.DueDate)
+	}
+	if err == nil && task.Completed != fields.Completed {
+		task, err = h.tasks.Toggle(r.Context(), task.ID)
+	}
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	status := http.StatusNoContent
+	if !exists {
+		status = http.StatusCreated
+		uid := todos[0].Text("UID")
+		if uid == "" {
+			uid = task.ID
+		}
+		if err := h.links.Add(caldav.Link{Name: name, UID: uid, TaskID: task.ID}); err != nil {
+			h.fail(w, r, err)
+			return
+		}
+	}
+	w.Header().Set("ETag", h.newEntry(task).etag)
+	w.WriteHeader(status)
+}
+
+// DeleteTask deletes a task.
+func (h *CalDAVHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	e, ok := h.entry(w, r, mux.Vars(r)["name"])
+	if !ok {
+		return
+	}
+	if !h.preconditions(w, r, true, e.task) {
+		return
+	}
+	if err := h.tasks.CheckLock(e.task.ID, lockOwner(r)); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	if err := h.tasks.Delete(r.Context(), e.task.ID); err != nil && !errors.Is(err, store.ErrTaskNotFound) {
+		h.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// preconditions checks If-Match and If-None-Match against the current
+// state of the task, which exists is false for. It answers 412 and returns
+// false when they fail.
+func (h *CalDAVHandler) preconditions(w http.ResponseWriter, r *http.Request, exists bool, task model.Task) bool {
+	ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+	failed := ifNoneMatch == "*" && exists ||
+		ifMatch == "*" && !exists ||
+		ifMatch != "" && ifMatch != "*" && (!exists || ifMatch != h.newEntry(task).etag)
+	if failed {
+		http.Error(w, "The task was changed meanwhile", http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+func (h *CalDAVHandler) parseRequest(w http.ResponseWriter, r *http.Request) (caldav.Request, bool) {
+	req, err := caldav.ParseRequest(io.LimitReader(r.Body, maxCalendarSize))
+	if err != nil {
+		http.Error(w, "Invalid XML body", http.StatusBadRequest)
+		return caldav.Request{}, false
+	}
+	return req, true
+}
+
+// entries returns every task of the calendar, forgetting the links of
+// tasks deleted by other clients.
+func (h *CalDAVHandler) entries(w http.ResponseWriter, r *http.Request) ([]calendarEntry, bool) {
+	tasks, err := h.tasks.GetAll(r.Context())
+	if err != nil {
+		h.fail(w, r, err)
+		return nil, false
+	}
+	ids := make(map[string]bool, len(tasks))
+	entries := make([]calendarEntry, len(tasks))
+	for i, task := range tasks {
+		ids[task.ID] = true
+		entries[i] = h.newEntry(task)
+	}
+	if err := h.links.Retain(func(id string) bool { return ids[id] }); err != nil {
+		h.fail(w, r, err)
+		return nil, false
+	}
+	return entries, true
+}
+
+// entry returns the task at the resource name, answering 404 when there
+// is none.
+func (h *CalDAVHandler) entry(w http.ResponseWriter, r *http.Request, name string) (calendarEntry, bool) {
+	task, err := h.tasks.Get(r.Context(), h.links.Resolve(name))
+	if err != nil {
+		h.fail(w, r, err)
+		return calendarEntry{}, false
+	}
+	return h.newEntry(task), true
+}
+
+func (h *CalDAVHandler) newEntry(task model.Task) calendarEntry {
+	name, uid := h.links.Of(task.ID)
+	content, _ := json.Marshal(task)
+	sum := sha256.Sum256(append(content, uid...))
+	return calendarEntry{task: task, name: name, uid: uid, etag: `"` + hex.EncodeToString(sum[:12]) + `"`}
+}
+
+// fail answers err as plain text, which CalDAV clients show to users, with
+// the status the error maps to; see mapError.
+func (h *CalDAVHandler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	status, body := mapError(r, h.reporter, err, "Internal server error")
+	http.Error(w, body.Error, status)
+}
+
+// calendarEntry is a task as a resource of the calendar.
+type calendarEntry struct {
+	task model.Task
+	name string // Resource name
+	uid  string
+	etag string // Changes with every change to the task
+}
+
+func (e calendarEntry) href() string {
+	return CalDAVCalendar + url.PathEscape(e.name)
+}
+
+func (e calendarEntry) calendar() []byte {
+	var b bytes.Buffer
+	ical.Write(&b, ical.Calendar(ical.Todo(e.task, e.uid)))
+	return b.Bytes()
+}
+
+// props returns the properties of the entry, with its iCalendar when
+// withData is set.
+func (e calendarEntry) props(withData bool) map[xml.Name]string {
+	props := map[xml.Name]string{
+		davName("resourcetype"):   "",
+		davName("getetag"):        caldav.Escape(e.etag),
+		davName("getcontenttype"): "text/calendar; charset=utf-8; component=VTODO",
+	}
+	if withData {
+		props[caldav.CalendarData] = caldav.Escape(string(e.calendar()))
+	}
+	return props
+}
+
+func rootProps() map[xml.Name]string {
+	return map[xml.Name]string{
+		davName("resourcetype"):                                      "<d:collection/>",
+		davName("displayname"):                                       "Tasks",
+		davName("current-user-principal"):                            caldav.Href(CalDAVRoot),
+		davName("principal-URL"):                                     caldav.Href(CalDAVRoot),
+		{Space: caldav.NSCalDAV, Local: "calendar-home-set"}:         caldav.Href(CalDAVRoot),
+		{Space: caldav.NSCalDAV, Local: "calendar-user-address-set"}: "",
+	}
+}
+
+// calendarProps returns the properties of the calendar. Its ETag and CTag
+// change with any of its tasks.
+func calendarProps(entries []calendarEntry) map[xml.Name]string {
+	hash := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintln(hash, e.name, e.etag)
+	}
+	tag := `"` + hex.EncodeToString(hash.Sum(nil)[:12]) + `"`
+
+	return map[xml.Name]string{
+		davName("resourcetype"):           "<d:collection/><c:calendar/>",
+		davName("displayname"):            "Tasks",
+		davName("getetag"):                caldav.Escape(tag),
+		davName("current-user-principal"): caldav.Href(CalDAVRoot),
+		davName("supported-report-set"): "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>",
+		davName("current-user-privilege-set"): "<d:privilege><d:read/></d:privilege><d:privilege><d:write/></d:privilege>" +
+			"<d:privilege><d:write-content/></d:privilege><d:privilege><d:bind/></d:privilege><d:privilege><d:unbind/></d:privilege>",
+		{Space: caldav.NSCalDAV, Local: "supported-calendar-component-set"}: `<c:comp name="VTODO"/>`,
+		{Space: caldav.NSCS, Local: "getctag"}:                              caldav.Escape(tag),
+	}
+}
+
+func davName(local string) xml.Name {
+	return xml.Name{Space: caldav.NSDAV, Local: local}
+}
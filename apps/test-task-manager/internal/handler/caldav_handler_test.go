@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/caldav"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// calDAVTest is a CalDAV calendar routed as the server routes it, with the
+// links persisted to a file.
+type calDAVTest struct {
+	t         *testing.T
+	tasks     *service.TaskService
+	linksFile string
+	router    *mux.Router
+}
+
+func newCalDAVTest(t *testing.T) *calDAVTest {
+	linksFile := filepath.Join(t.TempDir(), "links.json")
+	links, err := caldav.NewLinks(linksFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := service.NewTaskService(store.NewTaskStore())
+	h := NewCalDAVHandler(tasks, links, time.UTC, errorreport.Nop{})
+
+	r := mux.NewRouter()
+	dav := r.PathPrefix("/caldav").Subrouter()
+	dav.HandleFunc("/", h.PropfindRoot).Methods("PROPFIND")
+	dav.HandleFunc("/tasks/", h.PropfindCalendar).Methods("PROPFIND")
+	dav.HandleFunc("/tasks/", h.Report).Methods("REPORT")
+	dav.HandleFunc("/tasks/{name}", h.PropfindTask).Methods("PROPFIND")
+	dav.HandleFunc("/tasks/{name}", h.GetTask).Methods("GET")
+	dav.HandleFunc("/tasks/{name}", h.PutTask).Methods("PUT")
+	dav.HandleFunc("/tasks/{name}", h.DeleteTask).Methods("DELETE")
+	return &calDAVTest{t: t, tasks: tasks, linksFile: linksFile, router: r}
+}
+
+// do sends a request with body and the headers given as name, value pairs.
+func (c *calDAVTest) do(method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, r)
+	return w
+}
+
+// links reads the persisted links anew.
+func (c *calDAVTest) links() *caldav.Links {
+	links, err := caldav.NewLinks(c.linksFile)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return links
+}
+
+func vtodo(uid, summary string, extra ...string) string {
+	lines := append([]string{"BEGIN:VCALENDAR", "VERSION:2.0", "BEGIN:VTODO", "UID:" + uid, "SUMMARY:" + summary}, extra...)
+	return strings.Join(append(lines, "END:VTODO", "END:VCALENDAR", ""), "\r\n")
+}
+
+func TestCalDAVHandler_Propfind(t *testing.T) {
+	c := newCalDAVTest(t)
+	task, err := c.tasks.Create(t.Context(), "Water the plants", "⭐", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	href := "/caldav/tasks/" + task.ID + ".ics"
+
+	w := c.do("PROPFIND", "/caldav/", "", "Depth", "1")
+	if w.Code != http.StatusMultiStatus || !strings.Contains(w.Body.String(), "<d:href>/caldav/tasks/</d:href>") {
+		t.Errorf("expected the principal with its calendar, got %d: %s", w.Code, w.Body)
+	}
+	if w := c.do("PROPFIND", "/caldav/", "", "Depth", "0"); strings.Contains(w.Body.String(), "/caldav/tasks/") {
+		t.Errorf("expected only the principal without depth, got %s", w.Body)
+	}
+
+	w = c.do("PROPFIND", "/caldav/tasks/", "", "Depth", "1")
+	if body := w.Body.String(); w.Code != http.StatusMultiStatus || !strings.Contains(body, href) || !strings.Contains(body, "<cs:getctag>") {
+		t.Errorf("expected the calendar with its task, got %d: %s", w.Code, body)
+	}
+	if strings.Contains(w.Body.String(), "calendar-data") {
+		t.Errorf("expected no calendar data unless asked for, got %s", w.Body)
+	}
+
+	propfind := `<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/><c:calendar-data/><d:owner/></d:prop></d:propfind>`
+	w = c.do("PROPFIND", href, propfind)
+	body := w.Body.String()
+	if w.Code != http.StatusMultiStatus || !strings.Contains(body, "SUMMARY:Water the plants") || !strings.Contains(body, "<d:getetag>") {
+		t.Errorf("expected the task with its calendar data, got %d: %s", w.Code, body)
+	}
+	if !strings.Contains(body, "<d:owner/></d:prop><d:status>HTTP/1.1 404 Not Found</d:status>") {
+		t.Errorf("expected properties the task does not have to be not found, got %s", body)
+	}
+
+	if w := c.do("PROPFIND", "/caldav/tasks/nope.ics", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown task, got %d", w.Code)
+	}
+	if w := c.do("PROPFIND", "/caldav/tasks/", "<d:propfind"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid XML, got %d", w.Code)
+	}
+}
+
+func TestCalDAVHandler_Report(t *testing.T) {
+	c := newCalDAVTest(t)
+	first, _ := c.tasks.Create(t.Context(), "First", "⭐", "", nil)
+	second, _ := c.tasks.Create(t.Context(), "Second", "🔥", "", nil)
+
+	query := `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/><c:calendar-data/></d:prop>` +
+		`<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO"/></c:comp-filter></c:filter></c:calendar-query>`
+	w := c.do("REPORT", "/caldav/tasks/", query, "Depth", "1")
+	body := w.Body.String()
+	if w.Code != http.StatusMultiStatus || !strings.Contains(body, "SUMMARY:First") || !strings.Contains(body, "SUMMARY:Second") {
+		t.Errorf("expected every task from a calendar-query, got %d: %s", w.Code, body)
+	}
+
+	multiget := `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><c:calendar-data/></d:prop>` +
+		`<d:href>/caldav/tasks/` + second.ID + `.ics</d:href><d:href>/caldav/tasks/gone.ics</d:href></c:calendar-multiget>`
+	w = c.do("REPORT", "/caldav/tasks/", multiget)
+	body = w.Body.String()
+	if w.Code != http.StatusMultiStatus || !strings.Contains(body, "SUMMARY:Second") || strings.Contains(body, "/caldav/tasks/"+first.ID+".ics") {
+		t.Errorf("expected only the task asked for from a calendar-multiget, got %d: %s", w.Code, body)
+	}
+	if !strings.Contains(body, "<d:href>/caldav/tasks/gone.ics</d:href><d:status>HTTP/1.1 404 Not Found</d:status>") {
+		t.Errorf("expected unknown tasks to be not found, got %s", body)
+	}
+
+	if w := c.do("REPORT", "/caldav/tasks/", `<d:sync-collection xmlns:d="DAV:"/>`); w.Code != http.StatusForbidden {
+		t.Errorf("expected unsupported reports to be refused, got %d", w.Code)
+	}
+}
+
+func TestCalDAVHandler_PutTask(t *testing.T) {
+	c := newCalDAVTest(t)
+	href := "/caldav/tasks/client-1.ics"
+
+	w := c.do("PUT", href, vtodo("client-uid-1", "Book flights", "PRIORITY:1"), "If-None-Match", "*")
+	if w.Code != http.StatusCreated || w.Header().Get("ETag") == "" {
+		t.Fatalf("expected the task to be created with an ETag, got %d: %s", w.Code, w.Body)
+	}
+	created := w.Header().Get("ETag")
+
+	// The link to the name and UID of the client is persisted.
+	id := c.links().Resolve("client-1.ics")
+	task, err := c.tasks.Get(t.Context(), id)
+	if err != nil || task.Title != "Book flights" || task.Priority != "🔥" {
+		t.Fatalf("expected the task under the name of the client, got %+v (%v)", task, err)
+	}
+	if name, uid := c.links().Of(id); name != "client-1.ics" || uid != "client-uid-1" {
+		t.Errorf("expected the name and UID of the client, got %s and %s", name, uid)
+	}
+
+	w = c.do("GET", href, "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != created || !strings.Contains(w.Body.String(), "UID:client-uid-1") {
+		t.Errorf("expected the task under the UID of the client, got %d %s: %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		body    string
+		headers []string
+		status  int
+	}{
+		{"create over an existing task", href, vtodo("client-uid-1", "Again"), []string{"If-None-Match", "*"}, http.StatusPreconditionFailed},
+		{"update a changed task", href, vtodo("client-uid-1", "Stale"), []string{"If-Match", `"stale"`}, http.StatusPreconditionFailed},
+		{"update a missing task", "/caldav/tasks/missing.ics", vtodo("missing", "Missing"), []string{"If-Match", "*"}, http.StatusPreconditionFailed},
+		{"no VTODO", href, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nEND:VCALENDAR\r\n", nil, http.StatusBadRequest},
+		{"two VTODOs", href, strings.Replace(vtodo("a", "One"), "END:VCALENDAR", "BEGIN:VTODO\r\nUID:b\r\nSUMMARY:Two\r\nEND:VTODO\r\nEND:VCALENDAR", 1), nil, http.StatusBadRequest},
+		{"invalid calendar", href, "not a calendar", nil, http.StatusBadRequest},
+		{"invalid priority", href, vtodo("client-uid-1", "Book flights", "PRIORITY:high"), nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := c.do("PUT", tt.target, tt.body, tt.headers...); w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.status, w.Code, w.Body)
+		}
+	}
+	if task, _ := c.tasks.Get(t.Context(), id); task.Title != "Book flights" {
+		t.Fatalf("expected refused changes to leave the task alone, got %+v", task)
+	}
+
+	w = c.do("PUT", href, vtodo("client-uid-1", "Book trains", "PRIORITY:1", "STATUS:COMPLETED"), "If-Match", created)
+	if w.Code != http.StatusNoContent || w.Header().Get("ETag") == created {
+		t.Fatalf("expected the task to be updated with a new ETag, got %d: %s", w.Code, w.Body)
+	}
+	task, _ = c.tasks.Get(t.Context(), id)
+	if task.Title != "Book trains" || !task.Completed || task.Color == "" {
+		t.Errorf("expected the task to be renamed and completed, keeping its color, got %+v", task)
+	}
+	if _, err := c.tasks.Get(t.Context(), c.links().Resolve("missing.ics")); err == nil {
+		t.Error("expected no task to be created by a failed precondition")
+	}
+}
+
+func TestCalDAVHandler_DeleteTask(t *testing.T) {
+	c := newCalDAVTest(t)
+	href := "/caldav/tasks/client-1.ics"
+	etag := c.do("PUT", href, vtodo("client-uid-1", "Cancel the gym")).Header().Get("ETag")
+	id := c.links().Resolve("client-1.ics")
+
+	if w := c.do("DELETE", href, "", "If-Match", `"stale"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for a changed task, got %d", w.Code)
+	}
+	if w := c.do("DELETE", href, "", "If-Match", etag); w.Code != http.StatusNoContent {
+		t.Fatalf("expected the task to be deleted, got %d: %s", w.Code, w.Body)
+	}
+	if _, err := c.tasks.Get(t.Context(), id); err == nil {
+		t.Error("expected the task to be gone")
+	}
+	if w := c.do("GET", href, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for the deleted task, got %d", w.Code)
+	}
+	if w := c.do("DELETE", href, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 when deleting again, got %d", w.Code)
+	}
+
+	// Listing the calendar forgets the links of deleted tasks.
+	c.do("PROPFIND", "/caldav/tasks/", "", "Depth", "1")
+	if name, _ := c.links().Of(id); name != id+".ics" {
+		t.Errorf("expected the link of the deleted task to be forgotten, got %s", name)
+	}
+}
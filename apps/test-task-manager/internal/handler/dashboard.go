@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/i18n"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+)
+
+// trendDays is the number of days, up to today, of the completion trend.
+const trendDays = 14
+
+// recentActivity is the number of latest changes the dashboard shows.
+const recentActivity = 10
+
+// activityLabels are what the dashboard shows for each kind of activity.
+var activityLabels = map[string]string{
+	service.ActivityCreated:    "Created",
+	service.ActivityUpdated:    "Edited",
+	service.ActivityReassigned: "Priority changed",
+	service.ActivityCompleted:  "Completed",
+	service.ActivityReopened:   "Reopened",
+	service.ActivityDeleted:    "Deleted",
+}
+
+// Geometry of the charts, in SVG user units. The charts scale to the width
+// of their card.
+const (
+	barChartWidth  = 420 // Of the bars and the labels and values beside them
+	barRowHeight   = 32
+	barLabelWidth  = 36 // Left of the bars
+	barValueWidth  = 36 // Right of the longest bar
+	trendHeight    = 120
+	trendSlotWidth = 30
+	trendAxisSpace = 20 // Below the columns, for the day labels
+)
+
+// dashboardPage is the data of the dashboard page.
+type dashboardPage struct {
+	layout
+	Stats             service.Stats
+	AverageCompletion string // Empty when no task was completed since startup
+	Priorities        chart  // Open tasks by priority
+	Trend             chart  // Tasks completed per day
+	Overdue           []model.Task
+	Activity          []activityItem // Latest first
+}
+
+// activityItem is a change in the recent activity of the dashboard.
+type activityItem struct {
+	service.Activity
+	Label string
+}
+
+// chart is a bar chart, drawn by the dashboard template as inline SVG.
+type chart struct {
+	Width, Height int
+	Bars          []bar
+	Total         int
+}
+
+// bar is a bar of a chart, with its position in the chart.
+type bar struct {
+	Label         string
+	Title         string // Tooltip
+	Value         int
+	Color         string
+	X, Y          int
+	Width, Height int
+	TextX         int // Of the value beside a bar, or the label below a column
+}
+
+// ServeDashboard renders the dashboard page: the task statistics of
+// /api/stats, the open tasks by priority, the tasks completed on each of
+// the last days, the overdue tasks and the recent activity. Its charts are SVG drawn on the
+// server, so the page needs no scripts.
+func (h *PageHandler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
+	var stats service.Stats
+	var tasks []model.Task
+	var err error
+	inService(r, func() {
+		stats, err = h.service.Stats(r.Context())
+		if err == nil {
+			tasks, err = h.service.GetAll(r.Context())
+		}
+	})
+	if err != nil {
+		h.pageError(w, r, err, "Failed to load tasks")
+		return
+	}
+
+	now := time.Now()
+	page := dashboardPage{
+		layout:     layoutOf(r),
+		Stats:      stats,
+		Priorities: priorityChart(tasks),
+		Overdue:    overdueTasks(tasks, now),
+	}
+	activity, _ := h.service.Activity(0, recentActivity)
+	for _, a := range activity {
+		page.Activity = append(page.Activity, activityItem{Activity: a, Label: activityLabels[a.Kind]})
+	}
+	page.Trend = trendChart(tasks, now, page.Locale)
+	if stats.CompletionLatency.Count > 0 {
+		page.AverageCompletion = formatDuration(time.Duration(stats.CompletionLatency.AverageSeconds * float64(time.Second)))
+	}
+	h.render(w, r, http.StatusOK, fragment{"dashboard.html", page})
+}
+
+// priorityChart charts the open tasks by priority, as horizontal bars in
+// the colors of the priorities.
+func priorityChart(tasks []model.Task) chart {
+	counts := make(map[string]int, len(service.Priorities))
+	for _, task := range tasks {
+		if !task.Completed {
+			counts[task.Priority]++
+		}
+	}
+
+	c := chart{Width: barChartWidth, Height: len(service.Priorities) * barRowHeight}
+	most := 0
+	for _, priority := range service.Priorities {
+		most = max(most, counts[priority])
+	}
+	for i, priority := range service.Priorities {
+		b := bar{Label: priority, Value: counts[priority], Color: priorityColors[priority], X: barLabelWidth, Y: i*barRowHeight + 4, Height: barRowHeight - 8}
+		if most > 0 {
+			b.Width = b.Value * (barChartWidth - barLabelWidth - barValueWidth) / most
+		}
+		b.TextX = b.X + b.Width + 6
+		c.Bars = append(c.Bars, b)
+		c.Total += b.Value
+	}
+	return c
+}
+
+// trendChart charts the tasks completed on each of the trendDays days up
+// to the day of now, in its time zone, as columns. Deleted tasks are not
+// counted.
+func trendChart(tasks []model.Task, now time.Time, locale i18n.Locale) chart {
+	year, month, day := now.Date()
+	first := time.Date(year, month, day-trendDays+1, 0, 0, 0, 0, now.Location())
+	var counts [trendDays]int
+	for _, task := range tasks {
+		if !task.Completed || task.CompletedAt == nil {
+			continue
+		}
+		y, m, d := task.CompletedAt.In(now.Location()).Date()
+		// Counting calendar days, rather than hours, copes with days that
+		// are longer or shorter at changes of daylight saving time.
+		i := int(time.Date(y, m, d, 12, 0, 0, 0, time.UTC).Sub(time.Date(first.Year(), first.Month(), first.Day(), 12, 0, 0, 0, time.UTC)).Hours() / 24)
+		if i >= 0 && i < trendDays {
+			counts[i]++
+		}
+	}
+
+	c := chart{Width: trendDays * trendSlotWidth, Height: trendHeight + trendAxisSpace}
+	most := slices.Max(counts[:])
+	for i, count := range counts {
+		date := first.AddDate(0, 0, i)
+		b := bar{
+			Label: strconv.Itoa(date.Day()),
+			Title: i18n.FormatDate(locale, date) + ": " + strconv.Itoa(count),
+			Value: count,
+			Color: service.ColorGreen,
+			X:     i*trendSlotWidth + 4,
+			Width: trendSlotWidth - 8,
+		}
+		if most > 0 {
+			b.Height = count * trendHeight / most
+		}
+		b.Y, b.TextX = trendHeight-b.Height, b.X+b.Width/2
+		c.Bars = append(c.Bars, b)
+		c.Total += count
+	}
+	return c
+}
+
+// overdueTasks returns the open tasks due before now, longest overdue first.
+func overdueTasks(tasks []model.Task, now time.Time) []model.Task {
+	var overdue []model.Task
+	for _, task := range tasks {
+		if !task.Completed && task.DueDate != nil && task.DueDate.Before(now) {
+			overdue = append(overdue, task)
+		}
+	}
+	slices.SortStableFunc(overdue, func(a, b model.Task) int { return cmp.Compare(a.DueDate.Unix(), b.DueDate.Unix()) })
+	return overdue
+}
+
+// formatDuration formats d to the minute, like "2d 3h", "3h 20m" or "45m".
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	days, hours := minutes/(24*60), minutes/60%24
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes%60)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
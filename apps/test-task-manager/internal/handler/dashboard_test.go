@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/i18n"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+func TestTrendChart(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	// The last days span the start of daylight saving time, on 29 March.
+	now := time.Date(2026, time.April, 2, 9, 0, 0, 0, amsterdam)
+	completed := func(at time.Time) model.Task { return model.Task{Completed: true, CompletedAt: &at} }
+	tasks := []model.Task{
+		completed(time.Date(2026, time.April, 1, 22, 30, 0, 0, time.UTC)), // 2 April in Amsterdam
+		completed(time.Date(2026, time.April, 2, 8, 0, 0, 0, amsterdam)),
+		completed(time.Date(2026, time.March, 20, 12, 0, 0, 0, amsterdam)), // The first day
+		completed(time.Date(2026, time.March, 19, 12, 0, 0, 0, amsterdam)), // Before it
+		{Title: "Open"},
+	}
+
+	c := trendChart(tasks, now, i18n.Dutch)
+	if len(c.Bars) != trendDays || c.Total != 3 {
+		t.Fatalf("expected %d days holding 3 completions, got %d days holding %d", trendDays, len(c.Bars), c.Total)
+	}
+	first, today := c.Bars[0], c.Bars[trendDays-1]
+	if first.Value != 1 || first.Label != "20" || today.Value != 2 || today.Label != "2" {
+		t.Errorf("expected 1 completion on the 20th and 2 today, got %+v and %+v", first, today)
+	}
+	if today.Height != trendHeight || today.Y != 0 || first.Height != trendHeight/2 {
+		t.Errorf("expected the columns to scale to the busiest day, got %+v and %+v", first, today)
+	}
+	if today.Title != "2 apr 2026: 2" {
+		t.Errorf("expected the tooltip in Dutch, got %q", today.Title)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second:                   "1m",
+		45 * time.Minute:                   "45m",
+		3*time.Hour + 20*time.Minute:       "3h 20m",
+		51*time.Hour + 10*time.Minute:      "2d 3h",
+		24*time.Hour - 10*time.Second:      "1d 0h",
+		2*time.Minute + 29*time.Second + 1: "2m",
+		time.Duration(0):                   "0m",
+	}
+	for d, want := range tests {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%s): expected %q, got %q", d, want, got)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/apperr"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/i18n"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+)
+
+// errorMapping is how errors with a code are answered.
+type errorMapping struct {
+	status  int
+	message string // Shown to clients; the message of the error when empty
+	report  bool   // Whether the error is reported, as it needs looking into
+}
+
+// errorMappings maps the codes of service and store errors to responses.
+// Every code clients may see must be listed here, or errors with it are
+// answered like those without one: 500 INTERNAL_SERVER_ERROR.
+var errorMappings = map[apperr.Code]errorMapping{
+	apperr.TaskNotFound:      {status: http.StatusNotFound, message: "Task not found"},
+	apperr.TaskConflict:      {status: http.StatusConflict, message: "The task was changed by another request meanwhile. Try again."},
+	apperr.TaskLocked:        {status: http.StatusLocked, message: "Someone else is editing the task. Try again later."},
+	apperr.EmptyTitle:        {status: http.StatusBadRequest},
+	apperr.TitleTooShort:     {status: http.StatusBadRequest},
+	apperr.TitleTooLong:      {status: http.StatusBadRequest},
+	apperr.InvalidTitle:      {status: http.StatusBadRequest},
+	apperr.DuplicateTitle:    {status: http.StatusConflict},
+	apperr.WIPLimit:          {status: http.StatusConflict},
+	apperr.QuotaExceeded:     {status: http.StatusPaymentRequired},
+	apperr.UserQuotaExceeded: {status: http.StatusConflict},
+	apperr.InvalidPriority:   {status: http.StatusBadRequest, message: "Invalid priority. Must be one of: 🔥, ⭐, ⚡, 💡, 📋, or urgent, high, low or p1 to p5"},
+	apperr.InvalidColor:      {status: http.StatusBadRequest, message: "Invalid color code. Must be a valid hex code."},
+	apperr.MergeWithSelf:     {status: http.StatusBadRequest},
+	apperr.InvalidCursor:     {status: http.StatusBadRequest},
+	apperr.StoreFull:         {status: http.StatusInsufficientStorage, message: "The task store is full. Delete tasks before adding new ones."},
+	apperr.StoreUnavailable:  {status: http.StatusServiceUnavailable, message: "The task store is unavailable. Try again later.", report: true},
+	apperr.StoreTimeout:      {status: http.StatusGatewayTimeout, message: "The task store did not answer in time. Try again."},
+	apperr.TombstonesExpired: {status: http.StatusGone, message: "Deletions that long ago are no longer kept; reload the tasks"},
+	apperr.StoreReadOnly:     {status: http.StatusServiceUnavailable, message: "The task store is unreachable, so tasks can only be read for now. Try the change again later."},
+}
+
+// mapError returns the status and body answering err, reporting it when it
+// needs looking into, with the message in the locale of the request.
+// Errors without a known code answer 500 with fallback as message, so their
+// details, which may hold queries or paths, stay private.
+func mapError(r *http.Request, reporter errorreport.Reporter, err error, fallback string) (int, ErrorResponse) {
+	code := apperr.CodeOf(err)
+	m, ok := errorMappings[code]
+	if !ok {
+		reporter.CaptureError(r, err)
+		return http.StatusInternalServerError, ErrorResponse{Error: i18n.T(localeOf(r), fallback), Code: "INTERNAL_SERVER_ERROR"}
+	}
+	if m.report {
+		reporter.CaptureError(r, err)
+	}
+	message := m.message
+	if message == "" {
+		message = apperr.MessageOf(err)
+	}
+	resp := ErrorResponse{Error: i18n.T(localeOf(r), message), Code: string(code)}
+	var quota *service.UserQuotaError
+	if errors.As(err, &quota) {
+		resp.Usage = &UserUsage{Open: quota.Open, Limit: quota.Limit}
+	}
+	return m.status, resp
+}
+
+// respondMappedError answers err, returned by the service, in the format
+// negotiated from the Accept header; see mapError.
+func respondMappedError(w http.ResponseWriter, r *http.Request, reporter errorreport.Reporter, err error, fallback string) {
+	status, body := mapError(r, reporter, err, fallback)
+	setLanguage(w, r)
+	respond(w, r, body, status)
+}
+
+// invalidError is a problem with the parameters of a request. Its message
+// is in English and translated when it is answered.
+type invalidError struct {
+	format string
+	args   []any
+}
+
+// invalidf returns an invalidError with the message of format and args, as
+// formatted by i18n.T.
+func invalidf(format string, args ...any) error {
+	return &invalidError{format: format, args: args}
+}
+
+func (e *invalidError) Error() string {
+	return fmt.Sprintf(e.format, e.args...)
+}
+
+// localize returns the message of err in locale: translated for an
+// invalidError, as it is otherwise.
+func localize(locale i18n.Locale, err error) string {
+	var invalid *invalidError
+	if errors.As(err, &invalid) {
+		return i18n.T(locale, invalid.format, invalid.args...)
+	}
+	return err.Error()
+}
+
+// respondInvalid answers err, made by invalidf, with 400 INVALID_INPUT.
+func respondInvalid(w http.ResponseWriter, r *http.Request, err error) {
+	setLanguage(w, r)
+	respond(w, r, ErrorResponse{Error: localize(localeOf(r), err), Code: "INVALID_INPUT"}, http.StatusBadRequest)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/apperr"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/failover"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// countingReporter counts the errors reported to it.
+type countingReporter struct {
+	errorreport.Nop
+	errors int
+}
+
+func (c *countingReporter) CaptureError(*http.Request, error) { c.errors++ }
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		err      error
+		status   int
+		code     string
+		message  string
+		reported bool
+	}{
+		{fmt.Errorf("failed to toggle task: %w", store.ErrTaskNotFound), http.StatusNotFound, "TASK_NOT_FOUND", "Task not found", false},
+		{service.ErrEmptyTitle, http.StatusBadRequest, "EMPTY_TITLE", "task title cannot be empty", false},
+		{service.ErrInvalidPriority, http.StatusBadRequest, "INVALID_PRIORITY", "Invalid priority. Must be one of: 🔥, ⭐, ⚡, 💡, 📋, or urgent, high, low or p1 to p5", false},
+		{store.ErrStoreFull, http.StatusInsufficientStorage, "STORE_FULL", "The task store is full. Delete tasks before adding new ones.", false},
+		{apperr.Wrap(apperr.StoreUnavailable, "task store is unavailable", errors.New("dial tcp: connection refused")), http.StatusServiceUnavailable, "STORE_UNAVAILABLE", "The task store is unavailable. Try again later.", true},
+		{apperr.Wrap(apperr.StoreTimeout, "the request ended before the task store answered", context.DeadlineExceeded), http.StatusGatewayTimeout, "STORE_TIMEOUT", "The task store did not answer in time. Try again.", false},
+		{fmt.Errorf("failed to get tombstones: %w", store.ErrTombstonesExpired), http.StatusGone, "TOMBSTONES_EXPIRED", "Deletions that long ago are no longer kept; reload the tasks", false},
+		{fmt.Errorf("failed to create task: %w", failover.ErrReadOnly), http.StatusServiceUnavailable, "STORE_READ_ONLY", "The task store is unreachable, so tasks can only be read for now. Try the change again later.", false},
+		{errors.New("open /var/lib/tasks.json: permission denied"), http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", "Failed", true},
+	}
+	for _, tt := range tests {
+		reporter := &countingReporter{}
+		status, body := mapError(httptest.NewRequest("GET", "/", nil), reporter, tt.err, "Failed")
+		if status != tt.status || body.Code != tt.code || body.Error != tt.message {
+			t.Errorf("%v: expected %d %s %q, got %d %s %q", tt.err, tt.status, tt.code, tt.message, status, body.Code, body.Error)
+		}
+		if reported := reporter.errors > 0; reported != tt.reported {
+			t.Errorf("%v: expected reported=%t", tt.err, tt.reported)
+		}
+	}
+}
+
+func TestMapError_UserQuotaUsage(t *testing.T) {
+	err := fmt.Errorf("failed to create task: %w", &service.UserQuotaError{Open: 3, Limit: 3})
+	status, body := mapError(httptest.NewRequest("POST", "/", nil), &countingReporter{}, err, "Failed")
+	if status != http.StatusConflict || body.Code != "USER_QUOTA_EXCEEDED" {
+		t.Errorf("expected 409 USER_QUOTA_EXCEEDED, got %d %s", status, body.Code)
+	}
+	if body.Usage == nil || *body.Usage != (UserUsage{Open: 3, Limit: 3}) {
+		t.Errorf("expected a usage of 3 of 3 open tasks, got %+v", body.Usage)
+	}
+}
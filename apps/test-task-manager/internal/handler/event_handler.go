@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/events"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Page sizes of event replays.
+const (
+	defaultReplayLimit = 100
+	maxReplayLimit     = 1000
+)
+
+// EventHandler replays the task events kept in the event history.
+type EventHandler struct {
+	history *events.History
+}
+
+// NewEventHandler creates a new EventHandler.
+func NewEventHandler(history *events.History) *EventHandler {
+	return &EventHandler{history: history}
+}
+
+// EventReplay holds a page of replayed events. Next is the from of the
+// next page: the sequence number of the last event, or the from of the
+// request when there are none.
+type EventReplay struct {
+	Events []store.Event `json:"events"`
+	Next   int64         `json:"next"`
+	More   bool          `json:"more"`
+}
+
+// Replay returns the events after the sequence number of the from query
+// parameter, oldest first, up to the limit parameter. Replays from before
+// the oldest kept event are answered with 410 EVENTS_EXPIRED, as events
+// would be missing.
+func (h *EventHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, err := strconv.ParseInt(query.Get("from"), 10, 64)
+	if err != nil || from < 0 {
+		respondError(w, r, "from must be the sequence number of the last event received, or 0", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+	limit := defaultReplayLimit
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxReplayLimit {
+			respondError(w, r, "limit must be between 1 and %d", "INVALID_INPUT", http.StatusBadRequest, maxReplayLimit)
+			return
+		}
+	}
+
+	replayed, more, err := h.history.Since(from, limit)
+	if err != nil { // Expired
+		respondError(w, r, "Events after %d are no longer kept; reload the tasks and replay from %d", "EVENTS_EXPIRED", http.StatusGone, from, h.history.Oldest())
+		return
+	}
+
+	replay := EventReplay{Events: []store.Event{}, Next: from, More: more}
+	if len(replayed) > 0 {
+		replay.Events, replay.Next = replayed, replayed[len(replayed)-1].Seq
+	}
+	respondJSON(w, replay, http.StatusOK)
+}
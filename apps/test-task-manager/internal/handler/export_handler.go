@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/caldav"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/export"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/ical"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// formatICS exports tasks as an iCalendar file of VTODOs, next to the
+// formats of package export.
+const formatICS = "ics"
+
+// exportFormats are the formats ExportTasks serves.
+var exportFormats = append(slices.Clone(export.Formats), formatICS)
+
+const (
+	// streamFlushEvery is the number of tasks after which a streamed
+	// export is flushed to the client.
+	streamFlushEvery = 100
+	// streamWriteWindow is how long writing the next part of a streamed
+	// export may take; the deadline moves along with every flush, so only
+	// clients that stop reading time out.
+	streamWriteWindow = 30 * time.Second
+)
+
+// ExportHandler serves the task list as file download.
+type ExportHandler struct {
+	tasks    *service.TaskService
+	links    *caldav.Links // UIDs of the tasks, shared with the CalDAV calendar
+	reporter errorreport.Reporter
+}
+
+// NewExportHandler creates a new ExportHandler.
+func NewExportHandler(tasks *service.TaskService, links *caldav.Links, reporter errorreport.Reporter) *ExportHandler {
+	return &ExportHandler{tasks: tasks, links: links, reporter: reporter}
+}
+
+// ExportTasks returns the tasks matching the filters of the task list as
+// attachment in the format query parameter: json (the default), csv,
+// ndjson or ics. The list is not paged; ndjson is streamed, see
+// streamNDJSON. Tasks are exported to iCalendar under the UIDs the CalDAV
+// calendar serves them with, so importing the file again counts them as
+// duplicates.
+func (h *ExportHandler) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	format := params.Get("format")
+	if format == "" {
+		format = export.FormatJSON
+	}
+	if !slices.Contains(exportFormats, format) {
+		respondError(w, r, "format must be json, csv, ndjson or ics", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+	q, err := parseTaskQuery(params, h.tasks.StaleCutoff(time.Now()))
+	if err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+	order, err := parseSort(params)
+	if err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+
+	if format == export.FormatNDJSON {
+		h.streamNDJSON(w, r, q, order)
+		return
+	}
+
+	tasks, err := h.tasks.Find(r.Context(), q)
+	if err != nil {
+		respondMappedError(w, r, h.reporter, err, "Failed to export tasks")
+		return
+	}
+	sortTasks(tasks, order)
+
+	// The file is written to a buffer first, so failures still get an
+	// error response rather than a truncated download.
+	var buf bytes.Buffer
+	contentType := export.ContentType(format)
+	if format == formatICS {
+		todos := make([]*ical.Component, len(tasks))
+		for i, task := range tasks {
+			_, uid := h.links.Of(task.ID)
+			todos[i] = ical.Todo(task, uid)
+		}
+		contentType = "text/calendar; charset=utf-8"
+		err = ical.Write(&buf, ical.Calendar(todos...))
+	} else {
+		err = export.Write(&buf, format, tasks)
+	}
+	if err != nil {
+		h.reporter.CaptureError(r, err)
+		respondError(w, r, "Failed to export tasks", "INTERNAL_SERVER_ERROR", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="tasks.`+format+`"`)
+	w.Write(buf.Bytes())
+}
+
+// streamNDJSON writes the tasks matching q as NDJSON while reading them
+// from the store, flushing after the first task and every streamFlushEvery
+// tasks, so large exports start at once and are not held in memory as a
+// whole. Orders other than creation order need all tasks to sort them
+// first. As the status is sent with the first task, a failure after it
+// aborts the response, so clients do not take the part for the export.
+func (h *ExportHandler) streamNDJSON(w http.ResponseWriter, r *http.Request, q store.Query, order string) {
+	each := h.tasks.Each
+	if order != sortCreated {
+		each = func(ctx context.Context, q store.Query, fn func(model.Task) error) error {
+			tasks, err := h.tasks.Find(ctx, q)
+			if err != nil {
+				return err
+			}
+			sortTasks(tasks, order)
+			for _, task := range tasks {
+				if err := fn(task); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	written := 0
+	start := func() {
+		w.Header().Set("Content-Type", export.ContentType(export.FormatNDJSON))
+		w.Header().Set("Content-Disposition", `attachment; filename="tasks.`+export.FormatNDJSON+`"`)
+		w.WriteHeader(http.StatusOK)
+	}
+	var writeErr error
+	err := each(r.Context(), q, func(task model.Task) error {
+		if written == 0 {
+			start()
+		}
+		if writeErr = encoder.Encode(task); writeErr != nil {
+			return writeErr
+		}
+		written++
+		if written == 1 || written%streamFlushEvery == 0 {
+			_ = rc.SetWriteDeadline(time.Now().Add(streamWriteWindow))
+			if writeErr = rc.Flush(); writeErr != nil && !errors.Is(writeErr, http.ErrNotSupported) {
+				return writeErr
+			}
+			writeErr = nil
+		}
+		return nil
+	})
+	switch {
+	case writeErr != nil:
+		panic(http.ErrAbortHandler) // The client is gone
+	case err != nil && written == 0:
+		respondMappedError(w, r, h.reporter, err, "Failed to export tasks")
+	case err != nil:
+		h.reporter.CaptureError(r, err)
+		panic(http.ErrAbortHandler)
+	case written == 0:
+		start()
+	}
+}
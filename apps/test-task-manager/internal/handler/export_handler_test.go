@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/caldav"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// streamingStore is a store.Streamer failing with err after yielding
+// failAfter tasks, when err is set.
+type streamingStore struct {
+	*store.TaskStore
+	failAfter int
+	err       error
+}
+
+func (s *streamingStore) Each(ctx context.Context, q store.Query, fn func(model.Task) error) error {
+	tasks, err := s.Find(ctx, q)
+	if err != nil {
+		return err
+	}
+	for i, task := range tasks {
+		if s.err != nil && i == s.failAfter {
+			return s.err
+		}
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func TestExportTasks_StreamsNDJSON(t *testing.T) {
+	s := &streamingStore{TaskStore: store.NewTaskStore()}
+	tasks := service.NewTaskService(s)
+	for _, title := range []string{"Ship release", "Water plants"} {
+		if _, err := tasks.Create(t.Context(), title, "", "", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links, _ := caldav.NewLinks("")
+	h := NewExportHandler(tasks, links, errorreport.Nop{})
+	export := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ExportTasks(w, httptest.NewRequest("GET", "/api/tasks/export?format=ndjson", nil))
+		return w
+	}
+
+	w := export()
+	if lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n"); w.Code != http.StatusOK || len(lines) != 2 || !w.Flushed {
+		t.Errorf("expected two flushed lines, got %d, flushed %t: %s", w.Code, w.Flushed, w.Body)
+	}
+
+	// Failing before the first task still gets an error response
+	s.err, s.failAfter = errors.New("connection refused"), 0
+	if w := export(); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d: %s", w.Code, w.Body)
+	}
+
+	// After it, the response is aborted rather than ended early
+	s.failAfter = 1
+	defer func() {
+		if got := recover(); got != http.ErrAbortHandler {
+			t.Errorf("expected the response to be aborted, got %v", got)
+		}
+	}()
+	export()
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func FuzzCreateTask(f *testing.F) {
+	f.Add(`{"title":"Buy milk","priority":"🔥","color":"#dc3545","dueDate":"2026-03-01T09:00:00Z"}`, false)
+	f.Add(`{"title":"\u0000","color":"#DC3545"}`, false)
+	f.Add(`{"title":"a\nb","dueDate":"tomorrow"}`, false)
+	f.Add(`{"title":"`+strings.Repeat("🔥", 300)+`"}`, false)
+	f.Add(`{"title":"x"}{"title":"y"}`, false)
+	f.Add(`[{"title":"x"}]`, false)
+	f.Add(`<task><title>Buy milk</title><priority>⭐</priority></task>`, true)
+	f.Add(`<task><title>&#x0;</title></task>`, true)
+	f.Add(`<task><title><![CDATA[ padded ]]></title><dueDate>2026-03-01T09:00:00Z</dueDate></task>`, true)
+
+	f.Fuzz(func(t *testing.T, body string, xml bool) {
+		taskService := service.NewTaskService(store.NewTaskStore())
+		h := NewAPIHandler(taskService, errorreport.Nop{})
+
+		r := httptest.NewRequest("POST", "/api/tasks", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if xml {
+			r.Header.Set("Content-Type", "application/xml")
+		}
+		w := httptest.NewRecorder()
+		h.CreateTask(w, r)
+
+		switch w.Code {
+		case http.StatusCreated:
+		case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+			if tasks, _ := taskService.GetAll(t.Context()); len(tasks) != 0 {
+				t.Fatalf("expected a rejected body to store nothing, got %+v", tasks)
+			}
+			return
+		default:
+			t.Fatalf("expected status 201, 400 or 413, got %d: %s", w.Code, w.Body)
+		}
+
+		tasks, err := taskService.GetAll(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 1 {
+			t.Fatalf("expected one stored task, got %d", len(tasks))
+		}
+		task := tasks[0]
+		if task.Title == "" || task.Title != strings.TrimSpace(task.Title) || utf8.RuneCountInString(task.Title) > 255 ||
+			!utf8.ValidString(task.Title) || strings.ContainsFunc(task.Title, unicode.IsControl) {
+			t.Errorf("invalid title %q stored", task.Title)
+		}
+		if _, err := service.NewTask(task.Title, task.Priority, task.Color, task.DueDate); err != nil {
+			t.Errorf("expected the stored task to be valid, got %v", err)
+		}
+		if !xml {
+			var got model.Task
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.ID != task.ID {
+				t.Errorf("expected the created task as JSON, got %v: %s", err, w.Body)
+			}
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/caldav"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/ical"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// maxImportSize limits the iCalendar files that may be imported.
+const maxImportSize = 10 << 20
+
+// ImportHandler imports tasks from the calendar files of other applications.
+type ImportHandler struct {
+	tasks    *service.TaskService
+	links    *caldav.Links  // UIDs of imported tasks, shared with the CalDAV calendar
+	loc      *time.Location // Of floating dates, unless the request names one
+	reporter errorreport.Reporter
+}
+
+// NewImportHandler creates a new ImportHandler.
+func NewImportHandler(tasks *service.TaskService, links *caldav.Links, loc *time.Location, reporter errorreport.Reporter) *ImportHandler {
+	return &ImportHandler{tasks: tasks, links: links, loc: loc, reporter: reporter}
+}
+
+// ImportResult reports what an import did.
+type ImportResult struct {
+	Imported   int            `json:"imported"`
+	Duplicates int            `json:"duplicates"` // Entries whose UID was imported before
+	Skipped    []SkippedEntry `json:"skipped"`
+	Tasks      []model.Task   `json:"tasks"` // The imported tasks
+}
+
+// SkippedEntry is a calendar entry that could not be imported.
+type SkippedEntry struct {
+	UID     string `json:"uid,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// ImportICS creates a task for each VTODO and VEVENT of an iCalendar file,
+// sent as request body or as the "file" field of a multipart form. The due
+// date of an event is its start. Floating dates are in the time zone named
+// by the timezone query parameter, or else the CalDAV time zone. Entries
+// whose UID was imported before, or that are served under that UID by the
+// CalDAV calendar, are counted as duplicates and left alone; invalid
+// entries are skipped and reported.
+func (h *ImportHandler) ImportICS(w http.ResponseWriter, r *http.Request) {
+	loc := h.loc
+	if name := r.URL.Query().Get("timezone"); name != "" {
+		zone, err := time.LoadLocation(name)
+		if err != nil {
+			respondError(w, r, "timezone must be an IANA time zone like Europe/Amsterdam", "INVALID_INPUT", http.StatusBadRequest)
+			return
+		}
+		loc = zone
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	var body io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			respondError(w, r, "The multipart form must hold the calendar as file field", "INVALID_INPUT", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	cal, err := ical.Parse(body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, r, "The calendar file is larger than 10 MiB", "INVALID_INPUT", http.StatusRequestEntityTooLarge)
+			return
+		}
+		respondError(w, r, "Invalid iCalendar file: %s", "INVALID_INPUT", http.StatusBadRequest, err)
+		return
+	}
+
+	result := ImportResult{Skipped: []SkippedEntry{}, Tasks: []model.Task{}}
+	var tasks []model.Task
+	var uids []string
+	seen := make(map[string]bool)
+	for _, entry := range append(cal.Find("VTODO"), cal.Find("VEVENT")...) {
+		uid := entry.Text("UID")
+		skip := func(reason string) {
+			result.Skipped = append(result.Skipped, SkippedEntry{UID: uid, Summary: entry.Text("SUMMARY"), Reason: reason})
+		}
+
+		if uid != "" {
+			imported, err := h.imported(r.Context(), uid)
+			if err != nil {
+				respondMappedError(w, r, h.reporter, err, "Failed to import tasks")
+				return
+			}
+			// Changed occurrences of a recurring entry repeat its UID.
+			if seen[uid] || imported {
+				result.Duplicates++
+				continue
+			}
+			seen[uid] = true
+		}
+		if status := entry.Prop("STATUS"); status != nil && status.Value == "CANCELLED" {
+			skip("cancelled")
+			continue
+		}
+
+		fields, err := ical.Task(entry, loc)
+		if err != nil {
+			skip(err.Error())
+			continue
+		}
+		task, err := h.tasks.Validate(fields.Title, fields.Priority, fields.Color, fields.DueDate)
+		if err != nil {
+			skip(err.Error())
+			continue
+		}
+		task.Completed = fields.Completed
+		tasks = append(tasks, task)
+		uids = append(uids, uid)
+	}
+
+	if len(tasks) > 0 {
+		created, err := h.tasks.CreateMany(r.Context(), tasks)
+		if err != nil {
+			respondMappedError(w, r, h.reporter, err, "Failed to import tasks")
+			return
+		}
+
+		var links []caldav.Link
+		for i, task := range created {
+			if uids[i] != "" {
+				links = append(links, caldav.Link{Name: task.ID + ".ics", UID: uids[i], TaskID: task.ID})
+			}
+		}
+		if err := h.links.Add(links...); err != nil {
+			// The tasks exist; only a repeated import would duplicate them.
+			h.reporter.CaptureError(r, err)
+		}
+		result.Imported, result.Tasks = len(created), created
+	}
+
+	status := http.StatusOK
+	if result.Imported > 0 {
+		status = http.StatusCreated
+	}
+	respondJSON(w, result, status)
+}
+
+// imported reports whether a task with uid exists: one imported or created
+// through CalDAV under that UID, or one served with its ID as UID.
+func (h *ImportHandler) imported(ctx context.Context, uid string) (bool, error) {
+	id, ok := h.links.Task(uid)
+	if !ok {
+		id = uid
+	}
+	_, err := h.tasks.Get(ctx, id)
+	if errors.Is(err, store.ErrTaskNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
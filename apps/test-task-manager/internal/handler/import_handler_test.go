@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/caldav"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestImportICS_SkipsDuplicateUIDs(t *testing.T) {
+	links, _ := caldav.NewLinks("")
+	h := NewImportHandler(service.NewTaskService(store.NewTaskStore()), links, time.UTC, errorreport.Nop{})
+	calendar := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VTODO\r\nUID:todo-1\r\nSUMMARY:File taxes\r\nDUE;VALUE=DATE:20260401\r\nEND:VTODO\r\n" +
+		"BEGIN:VEVENT\r\nUID:event-1\r\nSUMMARY:Dentist\r\nDTSTART;TZID=Europe/Amsterdam:20260310T143000\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:event-1\r\nRECURRENCE-ID:20260317T143000\r\nSUMMARY:Dentist\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:event-2\r\nSUMMARY:\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	importICS := func() ImportResult {
+		w := httptest.NewRecorder()
+		h.ImportICS(w, httptest.NewRequest("POST", "/api/import/ics", strings.NewReader(calendar)))
+		var result ImportResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("expected a JSON result, got %d: %s", w.Code, w.Body)
+		}
+		return result
+	}
+
+	first := importICS()
+	if first.Imported != 2 || first.Duplicates != 1 || len(first.Skipped) != 1 {
+		t.Fatalf("expected two tasks, one duplicate and one skipped entry, got %+v", first)
+	}
+	if want := time.Date(2026, 3, 10, 13, 30, 0, 0, time.UTC); !first.Tasks[1].DueDate.Equal(want) {
+		t.Errorf("expected the event to be due at %s, got %s", want, first.Tasks[1].DueDate)
+	}
+
+	second := importICS()
+	if second.Imported != 0 || second.Duplicates != 3 {
+		t.Errorf("expected nothing to be imported twice, got %+v", second)
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+)
+
+// Messages of the login page.
+const (
+	loginFailed      = "Invalid user name or API key"
+	loginUnavailable = "Signing in is unavailable. Try again later."
+)
+
+// SessionStore signs users in and out of the HTML UI.
+type SessionStore interface {
+	Authenticate(token string) (auth.User, error)
+	CreateSession(userRef string, ttl time.Duration) (auth.IssuedSession, error)
+	EndSession(id string) error
+}
+
+// LoginHandler signs browsers in to the HTML UI, with a session kept in the
+// auth.SessionCookie cookie, and out again. Users sign in with their name
+// and one of their API keys, as calendar applications do.
+type LoginHandler struct {
+	sessions SessionStore
+	pages    *PageHandler // Renders the login page
+	ttl      time.Duration
+	reporter errorreport.Reporter
+}
+
+// NewLoginHandler creates a new LoginHandler, signing in for ttl.
+func NewLoginHandler(sessions SessionStore, pages *PageHandler, ttl time.Duration, reporter errorreport.Reporter) *LoginHandler {
+	return &LoginHandler{sessions: sessions, pages: pages, ttl: ttl, reporter: reporter}
+}
+
+// loginPage is the data of the login page.
+type loginPage struct {
+	layout
+	Name   string
+	Return string // The page to go to after signing in
+	Error  string
+}
+
+// ServeLogin renders the login page, which returns to the page in its
+// return query parameter after signing in. Signed-in users go there
+// directly.
+func (h *LoginHandler) ServeLogin(w http.ResponseWriter, r *http.Request) {
+	target := localPath(r.URL.Query().Get("return"))
+	if _, ok := auth.UserFromContext(r.Context()); ok {
+		http.Redirect(w, r, target, http.StatusSeeOther)
+		return
+	}
+	h.pages.render(w, r, http.StatusOK, fragment{"login.html", loginPage{layout: layoutOf(r), Return: target}})
+}
+
+// Login signs in the user named in the submitted form with one of their
+// API keys, and redirects to the page in its return field. Failures show
+// the login page again.
+func (h *LoginHandler) Login(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	page := loginPage{layout: layoutOf(r), Name: name, Return: localPath(r.PostFormValue("return"))}
+
+	user, err := h.sessions.Authenticate(r.PostFormValue("key"))
+	if errors.Is(err, auth.ErrInvalidCredentials) || err == nil && user.Name != name {
+		page.Error = loginFailed
+		h.pages.render(w, r, http.StatusUnauthorized, fragment{"login.html", page})
+		return
+	}
+	var session auth.IssuedSession
+	if err == nil {
+		session, err = h.sessions.CreateSession(user.ID, h.ttl)
+	}
+	if err != nil {
+		h.reporter.CaptureError(r, err)
+		page.Error = loginUnavailable
+		h.pages.render(w, r, http.StatusInternalServerError, fragment{"login.html", page})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookie,
+		Value:    session.Token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, page.Return, http.StatusSeeOther)
+}
+
+// Logout ends the session of the browser, removes its cookie and redirects
+// to the login page.
+func (h *LoginHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(auth.SessionCookie); err == nil {
+		// Only the holder of the token may end its session.
+		id, ok := auth.SessionID(cookie.Value)
+		if _, err := h.sessions.Authenticate(cookie.Value); ok && err == nil {
+			if err := h.sessions.EndSession(id); err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+				h.reporter.CaptureError(r, err)
+			}
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
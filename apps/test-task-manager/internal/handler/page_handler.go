@@ -1,42 +1,679 @@
 package handler
 
 import (
+	"bytes"
 	"html/template"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/apperr"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/assets"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/i18n"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/maintenance"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/palette"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/timing"
 )
 
 // PageHandler handles HTML page requests.
 type PageHandler struct {
 	service   *service.TaskService
-	templates *template.Template
+	templates map[i18n.Locale]*template.Template // Translating into their locale
+	reporter  errorreport.Reporter
+	cache     *responseCache // nil when caching is disabled
+
+	listLimit    int // Default page size of the task list (0 lists all tasks)
+	maxListLimit int // Largest page size visitors may ask for (0 means no cap)
+
+	readOnly bool // Whether the controls changing tasks are hidden
+}
+
+// PageOption configures optional PageHandler behavior.
+type PageOption func(*PageHandler)
+
+// WithRenderCache caches the rendered task list page for up to ttl, until
+// the next change made through the service. Visitors only see different
+// pages when their preferences, languages or users differ, so an entry
+// serves every visitor with the same ones. Hits and misses are counted on reg.
+func WithRenderCache(ttl time.Duration, reg *metrics.Registry) PageOption {
+	return func(h *PageHandler) {
+		if ttl > 0 {
+			h.cache = newResponseCache(ttl, reg.CounterVec("page_render_cache_requests_total", "Task list page requests by render cache result.", "result"))
+		}
+	}
+}
+
+// WithPageSize shows the task list in pages of defaultLimit tasks unless
+// the preferences or the limit query parameter ask for other pages, of at
+// most maxLimit tasks (0 means no cap), like the lists of the API.
+func WithPageSize(defaultLimit, maxLimit int) PageOption {
+	return func(h *PageHandler) {
+		h.listLimit, h.maxListLimit = defaultLimit, maxLimit
+	}
+}
+
+// WithReadOnly hides the controls creating, toggling, editing and deleting
+// tasks, and those saving preferences, for instances whose changes are
+// turned away by middleware.ReadOnly.
+func WithReadOnly() PageOption {
+	return func(h *PageHandler) {
+		h.readOnly = true
+	}
 }
 
 // NewPageHandler creates a new PageHandler.
-func NewPageHandler(service *service.TaskService) *PageHandler {
-	// Parse all templates
-	templates := template.Must(template.ParseGlob("templates/*.html"))
+// Templates reference static files through the asset helper, e.g. {{asset "css/styles.css"}},
+// translate messages with the i18n helpers, e.g. {{t "Total: %d tasks" .Total}},
+// leave out controls making changes when {{readOnly}}, and style elements
+// with the variants of a task color with {{swatch .Color}}.
+func NewPageHandler(service *service.TaskService, reporter errorreport.Reporter, staticAssets *assets.Assets, opts ...PageOption) *PageHandler {
+	h := &PageHandler{
+		service:  service,
+		reporter: reporter,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
 
-	return &PageHandler{
-		service:   service,
-		templates: templates,
+	// Parse all templates, then bind a copy to the helpers of each locale
+	parsed := template.Must(template.New("").Funcs(template.FuncMap{
+		"asset":    staticAssets.Path,
+		"readOnly": func() bool { return h.readOnly },
+		"swatch":   func(code string) template.CSS { return swatch(h.service.Palette().Resolve(code)) },
+	}).Funcs(i18n.Funcs(i18n.Default)).ParseGlob("templates/*.html"))
+	h.templates = make(map[i18n.Locale]*template.Template, len(i18n.Locales))
+	for _, locale := range i18n.Locales {
+		h.templates[locale] = template.Must(parsed.Clone()).Funcs(i18n.Funcs(locale))
 	}
+	return h
 }
 
-// ServeTaskList renders the main task list page.
+// ServeTaskList renders the main task list page. The status, priority,
+// dueAfter, dueBefore and sort query parameters filter and order the list
+// as they do that of the API; without sort, the list is in the order of
+// the preferences. Like those of the API, limit and offset select the page
+// of the list shown.
 func (h *PageHandler) ServeTaskList(w http.ResponseWriter, r *http.Request) {
-	tasks := h.service.GetAll()
+	shown := layoutOf(r)
+	filter, err := h.parseListFilter(r.URL.Query(), shown.Prefs)
+	if err != nil {
+		http.Error(w, localize(localeOf(r), err), http.StatusBadRequest)
+		return
+	}
 
-	data := struct {
-		Tasks []model.Task
-	}{
-		Tasks: tasks,
+	// As for task lists, the generation is read before the tasks.
+	w.Header().Add("Vary", "Accept-Language")
+	key, generation := "index?"+r.URL.Query().Encode()+"#"+shown.Prefs.encode()+"#"+string(shown.Locale)+"#"+shown.User+"#"+shown.Maintenance, h.service.Generation()
+	if h.cache != nil {
+		if entry, ok := h.cache.get(key, generation); ok {
+			entry.write(w, "HIT")
+			return
+		}
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "index.html", data); err != nil {
+	data, err := h.listTasks(r, filter)
+	if err != nil {
+		h.pageError(w, r, err, "Failed to load tasks")
+		return
+	}
+	data.layout = shown
+
+	stopTiming := timing.Track(r.Context(), "template")
+	buf := getBuffer()
+	defer putBuffer(buf)
+	err = h.templates[shown.Locale].ExecuteTemplate(buf, "index.html", data)
+	stopTiming()
+	if err != nil {
+		h.reporter.CaptureError(r, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	if h.cache == nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(buf.Bytes())
+		return
+	}
+	// The buffer goes back to the pool, so the cache keeps a copy.
+	entry := cachedResponse{generation: generation, contentType: "text/html; charset=utf-8", body: bytes.Clone(buf.Bytes())}
+	h.cache.put(key, entry)
+	entry.write(w, "MISS")
+}
+
+// layout is the data of what all pages show: the locale and preferences
+// they are shown with, their URL, which the preferences form returns to,
+// the name of the signed-in user, if any, and the message of the
+// maintenance banner, when changes are turned away.
+type layout struct {
+	Locale      i18n.Locale
+	Prefs       Preferences
+	URL         string
+	User        string
+	Maintenance string
+}
+
+func layoutOf(r *http.Request) layout {
+	l := layout{Locale: localeOf(r), Prefs: preferencesOf(r), URL: r.URL.RequestURI()}
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		l.User = user.Name
+	}
+	if settings, ok := maintenance.FromContext(r.Context()); ok {
+		l.Maintenance = settings.ClientMessage()
+	}
+	return l
+}
+
+// localeOf returns the locale of the languages the client accepts.
+func localeOf(r *http.Request) i18n.Locale {
+	return i18n.Match(r.Header.Get("Accept-Language"))
+}
+
+// pageData is the data of the task list page and its task-list fragment.
+type pageData struct {
+	layout
+	Tasks      []model.Task // On the page shown
+	Total      int          // Matching the filter, on all pages
+	Prev, Next string       // URLs of the pages around the one shown, if any
+	Filter     listFilter
+	Form       taskForm
+	Priorities []option
+}
+
+// listFilter is how the task list is filtered and ordered, as chosen with
+// the controls above it, and which page of it is shown.
+type listFilter struct {
+	Status   string
+	Priority string
+	Sort     string
+
+	query  store.Query
+	page   page
+	params url.Values
+}
+
+// parseListFilter reads the filter from query parameters, with the order
+// and page size of prefs unless they ask for others.
+func (h *PageHandler) parseListFilter(params url.Values, prefs Preferences) (listFilter, error) {
+	q, err := parseTaskQuery(params, h.service.StaleCutoff(time.Now()))
+	if err != nil {
+		return listFilter{}, err
+	}
+	order, err := parseSort(params)
+	if err != nil {
+		return listFilter{}, err
+	}
+	if params.Get("sort") == "" {
+		order = prefs.Sort
+	}
+	limit := h.listLimit
+	if prefs.PageSize > 0 {
+		limit = prefs.PageSize
+		if h.maxListLimit > 0 {
+			limit = min(limit, h.maxListLimit)
+		}
+	}
+	p, err := parsePage(params, limit, h.maxListLimit)
+	if err != nil {
+		return listFilter{}, err
+	}
+	return listFilter{Status: params.Get("status"), Priority: q.Priority, Sort: order, query: q, page: p, params: params}, nil
+}
+
+// Active reports whether the filter leaves tasks out.
+func (f listFilter) Active() bool {
+	return f.query != store.Query{}
+}
+
+// ExportURL returns the URL downloading the tasks of the filter, on every
+// page, in format. The order is that of the list, which may be the one of
+// the preferences.
+func (f listFilter) ExportURL(format string) string {
+	params := url.Values{}
+	for name, values := range f.params {
+		if name != "limit" && name != "offset" {
+			params[name] = values
+		}
+	}
+	params.Set("format", format)
+	params.Set("sort", f.Sort)
+	return (&url.URL{Path: "/api/tasks/export", RawQuery: params.Encode()}).String()
+}
+
+// listTasks returns the data of the task list filtered by filter. Pages
+// past the last one show the last one.
+func (h *PageHandler) listTasks(r *http.Request, filter listFilter) (pageData, error) {
+	var tasks []model.Task
+	var err error
+	inService(r, func() { tasks, err = h.service.Find(r.Context(), filter.query) })
+	if err != nil {
+		return pageData{}, err
+	}
+	sortTasks(tasks, filter.Sort)
+
+	p := filter.page.last(len(tasks))
+	data := pageData{Tasks: p.apply(tasks), Total: len(tasks), Filter: filter, Form: h.newForm(), Priorities: priorityOptions}
+	if prev, ok := p.prev(); ok {
+		data.Prev = prev.url("/", filter.params)
+	}
+	if next, ok := p.next(len(tasks)); ok {
+		data.Next = next.url("/", filter.params)
+	}
+	return data, nil
+}
+
+// renderList renders the task list, followed by the extra fragments. Its
+// filter is that of the request, or of the page htmx made the request
+// from when the request has none, so the list keeps the filters the page
+// shows.
+func (h *PageHandler) renderList(w http.ResponseWriter, r *http.Request, extra ...fragment) {
+	params := r.URL.Query()
+	if current, err := url.Parse(r.Header.Get("HX-Current-URL")); len(params) == 0 && err == nil {
+		params = current.Query()
+	}
+	filter, err := h.parseListFilter(params, preferencesOf(r))
+	if err != nil {
+		http.Error(w, localize(localeOf(r), err), http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.listTasks(r, filter)
+	if err != nil {
+		h.pageError(w, r, err, "Failed to load tasks")
+		return
+	}
+	h.render(w, r, http.StatusOK, append([]fragment{{"task-list", data}}, extra...)...)
+}
+
+// taskForm is the data of the task creation form: the values entered and
+// why they were rejected.
+type taskForm struct {
+	Title    string
+	Priority string
+	Error    string
+	OOB      bool // Swapped in out of band, to clear the form after a task was created
+}
+
+// priorityColors is the color of tasks created with each priority through
+// the form, which only asks for the priority. Palettes without it give the
+// tasks the default color.
+var priorityColors = map[string]string{
+	service.PriorityUrgentImportant: service.ColorRed,
+	service.PriorityImportant:       service.ColorBlue,
+	service.PriorityUrgent:          service.ColorYellow,
+	service.PriorityLow:             service.ColorGreen,
+	service.PriorityDefault:         service.ColorGrey,
+}
+
+// TaskListFragment renders the task list, as swapped into the page by
+// htmx after changes. It takes the query parameters of the page.
+func (h *PageHandler) TaskListFragment(w http.ResponseWriter, r *http.Request) {
+	h.renderList(w, r)
+}
+
+// TaskFragment renders the row of a task.
+func (h *PageHandler) TaskFragment(w http.ResponseWriter, r *http.Request) {
+	task, err := h.service.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.pageError(w, r, err, "Failed to load task")
+		return
+	}
+	h.render(w, r, http.StatusOK, fragment{"task-item", task})
+}
+
+// newForm returns an empty task creation form, with the default priority
+// selected.
+func (h *PageHandler) newForm() taskForm {
+	return taskForm{Priority: h.service.Defaults().Priority}
+}
+
+// TaskFormFragment renders an empty task creation form.
+func (h *PageHandler) TaskFormFragment(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, http.StatusOK, fragment{"task-form", h.newForm()})
+}
+
+// CreateTaskFragment creates a task from the submitted form and renders the
+// task list with it, along with an empty form swapped in out of band. An
+// invalid task answers 422 with the form showing why, which the response
+// headers make htmx swap in place of the form.
+func (h *PageHandler) CreateTaskFragment(w http.ResponseWriter, r *http.Request) {
+	form := taskForm{Title: r.PostFormValue("title"), Priority: r.PostFormValue("priority")}
+	if form.Priority == "" {
+		form.Priority = h.service.Defaults().Priority
+	}
+
+	var err error
+	inService(r, func() {
+		color := priorityColors[form.Priority]
+		if _, ok := h.service.Palette().Lookup(color); !ok {
+			color = ""
+		}
+		_, err = h.service.CreateBy(r.Context(), userID(r), form.Title, form.Priority, color, nil)
+	})
+	if err != nil {
+		status, message := h.errorMessage(r, err, "Failed to create task")
+		if status != http.StatusBadRequest {
+			http.Error(w, message, status)
+			return
+		}
+		form.Error = message
+		w.Header().Set("HX-Retarget", "#task-form")
+		w.Header().Set("HX-Reswap", "outerHTML")
+		h.render(w, r, http.StatusUnprocessableEntity, fragment{"task-form", form})
+		return
+	}
+	form = h.newForm()
+	form.OOB = true
+	h.renderList(w, r, fragment{"task-form", form})
+}
+
+// ToggleTaskFragment toggles a task and renders its row.
+func (h *PageHandler) ToggleTaskFragment(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := h.service.CheckLock(id, lockOwner(r)); err != nil {
+		h.pageError(w, r, err, "Failed to toggle task")
+		return
+	}
+
+	var task model.Task
+	var err error
+	inService(r, func() { task, err = h.service.Toggle(r.Context(), id) })
+	if err != nil {
+		h.pageError(w, r, err, "Failed to toggle task")
+		return
+	}
+	h.render(w, r, http.StatusOK, fragment{"task-item", task})
+}
+
+// DeleteTaskFragment deletes a task and renders the task list without it,
+// so the counts and the empty state are up to date.
+func (h *PageHandler) DeleteTaskFragment(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := h.service.CheckLock(id, lockOwner(r)); err != nil {
+		h.pageError(w, r, err, "Failed to delete task")
+		return
+	}
+
+	var err error
+	inService(r, func() { err = h.service.Delete(r.Context(), id) })
+	if err != nil {
+		h.pageError(w, r, err, "Failed to delete task")
+		return
+	}
+	h.renderList(w, r)
+}
+
+// Views of the board page.
+const (
+	boardMatrix = "matrix" // Open tasks in the quadrants of the Eisenhower matrix
+	boardStatus = "status" // Tasks in open and completed columns
+)
+
+// quadrants are the columns of the matrix view, which lists tasks without
+// priority (📋) after them.
+var quadrants = []boardColumn{
+	{Priority: service.PriorityUrgentImportant, Title: "Do first", Hint: "Urgent and important"},
+	{Priority: service.PriorityImportant, Title: "Schedule", Hint: "Important, not urgent"},
+	{Priority: service.PriorityUrgent, Title: "Delegate", Hint: "Urgent, not important"},
+	{Priority: service.PriorityLow, Title: "Eliminate", Hint: "Neither urgent nor important"},
+	{Priority: service.PriorityDefault, Title: "Uncategorized", Hint: "No priority yet"},
+}
+
+// boardPage is the data of the board page.
+type boardPage struct {
+	layout
+	View    string
+	Columns []boardColumn
+}
+
+// boardColumn is a group of tasks on the board.
+type boardColumn struct {
+	Priority string // Of the tasks in the column, in the matrix view
+	Title    string
+	Hint     string
+	Tasks    []model.Task
+}
+
+// ServeBoard renders the board page, which groups tasks into the four
+// quadrants of the Eisenhower matrix by priority, or into open and
+// completed columns with view=status. Completed tasks need no
+// prioritizing, so the matrix leaves them out.
+func (h *PageHandler) ServeBoard(w http.ResponseWriter, r *http.Request) {
+	view := r.URL.Query().Get("view")
+	if view == "" {
+		view = boardMatrix
+	}
+	if view != boardMatrix && view != boardStatus {
+		http.Error(w, "view must be matrix or status", http.StatusBadRequest)
+		return
+	}
+
+	var tasks []model.Task
+	var err error
+	inService(r, func() { tasks, err = h.service.GetAll(r.Context()) })
+	if err != nil {
+		h.pageError(w, r, err, "Failed to load tasks")
+		return
+	}
+
+	page := boardPage{layout: layoutOf(r), View: view}
+	switch view {
+	case boardMatrix:
+		page.Columns = slices.Clone(quadrants)
+		for _, task := range tasks {
+			if task.Completed {
+				continue
+			}
+			for i := range page.Columns {
+				if page.Columns[i].Priority == task.Priority {
+					page.Columns[i].Tasks = append(page.Columns[i].Tasks, task)
+				}
+			}
+		}
+	case boardStatus:
+		open := boardColumn{Title: "Open", Hint: "To do"}
+		completed := boardColumn{Title: "Completed", Hint: "Done"}
+		for _, task := range tasks {
+			if task.Completed {
+				completed.Tasks = append(completed.Tasks, task)
+			} else {
+				open.Tasks = append(open.Tasks, task)
+			}
+		}
+		page.Columns = []boardColumn{open, completed}
+	}
+	h.render(w, r, http.StatusOK, fragment{"board.html", page})
+}
+
+// option is a choice of a form field.
+type option struct {
+	Value string
+	Label string
+}
+
+// priorityOptions are the priority choices of the edit form.
+var priorityOptions = []option{
+	{service.PriorityUrgentImportant, "🔥 Urgent & Important"},
+	{service.PriorityImportant, "⭐ Important"},
+	{service.PriorityUrgent, "⚡ Urgent"},
+	{service.PriorityLow, "💡 Low"},
+	{service.PriorityDefault, "📋 Default"},
+}
+
+// colorOptions returns the color choices of the edit form: the colors of p,
+// labeled with their capitalized names, which are translated when they are
+// those of the default palette.
+func colorOptions(p *palette.Palette) []option {
+	var options []option
+	for _, c := range p.Colors() {
+		options = append(options, option{c.Light, strings.ToUpper(c.Name[:1]) + c.Name[1:]})
+	}
+	return options
+}
+
+// swatch returns the CSS custom properties styling an element with c: the
+// task-color classes of styles.css use the light or the dark variant, and
+// the text color readable on it, by the theme of the page.
+func swatch(c palette.Color) template.CSS {
+	if c.Light == "" {
+		return ""
+	}
+	// The codes are validated hex codes, so they are safe as CSS.
+	return template.CSS("--task-color: " + c.Light + "; --task-color-dark: " + c.Dark +
+		"; --task-text: " + c.LightText + "; --task-text-dark: " + c.DarkText)
+}
+
+// dateLayout is the format of date inputs.
+const dateLayout = "2006-01-02"
+
+// editPage is the data of the task edit page: the task as stored, the
+// values entered and why they were rejected, by form field.
+type editPage struct {
+	layout
+	Task       model.Task
+	Title      string
+	Priority   string
+	Color      string
+	DueDate    string
+	Errors     map[string]string
+	Priorities []option
+	Colors     []option
+}
+
+// fieldOf is the form field errors with each code are shown at.
+var fieldOf = map[apperr.Code]string{
+	apperr.EmptyTitle:      "title",
+	apperr.TitleTooShort:   "title",
+	apperr.TitleTooLong:    "title",
+	apperr.InvalidTitle:    "title",
+	apperr.DuplicateTitle:  "title",
+	apperr.InvalidPriority: "priority",
+	apperr.InvalidColor:    "color",
+}
+
+// EditTaskPage renders the form changing the title, priority, color and
+// due date of a task.
+func (h *PageHandler) EditTaskPage(w http.ResponseWriter, r *http.Request) {
+	task, err := h.service.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.pageError(w, r, err, "Failed to load task")
+		return
+	}
+
+	page := editPage{Task: task, Title: task.Title, Priority: task.Priority, Color: task.Color}
+	if task.DueDate != nil {
+		page.DueDate = task.DueDate.In(time.Local).Format(dateLayout)
+	}
+	h.renderEditPage(w, r, http.StatusOK, page)
+}
+
+// UpdateTask changes a task from the submitted edit form and redirects to
+// the task list. The due date is a day in the server's time zone, due at
+// 17:00 unless it is the day the task was due already, whose time is kept.
+// Invalid values answer 422 with the form showing why next to them.
+func (h *PageHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	task, err := h.service.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.pageError(w, r, err, "Failed to load task")
+		return
+	}
+
+	page := editPage{
+		Task:     task,
+		Title:    r.PostFormValue("title"),
+		Priority: r.PostFormValue("priority"),
+		Color:    r.PostFormValue("color"),
+		DueDate:  r.PostFormValue("dueDate"),
+		Errors:   make(map[string]string),
+	}
+	var due *time.Time
+	if page.DueDate != "" {
+		day, err := time.ParseInLocation(dateLayout, page.DueDate, time.Local)
+		if err != nil {
+			page.Errors["dueDate"] = i18n.T(localeOf(r), "The due date must be a date like 2026-03-01")
+			h.renderEditPage(w, r, http.StatusUnprocessableEntity, page)
+			return
+		}
+		if task.DueDate != nil && task.DueDate.In(time.Local).Format(dateLayout) == page.DueDate {
+			due = task.DueDate
+		} else {
+			d := day.Add(17 * time.Hour)
+			due = &d
+		}
+	}
+
+	if err := h.service.CheckLock(task.ID, lockOwner(r)); err != nil {
+		h.pageError(w, r, err, "Failed to update task")
+		return
+	}
+
+	inService(r, func() { _, err = h.service.Update(r.Context(), task.ID, page.Title, page.Priority, page.Color, due) })
+	if field, ok := fieldOf[apperr.CodeOf(err)]; ok {
+		_, page.Errors[field] = h.errorMessage(r, err, "")
+		h.renderEditPage(w, r, http.StatusUnprocessableEntity, page)
+		return
+	}
+	if err != nil {
+		h.pageError(w, r, err, "Failed to update task")
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (h *PageHandler) renderEditPage(w http.ResponseWriter, r *http.Request, status int, page editPage) {
+	page.layout = layoutOf(r)
+	page.Priorities, page.Colors = priorityOptions, colorOptions(h.service.Palette())
+	h.render(w, r, status, fragment{"edit.html", page})
+}
+
+// fragment is a template, or a page, to render with its data.
+type fragment struct {
+	name string
+	data any
+}
+
+// render writes the fragments, one after the other, as an HTML response.
+// They are rendered into a buffer first, so template errors still answer
+// 500.
+func (h *PageHandler) render(w http.ResponseWriter, r *http.Request, status int, fragments ...fragment) {
+	templates := h.templates[localeOf(r)]
+	stopTiming := timing.Track(r.Context(), "template")
+	buf := getBuffer()
+	defer putBuffer(buf)
+	for _, f := range fragments {
+		if err := templates.ExecuteTemplate(buf, f.name, f.data); err != nil {
+			stopTiming()
+			h.reporter.CaptureError(r, err)
+			http.Error(w, "Failed to render page", http.StatusInternalServerError)
+			return
+		}
+	}
+	stopTiming()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// pageError answers err as plain text, which the task list page shows in
+// its error alert; see errorMessage.
+func (h *PageHandler) pageError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	status, message := h.errorMessage(r, err, fallback)
+	http.Error(w, message, status)
+}
+
+// errorMessage returns the status and message answering err, as mapError
+// does.
+func (h *PageHandler) errorMessage(r *http.Request, err error, fallback string) (int, string) {
+	status, body := mapError(r, h.reporter, err, fallback)
+	return status, body.Error
 }
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/apperr"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/assets"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/i18n"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/maintenance"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/palette"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// TestPageMessagesTranslated checks the messages pages show from data,
+// which the template check of package i18n cannot see.
+func TestPageMessagesTranslated(t *testing.T) {
+	var messages []string
+	for _, o := range slices.Concat(priorityOptions, colorOptions(palette.Default())) {
+		messages = append(messages, o.Label)
+	}
+	for _, label := range activityLabels {
+		messages = append(messages, label)
+	}
+	for _, c := range quadrants {
+		messages = append(messages, c.Title, c.Hint)
+	}
+	for _, m := range errorMappings {
+		if m.message != "" {
+			messages = append(messages, m.message)
+		}
+	}
+	messages = append(messages, loginFailed, loginUnavailable, maintenance.DefaultMessage)
+	for _, err := range []error{service.ErrEmptyTitle, service.ErrTitleTooShort, service.ErrTitleTooLong, service.ErrInvalidTitle, service.ErrDuplicateTitle} {
+		messages = append(messages, apperr.MessageOf(err))
+	}
+
+	for _, locale := range i18n.Locales {
+		for _, message := range messages {
+			if !i18n.Translated(locale, message) {
+				t.Errorf("%q has no %s translation", message, locale)
+			}
+		}
+	}
+}
+
+func TestServeTaskList_RenderCache(t *testing.T) {
+	t.Chdir("../..") // The module root, where the templates are read from
+	staticAssets, err := assets.New("static", "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskService := service.NewTaskService(store.NewTaskStore())
+	h := NewPageHandler(taskService, errorreport.Nop{}, staticAssets, WithRenderCache(time.Minute, metrics.NewRegistry()))
+
+	get := func(language string) (cacheStatus, body string) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Language", language)
+		h.ServeTaskList(w, r)
+		if w.Code != 200 {
+			t.Fatalf("expected the page, got %d: %s", w.Code, w.Body)
+		}
+		return w.Header().Get("X-Cache"), w.Body.String()
+	}
+
+	first, err := taskService.Create(t.Context(), "Write the report", "🔥", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := get("en"); status != "MISS" {
+		t.Errorf("expected a miss on the first request, got %q", status)
+	}
+	if status, _ := get("en"); status != "HIT" {
+		t.Errorf("expected a hit on the second request, got %q", status)
+	}
+	if status, _ := get("nl"); status != "MISS" {
+		t.Errorf("expected pages in another language to be cached apart, got %q", status)
+	}
+
+	taskService.Create(t.Context(), "Plan the sprint", "⭐", "", nil)
+	if status, body := get("en"); status != "MISS" || !strings.Contains(body, "Plan the sprint") {
+		t.Errorf("expected a new task to invalidate the page, got %q", status)
+	}
+
+	before, _ := get("en")
+	if before != "HIT" {
+		t.Fatalf("expected the page to be cached again, got %q", before)
+	}
+	if _, err := taskService.Toggle(t.Context(), first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := get("en"); status != "MISS" {
+		t.Errorf("expected a toggled task to invalidate the page, got %q", status)
+	}
+	if err := taskService.Delete(t.Context(), first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if status, body := get("en"); status != "MISS" || strings.Contains(body, "Write the report") {
+		t.Errorf("expected a deleted task to invalidate the page, got %q", status)
+	}
+}
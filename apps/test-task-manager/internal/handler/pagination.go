@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// page is the slice of a task list selected by the limit and offset query
+// parameters. A zero limit selects all tasks from offset on.
+type page struct {
+	limit  int
+	offset int
+}
+
+// parsePage reads the page from query parameters. Without a limit
+// parameter defaultLimit applies; larger pages must be asked for
+// explicitly and are capped at maxLimit (0 means no cap).
+func parsePage(params url.Values, defaultLimit, maxLimit int) (page, error) {
+	p := page{limit: defaultLimit}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || (maxLimit > 0 && limit > maxLimit) {
+			if maxLimit > 0 {
+				return page{}, invalidf("limit must be between 1 and %d", maxLimit)
+			}
+			return page{}, invalidf("limit must be a positive number")
+		}
+		p.limit = limit
+	}
+	if v := params.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return page{}, invalidf("offset must be zero or a positive number")
+		}
+		p.offset = offset
+	}
+	return p, nil
+}
+
+// apply returns the tasks on the page.
+func (p page) apply(tasks []model.Task) []model.Task {
+	tasks = tasks[min(p.offset, len(tasks)):]
+	if p.limit > 0 && len(tasks) > p.limit {
+		tasks = tasks[:p.limit]
+	}
+	return tasks
+}
+
+// next returns the page after p in a list of total tasks, if there is one.
+func (p page) next(total int) (page, bool) {
+	if p.limit == 0 || p.offset+p.limit >= total {
+		return page{}, false
+	}
+	return page{limit: p.limit, offset: p.offset + p.limit}, true
+}
+
+// prev returns the page before p, if there is one.
+func (p page) prev() (page, bool) {
+	if p.limit == 0 || p.offset == 0 {
+		return page{}, false
+	}
+	return page{limit: p.limit, offset: max(p.offset-p.limit, 0)}, true
+}
+
+// last returns p, or the last page of a list of total tasks when p is
+// past its end.
+func (p page) last(total int) page {
+	if p.limit == 0 || p.offset < total || total == 0 {
+		return p
+	}
+	return page{limit: p.limit, offset: (total - 1) / p.limit * p.limit}
+}
+
+// url returns the URL of p in the list at path with params, which keep
+// the filters and order of the list.
+func (p page) url(path string, params url.Values) string {
+	params = maps.Clone(params)
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("limit", strconv.Itoa(p.limit))
+	params.Set("offset", strconv.Itoa(p.offset))
+	return (&url.URL{Path: path, RawQuery: params.Encode()}).String()
+}
+
+// header returns the pagination headers for a page of a list of total
+// tasks: X-Total-Count, and a Link to the next page when there is one.
+func (p page) header(r *http.Request, total int) http.Header {
+	header := http.Header{}
+	header.Set("X-Total-Count", strconv.Itoa(total))
+
+	if next, ok := p.next(total); ok {
+		header.Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.url(r.URL.Path, r.URL.Query())))
+	}
+	return header
+}
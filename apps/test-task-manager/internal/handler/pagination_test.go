@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestGetTasks_ListLimit(t *testing.T) {
+	taskService := service.NewTaskService(store.NewTaskStore())
+	for i := range 5 {
+		taskService.Create(t.Context(), "task "+strconv.Itoa(i), "", "", nil)
+	}
+	h := NewAPIHandler(taskService, errorreport.Nop{}, WithListLimit(2, 3), WithResponseCache(time.Minute, metrics.NewRegistry()))
+
+	tests := []struct {
+		target string
+		status int
+		ids    []string
+		next   string
+	}{
+		{target: "/api/tasks", status: 200, ids: []string{"1", "2"}, next: `</api/tasks?limit=2&offset=2>; rel="next"`},
+		{target: "/api/tasks?limit=2&offset=4", status: 200, ids: []string{"5"}},
+		{target: "/api/tasks?limit=3&offset=1", status: 200, ids: []string{"2", "3", "4"}, next: `</api/tasks?limit=3&offset=4>; rel="next"`},
+		{target: "/api/tasks?offset=10", status: 200, ids: []string{}},
+		{target: "/api/tasks?limit=4", status: 400},
+		{target: "/api/tasks?offset=-1", status: 400},
+	}
+	for _, tt := range tests {
+		// Twice, so cached responses are checked too.
+		for range 2 {
+			w := httptest.NewRecorder()
+			h.GetTasks(w, httptest.NewRequest("GET", tt.target, nil))
+			if w.Code != tt.status {
+				t.Fatalf("%s: expected status %d, got %d", tt.target, tt.status, w.Code)
+			}
+			if tt.status != 200 {
+				continue
+			}
+
+			var tasks []model.Task
+			if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]string, len(tasks))
+			for i, task := range tasks {
+				ids[i] = task.ID
+			}
+			if !slices.Equal(ids, tt.ids) {
+				t.Errorf("%s: expected tasks %v, got %v", tt.target, tt.ids, ids)
+			}
+			if total := w.Header().Get("X-Total-Count"); total != "5" {
+				t.Errorf("%s: expected X-Total-Count 5, got %q", tt.target, total)
+			}
+			if link := w.Header().Get("Link"); link != tt.next {
+				t.Errorf("%s: expected Link %q, got %q", tt.target, tt.next, link)
+			}
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+)
+
+// Themes of the HTML UI, which are Bootstrap color modes.
+const (
+	themeLight = "light"
+	themeDark  = "dark"
+)
+
+const (
+	// maxPageSize is the most tasks a page of the task list can show.
+	maxPageSize = 100
+	// maxPreferencesBodySize limits the bodies of preferences requests.
+	maxPreferencesBodySize = 4 << 10
+	// preferencesCookie keeps the preferences of browsers without a user.
+	preferencesCookie = "ttm_prefs"
+	// preferencesCookieAge is how long browsers keep the cookie, in seconds.
+	preferencesCookieAge = 365 * 24 * 60 * 60
+)
+
+// Preferences are the choices of how the HTML UI shows tasks.
+type Preferences struct {
+	XMLName  xml.Name `json:"-" xml:"preferences"`
+	Theme    string   `json:"theme" xml:"theme"`       // light or dark
+	Sort     string   `json:"sort" xml:"sort"`         // Order of the task list when the page asks for none
+	PageSize int      `json:"pageSize" xml:"pageSize"` // Tasks per page of the task list; 0 for the default of the server
+}
+
+// defaultPreferences are the choices made for users who made none.
+var defaultPreferences = Preferences{Theme: themeLight, Sort: sortCreated}
+
+// withDefaults returns p with the default of every choice not made.
+func (p Preferences) withDefaults() Preferences {
+	if p.Theme == "" {
+		p.Theme = defaultPreferences.Theme
+	}
+	if p.Sort == "" {
+		p.Sort = defaultPreferences.Sort
+	}
+	return p
+}
+
+func (p Preferences) validate() error {
+	switch p.Theme {
+	case "", themeLight, themeDark:
+	default:
+		return invalidf("theme must be light or dark")
+	}
+	if _, err := parseSort(url.Values{"sort": {p.Sort}}); err != nil {
+		return err
+	}
+	if p.PageSize < 0 || p.PageSize > maxPageSize {
+		return invalidf("pageSize must be between 0 (the default) and %d", maxPageSize)
+	}
+	return nil
+}
+
+// encode returns p as the value of the preferences cookie.
+func (p Preferences) encode() string {
+	return url.Values{
+		"theme":    {p.Theme},
+		"sort":     {p.Sort},
+		"pageSize": {strconv.Itoa(p.PageSize)},
+	}.Encode()
+}
+
+func decodePreferences(value string) (Preferences, error) {
+	params, err := url.ParseQuery(value)
+	if err != nil {
+		return Preferences{}, err
+	}
+	p := Preferences{Theme: params.Get("theme"), Sort: params.Get("sort")}
+	if v := params.Get("pageSize"); v != "" {
+		if p.PageSize, err = strconv.Atoi(v); err != nil {
+			return Preferences{}, err
+		}
+	}
+	return p.withDefaults(), p.validate()
+}
+
+// preferencesOf returns the preferences of the user of the request or,
+// without one, those kept in the cookie of the browser. Cookies that are
+// invalid, for instance because choices were dropped, count as none.
+func preferencesOf(r *http.Request) Preferences {
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		return Preferences{Theme: user.UI.Theme, Sort: user.UI.Sort, PageSize: user.UI.PageSize}.withDefaults()
+	}
+	if cookie, err := r.Cookie(preferencesCookie); err == nil {
+		if p, err := decodePreferences(cookie.Value); err == nil {
+			return p
+		}
+	}
+	return defaultPreferences
+}
+
+// PreferenceStore stores the HTML UI and notification preferences of
+// users.
+type PreferenceStore interface {
+	SetUIPreferences(ref string, prefs auth.UIPreferences) (auth.User, error)
+	SetNotificationPreferences(ref string, prefs auth.NotificationPreferences) (auth.User, error)
+}
+
+// PreferencesHandler reads and changes the preferences of the HTML UI:
+// those of the authenticated user, so they apply on every device the user
+// signs in on, or those of the browser, kept in a cookie, for requests
+// without a user. It also reads and changes the notification preferences
+// of authenticated users.
+type PreferencesHandler struct {
+	users    PreferenceStore
+	reporter errorreport.Reporter
+}
+
+// NewPreferencesHandler creates a new PreferencesHandler.
+func NewPreferencesHandler(users PreferenceStore, reporter errorreport.Reporter) *PreferencesHandler {
+	return &PreferencesHandler{users: users, reporter: reporter}
+}
+
+// GetPreferences returns the preferences, with the defaults of the choices
+// not made.
+func (h *PreferencesHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	respond(w, r, preferencesOf(r), http.StatusOK)
+}
+
+// UpdatePreferences replaces the preferences with those in the JSON (or
+// XML) request body. Choices left out are reset to their defaults.
+func (h *PreferencesHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	var prefs Preferences
+	r.Body = http.MaxBytesReader(w, r.Body, maxPreferencesBodySize)
+	decode := json.NewDecoder(r.Body).Decode
+	if isXML(r) {
+		decode = xml.NewDecoder(r.Body).Decode
+	}
+	if err := decode(&prefs); err != nil {
+		respondError(w, r, "Invalid request body", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+	if err := prefs.validate(); err != nil {
+		respondError(w, r, "Invalid preferences: %s", "INVALID_INPUT", http.StatusBadRequest, localize(localeOf(r), err))
+		return
+	}
+
+	prefs = prefs.withDefaults()
+	if err := h.save(w, r, prefs); err != nil {
+		h.reporter.CaptureError(r, err)
+		respondError(w, r, "Failed to store preferences", "INTERNAL_SERVER_ERROR", http.StatusInternalServerError)
+		return
+	}
+	respond(w, r, prefs, http.StatusOK)
+}
+
+// SavePreferences replaces the preferences with those of the submitted
+// form and redirects back to the page in its return field.
+func (h *PreferencesHandler) SavePreferences(w http.ResponseWriter, r *http.Request) {
+	prefs := Preferences{Theme: r.PostFormValue("theme"), Sort: r.PostFormValue("sort")}
+	if v := r.PostFormValue("pageSize"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "pageSize must be a number", http.StatusBadRequest)
+			return
+		}
+		prefs.PageSize = size
+	}
+	if err := prefs.validate(); err != nil {
+		http.Error(w, localize(localeOf(r), err), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.save(w, r, prefs.withDefaults()); err != nil {
+		h.reporter.CaptureError(r, err)
+		http.Error(w, "Failed to store preferences", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, localPath(r.PostFormValue("return")), http.StatusSeeOther)
+}
+
+// save stores prefs for the user of the request or, without one, in the
+// cookie of the browser.
+func (h *PreferencesHandler) save(w http.ResponseWriter, r *http.Request, prefs Preferences) error {
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		_, err := h.users.SetUIPreferences(user.ID, auth.UIPreferences{Theme: prefs.Theme, Sort: prefs.Sort, PageSize: prefs.PageSize})
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     preferencesCookie,
+		Value:    prefs.encode(),
+		Path:     "/",
+		MaxAge:   preferencesCookieAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// localPath returns path when it is one of this site, and / otherwise, so
+// forms cannot redirect to other sites.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+// NotificationPreferences are how the authenticated user hears about the
+// tasks they created, with the defaults of the choices not made.
+type NotificationPreferences struct {
+	Mode       string   `json:"mode"`                 // all, digest-only or mute
+	Channels   []string `json:"channels"`             // email, webhook and push
+	WebhookURL string   `json:"webhookUrl,omitempty"` // Where the webhook channel posts to
+}
+
+// notificationPreferencesOf returns the notification preferences of user.
+func notificationPreferencesOf(user auth.User) NotificationPreferences {
+	prefs := NotificationPreferences{Mode: user.Notifications.Mode, Channels: user.Notifications.Channels, WebhookURL: user.Notifications.WebhookURL}
+	if prefs.Mode == "" {
+		prefs.Mode = auth.NotifyAll
+	}
+	if prefs.Channels == nil {
+		prefs.Channels = []string{}
+	}
+	return prefs
+}
+
+// GetNotificationPreferences returns the notification preferences of the
+// authenticated user.
+func (h *PreferencesHandler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		respondError(w, r, "Sign in to manage notification preferences", "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+	respondJSON(w, notificationPreferencesOf(user), http.StatusOK)
+}
+
+// UpdateNotificationPreferences replaces the notification preferences of
+// the authenticated user with those in the JSON request body. Choices left
+// out are reset to their defaults. The email channel needs the user to
+// have an email address, which admins set.
+func (h *PreferencesHandler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		respondError(w, r, "Sign in to manage notification preferences", "UNAUTHORIZED", http.StatusUnauthorized)
+		return
+	}
+	var prefs NotificationPreferences
+	r.Body = http.MaxBytesReader(w, r.Body, maxPreferencesBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		respondError(w, r, "Invalid request body", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+	settings := auth.NotificationPreferences{Mode: prefs.Mode, Channels: prefs.Channels, WebhookURL: prefs.WebhookURL}
+	if err := settings.Validate(); err != nil {
+		respondError(w, r, "Invalid notification preferences: %s", "INVALID_INPUT", http.StatusBadRequest, err)
+		return
+	}
+	if slices.Contains(settings.Channels, auth.ChannelEmail) && user.Email == "" {
+		respondError(w, r, "The email channel needs an email address; ask an admin to set yours", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.users.SetNotificationPreferences(user.ID, settings)
+	if err != nil {
+		h.reporter.CaptureError(r, err)
+		respondError(w, r, "Failed to store preferences", "INTERNAL_SERVER_ERROR", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, notificationPreferencesOf(user), http.StatusOK)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/notify"
+)
+
+// PushHandler lets browsers subscribe to web push notifications.
+type PushHandler struct {
+	subs      *notify.Subscriptions
+	publicKey string // VAPID public key browsers subscribe with
+	reporter  errorreport.Reporter
+}
+
+// NewPushHandler creates a new PushHandler.
+func NewPushHandler(subs *notify.Subscriptions, publicKey string, reporter errorreport.Reporter) *PushHandler {
+	return &PushHandler{subs: subs, publicKey: publicKey, reporter: reporter}
+}
+
+// GetPublicKey returns the VAPID public key to pass to
+// PushManager.subscribe as applicationServerKey.
+func (h *PushHandler) GetPublicKey(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, map[string]string{"publicKey": h.publicKey}, http.StatusOK)
+}
+
+// Subscribe stores the JSON PushSubscription in the request body, for the
+// authenticated user, if any, who then gets the notifications chosen in
+// their notification preferences on it.
+func (h *PushHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	var sub notify.Subscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		respondError(w, r, "Invalid request body", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+	if err := sub.Validate(); err != nil {
+		respondError(w, r, "Invalid subscription: %s", "INVALID_INPUT", http.StatusBadRequest, err)
+		return
+	}
+	sub.UserID = userID(r)
+
+	if err := h.subs.Add(sub); err != nil {
+		h.reporter.CaptureError(r, err)
+		respondError(w, r, "Failed to store subscription", "INTERNAL_SERVER_ERROR", http.StatusInternalServerError)
+		return
+	}
+	respond(w, r, MessageResponse{Message: "Subscribed to notifications"}, http.StatusCreated)
+}
+
+// Unsubscribe removes the subscription whose endpoint is in the request body.
+func (h *PushHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Endpoint == "" {
+		respondError(w, r, "Invalid request body", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+
+	removed, err := h.subs.Remove(req.Endpoint)
+	if err != nil {
+		h.reporter.CaptureError(r, err)
+		respondError(w, r, "Failed to remove subscription", "INTERNAL_SERVER_ERROR", http.StatusInternalServerError)
+		return
+	}
+	if !removed {
+		respondError(w, r, "Subscription not found", "NOT_FOUND", http.StatusNotFound)
+		return
+	}
+	respond(w, r, MessageResponse{Message: "Unsubscribed from notifications"}, http.StatusOK)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// The task lists of the API and the task list page take the same query
+// parameters, read by the functions below, so both show the same tasks.
+
+// parseTaskQuery reads the task filters from query parameters. stale=true
+// selects the open tasks last changed before staleCutoff.
+func parseTaskQuery(params url.Values, staleCutoff time.Time) (store.Query, error) {
+	q := store.Query{Priority: params.Get("priority")}
+
+	switch status := params.Get("status"); status {
+	case "":
+	case "open", "completed":
+		completed := status == "completed"
+		q.Completed = &completed
+	default:
+		return store.Query{}, invalidf("status must be open or completed")
+	}
+
+	for name, bound := range map[string]**time.Time{"dueAfter": &q.DueAfter, "dueBefore": &q.DueBefore, "createdBefore": &q.CreatedBefore} {
+		if v := params.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return store.Query{}, invalidf("%s must be an RFC 3339 timestamp", name)
+			}
+			*bound = &t
+		}
+	}
+
+	switch params.Get("stale") {
+	case "":
+	case "true":
+		if q.Completed != nil && *q.Completed {
+			return store.Query{}, invalidf("stale tasks are open, so stale cannot be combined with status=completed")
+		}
+		open := false
+		q.Completed, q.ChangedBefore = &open, &staleCutoff
+	default:
+		return store.Query{}, invalidf("stale must be true")
+	}
+	return q, nil
+}
+
+// Orders of task lists.
+const (
+	sortCreated  = "created"  // Oldest first
+	sortDue      = "due"      // Soonest due first, tasks without due date last
+	sortPriority = "priority" // In the order of service.Priorities
+	sortTitle    = "title"    // Alphabetically, ignoring case
+)
+
+// parseSort reads the order of the task list from the sort query
+// parameter, which defaults to creation order.
+func parseSort(params url.Values) (string, error) {
+	switch order := params.Get("sort"); order {
+	case "":
+		return sortCreated, nil
+	case sortCreated, sortDue, sortPriority, sortTitle:
+		return order, nil
+	default:
+		return "", invalidf("sort must be created, due, priority or title")
+	}
+}
+
+// sortTasks orders tasks, listed in creation order, by order. Tasks that
+// compare equal stay in creation order.
+func sortTasks(tasks []model.Task, order string) {
+	var compare func(a, b model.Task) int
+	switch order {
+	case sortDue:
+		compare = func(a, b model.Task) int {
+			switch {
+			case a.DueDate == nil || b.DueDate == nil:
+				return cmp.Compare(dueRank(a), dueRank(b))
+			default:
+				return a.DueDate.Compare(*b.DueDate)
+			}
+		}
+	case sortPriority:
+		compare = func(a, b model.Task) int {
+			return cmp.Compare(slices.Index(service.Priorities, a.Priority), slices.Index(service.Priorities, b.Priority))
+		}
+	case sortTitle:
+		compare = func(a, b model.Task) int {
+			return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		}
+	default:
+		return
+	}
+	slices.SortStableFunc(tasks, compare)
+}
+
+// dueRank puts tasks without due date after those with one.
+func dueRank(task model.Task) int {
+	if task.DueDate == nil {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+func TestSortTasks(t *testing.T) {
+	day := func(d int) *time.Time {
+		due := time.Date(2026, 3, d, 17, 0, 0, 0, time.UTC)
+		return &due
+	}
+	tasks := []model.Task{
+		{ID: "1", Title: "water plants", Priority: "💡", DueDate: day(3)},
+		{ID: "2", Title: "Book flights", Priority: "🔥"},
+		{ID: "3", Title: "Call plumber", Priority: "💡", DueDate: day(1)},
+		{ID: "4", Title: "answer mail", Priority: "⭐"},
+	}
+
+	tests := map[string][]string{
+		"":         {"1", "2", "3", "4"},
+		"created":  {"1", "2", "3", "4"},
+		"due":      {"3", "1", "2", "4"},
+		"priority": {"2", "4", "1", "3"},
+		"title":    {"4", "2", "3", "1"},
+	}
+	for order, want := range tests {
+		parsed, err := parseSort(url.Values{"sort": {order}})
+		if err != nil {
+			t.Fatalf("sort=%s: %v", order, err)
+		}
+		sorted := slices.Clone(tasks)
+		sortTasks(sorted, parsed)
+		var ids []string
+		for _, task := range sorted {
+			ids = append(ids, task.ID)
+		}
+		if !slices.Equal(ids, want) {
+			t.Errorf("sort=%s: expected %v, got %v", order, want, ids)
+		}
+	}
+
+	if _, err := parseSort(url.Values{"sort": {"size"}}); err == nil {
+		t.Error("expected an unknown order to be rejected")
+	}
+}
@@ -1,31 +1,234 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
+	"encoding/xml"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/i18n"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/palette"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/schema"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/timing"
+)
+
+const (
+	// streamThreshold is the number of tasks above which lists are streamed.
+	streamThreshold = 500
+	// streamChunkSize is the amount of encoded data written per flush.
+	streamChunkSize = 32 << 10
+	// maxPooledBuffer keeps buffers grown by unusually large responses out
+	// of the pool.
+	maxPooledBuffer = 1 << 20
 )
 
+// buffers holds response encoding buffers for reuse across requests.
+var buffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	buffers.Put(buf)
+}
+
 // ErrorResponse represents a JSON error response.
 type ErrorResponse struct {
-	Error string `json:"error"`
-	Code  string `json:"code"`
+	XMLName xml.Name `json:"-" xml:"error"`
+	Error   string   `json:"error" xml:"message"`
+	Code    string   `json:"code" xml:"code"`
+	// Usage is the usage of the quota USER_QUOTA_EXCEEDED answers.
+	Usage *UserUsage `json:"usage,omitempty" xml:"usage,omitempty"`
+	// Fields are the field errors of request bodies not matching their
+	// schema.
+	Fields []schema.FieldError `json:"fields,omitempty" xml:"field,omitempty"`
 }
 
 // MessageResponse represents a success message response.
 type MessageResponse struct {
-	Message string `json:"message"`
+	XMLName xml.Name `json:"-" xml:"response"`
+	Message string   `json:"message" xml:"message"`
+}
+
+// taskResponse is a task as the API returns it, with the name of its color
+// in the palette and the text color readable on it. Tasks of colors since
+// dropped from the palette have no color name.
+type taskResponse struct {
+	model.Task
+	ColorName string `json:"colorName,omitempty" xml:"colorName,omitempty"`
+	TextColor string `json:"textColor" xml:"textColor"`
+}
+
+// colored returns task as the API returns it, with colors of p.
+func colored(p *palette.Palette, task model.Task) taskResponse {
+	color := p.Resolve(task.Color)
+	return taskResponse{Task: task, ColorName: color.Name, TextColor: color.LightText}
+}
+
+// coloredAll returns tasks as the API returns them, with colors of p.
+func coloredAll(p *palette.Palette, tasks []model.Task) []taskResponse {
+	list := make([]taskResponse, len(tasks))
+	for i, task := range tasks {
+		list[i] = colored(p, task)
+	}
+	return list
+}
+
+// taskList is a list of tasks that marshals to a JSON array or a <tasks> XML element.
+type taskList []listedTask
+
+// listedTask is a task in a task list, with the days since it was created
+// and whether it is stale: open and not changed for the stale period.
+type listedTask struct {
+	taskResponse
+	AgeDays int  `json:"ageDays" xml:"ageDays"`
+	Stale   bool `json:"stale" xml:"stale"`
+}
+
+// MarshalXML implements xml.Marshaler.
+func (l taskList) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return e.Encode(struct {
+		XMLName xml.Name     `xml:"tasks"`
+		Tasks   []listedTask `xml:"task"`
+	}{Tasks: l})
+}
+
+// inService runs call, the service calls made for r, as the "service"
+// phase of the timing breakdown of r.
+func inService(r *http.Request, call func()) {
+	defer timing.Track(r.Context(), "service")()
+	call()
 }
 
-// respondError sends a JSON error response.
-func respondError(w http.ResponseWriter, message, code string, status int) {
+// respondError sends an error response in the format negotiated from the
+// Accept header. The English message is translated into the language of
+// the Accept-Language header, with args formatted into it as by i18n.T.
+func respondError(w http.ResponseWriter, r *http.Request, message, code string, status int, args ...any) {
+	setLanguage(w, r)
+	respond(w, r, ErrorResponse{Error: i18n.T(localeOf(r), message, args...), Code: code}, status)
+}
+
+// setLanguage marks the response as in the locale of the request.
+func setLanguage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Language", string(localeOf(r)))
+	w.Header().Add("Vary", "Accept-Language")
+}
+
+// respond sends data as XML when the client prefers it, and as JSON otherwise.
+func respond(w http.ResponseWriter, r *http.Request, data interface{}, status int) {
+	w.Header().Add("Vary", "Accept")
+	if prefersXML(r) {
+		respondXML(w, data, status)
+		return
+	}
+	respondJSON(w, data, status)
+}
+
+// respondJSON sends a JSON response. The body is encoded into a pooled
+// buffer first, so it has a Content-Length and encoding errors still result
+// in a 500. Large task lists are streamed instead.
+func respondJSON(w http.ResponseWriter, data interface{}, status int) {
+	if tasks, ok := data.(taskList); ok && len(tasks) > streamThreshold {
+		streamTaskList(w, tasks, status)
+		return
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := json.NewEncoder(buf).Encode(data); err != nil {
+		http.Error(w, `{"error":"Failed to encode response","code":"INTERNAL_SERVER_ERROR"}`, http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Code: code})
+	w.Write(buf.Bytes())
 }
 
-// respondJSON sends a JSON response.
-func respondJSON(w http.ResponseWriter, data interface{}, status int) {
+// streamTaskList writes tasks as a JSON array in chunks of about
+// streamChunkSize bytes, flushing after each, so the response starts before
+// the whole list is encoded and never sits in memory as a whole. The
+// response uses chunked transfer encoding.
+func streamTaskList(w http.ResponseWriter, tasks taskList, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+
+	rc := http.NewResponseController(w)
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	encoder := json.NewEncoder(buf)
+	buf.WriteByte('[')
+	for i, task := range tasks {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		// Tasks always encode; Encode appends a newline, which is valid
+		// whitespace inside the array.
+		encoder.Encode(task)
+
+		if buf.Len() >= streamChunkSize {
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return // The client went away
+			}
+			rc.Flush()
+			buf.Reset()
+		}
+	}
+	buf.WriteString("]\n")
+	w.Write(buf.Bytes())
+}
+
+// respondXML sends an XML response.
+func respondXML(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(data)
+}
+
+// prefersXML reports whether the Accept header ranks XML above JSON.
+// JSON wins ties, wildcards and a missing header.
+func prefersXML(r *http.Request) bool {
+	var jsonQ, xmlQ float64
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case "application/xml", "text/xml":
+			xmlQ = max(xmlQ, q)
+		case "application/json", "application/*", "*/*":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return xmlQ > jsonQ
+}
+
+// isXML reports whether the request body is XML.
+func isXML(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/xml" || mediaType == "text/xml"
 }
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestRespondJSON_StreamsLargeLists(t *testing.T) {
+	for _, n := range []int{0, 3, streamThreshold + 1, 5000} {
+		tasks := make(taskList, n)
+		for i := range tasks {
+			tasks[i] = listedTask{taskResponse: taskResponse{Task: model.Task{ID: strconv.Itoa(i + 1), Title: "task " + strconv.Itoa(i), Priority: "📋", Color: "#6c757d"}}}
+		}
+
+		w := httptest.NewRecorder()
+		respondJSON(w, tasks, 200)
+
+		var got []model.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%d tasks: invalid JSON: %v", n, err)
+		}
+		if len(got) != n || (n > 0 && got[n-1].ID != strconv.Itoa(n)) {
+			t.Errorf("%d tasks: decoded %d", n, len(got))
+		}
+
+		streamed := n > streamThreshold
+		if hasLength := w.Header().Get("Content-Length") != ""; hasLength == streamed {
+			t.Errorf("%d tasks: Content-Length set = %t, want %t", n, hasLength, !streamed)
+		}
+	}
+}
+
+func TestPrefersXML(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"application/xml", true},
+		{"text/xml", true},
+		{"*/*", false},
+		{"application/*", false},
+		{"application/xml, application/json", false},      // Ties go to JSON
+		{"application/xml, */*", false},                   // Wildcards too
+		{"application/xml, application/json;q=0.9", true}, // Higher q wins
+		{"application/json;q=0.5, text/xml;q=0.8", true},
+		{"application/xml;q=0.5, */*;q=0.8", false},
+		{"application/xml;q=abc, application/json;q=0.1", false}, // Invalid q ignored
+		{"text/html, application/xhtml+xml", false},
+		{"not a media type;;, application/xml", true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/tasks", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		if got := prefersXML(r); got != tt.want {
+			t.Errorf("Accept %q: expected %t, got %t", tt.accept, tt.want, got)
+		}
+	}
+}
+
+func TestIsXML(t *testing.T) {
+	for contentType, want := range map[string]bool{
+		"application/xml":                 true,
+		"text/xml; charset=utf-8":         true,
+		"application/json":                false,
+		"":                                false,
+		"application/xml-patch+xml":       false,
+		"application/json; charset=utf-8": false,
+	} {
+		r := httptest.NewRequest("POST", "/api/tasks", nil)
+		r.Header.Set("Content-Type", contentType)
+		if got := isXML(r); got != want {
+			t.Errorf("Content-Type %q: expected %t, got %t", contentType, want, got)
+		}
+	}
+}
+
+func TestTaskList_MarshalXML(t *testing.T) {
+	tasks := taskList{
+		{taskResponse: taskResponse{Task: model.Task{ID: "1", Title: "Ship <release>", Priority: "🔥"}}, AgeDays: 2},
+		{taskResponse: taskResponse{Task: model.Task{ID: "2", Title: "Water plants"}}, Stale: true},
+	}
+	w := httptest.NewRecorder()
+	respondXML(w, tasks, 200)
+
+	var got struct {
+		XMLName xml.Name `xml:"tasks"`
+		Tasks   []struct {
+			ID      string `xml:"id"`
+			Title   string `xml:"title"`
+			AgeDays int    `xml:"ageDays"`
+			Stale   bool   `xml:"stale"`
+		} `xml:"task"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid XML: %v: %s", err, w.Body)
+	}
+	if len(got.Tasks) != 2 || got.Tasks[0].Title != "Ship <release>" || got.Tasks[0].AgeDays != 2 || !got.Tasks[1].Stale {
+		t.Errorf("unexpected tasks %+v", got.Tasks)
+	}
+	if !strings.HasPrefix(w.Body.String(), xml.Header) {
+		t.Errorf("expected the XML declaration, got %s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	respondXML(w, taskList{}, 200)
+	if !strings.Contains(w.Body.String(), "<tasks></tasks>") {
+		t.Errorf("expected an empty <tasks> element, got %s", w.Body)
+	}
+}
+
+func TestAPIHandler_XMLRoundTrip(t *testing.T) {
+	taskService := service.NewTaskService(store.NewTaskStore())
+	h := NewAPIHandler(taskService, errorreport.Nop{})
+	xmlRequest := func(method, target, body string) *http.Request {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/xml")
+		r.Header.Set("Accept", "application/xml, application/json;q=0.5")
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	h.CreateTask(w, xmlRequest("POST", "/api/tasks", `<task><title>Ship release</title><priority>🔥</priority></task>`))
+	var created model.Task
+	if err := xml.Unmarshal(w.Body.Bytes(), &created); w.Code != http.StatusCreated || err != nil {
+		t.Fatalf("expected the created task as XML, got %d (%v): %s", w.Code, err, w.Body)
+	}
+	if created.Title != "Ship release" || created.Priority != "🔥" || created.ID == "" {
+		t.Errorf("unexpected task %+v", created)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/xml; charset=utf-8" {
+		t.Errorf("expected an XML content type, got %q", ct)
+	}
+
+	w = httptest.NewRecorder()
+	h.GetTasks(w, xmlRequest("GET", "/api/tasks", ""))
+	var list struct {
+		Tasks []model.Task `xml:"task"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Tasks) != 1 || list.Tasks[0].ID != created.ID {
+		t.Errorf("expected the task in a <tasks> list, got %v: %s", err, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.GetStats(w, xmlRequest("GET", "/api/stats", ""))
+	var stats StatsResponse
+	if err := xml.Unmarshal(w.Body.Bytes(), &stats); err != nil || stats.Created != 1 || stats.Open != 1 {
+		t.Errorf("expected the stats as XML, got %v: %s", err, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.CreateTask(w, xmlRequest("POST", "/api/tasks", `<task><title>`))
+	var e ErrorResponse
+	if err := xml.Unmarshal(w.Body.Bytes(), &e); w.Code != http.StatusBadRequest || err != nil || e.Code != "INVALID_INPUT" {
+		t.Errorf("expected an XML error for the malformed body, got %d (%v): %s", w.Code, err, w.Body)
+	}
+}
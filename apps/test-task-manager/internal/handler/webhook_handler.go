@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/events"
+)
+
+// WebhookHandler shows the deliveries of task events to webhooks that
+// failed and redelivers them.
+type WebhookHandler struct {
+	dispatcher *events.Dispatcher
+}
+
+// NewWebhookHandler creates a new WebhookHandler.
+func NewWebhookHandler(dispatcher *events.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{dispatcher: dispatcher}
+}
+
+// DeliveryList holds the failed deliveries to a webhook.
+type DeliveryList struct {
+	Webhook    events.WebhookInfo `json:"webhook"`
+	Deliveries []events.Delivery  `json:"deliveries"`
+}
+
+// ListWebhooks returns the webhooks task events are delivered to.
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, map[string][]events.WebhookInfo{"webhooks": h.dispatcher.Webhooks()}, http.StatusOK)
+}
+
+// ListDeliveries returns the retrying and dead-lettered deliveries to the
+// webhook of the URL, only those with the status of the status query
+// parameter when given.
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	status := r.URL.Query().Get("status")
+	if status != "" && status != events.DeliveryRetrying && status != events.DeliveryDead {
+		respondError(w, r, "status must be retrying or dead", "INVALID_INPUT", http.StatusBadRequest)
+		return
+	}
+	deliveries, err := h.dispatcher.Deliveries(id)
+	if err != nil {
+		respondError(w, r, "Webhook not found", "NOT_FOUND", http.StatusNotFound)
+		return
+	}
+
+	list := DeliveryList{Deliveries: make([]events.Delivery, 0, len(deliveries))}
+	for _, info := range h.dispatcher.Webhooks() {
+		if info.ID == id {
+			list.Webhook = info
+		}
+	}
+	for _, d := range deliveries {
+		if status == "" || d.Status == status {
+			list.Deliveries = append(list.Deliveries, d)
+		}
+	}
+	respondJSON(w, list, http.StatusOK)
+}
+
+// Redeliver delivers the retrying or dead-lettered event of the URL to its
+// webhook right away. A failing webhook is answered with 502
+// REDELIVERY_FAILED, and the delivery stays as it was.
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	seq, err := strconv.ParseInt(mux.Vars(r)["seq"], 10, 64)
+	if err != nil {
+		respondError(w, r, "Delivery not found", "NOT_FOUND", http.StatusNotFound)
+		return
+	}
+
+	delivery, err := h.dispatcher.Redeliver(r.Context(), mux.Vars(r)["id"], seq)
+	switch {
+	case errors.Is(err, events.ErrWebhookNotFound):
+		respondError(w, r, "Webhook not found", "NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, events.ErrDeliveryNotFound):
+		respondError(w, r, "Delivery not found", "NOT_FOUND", http.StatusNotFound)
+	case err != nil:
+		respondError(w, r, "The webhook failed again: %s", "REDELIVERY_FAILED", http.StatusBadGateway, strings.TrimPrefix(err.Error(), events.ErrRedeliveryFailed.Error()+": "))
+	default:
+		respondJSON(w, delivery, http.StatusOK)
+	}
+}
@@ -0,0 +1,175 @@
+// Package health aggregates the health of the application's components.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// StatusUp means the component (or application) is fully functional.
+	StatusUp = "up"
+	// StatusDegraded means a non-critical component is down.
+	StatusDegraded = "degraded"
+	// StatusDown means the component, or a critical component, is down.
+	StatusDown = "down"
+)
+
+// DefaultTimeout bounds how long a single component check may take.
+const DefaultTimeout = 2 * time.Second
+
+// ErrTimeout is reported when a check does not finish within the timeout.
+var ErrTimeout = errors.New("health check timed out")
+
+// Check reports whether a component is healthy by returning nil.
+type Check func(ctx context.Context) error
+
+// ComponentStatus is the result of checking a single component.
+type ComponentStatus struct {
+	Status      string     `json:"status"`
+	Critical    bool       `json:"critical"`
+	LatencyMs   float64    `json:"latencyMs"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// Report is the aggregated result of checking all components.
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// Healthy reports whether all critical components are up.
+func (r Report) Healthy() bool {
+	return r.Status != StatusDown
+}
+
+// Outcome records the result of the latest run of a background worker.
+// Its Check reports the worker down while the last run failed. The zero
+// value is up.
+type Outcome struct {
+	mu  sync.Mutex
+	err error
+}
+
+// Record sets the result of a run.
+func (o *Outcome) Record(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.err = err
+}
+
+// Check is a Check returning the error of the last run.
+func (o *Outcome) Check(context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.err
+}
+
+// component is a registered check together with its last failure.
+type component struct {
+	name     string
+	critical bool
+	check    Check
+
+	mu          sync.Mutex
+	lastError   string
+	lastErrorAt *time.Time
+}
+
+// Registry holds the registered component checks.
+type Registry struct {
+	mu         sync.RWMutex
+	components []*component
+	timeout    time.Duration
+}
+
+// NewRegistry creates a new Registry using DefaultTimeout per check.
+func NewRegistry() *Registry {
+	return &Registry{timeout: DefaultTimeout}
+}
+
+// Register adds a component check. When a critical component is down,
+// the overall status is down; otherwise it is degraded.
+func (r *Registry) Register(name string, critical bool, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.components = append(r.components, &component{name: name, critical: critical, check: check})
+}
+
+// Run checks all components concurrently and aggregates the result.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	components := append([]*component(nil), r.components...)
+	r.mu.RUnlock()
+
+	statuses := make([]ComponentStatus, len(components))
+
+	var wg sync.WaitGroup
+	for i, c := range components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = r.runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Components: make(map[string]ComponentStatus, len(components))}
+	for i, c := range components {
+		report.Components[c.name] = statuses[i]
+
+		if statuses[i].Status == StatusUp {
+			continue
+		}
+		if c.critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// runCheck executes a single check with the registry timeout.
+func (r *Registry) runCheck(ctx context.Context, c *component) ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrTimeout
+	}
+	latency := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := ComponentStatus{
+		Status:    StatusUp,
+		Critical:  c.critical,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
+	if err != nil {
+		now := time.Now()
+		c.lastError = err.Error()
+		c.lastErrorAt = &now
+		status.Status = StatusDown
+	}
+	status.LastError = c.lastError
+	status.LastErrorAt = c.lastErrorAt
+
+	return status
+}
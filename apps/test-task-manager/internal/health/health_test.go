@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRegistry_Run(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("unreachable") }
+
+	tests := []struct {
+		name     string
+		register func(r *Registry)
+		want     string
+	}{
+		{"no components", func(r *Registry) {}, StatusUp},
+		{"all up", func(r *Registry) {
+			r.Register("store", true, up)
+			r.Register("scheduler", false, up)
+		}, StatusUp},
+		{"non-critical down", func(r *Registry) {
+			r.Register("store", true, up)
+			r.Register("scheduler", false, down)
+		}, StatusDegraded},
+		{"critical down", func(r *Registry) {
+			r.Register("store", true, down)
+			r.Register("scheduler", false, up)
+		}, StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			tt.register(r)
+
+			report := r.Run(context.Background())
+			if report.Status != tt.want {
+				t.Errorf("expected status %s, got %s", tt.want, report.Status)
+			}
+		})
+	}
+}
+
+func TestRegistry_RunKeepsLastError(t *testing.T) {
+	r := NewRegistry()
+	fail := true
+	r.Register("store", true, func(ctx context.Context) error {
+		if fail {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	r.Run(context.Background())
+	fail = false
+	report := r.Run(context.Background())
+
+	status := report.Components["store"]
+	if status.Status != StatusUp {
+		t.Errorf("expected store to be up, got %s", status.Status)
+	}
+	if status.LastError != "connection refused" || status.LastErrorAt == nil {
+		t.Errorf("expected last error to be kept, got %q", status.LastError)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	r := NewRegistry()
+	var outcome Outcome
+	r.Register("scheduler", false, outcome.Check)
+
+	if report := r.Run(context.Background()); report.Status != StatusUp {
+		t.Errorf("expected a worker that did not run yet to be up, got %s", report.Status)
+	}
+	outcome.Record(errors.New("store unavailable"))
+	report := r.Run(context.Background())
+	if status := report.Components["scheduler"]; status.Status != StatusDown || status.LastError != "store unavailable" {
+		t.Errorf("expected the failed run to be reported, got %+v", status)
+	}
+	outcome.Record(nil)
+	if report := r.Run(context.Background()); report.Components["scheduler"].Status != StatusUp {
+		t.Errorf("expected a successful run to be up again, got %+v", report.Components["scheduler"])
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/apperr"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// maxBundleSize limits the workspace bundles that may be imported.
+const maxBundleSize = 256 << 20
+
+type bundleImportResponse struct {
+	Workspace string            `json:"workspace"`
+	Imported  int               `json:"imported"`
+	IDs       map[string]string `json:"ids"` // IDs of the bundle to those of the imported tasks
+}
+
+// ExportBundleHandler downloads the tasks of the workspace named by the
+// workspace parameter, the default one when empty, as a bundle that
+// ImportBundleHandler imports with their IDs, timestamps and history.
+func ExportBundleHandler(provider loggerProvider, workspaces []WorkspaceSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws, ok := workspaceOf(r, workspaces)
+		if !ok {
+			errorHandler(fmt.Errorf("workspace %q is not served", ws.Name), http.StatusNotFound, w, provider.Logger())
+			return
+		}
+		bundle, err := ws.Service.ExportBundle(r.Context())
+		if err != nil {
+			errorHandler(err, http.StatusInternalServerError, w, provider.Logger())
+			return
+		}
+		bundle.Workspace = ws.Name
+
+		provider.Logger().Infow("workspace exported", "workspace", ws.Name, "tasks", len(bundle.Tasks))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ttm.json"`, ws.Name))
+		writeJSON(w, http.StatusOK, bundle)
+	}
+}
+
+// ImportBundleHandler adds the tasks of a bundle written by
+// ExportBundleHandler to the workspace named by the workspace parameter, the
+// default one when empty, all or none of them.
+func ImportBundleHandler(provider loggerProvider, workspaces []WorkspaceSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws, ok := workspaceOf(r, workspaces)
+		if !ok {
+			errorHandler(fmt.Errorf("workspace %q is not served", ws.Name), http.StatusNotFound, w, provider.Logger())
+			return
+		}
+
+		var bundle service.Bundle
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBundleSize)).Decode(&bundle); err != nil {
+			errorHandler(fmt.Errorf("invalid bundle: %w", err), http.StatusBadRequest, w, provider.Logger())
+			return
+		}
+		ids, err := ws.Service.ImportBundle(r.Context(), bundle)
+		if err != nil {
+			errorHandler(err, importStatus(err), w, provider.Logger())
+			return
+		}
+
+		provider.Logger().Warnw("workspace imported", "workspace", ws.Name, "from", bundle.Workspace, "tasks", len(ids))
+		writeJSON(w, http.StatusCreated, bundleImportResponse{Workspace: ws.Name, Imported: len(ids), IDs: ids})
+	}
+}
+
+// workspaceOf returns the served workspace named by the workspace parameter
+// of r, or the default one, and whether it is served.
+func workspaceOf(r *http.Request, workspaces []WorkspaceSource) (WorkspaceSource, bool) {
+	name := cmp.Or(r.URL.Query().Get("workspace"), store.DefaultWorkspace)
+	for _, ws := range workspaces {
+		if ws.Name == name {
+			return ws, true
+		}
+	}
+	return WorkspaceSource{Name: name}, false
+}
+
+// importStatus returns the status of the error importing a bundle failed
+// with.
+func importStatus(err error) int {
+	if errors.Is(err, service.ErrUnsupportedBundle) {
+		return http.StatusBadRequest
+	}
+	switch apperr.CodeOf(err) {
+	case apperr.Internal:
+		return http.StatusInternalServerError
+	case apperr.StoreUnavailable, apperr.StoreTimeout, apperr.StoreReadOnly:
+		return http.StatusServiceUnavailable
+	case apperr.StoreFull, apperr.QuotaExceeded:
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest // A task of the bundle is invalid
+	}
+}
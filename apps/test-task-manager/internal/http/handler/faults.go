@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/faults"
+	"go.uber.org/zap"
+)
+
+type faultsProvider interface {
+	Logger() *zap.SugaredLogger
+	Faults() *faults.Injector
+}
+
+// FaultsHandler reads (GET) or replaces (PUT) the fault injection settings.
+func FaultsHandler(provider faultsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var in faults.Settings
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				errorHandler(err, http.StatusBadRequest, w, provider.Logger())
+				return
+			}
+			in.Latency = time.Duration(in.LatencyMs) * time.Millisecond
+
+			if err := provider.Faults().Update(in); err != nil {
+				errorHandler(err, http.StatusBadRequest, w, provider.Logger())
+				return
+			}
+			provider.Logger().Warnw("fault injection settings changed",
+				"latencyMs", in.LatencyMs,
+				"errorRate", in.ErrorRate,
+				"targets", in.Targets,
+			)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(provider.Faults().Settings())
+	}
+}
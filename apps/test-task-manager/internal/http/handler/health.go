@@ -5,52 +5,59 @@ import (
 	"net/http"
 
 	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/health"
 )
 
 type configProvider interface {
 	Config() app.Configuration
+	Health() *health.Registry
 }
 
-// HealthHandler returns a 200 OK status code.
+// HealthHandler reports the status of every registered component.
+// It returns 200 OK when all critical components are up (possibly degraded),
+// and 503 Service Unavailable when a critical component is down.
 func HealthHandler(provider configProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		type output struct {
 			Environment string `json:"environment"`
+			health.Report
 		}
 
 		o := output{
 			Environment: string(provider.Config().Environment),
+			Report:      provider.Health().Run(r.Context()),
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+		if !o.Healthy() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
 
 		json.NewEncoder(w).Encode(o)
 	}
 }
 
-// ReadinessHandler returns a 200 OK status code if the database connection is alive.
-// Otherwise, it returns a 503 Service Unavailable status code.
-func ReadinessHandler(dbConn interface {
-	IsAlive() bool
-}) http.HandlerFunc {
+// ReadinessHandler reports whether the application can serve traffic: 200 OK
+// when all critical components are up, and 503 Service Unavailable otherwise.
+// Unlike HealthHandler it only returns the overall status, which keeps it
+// cheap to poll from container health checks and readiness probes.
+func ReadinessHandler(provider configProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		type output struct {
-			DatabaseHealthy bool `json:"databaseHealthy"`
+			Status string `json:"status"`
 		}
 
-		o := output{
-			DatabaseHealthy: dbConn != nil && dbConn.IsAlive(),
-		}
+		report := provider.Health().Run(r.Context())
 
 		w.Header().Set("Content-Type", "application/json")
-		defer json.NewEncoder(w).Encode(o)
-
-		if !o.DatabaseHealthy {
+		if !report.Healthy() {
 			w.WriteHeader(http.StatusServiceUnavailable)
-			return
+		} else {
+			w.WriteHeader(http.StatusOK)
 		}
 
-		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(output{Status: report.Status})
 	}
 }
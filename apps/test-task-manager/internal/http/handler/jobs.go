@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/jobs"
+	"go.uber.org/zap"
+)
+
+type jobsProvider interface {
+	Logger() *zap.SugaredLogger
+	Jobs() *jobs.Queue
+}
+
+type jobsResponse struct {
+	Queued      int        `json:"queued"`
+	DeadLetters []jobs.Job `json:"deadLetters"`
+}
+
+// JobsHandler returns the number of queued background jobs and the
+// dead-lettered ones.
+func JobsHandler(provider jobsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dead, err := provider.Jobs().DeadLetters()
+		if err != nil {
+			errorHandler(err, http.StatusInternalServerError, w, provider.Logger())
+			return
+		}
+		writeJSON(w, http.StatusOK, jobsResponse{Queued: provider.Jobs().Len(), DeadLetters: dead})
+	}
+}
+
+// RetryJobHandler moves the dead-lettered job {id} back into the queue.
+func RetryJobHandler(provider jobsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := provider.Jobs().Retry(mux.Vars(r)["id"])
+		if err != nil {
+			status := http.StatusServiceUnavailable
+			if errors.Is(err, jobs.ErrJobNotFound) {
+				status = http.StatusNotFound
+			}
+			errorHandler(err, status, w, provider.Logger())
+			return
+		}
+		provider.Logger().Infow("dead-lettered job retried", "job", job.ID, "type", job.Type)
+		writeJSON(w, http.StatusAccepted, job)
+	}
+}
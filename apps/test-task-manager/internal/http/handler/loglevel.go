@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type logLevelProvider interface {
+	Logger() *zap.SugaredLogger
+	LogLevel() string
+	SetLogLevel(level string) error
+}
+
+type logLevelPayload struct {
+	Level string `json:"level"`
+}
+
+// LogLevelHandler reads (GET) or changes (PUT) the log level at runtime.
+func LogLevelHandler(provider logLevelProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var in logLevelPayload
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				errorHandler(err, http.StatusBadRequest, w, provider.Logger())
+				return
+			}
+
+			if err := provider.SetLogLevel(in.Level); err != nil {
+				errorHandler(err, http.StatusBadRequest, w, provider.Logger())
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(logLevelPayload{Level: provider.LogLevel()})
+	}
+}
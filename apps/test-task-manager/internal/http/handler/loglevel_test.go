@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type fakeLogLevels struct {
+	level string
+}
+
+func (f *fakeLogLevels) Logger() *zap.SugaredLogger { return zap.NewNop().Sugar() }
+
+func (f *fakeLogLevels) LogLevel() string { return f.level }
+
+func (f *fakeLogLevels) SetLogLevel(level string) error {
+	if level != "debug" && level != "info" {
+		return fmt.Errorf("invalid log level: %s", level)
+	}
+	f.level = level
+	return nil
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	levels := &fakeLogLevels{level: "info"}
+	handler := LogLevelHandler(levels)
+
+	tests := []struct {
+		method, body string
+		status       int
+		level        string
+	}{
+		{http.MethodGet, "", http.StatusOK, "info"},
+		{http.MethodPut, `{"level":"debug"}`, http.StatusOK, "debug"},
+		{http.MethodPut, `{"level":"loud"}`, http.StatusBadRequest, "debug"},
+		{http.MethodPut, `{"level":`, http.StatusBadRequest, "debug"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(tt.method, "/admin/loglevel", strings.NewReader(tt.body)))
+
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d: %s", tt.method, tt.body, tt.status, rec.Code, rec.Body)
+		}
+		if levels.level != tt.level {
+			t.Errorf("%s %s: expected level %s, got %s", tt.method, tt.body, tt.level, levels.level)
+		}
+		if tt.status == http.StatusOK {
+			var got logLevelPayload
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || got.Level != tt.level {
+				t.Errorf("%s %s: expected the level %s in the response, got %+v (%v)", tt.method, tt.body, tt.level, got, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/maintenance"
+	"go.uber.org/zap"
+)
+
+type maintenanceProvider interface {
+	Logger() *zap.SugaredLogger
+	Maintenance() *maintenance.Mode
+}
+
+// MaintenanceHandler reads (GET) or replaces (PUT) the maintenance mode
+// settings, such as {"enabled": true, "message": "Back at noon",
+// "retryAfter": 600}.
+func MaintenanceHandler(provider maintenanceProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var in maintenance.Settings
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				errorHandler(err, http.StatusBadRequest, w, provider.Logger())
+				return
+			}
+			in.RetryAfter = time.Duration(in.RetryAfterSeconds) * time.Second
+
+			if err := provider.Maintenance().Update(in); err != nil {
+				errorHandler(err, http.StatusBadRequest, w, provider.Logger())
+				return
+			}
+			provider.Logger().Warnw("maintenance mode changed",
+				"enabled", in.Enabled,
+				"message", in.Message,
+				"retryAfter", in.RetryAfterSeconds,
+			)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(provider.Maintenance().Settings())
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/activity"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/archive"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// WorkspaceSource is a served workspace, as the admin overview reports it
+// and workspace bundles are exported from and imported into.
+type WorkspaceSource struct {
+	Name      string
+	Plan      string // Empty without a plan
+	Store     store.Store
+	Service   *service.TaskService
+	Retention *archive.Retention // Nil when archived tasks are kept forever
+}
+
+type taskCounts struct {
+	Total int `json:"total"`
+	Open  int `json:"open"`
+}
+
+type workspaceOverview struct {
+	Name         string            `json:"name"`
+	Plan         string            `json:"plan,omitempty"`
+	Tasks        taskCounts        `json:"tasks"`
+	LastChangeAt *time.Time        `json:"lastChangeAt,omitempty"`
+	StorageBytes *int64            `json:"storageBytes,omitempty"` // Left out when the store cannot tell
+	Requests     activity.Requests `json:"requests"`
+}
+
+type userOverview struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	DisabledAt *time.Time        `json:"disabledAt,omitempty"`
+	Workspaces []string          `json:"workspaces"`
+	Requests   activity.Requests `json:"requests"`
+}
+
+// WorkspacesOverviewHandler lists the served workspaces with their tasks,
+// storage and the API requests made to them since the process started.
+func WorkspacesOverviewHandler(provider loggerProvider, workspaces []WorkspaceSource, tracker *activity.Tracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out := make([]workspaceOverview, 0, len(workspaces))
+		for _, ws := range workspaces {
+			tasks, err := ws.Store.GetAll(r.Context())
+			if err != nil {
+				errorHandler(err, http.StatusInternalServerError, w, provider.Logger())
+				return
+			}
+			overview := workspaceOverview{Name: ws.Name, Plan: ws.Plan, Requests: tracker.Workspace(ws.Name)}
+			for _, task := range tasks {
+				overview.Tasks.Total++
+				if !task.Completed {
+					overview.Tasks.Open++
+				}
+				if at := task.ChangedAt(); overview.LastChangeAt == nil || at.After(*overview.LastChangeAt) {
+					overview.LastChangeAt = &at
+				}
+			}
+			if sizer, ok := ws.Store.(store.Sizer); ok {
+				if size, err := sizer.Size(); err == nil {
+					overview.StorageBytes = &size
+				} else {
+					provider.Logger().Warnw("failed to size store", "workspace", ws.Name, "error", err)
+				}
+			}
+			out = append(out, overview)
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// UsersOverviewHandler lists the users with the workspaces they can access
+// and the API requests they made since the process started.
+func UsersOverviewHandler(provider authProvider, tracker *activity.Tracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		users, err := provider.Auth().Users()
+		if err != nil {
+			errorHandler(err, http.StatusInternalServerError, w, provider.Logger())
+			return
+		}
+		out := make([]userOverview, 0, len(users))
+		for _, user := range users {
+			out = append(out, userOverview{
+				ID:         user.ID,
+				Name:       user.Name,
+				DisabledAt: user.DisabledAt,
+				Workspaces: append([]string{store.DefaultWorkspace}, user.Workspaces...),
+				Requests:   tracker.User(user.ID),
+			})
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/capture"
+	"go.uber.org/zap"
+)
+
+// maxCaptureTokenTTL is the longest a capture header handed out lasts.
+const maxCaptureTokenTTL = 24 * time.Hour
+
+type capturesProvider interface {
+	Logger() *zap.SugaredLogger
+	Captures() *capture.Recorder
+}
+
+type captureToken struct {
+	Header    string    `json:"header"`
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// RequestsHandler lists the captured API requests with their responses,
+// newest first (GET), or drops them (DELETE).
+func RequestsHandler(provider capturesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			provider.Captures().Clear()
+			provider.Logger().Infow("captured requests cleared")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, provider.Captures().List())
+	}
+}
+
+// CaptureTokenHandler hands out a value of the capture header, which has
+// the requests sending it captured for ttl (1h by default, 24h at most).
+func CaptureTokenHandler(provider capturesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ttl := time.Hour
+		if raw := r.URL.Query().Get("ttl"); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil || d <= 0 || d > maxCaptureTokenTTL {
+				errorHandler(errors.New("ttl must be a duration of at most 24h, such as 30m"), http.StatusBadRequest, w, provider.Logger())
+				return
+			}
+			ttl = d
+		}
+
+		expires := time.Now().Add(ttl).UTC().Truncate(time.Second)
+		value, err := provider.Captures().Sign(expires)
+		if err != nil {
+			errorHandler(err, http.StatusConflict, w, provider.Logger())
+			return
+		}
+		provider.Logger().Infow("capture header handed out", "expiresAt", expires)
+		writeJSON(w, http.StatusCreated, captureToken{Header: capture.Header, Value: value, ExpiresAt: expires})
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/archive"
+	"go.uber.org/zap"
+)
+
+type loggerProvider interface {
+	Logger() *zap.SugaredLogger
+}
+
+type retentionPreviewResponse struct {
+	ArchivedBefore time.Time       `json:"archivedBefore"`
+	Count          int             `json:"count"`
+	Tasks          []archive.Entry `json:"tasks"`
+}
+
+// RetentionPreviewHandler returns the archived tasks the retention policy
+// of the workspace named by the workspace parameter, the default one when
+// empty, would purge now, without purging them.
+func RetentionPreviewHandler(provider loggerProvider, workspaces []WorkspaceSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws, ok := workspaceOf(r, workspaces)
+		if !ok || ws.Retention == nil {
+			errorHandler(fmt.Errorf("workspace %q is not served or keeps its archived tasks", ws.Name), http.StatusNotFound, w, provider.Logger())
+			return
+		}
+		now := time.Now()
+		entries, err := ws.Retention.Preview(now)
+		if err != nil {
+			errorHandler(err, http.StatusInternalServerError, w, provider.Logger())
+			return
+		}
+		if entries == nil {
+			entries = []archive.Entry{}
+		}
+		writeJSON(w, http.StatusOK, retentionPreviewResponse{
+			ArchivedBefore: ws.Retention.Cutoff(now),
+			Count:          len(entries),
+			Tasks:          entries,
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+	"go.uber.org/zap"
+)
+
+type authProvider interface {
+	Logger() *zap.SugaredLogger
+	Auth() *auth.Store
+}
+
+type userPayload struct {
+	Name string `json:"name"`
+}
+
+type workspacesPayload struct {
+	Workspaces []string `json:"workspaces"`
+}
+
+type keyPayload struct {
+	Name string `json:"name"`
+}
+
+// UsersHandler lists (GET) or creates (POST) users.
+func UsersHandler(provider authProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var in userPayload
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				errorHandler(err, http.StatusBadRequest, w, provider.Logger())
+				return
+			}
+
+			user, err := provider.Auth().CreateUser(in.Name)
+			if err != nil {
+				errorHandler(err, authStatus(err), w, provider.Logger())
+				return
+			}
+			provider.Logger().Infow("user created", "user", user.ID, "name", user.Name)
+			writeJSON(w, http.StatusCreated, user)
+			return
+		}
+
+		users, err := provider.Auth().Users()
+		if err != nil {
+			errorHandler(err, http.StatusInternalServerError, w, provider.Logger())
+			return
+		}
+		writeJSON(w, http.StatusOK, users)
+	}
+}
+
+// DisableUserHandler disables the user named by the {user} ID or name.
+func DisableUserHandler(provider authProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := provider.Auth().DisableUser(mux.Vars(r)["user"])
+		if err != nil {
+			errorHandler(err, authStatus(err), w, provider.Logger())
+			return
+		}
+		provider.Logger().Infow("user disabled", "user", user.ID, "name", user.Name)
+		writeJSON(w, http.StatusOK, user)
+	}
+}
+
+// PreferencesHandler changes the notification preferences of the {user}
+// ID or name; fields left out of the body are kept.
+func PreferencesHandler(provider authProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in auth.Preferences
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			errorHandler(err, http.StatusBadRequest, w, provider.Logger())
+			return
+		}
+
+		user, err := provider.Auth().SetPreferences(mux.Vars(r)["user"], in)
+		if err != nil {
+			errorHandler(err, authStatus(err), w, provider.Logger())
+			return
+		}
+		provider.Logger().Infow("user preferences changed", "user", user.ID, "name", user.Name)
+		writeJSON(w, http.StatusOK, user)
+	}
+}
+
+// WorkspacesHandler replaces the workspaces the {user} ID or name is a
+// member of; an empty list leaves the user the default workspace only.
+func WorkspacesHandler(provider authProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in workspacesPayload
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			errorHandler(err, http.StatusBadRequest, w, provider.Logger())
+			return
+		}
+
+		user, err := provider.Auth().SetWorkspaces(mux.Vars(r)["user"], in.Workspaces)
+		if err != nil {
+			errorHandler(err, authStatus(err), w, provider.Logger())
+			return
+		}
+		provider.Logger().Infow("user workspaces changed", "user", user.ID, "name", user.Name, "workspaces", user.Workspaces)
+		writeJSON(w, http.StatusOK, user)
+	}
+}
+
+// IssueKeyHandler issues an API key for the {user} ID or name. The
+// response holds the token, which cannot be retrieved again.
+func IssueKeyHandler(provider authProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in keyPayload
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				errorHandler(err, http.StatusBadRequest, w, provider.Logger())
+				return
+			}
+		}
+
+		key, err := provider.Auth().IssueKey(mux.Vars(r)["user"], in.Name)
+		if err != nil {
+			errorHandler(err, authStatus(err), w, provider.Logger())
+			return
+		}
+		provider.Logger().Infow("API key issued", "key", key.ID, "user", key.UserID)
+		writeJSON(w, http.StatusCreated, key)
+	}
+}
+
+// KeysHandler lists the API keys, optionally of a single user (?user=).
+func KeysHandler(provider authProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := provider.Auth().Keys(r.URL.Query().Get("user"))
+		if err != nil {
+			errorHandler(err, authStatus(err), w, provider.Logger())
+			return
+		}
+		writeJSON(w, http.StatusOK, keys)
+	}
+}
+
+// RevokeKeyHandler revokes the API key with the given {id}.
+func RevokeKeyHandler(provider authProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := provider.Auth().RevokeKey(mux.Vars(r)["id"])
+		if err != nil {
+			errorHandler(err, authStatus(err), w, provider.Logger())
+			return
+		}
+		provider.Logger().Infow("API key revoked", "key", key.ID, "user", key.UserID)
+		writeJSON(w, http.StatusOK, key)
+	}
+}
+
+// SessionsHandler lists the active sessions.
+func SessionsHandler(provider authProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessions, err := provider.Auth().Sessions()
+		if err != nil {
+			errorHandler(err, http.StatusInternalServerError, w, provider.Logger())
+			return
+		}
+		writeJSON(w, http.StatusOK, sessions)
+	}
+}
+
+// authStatus maps auth errors to HTTP status codes.
+func authStatus(err error) int {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound), errors.Is(err, auth.ErrKeyNotFound), errors.Is(err, auth.ErrSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, auth.ErrUserExists):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/version"
+)
+
+// VersionHandler returns the version, git commit and build time of the running binary.
+func VersionHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(version.Get())
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// BearerToken requires an "Authorization: Bearer <token>" header matching token.
+// With an empty token no header can match, so every request is turned away
+// with 403 ADMIN_DISABLED rather than let through.
+func BearerToken(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		if token == "" {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeError(w, r, http.StatusForbidden, "ADMIN_DISABLED", "Admin endpoints are disabled: no admin token is configured")
+			})
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				writeError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		token, header string
+		status        int
+		code          string
+	}{
+		{"matching token", "secret", "Bearer secret", http.StatusOK, ""},
+		{"wrong token", "secret", "Bearer guess", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"no header", "secret", "", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"other scheme", "secret", "Basic secret", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"no token configured", "", "", http.StatusForbidden, "ADMIN_DISABLED"},
+		{"no token configured, empty bearer", "", "Bearer ", http.StatusForbidden, "ADMIN_DISABLED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			served := false
+			handler := BearerToken(tt.token)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				served = true
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/loglevel", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status || served != (tt.status == http.StatusOK) {
+				t.Fatalf("expected status %d, got %d (served: %v)", tt.status, rec.Code, served)
+			}
+			if tt.code != "" {
+				var body errorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Code != tt.code {
+					t.Errorf("expected error code %s, got %+v (%v)", tt.code, body, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+)
+
+// Authenticator resolves an API key or session token to its user.
+type Authenticator interface {
+	Authenticate(token string) (auth.User, error)
+}
+
+// Authenticate resolves the "Authorization: Bearer <token>" credentials of
+// a request and stores the user in the context (see auth.UserFromContext).
+// Invalid credentials are always rejected; requests without credentials
+// are rejected only when required is set. CORS preflight requests pass,
+// because browsers send them without credentials.
+//
+// Requests without an Authorization header are authenticated with the
+// session token in the auth.SessionCookie cookie instead, if they have one.
+// Sessions expire while browsers keep their cookie, so an invalid session
+// counts as no credentials rather than as invalid ones.
+func Authenticate(authenticator Authenticator, required bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok && r.Header.Get("Authorization") == "" {
+				if cookie, err := r.Cookie(auth.SessionCookie); err == nil {
+					user, err := authenticator.Authenticate(cookie.Value)
+					if err == nil {
+						next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+						return
+					}
+					if !errors.Is(err, auth.ErrInvalidCredentials) {
+						unavailable(w, r)
+						return
+					}
+				}
+			}
+			if !ok {
+				if required {
+					unauthorized(w, r, "Authentication required")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := authenticator.Authenticate(token)
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				unauthorized(w, r, "Invalid credentials")
+				return
+			}
+			if err != nil {
+				unavailable(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// RequireLogin redirects requests without an authenticated user, such as
+// those of browsers that did not sign in, to the login page at loginPath,
+// which sends the browser back to the requested URL after signing in. It
+// goes after Authenticate.
+func RequireLogin(loginPath string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := auth.UserFromContext(r.Context()); !ok {
+				target := loginPath + "?" + url.Values{"return": {r.URL.RequestURI()}}.Encode()
+				http.Redirect(w, r, target, http.StatusSeeOther)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unavailable(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", "Authentication unavailable")
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	writeError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", message)
+}
+
+// BasicAuth is Authenticate for clients that only speak HTTP Basic
+// authentication, such as calendar applications: the password is an API
+// key or session token, and the user name must be the name of its user.
+// Rejected requests are challenged for Basic credentials in realm.
+func BasicAuth(authenticator Authenticator, required bool, realm string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			name, token, ok := r.BasicAuth()
+			if !ok {
+				if required {
+					challenge(w, realm)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := authenticator.Authenticate(token)
+			if errors.Is(err, auth.ErrInvalidCredentials) || err == nil && user.Name != name {
+				challenge(w, realm)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Authentication unavailable", http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+func challenge(w http.ResponseWriter, realm string) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+	http.Error(w, "Authentication required", http.StatusUnauthorized)
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+)
+
+// fakeAuthenticator knows the users by token. Authenticating fails with
+// err when it is set.
+type fakeAuthenticator struct {
+	users map[string]auth.User
+	err   error
+}
+
+func (f fakeAuthenticator) Authenticate(token string) (auth.User, error) {
+	if f.err != nil {
+		return auth.User{}, f.err
+	}
+	user, ok := f.users[token]
+	if !ok {
+		return auth.User{}, auth.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func TestBasicAuth(t *testing.T) {
+	users := fakeAuthenticator{users: map[string]auth.User{"key-ann": {ID: "u1", Name: "ann"}}}
+	tests := []struct {
+		name          string
+		authenticator fakeAuthenticator
+		required      bool
+		method        string
+		user, token   string // No credentials when both are empty
+		status        int
+		servedAs      string
+	}{
+		{"valid credentials", users, true, "PROPFIND", "ann", "key-ann", http.StatusOK, "ann"},
+		{"another user name", users, true, "PROPFIND", "bob", "key-ann", http.StatusUnauthorized, ""},
+		{"unknown token", users, true, "PROPFIND", "ann", "key-bob", http.StatusUnauthorized, ""},
+		{"no credentials", users, true, "PROPFIND", "", "", http.StatusUnauthorized, ""},
+		{"no credentials, not required", users, false, "PROPFIND", "", "", http.StatusOK, ""},
+		{"invalid credentials, not required", users, false, "PROPFIND", "ann", "key-bob", http.StatusUnauthorized, ""},
+		{"preflight", users, true, "OPTIONS", "", "", http.StatusOK, ""},
+		{"store unavailable", fakeAuthenticator{err: errors.New("disk on fire")}, true, "PROPFIND", "ann", "key-ann", http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			served, servedAs := false, ""
+			handler := BasicAuth(tt.authenticator, tt.required, "Tasks")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				served = true
+				if user, ok := auth.UserFromContext(r.Context()); ok {
+					servedAs = user.Name
+				}
+			}))
+
+			req := httptest.NewRequest(tt.method, "/caldav/", nil)
+			if tt.user != "" || tt.token != "" {
+				req.SetBasicAuth(tt.user, tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status || served != (tt.status == http.StatusOK) || servedAs != tt.servedAs {
+				t.Fatalf("expected status %d as %q, got %d (served: %v as %q)", tt.status, tt.servedAs, rec.Code, served, servedAs)
+			}
+			challenged := rec.Header().Get("WWW-Authenticate") == `Basic realm="Tasks", charset="UTF-8"`
+			if challenged != (tt.status == http.StatusUnauthorized) {
+				t.Errorf("expected a challenge only with 401, got %q", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/capture"
+)
+
+// Capture records the requests the recorder wants, with their responses, up
+// to capture.MaxBodySize bytes of each body. Bodies are captured as sent,
+// before Compress encodes the response.
+func Capture(recorder *capture.Recorder) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !recorder.Wants(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			e := capture.Exchange{
+				RequestID:     RequestIDFromContext(r.Context()),
+				At:            time.Now().UTC(),
+				Method:        r.Method,
+				URL:           r.URL.RequestURI(),
+				ClientIP:      clientIP(r),
+				RequestHeader: r.Header.Clone(),
+			}
+			// The start of the body is read up front, so it is captured
+			// even when the handler answers without reading it, and put
+			// back in front of the rest.
+			if r.Body != nil && r.Body != http.NoBody {
+				head, _ := io.ReadAll(io.LimitReader(r.Body, capture.MaxBodySize+1))
+				e.RequestTruncated = len(head) > capture.MaxBodySize
+				e.RequestBody = capture.Body(head[:min(len(head), capture.MaxBodySize)])
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+			}
+
+			rec := &captureRecorder{responseRecorder: newResponseRecorder(w)}
+			start := time.Now()
+			next.ServeHTTP(rec, r)
+
+			e.DurationMs = time.Since(start).Milliseconds()
+			e.Status = rec.Status()
+			e.ResponseHeader = w.Header().Clone()
+			e.ResponseBody = capture.Body(rec.body.Bytes())
+			e.ResponseTruncated = rec.truncated
+			recorder.Add(e)
+		})
+	}
+}
+
+// captureRecorder keeps the first capture.MaxBodySize bytes of the body
+// written by a handler.
+type captureRecorder struct {
+	*responseRecorder
+	body      bytes.Buffer
+	truncated bool
+}
+
+// Write keeps what fits of b before delegating.
+func (r *captureRecorder) Write(b []byte) (int, error) {
+	keep := min(len(b), capture.MaxBodySize-r.body.Len())
+	r.body.Write(b[:keep])
+	r.truncated = r.truncated || keep < len(b)
+	return r.responseRecorder.Write(b)
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/capture"
+)
+
+func TestCapture(t *testing.T) {
+	recorder := capture.New(capture.Settings{Secret: "0123456789abcdef", Size: 10})
+	handler := Capture(recorder)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+
+	serve := func(header string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/tasks?x=1", strings.NewReader(`"Plan"`))
+		r.Header.Set("Authorization", "Bearer key")
+		if header != "" {
+			r.Header.Set(capture.Header, header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	if rec := serve(""); rec.Body.String() != `{"echo":"Plan"}` {
+		t.Fatalf("expected the request to be served, got %q", rec.Body.String())
+	}
+	if list := recorder.List(); len(list) != 0 {
+		t.Fatalf("expected unsigned requests not to be captured, got %+v", list)
+	}
+
+	value, _ := recorder.Sign(time.Now().Add(time.Minute))
+	if rec := serve(value); rec.Body.String() != `{"echo":"Plan"}` {
+		t.Fatalf("expected the handler to read the whole body, got %q", rec.Body.String())
+	}
+	list := recorder.List()
+	if len(list) != 1 {
+		t.Fatalf("expected the signed request to be captured, got %+v", list)
+	}
+	e := list[0]
+	if e.Method != http.MethodPost || e.URL != "/api/tasks?x=1" || e.Status != http.StatusCreated {
+		t.Errorf("unexpected exchange %+v", e)
+	}
+	if e.RequestBody != `"Plan"` || e.ResponseBody != `{"echo":"Plan"}` || e.RequestTruncated || e.ResponseTruncated {
+		t.Errorf("unexpected bodies %q and %q", e.RequestBody, e.ResponseBody)
+	}
+	if e.RequestHeader.Get("Authorization") != "[redacted]" || e.ResponseHeader.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v and %v", e.RequestHeader, e.ResponseHeader)
+	}
+}
+
+func TestCapture_Truncates(t *testing.T) {
+	recorder := capture.New(capture.Settings{All: true, Size: 1})
+	big := strings.Repeat("a", capture.MaxBodySize+10)
+	var read int
+	handler := Capture(recorder)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		read = len(body)
+		w.Write([]byte(big))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/api/tasks/1", strings.NewReader(big)))
+
+	if read != len(big) {
+		t.Errorf("expected the handler to read all %d bytes, got %d", len(big), read)
+	}
+	e := recorder.List()[0]
+	if len(e.RequestBody) != capture.MaxBodySize || !e.RequestTruncated {
+		t.Errorf("expected the request body truncated to %d bytes, got %d", capture.MaxBodySize, len(e.RequestBody))
+	}
+	if len(e.ResponseBody) != capture.MaxBodySize || !e.ResponseTruncated {
+		t.Errorf("expected the response body truncated to %d bytes, got %d", capture.MaxBodySize, len(e.ResponseBody))
+	}
+}
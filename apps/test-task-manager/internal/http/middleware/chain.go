@@ -0,0 +1,29 @@
+package middleware
+
+import "net/http"
+
+// Middleware wraps an http.Handler with additional behavior.
+type Middleware func(http.Handler) http.Handler
+
+// Chain is an ordered list of middleware. The first middleware is the outermost.
+type Chain []Middleware
+
+// NewChain creates a chain from the given middleware.
+func NewChain(middleware ...Middleware) Chain {
+	return Chain(middleware)
+}
+
+// Append returns a new chain with middleware added after the existing ones.
+func (c Chain) Append(middleware ...Middleware) Chain {
+	chain := make(Chain, 0, len(c)+len(middleware))
+	chain = append(chain, c...)
+	return append(chain, middleware...)
+}
+
+// Then wraps h with every middleware in the chain.
+func (c Chain) Then(h http.Handler) http.Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i](h)
+	}
+	return h
+}
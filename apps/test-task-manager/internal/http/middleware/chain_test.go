@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChain_Then(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	chain := NewChain(mark("first")).Append(mark("second"))
+	chain.Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := []string{"first", "second", "handler"}
+	if len(order) != len(want) {
+		t.Fatalf("expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, order)
+		}
+	}
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	limiter := NewRateLimiter(1, 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.allow("10.0.0.1", now); !ok {
+			t.Fatalf("expected request %d within burst to be allowed", i+1)
+		}
+	}
+	if ok, retryAfter := limiter.allow("10.0.0.1", now); ok || retryAfter <= 0 {
+		t.Errorf("expected request beyond burst to be limited with a retry delay")
+	}
+	if ok, _ := limiter.allow("10.0.0.2", now); !ok {
+		t.Error("expected other clients to be unaffected")
+	}
+	if ok, _ := limiter.allow("10.0.0.1", now.Add(time.Second)); !ok {
+		t.Error("expected a token to be refilled after one second")
+	}
+}
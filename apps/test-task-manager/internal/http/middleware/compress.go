@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// compressibleTypes are the media types worth compressing.
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"text/html":              true,
+	"text/css":               true,
+	"text/javascript":        true,
+	"text/plain":             true,
+	"text/xml":               true,
+	"image/svg+xml":          true,
+}
+
+var gzipWriters = sync.Pool{
+	New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	},
+}
+
+// Compress gzips text responses of at least minSize bytes when the client accepts it.
+// Responses that already have a Content-Encoding are left untouched. A minSize of zero disables it.
+func Compress(minSize int) Middleware {
+	return func(next http.Handler) http.Handler {
+		if minSize <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead || !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, minSize: minSize}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		return strings.ReplaceAll(params, " ", "") != "q=0"
+	}
+	return false
+}
+
+// compressWriter buffers the response until it knows whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+// WriteHeader defers the status until the compression decision is made.
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+// Write buffers data until minSize is reached, then streams it.
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.minSize {
+			return len(b), nil
+		}
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if cw.gz != nil {
+		return cw.gz.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data. Streaming responses are compressed regardless of size.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.decide(true)
+	}
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close finishes the response, writing anything still buffered.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if cw.status == 0 {
+			// Nothing was written; leave the response untouched.
+			return nil
+		}
+		if err := cw.decide(false); err != nil {
+			return err
+		}
+	}
+	if cw.gz != nil {
+		err := cw.gz.Close()
+		gzipWriters.Put(cw.gz)
+		cw.gz = nil
+		return err
+	}
+	return nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide sends the headers and the buffered body, compressed if allowed.
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	h := cw.Header()
+
+	if compress && cw.compressible() {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		h.Add("Vary", "Accept-Encoding")
+
+		gz := gzipWriters.Get().(*gzip.Writer)
+		gz.Reset(cw.ResponseWriter)
+		cw.gz = gz
+
+		cw.ResponseWriter.WriteHeader(cw.status)
+		_, err := cw.gz.Write(cw.buf)
+		cw.buf = nil
+		return err
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	_, err := cw.ResponseWriter.Write(cw.buf)
+	cw.buf = nil
+	return err
+}
+
+// compressible reports whether the response may be compressed. Partial
+// content is left alone: its Content-Range counts bytes of the identity
+// encoding.
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" || cw.status < 200 || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	if cw.status == http.StatusPartialContent || h.Get("Content-Range") != "" {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buf)
+		h.Set("Content-Type", contentType)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return compressibleTypes[mediaType]
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"title":"task"}`, 100)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		wantGzip       bool
+	}{
+		{"large JSON", "gzip, deflate", "application/json", large, true},
+		{"small JSON", "gzip", "application/json", `{"title":"task"}`, false},
+		{"gzip not accepted", "", "application/json", large, false},
+		{"gzip refused", "gzip;q=0", "application/json", large, false},
+		{"already compressed type", "gzip", "image/png", large, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Compress(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusOK)
+				io.WriteString(w, tt.body)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			gotGzip := rec.Header().Get("Content-Encoding") == "gzip"
+			if gotGzip != tt.wantGzip {
+				t.Fatalf("expected gzip=%v, got %v", tt.wantGzip, gotGzip)
+			}
+
+			body := rec.Body.String()
+			if gotGzip {
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("expected valid gzip body, got %v", err)
+				}
+				b, _ := io.ReadAll(zr)
+				body = string(b)
+			}
+			if body != tt.body {
+				t.Errorf("expected body to round-trip, got %d bytes", len(body))
+			}
+		})
+	}
+}
+
+func TestCompress_Range(t *testing.T) {
+	dir := t.TempDir()
+	css := strings.Repeat("body { color: #212529; }\n", 168)
+	if err := os.WriteFile(filepath.Join(dir, "app.css"), []byte(css), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h := Compress(1024)(http.FileServer(http.Dir(dir)))
+
+	req := httptest.NewRequest(http.MethodGet, "/app.css", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Range", "bytes=0-1999")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("expected 206, got %d", rec.Code)
+	}
+	if enc := rec.Header().Get("Content-Encoding"); enc != "" {
+		t.Errorf("expected partial content to be sent as is, got Content-Encoding %q", enc)
+	}
+	if cr := rec.Header().Get("Content-Range"); cr != "bytes 0-1999/4200" || rec.Body.String() != css[:2000] {
+		t.Errorf("expected the first 2000 bytes with their range, got %q and %d bytes", cr, rec.Body.Len())
+	}
+
+	// The whole file is still compressed
+	req.Header.Del("Range")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("expected a gzipped 200 without Range, got %d %q", rec.Code, rec.Header().Get("Content-Encoding"))
+	}
+}
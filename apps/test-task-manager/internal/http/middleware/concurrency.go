@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+)
+
+// ConcurrencyLimit caps the number of requests handled at the same time.
+// Requests beyond the limit are shed immediately with a 503 and Retry-After
+// instead of queueing. The limit is shared by every chain the returned
+// middleware is used in. Requests exempt reports true for, such as
+// long-polls that spend their time waiting rather than working, hold no
+// slot; exempt may be nil. A limit of zero disables it.
+func ConcurrencyLimit(limit int, reg *metrics.Registry, exempt func(*http.Request) bool) Middleware {
+	inFlight := reg.Gauge("http_requests_in_flight", "Number of requests currently being handled by limited routes.")
+	shed := reg.Counter("http_requests_shed_total", "Total number of requests rejected because the concurrency limit was reached.")
+	slots := make(chan struct{}, max(limit, 0))
+
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exempt != nil && exempt(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			select {
+			case slots <- struct{}{}:
+			default:
+				shed.Inc()
+				w.Header().Set("Retry-After", "1")
+				writeError(w, r, http.StatusServiceUnavailable, "OVERLOADED", "Server is overloaded, please retry")
+				return
+			}
+
+			inFlight.Inc()
+			defer func() {
+				inFlight.Dec()
+				<-slots
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
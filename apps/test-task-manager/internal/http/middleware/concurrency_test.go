@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := ConcurrencyLimit(1, metrics.NewRegistry(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	<-entered
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while the limit is reached, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+
+	close(release)
+	<-done
+
+	go func() { <-entered }()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 after the slot was released, got %d", rec.Code)
+	}
+}
+
+func TestConcurrencyLimit_Exempt(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	waiting := func(r *http.Request) bool { return r.URL.Path == "/wait" }
+	handler := ConcurrencyLimit(1, metrics.NewRegistry(), waiting)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/wait" {
+			entered <- struct{}{}
+			<-release
+		}
+	}))
+
+	done := make(chan struct{})
+	for range 2 {
+		go func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/wait", nil))
+			done <- struct{}{}
+		}()
+		<-entered
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected exempt requests to hold no slot, got %d", rec.Code)
+	}
+
+	close(release)
+	<-done
+	<-done
+}
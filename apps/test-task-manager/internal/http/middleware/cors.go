@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/capture"
+)
+
+// CORS allows cross-origin requests from the given origins ("*" allows any).
+// Preflight requests are answered directly. With no origins it is a no-op.
+func CORS(allowedOrigins []string) Middleware {
+	allowAny := slices.Contains(allowedOrigins, "*")
+
+	return func(next http.Handler) http.Handler {
+		if len(allowedOrigins) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || (!allowAny && !slices.Contains(allowedOrigins, origin)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join([]string{
+					http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+				}, ", "))
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Lock-Token, "+WorkspaceHeader+", "+RequestIDHeader+", "+capture.Header)
+				w.Header().Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Deprecation describes a deprecated route. Clients are told with the
+// Deprecation (RFC 9745) and Sunset (RFC 8594) headers, and pointed to what
+// replaces it with Link headers.
+type Deprecation struct {
+	Since     time.Time // When the route was deprecated
+	Sunset    time.Time // When it will be removed; zero while undecided
+	Successor string    // URL of the route replacing it, if any
+	Docs      string    // URL of what the deprecation means for clients, if any
+}
+
+// Deprecations marks routes deprecated, logging and counting the requests
+// they still get so they can be removed once clients moved on.
+type Deprecations struct {
+	logger *zap.SugaredLogger
+	hits   *metrics.CounterVec
+}
+
+// NewDeprecations creates Deprecations logging to logger and counting
+// requests to deprecated routes in reg.
+func NewDeprecations(logger *zap.SugaredLogger, reg *metrics.Registry) *Deprecations {
+	return &Deprecations{
+		logger: logger,
+		hits:   reg.CounterVec("http_deprecated_requests_total", "Total number of requests to deprecated routes.", "route"),
+	}
+}
+
+// Route marks the routes it wraps deprecated by d. route names them in logs
+// and metrics, such as "GET /api/export".
+func (d *Deprecations) Route(route string, dep Deprecation) Middleware {
+	deprecation := fmt.Sprintf("@%d", dep.Since.Unix())
+	var sunset string
+	if !dep.Sunset.IsZero() {
+		sunset = dep.Sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			if sunset != "" {
+				w.Header().Set("Sunset", sunset)
+			}
+			if dep.Successor != "" {
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, dep.Successor))
+			}
+			if dep.Docs != "" {
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, dep.Docs))
+			}
+
+			d.hits.With(route).Inc()
+			d.logger.Warnw("deprecated route requested",
+				"route", route,
+				"sunset", sunset,
+				"client", clientIP(r),
+				"userAgent", r.UserAgent(),
+				"requestId", RequestIDFromContext(r.Context()),
+			)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"go.uber.org/zap"
+)
+
+func TestDeprecations_Route(t *testing.T) {
+	reg := metrics.NewRegistry()
+	deprecations := NewDeprecations(zap.NewNop().Sugar(), reg)
+	handler := deprecations.Route("GET /old", Deprecation{
+		Since:     time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC),
+		Successor: "/new",
+		Docs:      "https://example.com/deprecations",
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/old", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected the route to be served, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Deprecation"); got != "@1790812800" {
+		t.Errorf("expected Deprecation @1790812800, got %q", got)
+	}
+	if got := rec.Header().Get("Sunset"); got != "Thu, 01 Apr 2027 00:00:00 GMT" {
+		t.Errorf("expected the sunset as HTTP date, got %q", got)
+	}
+	links := rec.Header().Values("Link")
+	if !slices.Contains(links, `</new>; rel="successor-version"`) || !slices.Contains(links, `<https://example.com/deprecations>; rel="deprecation"`) {
+		t.Errorf("expected successor and deprecation links, got %q", links)
+	}
+	if got := reg.CounterVec("http_deprecated_requests_total", "", "route").With("GET /old").Value(); got != 1 {
+		t.Errorf("expected 1 deprecated request counted, got %v", got)
+	}
+
+	// Without a sunset or links only Deprecation is set
+	rec = httptest.NewRecorder()
+	deprecations.Route("GET /older", Deprecation{Since: time.Unix(0, 0)})(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/older", nil))
+	if rec.Header().Get("Deprecation") != "@0" || rec.Header().Get("Sunset") != "" || rec.Header().Get("Link") != "" {
+		t.Errorf("expected only Deprecation, got %v", rec.Header())
+	}
+}
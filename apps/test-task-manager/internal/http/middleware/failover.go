@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/failover"
+)
+
+// staleWarning is the Warning header of responses read from the snapshot
+// of an unreachable store (RFC 7234, section 5.5.1).
+const staleWarning = `110 - "Response is Stale"`
+
+// Failover marks responses built from tasks the failover.Store read from
+// its snapshot, while the store backend is unreachable, as stale: they get
+// a Warning header and an Age header with the seconds since the backend
+// last answered, so clients can tell and show it.
+func Failover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, marker := failover.NewContext(r.Context())
+		next.ServeHTTP(&staleWriter{ResponseWriter: w, marker: marker}, r.WithContext(ctx))
+	})
+}
+
+// staleWriter adds the stale headers, when the marker is stale, before the
+// headers are written.
+type staleWriter struct {
+	http.ResponseWriter
+	marker      *failover.Marker
+	wroteHeader bool
+}
+
+func (sw *staleWriter) WriteHeader(status int) {
+	if !sw.wroteHeader {
+		sw.wroteHeader = true
+		if stale, lastOK := sw.marker.Stale(); stale {
+			h := sw.Header()
+			h.Set("Warning", staleWarning)
+			if !lastOK.IsZero() {
+				h.Set("Age", strconv.Itoa(int(time.Since(lastOK).Seconds())))
+			}
+		}
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *staleWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher when the underlying writer supports it.
+func (sw *staleWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		if !sw.wroteHeader {
+			sw.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (sw *staleWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/failover"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/faults"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestFailover(t *testing.T) {
+	injector := faults.NewInjector(faults.Settings{}, metrics.NewRegistry())
+	s := failover.Wrap(faults.WrapStore(store.NewTaskStore(), injector))
+	if err := s.Load(t.Context()); err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+	handler := Failover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := s.GetAll(r.Context()); err != nil {
+			t.Errorf("expected tasks, got %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tasks", nil))
+	if got := rec.Header().Get("Warning"); got != "" {
+		t.Errorf("expected no Warning while the store is up, got %q", got)
+	}
+
+	if err := injector.Update(faults.Settings{ErrorRate: 1, Targets: []string{faults.TargetStore}}); err != nil {
+		t.Fatalf("failed to update faults: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tasks", nil))
+	if got := rec.Header().Get("Warning"); got != staleWarning {
+		t.Errorf("expected Warning %q while the store is down, got %q", staleWarning, got)
+	}
+	if got := rec.Header().Get("Age"); got != "0" {
+		t.Errorf("expected Age 0, got %q", got)
+	}
+}
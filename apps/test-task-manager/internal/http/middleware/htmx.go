@@ -0,0 +1,16 @@
+package middleware
+
+import "net/http"
+
+// HTMXOnly rejects requests without the HX-Request header htmx sends. Pages
+// of other origins cannot set it without a CORS preflight, which the routes
+// it guards do not answer, so they cannot submit changes from forms.
+func HTMXOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("HX-Request") != "true" {
+			http.Error(w, "This endpoint only serves htmx requests", http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
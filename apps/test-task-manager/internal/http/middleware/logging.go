@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Logging writes an access log line for every request.
+func Logging(logger *zap.SugaredLogger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := newResponseRecorder(w)
+			start := time.Now()
+
+			next.ServeHTTP(rec, r)
+
+			logger.Infow("request",
+				"method", r.Method,
+				"route", routeTemplate(r),
+				"path", r.URL.Path,
+				"status", rec.Status(),
+				"bytes", rec.bytes,
+				"durationMs", time.Since(start).Milliseconds(),
+				"remoteAddr", r.RemoteAddr,
+				"clientIp", clientIP(r),
+				"requestId", RequestIDFromContext(r.Context()),
+			)
+		})
+	}
+}
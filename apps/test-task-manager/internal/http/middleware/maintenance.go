@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/maintenance"
+)
+
+// Maintenance turns away changes while mode is enabled with a 503 and
+// Retry-After: requests other than GET, HEAD, OPTIONS and the read-only
+// PROPFIND and REPORT of CalDAV. htmx requests get the message as plain
+// text, which the page shows, others a JSON error. Reads pass with the
+// settings in their context, so pages can show a banner.
+func Maintenance(mode *maintenance.Mode) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			settings := mode.Settings()
+			if !settings.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if readOnly(r.Method) {
+				next.ServeHTTP(w, r.WithContext(maintenance.NewContext(r.Context(), settings)))
+				return
+			}
+
+			w.Header().Set("Retry-After", strconv.FormatInt(settings.RetryAfterSeconds, 10))
+			if r.Header.Get("HX-Request") == "true" {
+				http.Error(w, settings.ClientMessage(), http.StatusServiceUnavailable)
+				return
+			}
+			writeError(w, r, http.StatusServiceUnavailable, "MAINTENANCE", settings.ClientMessage())
+		})
+	}
+}
+
+// readOnly reports whether requests with method do not change anything.
+func readOnly(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND", "REPORT":
+		return true
+	}
+	return false
+}
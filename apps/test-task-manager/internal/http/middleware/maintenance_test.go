@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/maintenance"
+)
+
+func TestMaintenance(t *testing.T) {
+	mode := maintenance.New(maintenance.Settings{})
+	var banner bool
+	handler := Maintenance(mode)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, banner = maintenance.FromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(method string, htmx bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/tasks", nil)
+		if htmx {
+			req.Header.Set("HX-Request", "true")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, false); rec.Code != http.StatusNoContent || banner {
+		t.Fatalf("expected changes to pass without a banner when disabled, got %d", rec.Code)
+	}
+
+	if err := mode.Update(maintenance.Settings{Enabled: true, RetryAfter: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(http.MethodGet, false); rec.Code != http.StatusNoContent || !banner {
+		t.Errorf("expected reads to pass with the settings in their context, got %d", rec.Code)
+	}
+
+	rec := serve(http.MethodDelete, false)
+	var body errorResponse
+	json.NewDecoder(rec.Body).Decode(&body)
+	if rec.Code != http.StatusServiceUnavailable || body.Code != "MAINTENANCE" || body.Error != maintenance.DefaultMessage {
+		t.Errorf("expected 503 MAINTENANCE with the default message, got %d %+v", rec.Code, body)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected Retry-After 60, got %q", got)
+	}
+
+	mode.Update(maintenance.Settings{Enabled: true, Message: "Back at noon"})
+	rec = serve(http.MethodPatch, true)
+	if rec.Code != http.StatusServiceUnavailable || strings.TrimSpace(rec.Body.String()) != "Back at noon" {
+		t.Errorf("expected htmx changes to get the message as text, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Retry-After"); got != "300" {
+		t.Errorf("expected the default Retry-After of 300, got %q", got)
+	}
+}
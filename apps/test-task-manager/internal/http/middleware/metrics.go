@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+)
+
+// unmatchedRoute is the route label of requests no route matched, so
+// unknown paths share their series instead of each adding their own.
+const unmatchedRoute = "unmatched"
+
+// otherMethod is the method label of requests with a method that is not
+// a standard one, which clients can choose freely.
+const otherMethod = "other"
+
+// Metrics counts requests, server errors among them and observes their
+// duration, by method and the template of the matched route, such as
+// /api/tasks/{id}: the rate, errors and duration of every route for RED
+// dashboards and SLO alerts. Raw paths are never used as labels, as every
+// task ID would add series, and neither are non-standard methods.
+func Metrics(reg *metrics.Registry) Middleware {
+	requests := reg.CounterVec("http_requests_total", "Total number of HTTP requests.", "method", "route", "code")
+	errors := reg.CounterVec("http_request_errors_total", "Total number of HTTP requests answered with a server error (5xx).", "method", "route")
+	duration := reg.HistogramVec("http_request_duration_seconds", "Duration of HTTP requests.", nil, "method", "route")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := newResponseRecorder(w)
+			start := time.Now()
+
+			next.ServeHTTP(rec, r)
+
+			method, route := metricsMethod(r), metricsRoute(r)
+			duration.With(method, route).Observe(time.Since(start).Seconds())
+			requests.With(method, route, strconv.Itoa(rec.Status())).Inc()
+			if rec.Status() >= http.StatusInternalServerError {
+				errors.With(method, route).Inc()
+			}
+		})
+	}
+}
+
+// metricsMethod returns the method of r when it is a standard one, and
+// otherMethod otherwise.
+func metricsMethod(r *http.Request) string {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return r.Method
+	}
+	return otherMethod
+}
+
+// metricsRoute returns the matched mux route template, or unmatchedRoute.
+func metricsRoute(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return unmatchedRoute
+}
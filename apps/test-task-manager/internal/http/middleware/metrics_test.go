@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+)
+
+func TestMetrics_LabelsByRouteTemplate(t *testing.T) {
+	reg := metrics.NewRegistry()
+	instrument := Metrics(reg)
+	router := mux.NewRouter()
+	router.Use(mux.MiddlewareFunc(instrument))
+	router.HandleFunc("/api/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "3" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}).Methods("GET")
+	router.NotFoundHandler = instrument(http.NotFoundHandler())
+
+	for _, path := range []string{"/api/tasks/1", "/api/tasks/2", "/api/tasks/3", "/nope/1", "/nope/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	for _, method := range []string{"FOO", "BAR"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/nope/1", nil))
+	}
+
+	var out strings.Builder
+	reg.Write(&out)
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/api/tasks/{id}",code="200"} 2`,
+		`http_requests_total{method="GET",route="/api/tasks/{id}",code="503"} 1`,
+		`http_requests_total{method="GET",route="unmatched",code="404"} 2`,
+		`http_requests_total{method="other",route="unmatched",code="404"} 2`,
+		`http_request_errors_total{method="GET",route="/api/tasks/{id}"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/api/tasks/{id}"} 3`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "/api/tasks/1") || strings.Contains(out.String(), "/nope") || strings.Contains(out.String(), "FOO") {
+		t.Errorf("expected no raw paths or methods as labels, got:\n%s", out.String())
+	}
+}
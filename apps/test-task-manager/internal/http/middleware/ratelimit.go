@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// idleBucketTTL is how long an unused client bucket is kept.
+const idleBucketTTL = 10 * time.Minute
+
+// RateLimiter applies a token bucket per client IP allowing rps requests per
+// second with bursts of up to burst requests. A rate of zero disables it.
+// The limits can be changed at runtime with SetLimits.
+type RateLimiter struct {
+	mu        sync.Mutex
+	rps       float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter creates a RateLimiter with the given limits.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	l := &RateLimiter{buckets: make(map[string]*bucket)}
+	l.SetLimits(rps, burst)
+	return l
+}
+
+// SetLimits changes the limits. Existing buckets keep their tokens, capped at the new burst.
+func (l *RateLimiter) SetLimits(rps float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rps = rps
+	l.burst = float64(max(burst, 1))
+}
+
+// Middleware rejects requests beyond the limit with a 429 and Retry-After.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return l.limit(next, clientIP, "Too many requests", "RATE_LIMITED")
+}
+
+// Shared limits the requests it passes together, as those of client key,
+// rather than per client IP. It limits the requests to a workspace.
+func (l *RateLimiter) Shared(key string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return l.limit(next, func(*http.Request) string { return key }, "Too many requests to the workspace", "WORKSPACE_RATE_LIMITED")
+	}
+}
+
+// Remaining returns the number of requests client may make at once before
+// it is limited, or -1 when the rate limiter is disabled.
+func (l *RateLimiter) Remaining(client string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rps <= 0 {
+		return -1
+	}
+	b, ok := l.buckets[client]
+	if !ok {
+		return int(l.burst)
+	}
+	return int(math.Min(l.burst, b.tokens+time.Since(b.lastSeen).Seconds()*l.rps))
+}
+
+// limit rejects requests beyond the limit of the client returned by client
+// with a 429 and Retry-After.
+func (l *RateLimiter) limit(next http.Handler, client func(*http.Request) string, message, code string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, retryAfter := l.allow(client(r), time.Now())
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			writeError(w, r, http.StatusTooManyRequests, code, message)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// allow takes a token from the client's bucket, or reports how long to wait for one.
+func (l *RateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rps <= 0 {
+		return true, 0
+	}
+
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, lastSeen: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*l.rps)
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rps * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets of clients that have been idle for a while.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBucketTTL {
+		return
+	}
+	l.lastSweep = now
+
+	for client, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleBucketTTL {
+			delete(l.buckets, client)
+		}
+	}
+}
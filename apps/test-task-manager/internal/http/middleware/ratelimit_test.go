@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimiter_Shared(t *testing.T) {
+	limiter := NewRateLimiter(0.001, 2)
+	handler := limiter.Shared("team")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	if got := limiter.Remaining("team"); got != 2 {
+		t.Errorf("expected 2 requests remaining, got %d", got)
+	}
+	// Requests from different clients count together
+	for i, addr := range []string{"10.0.0.1:1234", "10.0.0.2:1234", "10.0.0.3:1234"} {
+		req := httptest.NewRequest(http.MethodGet, "/w/team/api/tasks", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		want := http.StatusNoContent
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if rec.Code != want {
+			t.Errorf("request %d: expected status %d, got %d", i+1, want, rec.Code)
+		}
+	}
+	if got := limiter.Remaining("team"); got != 0 {
+		t.Errorf("expected no requests remaining, got %d", got)
+	}
+	if got := NewRateLimiter(0, 1).Remaining("team"); got != -1 {
+		t.Errorf("expected -1 for a disabled limiter, got %d", got)
+	}
+}
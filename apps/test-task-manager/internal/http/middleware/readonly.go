@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// readOnlyMessage answers changes in read-only mode.
+const readOnlyMessage = "The task manager is read-only. Tasks can be read but not changed."
+
+// ReadOnly turns away every change with a 403 READ_ONLY, for instances
+// that only show tasks, such as sandbox demos or ones kept as they were
+// during an incident. Like Maintenance it passes the read-only methods,
+// and answers htmx requests with the message as plain text.
+func ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if readOnly(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Header.Get("HX-Request") == "true" {
+			http.Error(w, readOnlyMessage, http.StatusForbidden)
+			return
+		}
+		writeError(w, r, http.StatusForbidden, "READ_ONLY", readOnlyMessage)
+	})
+}
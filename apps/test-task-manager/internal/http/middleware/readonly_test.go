@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	handler := ReadOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND", "REPORT"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/api/tasks", nil))
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("%s: expected reads to pass, got %d", method, rec.Code)
+		}
+	}
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/api/tasks", nil))
+		var body errorResponse
+		json.NewDecoder(rec.Body).Decode(&body)
+		if rec.Code != http.StatusForbidden || body.Code != "READ_ONLY" {
+			t.Errorf("%s: expected 403 READ_ONLY, got %d %+v", method, rec.Code, body)
+		}
+	}
+}
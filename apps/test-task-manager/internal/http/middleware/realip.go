@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// ParseTrustedProxies parses a list of CIDRs or single IP addresses.
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q is not an IP address or CIDR", proxy)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// RealIP determines the client IP of every request. Forwarding headers are
+// only honored when the direct peer is a trusted proxy: X-Forwarded-For is
+// read from right to left, skipping trusted proxies, and X-Real-IP is used
+// when X-Forwarded-For is absent. Peers connected over a unix socket are
+// local proxies and always trusted. Invalid proxies are ignored; validate
+// them with ParseTrustedProxies.
+func RealIP(trustedProxies []string) Middleware {
+	trusted, _ := ParseTrustedProxies(trustedProxies)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolveClientIP(r, trusted)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+		})
+	}
+}
+
+// ClientIPFromContext returns the client IP stored by RealIP, if any.
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// clientIP returns the client IP resolved by RealIP, or the direct peer's IP.
+func clientIP(r *http.Request) string {
+	if ip := ClientIPFromContext(r.Context()); ip != "" {
+		return ip
+	}
+	return peerIP(r)
+}
+
+// peerIP returns the IP address of the direct peer.
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func resolveClientIP(r *http.Request, trusted []netip.Prefix) string {
+	peer := peerIP(r)
+	peerAddr, err := netip.ParseAddr(peer)
+	isUnixSocket := err != nil
+	if !isUnixSocket && !isTrusted(peerAddr, trusted) {
+		return peer
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		var client string
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			client = addr.Unmap().String()
+			if !isTrusted(addr, trusted) {
+				break
+			}
+		}
+		if client != "" {
+			return client
+		}
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap().String()
+	}
+
+	return peer
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{name: "untrusted peer ignores headers", remoteAddr: "203.0.113.7:1234", forwarded: "198.51.100.1", want: "203.0.113.7"},
+		{name: "trusted peer uses forwarded client", remoteAddr: "10.0.0.2:1234", forwarded: "198.51.100.1", want: "198.51.100.1"},
+		{name: "trusted hops are skipped", remoteAddr: "10.0.0.2:1234", forwarded: "1.1.1.1, 198.51.100.1, 10.0.0.3", want: "198.51.100.1"},
+		{name: "spoofed leftmost entry is ignored", remoteAddr: "10.0.0.2:1234", forwarded: "6.6.6.6, 198.51.100.1", want: "198.51.100.1"},
+		{name: "real IP without forwarded header", remoteAddr: "10.0.0.2:1234", realIP: "198.51.100.2", want: "198.51.100.2"},
+		{name: "trusted peer without headers", remoteAddr: "10.0.0.2:1234", want: "10.0.0.2"},
+		{name: "unix socket peer is trusted", remoteAddr: "@", forwarded: "198.51.100.1", want: "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := RealIP([]string{"10.0.0.0/8"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = clientIP(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("expected client IP %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "::1"}); err != nil {
+		t.Errorf("expected valid proxies, got %v", err)
+	}
+	if _, err := ParseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Error("expected error for invalid proxy")
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/i18n"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/schema"
+	"go.uber.org/zap"
+)
+
+// errorResponse mirrors the API error envelope, including the request ID.
+type errorResponse struct {
+	Error     string `json:"error"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
+	// Fields are the field errors of request bodies ValidateJSON rejects.
+	Fields []schema.FieldError `json:"fields,omitempty"`
+}
+
+// writeError answers with status and an errorResponse. The English message
+// is translated into the language of the Accept-Language header, with args
+// formatted into it as by i18n.T; error codes stay the same in every
+// language.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string, args ...any) {
+	locale := setLanguage(w, r)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{
+		Error:     i18n.T(locale, message, args...),
+		Code:      code,
+		RequestID: RequestIDFromContext(r.Context()),
+	})
+}
+
+// setLanguage marks the response as in the locale of the request, which it
+// returns.
+func setLanguage(w http.ResponseWriter, r *http.Request) i18n.Locale {
+	locale := i18n.Match(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", string(locale))
+	w.Header().Add("Vary", "Accept-Language")
+	return locale
+}
+
+// Recovery turns handler panics into a 500 JSON error instead of dropping the connection.
+// The panic value and stack are logged, counted in http_panics_total and sent to the reporter.
+func Recovery(logger *zap.SugaredLogger, reg *metrics.Registry, reporter errorreport.Reporter) Middleware {
+	panics := reg.Counter("http_panics_total", "Total number of panics recovered in HTTP handlers.")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := newResponseRecorder(w)
+
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+				// Let net/http handle deliberate aborts.
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				requestID := RequestIDFromContext(r.Context())
+				stack := debug.Stack()
+				panics.Inc()
+				logger.Errorw("panic recovered in HTTP handler",
+					"panic", err,
+					"requestId", requestID,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"stack", string(stack),
+				)
+				reporter.CapturePanic(r, err, stack)
+
+				// Headers are already sent; nothing sensible can be written anymore.
+				if rec.Written() {
+					return
+				}
+
+				writeError(w, r, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", "Internal server error")
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
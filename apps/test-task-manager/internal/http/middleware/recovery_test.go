@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"go.uber.org/zap"
+)
+
+func TestRecovery(t *testing.T) {
+	reg := metrics.NewRegistry()
+	h := RequestID(Recovery(zap.NewNop().Sugar(), reg, errorreport.Nop{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", rec.Code)
+	}
+
+	var body errorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON body, got %v", err)
+	}
+	if body.RequestID != "req-123" {
+		t.Errorf("expected request ID req-123, got %q", body.RequestID)
+	}
+	if got := reg.Counter("http_panics_total", "").Value(); got != 1 {
+		t.Errorf("expected panic counter 1, got %v", got)
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/timing"
+	"go.uber.org/zap"
+)
+
+// SlowRequests logs a warning with a timing breakdown for every request
+// that takes longer than threshold. A threshold of zero disables it.
+func SlowRequests(threshold time.Duration, logger *zap.SugaredLogger) Middleware {
+	return func(next http.Handler) http.Handler {
+		if threshold <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, breakdown := timing.NewContext(r.Context())
+			rec := newResponseRecorder(w)
+
+			start := time.Now()
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			elapsed := time.Since(start)
+
+			if elapsed < threshold {
+				return
+			}
+
+			fields := []any{
+				"method", r.Method,
+				"route", routeTemplate(r),
+				"path", r.URL.Path,
+				"status", rec.Status(),
+				"durationMs", elapsed.Milliseconds(),
+				"thresholdMs", threshold.Milliseconds(),
+				"requestId", RequestIDFromContext(r.Context()),
+			}
+
+			// Whatever is not attributed to a phase was spent in the handler itself.
+			handler := elapsed
+			for phase, d := range breakdown.Phases() {
+				fields = append(fields, phase+"Ms", d.Milliseconds())
+				handler -= d
+			}
+			fields = append(fields, "handlerMs", handler.Milliseconds())
+
+			logger.Warnw("slow request", fields...)
+		})
+	}
+}
+
+// routeTemplate returns the matched mux route template, or the raw path.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return r.URL.Path
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/timing"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlowRequests(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	handler := SlowRequests(20*time.Millisecond, zap.New(core).Sugar())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			stop := timing.Track(r.Context(), "store")
+			time.Sleep(30 * time.Millisecond)
+			stop()
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if logs.Len() != 0 {
+		t.Fatalf("expected fast requests not to be logged, got %v", logs.All())
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	entries := logs.FilterMessage("slow request").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected the slow request to be logged once, got %v", logs.All())
+	}
+	fields := entries[0].ContextMap()
+	if fields["path"] != "/slow" || fields["status"] != int64(http.StatusAccepted) || fields["thresholdMs"] != int64(20) {
+		t.Errorf("unexpected fields %v", fields)
+	}
+	if store, ok := fields["storeMs"].(int64); !ok || store < 30 {
+		t.Errorf("expected the time spent in the store phase, got %v", fields)
+	}
+	if _, ok := fields["handlerMs"]; !ok {
+		t.Errorf("expected the time spent in the handler, got %v", fields)
+	}
+}
+
+func TestSlowRequests_Disabled(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	handler := SlowRequests(0, zap.New(core).Sugar())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if logs.Len() != 0 {
+		t.Errorf("expected nothing to be logged without a threshold, got %v", logs.All())
+	}
+}
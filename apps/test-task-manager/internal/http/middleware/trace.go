@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/tracing"
+)
+
+// Trace continues the trace from an incoming traceparent header,
+// or starts a new one, and stores the server span in the request context.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc, ok := tracing.Parse(r.Header.Get(tracing.Header))
+		if ok {
+			sc = sc.Child()
+		} else {
+			sc = tracing.New()
+		}
+
+		next.ServeHTTP(w, r.WithContext(tracing.NewContext(r.Context(), sc)))
+	})
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/i18n"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/schema"
+)
+
+// maxValidatedBody is the size up to which request bodies are validated.
+// Larger ones are passed on for the handler to reject with its own limit.
+const maxValidatedBody = 64 << 10
+
+// ValidateJSON checks JSON request bodies against s before the handler runs,
+// answering 400 INVALID_INPUT with the field errors when they do not match,
+// in the language of the request.
+// The body is restored for the handler. Empty bodies, bodies that are not
+// JSON and other content types, such as XML, are left to the handler.
+func ValidateJSON(s *schema.Schema) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || !isJSON(r.Header.Get("Content-Type")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBody+1))
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			if err != nil || len(body) > maxValidatedBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var v any
+			if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &v) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			locale := i18n.Match(r.Header.Get("Accept-Language"))
+			fields := s.ValidateWith(v, func(format string, args ...any) string {
+				return i18n.T(locale, format, args...)
+			})
+			if len(fields) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if s.ID != "" {
+				w.Header().Set("Link", `<`+s.ID+`>; rel="describedby"`)
+			}
+			setLanguage(w, r)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(errorResponse{
+				Error:     i18n.T(locale, "The request body does not match its schema: %s", fields[0].Error()),
+				Code:      "INVALID_INPUT",
+				RequestID: RequestIDFromContext(r.Context()),
+				Fields:    fields,
+			})
+		})
+	}
+}
+
+// isJSON reports whether contentType is JSON, which handlers assume when it
+// is missing.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/schema"
+)
+
+func TestValidateJSON(t *testing.T) {
+	var got string
+	handler := ValidateJSON(schema.MustLookup("merge"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/tasks/1/merge", strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("application/json", `{"source": "2"}`); rec.Code != http.StatusNoContent || got != `{"source": "2"}` {
+		t.Errorf("expected a valid body to reach the handler unchanged, got %d %q", rec.Code, got)
+	}
+
+	rec := serve("", `{"source": 2, "into": "3"}`)
+	var body errorResponse
+	json.NewDecoder(rec.Body).Decode(&body)
+	if rec.Code != http.StatusBadRequest || body.Code != "INVALID_INPUT" {
+		t.Fatalf("expected 400 INVALID_INPUT, got %d %+v", rec.Code, body)
+	}
+	want := []schema.FieldError{{Field: "/into", Message: "is not allowed"}, {Field: "/source", Message: "must be a string"}}
+	if len(body.Fields) != 2 || body.Fields[0] != want[0] || body.Fields[1] != want[1] {
+		t.Errorf("expected field errors %v, got %v", want, body.Fields)
+	}
+	if link := rec.Header().Get("Link"); link != `</api/schemas/merge>; rel="describedby"` {
+		t.Errorf("expected a link to the schema, got %q", link)
+	}
+
+	// Other content types, empty and malformed bodies are the handler's
+	for _, tt := range []struct{ contentType, body string }{
+		{"application/xml", "<merge/>"},
+		{"application/json", ""},
+		{"application/json", "{"},
+	} {
+		if rec := serve(tt.contentType, tt.body); rec.Code != http.StatusNoContent || got != tt.body {
+			t.Errorf("%s %q: expected the handler to get the body, got %d %q", tt.contentType, tt.body, rec.Code, got)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/activity"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+)
+
+// WorkspaceHeader selects the workspace of an /api request, as the
+// /w/{workspace} path prefix does.
+const WorkspaceHeader = "X-Workspace"
+
+// RequireMember rejects requests of users who are not a member of
+// workspace with 403. It goes after Authenticate; requests without a user
+// pass, as they only get this far when authentication is optional. CORS
+// preflight requests pass, because browsers send them without credentials.
+func RequireMember(workspace string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, ok := auth.UserFromContext(r.Context()); ok && r.Method != http.MethodOptions && !user.MemberOf(workspace) {
+				writeError(w, r, http.StatusForbidden, "WORKSPACE_FORBIDDEN", "Not a member of the workspace")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnknownWorkspace answers requests for a workspace that is not served.
+func UnknownWorkspace(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, "WORKSPACE_NOT_FOUND", "Workspace not found")
+}
+
+// TrackActivity records the requests to workspace in tracker, with the
+// user who made them. It goes after Authenticate.
+func TrackActivity(tracker *activity.Tracker, workspace string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var user string
+			if u, ok := auth.UserFromContext(r.Context()); ok {
+				user = u.ID
+			}
+			tracker.Record(workspace, user, activity.IsWrite(r.Method), time.Now())
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/activity"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+)
+
+func TestRequireMember(t *testing.T) {
+	handler := RequireMember("team")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		user   *auth.User
+		method string
+		want   int
+	}{
+		{name: "member", user: &auth.User{Name: "alice", Workspaces: []string{"team"}}, method: http.MethodGet, want: http.StatusNoContent},
+		{name: "non-member", user: &auth.User{Name: "bob"}, method: http.MethodGet, want: http.StatusForbidden},
+		{name: "preflight", user: &auth.User{Name: "bob"}, method: http.MethodOptions, want: http.StatusNoContent},
+		{name: "anonymous", method: http.MethodGet, want: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/w/team/api/tasks", nil)
+			if tt.user != nil {
+				req = req.WithContext(auth.WithUser(req.Context(), *tt.user))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestTrackActivity(t *testing.T) {
+	tracker := activity.NewTracker()
+	handler := TrackActivity(tracker, "team")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/w/team/api/tasks", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(auth.WithUser(req.Context(), auth.User{ID: "u1"})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/w/team/api/tasks", nil))
+
+	if got := tracker.Workspace("team"); got.Total != 2 || got.Writes != 1 {
+		t.Errorf("expected 2 requests with 1 write to team, got %+v", got)
+	}
+	if got := tracker.User("u1"); got.Total != 1 || got.Writes != 1 {
+		t.Errorf("expected 1 write by u1, got %+v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// Timeouts configures the connection timeouts of an HTTP server.
+type Timeouts struct {
+	Read       time.Duration
+	ReadHeader time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+// httpServer serves a mux router with explicit timeouts.
+type httpServer struct {
+	Router *mux.Router
+
+	network         string
+	srv             *http.Server
+	logger          *zap.SugaredLogger
+	shutdownTimeout time.Duration
+}
+
+// newHTTPServer creates a server for addr on the given network ("tcp" or
+// "unix"). It does not start listening.
+func newHTTPServer(network, addr string, timeouts Timeouts, shutdownTimeout time.Duration, logger *zap.SugaredLogger) *httpServer {
+	router := mux.NewRouter()
+
+	return &httpServer{
+		Router:  router,
+		network: network,
+		srv: &http.Server{
+			Addr:              addr,
+			Handler:           router,
+			ReadTimeout:       timeouts.Read,
+			ReadHeaderTimeout: timeouts.ReadHeader,
+			WriteTimeout:      timeouts.Write,
+			IdleTimeout:       timeouts.Idle,
+			ErrorLog:          zap.NewStdLog(logger.Desugar()),
+		},
+		logger:          logger,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+// listenFunc opens (or inherits) the listener for a network and address.
+type listenFunc func(network, addr string) (net.Listener, error)
+
+// Start listens using listen and serves in the background.
+func (s *httpServer) Start(listen listenFunc) {
+	ln, err := listen(s.network, s.srv.Addr)
+	if err != nil {
+		s.logger.Fatalw("failed to listen", "network", s.network, "addr", s.srv.Addr, "error", err)
+	}
+
+	s.logger.Infow("HTTP server listening", "network", s.network, "addr", ln.Addr().String())
+
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Errorw("HTTP server stopped unexpectedly", "error", err)
+		}
+	}()
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests
+// up to the shutdown timeout.
+func (s *httpServer) Shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if err := s.srv.Shutdown(ctx); err != nil {
+		s.logger.Warnw("HTTP server did not shut down gracefully", "error", err)
+		s.srv.Close()
+	}
+}
@@ -2,30 +2,415 @@ package server
 
 import (
 	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/activity"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/assets"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/handler"
 	oldhandler "gitlab.com/btcdirect-api/test-task-manager/internal/http/handler"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/http/middleware"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/schema"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
 )
 
-// Registers all routes for the application.
-func registerRoutes(r *mux.Router, app *app.App, pageHandler *handler.PageHandler, apiHandler *handler.APIHandler) {
-	// Health endpoint
-	r.HandleFunc("/health", oldhandler.HealthHandler(app)).Methods("GET")
+// Middlewares holds the middleware chain applied to each route group.
+// Every group is additionally wrapped in Common.
+type Middlewares struct {
+	Common    middleware.Chain // All routes
+	Ops       middleware.Chain // Health, version and metrics
+	Admin     middleware.Chain // Admin and debug endpoints
+	Static    middleware.Chain // Static assets
+	Pages     middleware.Chain // HTML pages
+	Fragments middleware.Chain // HTML fragments htmx swaps into pages
+	Forms     middleware.Chain // HTML form submissions
+	Login     middleware.Chain // Login page and the sign-in and sign-out forms
+	API       middleware.Chain // JSON API
+	CalDAV    middleware.Chain // CalDAV calendar
 
+	// Workspaces holds the chain applied to the API of each workspace,
+	// after API, by workspace name.
+	Workspaces map[string]middleware.Chain
+
+	// Deprecations marks the deprecated routes, such as legacyExport.
+	Deprecations *middleware.Deprecations
+}
+
+// isLongPoll reports whether r long-polls the changes of a workspace. Long
+// polls wait for most of their time, so they are not held to the
+// concurrency limit: a few idle clients would take every slot otherwise.
+func isLongPoll(r *http.Request) bool {
+	return r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/api/changes")
+}
+
+// legacyExport deprecates GET /api/export, the former path of GET
+// /api/tasks/export. The successor is relative, so it resolves to the path
+// of the same workspace.
+var legacyExport = middleware.Deprecation{
+	Since:     time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+	Sunset:    time.Date(2027, 4, 16, 0, 0, 0, 0, time.UTC),
+	Successor: "tasks/export",
+}
+
+// defaultMiddlewares builds the production middleware chains from the configuration.
+func defaultMiddlewares(application *app.App) Middlewares {
+	c := application.Config()
+
+	// Shared by pages and API so the limit applies to their combined load.
+	concurrencyLimit := middleware.ConcurrencyLimit(c.MaxConcurrentRequests, application.Metrics(), isLongPoll)
+	// Turns away changes in maintenance mode, and has pages show a banner.
+	maintenance := middleware.Maintenance(application.Maintenance())
+	// Read-only mode turns away changes for good, so it answers them
+	// before maintenance mode, which ends.
+	writes := maintenance
+	if c.ReadOnly {
+		writes = middleware.NewChain(middleware.ReadOnly, maintenance).Then
+	}
+
+	// Pages show the preferences of their user, if any, but are served
+	// without one too unless authentication is required, which sends
+	// browsers to the login page first.
+	pages := middleware.NewChain(
+		concurrencyLimit,
+		maintenance,
+		middleware.Authenticate(application.Auth(), false),
+	)
+	if c.AuthRequired {
+		pages = pages.Append(middleware.RequireLogin("/login"))
+	}
+
+	api := middleware.NewChain(
+		concurrencyLimit,
+		middleware.CORS(c.CORSAllowedOrigins),
+		writes,
+		application.RateLimiter().Middleware,
+		middleware.Authenticate(application.Auth(), c.AuthRequired),
+	)
+	// Captured requests include those the API chain turns away, such as
+	// with a 429 or 401.
+	if c.CaptureEnabled() {
+		api = middleware.NewChain(middleware.Capture(application.Captures())).Append(api...)
+	}
+
+	return Middlewares{
+		// Recovery is innermost so logging and metrics observe the 500 it produces.
+		Common: middleware.NewChain(
+			middleware.RequestID,
+			middleware.RealIP(c.TrustedProxies),
+			middleware.Trace,
+			middleware.Logging(application.Logger()),
+			middleware.Metrics(application.Metrics()),
+			middleware.SlowRequests(c.SlowRequestThreshold, application.Logger()),
+			middleware.Failover,
+			middleware.Recovery(application.Logger(), application.Metrics(), application.ErrorReporter()),
+			middleware.Compress(c.CompressionMinSize),
+		),
+		Admin: middleware.NewChain(
+			middleware.BearerToken(c.AdminToken),
+		),
+		Pages: pages,
+		// Fragments change tasks like the API does, so they are rate
+		// limited and authenticated like it.
+		Fragments: middleware.NewChain(
+			concurrencyLimit,
+			writes,
+			middleware.HTMXOnly,
+			application.RateLimiter().Middleware,
+			middleware.Authenticate(application.Auth(), c.AuthRequired),
+		),
+		// Plain forms cannot be told apart from those of other sites by a
+		// header htmx sets, so cross-origin submissions are rejected by
+		// their Sec-Fetch-Site and Origin headers.
+		Forms: middleware.NewChain(
+			concurrencyLimit,
+			writes,
+			http.NewCrossOriginProtection().Handler,
+			application.RateLimiter().Middleware,
+			middleware.Authenticate(application.Auth(), c.AuthRequired),
+		),
+		// Signing in is rate limited against guessing API keys; the user, if
+		// any, is only read to send signed-in browsers on.
+		Login: middleware.NewChain(
+			concurrencyLimit,
+			http.NewCrossOriginProtection().Handler,
+			application.RateLimiter().Middleware,
+			middleware.Authenticate(application.Auth(), false),
+		),
+		API: api,
+		// Calendar applications sync on their own schedule, so they are
+		// neither rate limited nor subject to CORS.
+		CalDAV: middleware.NewChain(
+			concurrencyLimit,
+			writes,
+			middleware.BasicAuth(application.Auth(), c.AuthRequired, "tasks"),
+		),
+		Deprecations: middleware.NewDeprecations(application.Logger(), application.Metrics()),
+	}
+}
+
+// registerInternalRoutes registers the operational and admin endpoints,
+// workspaces starting with the default one. The admin overview reports
+// workspaces and the requests tracker counted.
+func registerInternalRoutes(r *mux.Router, application *app.App, workspaces []oldhandler.WorkspaceSource, tracker *activity.Tracker, mw Middlewares) {
+	// Operational endpoints
+	ops := r.NewRoute().Subrouter()
+	ops.Use(mw.Common.Append(mw.Ops...).Then)
+	ops.HandleFunc("/health", oldhandler.HealthHandler(application)).Methods("GET")
+	ops.HandleFunc("/health/ready", oldhandler.ReadinessHandler(application)).Methods("GET")
+	ops.HandleFunc("/version", oldhandler.VersionHandler()).Methods("GET")
+	ops.Handle("/metrics", application.Metrics().Handler()).Methods("GET")
+
+	// Admin endpoints
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(mw.Common.Append(mw.Admin...).Then)
+	admin.HandleFunc("/loglevel", oldhandler.LogLevelHandler(application)).Methods("GET", "PUT")
+	admin.HandleFunc("/maintenance", oldhandler.MaintenanceHandler(application)).Methods("GET", "PUT")
+	if application.Config().Environment != app.Prod {
+		admin.HandleFunc("/faults", oldhandler.FaultsHandler(application)).Methods("GET", "PUT")
+	}
+	admin.HandleFunc("/users", oldhandler.UsersHandler(application)).Methods("GET", "POST")
+	admin.HandleFunc("/users/{user}/disable", oldhandler.DisableUserHandler(application)).Methods("POST")
+	admin.HandleFunc("/users/{user}/preferences", oldhandler.PreferencesHandler(application)).Methods("PUT")
+	admin.HandleFunc("/users/{user}/workspaces", oldhandler.WorkspacesHandler(application)).Methods("PUT")
+	admin.HandleFunc("/users/{user}/keys", oldhandler.IssueKeyHandler(application)).Methods("POST")
+	admin.HandleFunc("/keys", oldhandler.KeysHandler(application)).Methods("GET")
+	admin.HandleFunc("/keys/{id}", oldhandler.RevokeKeyHandler(application)).Methods("DELETE")
+	admin.HandleFunc("/sessions", oldhandler.SessionsHandler(application)).Methods("GET")
+	admin.HandleFunc("/jobs", oldhandler.JobsHandler(application)).Methods("GET")
+	admin.HandleFunc("/jobs/{id}/retry", oldhandler.RetryJobHandler(application)).Methods("POST")
+	admin.HandleFunc("/requests", oldhandler.RequestsHandler(application)).Methods("GET", "DELETE")
+	admin.HandleFunc("/requests/token", oldhandler.CaptureTokenHandler(application)).Methods("POST")
+	// Bundles carry and replace every task, so they are left out entirely
+	// rather than refused when no admin token guards them.
+	if application.Config().AdminToken != "" {
+		admin.HandleFunc("/export", oldhandler.ExportBundleHandler(application, workspaces)).Methods("POST")
+		admin.HandleFunc("/import", oldhandler.ImportBundleHandler(application, workspaces)).Methods("POST")
+	}
+	admin.HandleFunc("/api/workspaces", oldhandler.WorkspacesOverviewHandler(application, workspaces, tracker)).Methods("GET")
+	admin.HandleFunc("/api/users", oldhandler.UsersOverviewHandler(application, tracker)).Methods("GET")
+	if workspaces[0].Retention != nil {
+		admin.HandleFunc("/retention/preview", oldhandler.RetentionPreviewHandler(application, workspaces)).Methods("GET")
+	}
+}
+
+// registerDebugRoutes registers the pprof endpoints behind the admin middleware.
+// They are only served on the dedicated admin listener, never on the public one.
+func registerDebugRoutes(r *mux.Router, mw Middlewares) {
+	debug := r.PathPrefix("/debug/pprof").Subrouter()
+	debug.Use(mw.Common.Append(mw.Admin...).Then)
+	debug.HandleFunc("/cmdline", pprof.Cmdline).Methods("GET")
+	debug.HandleFunc("/profile", pprof.Profile).Methods("GET")
+	debug.HandleFunc("/symbol", pprof.Symbol).Methods("GET", "POST")
+	debug.HandleFunc("/trace", pprof.Trace).Methods("GET")
+	debug.PathPrefix("/").HandlerFunc(pprof.Index).Methods("GET")
+}
+
+// registerDevRoutes registers development helpers. They must be registered
+// before registerRoutes, whose /api subrouter answers every /api path.
+func registerDevRoutes(r *mux.Router, apiHandler *handler.APIHandler, mw Middlewares) {
+	dev := r.PathPrefix("/api/dev").Subrouter()
+	dev.Use(mw.Common.Append(mw.API...).Then)
+	dev.HandleFunc("/seed", apiHandler.SeedTasks).Methods("POST")
+}
+
+// registerRoutes registers the public routes: static files, pages and the
+// API of the default workspace. The push subscription endpoints are left out
+// when pushHandler is nil, and the login page when loginHandler is.
+func registerRoutes(r *mux.Router, staticAssets *assets.Assets, pageHandler *handler.PageHandler, apiHandler *handler.APIHandler, importHandler *handler.ImportHandler, exportHandler *handler.ExportHandler, preferencesHandler *handler.PreferencesHandler, pushHandler *handler.PushHandler, webhookHandler *handler.WebhookHandler, eventHandler *handler.EventHandler, loginHandler *handler.LoginHandler, mw Middlewares) {
 	// Static files
-	staticDir := http.Dir("static")
-	staticHandler := http.StripPrefix("/static/", http.FileServer(staticDir))
-	r.PathPrefix("/static/").Handler(staticHandler)
+	staticHandler := http.StripPrefix("/static/", staticAssets.Handler())
+	r.PathPrefix("/static/").Handler(mw.Common.Append(mw.Static...).Then(staticHandler))
 
 	// Page routes (HTML)
-	r.HandleFunc("/", pageHandler.ServeTaskList).Methods("GET")
+	pages := r.NewRoute().Subrouter()
+	pages.Use(mw.Common.Append(mw.Pages...).Then)
+	pages.HandleFunc("/", pageHandler.ServeTaskList).Methods("GET")
+	pages.HandleFunc("/board", pageHandler.ServeBoard).Methods("GET")
+	pages.HandleFunc("/dashboard", pageHandler.ServeDashboard).Methods("GET")
+	pages.HandleFunc("/tasks/{id}/edit", pageHandler.EditTaskPage).Methods("GET")
+
+	// Login routes (HTML)
+	if loginHandler != nil {
+		login := r.NewRoute().Subrouter()
+		login.Use(mw.Common.Append(mw.Login...).Then)
+		login.HandleFunc("/login", loginHandler.ServeLogin).Methods("GET")
+		login.HandleFunc("/login", loginHandler.Login).Methods("POST")
+		login.HandleFunc("/logout", loginHandler.Logout).Methods("POST")
+	}
+
+	// Form routes (HTML)
+	forms := r.NewRoute().Subrouter()
+	forms.Use(mw.Common.Append(mw.Forms...).Then)
+	forms.HandleFunc("/tasks/{id}/edit", pageHandler.UpdateTask).Methods("POST")
+	forms.HandleFunc("/preferences", preferencesHandler.SavePreferences).Methods("POST")
+
+	// Fragment routes (HTML for htmx)
+	fragments := r.PathPrefix("/fragments").Subrouter()
+	fragments.Use(mw.Common.Append(mw.Fragments...).Then)
+	fragments.HandleFunc("/tasks", pageHandler.TaskListFragment).Methods("GET")
+	fragments.HandleFunc("/tasks", pageHandler.CreateTaskFragment).Methods("POST")
+	fragments.HandleFunc("/tasks/new", pageHandler.TaskFormFragment).Methods("GET")
+	fragments.HandleFunc("/tasks/{id}", pageHandler.TaskFragment).Methods("GET")
+	fragments.HandleFunc("/tasks/{id}", pageHandler.DeleteTaskFragment).Methods("DELETE")
+	fragments.HandleFunc("/tasks/{id}/toggle", pageHandler.ToggleTaskFragment).Methods("PATCH")
 
 	// API routes (JSON)
-	api := r.PathPrefix("/api").Subrouter()
+	if webhookHandler != nil {
+		registerWebhookRoutes(r.PathPrefix("/api/webhooks").Subrouter(), webhookHandler, mw)
+	}
+	registerAPIRoutes(r.PathPrefix("/api").Subrouter(), mw.Common.Append(mw.API...).Append(mw.Workspaces[store.DefaultWorkspace]...), apiHandler, importHandler, exportHandler, preferencesHandler, pushHandler, eventHandler, mw.Deprecations)
+}
+
+// registerAPIRoutes registers the JSON API on api, wrapped in chain, with
+// the deprecated routes marked by deprecations and JSON request bodies
+// validated against their schema.Schema. The push subscription
+// endpoints are left out when pushHandler is nil, and event replays when
+// eventHandler is.
+func registerAPIRoutes(api *mux.Router, chain middleware.Chain, apiHandler *handler.APIHandler, importHandler *handler.ImportHandler, exportHandler *handler.ExportHandler, preferencesHandler *handler.PreferencesHandler, pushHandler *handler.PushHandler, eventHandler *handler.EventHandler, deprecations *middleware.Deprecations) {
+	api.Use(chain.Then)
+	// Router middleware only wraps matched routes, so the error handlers get the chain explicitly.
+	unmatched := chain.Then(unmatchedHandler(api, apiHandler.NotFound, apiHandler.MethodNotAllowed))
+	api.NotFoundHandler = unmatched
+	api.MethodNotAllowedHandler = unmatched
+	api.Methods("OPTIONS").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// CORS preflight requests are answered by the CORS middleware.
+		w.WriteHeader(http.StatusNoContent)
+	})
+	// validated checks the JSON bodies of a route against the named schema.
+	validated := func(name string, h http.HandlerFunc) http.Handler {
+		return middleware.ValidateJSON(schema.MustLookup(name))(h)
+	}
 	api.HandleFunc("/tasks", apiHandler.GetTasks).Methods("GET")
-	api.HandleFunc("/tasks", apiHandler.CreateTask).Methods("POST")
+	api.Handle("/tasks", validated("new-task", apiHandler.CreateTask)).Methods("POST")
+	api.Handle("/tasks/reprioritize", validated("reprioritize", apiHandler.Reprioritize)).Methods("POST")
+	api.HandleFunc("/tasks/export", exportHandler.ExportTasks).Methods("GET")
 	api.HandleFunc("/tasks/{id}/toggle", apiHandler.ToggleTask).Methods("PATCH")
 	api.HandleFunc("/tasks/{id}", apiHandler.DeleteTask).Methods("DELETE")
+	api.Handle("/tasks/{id}/merge", validated("merge", apiHandler.MergeTask)).Methods("POST")
+	api.Handle("/tasks/{id}/priority", validated("priority", apiHandler.ChangePriority)).Methods("POST")
+	api.HandleFunc("/tasks/{id}/history", apiHandler.GetHistory).Methods("GET")
+	api.Handle("/tasks/{id}/lock", validated("lock", apiHandler.LockTask)).Methods("POST")
+	api.HandleFunc("/tasks/{id}/lock", apiHandler.UnlockTask).Methods("DELETE")
+	api.HandleFunc("/changes", apiHandler.GetChanges).Methods("GET")
+	api.HandleFunc("/activity", apiHandler.GetActivity).Methods("GET")
+	api.HandleFunc("/tombstones", apiHandler.GetTombstones).Methods("GET")
+	api.HandleFunc("/stats", apiHandler.GetStats).Methods("GET")
+	api.HandleFunc("/meta", apiHandler.GetMeta).Methods("GET")
+	api.HandleFunc("/usage", apiHandler.GetUsage).Methods("GET")
+	api.HandleFunc("/schemas", apiHandler.ListSchemas).Methods("GET")
+	api.HandleFunc("/schemas/{name}", apiHandler.GetSchema).Methods("GET")
+	api.HandleFunc("/import/ics", importHandler.ImportICS).Methods("POST")
+	api.Handle("/export", deprecations.Route("GET /api/export", legacyExport)(http.HandlerFunc(exportHandler.ExportTasks))).Methods("GET")
+	api.HandleFunc("/preferences", preferencesHandler.GetPreferences).Methods("GET")
+	api.Handle("/preferences", validated("preferences", preferencesHandler.UpdatePreferences)).Methods("PUT")
+	api.HandleFunc("/users/me/preferences", preferencesHandler.GetNotificationPreferences).Methods("GET")
+	api.Handle("/users/me/preferences", validated("notification-preferences", preferencesHandler.UpdateNotificationPreferences)).Methods("PUT")
+	if pushHandler != nil {
+		api.HandleFunc("/push/key", pushHandler.GetPublicKey).Methods("GET")
+		api.Handle("/push/subscriptions", validated("push-subscription", pushHandler.Subscribe)).Methods("POST")
+		api.Handle("/push/subscriptions", validated("push-unsubscribe", pushHandler.Unsubscribe)).Methods("DELETE")
+	}
+	if eventHandler != nil {
+		api.HandleFunc("/events/replay", eventHandler.Replay).Methods("GET")
+	}
+}
+
+// registerWebhookRoutes registers the webhooks of a workspace and their
+// deliveries on webhooks, for the admin token only: deliveries hold the
+// events of every task, and redelivering sends them again. Like
+// registerDevRoutes they must be registered before the /api subrouter of
+// the workspace, whose chain would turn the admin token away as an unknown
+// API key.
+func registerWebhookRoutes(webhooks *mux.Router, webhookHandler *handler.WebhookHandler, mw Middlewares) {
+	webhooks.Use(mw.Common.Append(mw.Admin...).Then)
+	webhooks.HandleFunc("", webhookHandler.ListWebhooks).Methods("GET")
+	webhooks.HandleFunc("/{id}/deliveries", webhookHandler.ListDeliveries).Methods("GET")
+	webhooks.HandleFunc("/{id}/deliveries/{seq}/redeliver", webhookHandler.Redeliver).Methods("POST")
+}
+
+// workspaceHandlers serve the API of a workspace other than the default one.
+type workspaceHandlers struct {
+	name     string
+	api      *handler.APIHandler
+	imports  *handler.ImportHandler
+	exports  *handler.ExportHandler
+	webhooks *handler.WebhookHandler // Nil without webhooks
+	events   *handler.EventHandler   // Nil without an event history
+}
+
+// registerWorkspaceRoutes registers the API of every workspace under
+// /w/{workspace}/api and, for requests with the X-Workspace header, under
+// /api, serving members only. Other workspaces get a 404, except that the
+// header may name the default workspace, which registerRoutes serves. They
+// must be registered before registerDevRoutes and registerRoutes, whose
+// /api subrouters answer every /api path. Preferences and push
+// subscriptions belong to users, so they are the same in every workspace.
+func registerWorkspaceRoutes(r *mux.Router, workspaces []workspaceHandlers, preferencesHandler *handler.PreferencesHandler, pushHandler *handler.PushHandler, mw Middlewares) {
+	for _, ws := range workspaces {
+		if ws.webhooks != nil {
+			registerWebhookRoutes(r.PathPrefix("/w/"+ws.name+"/api/webhooks").Subrouter(), ws.webhooks, mw)
+			registerWebhookRoutes(r.PathPrefix("/api/webhooks").Headers(middleware.WorkspaceHeader, ws.name).Subrouter(), ws.webhooks, mw)
+		}
+		chain := mw.Common.Append(mw.API...).Append(middleware.RequireMember(ws.name)).Append(mw.Workspaces[ws.name]...)
+		registerAPIRoutes(r.PathPrefix("/w/"+ws.name+"/api").Subrouter(), chain, ws.api, ws.imports, ws.exports, preferencesHandler, pushHandler, ws.events, mw.Deprecations)
+		registerAPIRoutes(r.PathPrefix("/api").Headers(middleware.WorkspaceHeader, ws.name).Subrouter(), chain, ws.api, ws.imports, ws.exports, preferencesHandler, pushHandler, ws.events, mw.Deprecations)
+	}
+
+	unknown := mw.Common.Append(mw.API...).Then(http.HandlerFunc(middleware.UnknownWorkspace))
+	r.PathPrefix("/w/").Handler(unknown)
+	r.PathPrefix("/api").MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+		name := r.Header.Get(middleware.WorkspaceHeader)
+		return name != "" && name != store.DefaultWorkspace
+	}).Handler(unknown)
+}
+
+// registerCalDAVRoutes registers the CalDAV calendar and its discovery URL.
+func registerCalDAVRoutes(r *mux.Router, calDAVHandler *handler.CalDAVHandler, mw Middlewares) {
+	chain := mw.Common.Append(mw.CalDAV...)
+	r.Handle("/.well-known/caldav", chain.Then(http.HandlerFunc(calDAVHandler.WellKnown)))
+
+	// Paths are relative to the prefix, as in handler.CalDAVRoot and CalDAVCalendar.
+	dav := r.PathPrefix("/caldav").Subrouter()
+	dav.Use(chain.Then)
+	dav.Methods("OPTIONS").HandlerFunc(calDAVHandler.Options)
+	dav.HandleFunc("/", calDAVHandler.PropfindRoot).Methods("PROPFIND")
+	dav.HandleFunc("/tasks/", calDAVHandler.PropfindCalendar).Methods("PROPFIND")
+	dav.HandleFunc("/tasks/", calDAVHandler.Report).Methods("REPORT")
+	dav.HandleFunc("/tasks/{name}", calDAVHandler.PropfindTask).Methods("PROPFIND")
+	dav.HandleFunc("/tasks/{name}", calDAVHandler.GetTask).Methods("GET")
+	dav.HandleFunc("/tasks/{name}", calDAVHandler.PutTask).Methods("PUT")
+	dav.HandleFunc("/tasks/{name}", calDAVHandler.DeleteTask).Methods("DELETE")
+}
+
+// probeMethods are the methods tried when looking for routes matching a path.
+var probeMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// unmatchedHandler answers requests that router could not route. When the
+// path is served under other methods, it sets the Allow header and calls
+// methodNotAllowed; otherwise it calls notFound. The router is probed per
+// method because mux does not reliably report method mismatches on
+// subrouters with several routes.
+func unmatchedHandler(router *mux.Router, notFound, methodNotAllowed http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range probeMethods {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+
+			var match mux.RouteMatch
+			if router.Match(probe, &match) && match.MatchErr == nil {
+				allowed = append(allowed, method)
+			}
+		}
+
+		if len(allowed) == 0 {
+			notFound(w, r)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		methodNotAllowed(w, r)
+	})
 }
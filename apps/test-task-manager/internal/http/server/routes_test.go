@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/errorreport"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/handler"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/http/middleware"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestUnmatchedHandler(t *testing.T) {
+	apiHandler := handler.NewAPIHandler(service.NewTaskService(store.NewTaskStore()), errorreport.Nop{})
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	// The chain marks responses, so the error handlers are seen to be wrapped in it.
+	chain := middleware.NewChain(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Chain", "api")
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(chain.Then)
+	unmatched := chain.Then(unmatchedHandler(api, apiHandler.NotFound, apiHandler.MethodNotAllowed))
+	api.NotFoundHandler = unmatched
+	api.MethodNotAllowedHandler = unmatched
+	api.HandleFunc("/tasks", ok).Methods("GET")
+	api.HandleFunc("/tasks", ok).Methods("POST")
+	api.HandleFunc("/tasks/{id}", ok).Methods("DELETE")
+	api.HandleFunc("/tasks/{id}/toggle", ok).Methods("PATCH")
+
+	tests := []struct {
+		method, path string
+		status       int
+		code, allow  string
+	}{
+		{"GET", "/api/tasks", http.StatusOK, "", ""},
+		{"GET", "/api/nope", http.StatusNotFound, "NOT_FOUND", ""},
+		{"GET", "/api/tasks/1/nope", http.StatusNotFound, "NOT_FOUND", ""},
+		{"PUT", "/api/tasks", http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "GET, POST"},
+		{"GET", "/api/tasks/1", http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "DELETE"},
+		{"POST", "/api/tasks/1/toggle", http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "PATCH"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d: %s", tt.method, tt.path, tt.status, w.Code, w.Body)
+			continue
+		}
+		if w.Header().Get("X-Chain") != "api" {
+			t.Errorf("%s %s: expected the response to pass the chain", tt.method, tt.path)
+		}
+		if allow := w.Header().Get("Allow"); allow != tt.allow {
+			t.Errorf("%s %s: expected Allow %q, got %q", tt.method, tt.path, tt.allow, allow)
+		}
+		if tt.code == "" {
+			continue
+		}
+		var e handler.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.Code != tt.code || e.Error == "" {
+			t.Errorf("%s %s: expected a JSON error %s, got %v: %s", tt.method, tt.path, tt.code, err, w.Body)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %s: expected a JSON content type, got %q", tt.method, tt.path, ct)
+		}
+	}
+}
@@ -1,31 +1,669 @@
 package server
 
 import (
-	"gitlab.com/btcdirect-api/go-modules/http"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/activity"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/archive"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/assets"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/auth"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/caldav"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/cron"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/events"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/failover"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/faults"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/handler"
+	oldhandler "gitlab.com/btcdirect-api/test-task-manager/internal/http/handler"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/http/middleware"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/notify"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/seed"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/timing"
+	"go.uber.org/zap"
 )
 
 type Server interface {
 	Shutdown()
 }
 
+// servers shuts down several HTTP servers together.
+type servers []*httpServer
+
+// Shutdown shuts all servers down concurrently.
+func (s servers) Shutdown() {
+	var wg sync.WaitGroup
+	for _, srv := range s {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			srv.Shutdown()
+		}()
+	}
+	wg.Wait()
+}
+
+// instance is a started application: its servers, the stores of its
+// workspaces and its background workers.
+type instance struct {
+	servers servers
+	stores  []store.Store
+	workers []func() // Stop the enabled background workers
+	logger  *zap.SugaredLogger
+}
+
+// Shutdown stops the servers and the background workers, then closes the
+// stores they were using.
+func (i instance) Shutdown() {
+	i.servers.Shutdown()
+	for _, stop := range i.workers {
+		stop()
+	}
+	for _, s := range i.stores {
+		if err := store.Close(s); err != nil {
+			i.logger.Warnw("failed to close store", "error", err)
+		}
+	}
+}
+
 // Start Creates a new HTTP server, registers routes and starts it.
 // Do not forget to call Shutdown() on the server when shutting down.
 func Start(application *app.App) Server {
-	s := http.CreateServer(application.Config().HTTPPort, application.Logger())
+	i := build(application)
+	for _, srv := range i.servers {
+		srv.Start(application.Upgrader().Listen)
+	}
+	return i
+}
+
+// Handler builds the application as Start does without listening, and
+// returns the handler of the public listener, to serve it in-process in
+// tests. Call Shutdown on the returned server to stop the workers and close
+// the store.
+func Handler(application *app.App) (http.Handler, Server) {
+	i := build(application)
+	return i.servers[0].Router, i
+}
+
+// build opens the stores, starts the enabled background workers and
+// registers the routes of every server, without starting the servers. The
+// public server comes first.
+func build(application *app.App) instance {
+	c := application.Config()
+	timeouts := Timeouts{
+		Read:       c.HTTPReadTimeout,
+		ReadHeader: c.HTTPReadHeaderTimeout,
+		Write:      c.HTTPWriteTimeout,
+		Idle:       c.HTTPIdleTimeout,
+	}
+	network, addr := c.ListenAddress()
+	s := newHTTPServer(network, addr, timeouts, application.ShutdownTimeout(), application.Logger())
+	started := servers{s}
 
 	// Initialize task manager components
-	taskStore := store.NewTaskStore()
-	taskService := service.NewTaskService(taskStore)
-	pageHandler := handler.NewPageHandler(taskService)
-	apiHandler := handler.NewAPIHandler(taskService)
+	backend := openStore(application, store.DefaultWorkspace)
+	application.Logger().Infow("opened store", "store", c.Store, "workspaces", len(c.Workspaces))
+
+	taskStore, storeCritical := serviceStore(application, backend, store.DefaultWorkspace)
+	serviceOpts := []service.Option{
+		service.WithMetrics(application.Metrics()),
+		service.WithTitleLimits(service.TitleLimits{Min: c.TitleMinLength, Max: c.TitleMaxLength}),
+		service.WithPalette(c.Colors()),
+		service.WithDefaults(c.TaskDefaults()),
+		service.WithStaleAfter(time.Duration(c.StaleAfterDays) * 24 * time.Hour),
+		service.WithUserQuota(c.UserTaskQuota),
+	}
+	if c.UniqueTitles {
+		serviceOpts = append(serviceOpts, service.WithUniqueTitles())
+	}
+	if len(c.WIPLimits) > 0 {
+		serviceOpts = append(serviceOpts, service.WithWIPLimits(c.WIP(), c.WIPMode == "warn"))
+	}
+	// The plans of workspaces limit their tasks in the service, their
+	// requests in middleware and are reported by the API handler.
+	quotas := make(map[string]service.Option)
+	planLimits := make(map[string]middleware.Chain)
+	usages := make(map[string]handler.APIOption)
+	for _, name := range append([]string{store.DefaultWorkspace}, c.Workspaces...) {
+		quotas[name], planLimits[name], usages[name] = workspacePlan(c, name)
+	}
+
+	taskService := service.NewTaskService(taskStore, slices.Concat(serviceOpts, []service.Option{quotas[store.DefaultWorkspace], service.WithWorkspace(store.DefaultWorkspace)})...)
+
+	application.Health().Register(component("store", store.DefaultWorkspace), storeCritical, func(ctx context.Context) error {
+		return taskStore.Ping(ctx)
+	})
+
+	// Other workspaces get stores and services of their own, configured
+	// like those of the default workspace, with their metrics labeled by
+	// workspace.
+	stores := []store.Store{backend}
+	overview := []oldhandler.WorkspaceSource{{Name: store.DefaultWorkspace, Plan: c.PlanOf(store.DefaultWorkspace).Name, Store: backend, Service: taskService}}
+	workspaceServices := make(map[string]*service.TaskService, len(c.Workspaces))
+	for _, name := range c.Workspaces {
+		wsBackend := openStore(application, name)
+		stores = append(stores, wsBackend)
+		wsStore, wsCritical := serviceStore(application, wsBackend, name)
+		workspaceServices[name] = service.NewTaskService(wsStore, slices.Concat(serviceOpts, []service.Option{quotas[name], service.WithWorkspace(name)})...)
+		overview = append(overview, oldhandler.WorkspaceSource{Name: name, Plan: c.PlanOf(name).Name, Store: wsBackend, Service: workspaceServices[name]})
+		application.Health().Register(component("store", name), wsCritical, func(ctx context.Context) error {
+			return wsStore.Ping(ctx)
+		})
+	}
+
+	if c.Fixtures != "" {
+		result, err := seed.LoadFixtures(context.Background(), taskService, c.Fixtures, time.Now())
+		if err != nil {
+			application.Logger().Fatalw("failed to load fixtures", "fixtures", c.Fixtures, "error", err)
+		}
+		application.Logger().Infow("loaded fixtures", "fixtures", c.Fixtures, "created", result.Created, "skipped", result.Skipped)
+	}
+
+	// The background workers serve every workspace.
+	var workers []func()
+	var webhookHandlers map[string]*handler.WebhookHandler
+	var eventHandlers map[string]*handler.EventHandler
+	if c.EventsEnabled() {
+		var stop func()
+		stop, webhookHandlers, eventHandlers = startEvents(application, overview)
+		workers = append(workers, stop)
+	}
+	var pushHandler *handler.PushHandler
+	if c.NotificationsEnabled() || c.EscalationEnabled() {
+		var stop func()
+		stop, pushHandler = startNotifications(application, overview)
+		workers = append(workers, stop)
+	}
+	if c.ArchiveEnabled() {
+		stop, retentions := startArchival(application, overview)
+		for i := range overview {
+			overview[i].Retention = retentions[overview[i].Name]
+		}
+		workers = append(workers, stop)
+	}
+
+	staticAssets, err := assets.New("static", "/static/")
+	if err != nil {
+		application.Logger().Fatalw("failed to load static assets", "error", err)
+	}
+
+	pageOpts := []handler.PageOption{
+		handler.WithRenderCache(c.ResponseCacheTTL, application.Metrics()),
+		handler.WithPageSize(c.ListLimit, c.MaxListLimit),
+	}
+	if c.ReadOnly {
+		pageOpts = append(pageOpts, handler.WithReadOnly())
+	}
+	pageHandler := handler.NewPageHandler(taskService, application.ErrorReporter(), staticAssets, pageOpts...)
+	apiHandler := handler.NewAPIHandler(taskService, application.ErrorReporter(),
+		handler.WithResponseCache(c.ResponseCacheTTL, application.Metrics()),
+		handler.WithListLimit(c.ListLimit, c.MaxListLimit),
+		usages[store.DefaultWorkspace])
+
+	mw := defaultMiddlewares(application)
+	// The requests to every workspace are counted for the admin overview
+	// before its plan can turn them away.
+	tracker := activity.NewTracker()
+	mw.Workspaces = make(map[string]middleware.Chain, len(planLimits))
+	for name, limit := range planLimits {
+		mw.Workspaces[name] = middleware.NewChain(middleware.TrackActivity(tracker, name)).Append(limit...)
+	}
+
+	// Operational and admin endpoints move to their own listener when configured.
+	if c.AdminListen == "" {
+		registerInternalRoutes(s.Router, application, overview, tracker, mw)
+	} else {
+		adminNetwork, adminAddr, _ := app.ParseListen(c.AdminListen)
+		adminTimeouts := timeouts
+		adminTimeouts.Write = 0 // CPU profiles and traces stream for longer than the write timeout
+		admin := newHTTPServer(adminNetwork, adminAddr, adminTimeouts, application.ShutdownTimeout(), application.Logger())
+
+		registerInternalRoutes(admin.Router, application, overview, tracker, mw)
+		registerDebugRoutes(admin.Router, mw)
+		started = append(started, admin)
+	}
+
+	// The UIDs of imported tasks are linked like those of tasks created by
+	// CalDAV clients, so neither imports them twice, and exported under the
+	// UIDs CalDAV serves them with.
+	links, err := caldav.NewLinks(c.CalDAVLinkFile)
+	if err != nil {
+		application.Logger().Fatalw("failed to open CalDAV links", "file", c.CalDAVLinkFile, "error", err)
+	}
+	importHandler := handler.NewImportHandler(taskService, links, c.CalDAVLocation(), application.ErrorReporter())
+	exportHandler := handler.NewExportHandler(taskService, links, application.ErrorReporter())
+	var workspaces []workspaceHandlers
+	for _, name := range c.Workspaces {
+		wsLinks := links
+		if c.CalDAVLinkFile != "" {
+			// Task IDs are only unique within a workspace, so are links
+			file := store.WorkspaceDSN(store.BackendFile, c.CalDAVLinkFile, name)
+			if wsLinks, err = caldav.NewLinks(file); err != nil {
+				application.Logger().Fatalw("failed to open CalDAV links", "file", file, "error", err)
+			}
+		}
+		workspaces = append(workspaces, workspaceHandlers{
+			name: name,
+			api: handler.NewAPIHandler(workspaceServices[name], application.ErrorReporter(),
+				handler.WithResponseCache(c.ResponseCacheTTL, application.Metrics()),
+				handler.WithListLimit(c.ListLimit, c.MaxListLimit),
+				usages[name]),
+			imports:  handler.NewImportHandler(workspaceServices[name], wsLinks, c.CalDAVLocation(), application.ErrorReporter()),
+			exports:  handler.NewExportHandler(workspaceServices[name], wsLinks, application.ErrorReporter()),
+			webhooks: webhookHandlers[name],
+			events:   eventHandlers[name],
+		})
+	}
+	if c.CalDAVEnabled {
+		calDAVHandler := handler.NewCalDAVHandler(taskService, links, c.CalDAVLocation(), application.ErrorReporter())
+		registerCalDAVRoutes(s.Router, calDAVHandler, mw)
+	}
+	preferencesHandler := handler.NewPreferencesHandler(application.Auth(), application.ErrorReporter())
+	var loginHandler *handler.LoginHandler
+	if c.AuthRequired {
+		loginHandler = handler.NewLoginHandler(application.Auth(), pageHandler, c.SessionTTL, application.ErrorReporter())
+	}
+	registerWorkspaceRoutes(s.Router, workspaces, preferencesHandler, pushHandler, mw)
+	if c.Environment == app.Dev {
+		registerDevRoutes(s.Router, apiHandler, mw)
+	}
+	registerRoutes(s.Router, staticAssets, pageHandler, apiHandler, importHandler, exportHandler, preferencesHandler, pushHandler, webhookHandlers[store.DefaultWorkspace], eventHandlers[store.DefaultWorkspace], loginHandler, mw)
+
+	return instance{servers: started, stores: stores, workers: workers, logger: application.Logger()}
+}
+
+// workspacePlan returns the task service option, the API middleware and
+// the API handler option enforcing and reporting the plan of workspace.
+func workspacePlan(c app.Configuration, workspace string) (quota service.Option, limit middleware.Chain, usage handler.APIOption) {
+	plan := c.PlanOf(workspace)
+	var remaining func() int
+	if plan.RateLimit > 0 {
+		limiter := middleware.NewRateLimiter(plan.RateLimit, plan.RateBurst)
+		limit = middleware.NewChain(limiter.Shared(workspace))
+		remaining = func() int { return limiter.Remaining(workspace) }
+	}
+	return service.WithTaskQuota(plan.MaxTasks), limit, handler.WithPlan(workspace, plan, remaining)
+}
+
+// openStore opens the store of workspace as configured and checks that it
+// can serve requests, exiting otherwise.
+func openStore(application *app.App, workspace string) store.Store {
+	c := application.Config()
+	backend, err := store.OpenWorkspace(c.Store, c.StoreDSN, workspace)
+	if err != nil {
+		application.Logger().Fatalw("failed to open store", "store", c.Store, "workspace", workspace, "error", err)
+	}
+	if m, ok := backend.(store.Migrator); ok {
+		if pending, err := m.PendingMigrations(); err != nil || pending > 0 {
+			application.Logger().Fatalw("store schema is not up to date, run the migrate command", "store", c.Store, "workspace", workspace, "pending", pending, "error", err)
+		}
+	}
+	if a, ok := backend.(store.IDAssigner); ok {
+		ids, _ := store.NewIDGenerator(c.IDStrategy) // Checked by Validate
+		a.SetIDGenerator(ids)
+	}
+	if ts, ok := backend.(*store.TaskStore); ok {
+		const mib = 1 << 20
+		ts.SetMemoryLimits(store.MemoryLimits{
+			Soft: int64(c.MemorySoftLimitMB) * mib,
+			Hard: int64(c.MemoryHardLimitMB) * mib,
+			OnSoftLimit: func(used int64) {
+				application.Logger().Warnw("memory store is above its soft limit", "workspace", workspace, "usedMiB", used/mib, "softLimitMiB", c.MemorySoftLimitMB, "hardLimitMiB", c.MemoryHardLimitMB)
+			},
+		})
+		application.Metrics().GaugeFuncVec("task_store_memory_bytes", "Approximate memory used by the tasks of the memory store.", "workspace").Set(func() float64 {
+			return float64(ts.MemoryUsage())
+		}, workspace)
+	}
+	if t, ok := backend.(store.Tombstoner); ok {
+		t.KeepTombstones(c.TombstoneRetention)
+	}
+	if c.EventsEnabled() {
+		backend.(store.Outbox).EnableOutbox() // Checked by Validate
+	}
+	if p, ok := backend.(store.Pooled); ok {
+		p.ConfigurePool(c.PoolConfig())
+		store.RegisterPoolMetrics(application.Metrics(), p, workspace)
+	}
+	return backend
+}
+
+// serviceStore wraps the backend of workspace for its service: faults are
+// injected outside production, reads fail over when enabled, and the calls
+// are timed as the store phase of slow requests. It reports whether the
+// store is critical to health, as withFailover does.
+func serviceStore(application *app.App, backend store.Store, workspace string) (store.Store, bool) {
+	taskStore := backend
+	if application.Config().Environment != app.Prod {
+		taskStore = faults.WrapStore(taskStore, application.Faults())
+	}
+	taskStore, critical := withFailover(application, taskStore, workspace)
+	return timing.WrapStore(taskStore), critical
+}
+
+// withFailover wraps the store of workspace in a failover.Store, loading its
+// snapshot, when store failover is enabled for a backend that can become
+// unreachable. It reports whether the store is critical to health: not
+// when failing over, as the instance keeps serving reads.
+func withFailover(application *app.App, taskStore store.Store, workspace string) (store.Store, bool) {
+	c := application.Config()
+	if !c.StoreFailover || c.Store == store.BackendMemory {
+		return taskStore, true
+	}
+
+	logger := application.Logger()
+	fs := failover.Wrap(taskStore, failover.OnChange(func(down bool) {
+		if down {
+			logger.Errorw("store is unreachable, serving reads from the last known tasks", "store", c.Store, "workspace", workspace)
+		} else {
+			logger.Infow("store is reachable again", "store", c.Store, "workspace", workspace)
+		}
+	}))
+	if err := fs.Load(context.Background()); err != nil {
+		logger.Warnw("failed to load the failover snapshot, loading it with the first read", "store", c.Store, "workspace", workspace, "error", err)
+	}
+	return fs, false
+}
+
+// startArchival starts archiving the completed tasks of every workspace and
+// purging archived ones, as far as enabled, on the archive schedule. Every
+// workspace other than the default one has an archive file of its own,
+// named like its file store. The policies report their last run as
+// non-critical health checks, as tasks only wait longer to be archived
+// while they fail. The returned function stops all of them. The
+// retention policies are returned by workspace, and nil when archived
+// tasks are kept forever.
+func startArchival(application *app.App, workspaces []oldhandler.WorkspaceSource) (stop func(), retentions map[string]*archive.Retention) {
+	c := application.Config()
+	schedule, _ := cron.Parse(c.ArchiveSchedule) // Checked by Validate
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	retentions = make(map[string]*archive.Retention, len(workspaces))
+	for _, ws := range workspaces {
+		path := workspaceFile(c.ArchiveFile, ws.Name)
+		file, err := archive.Open(path)
+		if err != nil {
+			application.Logger().Fatalw("failed to open archive", "file", path, "workspace", ws.Name, "error", err)
+		}
+		logger := application.Logger().With("workspace", ws.Name)
+		if c.ArchiveAfterDays > 0 {
+			policy := archive.NewPolicy(ws.Service, file, days(c.ArchiveAfterDays), ws.Name, logger, application.Metrics())
+			application.Health().Register(component("scheduler/archive", ws.Name), false, policy.Check)
+			wg.Go(func() { policy.Run(ctx, schedule) })
+		}
+		if c.ArchiveRetentionDays > 0 {
+			retention := archive.NewRetention(file, days(c.ArchiveRetentionDays), logger, application.Metrics())
+			retentions[ws.Name] = retention
+			application.Health().Register(component("scheduler/retention", ws.Name), false, retention.Check)
+			wg.Go(func() { retention.Run(ctx, schedule) })
+		}
+	}
+	application.Logger().Infow("archiving completed tasks", "afterDays", c.ArchiveAfterDays, "retentionDays", c.ArchiveRetentionDays, "schedule", c.ArchiveSchedule, "file", c.ArchiveFile, "workspaces", len(workspaces))
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}, retentions
+}
+
+// workspaceFile returns the path of the state file at path for workspace,
+// named like the file store of the workspace, or "" when path is empty.
+func workspaceFile(path, workspace string) string {
+	if path == "" {
+		return ""
+	}
+	return store.WorkspaceDSN(store.BackendFile, path, workspace)
+}
+
+// component returns the name the health check of a component of workspace
+// is registered by: the component for the default workspace, followed by
+// the workspace for the others.
+func component(name, workspace string) string {
+	if workspace == store.DefaultWorkspace {
+		return name
+	}
+	return name + "/" + workspace
+}
+
+// days returns the duration of n days.
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// startEvents starts relaying the task events recorded in the outbox of
+// every workspace to the configured sinks. Every workspace has an event
+// history and webhook deliveries of its own, named like its file store, as
+// the sequence numbers of events are those of its outbox; NATS is shared.
+// The relay and webhooks report their last failure as non-critical health
+// checks, as undelivered events stay in the outbox. The returned function stops relaying; events that were not delivered yet
+// stay in the outbox for the next start. The webhook handlers by workspace
+// are nil without webhooks, and the event handlers without an event history.
+func startEvents(application *app.App, workspaces []oldhandler.WorkspaceSource) (stop func(), webhooks map[string]*handler.WebhookHandler, replays map[string]*handler.EventHandler) {
+	c := application.Config()
+	var nats *events.NATS
+	if c.EventNATSURL != "" {
+		nats, _ = events.NewNATS(c.EventNATSURL, c.EventNATSSubject) // Checked by Validate
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	webhooks = make(map[string]*handler.WebhookHandler, len(workspaces))
+	replays = make(map[string]*handler.EventHandler, len(workspaces))
+	for _, ws := range workspaces {
+		var sinks []events.Sink
+		logger := application.Logger().With("workspace", ws.Name)
+
+		var history *events.History
+		if c.EventHistorySize > 0 {
+			path := workspaceFile(c.EventHistoryFile, ws.Name)
+			var err error
+			history, err = events.OpenHistory(path, c.EventHistorySize)
+			if err != nil {
+				application.Logger().Fatalw("failed to open event history", "file", path, "workspace", ws.Name, "error", err)
+			}
+			replays[ws.Name] = handler.NewEventHandler(history)
+		}
+
+		if len(c.EventWebhookURLs) > 0 {
+			path := workspaceFile(c.EventWebhookStateFile, ws.Name)
+			log, err := events.NewDeliveryLog(path)
+			if err != nil {
+				application.Logger().Fatalw("failed to open webhook deliveries", "file", path, "workspace", ws.Name, "error", err)
+			}
+			hooks := make([]*events.Webhook, len(c.EventWebhookURLs))
+			for i, hook := range c.EventWebhookURLs {
+				hooks[i] = events.NewWebhook(hook, c.EventWebhookSecret, application.HTTPClients().Client("webhook", 0))
+			}
+			dispatcher := events.NewDispatcher(hooks, c.WebhookRetryPolicy(), log, c.OutboundTimeout, ws.Name, logger, application.Metrics())
+			sinks = append(sinks, dispatcher)
+			application.Health().Register(component("webhooks", ws.Name), false, dispatcher.Check)
+			webhooks[ws.Name] = handler.NewWebhookHandler(dispatcher)
+		}
+		if nats != nil {
+			sinks = append(sinks, nats)
+		}
+
+		outbox := workspaceOutbox{Outbox: ws.Store.(store.Outbox), workspace: ws.Name} // Enabled by openStore
+		relay := events.NewRelay(outbox, history, c.EventRelayInterval, c.OutboundTimeout, logger, application.Metrics(), sinks...)
+		application.Health().Register(component("events", ws.Name), false, relay.Check)
+		wg.Go(func() { relay.Run(ctx) })
+	}
+	application.Logger().Infow("relaying task events", "webhooks", len(c.EventWebhookURLs), "nats", nats != nil, "workspaces", len(workspaces))
+
+	return func() {
+		cancel()
+		wg.Wait()
+		if nats != nil {
+			nats.Close()
+		}
+	}, webhooks, replays
+}
+
+// workspaceOutbox sets the workspace of the events of a workspace other
+// than the default one, so sinks shared by the workspaces can tell them
+// apart.
+type workspaceOutbox struct {
+	store.Outbox
+	workspace string
+}
+
+// PendingEvents implements store.Outbox.
+func (o workspaceOutbox) PendingEvents(limit int) ([]store.Event, error) {
+	pending, err := o.Outbox.PendingEvents(limit)
+	if o.workspace != store.DefaultWorkspace {
+		for i := range pending {
+			pending[i].Workspace = o.workspace
+		}
+	}
+	return pending, err
+}
+
+// startNotifications starts notifying about the due and overdue tasks of
+// every workspace through the configured channels, sent by background
+// jobs. The returned function stops scanning; queued notifications are sent
+// while the application shuts down. Overdue tasks are escalated by the
+// configured rules. Every workspace other than the default one keeps the
+// notifications it sent in a state file of its own, named like its file
+// store, and sends its daily digest to its members only. The due watcher
+// and digester report their last run as non-critical health checks. The
+// push handler is nil when web push is disabled.
+func startNotifications(application *app.App, workspaces []oldhandler.WorkspaceSource) (stop func(), push *handler.PushHandler) {
+	c := application.Config()
+	var notifiers []notify.Notifier
+
+	if c.SMTPHost != "" {
+		email, err := notify.NewEmail(notify.EmailConfig{
+			Host:        c.SMTPHost,
+			Port:        c.SMTPPort,
+			Username:    c.SMTPUsername,
+			Password:    c.SMTPPassword,
+			From:        c.SMTPFrom,
+			To:          c.NotifyEmailTo,
+			TemplateDir: c.NotifyTemplateDir,
+		})
+		if err != nil {
+			application.Logger().Fatalw("failed to set up email notifications", "error", err)
+		}
+		notifiers = append(notifiers, email)
+	}
+
+	if c.VAPIDPrivateKey != "" {
+		keys, _ := notify.ParseVAPIDKeys(c.VAPIDPublicKey, c.VAPIDPrivateKey) // Checked by Validate
+		subs, err := notify.NewSubscriptions(c.PushSubscriptionFile)
+		if err != nil {
+			application.Logger().Fatalw("failed to open push subscriptions", "error", err)
+		}
+		application.Metrics().GaugeFunc("push_subscriptions", "Browsers subscribed to web push notifications.", func() float64 {
+			return float64(subs.Len())
+		})
+		notifiers = append(notifiers, notify.NewWebPush(keys, c.VAPIDSubject, subs, application.HTTPClients().Client("webpush", 0), application.Logger()))
+		push = handler.NewPushHandler(subs, keys.PublicKey(), application.ErrorReporter())
+	}
+
+	if c.NtfyURL != "" {
+		events, _ := notify.ParseEvents(c.NtfyEvents) // Checked by Validate
+		ntfy, _ := notify.NewNtfy(c.NtfyURL, c.NtfyToken, events, application.HTTPClients().Client("ntfy", 0))
+		notifiers = append(notifiers, ntfy)
+	}
 
-	registerRoutes(s.Router, application, pageHandler, apiHandler)
+	if c.DiscordWebhookURL != "" {
+		events, _ := notify.ParseEvents(c.DiscordEvents) // Checked by Validate
+		discord, _ := notify.NewDiscord(c.DiscordWebhookURL, events, application.HTTPClients().Client("discord", 0))
+		notifiers = append(notifiers, discord)
+	}
 
-	s.Start()
+	if c.TeamsWebhookURL != "" {
+		events, _ := notify.ParseEvents(c.TeamsEvents) // Checked by Validate
+		teams, _ := notify.NewTeams(c.TeamsWebhookURL, events, application.HTTPClients().Client("teams", 0))
+		notifiers = append(notifiers, teams)
+	}
+
+	notifiers = append(notifiers, notify.NewUserWebhook(application.HTTPClients().PublicClient("user-webhook", 0)))
+
+	dispatcher := notify.NewDispatcher(application.Jobs(), c.OutboundTimeout, application.Logger(), application.Metrics(), notifiers...)
+	dispatcher.NotifyOwners(taskOwners(application.Auth()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	schedule, _ := cron.Parse(c.NotifySchedule) // Checked by Validate
+	var wg sync.WaitGroup
+	for _, ws := range workspaces {
+		tasks := ws.Service
+		path := workspaceFile(c.NotifyStateFile, ws.Name)
+		reminders, err := notify.NewReminders(path)
+		if err != nil {
+			application.Logger().Fatalw("failed to open notification state", "file", path, "workspace", ws.Name, "error", err)
+		}
+		logger := application.Logger().With("workspace", ws.Name)
+		watcher := notify.NewDueWatcher(tasks.Find, dispatcher, c.NotifyDueSoon, reminders, logger)
+		watcher.Escalate(c.Escalations(), tasks.SetPriority, c.EscalationEmailTo)
+		tasks.Observe(dispatcher.Observe)
+		application.Health().Register(component("scheduler/notify", ws.Name), false, watcher.Check)
+		wg.Go(func() { watcher.Run(ctx, schedule) })
+
+		if c.DigestTime != "" {
+			clock, _ := time.Parse("15:04", c.DigestTime) // Checked by Validate
+			daily, _ := cron.Parse(fmt.Sprintf("%d %d * * *", clock.Minute(), clock.Hour()))
+			digester := notify.NewDigester(tasks.GetAll, digestRecipients(application.Auth(), ws.Name), dispatcher, logger)
+			application.Health().Register(component("scheduler/digest", ws.Name), false, digester.Check)
+			wg.Go(func() { digester.Run(ctx, daily, c.DigestLocation()) })
+		}
+	}
+	application.Logger().Infow("sending notifications", "email", c.SMTPHost != "", "webPush", push != nil, "ntfy", c.NtfyURL != "", "discord", c.DiscordWebhookURL != "", "teams", c.TeamsWebhookURL != "", "digestTime", c.DigestTime, "escalationRules", len(c.EscalationRules), "workspaces", len(workspaces))
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}, push
+}
+
+// taskOwners returns how the users who created tasks hear about them, by
+// their notification preferences. Email needs an email address, and users
+// who are disabled hear nothing.
+func taskOwners(users *auth.Store) notify.Owners {
+	return func(userID string) (notify.Owner, bool) {
+		all, err := users.Users()
+		if err != nil {
+			return notify.Owner{}, false
+		}
+		i := slices.IndexFunc(all, func(u auth.User) bool { return u.ID == userID })
+		if i < 0 || all[i].Disabled() {
+			return notify.Owner{}, false
+		}
+		user, prefs := all[i], all[i].Notifications
+		var owner notify.Owner
+		if prefs.Uses(auth.ChannelEmail) {
+			owner.Email = user.Email
+		}
+		if prefs.Uses(auth.ChannelWebhook) {
+			owner.Webhook = prefs.WebhookURL
+		}
+		owner.Push = prefs.Uses(auth.ChannelPush)
+		return owner, owner != notify.Owner{}
+	}
+}
 
-	return s
+// digestRecipients returns the email addresses of the enabled users with
+// access to workspace who did not opt out of the daily digest or mute
+// notifications.
+func digestRecipients(users *auth.Store, workspace string) func() ([]string, error) {
+	return func() ([]string, error) {
+		all, err := users.Users()
+		if err != nil {
+			return nil, err
+		}
+		var addresses []string
+		for _, user := range all {
+			if user.GetsDigest() && (workspace == store.DefaultWorkspace || user.MemberOf(workspace)) {
+				addresses = append(addresses, user.Email)
+			}
+		}
+		return addresses, nil
+	}
 }
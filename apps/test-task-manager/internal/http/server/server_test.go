@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+)
+
+const testAdminToken = "server-test-admin-token"
+
+// buildForTest builds the application with the dev defaults after applying
+// configure, from the module root where the static assets are read from.
+func buildForTest(t *testing.T, configure func(*app.Configuration)) instance {
+	t.Helper()
+	t.Chdir("../../..")
+
+	c := app.DefaultConfiguration(app.Dev)
+	c.LogLevel = "error"
+	c.AdminToken = testAdminToken
+	configure(&c)
+	application, err := app.Initialize(c)
+	if err != nil {
+		t.Fatalf("invalid configuration: %v", err)
+	}
+	i := build(application)
+	t.Cleanup(func() {
+		i.Shutdown()
+		application.Shutdown()
+	})
+	return i
+}
+
+// serve returns the status h answers a GET of path with, authenticated as
+// admin when admin is set.
+func serve(h http.Handler, path string, admin bool) int {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if admin {
+		r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestBuild_AdminListener(t *testing.T) {
+	i := buildForTest(t, func(c *app.Configuration) { c.AdminListen = "tcp://127.0.0.1:0" })
+	if len(i.servers) != 2 {
+		t.Fatalf("expected a public and an admin server, got %d", len(i.servers))
+	}
+	public, admin := i.servers[0].Router, i.servers[1].Router
+
+	internal := []string{"/admin/loglevel", "/admin/users", "/admin/keys", "/admin/api/workspaces", "/health", "/metrics", "/debug/pprof/"}
+	for _, path := range internal {
+		if status := serve(public, path, true); status != http.StatusNotFound {
+			t.Errorf("public listener: expected %s to be absent, got %d", path, status)
+		}
+		if status := serve(admin, path, true); status != http.StatusOK {
+			t.Errorf("admin listener: expected %s to be served, got %d", path, status)
+		}
+	}
+	if status := serve(admin, "/admin/loglevel", false); status != http.StatusUnauthorized {
+		t.Errorf("admin listener: expected unauthenticated requests to be refused, got %d", status)
+	}
+	if status := serve(public, "/health", false); status != http.StatusNotFound {
+		t.Errorf("public listener: expected no health endpoint, got %d", status)
+	}
+}
+
+func TestBuild_SingleListener(t *testing.T) {
+	i := buildForTest(t, func(c *app.Configuration) {})
+	if len(i.servers) != 1 {
+		t.Fatalf("expected only the public server, got %d", len(i.servers))
+	}
+	public := i.servers[0].Router
+
+	if status := serve(public, "/admin/loglevel", true); status != http.StatusOK {
+		t.Errorf("expected the admin endpoints on the public listener, got %d", status)
+	}
+	if status := serve(public, "/admin/loglevel", false); status != http.StatusUnauthorized {
+		t.Errorf("expected unauthenticated admin requests to be refused, got %d", status)
+	}
+	if status := serve(public, "/debug/pprof/", true); status != http.StatusNotFound {
+		t.Errorf("expected pprof to be left off the public listener, got %d", status)
+	}
+}
@@ -0,0 +1,98 @@
+// Package i18n translates the HTML pages and the messages of API errors,
+// whose codes stay the same in every language. Messages are identified by
+// their English text, so English needs no catalog; the catalog of every
+// other locale maps the English text to its translation. Messages missing
+// from a catalog are shown in English.
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is a supported language, as ISO 639-1 code.
+type Locale string
+
+// Supported locales.
+const (
+	English Locale = "en"
+	Dutch   Locale = "nl"
+)
+
+// Default is the locale of visitors whose languages are not supported.
+const Default = English
+
+// Locales are the supported locales.
+var Locales = []Locale{English, Dutch}
+
+// catalogs holds the translations of the locales other than English.
+var catalogs = map[Locale]map[string]string{
+	Dutch: dutch,
+}
+
+// T returns the translation of message into locale, with args formatted
+// into it as by fmt.Sprintf.
+func T(locale Locale, message string, args ...any) string {
+	if translated, ok := catalogs[locale][message]; ok {
+		message = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Translated reports whether locale has a translation of message. English
+// has every message.
+func Translated(locale Locale, message string) bool {
+	if locale == English {
+		return true
+	}
+	_, ok := catalogs[locale][message]
+	return ok
+}
+
+// Match returns the supported locale an Accept-Language header prefers,
+// such as "nl-NL,nl;q=0.9,en;q=0.8", or Default when it prefers none.
+// Regional variants match their language.
+func Match(acceptLanguage string) Locale {
+	best, bestQuality := Default, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+		language, _, _ := strings.Cut(tag, "-")
+		locale := Locale(strings.ToLower(language))
+		if quality > bestQuality && (locale == English || catalogs[locale] != nil) {
+			best, bestQuality = locale, quality
+		}
+	}
+	return best
+}
+
+// FormatDate formats t as a day such as "2 Jan 2006", with the month
+// abbreviated in locale.
+func FormatDate(locale Locale, t time.Time) string {
+	month := t.Format("Jan")
+	if names, ok := months[locale]; ok {
+		month = names[t.Month()-1]
+	}
+	return fmt.Sprintf("%d %s %d", t.Day(), month, t.Year())
+}
+
+// Funcs returns the template functions translating into locale:
+// {{t "message" args...}} and {{date .DueDate}}.
+func Funcs(locale Locale) map[string]any {
+	return map[string]any{
+		"t":    func(message string, args ...any) string { return T(locale, message, args...) },
+		"date": func(t time.Time) string { return FormatDate(locale, t) },
+	}
+}
@@ -0,0 +1,121 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Locale
+	}{
+		{"", English},
+		{"nl", Dutch},
+		{"nl-BE,nl;q=0.9,en;q=0.8", Dutch},
+		{"en-GB,en;q=0.9,nl;q=0.8", English},
+		{"de-DE,de;q=0.9,nl;q=0.5,en;q=0.4", Dutch},
+		{"fr, de", English},
+		{"NL-nl", Dutch},
+		{"nl;q=abc, en", English},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.header); got != tt.want {
+			t.Errorf("Match(%q): expected %s, got %s", tt.header, tt.want, got)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T(Dutch, "Total: %d tasks", 3); got != "Totaal: 3 taken" {
+		t.Errorf("expected the Dutch message with its argument, got %q", got)
+	}
+	if got := T(English, "Total: %d tasks", 3); got != "Total: 3 tasks" {
+		t.Errorf("expected the English message with its argument, got %q", got)
+	}
+	if got := T(Dutch, "Not in any catalog"); got != "Not in any catalog" {
+		t.Errorf("expected messages without translation in English, got %q", got)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	day := time.Date(2026, time.March, 1, 17, 0, 0, 0, time.UTC)
+	if got := FormatDate(English, day); got != "1 Mar 2026" {
+		t.Errorf("expected 1 Mar 2026, got %q", got)
+	}
+	if got := FormatDate(Dutch, day); got != "1 mrt 2026" {
+		t.Errorf("expected 1 mrt 2026, got %q", got)
+	}
+}
+
+// templateMessage matches the messages templates translate with a literal.
+var templateMessage = regexp.MustCompile(`\bt ("(?:[^"\\]|\\.)*")`)
+
+func TestCatalogsCoverTemplates(t *testing.T) {
+	files, err := filepath.Glob("../../templates/*.html")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no templates found: %v", err)
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range templateMessage.FindAllStringSubmatch(string(content), -1) {
+			message, err := strconv.Unquote(match[1])
+			if err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+			for _, locale := range Locales {
+				if !Translated(locale, message) {
+					t.Errorf("%s: %q has no %s translation", filepath.Base(file), message, locale)
+				}
+			}
+		}
+	}
+}
+
+// literal matches a Go string literal.
+const literal = `("(?:[^"\\]|\\.)*")`
+
+// errorMessages match the English messages of API errors, the first group
+// being the literal, in the files of the globs.
+var errorMessages = map[string]*regexp.Regexp{
+	"../handler/*.go":         regexp.MustCompile(`(?:respondError\(w, r, |respondMappedError\([^"\n]*|invalidf\(|message: )` + literal),
+	"../http/middleware/*.go": regexp.MustCompile(`(?:writeError\(w, r, [^,]+, [^,]+, |unauthorized\(w, r, |\.limit\(next, [^"\n]*|Message = |i18n\.T\(locale, )` + literal),
+	"../schema/*.go":          regexp.MustCompile(`(?:fail\(|message\()` + literal),
+}
+
+func TestCatalogsCoverAPIErrors(t *testing.T) {
+	for glob, pattern := range errorMessages {
+		files, err := filepath.Glob(glob)
+		if err != nil || len(files) == 0 {
+			t.Fatalf("no files match %s: %v", glob, err)
+		}
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			content, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, match := range pattern.FindAllStringSubmatch(string(content), -1) {
+				message, err := strconv.Unquote(match[1])
+				if err != nil {
+					t.Fatalf("%s: %v", file, err)
+				}
+				for _, locale := range Locales {
+					if !Translated(locale, message) {
+						t.Errorf("%s: %q has no %s translation", filepath.Base(file), message, locale)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package i18n
+
+// months are the abbreviated month names of the locales other than English.
+var months = map[Locale][12]string{
+	Dutch: {"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+}
+
+// dutch is the Dutch catalog.
+var dutch = map[string]string{
+	// Layout
+	"Task List":         "Takenlijst",
+	"Tasks":             "Taken",
+	"Board":             "Bord",
+	"Dashboard":         "Dashboard",
+	"Enable reminders":  "Herinneringen aan",
+	"Disable reminders": "Herinneringen uit",
+	"Request failed":    "Verzoek mislukt",
+	"Network error: the server could not be reached": "Netwerkfout: de server is niet bereikbaar",
+
+	// Maintenance banner
+	"The task manager is in maintenance. Tasks can be read but not changed; try again later.": "Het takenbeheer is in onderhoud. Taken kunnen worden bekeken maar niet gewijzigd; probeer het later opnieuw.",
+
+	// Login
+	"Signed in as %s": "Ingelogd als %s",
+	"Sign out":        "Uitloggen",
+	"Sign in":         "Inloggen",
+	"User name":       "Gebruikersnaam",
+	"API key":         "API-sleutel",
+	"One of your API keys, as issued by an administrator.": "Een van je API-sleutels, zoals uitgegeven door een beheerder.",
+	"Invalid user name or API key":                         "Ongeldige gebruikersnaam of API-sleutel",
+	"Signing in is unavailable. Try again later.":          "Inloggen is niet beschikbaar. Probeer het later opnieuw.",
+
+	// Preferences
+	"Preferences":    "Voorkeuren",
+	"Theme":          "Thema",
+	"Light":          "Licht",
+	"Dark":           "Donker",
+	"Sort tasks by":  "Taken sorteren op",
+	"Tasks per page": "Taken per pagina",
+	"Default":        "Standaard",
+	"Save":           "Opslaan",
+
+	// Task list
+	"My Tasks":                    "Mijn taken",
+	"Add New Task":                "Nieuwe taak",
+	"Enter task title...":         "Titel van de taak...",
+	"Add":                         "Toevoegen",
+	"Priority selector":           "Prioriteit kiezen",
+	"🔥 Urgent & Important":        "🔥 Urgent en belangrijk",
+	"⭐ Important":                 "⭐ Belangrijk",
+	"⚡ Urgent":                    "⚡ Urgent",
+	"💡 Low":                       "💡 Laag",
+	"📋 Default":                   "📋 Standaard",
+	"All tasks":                   "Alle taken",
+	"Open":                        "Open",
+	"Completed":                   "Afgerond",
+	"Status":                      "Status",
+	"Any priority":                "Elke prioriteit",
+	"Sort by":                     "Sorteren op",
+	"Oldest first":                "Oudste eerst",
+	"Due date":                    "Einddatum",
+	"Priority":                    "Prioriteit",
+	"Title":                       "Titel",
+	"Apply":                       "Toepassen",
+	"Show all":                    "Alles tonen",
+	"Showing %d of %d tasks":      "%d van %d taken",
+	"Task list pages":             "Pagina's van de takenlijst",
+	"Previous":                    "Vorige",
+	"Next":                        "Volgende",
+	"Export":                      "Exporteren",
+	"No tasks match the filters.": "Geen taken voldoen aan de filters.",
+	"No tasks yet. Add your first task above!": "Nog geen taken. Voeg hierboven je eerste taak toe!",
+	"Total: %d tasks":                          "Totaal: %d taken",
+	"due %s":                                   "voor %s",
+	"Edit task":                                "Taak bewerken",
+	"Delete task":                              "Taak verwijderen",
+	"Are you sure you want to delete this task?": "Weet je zeker dat je deze taak wilt verwijderen?",
+
+	// Edit page
+	"Edit Task":                    "Taak bewerken",
+	"Color":                        "Kleur",
+	"Red":                          "Rood",
+	"Blue":                         "Blauw",
+	"Yellow":                       "Geel",
+	"Green":                        "Groen",
+	"Purple":                       "Paars",
+	"Orange":                       "Oranje",
+	"Grey":                         "Grijs",
+	"Leave empty for no due date.": "Laat leeg voor geen einddatum.",
+	"Cancel":                       "Annuleren",
+
+	// Board
+	"Board view":                   "Weergave van het bord",
+	"Eisenhower matrix":            "Eisenhowermatrix",
+	"Do first":                     "Eerst doen",
+	"Urgent and important":         "Urgent en belangrijk",
+	"Schedule":                     "Inplannen",
+	"Important, not urgent":        "Belangrijk, niet urgent",
+	"Delegate":                     "Delegeren",
+	"Urgent, not important":        "Urgent, niet belangrijk",
+	"Eliminate":                    "Schrappen",
+	"Neither urgent nor important": "Niet urgent en niet belangrijk",
+	"Uncategorized":                "Niet ingedeeld",
+	"No priority yet":              "Nog geen prioriteit",
+	"To do":                        "Te doen",
+	"Done":                         "Klaar",
+	"%d tasks":                     "%d taken",
+	"Edit":                         "Bewerken",
+	"No tasks":                     "Geen taken",
+
+	// Dashboard
+	"Overdue":                   "Te laat",
+	"Created since startup":     "Aangemaakt sinds de start",
+	"Completed since startup":   "Afgerond sinds de start",
+	"Average time to complete":  "Gemiddelde tijd tot afronden",
+	"Open tasks by priority":    "Open taken per prioriteit",
+	"Completed per day":         "Afgerond per dag",
+	"%d in the last %d days":    "%d in de afgelopen %d dagen",
+	"Overdue tasks":             "Taken over tijd",
+	"No overdue tasks":          "Geen taken over tijd",
+	"Recent activity":           "Recente activiteit",
+	"No activity since startup": "Geen activiteit sinds de start",
+	"Created":                   "Aangemaakt",
+	"Edited":                    "Bewerkt",
+	"Priority changed":          "Prioriteit gewijzigd",
+	"Reopened":                  "Heropend",
+	"Deleted":                   "Verwijderd",
+
+	// Errors
+	"Task not found": "Taak niet gevonden",
+	"The task was changed by another request meanwhile. Try again.": "De taak is intussen door een ander verzoek gewijzigd. Probeer het opnieuw.",
+	"Someone else is editing the task. Try again later.":            "Iemand anders bewerkt de taak. Probeer het later opnieuw.",
+	"task title cannot be empty":                                    "de titel van de taak mag niet leeg zijn",
+	"task title is too short":                                       "de titel van de taak is te kort",
+	"task title is too long":                                        "de titel van de taak is te lang",
+	"task title must be UTF-8 text":                                 "de titel van de taak moet UTF-8-tekst zijn",
+	"an open task with this title already exists":                   "er is al een open taak met deze titel",
+	"the work in progress limit of open tasks is reached":           "de limiet van open taken in behandeling is bereikt",
+	"the task quota of the plan is reached":                         "het takenquotum van het abonnement is bereikt",
+	"the quota of open tasks per user is reached":                   "het quotum van open taken per gebruiker is bereikt",
+	"invalid changes cursor":                                        "ongeldige cursor voor wijzigingen",
+	"a task cannot be merged into itself":                           "een taak kan niet in zichzelf worden samengevoegd",
+	"Invalid priority. Must be one of: 🔥, ⭐, ⚡, 💡, 📋, or urgent, high, low or p1 to p5":             "Ongeldige prioriteit. Kies een van: 🔥, ⭐, ⚡, 💡, 📋, of urgent, high, low of p1 tot en met p5",
+	"Invalid color code. Must be a valid hex code.":                                                 "Ongeldige kleurcode. Gebruik een geldige hexcode.",
+	"The task store is full. Delete tasks before adding new ones.":                                  "De takenopslag is vol. Verwijder taken voordat je nieuwe toevoegt.",
+	"The task store is unavailable. Try again later.":                                               "De takenopslag is niet beschikbaar. Probeer het later opnieuw.",
+	"The task store did not answer in time. Try again.":                                             "De takenopslag antwoordde niet op tijd. Probeer het opnieuw.",
+	"Deletions that long ago are no longer kept; reload the tasks":                                  "Verwijderingen van zo lang geleden worden niet meer bewaard; laad de taken opnieuw",
+	"The task store is unreachable, so tasks can only be read for now. Try the change again later.": "De takenopslag is onbereikbaar, dus taken kunnen voorlopig alleen gelezen worden. Probeer de wijziging later opnieuw.",
+	"The due date must be a date like 2026-03-01":                                                   "De einddatum moet een datum zijn zoals 2026-03-01",
+	"Failed to load tasks":  "Taken laden mislukt",
+	"Failed to load task":   "Taak laden mislukt",
+	"Failed to create task": "Taak aanmaken mislukt",
+	"Failed to update task": "Taak bijwerken mislukt",
+	"Failed to toggle task": "Taak afvinken mislukt",
+	"Failed to delete task": "Taak verwijderen mislukt",
+
+	// API errors
+	"A priority or color is required": "Een prioriteit of kleur is verplicht",
+	"Delivery not found":              "Aflevering niet gevonden",
+	"Events after %d are no longer kept; reload the tasks and replay from %d": "Gebeurtenissen na %d worden niet meer bewaard; laad de taken opnieuw en speel ze af vanaf %d",
+	"before must be the next of an earlier page":                              "before moet de next van een eerdere pagina zijn",
+	"since must be an RFC 3339 time, like 2026-03-01T09:00:00Z":               "since moet een RFC 3339-tijd zijn, zoals 2026-03-01T09:00:00Z",
+	"Failed to compute stats":                                                 "Statistieken berekenen mislukt",
+	"Failed to compute usage":                                                 "Gebruik berekenen mislukt",
+	"Failed to export tasks":                                                  "Taken exporteren mislukt",
+	"Failed to get changes":                                                   "Wijzigingen ophalen mislukt",
+	"Failed to get tombstones":                                                "Verwijderde taken ophalen mislukt",
+	"Failed to import tasks":                                                  "Taken importeren mislukt",
+	"Failed to list tasks":                                                    "Taken ophalen mislukt",
+	"Failed to lock task":                                                     "Taak vergrendelen mislukt",
+	"Failed to merge tasks":                                                   "Taken samenvoegen mislukt",
+	"Failed to change priority":                                               "Prioriteit wijzigen mislukt",
+	"Failed to load task history":                                             "Taakgeschiedenis laden mislukt",
+	"Failed to remove subscription":                                           "Abonnement verwijderen mislukt",
+	"Failed to reprioritize tasks":                                            "Prioriteit van taken wijzigen mislukt",
+	"Failed to seed tasks":                                                    "Voorbeeldtaken toevoegen mislukt",
+	"Failed to store preferences":                                             "Voorkeuren opslaan mislukt",
+	"Failed to store subscription":                                            "Abonnement opslaan mislukt",
+	"Failed to unlock task":                                                   "Taak ontgrendelen mislukt",
+	"Invalid iCalendar file: %s":                                              "Ongeldig iCalendar-bestand: %s",
+	"Invalid preferences: %s":                                                 "Ongeldige voorkeuren: %s",
+	"Invalid notification preferences: %s":                                    "Ongeldige meldingsvoorkeuren: %s",
+	"Sign in to manage notification preferences":                              "Log in om je meldingsvoorkeuren te beheren",
+	"The email channel needs an email address; ask an admin to set yours":     "Het e-mailkanaal heeft een e-mailadres nodig; vraag een beheerder het jouwe in te stellen",
+	"Invalid request body":                                                    "Ongeldige inhoud van het verzoek",
+	"Invalid subscription: %s":                                                "Ongeldig abonnement: %s",
+	"Method not allowed":                                                      "Methode niet toegestaan",
+	"Resource not found":                                                      "Bron niet gevonden",
+	"Schema not found":                                                        "Schema niet gevonden",
+	"Subscription not found":                                                  "Abonnement niet gevonden",
+	"The body must name the source task, like {\"source\": \"2\"}":            "De inhoud moet de brontaak noemen, zoals {\"source\": \"2\"}",
+	"The body must hold the new priority, like {\"priority\": \"🔥\"}":         "De inhoud moet de nieuwe prioriteit bevatten, zoals {\"priority\": \"🔥\"}",
+	"The calendar file is larger than 10 MiB":                                 "Het agendabestand is groter dan 10 MiB",
+	"The multipart form must hold the calendar as file field":                 "Het multipart-formulier moet de agenda in het veld file bevatten",
+	"The request body is larger than 64 KiB":                                  "De inhoud van het verzoek is groter dan 64 KiB",
+	"The webhook failed again: %s":                                            "De webhook is opnieuw mislukt: %s",
+	"Webhook not found":                                                       "Webhook niet gevonden",
+	"count must be a number between 1 and %d":                                 "count moet een getal tussen 1 en %d zijn",
+	"format must be json, csv, ndjson or ics":                                 "format moet json, csv, ndjson of ics zijn",
+	"from must be the sequence number of the last event received, or 0":       "from moet het volgnummer van de laatst ontvangen gebeurtenis zijn, of 0",
+	"limit must be between 1 and %d":                                          "limit moet tussen 1 en %d liggen",
+	"limit must be a positive number":                                         "limit moet een positief getal zijn",
+	"offset must be zero or a positive number":                                "offset moet nul of een positief getal zijn",
+	"pageSize must be between 0 (the default) and %d":                         "pageSize moet tussen 0 (de standaard) en %d liggen",
+	"sort must be created, due, priority or title":                            "sort moet created, due, priority of title zijn",
+	"stale must be true":                                                      "stale moet true zijn",
+	"stale tasks are open, so stale cannot be combined with status=completed": "verouderde taken zijn open, dus stale kan niet samen met status=completed",
+	"status must be open or completed":                                        "status moet open of completed zijn",
+	"status must be retrying or dead":                                         "status moet retrying of dead zijn",
+	"theme must be light or dark":                                             "theme moet light of dark zijn",
+	"timezone must be an IANA time zone like Europe/Amsterdam":                "timezone moet een IANA-tijdzone zijn, zoals Europe/Amsterdam",
+	"ttl must be between 1 and %d seconds":                                    "ttl moet tussen 1 en %d seconden liggen",
+	"wait must be a duration between 0s and %s, like 30s":                     "wait moet een duur tussen 0s en %s zijn, zoals 30s",
+	"%s must be an RFC 3339 timestamp":                                        "%s moet een RFC 3339-tijdstip zijn",
+	"Authentication required":                                                 "Authenticatie vereist",
+	"Admin endpoints are disabled: no admin token is configured":              "Beheerendpoints zijn uitgeschakeld: er is geen beheertoken ingesteld",
+	"Authentication unavailable":                                              "Authenticatie is niet beschikbaar",
+	"Invalid credentials":                                                     "Ongeldige inloggegevens",
+	"Internal server error":                                                   "Interne serverfout",
+	"Server is overloaded, please retry":                                      "De server is overbelast, probeer het opnieuw",
+	"Too many requests":                                                       "Te veel verzoeken",
+	"Too many requests to the workspace":                                      "Te veel verzoeken aan de werkruimte",
+	"Not a member of the workspace":                                           "Geen lid van de werkruimte",
+	"Workspace not found":                                                     "Werkruimte niet gevonden",
+	"The task manager is read-only. Tasks can be read but not changed.":       "Het takenbeheer is alleen-lezen. Taken kunnen worden bekeken maar niet gewijzigd.",
+	"The request body does not match its schema: %s":                          "De inhoud van het verzoek komt niet overeen met het schema: %s",
+
+	// Field errors of request bodies
+	"must be %s":                     "moet %s zijn",
+	"a string":                       "een tekst",
+	"a number":                       "een getal",
+	"an integer":                     "een geheel getal",
+	"a boolean":                      "een boolean",
+	"an array":                       "een lijst",
+	"an object":                      "een object",
+	"null":                           "null",
+	"%s or %s":                       "%s of %s",
+	"must be one of %s":              "moet een van %s zijn",
+	"must not be empty":              "mag niet leeg zijn",
+	"must be at least %d characters": "moet minstens %d tekens lang zijn",
+	"must be at most %d characters":  "mag hoogstens %d tekens lang zijn",
+	"must match %s":                  "moet overeenkomen met %s",
+	"must be an RFC 3339 date-time":  "moet een RFC 3339-tijdstip zijn",
+	"must be at least %v":            "moet minstens %v zijn",
+	"must be at most %v":             "mag hoogstens %v zijn",
+	"must have at least one of %s":   "moet minstens een van %s hebben",
+	"is required":                    "is verplicht",
+	"is not allowed":                 "is niet toegestaan",
+}
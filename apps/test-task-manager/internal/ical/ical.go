@@ -0,0 +1,271 @@
+// Package ical reads and writes the parts of iCalendar (RFC 5545) needed to
+// exchange tasks with calendar applications: components, properties with
+// parameters, text escaping and date-times.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Component is a BEGIN/END block, such as VCALENDAR or VTODO.
+type Component struct {
+	Name     string
+	Props    []Property
+	Children []*Component
+}
+
+// Property is a content line, such as "DUE;TZID=Europe/Amsterdam:20240301T120000".
+type Property struct {
+	Name   string
+	Params map[string]string // Keys in upper case
+	Value  string            // Raw value; use Text for TEXT properties
+}
+
+// Prop returns the first property called name, or nil.
+func (c *Component) Prop(name string) *Property {
+	for i := range c.Props {
+		if c.Props[i].Name == name {
+			return &c.Props[i]
+		}
+	}
+	return nil
+}
+
+// Text returns the unescaped TEXT value of the property called name, or ""
+// when there is none.
+func (c *Component) Text(name string) string {
+	if p := c.Prop(name); p != nil {
+		return unescape(p.Value)
+	}
+	return ""
+}
+
+// Set appends a property with a raw value.
+func (c *Component) Set(name, value string) {
+	c.Props = append(c.Props, Property{Name: name, Value: value})
+}
+
+// SetText appends a TEXT property, escaping value.
+func (c *Component) SetText(name, value string) {
+	c.Set(name, escape(value))
+}
+
+// SetTime appends a date-time property in UTC.
+func (c *Component) SetTime(name string, t time.Time) {
+	c.Set(name, t.UTC().Format(utcLayout))
+}
+
+// Find returns the descendants of c called name, depth first.
+func (c *Component) Find(name string) []*Component {
+	var found []*Component
+	for _, child := range c.Children {
+		if child.Name == name {
+			found = append(found, child)
+		}
+		found = append(found, child.Find(name)...)
+	}
+	return found
+}
+
+// Date-time layouts of RFC 5545 section 3.3.5 and the DATE value type.
+const (
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+	dateLayout  = "20060102"
+)
+
+// Time parses a DATE or DATE-TIME property. UTC times end in Z, times with
+// a TZID parameter are in that IANA time zone, and floating times and dates
+// are in loc. Dates are at midnight. allDay reports whether it was a date.
+func (p Property) Time(loc *time.Location) (t time.Time, allDay bool, err error) {
+	if tzid := p.Params["TZID"]; tzid != "" {
+		// Some clients quote the ID or prefix it with a slash.
+		zone, err := time.LoadLocation(strings.TrimPrefix(strings.Trim(tzid, `"`), "/"))
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("%s: unknown time zone %q", p.Name, tzid)
+		}
+		loc = zone
+	}
+
+	switch {
+	case p.Params["VALUE"] == "DATE" || len(p.Value) == len(dateLayout):
+		t, err = time.ParseInLocation(dateLayout, p.Value, loc)
+		allDay = true
+	case strings.HasSuffix(p.Value, "Z"):
+		t, err = time.Parse(utcLayout, p.Value)
+	default:
+		t, err = time.ParseInLocation(localLayout, p.Value, loc)
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%s: invalid date %q", p.Name, p.Value)
+	}
+	return t, allDay, nil
+}
+
+// Parse reads an iCalendar stream and returns its top-level component,
+// normally a VCALENDAR.
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for i, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		switch prop.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, c)
+			} else if root != nil {
+				return nil, fmt.Errorf("line %d: more than one top-level component", i+1)
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property %s outside a component", i+1, prop.Name)
+			}
+			c := stack[len(stack)-1]
+			c.Props = append(c.Props, prop)
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no calendar data")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// unfold returns the logical lines of r, joining the continuation lines
+// that start with a space or tab.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case line == "":
+		case (line[0] == ' ' || line[0] == '\t') && len(lines) > 0:
+			lines[len(lines)-1] += line[1:]
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine splits a content line into its name, parameters and value.
+// Colons and semicolons inside quoted parameter values are kept.
+func parseLine(line string) (Property, error) {
+	quoted := false
+	end := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return Property{}, fmt.Errorf("missing colon in %q", line)
+	}
+
+	prop := Property{Value: line[end+1:]}
+	parts := splitUnquoted(line[:end], ';')
+	prop.Name = strings.ToUpper(parts[0])
+	if prop.Name == "" {
+		return Property{}, fmt.Errorf("missing property name in %q", line)
+	}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		if prop.Params == nil {
+			prop.Params = make(map[string]string)
+		}
+		prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Write serializes c with CRLF line endings, folding lines longer than 75
+// octets without splitting UTF-8 sequences.
+func Write(w io.Writer, c *Component) error {
+	bw := bufio.NewWriter(w)
+	writeComponent(bw, c)
+	return bw.Flush()
+}
+
+func writeComponent(w *bufio.Writer, c *Component) {
+	writeLine(w, "BEGIN:"+c.Name)
+	for _, p := range c.Props {
+		line := p.Name
+		for key, value := range p.Params {
+			if strings.ContainsAny(value, ":;,") {
+				value = `"` + value + `"`
+			}
+			line += ";" + key + "=" + value
+		}
+		writeLine(w, line+":"+p.Value)
+	}
+	for _, child := range c.Children {
+		writeComponent(w, child)
+	}
+	writeLine(w, "END:"+c.Name)
+}
+
+func writeLine(w *bufio.Writer, line string) {
+	const limit = 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 { // Continuation byte
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n")
+		line = " " + line[cut:]
+	}
+	w.WriteString(line + "\r\n")
+}
+
+var (
+	escaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escape(s string) string   { return escaper.Replace(s) }
+func unescape(s string) string { return unescaper.Replace(s) }
@@ -0,0 +1,80 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+func TestParse_Todo(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:abc\r\n" +
+		"SUMMARY:Buy milk\\, eggs and a very long list of other things that needs fol\r\n" +
+		" ding\r\n" +
+		"PRIORITY:5\r\n" +
+		"DUE;TZID=\"Europe/Amsterdam\":20260301T090000\r\n" +
+		"STATUS:COMPLETED\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	todos := cal.Find("VTODO")
+	if len(todos) != 1 {
+		t.Fatalf("expected one VTODO, got %d", len(todos))
+	}
+	task, err := Task(todos[0], time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Buy milk, eggs and a very long list of other things that needs folding"; task.Title != want {
+		t.Errorf("expected title %q, got %q", want, task.Title)
+	}
+	if task.Priority != "⭐" || !task.Completed {
+		t.Errorf("expected an important completed task, got %+v", task)
+	}
+	if want := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC); task.DueDate == nil || !task.DueDate.Equal(want) {
+		t.Errorf("expected due date %s, got %v", want, task.DueDate)
+	}
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	due := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	task := model.Task{
+		ID:       "7",
+		Title:    strings.Repeat("Très long; ", 10),
+		Priority: "⚡",
+		Color:    "#dc3545",
+		DueDate:  &due,
+	}
+
+	var b bytes.Buffer
+	if err := Write(&b, Calendar(Todo(task, "7"))); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(b.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("expected lines of at most 75 octets, got %d: %q", len(line), line)
+		}
+	}
+
+	cal, err := Parse(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Task(cal.Find("VTODO")[0], time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != task.Title || got.Priority != task.Priority || got.Color != task.Color || !got.DueDate.Equal(due) || got.Completed {
+		t.Errorf("expected %+v back, got %+v", task, got)
+	}
+}
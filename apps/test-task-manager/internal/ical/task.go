@@ -0,0 +1,122 @@
+package ical
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+)
+
+// ProdID identifies the application in the calendars it writes.
+const ProdID = "-//test-task-manager//Tasks//EN"
+
+// colorProp carries the task color, which iCalendar has no hex value for.
+const colorProp = "X-TTM-COLOR"
+
+// Calendar wraps components in a VCALENDAR.
+func Calendar(components ...*Component) *Component {
+	cal := &Component{Name: "VCALENDAR", Children: components}
+	cal.Set("VERSION", "2.0")
+	cal.Set("PRODID", ProdID)
+	return cal
+}
+
+// Todo returns task as a VTODO identified by uid.
+func Todo(task model.Task, uid string) *Component {
+	c := &Component{Name: "VTODO"}
+	c.SetText("UID", uid)
+	c.SetTime("DTSTAMP", time.Now())
+	c.SetTime("CREATED", task.CreatedAt)
+	c.SetText("SUMMARY", task.Title)
+	c.Set("PRIORITY", strconv.Itoa(priorityLevel(task.Priority)))
+	c.Set(colorProp, task.Color)
+	if task.DueDate != nil {
+		c.SetTime("DUE", *task.DueDate)
+	}
+	if task.Completed {
+		c.Set("STATUS", "COMPLETED")
+		if task.CompletedAt != nil {
+			c.SetTime("COMPLETED", *task.CompletedAt)
+		}
+	} else {
+		c.Set("STATUS", "NEEDS-ACTION")
+	}
+	return c
+}
+
+// Task maps a VTODO or VEVENT to the fields of a task: the summary as
+// title, the PRIORITY level, the due date (the start of an event) and
+// whether it is completed. Dates without a time zone are in loc. The task
+// is not validated; see service.NewTask.
+func Task(c *Component, loc *time.Location) (model.Task, error) {
+	task := model.Task{Title: c.Text("SUMMARY")}
+
+	if p := c.Prop("PRIORITY"); p != nil {
+		level, err := strconv.Atoi(p.Value)
+		if err != nil || level < 0 || level > 9 {
+			return model.Task{}, errors.New("PRIORITY must be a number from 0 to 9")
+		}
+		task.Priority = priorityOf(level)
+	}
+	if color := c.Prop(colorProp); color != nil {
+		task.Color = color.Value
+	}
+
+	due := c.Prop("DUE")
+	if c.Name == "VEVENT" {
+		due = c.Prop("DTSTART")
+	}
+	if due != nil {
+		t, _, err := due.Time(loc)
+		if err != nil {
+			return model.Task{}, err
+		}
+		task.DueDate = &t
+	}
+
+	if status := c.Prop("STATUS"); status != nil && status.Value == "COMPLETED" || c.Prop("COMPLETED") != nil {
+		task.Completed = true
+		if p := c.Prop("COMPLETED"); p != nil {
+			if t, _, err := p.Time(loc); err == nil {
+				task.CompletedAt = &t
+			}
+		}
+	}
+	return task, nil
+}
+
+// priorityLevel maps a priority to the PRIORITY levels calendar
+// applications offer: 1 is high, 5 medium, 9 low and 0 undefined.
+func priorityLevel(priority string) int {
+	switch priority {
+	case service.PriorityUrgentImportant:
+		return 1
+	case service.PriorityUrgent:
+		return 3
+	case service.PriorityImportant:
+		return 5
+	case service.PriorityLow:
+		return 9
+	default:
+		return 0
+	}
+}
+
+// priorityOf is the inverse of priorityLevel, mapping levels in between to
+// the nearest priority.
+func priorityOf(level int) string {
+	switch {
+	case level == 0:
+		return service.PriorityDefault
+	case level <= 2:
+		return service.PriorityUrgentImportant
+	case level <= 4:
+		return service.PriorityUrgent
+	case level == 5:
+		return service.PriorityImportant
+	default:
+		return service.PriorityLow
+	}
+}
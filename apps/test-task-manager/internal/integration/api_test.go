@@ -0,0 +1,998 @@
+package integration
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/activity"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/handler"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/health"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/notify"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/seed"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestAPI_TaskLifecycle(t *testing.T) {
+	h := New(t)
+
+	var created model.Task
+	h.Do("POST", "/api/tasks", map[string]any{"title": "Ship release", "priority": "🔥", "dueDate": "2026-03-01T09:00:00Z"}).
+		JSON(http.StatusCreated, &created)
+	if created.ID == "" || created.Title != "Ship release" || created.Color != service.ColorGrey || created.DueDate == nil {
+		t.Fatalf("expected the created task with defaults, got %+v", created)
+	}
+
+	var toggled model.Task
+	h.Do("PATCH", "/api/tasks/"+created.ID+"/toggle", nil).JSON(http.StatusOK, &toggled)
+	if !toggled.Completed || toggled.CompletedAt == nil {
+		t.Errorf("expected the task to be completed, got %+v", toggled)
+	}
+
+	var aliased model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Write release notes", "priority": "High"}).JSON(http.StatusCreated, &aliased)
+	if aliased.Priority != service.PriorityImportant {
+		t.Errorf("expected the alias high to be stored as ⭐, got %+v", aliased)
+	}
+
+	var tasks []model.Task
+	resp := h.Do("GET", "/api/tasks?status=completed&priority=urgent", nil)
+	resp.JSON(http.StatusOK, &tasks)
+	if len(tasks) != 1 || tasks[0].ID != created.ID || resp.Header.Get("X-Total-Count") != "1" {
+		t.Errorf("expected the completed task to be listed, got %+v", tasks)
+	}
+
+	var stats service.Stats
+	h.Do("GET", "/api/stats", nil).JSON(http.StatusOK, &stats)
+	if stats.Created != 2 || stats.Completed != 1 || stats.Open != 1 {
+		t.Errorf("expected two created tasks, one completed, got %+v", stats)
+	}
+
+	var deleted handler.MessageResponse
+	h.Do("DELETE", "/api/tasks/"+created.ID, nil).JSON(http.StatusOK, &deleted)
+	h.Do("DELETE", "/api/tasks/"+created.ID, nil).Error(http.StatusNotFound, "TASK_NOT_FOUND")
+	h.Do("PATCH", "/api/tasks/"+created.ID+"/toggle", nil).Error(http.StatusNotFound, "TASK_NOT_FOUND")
+}
+
+func TestAPI_InvalidInput(t *testing.T) {
+	h := New(t)
+
+	h.Do("POST", "/api/tasks", "{").Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("POST", "/api/tasks", map[string]string{"title": " "}).Error(http.StatusBadRequest, "EMPTY_TITLE")
+	h.Do("POST", "/api/tasks", map[string]string{"title": "x", "priority": "nope"}).Error(http.StatusBadRequest, "INVALID_PRIORITY")
+	h.Do("GET", "/api/tasks?priority=p0", nil).Error(http.StatusBadRequest, "INVALID_PRIORITY")
+	h.Do("POST", "/api/tasks", map[string]string{"title": "x", "color": "teal"}).Error(http.StatusBadRequest, "INVALID_COLOR")
+	h.Do("POST", "/api/tasks", map[string]string{"title": "\x00\n"}).Error(http.StatusBadRequest, "EMPTY_TITLE")
+	h.Do("POST", "/api/tasks", map[string]string{"title": strings.Repeat("x", 64<<10)}).Error(http.StatusRequestEntityTooLarge, "INVALID_INPUT")
+	h.Do("GET", "/api/tasks?status=later", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("GET", "/api/tasks?dueAfter=tomorrow", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("GET", "/api/tasks?sort=size", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+}
+
+func TestAPI_SchemaValidation(t *testing.T) {
+	h := New(t)
+
+	e := h.Do("POST", "/api/tasks", map[string]any{"title": 42, "dueDate": "tomorrow"}).Error(http.StatusBadRequest, "INVALID_INPUT")
+	if len(e.Fields) != 2 || e.Fields[0].Error() != "/dueDate must be an RFC 3339 date-time" || e.Fields[1].Error() != "/title must be a string" {
+		t.Errorf("expected /dueDate and /title errors, got %v", e.Fields)
+	}
+	e = h.Do("PUT", "/api/preferences", map[string]any{"theme": "dark", "font": "serif"}).Error(http.StatusBadRequest, "INVALID_INPUT")
+	if len(e.Fields) != 1 || e.Fields[0].Field != "/font" {
+		t.Errorf("expected /font to be rejected, got %v", e.Fields)
+	}
+	// XML bodies are left to the handler
+	req := h.Request("POST", "/api/tasks", "<task><title>From XML</title></task>")
+	req.Header.Set("Content-Type", "application/xml")
+	h.Send(req).Expect(http.StatusCreated)
+
+	var index struct{ Schemas []handler.SchemaLink }
+	h.Do("GET", "/api/schemas", nil).JSON(http.StatusOK, &index)
+	if !slices.Contains(index.Schemas, handler.SchemaLink{Name: "new-task", URL: "/api/schemas/new-task"}) {
+		t.Errorf("expected the new-task schema to be listed, got %+v", index.Schemas)
+	}
+	resp := h.Do("GET", "/api/schemas/new-task", nil).Expect(http.StatusOK)
+	if ct := resp.Header.Get("Content-Type"); ct != "application/schema+json" || !strings.Contains(string(resp.Body), `"$id": "/api/schemas/new-task"`) {
+		t.Errorf("expected the new-task schema, got %q %s", ct, resp.Body)
+	}
+	h.Do("GET", "/api/schemas/nope", nil).Error(http.StatusNotFound, "NOT_FOUND")
+}
+
+func TestAPI_LocalizedErrors(t *testing.T) {
+	h := New(t)
+
+	dutch := func(method, path string, body any) *http.Request {
+		req := h.Request(method, path, body)
+		req.Header.Set("Accept-Language", "nl-NL,nl;q=0.9,en;q=0.8")
+		return req
+	}
+	resp := h.Send(dutch("PATCH", "/api/tasks/999/toggle", nil))
+	if e := resp.Error(http.StatusNotFound, "TASK_NOT_FOUND"); e.Error != "Taak niet gevonden" {
+		t.Errorf("expected the Dutch message with the same code, got %q", e.Error)
+	}
+	if lang := resp.Header.Get("Content-Language"); lang != "nl" {
+		t.Errorf("expected Content-Language nl, got %q", lang)
+	}
+	if e := h.Send(dutch("GET", "/api/tasks?limit=0", nil)).Error(http.StatusBadRequest, "INVALID_INPUT"); !strings.HasPrefix(e.Error, "limit moet tussen 1 en") {
+		t.Errorf("expected the Dutch parameter error, got %q", e.Error)
+	}
+	e := h.Send(dutch("POST", "/api/tasks", map[string]any{"title": 42})).Error(http.StatusBadRequest, "INVALID_INPUT")
+	if len(e.Fields) != 1 || e.Fields[0].Error() != "/title moet een tekst zijn" {
+		t.Errorf("expected a Dutch field error, got %v", e.Fields)
+	}
+
+	// Other languages get English
+	req := h.Request("PATCH", "/api/tasks/999/toggle", nil)
+	req.Header.Set("Accept-Language", "fr")
+	if e := h.Send(req).Error(http.StatusNotFound, "TASK_NOT_FOUND"); e.Error != "Task not found" {
+		t.Errorf("expected the English message, got %q", e.Error)
+	}
+}
+
+func TestAPI_TitleLimits(t *testing.T) {
+	h := New(t, func(c *app.Configuration) { c.TitleMinLength, c.TitleMaxLength = 3, 10 })
+
+	var meta handler.Meta
+	h.Do("GET", "/api/meta", nil).JSON(http.StatusOK, &meta)
+	if meta.Title != (service.TitleLimits{Min: 3, Max: 10}) || len(meta.Priorities) != 5 || meta.ListLimit != 100 {
+		t.Errorf("expected the configured limits, got %+v", meta)
+	}
+
+	h.Do("POST", "/api/tasks", map[string]string{"title": "ab"}).Error(http.StatusBadRequest, "TITLE_TOO_SHORT")
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Ship it now"}).Error(http.StatusBadRequest, "TITLE_TOO_LONG")
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Café 🔥 ünï"}).Expect(http.StatusCreated)
+}
+
+func TestAPI_TaskDefaults(t *testing.T) {
+	h := New(t, func(c *app.Configuration) {
+		c.DefaultPriority, c.DefaultColor, c.DefaultDueIn = "p2", "blue", 24*time.Hour
+	})
+
+	var meta handler.Meta
+	h.Do("GET", "/api/meta", nil).JSON(http.StatusOK, &meta)
+	if meta.Defaults != (handler.MetaDefaults{Priority: service.PriorityImportant, Color: "#0d6efd", DueInSeconds: 86400}) {
+		t.Errorf("expected the configured defaults, got %+v", meta.Defaults)
+	}
+
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Defaults"}).JSON(http.StatusCreated, &task)
+	if task.Priority != service.PriorityImportant || task.Color != "#0d6efd" || task.DueDate == nil {
+		t.Errorf("expected the configured defaults, got %+v", task)
+	}
+}
+
+func TestAPI_Locks(t *testing.T) {
+	h := New(t)
+	if _, err := h.App.Auth().CreateUser("editor"); err != nil {
+		t.Fatal(err)
+	}
+	key, err := h.App.Auth().IssueKey("editor", "integration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	asEditor := func(method, path string) *Response {
+		req := h.Request(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+key.Token)
+		return h.Send(req)
+	}
+
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Edit together"}).JSON(http.StatusCreated, &task)
+	var lock service.Lock
+	h.Do("POST", "/api/tasks/"+task.ID+"/lock", map[string]int{"ttl": 60}).JSON(http.StatusOK, &lock)
+	if lock.TaskID != task.ID || lock.Holder != User || lock.Token != "" {
+		t.Errorf("expected a lock of %s without token, got %+v", User, lock)
+	}
+	h.Do("POST", "/api/tasks/"+task.ID+"/lock", map[string]int{"ttl": 7200}).Error(http.StatusBadRequest, "INVALID_INPUT")
+
+	asEditor("PATCH", "/api/tasks/"+task.ID+"/toggle").Error(http.StatusLocked, "TASK_LOCKED")
+	asEditor("DELETE", "/api/tasks/"+task.ID).Error(http.StatusLocked, "TASK_LOCKED")
+	asEditor("POST", "/api/tasks/"+task.ID+"/lock").Error(http.StatusLocked, "TASK_LOCKED")
+	asEditor("DELETE", "/api/tasks/"+task.ID+"/lock").Error(http.StatusLocked, "TASK_LOCKED")
+	h.Do("PATCH", "/api/tasks/"+task.ID+"/toggle", nil).Expect(http.StatusOK)
+
+	h.Do("DELETE", "/api/tasks/"+task.ID+"/lock", nil).Expect(http.StatusOK)
+	asEditor("PATCH", "/api/tasks/"+task.ID+"/toggle").Expect(http.StatusOK)
+	h.Do("POST", "/api/tasks/404/lock", nil).Error(http.StatusNotFound, "TASK_NOT_FOUND")
+}
+
+func TestAPI_Reprioritize(t *testing.T) {
+	h := New(t)
+	for _, priority := range []string{"🔥", "🔥", "⚡"} {
+		h.Do("POST", "/api/tasks", map[string]string{"title": "Task " + priority, "priority": priority}).Expect(http.StatusCreated)
+	}
+
+	var result handler.ReprioritizeResult
+	future := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+	h.Do("POST", "/api/tasks/reprioritize?priority=urgent&createdBefore="+future, map[string]string{"priority": "⭐"}).JSON(http.StatusOK, &result)
+	if result.Updated != 2 || len(result.Tasks) != 2 || result.Tasks[0].Priority != service.PriorityImportant {
+		t.Errorf("expected the 🔥 tasks to become ⭐, got %+v", result)
+	}
+
+	past := url.QueryEscape(time.Now().AddDate(0, 0, -30).Format(time.RFC3339))
+	h.Do("POST", "/api/tasks/reprioritize?createdBefore="+past, map[string]string{"priority": "💡"}).JSON(http.StatusOK, &result)
+	if result.Updated != 0 {
+		t.Errorf("expected no task older than 30 days, got %+v", result)
+	}
+
+	h.Do("POST", "/api/tasks/reprioritize", map[string]string{}).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("POST", "/api/tasks/reprioritize", map[string]string{"color": "#000000"}).Error(http.StatusBadRequest, "INVALID_COLOR")
+}
+
+func TestAPI_Merge(t *testing.T) {
+	h := New(t)
+	var target, source model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Ship release"}).JSON(http.StatusCreated, &target)
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Ship the release", "priority": "🔥"}).JSON(http.StatusCreated, &source)
+
+	var result handler.MergeResult
+	h.Do("POST", "/api/tasks/"+target.ID+"/merge", map[string]string{"source": source.ID}).JSON(http.StatusOK, &result)
+	if result.Target.ID != target.ID || result.Target.Priority != service.PriorityUrgentImportant || !result.Source.Completed {
+		t.Errorf("expected the source to be merged into the target and completed, got %+v", result)
+	}
+
+	h.Do("POST", "/api/tasks/"+target.ID+"/merge", map[string]string{"source": target.ID}).Error(http.StatusBadRequest, "MERGE_WITH_SELF")
+	h.Do("POST", "/api/tasks/"+target.ID+"/merge", map[string]string{}).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("POST", "/api/tasks/404/merge", map[string]string{"source": source.ID}).Error(http.StatusNotFound, "TASK_NOT_FOUND")
+}
+
+func TestAPI_ChangePriority(t *testing.T) {
+	h := New(t)
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Ship release", "priority": "⭐"}).JSON(http.StatusCreated, &task)
+
+	var changed model.Task
+	h.Do("POST", "/api/tasks/"+task.ID+"/priority", map[string]string{"priority": "p1"}).JSON(http.StatusOK, &changed)
+	if changed.Priority != service.PriorityUrgentImportant {
+		t.Errorf("expected priority 🔥, got %q", changed.Priority)
+	}
+
+	var history handler.TaskHistory
+	h.Do("GET", "/api/tasks/"+task.ID+"/history", nil).JSON(http.StatusOK, &history)
+	if history.TaskID != task.ID || len(history.Transitions) != 1 || history.Transitions[0].From != "⭐" || history.Transitions[0].To != "🔥" {
+		t.Errorf("expected the transition from ⭐ to 🔥, got %+v", history)
+	}
+
+	h.Do("POST", "/api/tasks/"+task.ID+"/priority", map[string]string{}).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("POST", "/api/tasks/"+task.ID+"/priority", map[string]string{"priority": "someday"}).Error(http.StatusBadRequest, "INVALID_PRIORITY")
+	h.Do("POST", "/api/tasks/404/priority", map[string]string{"priority": "⭐"}).Error(http.StatusNotFound, "TASK_NOT_FOUND")
+	h.Do("GET", "/api/tasks/404/history", nil).Error(http.StatusNotFound, "TASK_NOT_FOUND")
+}
+
+func TestAPI_Tombstones(t *testing.T) {
+	h := New(t)
+	since := time.Now().Add(-time.Second).UTC().Format(time.RFC3339)
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Ship release"}).JSON(http.StatusCreated, &task)
+	h.Do("DELETE", "/api/tasks/"+task.ID, nil).Expect(http.StatusOK)
+
+	var list handler.TombstoneList
+	h.Do("GET", "/api/tombstones?since="+since, nil).JSON(http.StatusOK, &list)
+	if len(list.Tombstones) != 1 || list.Tombstones[0].ID != task.ID || list.Until.IsZero() {
+		t.Errorf("expected the tombstone of the deleted task, got %+v", list)
+	}
+	h.Do("GET", "/api/tombstones?since="+list.Until.UTC().Format(time.RFC3339Nano), nil).JSON(http.StatusOK, &list)
+	if len(list.Tombstones) != 0 {
+		t.Errorf("expected no tombstones after the until of the last response, got %+v", list.Tombstones)
+	}
+
+	h.Do("GET", "/api/tombstones", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("GET", "/api/tombstones?since=2020-01-01T00:00:00Z", nil).Error(http.StatusGone, "TOMBSTONES_EXPIRED")
+}
+
+func TestAPI_NotificationPreferences(t *testing.T) {
+	h := New(t)
+	var prefs handler.NotificationPreferences
+	h.Do("GET", "/api/users/me/preferences", nil).JSON(http.StatusOK, &prefs)
+	if prefs.Mode != "all" || prefs.Channels == nil || len(prefs.Channels) != 0 {
+		t.Errorf("expected the defaults, got %+v", prefs)
+	}
+
+	update := map[string]any{"mode": "digest-only", "channels": []string{"webhook"}, "webhookUrl": "https://hooks.example.com/me"}
+	h.Do("PUT", "/api/users/me/preferences", update).JSON(http.StatusOK, &prefs)
+	h.Do("GET", "/api/users/me/preferences", nil).JSON(http.StatusOK, &prefs)
+	if prefs.Mode != "digest-only" || len(prefs.Channels) != 1 || prefs.WebhookURL != "https://hooks.example.com/me" {
+		t.Errorf("expected the stored preferences, got %+v", prefs)
+	}
+
+	h.Do("PUT", "/api/users/me/preferences", map[string]any{"channels": []string{"webhook"}}).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("PUT", "/api/users/me/preferences", map[string]any{"channels": []string{"email"}}).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("PUT", "/api/users/me/preferences", map[string]any{"mode": "loud"}).Error(http.StatusBadRequest, "INVALID_INPUT")
+}
+
+func TestAPI_WIPLimits(t *testing.T) {
+	h := New(t, func(c *app.Configuration) { c.WIPLimits = []string{"🔥=1"} })
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Fix outage", "priority": "🔥"}).Expect(http.StatusCreated)
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Fix another outage", "priority": "🔥"}).Error(http.StatusConflict, "WIP_LIMIT_EXCEEDED")
+
+	warn := New(t, func(c *app.Configuration) { c.WIPLimits, c.WIPMode = []string{"🔥=1"}, "warn" })
+	warn.Do("POST", "/api/tasks", map[string]string{"title": "Fix outage", "priority": "🔥"}).Expect(http.StatusCreated)
+	resp := warn.Do("POST", "/api/tasks", map[string]string{"title": "Fix another outage", "priority": "🔥"}).Expect(http.StatusCreated)
+	if got := resp.Header.Get("Warning"); got != `299 - "2 open p1 tasks exceed the WIP limit of 1"` {
+		t.Errorf("expected a WIP warning, got %q", got)
+	}
+}
+
+func TestAPI_StaleTasks(t *testing.T) {
+	h := New(t)
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Ship release"}).Expect(http.StatusCreated)
+
+	var listed []struct {
+		ID      string `json:"id"`
+		AgeDays int    `json:"ageDays"`
+		Stale   bool   `json:"stale"`
+	}
+	h.Do("GET", "/api/tasks", nil).JSON(http.StatusOK, &listed)
+	if len(listed) != 1 || listed[0].AgeDays != 0 || listed[0].Stale {
+		t.Errorf("expected a new task that is not stale, got %+v", listed)
+	}
+	h.Do("GET", "/api/tasks?stale=true", nil).JSON(http.StatusOK, &listed)
+	if len(listed) != 0 {
+		t.Errorf("expected no stale tasks, got %+v", listed)
+	}
+
+	h.Do("GET", "/api/tasks?stale=yes", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("GET", "/api/tasks?stale=true&status=completed", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+}
+
+func TestAPI_Changes(t *testing.T) {
+	h := New(t)
+	var start service.ChangeSet
+	h.Do("GET", "/api/changes", nil).JSON(http.StatusOK, &start)
+
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Sync me"}).JSON(http.StatusCreated, &task)
+	var set service.ChangeSet
+	h.Do("GET", "/api/changes?since="+url.QueryEscape(start.Cursor), nil).JSON(http.StatusOK, &set)
+	if len(set.Changes) != 1 || set.Changes[0].Type != "task.created" || set.Changes[0].Task.ID != task.ID {
+		t.Errorf("expected the created task, got %+v", set.Changes)
+	}
+
+	begin := time.Now()
+	var next service.ChangeSet
+	h.Do("GET", "/api/changes?wait=50ms&since="+url.QueryEscape(set.Cursor), nil).JSON(http.StatusOK, &next)
+	if len(next.Changes) != 0 || next.Cursor != set.Cursor || time.Since(begin) < 50*time.Millisecond {
+		t.Errorf("expected to wait for no changes, got %+v after %s", next, time.Since(begin))
+	}
+
+	h.Do("GET", "/api/changes?wait=2m", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("GET", "/api/changes?since=nonsense", nil).Error(http.StatusBadRequest, "INVALID_CURSOR")
+}
+
+func TestAPI_ChangesConcurrencyLimit(t *testing.T) {
+	h := New(t, func(c *app.Configuration) { c.MaxConcurrentRequests = 1 })
+	var start service.ChangeSet
+	h.Do("GET", "/api/changes", nil).JSON(http.StatusOK, &start)
+
+	// Waiting long-polls hold no slot of the limit
+	polled := make(chan int, 2)
+	for range 2 {
+		go func() {
+			resp, err := h.Server.Client().Do(h.Request("GET", "/api/changes?wait=1s&since="+url.QueryEscape(start.Cursor), nil))
+			if err != nil {
+				polled <- 0
+				return
+			}
+			resp.Body.Close()
+			polled <- resp.StatusCode
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Wake the polls"}).Expect(http.StatusCreated)
+	for range 2 {
+		if status := <-polled; status != http.StatusOK {
+			t.Errorf("expected the long-polls to get the change, got status %d", status)
+		}
+	}
+}
+
+func TestAPI_Activity(t *testing.T) {
+	h := New(t)
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Plan sprint"}).JSON(http.StatusCreated, &task)
+	h.Do("PATCH", "/api/tasks/"+task.ID+"/toggle", nil).JSON(http.StatusOK, &task)
+
+	var feed handler.ActivityFeed
+	h.Do("GET", "/api/activity?limit=1", nil).JSON(http.StatusOK, &feed)
+	if len(feed.Activity) != 1 || feed.Activity[0].Kind != service.ActivityCompleted || feed.Activity[0].TaskID != task.ID || !feed.More {
+		t.Fatalf("expected the completion with more, got %+v", feed)
+	}
+	var older handler.ActivityFeed
+	h.Do("GET", "/api/activity?before="+strconv.FormatInt(feed.Next, 10), nil).JSON(http.StatusOK, &older)
+	if len(older.Activity) != 1 || older.Activity[0].Kind != service.ActivityCreated || older.More {
+		t.Errorf("expected only the creation before the completion, got %+v", older)
+	}
+
+	h.Do("GET", "/api/activity?limit=500", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("GET", "/api/activity?before=latest", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+}
+
+func TestAPI_Authentication(t *testing.T) {
+	h := New(t)
+
+	req := h.Request("GET", "/api/tasks", nil)
+	req.Header.Del("Authorization")
+	h.Send(req).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+
+	req = h.Request("GET", "/api/tasks", nil)
+	req.Header.Set("Authorization", "Bearer nope")
+	h.Send(req).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+}
+
+func TestAPI_UnmatchedRoutes(t *testing.T) {
+	h := New(t)
+
+	h.Do("GET", "/api/nope", nil).Error(http.StatusNotFound, "NOT_FOUND")
+	resp := h.Do("PUT", "/api/tasks", nil)
+	resp.Error(http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED")
+	if allow := resp.Header.Get("Allow"); allow != "GET, POST" {
+		t.Errorf("expected Allow: GET, POST, got %q", allow)
+	}
+
+	req := h.Request("OPTIONS", "/api/tasks", nil)
+	req.Header.Del("Authorization")
+	h.Send(req).Expect(http.StatusNoContent)
+}
+
+func TestAPI_ImportICS(t *testing.T) {
+	h := New(t)
+	calendar := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:release\r\nSUMMARY:Ship release\r\nPRIORITY:1\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+	var result handler.ImportResult
+	h.Do("POST", "/api/import/ics", calendar).JSON(http.StatusCreated, &result)
+	if result.Imported != 1 || result.Tasks[0].Priority != service.PriorityUrgentImportant {
+		t.Errorf("expected one urgent task to be imported, got %+v", result)
+	}
+	h.Do("POST", "/api/import/ics", calendar).JSON(http.StatusOK, &result)
+	if result.Imported != 0 || result.Duplicates != 1 {
+		t.Errorf("expected the second import to be a duplicate, got %+v", result)
+	}
+	h.Do("POST", "/api/import/ics", "not a calendar").Error(http.StatusBadRequest, "INVALID_INPUT")
+}
+
+func TestAPI_Export(t *testing.T) {
+	h := New(t)
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Ship release", "priority": "🔥"}).JSON(http.StatusCreated, &task)
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Water plants"}).Expect(http.StatusCreated)
+
+	csv := h.Do("GET", "/api/tasks/export?format=csv&priority=🔥", nil).Expect(http.StatusOK)
+	if got := csv.Header.Get("Content-Disposition"); got != `attachment; filename="tasks.csv"` {
+		t.Errorf("expected an attachment named tasks.csv, got %q", got)
+	}
+	if body := string(csv.Body); !strings.Contains(body, "Ship release") || strings.Contains(body, "Water plants") {
+		t.Errorf("expected only the filtered task, got %s", body)
+	}
+
+	var tasks []model.Task
+	h.Do("GET", "/api/tasks/export?sort=title&limit=1", nil).JSON(http.StatusOK, &tasks)
+	if len(tasks) != 2 || tasks[0].Title != "Ship release" {
+		t.Errorf("expected both tasks by title, unpaged, got %+v", tasks)
+	}
+
+	// Exported calendars import as duplicates of the tasks they hold.
+	calendar := h.Do("GET", "/api/tasks/export?format=ics", nil).Expect(http.StatusOK)
+	var result handler.ImportResult
+	h.Do("POST", "/api/import/ics", string(calendar.Body)).JSON(http.StatusOK, &result)
+	if result.Imported != 0 || result.Duplicates != 2 {
+		t.Errorf("expected the exported tasks to be duplicates, got %+v", result)
+	}
+
+	h.Do("GET", "/api/tasks/export?format=pdf", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+
+	// The former path serves the same exports, marked deprecated
+	legacy := h.Do("GET", "/api/export", nil)
+	legacy.JSON(http.StatusOK, &tasks)
+	if len(tasks) != 2 {
+		t.Errorf("expected both tasks at the former path, got %+v", tasks)
+	}
+	if legacy.Header.Get("Deprecation") == "" || legacy.Header.Get("Sunset") == "" || legacy.Header.Get("Link") != `<tasks/export>; rel="successor-version"` {
+		t.Errorf("expected the former path to be marked deprecated, got headers %v", legacy.Header)
+	}
+	if h.Do("GET", "/api/tasks/export", nil).Expect(http.StatusOK).Header.Get("Deprecation") != "" {
+		t.Error("expected the current path not to be marked deprecated")
+	}
+}
+
+func TestAPI_ExportNDJSON(t *testing.T) {
+	h := New(t)
+	for _, title := range []string{"Water plants", "Ship release", "Fix outage"} {
+		h.Do("POST", "/api/tasks", map[string]string{"title": title}).Expect(http.StatusCreated)
+	}
+
+	resp := h.Do("GET", "/api/tasks/export?format=ndjson", nil).Expect(http.StatusOK)
+	if got := resp.Header.Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("expected NDJSON, got %q", got)
+	}
+	if got := ndjsonTitles(t, resp.Body); !slices.Equal(got, []string{"Water plants", "Ship release", "Fix outage"}) {
+		t.Errorf("expected the tasks in creation order, got %v", got)
+	}
+	resp = h.Do("GET", "/api/tasks/export?format=ndjson&sort=title", nil).Expect(http.StatusOK)
+	if got := ndjsonTitles(t, resp.Body); !slices.Equal(got, []string{"Fix outage", "Ship release", "Water plants"}) {
+		t.Errorf("expected the tasks by title, got %v", got)
+	}
+
+	// No matching tasks is an empty export rather than an error
+	resp = h.Do("GET", "/api/tasks/export?format=ndjson&status=completed", nil).Expect(http.StatusOK)
+	if len(resp.Body) != 0 || resp.Header.Get("Content-Disposition") != `attachment; filename="tasks.ndjson"` {
+		t.Errorf("expected an empty tasks.ndjson, got %q", resp.Body)
+	}
+	h.Do("GET", "/api/tasks/export?format=ndjson&priority=none", nil).Error(http.StatusBadRequest, "INVALID_PRIORITY")
+}
+
+func ndjsonTitles(t *testing.T, body []byte) []string {
+	t.Helper()
+	var titles []string
+	for line := range strings.SplitSeq(strings.TrimSuffix(string(body), "\n"), "\n") {
+		var task model.Task
+		if err := json.Unmarshal([]byte(line), &task); err != nil {
+			t.Fatalf("expected a task per line, got %q: %v", line, err)
+		}
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+func TestAPI_Seed(t *testing.T) {
+	h := New(t)
+
+	var result seed.Result
+	h.Do("POST", "/api/dev/seed?count=5", nil).JSON(http.StatusOK, &result)
+	if result.Created != 5 {
+		t.Errorf("expected 5 sample tasks, got %+v", result)
+	}
+	h.Do("POST", "/api/dev/seed?count=0", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+
+	prod := New(t, func(c *app.Configuration) { c.Environment = app.Prod })
+	prod.Do("POST", "/api/dev/seed", nil).Error(http.StatusNotFound, "NOT_FOUND")
+}
+
+func TestAPI_PushSubscriptions(t *testing.T) {
+	keys, err := notify.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(t, func(c *app.Configuration) {
+		c.VAPIDPublicKey, c.VAPIDPrivateKey, c.VAPIDSubject = keys.PublicKey(), keys.PrivateKey(), "mailto:ops@example.com"
+	})
+
+	var key map[string]string
+	h.Do("GET", "/api/push/key", nil).JSON(http.StatusOK, &key)
+	if key["publicKey"] != keys.PublicKey() {
+		t.Errorf("expected the VAPID public key, got %+v", key)
+	}
+
+	browser, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := make([]byte, 16)
+	rand.Read(secret)
+	endpoint := "https://push.example.com/send/1"
+	sub := map[string]any{"endpoint": endpoint, "keys": map[string]string{
+		"p256dh": base64.RawURLEncoding.EncodeToString(browser.PublicKey().Bytes()),
+		"auth":   base64.RawURLEncoding.EncodeToString(secret),
+	}}
+
+	h.Do("POST", "/api/push/subscriptions", sub).Expect(http.StatusCreated)
+	h.Do("POST", "/api/push/subscriptions", map[string]string{"endpoint": "http://insecure"}).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("DELETE", "/api/push/subscriptions", map[string]string{"endpoint": endpoint}).Expect(http.StatusOK)
+	h.Do("DELETE", "/api/push/subscriptions", map[string]string{"endpoint": endpoint}).Error(http.StatusNotFound, "NOT_FOUND")
+}
+
+func TestAPI_WebhookDeliveries(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer hook.Close()
+	h := New(t, func(c *app.Configuration) {
+		c.Store, c.StoreDSN = "file", filepath.Join(t.TempDir(), "tasks.json")
+		c.Workspaces = []string{"team"}
+		c.EventWebhookURLs, c.EventWebhookMaxAttempts, c.EventRelayInterval = []string{hook.URL}, 2, 10*time.Millisecond
+		c.EventWebhookRetryBackoff = time.Millisecond
+	})
+	if _, err := h.App.Auth().SetWorkspaces(User, []string{"team"}); err != nil {
+		t.Fatal(err)
+	}
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Announce"}).Expect(http.StatusCreated)
+
+	var list handler.DeliveryList
+	for deadline := time.Now().Add(5 * time.Second); len(list.Deliveries) == 0 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		h.Admin("GET", "/api/webhooks/1/deliveries?status=dead", nil).JSON(http.StatusOK, &list)
+	}
+	if len(list.Deliveries) != 1 || list.Deliveries[0].Attempts != 2 || list.Deliveries[0].Event.Task.Title != "Announce" {
+		t.Fatalf("expected the event to be dead-lettered after 2 attempts, got %+v", list)
+	}
+
+	e := h.Admin("POST", "/api/webhooks/1/deliveries/1/redeliver", nil).Error(http.StatusBadGateway, "REDELIVERY_FAILED")
+	if !strings.Contains(e.Error, "500") {
+		t.Errorf("expected the webhook response in the error, got %q", e.Error)
+	}
+	h.Admin("POST", "/api/webhooks/1/deliveries/2/redeliver", nil).Error(http.StatusNotFound, "NOT_FOUND")
+	h.Admin("GET", "/api/webhooks/2/deliveries", nil).Error(http.StatusNotFound, "NOT_FOUND")
+	h.Admin("GET", "/api/webhooks/1/deliveries?status=lost", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+
+	// Deliveries hold the events of every task, so API keys get none.
+	for _, path := range []string{"/api/webhooks", "/api/webhooks/1/deliveries", "/w/team/api/webhooks/1/deliveries"} {
+		h.Do("GET", path, nil).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+	}
+	h.Do("POST", "/api/webhooks/1/deliveries/1/redeliver", nil).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+	req := h.Request("GET", "/api/webhooks/1/deliveries", nil)
+	req.Header.Set("X-Workspace", "team")
+	h.Send(req).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+	h.Admin("GET", "/w/team/api/webhooks/1/deliveries", nil).Expect(http.StatusOK)
+}
+
+func TestAPI_EventReplay(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer hook.Close()
+	h := New(t, func(c *app.Configuration) {
+		c.Store, c.StoreDSN = "file", filepath.Join(t.TempDir(), "tasks.json")
+		c.EventWebhookURLs, c.EventRelayInterval, c.EventHistorySize = []string{hook.URL}, 10*time.Millisecond, 2
+	})
+	for _, title := range []string{"One", "Two", "Three"} {
+		h.Do("POST", "/api/tasks", map[string]string{"title": title}).Expect(http.StatusCreated)
+	}
+
+	var replay handler.EventReplay
+	for deadline := time.Now().Add(5 * time.Second); replay.Next < 3 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		h.Do("GET", "/api/events/replay?from=1", nil).JSON(http.StatusOK, &replay)
+	}
+	if len(replay.Events) != 2 || replay.Events[0].Task.Title != "Two" || replay.Next != 3 || replay.More {
+		t.Fatalf("expected the events of the last two tasks, got %+v", replay)
+	}
+	h.Do("GET", "/api/events/replay?from=1&limit=1", nil).JSON(http.StatusOK, &replay)
+	if len(replay.Events) != 1 || replay.Next != 2 || !replay.More {
+		t.Errorf("expected a page of one event with more to follow, got %+v", replay)
+	}
+	h.Do("GET", "/api/events/replay?from=3", nil).JSON(http.StatusOK, &replay)
+	if len(replay.Events) != 0 || replay.Next != 3 {
+		t.Errorf("expected no events after the newest, got %+v", replay)
+	}
+
+	e := h.Do("GET", "/api/events/replay?from=0", nil).Error(http.StatusGone, "EVENTS_EXPIRED")
+	if !strings.Contains(e.Error, "replay from 1") {
+		t.Errorf("expected the oldest replayable sequence number in the error, got %q", e.Error)
+	}
+	h.Do("GET", "/api/events/replay", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("GET", "/api/events/replay?from=1&limit=0", nil).Error(http.StatusBadRequest, "INVALID_INPUT")
+}
+
+func TestAPI_StoreFailover(t *testing.T) {
+	h := New(t, func(c *app.Configuration) {
+		c.Store, c.StoreDSN = "file", filepath.Join(t.TempDir(), "tasks.json")
+		c.StoreFailover = true
+	})
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Before the outage"}).JSON(http.StatusCreated, &task)
+
+	h.Admin("PUT", "/admin/faults", map[string]any{"errorRate": 1, "targets": []string{"store"}}).Expect(http.StatusOK)
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Lost"}).Error(http.StatusServiceUnavailable, "STORE_READ_ONLY")
+	h.Do("PATCH", "/api/tasks/"+task.ID+"/toggle", nil).Error(http.StatusServiceUnavailable, "STORE_READ_ONLY")
+
+	var tasks []model.Task
+	h.Do("GET", "/api/tasks", nil).JSON(http.StatusOK, &tasks)
+	if len(tasks) != 1 || tasks[0].ID != task.ID {
+		t.Errorf("expected the task to be read from the snapshot, got %+v", tasks)
+	}
+}
+
+func TestAPI_Workspaces(t *testing.T) {
+	h := New(t, func(c *app.Configuration) { c.Workspaces = []string{"team"} })
+
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Default task"}).Expect(http.StatusCreated)
+	h.Do("GET", "/w/team/api/tasks", nil).Error(http.StatusForbidden, "WORKSPACE_FORBIDDEN")
+
+	if _, err := h.App.Auth().SetWorkspaces(User, []string{"team"}); err != nil {
+		t.Fatal(err)
+	}
+	var task model.Task
+	h.Do("POST", "/w/team/api/tasks", map[string]string{"title": "Team task"}).JSON(http.StatusCreated, &task)
+
+	titles := func(req *http.Request) []string {
+		var tasks []model.Task
+		h.Send(req).JSON(http.StatusOK, &tasks)
+		var titles []string
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+	if got := titles(h.Request("GET", "/api/tasks", nil)); len(got) != 1 || got[0] != "Default task" {
+		t.Errorf("expected the default workspace to list its own task, got %v", got)
+	}
+	byHeader := h.Request("GET", "/api/tasks", nil)
+	byHeader.Header.Set("X-Workspace", "team")
+	if got := titles(byHeader); len(got) != 1 || got[0] != "Team task" {
+		t.Errorf("expected the header to select the team workspace, got %v", got)
+	}
+	h.Do("PATCH", "/w/team/api/tasks/"+task.ID+"/toggle", nil).Expect(http.StatusOK)
+
+	h.Do("GET", "/w/sales/api/tasks", nil).Error(http.StatusNotFound, "WORKSPACE_NOT_FOUND")
+	unknown := h.Request("GET", "/api/tasks", nil)
+	unknown.Header.Set("X-Workspace", "sales")
+	h.Send(unknown).Error(http.StatusNotFound, "WORKSPACE_NOT_FOUND")
+}
+
+func TestAPI_WorkerHealth(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusBadGateway)
+	}))
+	defer hook.Close()
+	h := New(t, func(c *app.Configuration) {
+		c.Workspaces = []string{"team"}
+		c.Store, c.StoreDSN = "file", filepath.Join(t.TempDir(), "tasks.json")
+		c.EventWebhookURLs, c.EventRelayInterval = []string{hook.URL}, 10*time.Millisecond
+		c.ArchiveAfterDays, c.ArchiveFile = 30, filepath.Join(t.TempDir(), "archive.jsonl")
+	})
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Relayed"}).Expect(http.StatusCreated)
+
+	var report health.Report
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		h.Do("GET", "/health", nil).JSON(http.StatusOK, &report)
+		if report.Components["webhooks"].Status == health.StatusDown {
+			break
+		}
+	}
+	if report.Status != health.StatusDegraded {
+		t.Errorf("expected a failing webhook to degrade the health, got %s", report.Status)
+	}
+	webhooks := report.Components["webhooks"]
+	if webhooks.Critical || !strings.Contains(webhooks.LastError, "502") {
+		t.Errorf("expected the webhooks to report the failed delivery, got %+v", webhooks)
+	}
+	for _, name := range []string{"store", "store/team", "events", "events/team", "webhooks/team", "scheduler/archive", "scheduler/archive/team"} {
+		if status, ok := report.Components[name]; !ok || status.Status != health.StatusUp {
+			t.Errorf("expected component %s to be up, got %+v", name, status)
+		}
+	}
+}
+
+func TestAPI_WorkspaceWorkers(t *testing.T) {
+	var mu sync.Mutex
+	relayed := make(map[string]string) // Workspace by task title
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e store.Event
+		json.NewDecoder(r.Body).Decode(&e)
+		mu.Lock()
+		relayed[e.Task.Title] = e.Workspace
+		mu.Unlock()
+	}))
+	defer hook.Close()
+	h := New(t, func(c *app.Configuration) {
+		c.Workspaces = []string{"team"}
+		c.Store, c.StoreDSN = "file", filepath.Join(t.TempDir(), "tasks.json")
+		c.EventWebhookURLs, c.EventRelayInterval, c.EventHistorySize = []string{hook.URL}, 10*time.Millisecond, 10
+	})
+	if _, err := h.App.Auth().SetWorkspaces(User, []string{"team"}); err != nil {
+		t.Fatal(err)
+	}
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Default task"}).Expect(http.StatusCreated)
+	for _, title := range []string{"Team task", "Other team task"} {
+		h.Do("POST", "/w/team/api/tasks", map[string]string{"title": title}).Expect(http.StatusCreated)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		mu.Lock()
+		n := len(relayed)
+		mu.Unlock()
+		if n == 3 {
+			break
+		}
+	}
+	mu.Lock()
+	if relayed["Default task"] != "" || relayed["Team task"] != "team" || relayed["Other team task"] != "team" {
+		t.Errorf("expected the events of both workspaces to be relayed with their workspace, got %v", relayed)
+	}
+	mu.Unlock()
+
+	var replay handler.EventReplay
+	h.Do("GET", "/w/team/api/events/replay?from=0", nil).JSON(http.StatusOK, &replay)
+	if len(replay.Events) != 2 || replay.Events[0].Task.Title != "Team task" {
+		t.Errorf("expected the team events to be replayed, got %+v", replay)
+	}
+
+	var stats service.Stats
+	h.Do("GET", "/w/team/api/stats", nil).JSON(http.StatusOK, &stats)
+	if stats.Created != 2 || stats.Open != 2 {
+		t.Errorf("expected the team counts only, got %+v", stats)
+	}
+	exposed := string(h.Do("GET", "/metrics", nil).Expect(http.StatusOK).Body)
+	for _, want := range []string{`tasks_created_total{workspace="default"} 1`, `tasks_created_total{workspace="team"} 2`, `tasks_open{workspace="team"} 2`} {
+		if !strings.Contains(exposed, want+"\n") {
+			t.Errorf("expected the metrics to contain %q", want)
+		}
+	}
+}
+
+func TestAPI_WorkspacePlans(t *testing.T) {
+	h := New(t, func(c *app.Configuration) {
+		c.Workspaces = []string{"team"}
+		c.Plans = []string{"free=0.001/3/1"}
+		c.WorkspacePlans = []string{"team=free"}
+	})
+	if _, err := h.App.Auth().SetWorkspaces(User, []string{"team"}); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Do("POST", "/w/team/api/tasks", map[string]string{"title": "Team task"}).Expect(http.StatusCreated)
+	h.Do("POST", "/w/team/api/tasks", map[string]string{"title": "One too many"}).Error(http.StatusPaymentRequired, "QUOTA_EXCEEDED")
+
+	var usage handler.Usage
+	h.Do("GET", "/w/team/api/usage", nil).JSON(http.StatusOK, &usage)
+	if usage.Workspace != "team" || usage.Plan != "free" || usage.Tasks != (handler.TaskUsage{Used: 1, Limit: 1}) ||
+		usage.Requests.Burst != 3 || usage.Requests.Remaining == nil || *usage.Requests.Remaining != 0 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+	h.Do("GET", "/w/team/api/usage", nil).Error(http.StatusTooManyRequests, "WORKSPACE_RATE_LIMITED")
+
+	// The default workspace has no plan
+	var unlimited handler.Usage
+	h.Do("GET", "/api/usage", nil).JSON(http.StatusOK, &unlimited)
+	if unlimited.Workspace != "default" || unlimited.Plan != "" || unlimited.Tasks.Limit != 0 || unlimited.Requests.Remaining != nil {
+		t.Errorf("expected the default workspace to be unlimited, got %+v", unlimited)
+	}
+}
+
+func TestAdmin_Overview(t *testing.T) {
+	h := New(t, func(c *app.Configuration) {
+		c.Workspaces = []string{"team"}
+		c.Plans = []string{"free=0/0/10"}
+		c.WorkspacePlans = []string{"team=free"}
+	})
+	if _, err := h.App.Auth().SetWorkspaces(User, []string{"team"}); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Do("POST", "/w/team/api/tasks", map[string]string{"title": "Team task"}).Expect(http.StatusCreated)
+	h.Do("GET", "/w/team/api/tasks", nil).Expect(http.StatusOK)
+
+	var workspaces []struct {
+		Name  string `json:"name"`
+		Plan  string `json:"plan"`
+		Tasks struct {
+			Total int `json:"total"`
+			Open  int `json:"open"`
+		} `json:"tasks"`
+		StorageBytes *int64            `json:"storageBytes"`
+		Requests     activity.Requests `json:"requests"`
+	}
+	h.Admin("GET", "/admin/api/workspaces", nil).JSON(http.StatusOK, &workspaces)
+	if len(workspaces) != 2 || workspaces[0].Name != "default" || workspaces[1].Name != "team" {
+		t.Fatalf("expected the default and team workspaces, got %+v", workspaces)
+	}
+	team := workspaces[1]
+	if team.Plan != "free" || team.Tasks.Total != 1 || team.Tasks.Open != 1 || team.StorageBytes == nil ||
+		team.Requests.Total != 2 || team.Requests.Writes != 1 || team.Requests.LastAt == nil {
+		t.Errorf("unexpected team overview %+v", team)
+	}
+
+	var users []struct {
+		Name       string            `json:"name"`
+		Workspaces []string          `json:"workspaces"`
+		Requests   activity.Requests `json:"requests"`
+	}
+	h.Admin("GET", "/admin/api/users", nil).JSON(http.StatusOK, &users)
+	if len(users) != 1 || users[0].Name != User || len(users[0].Workspaces) != 2 || users[0].Requests.Total != 2 {
+		t.Errorf("unexpected users overview %+v", users)
+	}
+}
+
+func TestAdmin_LogLevel(t *testing.T) {
+	h := New(t)
+
+	h.Do("GET", "/admin/loglevel", nil).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+	req := h.Request("PUT", "/admin/loglevel", map[string]string{"level": "debug"})
+	req.Header.Del("Authorization")
+	h.Send(req).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+	if level := h.App.LogLevel(); level != "error" {
+		t.Fatalf("expected unauthenticated requests to leave the level alone, got %s", level)
+	}
+
+	var level struct {
+		Level string `json:"level"`
+	}
+	h.Admin("PUT", "/admin/loglevel", map[string]string{"level": "debug"}).JSON(http.StatusOK, &level)
+	if level.Level != "debug" || h.App.LogLevel() != "debug" {
+		t.Errorf("expected the level to change to debug, got %s", level.Level)
+	}
+	h.Admin("PUT", "/admin/loglevel", map[string]string{"level": "loud"}).Expect(http.StatusBadRequest)
+}
+
+func TestAdmin_IssueKey(t *testing.T) {
+	h := New(t)
+	path := "/admin/users/" + User + "/keys"
+
+	req := h.Request("POST", path, map[string]string{"name": "stolen"})
+	req.Header.Del("Authorization")
+	h.Send(req).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+	h.Do("POST", path, map[string]string{"name": "stolen"}).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+	if keys, _ := h.App.Auth().Keys(User); len(keys) != 1 {
+		t.Fatalf("expected unauthenticated requests not to issue keys, got %+v", keys)
+	}
+
+	h.Admin("POST", path, map[string]string{"name": "issued"}).Expect(http.StatusCreated)
+	if keys, _ := h.App.Auth().Keys(User); len(keys) != 2 {
+		t.Errorf("expected the admin to issue a key, got %+v", keys)
+	}
+}
+
+func TestAdmin_Bundle(t *testing.T) {
+	h := New(t, func(c *app.Configuration) { c.Workspaces = []string{"team"} })
+	if _, err := h.App.Auth().SetWorkspaces(User, []string{"team"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Move me", "color": "blue"}).JSON(http.StatusCreated, &task)
+	h.Do("POST", "/api/tasks/"+task.ID+"/priority", map[string]string{"priority": "🔥"}).Expect(http.StatusOK)
+
+	exported := h.Admin("POST", "/admin/export", nil).Expect(http.StatusOK)
+	var bundle service.Bundle
+	exported.JSON(http.StatusOK, &bundle)
+	if bundle.Workspace != "default" || len(bundle.Tasks) != 1 || len(bundle.Tasks[0].History) != 1 {
+		t.Fatalf("expected the task with its history, got %+v", bundle)
+	}
+	if cd := exported.Header.Get("Content-Disposition"); cd != `attachment; filename="default.ttm.json"` {
+		t.Errorf("unexpected Content-Disposition %q", cd)
+	}
+
+	var result struct {
+		Imported int               `json:"imported"`
+		IDs      map[string]string `json:"ids"`
+	}
+	h.Admin("POST", "/admin/import?workspace=team", bundle).JSON(http.StatusCreated, &result)
+	if result.Imported != 1 || result.IDs[task.ID] == "" {
+		t.Fatalf("expected the task to be imported, got %+v", result)
+	}
+	var moved []model.Task
+	h.Do("GET", "/w/team/api/tasks", nil).JSON(http.StatusOK, &moved)
+	if len(moved) != 1 || moved[0].ID != result.IDs[task.ID] || moved[0].Priority != "🔥" || !moved[0].CreatedAt.Equal(task.CreatedAt) {
+		t.Errorf("expected the task as exported, got %+v", moved)
+	}
+
+	h.Admin("POST", "/admin/import?workspace=nope", bundle).Expect(http.StatusNotFound)
+	bundle.Version = service.BundleVersion + 1
+	h.Admin("POST", "/admin/import?workspace=team", bundle).Expect(http.StatusBadRequest)
+}
+
+func TestAdmin_BundleRequiresToken(t *testing.T) {
+	bundle := service.Bundle{Format: service.BundleFormat, Version: service.BundleVersion, Tasks: []service.BundledTask{
+		{Task: model.Task{ID: "1", Title: "Smuggled in", Priority: "🔥", Color: "#dc3545", CreatedAt: time.Now()}},
+	}}
+
+	h := New(t)
+	req := h.Request("POST", "/admin/import", bundle)
+	req.Header.Del("Authorization")
+	h.Send(req).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+	h.Do("POST", "/admin/import", bundle).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+	h.Do("POST", "/admin/export", nil).Error(http.StatusUnauthorized, "UNAUTHORIZED")
+
+	var tasks []model.Task
+	h.Do("GET", "/api/tasks", nil).JSON(http.StatusOK, &tasks)
+	if len(tasks) != 0 {
+		t.Fatalf("expected unauthenticated imports to be refused, got %+v", tasks)
+	}
+
+	// Without an admin token the endpoints do not exist.
+	h = New(t, func(c *app.Configuration) { c.AuthRequired, c.AdminToken = false, "" })
+	h.Admin("POST", "/admin/import", bundle).Expect(http.StatusNotFound)
+	h.Admin("POST", "/admin/export", nil).Expect(http.StatusNotFound)
+	h.Do("GET", "/api/tasks", nil).JSON(http.StatusOK, &tasks)
+	if len(tasks) != 0 {
+		t.Errorf("expected no tasks to be imported, got %+v", tasks)
+	}
+}
@@ -0,0 +1,394 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/notify"
+	"gopkg.in/yaml.v3"
+)
+
+// specFile is the OpenAPI document of the API, relative to the module root.
+const specFile = "api/openapi.yaml"
+
+// openAPI is the part of an OpenAPI 3.0 document the contract tests use.
+type openAPI struct {
+	Paths      yaml.Node `yaml:"paths"` // Kept as node to replay in document order
+	Components struct {
+		Schemas    map[string]*schema   `yaml:"schemas"`
+		Parameters map[string]parameter `yaml:"parameters"`
+		Responses  map[string]response  `yaml:"responses"`
+	} `yaml:"components"`
+}
+
+type operation struct {
+	ID          string      `yaml:"operationId"`
+	Parameters  []parameter `yaml:"parameters"`
+	RequestBody *struct {
+		Content map[string]mediaType `yaml:"content"`
+	} `yaml:"requestBody"`
+	Responses map[string]response   `yaml:"responses"`
+	Security  []map[string][]string `yaml:"security"`
+}
+
+// admin reports whether the operation takes the admin token instead of an
+// API key.
+func (o operation) admin() bool {
+	return slices.ContainsFunc(o.Security, func(requirement map[string][]string) bool {
+		_, ok := requirement["adminToken"]
+		return ok
+	})
+}
+
+type parameter struct {
+	Ref     string  `yaml:"$ref"`
+	Name    string  `yaml:"name"`
+	In      string  `yaml:"in"`
+	Schema  *schema `yaml:"schema"`
+	Example any     `yaml:"example"`
+}
+
+type response struct {
+	Ref     string               `yaml:"$ref"`
+	Content map[string]mediaType `yaml:"content"`
+}
+
+type mediaType struct {
+	Schema  *schema `yaml:"schema"`
+	Example any     `yaml:"example"`
+}
+
+// schema holds the JSON Schema keywords the documented schemas use.
+type schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 string             `yaml:"type"`
+	Format               string             `yaml:"format"`
+	Enum                 []any              `yaml:"enum"`
+	Pattern              string             `yaml:"pattern"`
+	MaxLength            *int               `yaml:"maxLength"`
+	Minimum              *float64           `yaml:"minimum"`
+	Maximum              *float64           `yaml:"maximum"`
+	Nullable             bool               `yaml:"nullable"`
+	Properties           map[string]*schema `yaml:"properties"`
+	Required             []string           `yaml:"required"`
+	AdditionalProperties *bool              `yaml:"additionalProperties"`
+	Items                *schema            `yaml:"items"`
+}
+
+// TestContract replays the example of every documented operation against
+// the router, in document order, and validates the requests and responses
+// against their schemas. The examples must succeed.
+func TestContract(t *testing.T) {
+	h := contractHarness(t)
+	spec := loadSpec(t)
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Replayed"}).Expect(http.StatusCreated) // Task 1 of the examples
+	awaitDeadLetter(t, h)
+
+	for _, op := range spec.operations(t) {
+		t.Run(op.ID, func(t *testing.T) {
+			target, body, contentType := spec.example(t, op.Path, op.operation)
+			req := h.Request(op.Method, target, body)
+			if contentType != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+			if op.admin() {
+				req.Header.Set("Authorization", "Bearer "+AdminToken)
+			}
+			resp := h.Send(req)
+
+			documented, ok := op.Responses[strconv.Itoa(resp.StatusCode)]
+			if !ok || resp.StatusCode >= 300 {
+				t.Fatalf("%s %s: expected a documented success status, got %d: %s", op.Method, target, resp.StatusCode, resp.Body)
+			}
+			documented = spec.response(documented)
+			media, ok := documented.Content["application/json"]
+			if !ok {
+				return
+			}
+			if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Fatalf("expected a JSON response, got %q", ct)
+			}
+			var v any
+			if err := json.Unmarshal(resp.Body, &v); err != nil {
+				t.Fatalf("expected a JSON response, got %v: %s", err, resp.Body)
+			}
+			for _, problem := range spec.validate(media.Schema, v, "response") {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+// TestContract_RoutesDocumented fails on API routes missing from the document.
+func TestContract_RoutesDocumented(t *testing.T) {
+	h := contractHarness(t)
+	spec := loadSpec(t)
+	documented := make(map[string]bool)
+	for _, op := range spec.operations(t) {
+		documented[op.Method+" "+op.Path] = true
+	}
+
+	router := h.Server.Config.Handler.(*mux.Router)
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		methods, merr := route.GetMethods()
+		if err != nil || merr != nil || !strings.HasPrefix(path, "/api/") {
+			return nil
+		}
+		for _, method := range methods {
+			if method != http.MethodOptions && !documented[method+" "+path] {
+				t.Errorf("%s %s is not documented in %s", method, path, specFile)
+			}
+		}
+		return nil
+	})
+}
+
+// contractHarness starts the application with every API route enabled.
+// Its webhook fails the first delivery, which is dead-lettered, so the
+// examples can redeliver the event of task 1.
+func contractHarness(t *testing.T) *Harness {
+	keys, err := notify.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if received.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(hook.Close)
+	return New(t, func(c *app.Configuration) {
+		c.VAPIDPublicKey, c.VAPIDPrivateKey, c.VAPIDSubject = keys.PublicKey(), keys.PrivateKey(), "mailto:ops@example.com"
+		c.Store, c.StoreDSN = "file", filepath.Join(t.TempDir(), "tasks.json")
+		c.EventWebhookURLs, c.EventWebhookMaxAttempts, c.EventRelayInterval = []string{hook.URL}, 1, 10*time.Millisecond
+		c.TombstoneRetention = 100 * 365 * 24 * time.Hour // Keeps the deletions since the example time
+	})
+}
+
+// awaitDeadLetter waits until the event of task 1 is dead-lettered.
+func awaitDeadLetter(t *testing.T, h *Harness) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		var list struct{ Deliveries []json.RawMessage }
+		h.Admin("GET", "/api/webhooks/1/deliveries?status=dead", nil).JSON(http.StatusOK, &list)
+		if len(list.Deliveries) > 0 {
+			return
+		}
+	}
+	t.Fatal("expected the first webhook delivery to be dead-lettered")
+}
+
+func loadSpec(t *testing.T) *openAPI {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(moduleRoot(t), specFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec openAPI
+	if err := yaml.Unmarshal(content, &spec); err != nil {
+		t.Fatalf("invalid %s: %v", specFile, err)
+	}
+	return &spec
+}
+
+// documentedOperation is an operation with its path and method.
+type documentedOperation struct {
+	operation
+	Path, Method string
+}
+
+// operations returns the documented operations in document order.
+func (s *openAPI) operations(t *testing.T) []documentedOperation {
+	var ops []documentedOperation
+	for i := 0; i+1 < len(s.Paths.Content); i += 2 {
+		path, methods := s.Paths.Content[i].Value, s.Paths.Content[i+1]
+		for j := 0; j+1 < len(methods.Content); j += 2 {
+			var op operation
+			if err := methods.Content[j+1].Decode(&op); err != nil {
+				t.Fatalf("invalid operation %s %s: %v", methods.Content[j].Value, path, err)
+			}
+			ops = append(ops, documentedOperation{operation: op, Path: path, Method: strings.ToUpper(methods.Content[j].Value)})
+		}
+	}
+	return ops
+}
+
+// example returns the target URL and request body built from the examples
+// of the parameters and request body of op, and the content type of the
+// body. The request body example is validated against its schema.
+func (s *openAPI) example(t *testing.T, path string, op operation) (target string, body any, contentType string) {
+	t.Helper()
+	query := url.Values{}
+	for _, p := range op.Parameters {
+		p = s.parameter(p)
+		if p.Example == nil {
+			continue
+		}
+		value := fmt.Sprint(p.Example)
+		if p.In == "path" {
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(value))
+		} else if p.In == "query" {
+			query.Set(p.Name, value)
+		}
+	}
+	target = path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	if op.RequestBody == nil {
+		return target, nil, ""
+	}
+	types := make([]string, 0, len(op.RequestBody.Content))
+	for contentType := range op.RequestBody.Content {
+		types = append(types, contentType)
+	}
+	slices.Sort(types)
+	for _, contentType := range types {
+		media := op.RequestBody.Content[contentType]
+		if media.Example == nil {
+			continue
+		}
+		if contentType != "application/json" {
+			return target, media.Example, contentType
+		}
+		// Through JSON, so the example is validated as the server reads it.
+		encoded, err := json.Marshal(media.Example)
+		if err != nil {
+			t.Fatalf("request example: %v", err)
+		}
+		var v any
+		json.Unmarshal(encoded, &v)
+		for _, problem := range s.validate(media.Schema, v, "request") {
+			t.Error(problem)
+		}
+		return target, encoded, contentType
+	}
+	t.Fatalf("%s has a request body without example", op.ID)
+	return "", nil, ""
+}
+
+func (s *openAPI) parameter(p parameter) parameter {
+	if name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/"); ok {
+		return s.Components.Parameters[name]
+	}
+	return p
+}
+
+func (s *openAPI) response(r response) response {
+	if name, ok := strings.CutPrefix(r.Ref, "#/components/responses/"); ok {
+		return s.Components.Responses[name]
+	}
+	return r
+}
+
+// validate checks the JSON value v against sch and returns the problems,
+// prefixed with the path to the offending value.
+func (s *openAPI) validate(sch *schema, v any, at string) []string {
+	if sch == nil {
+		return nil
+	}
+	if name, ok := strings.CutPrefix(sch.Ref, "#/components/schemas/"); ok {
+		ref, found := s.Components.Schemas[name]
+		if !found {
+			return []string{fmt.Sprintf("%s: unknown schema %s", at, sch.Ref)}
+		}
+		return s.validate(ref, v, at)
+	}
+	if v == nil {
+		if sch.Nullable {
+			return nil
+		}
+		return []string{at + ": must not be null"}
+	}
+	if len(sch.Enum) > 0 && !slices.ContainsFunc(sch.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(v) }) {
+		return []string{fmt.Sprintf("%s: %v is not one of %v", at, v, sch.Enum)}
+	}
+
+	var problems []string
+	switch sch.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an object, got %T", at, v)}
+		}
+		for _, name := range sch.Required {
+			if _, ok := obj[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing required property %q", at, name))
+			}
+		}
+		for name, value := range obj {
+			prop, ok := sch.Properties[name]
+			if !ok {
+				if sch.AdditionalProperties != nil && !*sch.AdditionalProperties {
+					problems = append(problems, fmt.Sprintf("%s: undocumented property %q", at, name))
+				}
+				continue
+			}
+			problems = append(problems, s.validate(prop, value, at+"."+name)...)
+		}
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an array, got %T", at, v)}
+		}
+		for i, item := range items {
+			problems = append(problems, s.validate(sch.Items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected a string, got %T", at, v)}
+		}
+		if sch.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not a date-time", at, str))
+			}
+		}
+		if sch.Pattern != "" && !regexp.MustCompile(sch.Pattern).MatchString(str) {
+			problems = append(problems, fmt.Sprintf("%s: %q does not match %s", at, str, sch.Pattern))
+		}
+		if sch.MaxLength != nil && len([]rune(str)) > *sch.MaxLength {
+			problems = append(problems, fmt.Sprintf("%s: longer than %d characters", at, *sch.MaxLength))
+		}
+	case "integer", "number":
+		num, ok := v.(float64)
+		if !ok || sch.Type == "integer" && num != math.Trunc(num) {
+			return []string{fmt.Sprintf("%s: expected an %s, got %v", at, sch.Type, v)}
+		}
+		if sch.Minimum != nil && num < *sch.Minimum || sch.Maximum != nil && num > *sch.Maximum {
+			problems = append(problems, fmt.Sprintf("%s: %v is out of range", at, num))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return []string{fmt.Sprintf("%s: expected a boolean, got %T", at, v)}
+		}
+	}
+	return problems
+}
+
+func TestValidate_ReportsDrift(t *testing.T) {
+	spec := loadSpec(t)
+	task := map[string]any{"id": "1", "title": "x", "completed": "no", "createdAt": "yesterday", "priority": "🔥", "color": "#dc3545", "owner": "alice"}
+	problems := spec.validate(&schema{Ref: "#/components/schemas/Task"}, task, "task")
+	if len(problems) != 3 {
+		t.Errorf("expected a wrong type, a bad date-time and an undocumented property, got %q", problems)
+	}
+}
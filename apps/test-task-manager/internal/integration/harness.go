@@ -0,0 +1,193 @@
+// Package integration runs the application in-process for end-to-end tests:
+// the full router with its middleware, handlers, service and store behind
+// an httptest server, with helpers for authenticated JSON requests and
+// assertions on the response envelopes.
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/handler"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/http/server"
+)
+
+// User is the name of the user the harness makes requests as.
+const User = "integration"
+
+// AdminToken is the token the harness configures for the admin endpoints,
+// which Admin requests are sent with.
+const AdminToken = "integration-admin-token"
+
+// Harness is a running application.
+type Harness struct {
+	App    *app.App
+	Server *httptest.Server
+	Token  string // API key of User, sent with every request
+
+	t testing.TB
+}
+
+// New starts the application with the dev defaults, the memory store,
+// authentication required and AdminToken, after applying configure to the
+// configuration.
+// It runs from the module root, where templates and static assets are
+// read from, and stops when the test ends.
+func New(t testing.TB, configure ...func(*app.Configuration)) *Harness {
+	t.Helper()
+	t.Chdir(moduleRoot(t))
+
+	c := app.DefaultConfiguration(app.Dev)
+	c.LogLevel = "error"
+	c.AuthRequired = true
+	c.AdminToken = AdminToken
+	c.RateLimit = 0
+	for _, fn := range configure {
+		fn(&c)
+	}
+
+	application, err := app.Initialize(c)
+	if err != nil {
+		t.Fatalf("invalid configuration: %v", err)
+	}
+	if _, err := application.Auth().CreateUser(User); err != nil {
+		t.Fatal(err)
+	}
+	key, err := application.Auth().IssueKey(User, "integration")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes, instance := server.Handler(application)
+	srv := httptest.NewServer(routes)
+	t.Cleanup(func() {
+		srv.Close()
+		instance.Shutdown()
+		application.Shutdown()
+	})
+	return &Harness{App: application, Server: srv, Token: key.Token, t: t}
+}
+
+// Request builds a request for path with the API key of User. A string or
+// byte slice body is sent as is; any other non-nil body is encoded as JSON.
+func (h *Harness) Request(method, path string, body any) *http.Request {
+	h.t.Helper()
+	var content io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		content = bytes.NewBufferString(b)
+	case []byte:
+		content = bytes.NewBuffer(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		content = bytes.NewBuffer(encoded)
+	}
+
+	req, err := http.NewRequest(method, h.Server.URL+path, content)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+h.Token)
+	return req
+}
+
+// Send sends req and reads the response.
+func (h *Harness) Send(req *http.Request) *Response {
+	h.t.Helper()
+	resp, err := h.Server.Client().Do(req)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return &Response{Response: resp, Body: body, t: h.t}
+}
+
+// Do sends a request built by Request.
+func (h *Harness) Do(method, path string, body any) *Response {
+	h.t.Helper()
+	return h.Send(h.Request(method, path, body))
+}
+
+// Admin sends a request built by Request with AdminToken instead of the API
+// key of User.
+func (h *Harness) Admin(method, path string, body any) *Response {
+	h.t.Helper()
+	req := h.Request(method, path, body)
+	req.Header.Set("Authorization", "Bearer "+AdminToken)
+	return h.Send(req)
+}
+
+// Response is a response that has been read.
+type Response struct {
+	*http.Response
+	Body []byte
+
+	t testing.TB
+}
+
+// Expect fails the test unless the response has status.
+func (r *Response) Expect(status int) *Response {
+	r.t.Helper()
+	if r.StatusCode != status {
+		r.t.Fatalf("%s %s: expected status %d, got %d: %s", r.Request.Method, r.Request.URL.Path, status, r.StatusCode, r.Body)
+	}
+	return r
+}
+
+// JSON fails the test unless the response has status and a JSON body,
+// which is decoded into v.
+func (r *Response) JSON(status int, v any) {
+	r.t.Helper()
+	r.Expect(status)
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		r.t.Fatalf("%s %s: expected a JSON body, got %v: %s", r.Request.Method, r.Request.URL.Path, err, r.Body)
+	}
+}
+
+// Error fails the test unless the response is an error envelope with
+// status and code, and returns it.
+func (r *Response) Error(status int, code string) handler.ErrorResponse {
+	r.t.Helper()
+	var e handler.ErrorResponse
+	r.JSON(status, &e)
+	if e.Code != code || e.Error == "" {
+		r.t.Fatalf("%s %s: expected error code %s with a message, got %+v", r.Request.Method, r.Request.URL.Path, code, e)
+	}
+	return e
+}
+
+// moduleRoot returns the directory of go.mod above the working directory.
+func moduleRoot(t testing.TB) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			t.Fatal("go.mod not found above the working directory")
+		}
+		dir = parent
+	}
+}
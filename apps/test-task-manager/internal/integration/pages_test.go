@@ -0,0 +1,391 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/app"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// fragment sends a request for an HTML fragment as htmx does, with the
+// form values as body when there are any.
+func (h *Harness) fragment(method, path string, form url.Values) *Response {
+	h.t.Helper()
+	var body any
+	if form != nil {
+		body = form.Encode()
+	}
+	req := h.Request(method, path, body)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	return h.Send(req)
+}
+
+// expectHTML fails the test unless the response body holds every snippet.
+func expectHTML(t *testing.T, r *Response, snippets ...string) {
+	t.Helper()
+	for _, s := range snippets {
+		if !strings.Contains(string(r.Body), s) {
+			t.Fatalf("%s %s: expected %q in:\n%s", r.Request.Method, r.Request.URL.Path, s, r.Body)
+		}
+	}
+}
+
+func TestPages_Fragments(t *testing.T) {
+	h := New(t)
+
+	created := h.fragment("POST", "/fragments/tasks", url.Values{"title": {"Water plants"}, "priority": {"⭐"}}).Expect(http.StatusOK)
+	expectHTML(t, created, `id="task-list"`, "Water plants", "Total: 1 tasks", `id="task-form" hx-swap-oob="true"`)
+
+	var tasks []model.Task
+	h.Do("GET", "/api/tasks", nil).JSON(http.StatusOK, &tasks)
+	if len(tasks) != 1 || tasks[0].Priority != "⭐" || tasks[0].Color != "#0d6efd" {
+		t.Fatalf("expected the task with the color of its priority, got %+v", tasks)
+	}
+	id := tasks[0].ID
+
+	toggled := h.fragment("PATCH", "/fragments/tasks/"+id+"/toggle", nil).Expect(http.StatusOK)
+	expectHTML(t, toggled, `data-task-id="`+id+`"`, "checked", "text-decoration-line-through")
+	if strings.Contains(string(toggled.Body), `id="task-list"`) {
+		t.Errorf("expected only the row of the toggled task, got:\n%s", toggled.Body)
+	}
+	expectHTML(t, h.fragment("GET", "/fragments/tasks/"+id, nil).Expect(http.StatusOK), "Water plants")
+	expectHTML(t, h.fragment("GET", "/fragments/tasks/new", nil).Expect(http.StatusOK), `id="task-form"`, `value=""`)
+
+	deleted := h.fragment("DELETE", "/fragments/tasks/"+id, nil).Expect(http.StatusOK)
+	expectHTML(t, deleted, `id="task-list"`, "No tasks yet")
+	expectHTML(t, h.fragment("DELETE", "/fragments/tasks/"+id, nil).Expect(http.StatusNotFound), "Task not found")
+}
+
+func TestPages_FragmentValidation(t *testing.T) {
+	h := New(t)
+
+	invalid := h.fragment("POST", "/fragments/tasks", url.Values{"title": {"  "}, "priority": {"🔥"}}).Expect(http.StatusUnprocessableEntity)
+	if invalid.Header.Get("HX-Retarget") != "#task-form" {
+		t.Errorf("expected the form to be retargeted, got headers %v", invalid.Header)
+	}
+	expectHTML(t, invalid, `id="task-form"`, "task title cannot be empty", `value="🔥" checked`)
+}
+
+func TestPages_FragmentsNeedHTMX(t *testing.T) {
+	h := New(t)
+
+	h.Do("POST", "/fragments/tasks", "title=Forged").Expect(http.StatusBadRequest)
+
+	req := h.Request("GET", "/fragments/tasks", nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Del("Authorization")
+	h.Send(req).Expect(http.StatusUnauthorized)
+}
+
+// submit posts the form values as a browser submitting a form does.
+func (h *Harness) submit(path string, form url.Values) *Response {
+	h.t.Helper()
+	req := h.Request("POST", path, form.Encode())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return h.Send(req)
+}
+
+func TestPages_EditTask(t *testing.T) {
+	h := New(t)
+
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Water plants", "dueDate": "2026-03-01T09:00:00Z"}).JSON(http.StatusCreated, &task)
+	path := "/tasks/" + task.ID + "/edit"
+	expectHTML(t, h.Do("GET", path, nil).Expect(http.StatusOK), `value="Water plants"`, `value="📋" selected`)
+	h.Do("GET", "/tasks/999/edit", nil).Expect(http.StatusNotFound)
+
+	form := url.Values{"title": {" "}, "priority": {"🔥"}, "color": {"#dc3545"}, "dueDate": {"2026-03-01"}}
+	invalid := h.submit(path, form).Expect(http.StatusUnprocessableEntity)
+	expectHTML(t, invalid, "is-invalid", "task title cannot be empty", `value="🔥" selected`)
+
+	form.Set("dueDate", "soon")
+	expectHTML(t, h.submit(path, form).Expect(http.StatusUnprocessableEntity), "The due date must be a date")
+
+	form.Set("title", "Water all plants")
+	form.Set("dueDate", task.DueDate.Local().Format("2006-01-02"))
+	saved := h.submit(path, form).Expect(http.StatusOK)
+	if saved.Request.URL.Path != "/" {
+		t.Errorf("expected a redirect to the task list, got %s", saved.Request.URL)
+	}
+
+	var tasks []model.Task
+	h.Do("GET", "/api/tasks", nil).JSON(http.StatusOK, &tasks)
+	got := tasks[0]
+	if got.Title != "Water all plants" || got.Priority != "🔥" || got.Color != "#dc3545" || !got.DueDate.Equal(*task.DueDate) {
+		t.Errorf("expected the task changed with its due time kept, got %+v", got)
+	}
+
+	form.Set("dueDate", "")
+	h.submit(path, form).Expect(http.StatusOK)
+	var cleared []model.Task
+	h.Do("GET", "/api/tasks", nil).JSON(http.StatusOK, &cleared)
+	if cleared[0].DueDate != nil {
+		t.Errorf("expected the due date removed, got %v", cleared[0].DueDate)
+	}
+}
+
+func TestPages_EditTaskCrossOrigin(t *testing.T) {
+	h := New(t)
+
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Water plants"}).JSON(http.StatusCreated, &task)
+
+	req := h.Request("POST", "/tasks/"+task.ID+"/edit", url.Values{"title": {"Forged"}}.Encode())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	h.Send(req).Expect(http.StatusForbidden)
+}
+
+func TestPages_Filters(t *testing.T) {
+	h := New(t)
+
+	var task model.Task
+	for _, title := range []string{"Water plants", "Book flights", "Call plumber"} {
+		h.Do("POST", "/api/tasks", map[string]string{"title": title}).JSON(http.StatusCreated, &task)
+	}
+	h.Do("PATCH", "/api/tasks/"+task.ID+"/toggle", nil).Expect(http.StatusOK)
+
+	page := string(h.Do("GET", "/?status=open&sort=title", nil).Expect(http.StatusOK).Body)
+	book, water := strings.Index(page, "Book flights"), strings.Index(page, "Water plants")
+	if book < 0 || water < book || strings.Contains(page, "Call plumber") {
+		t.Errorf("expected the open tasks by title, got:\n%s", page)
+	}
+	if !strings.Contains(page, `<option value="open" selected>`) {
+		t.Error("expected the status filter to show the chosen status")
+	}
+	h.Do("GET", "/?sort=size", nil).Expect(http.StatusBadRequest)
+	expectHTML(t, h.Do("GET", "/?priority=🔥", nil).Expect(http.StatusOK), "No tasks match the filters")
+
+	// The list swapped in after a change keeps the filters of the page.
+	req := h.Request("DELETE", "/fragments/tasks/1", nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("HX-Current-URL", h.Server.URL+"/?status=completed")
+	list := string(h.Send(req).Expect(http.StatusOK).Body)
+	if !strings.Contains(list, "Call plumber") || strings.Contains(list, "Book flights") {
+		t.Errorf("expected only the completed task, got:\n%s", list)
+	}
+}
+
+func TestPages_Board(t *testing.T) {
+	h := New(t)
+
+	var task model.Task
+	for _, priority := range []string{"🔥", "🔥", "⭐", "💡", "📋"} {
+		h.Do("POST", "/api/tasks", map[string]string{"title": "Task " + priority, "priority": priority}).JSON(http.StatusCreated, &task)
+	}
+	h.Do("PATCH", "/api/tasks/1/toggle", nil).Expect(http.StatusOK)
+
+	matrix := h.Do("GET", "/board", nil).Expect(http.StatusOK)
+	expectHTML(t, matrix, "Do first", "Schedule", "Delegate", "Eliminate", "Uncategorized", `aria-label="1 tasks"`, `aria-label="0 tasks"`)
+	if strings.Contains(string(matrix.Body), `aria-label="2 tasks"`) {
+		t.Error("expected the completed task to be left out of the matrix")
+	}
+
+	expectHTML(t, h.Do("GET", "/board?view=status", nil).Expect(http.StatusOK), `aria-label="4 tasks"`, "text-decoration-line-through")
+	h.Do("GET", "/board?view=kanban", nil).Expect(http.StatusBadRequest)
+}
+
+func TestPages_Dashboard(t *testing.T) {
+	h := New(t)
+
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "File taxes", "priority": "🔥", "dueDate": "2020-04-30T12:00:00Z"}).JSON(http.StatusCreated, &task)
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Water plants", "priority": "⭐"}).JSON(http.StatusCreated, &task)
+	h.Do("PATCH", "/api/tasks/"+task.ID+"/toggle", nil).Expect(http.StatusOK)
+
+	page := h.Do("GET", "/dashboard", nil).Expect(http.StatusOK)
+	expectHTML(t, page, "<svg", `fill="#dc3545"`, "1 in the last 14 days", "File taxes", "due 30 Apr 2020")
+}
+
+func TestPages_Preferences(t *testing.T) {
+	h := New(t)
+
+	var task model.Task
+	for _, title := range []string{"Water plants", "Book flights", "Call plumber"} {
+		h.Do("POST", "/api/tasks", map[string]string{"title": title}).JSON(http.StatusCreated, &task)
+	}
+
+	var prefs map[string]any
+	h.Do("GET", "/api/preferences", nil).JSON(http.StatusOK, &prefs)
+	if prefs["theme"] != "light" || prefs["sort"] != "created" || prefs["pageSize"] != 0.0 {
+		t.Errorf("expected the defaults, got %v", prefs)
+	}
+	h.Do("PUT", "/api/preferences", map[string]any{"theme": "blue"}).Error(http.StatusBadRequest, "INVALID_INPUT")
+	h.Do("PUT", "/api/preferences", map[string]any{"theme": "dark", "sort": "title", "pageSize": 2}).JSON(http.StatusOK, &prefs)
+
+	// The preferences are those of the user, so they apply to every client
+	// authenticating as the user.
+	first := h.Do("GET", "/", nil).Expect(http.StatusOK)
+	expectHTML(t, first, `data-bs-theme="dark"`, "Book flights", "Call plumber", "Showing 2 of 3 tasks", `href="/?limit=2&amp;offset=2"`)
+	if strings.Contains(string(first.Body), "Water plants") {
+		t.Errorf("expected the first page of tasks by title, got:\n%s", first.Body)
+	}
+	expectHTML(t, h.Do("GET", "/?offset=2", nil).Expect(http.StatusOK), "Water plants", `href="/?limit=2&amp;offset=0"`)
+	expectHTML(t, h.Do("GET", "/?sort=created", nil).Expect(http.StatusOK), "Water plants", "Book flights")
+	expectHTML(t, h.Do("GET", "/board", nil).Expect(http.StatusOK), `data-bs-theme="dark"`)
+}
+
+func TestPages_PreferencesWithoutUser(t *testing.T) {
+	h := New(t, func(c *app.Configuration) { c.AuthRequired = false })
+	jar, _ := cookiejar.New(nil)
+	h.Server.Client().Jar = jar
+
+	req := h.Request("POST", "/preferences", url.Values{"theme": {"dark"}, "sort": {"due"}, "pageSize": {"10"}, "return": {"/board"}}.Encode())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Del("Authorization")
+	saved := h.Send(req).Expect(http.StatusOK)
+	if saved.Request.URL.Path != "/board" {
+		t.Errorf("expected a redirect to the board, got %s", saved.Request.URL)
+	}
+	expectHTML(t, saved, `data-bs-theme="dark"`)
+
+	// Browsers without a user keep their preferences in a cookie, which
+	// authenticated requests do not use.
+	req = h.Request("GET", "/api/preferences", nil)
+	req.Header.Del("Authorization")
+	var prefs map[string]any
+	h.Send(req).JSON(http.StatusOK, &prefs)
+	if prefs["theme"] != "dark" || prefs["sort"] != "due" || prefs["pageSize"] != 10.0 {
+		t.Errorf("expected the preferences of the browser, got %v", prefs)
+	}
+	expectHTML(t, h.Do("GET", "/", nil).Expect(http.StatusOK), `data-bs-theme="light"`)
+
+	req = h.Request("POST", "/preferences", url.Values{"pageSize": {"1000"}}.Encode())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	h.Send(req).Expect(http.StatusBadRequest)
+}
+
+func TestPages_Login(t *testing.T) {
+	h := New(t)
+	jar, _ := cookiejar.New(nil)
+	h.Server.Client().Jar = jar
+	browser := func(method, path string, form url.Values) *Response {
+		req := h.Request(method, path, nil)
+		if form != nil {
+			req = h.Request(method, path, form.Encode())
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		req.Header.Del("Authorization")
+		return h.Send(req)
+	}
+
+	login := browser("GET", "/board?view=status", nil).Expect(http.StatusOK)
+	if login.Request.URL.Path != "/login" {
+		t.Fatalf("expected a redirect to the login page, got %s", login.Request.URL)
+	}
+	expectHTML(t, login, `name="return" value="/board?view=status"`)
+
+	failed := browser("POST", "/login", url.Values{"name": {"someone"}, "key": {h.Token}, "return": {"/board"}}).Expect(http.StatusUnauthorized)
+	expectHTML(t, failed, "Invalid user name or API key", `value="someone"`)
+
+	board := browser("POST", "/login", url.Values{"name": {User}, "key": {h.Token}, "return": {"/board?view=status"}}).Expect(http.StatusOK)
+	if board.Request.URL.RequestURI() != "/board?view=status" {
+		t.Errorf("expected a redirect back to the board, got %s", board.Request.URL)
+	}
+	expectHTML(t, board, "Signed in as integration", `action="/logout"`)
+
+	// htmx requests of the pages carry the session cookie too.
+	req := h.Request("GET", "/fragments/tasks", nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Del("Authorization")
+	h.Send(req).Expect(http.StatusOK)
+
+	if page := browser("POST", "/logout", url.Values{}).Expect(http.StatusOK); page.Request.URL.Path != "/login" {
+		t.Errorf("expected a redirect to the login page, got %s", page.Request.URL)
+	}
+	if page := browser("GET", "/", nil).Expect(http.StatusOK); page.Request.URL.Path != "/login" {
+		t.Errorf("expected signing out to end the session, got %s", page.Request.URL)
+	}
+
+	open := New(t, func(c *app.Configuration) { c.AuthRequired = false })
+	open.Do("GET", "/login", nil).Expect(http.StatusNotFound)
+}
+
+func TestPages_Pagination(t *testing.T) {
+	h := New(t, func(c *app.Configuration) { c.ListLimit, c.MaxListLimit = 2, 3 })
+
+	var task model.Task
+	for i := range 5 {
+		h.Do("POST", "/api/tasks", map[string]string{"title": fmt.Sprintf("Task %d", i+1)}).JSON(http.StatusCreated, &task)
+	}
+
+	first := h.Do("GET", "/?status=open", nil).Expect(http.StatusOK)
+	expectHTML(t, first, "Task 1", "Task 2", "Showing 2 of 5 tasks", `href="/?limit=2&amp;offset=2&amp;status=open"`)
+	if strings.Contains(string(first.Body), "Previous") {
+		t.Error("expected no link to a previous page on the first page")
+	}
+
+	last := h.Do("GET", "/?status=open&limit=3&offset=3", nil).Expect(http.StatusOK)
+	expectHTML(t, last, "Task 4", "Task 5", `href="/?limit=3&amp;offset=0&amp;status=open"`)
+	if strings.Contains(string(last.Body), "Next") {
+		t.Error("expected no link to a next page on the last page")
+	}
+
+	expectHTML(t, first, `href="/api/tasks/export?format=csv&amp;sort=created&amp;status=open"`)
+
+	expectHTML(t, h.Do("GET", "/?offset=50", nil).Expect(http.StatusOK), "Task 5", "Showing 1 of 5 tasks")
+	h.Do("GET", "/?limit=4", nil).Expect(http.StatusBadRequest)
+	h.Do("GET", "/?offset=-1", nil).Expect(http.StatusBadRequest)
+}
+
+func TestPages_Dutch(t *testing.T) {
+	h := New(t)
+
+	var task model.Task
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Water plants", "dueDate": "2026-03-01T12:00:00Z"}).JSON(http.StatusCreated, &task)
+
+	req := h.Request("GET", "/", nil)
+	req.Header.Set("Accept-Language", "nl-NL,nl;q=0.9,en;q=0.8")
+	page := h.Send(req).Expect(http.StatusOK)
+	expectHTML(t, page, `<html lang="nl"`, "Mijn taken", "Totaal: 1 taken", "voor 1 mrt 2026")
+	if !strings.Contains(page.Header.Get("Vary"), "Accept-Language") {
+		t.Errorf("expected the page to vary by language, got Vary %q", page.Header.Get("Vary"))
+	}
+	expectHTML(t, h.Do("GET", "/", nil).Expect(http.StatusOK), `<html lang="en"`, "My Tasks")
+
+	req = h.Request("POST", "/tasks/"+task.ID+"/edit", url.Values{"title": {" "}, "priority": {"📋"}, "color": {"#6c757d"}}.Encode())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept-Language", "nl")
+	expectHTML(t, h.Send(req).Expect(http.StatusUnprocessableEntity), "de titel van de taak mag niet leeg zijn", "Opslaan")
+
+	req = h.Request("DELETE", "/fragments/tasks/999", nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("Accept-Language", "nl")
+	expectHTML(t, h.Send(req).Expect(http.StatusNotFound), "Taak niet gevonden")
+}
+
+func TestPages_ReadOnly(t *testing.T) {
+	h := New(t, func(c *app.Configuration) { c.ReadOnly, c.Fixtures = true, "fixtures/*.json" })
+
+	var tasks []model.Task
+	h.Do("GET", "/api/tasks", nil).JSON(http.StatusOK, &tasks)
+	if len(tasks) == 0 {
+		t.Fatal("expected the fixture tasks to be listed")
+	}
+	id := tasks[0].ID
+
+	page := h.Do("GET", "/", nil).Expect(http.StatusOK)
+	expectHTML(t, page, tasks[0].Title, "disabled")
+	for _, control := range []string{`id="task-form"`, "hx-patch=", "hx-delete=", `action="/preferences"`} {
+		if strings.Contains(string(page.Body), control) {
+			t.Errorf("expected %s to be hidden in read-only mode", control)
+		}
+	}
+
+	h.Do("POST", "/api/tasks", map[string]string{"title": "Water plants"}).Error(http.StatusForbidden, "READ_ONLY")
+	h.Do("PATCH", "/api/tasks/"+id+"/toggle", nil).Error(http.StatusForbidden, "READ_ONLY")
+	h.Do("DELETE", "/api/tasks/"+id, nil).Error(http.StatusForbidden, "READ_ONLY")
+	expectHTML(t, h.fragment("DELETE", "/fragments/tasks/"+id, nil).Expect(http.StatusForbidden), "read-only")
+
+	var after []model.Task
+	h.Do("GET", "/api/tasks", nil).JSON(http.StatusOK, &after)
+	if len(after) != len(tasks) || after[0].Completed != tasks[0].Completed {
+		t.Errorf("expected the tasks to be unchanged, got %+v", after)
+	}
+}
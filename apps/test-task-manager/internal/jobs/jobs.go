@@ -0,0 +1,83 @@
+// Package jobs runs background work outside of requests. Jobs are queued
+// in a Backend and run by a pool of workers, which retry failed jobs with
+// backoff and move jobs out of attempts to a dead-letter list.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Errors returned by Enqueue.
+var (
+	ErrQueueFull   = errors.New("job queue is full")
+	ErrQueueClosed = errors.New("job queue is shut down")
+	ErrUnknownType = errors.New("no handler for job type")
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// Job is a unit of background work.
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempts   int             `json:"attempts"` // Attempts made so far
+	RunAt      time.Time       `json:"runAt"`    // Not run before this time
+	EnqueuedAt time.Time       `json:"enqueuedAt"`
+	LastError  string          `json:"lastError,omitempty"` // Error of the last attempt
+}
+
+// Decode unmarshals the payload of j into v.
+func (j Job) Decode(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Handler runs jobs of one type. Returning an error retries the job
+// according to the retry policy of the type.
+type Handler func(ctx context.Context, job Job) error
+
+// RetryPolicy decides how often and when failed jobs are retried.
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts before the job is dead-lettered, including the first
+	Backoff     time.Duration // Delay before the first retry, doubled for every next one
+	MaxBackoff  time.Duration // Longest delay between attempts (0 means no cap)
+}
+
+// Delay returns how long to wait before the next attempt after attempts
+// failed ones.
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// Backend stores the queued and dead-lettered jobs. The in-memory Memory
+// backend loses them on restart; a persistent backend can keep them.
+type Backend interface {
+	// Push adds a job, to be returned by Pop once its RunAt has passed.
+	Push(job Job) error
+	// Pop removes and returns the job that is due first, waiting until one
+	// is due or ctx is done.
+	Pop(ctx context.Context) (Job, error)
+	// Len returns the number of queued jobs, due or not.
+	Len() int
+	// Due returns the number of queued jobs that are due at now.
+	Due(now time.Time) int
+
+	// Bury adds a job to the dead-letter list.
+	Bury(job Job) error
+	// Buried returns the dead-lettered jobs, oldest first.
+	Buried() ([]Job, error)
+	// Unbury removes the dead-lettered job with id and returns it.
+	Unbury(id string) (Job, error)
+}
@@ -0,0 +1,136 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"go.uber.org/zap"
+)
+
+func newTestQueue(retry RetryPolicy) *Queue {
+	return New(NewMemory(10), 2, retry, zap.NewNop().Sugar(), metrics.NewRegistry())
+}
+
+func TestQueue_RetriesUntilSuccess(t *testing.T) {
+	q := newTestQueue(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+	var attempts atomic.Int64
+	done := make(chan string, 1)
+	q.Register("greet", func(ctx context.Context, job Job) error {
+		if attempts.Add(1) < 3 {
+			return errors.New("temporarily unavailable")
+		}
+		var name string
+		job.Decode(&name)
+		done <- name
+		return nil
+	})
+	q.Start()
+	defer q.Shutdown(time.Second)
+
+	if err := q.Enqueue("greet", "alice"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	select {
+	case name := <-done:
+		if name != "alice" {
+			t.Errorf("expected payload alice, got %q", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not succeed")
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts.Load())
+	}
+}
+
+func TestQueue_DeadLettersAndRetries(t *testing.T) {
+	q := newTestQueue(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+	var fail atomic.Bool
+	fail.Store(true)
+	succeeded := make(chan struct{}, 1)
+	q.Register("flaky", func(ctx context.Context, job Job) error {
+		if fail.Load() {
+			panic("boom")
+		}
+		succeeded <- struct{}{}
+		return nil
+	})
+	q.Start()
+	defer q.Shutdown(time.Second)
+
+	q.Enqueue("flaky", nil)
+	var dead []Job
+	for deadline := time.Now().Add(5 * time.Second); len(dead) == 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		dead, _ = q.DeadLetters()
+	}
+	if len(dead) != 1 || dead[0].Attempts != 2 || dead[0].LastError != "panic: boom" {
+		t.Fatalf("expected the job dead-lettered after 2 attempts, got %+v", dead)
+	}
+
+	fail.Store(false)
+	if _, err := q.Retry(dead[0].ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	select {
+	case <-succeeded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("retried job did not run")
+	}
+	if dead, _ := q.DeadLetters(); len(dead) != 0 {
+		t.Errorf("expected no dead letters, got %v", dead)
+	}
+	if _, err := q.Retry("job_unknown"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+}
+
+func TestQueue_ShutdownDrainsDueJobs(t *testing.T) {
+	q := newTestQueue(RetryPolicy{MaxAttempts: 1})
+	var ran atomic.Int64
+	q.Register("slow", func(ctx context.Context, job Job) error {
+		time.Sleep(10 * time.Millisecond)
+		ran.Add(1)
+		return nil
+	})
+	for range 5 {
+		q.Enqueue("slow", nil)
+	}
+	q.Start()
+	q.Shutdown(5 * time.Second)
+
+	if ran.Load() != 5 {
+		t.Errorf("expected 5 jobs to run before shutdown, got %d", ran.Load())
+	}
+	if err := q.Enqueue("slow", nil); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected ErrQueueClosed, got %v", err)
+	}
+}
+
+func TestQueue_Full(t *testing.T) {
+	q := New(NewMemory(1), 1, RetryPolicy{MaxAttempts: 1}, zap.NewNop().Sugar(), metrics.NewRegistry())
+	q.Register("noop", func(ctx context.Context, job Job) error { return nil })
+
+	if err := q.Enqueue("noop", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := q.Enqueue("noop", nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+	if err := q.Enqueue("unknown", nil); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("expected ErrUnknownType, got %v", err)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := p.Delay(attempts); got != want {
+			t.Errorf("delay after %d attempts: expected %v, got %v", attempts, want, got)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package jobs
+
+import (
+	"container/heap"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Memory is a Backend keeping jobs in memory, up to a maximum number of
+// queued jobs. Dead-lettered jobs are kept up to the same number; the
+// oldest are dropped.
+type Memory struct {
+	size int
+
+	mu      sync.Mutex
+	queued  jobHeap
+	pushed  uint64 // Jobs pushed so far, to keep jobs due at once in order
+	buried  []Job
+	changed chan struct{} // Closed and replaced when a job is pushed
+}
+
+// NewMemory creates an in-memory backend holding up to size queued jobs.
+func NewMemory(size int) *Memory {
+	return &Memory{size: size, changed: make(chan struct{})}
+}
+
+// Push implements Backend.
+func (m *Memory) Push(job Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.queued) >= m.size {
+		return ErrQueueFull
+	}
+	m.pushed++
+	heap.Push(&m.queued, queuedJob{Job: job, seq: m.pushed})
+	close(m.changed)
+	m.changed = make(chan struct{})
+	return nil
+}
+
+// Pop implements Backend.
+func (m *Memory) Pop(ctx context.Context) (Job, error) {
+	for {
+		m.mu.Lock()
+		changed := m.changed
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if len(m.queued) > 0 {
+			delay := time.Until(m.queued[0].RunAt)
+			if delay <= 0 {
+				job := heap.Pop(&m.queued).(queuedJob)
+				m.mu.Unlock()
+				return job.Job, nil
+			}
+			timer = time.NewTimer(delay)
+			wait = timer.C
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-wait:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return Job{}, err
+		}
+	}
+}
+
+// Len implements Backend.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queued)
+}
+
+// Due implements Backend.
+func (m *Memory) Due(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, job := range m.queued {
+		if !job.RunAt.After(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// Bury implements Backend.
+func (m *Memory) Bury(job Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.buried) >= m.size {
+		m.buried = slices.Delete(m.buried, 0, 1)
+	}
+	m.buried = append(m.buried, job)
+	return nil
+}
+
+// Buried implements Backend.
+func (m *Memory) Buried() ([]Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.buried), nil
+}
+
+// Unbury implements Backend.
+func (m *Memory) Unbury(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.buried, func(job Job) bool { return job.ID == id })
+	if i < 0 {
+		return Job{}, ErrJobNotFound
+	}
+	job := m.buried[i]
+	m.buried = slices.Delete(m.buried, i, i+1)
+	return job, nil
+}
+
+type queuedJob struct {
+	Job
+	seq uint64
+}
+
+// jobHeap orders jobs by RunAt, then by the order they were pushed in.
+type jobHeap []queuedJob
+
+func (h jobHeap) Len() int { return len(h) }
+func (h jobHeap) Less(i, j int) bool {
+	if !h[i].RunAt.Equal(h[j].RunAt) {
+		return h[i].RunAt.Before(h[j].RunAt)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h jobHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *jobHeap) Push(x any)   { *h = append(*h, x.(queuedJob)) }
+func (h *jobHeap) Pop() any {
+	old := *h
+	job := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return job
+}
@@ -0,0 +1,241 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// drainPoll is how often Shutdown checks whether the due jobs are done.
+const drainPoll = 20 * time.Millisecond
+
+// Queue runs jobs from a Backend on a pool of workers.
+type Queue struct {
+	backend Backend
+	workers int
+	retry   RetryPolicy // Of types registered without their own policy
+	logger  *zap.SugaredLogger
+
+	mu       sync.RWMutex
+	handlers map[string]registration
+	closed   bool
+
+	ctx    context.Context // Canceled when the workers must stop
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	active atomic.Int64 // Jobs being run
+
+	enqueued  *metrics.CounterVec
+	processed *metrics.CounterVec
+	duration  *metrics.HistogramVec
+}
+
+type registration struct {
+	handler Handler
+	retry   RetryPolicy
+}
+
+// New creates a queue running jobs from backend on the given number of
+// workers, retrying failed jobs with retry unless their type has its own
+// policy. Call Start to begin running jobs.
+func New(backend Backend, workers int, retry RetryPolicy, logger *zap.SugaredLogger, reg *metrics.Registry) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		backend:   backend,
+		workers:   workers,
+		retry:     retry,
+		logger:    logger,
+		handlers:  make(map[string]registration),
+		ctx:       ctx,
+		cancel:    cancel,
+		enqueued:  reg.CounterVec("jobs_enqueued_total", "Jobs added to the background queue by type.", "type"),
+		processed: reg.CounterVec("jobs_processed_total", "Job attempts by type and result.", "type", "result"),
+		duration:  reg.HistogramVec("job_duration_seconds", "Duration of job attempts.", nil, "type"),
+	}
+	reg.GaugeFunc("jobs_queued", "Jobs waiting in the background queue, including retries.", func() float64 {
+		return float64(backend.Len())
+	})
+	reg.GaugeFunc("jobs_dead_letter", "Jobs that ran out of attempts.", func() float64 {
+		buried, _ := backend.Buried()
+		return float64(len(buried))
+	})
+	return q
+}
+
+// Register runs jobs of jobType with h, retried with the default policy.
+func (q *Queue) Register(jobType string, h Handler) {
+	q.RegisterWithRetry(jobType, h, q.retry)
+}
+
+// RegisterWithRetry runs jobs of jobType with h, retried with policy.
+func (q *Queue) RegisterWithRetry(jobType string, h Handler, policy RetryPolicy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = registration{handler: h, retry: policy}
+}
+
+// Enqueue queues a job of jobType with payload, marshaled to JSON.
+func (q *Queue) Enqueue(jobType string, payload any) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if _, ok := q.handlers[jobType]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownType, jobType)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	job := Job{ID: newID(), Type: jobType, Payload: data, RunAt: now, EnqueuedAt: now}
+	if err := q.backend.Push(job); err != nil {
+		return err
+	}
+	q.enqueued.With(jobType).Inc()
+	return nil
+}
+
+// Start starts the workers.
+func (q *Queue) Start() {
+	for range q.workers {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for {
+				job, err := q.backend.Pop(q.ctx)
+				if err != nil {
+					return
+				}
+				q.active.Add(1)
+				q.run(job)
+				q.active.Add(-1)
+			}
+		}()
+	}
+}
+
+// Shutdown stops accepting jobs and waits up to timeout for the jobs that
+// are due to finish. Jobs waiting for a retry are not run; unless the
+// backend persists them, they are lost.
+func (q *Queue) Shutdown(timeout time.Duration) {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) && (q.backend.Due(time.Now()) > 0 || q.active.Load() > 0) {
+		time.Sleep(drainPoll)
+	}
+	q.cancel()
+	q.wg.Wait()
+
+	if left := q.backend.Len(); left > 0 {
+		q.logger.Warnw("background jobs left in the queue at shutdown", "jobs", left)
+	}
+}
+
+// DeadLetters returns the jobs that ran out of attempts, oldest first.
+func (q *Queue) DeadLetters() ([]Job, error) {
+	return q.backend.Buried()
+}
+
+// Retry moves the dead-lettered job with id back into the queue, with
+// fresh attempts.
+func (q *Queue) Retry(id string) (Job, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return Job{}, ErrQueueClosed
+	}
+
+	job, err := q.backend.Unbury(id)
+	if err != nil {
+		return Job{}, err
+	}
+	job.Attempts, job.RunAt = 0, time.Now()
+	if err := q.backend.Push(job); err != nil {
+		q.backend.Bury(job)
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// Len returns the number of queued jobs.
+func (q *Queue) Len() int {
+	return q.backend.Len()
+}
+
+// run makes an attempt at job and retries or buries it when it fails.
+func (q *Queue) run(job Job) {
+	q.mu.RLock()
+	reg, ok := q.handlers[job.Type]
+	q.mu.RUnlock()
+	if !ok {
+		job.LastError = ErrUnknownType.Error()
+		q.bury(job)
+		return
+	}
+
+	job.Attempts++
+	start := time.Now()
+	err := q.call(reg.handler, job)
+	q.duration.With(job.Type).Observe(time.Since(start).Seconds())
+	if err == nil {
+		q.processed.With(job.Type, "success").Inc()
+		return
+	}
+
+	job.LastError = err.Error()
+	if job.Attempts >= reg.retry.MaxAttempts {
+		q.processed.With(job.Type, "dead").Inc()
+		q.bury(job)
+		return
+	}
+
+	delay := reg.retry.Delay(job.Attempts)
+	job.RunAt = time.Now().Add(delay)
+	if err := q.backend.Push(job); err != nil {
+		job.LastError = fmt.Sprintf("%s (retry not queued: %v)", job.LastError, err)
+		q.processed.With(job.Type, "dead").Inc()
+		q.bury(job)
+		return
+	}
+	q.processed.With(job.Type, "retry").Inc()
+	q.logger.Warnw("background job failed, retrying", "job", job.ID, "type", job.Type, "attempt", job.Attempts, "retryIn", delay, "error", job.LastError)
+}
+
+// call runs h, turning a panic into an error.
+func (q *Queue) call(h Handler, job Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return h(q.ctx, job)
+}
+
+func (q *Queue) bury(job Job) {
+	if err := q.backend.Bury(job); err != nil {
+		q.logger.Errorw("failed to dead-letter background job", "job", job.ID, "type", job.Type, "error", err)
+		return
+	}
+	q.logger.Errorw("background job dead-lettered", "job", job.ID, "type", job.Type, "attempts", job.Attempts, "error", job.LastError)
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "job_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,104 @@
+// Package maintenance holds whether the task manager is in maintenance
+// mode, in which tasks can be read but not changed.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultRetryAfter is how long clients are told to wait before retrying
+// changes when the settings do not say.
+const DefaultRetryAfter = 5 * time.Minute
+
+// DefaultMessage answers changes when the settings have no message.
+const DefaultMessage = "The task manager is in maintenance. Tasks can be read but not changed; try again later."
+
+// Settings describe the maintenance mode.
+type Settings struct {
+	Enabled           bool          `json:"enabled"`
+	Message           string        `json:"message,omitempty"` // Shown to clients; DefaultMessage when empty
+	RetryAfter        time.Duration `json:"-"`
+	RetryAfterSeconds int64         `json:"retryAfter"`
+}
+
+// Validate checks the settings for out-of-range values.
+func (s Settings) Validate() error {
+	if s.RetryAfter < 0 {
+		return fmt.Errorf("maintenance retry after cannot be negative")
+	}
+	return nil
+}
+
+// ClientMessage returns the message clients are shown: Message, or else
+// DefaultMessage.
+func (s Settings) ClientMessage() string {
+	if s.Message == "" {
+		return DefaultMessage
+	}
+	return s.Message
+}
+
+// Mode holds the current Settings. It is safe for concurrent use.
+type Mode struct {
+	mu       sync.RWMutex
+	settings Settings
+}
+
+// New returns a Mode with the given settings. Invalid settings disable it.
+func New(settings Settings) *Mode {
+	m := &Mode{}
+	if err := m.Update(settings); err != nil {
+		m.settings = normalize(Settings{})
+	}
+	return m
+}
+
+// Update replaces the settings after validating them. A RetryAfter of 0
+// means DefaultRetryAfter.
+func (m *Mode) Update(settings Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings = normalize(settings)
+	return nil
+}
+
+// Settings returns the current settings.
+func (m *Mode) Settings() Settings {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.settings
+}
+
+// Enabled reports whether changes are turned away.
+func (m *Mode) Enabled() bool {
+	return m.Settings().Enabled
+}
+
+func normalize(s Settings) Settings {
+	if s.RetryAfter == 0 {
+		s.RetryAfter = DefaultRetryAfter
+	}
+	s.RetryAfterSeconds = int64(s.RetryAfter / time.Second)
+	return s
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx holding the settings of an enabled
+// maintenance mode, which pages show a banner for.
+func NewContext(ctx context.Context, settings Settings) context.Context {
+	return context.WithValue(ctx, contextKey{}, settings)
+}
+
+// FromContext returns the settings stored by NewContext, if any.
+func FromContext(ctx context.Context) (Settings, bool) {
+	settings, ok := ctx.Value(contextKey{}).(Settings)
+	return settings, ok
+}
@@ -0,0 +1,439 @@
+// Package metrics provides a minimal, dependency-free metrics registry
+// that renders the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the histogram buckets (in seconds) used when none are given.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds all registered metrics.
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]metric
+}
+
+// metric is implemented by every metric family that can be exposed.
+type metric interface {
+	write(w io.Writer)
+}
+
+// NewRegistry creates a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register adds m under name, or returns the metric already registered under that name.
+func (r *Registry) register(name string, m metric) metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[name]; ok {
+		return existing
+	}
+	r.metrics[name] = m
+	return m
+}
+
+// Counter registers (or returns the existing) counter with the given name.
+func (r *Registry) Counter(name, help string) *Counter {
+	return r.CounterVec(name, help).With()
+}
+
+// CounterVec registers (or returns the existing) labeled counter family.
+func (r *Registry) CounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{family: newFamily(name, help, "counter", labels)}
+	return r.register(name, v).(*CounterVec)
+}
+
+// Gauge registers (or returns the existing) gauge with the given name.
+func (r *Registry) Gauge(name, help string) *Gauge {
+	return r.GaugeVec(name, help).With()
+}
+
+// GaugeVec registers (or returns the existing) labeled gauge family.
+func (r *Registry) GaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{family: newFamily(name, help, "gauge", labels)}
+	return r.register(name, v).(*GaugeVec)
+}
+
+// GaugeFunc registers a gauge whose value is computed by fn at collection time.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(name, &gaugeFunc{name: name, help: help, fn: fn})
+}
+
+// GaugeFuncVec registers (or returns the existing) labeled family of gauges
+// computed at collection time.
+func (r *Registry) GaugeFuncVec(name, help string, labels ...string) *GaugeFuncVec {
+	v := &GaugeFuncVec{family: newFamily(name, help, "gauge", labels)}
+	return r.register(name, v).(*GaugeFuncVec)
+}
+
+// Histogram registers (or returns the existing) histogram with the given name.
+// When buckets is nil, DefaultBuckets are used.
+func (r *Registry) Histogram(name, help string, buckets []float64) *Histogram {
+	return r.HistogramVec(name, help, buckets).With()
+}
+
+// HistogramVec registers (or returns the existing) labeled histogram family.
+func (r *Registry) HistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	v := &HistogramVec{family: newFamily(name, help, "histogram", labels), buckets: buckets}
+	return r.register(name, v).(*HistogramVec)
+}
+
+// Write writes all metrics in the Prometheus text format, sorted by name.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	metrics := make([]metric, 0, len(names))
+	for _, name := range names {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.mu.RUnlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// Handler returns an http.Handler that exposes the registry.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// family holds the shared bookkeeping of a labeled metric family.
+type family struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]any
+	keys   map[string][]string
+}
+
+func newFamily(name, help, kind string, labels []string) *family {
+	return &family{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		series: make(map[string]any),
+		keys:   make(map[string][]string),
+	}
+}
+
+// child returns the series for the given label values, creating it with create if needed.
+func (f *family) child(values []string, create func() any) any {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if s, ok := f.series[key]; ok {
+		return s
+	}
+	s := create()
+	f.series[key] = s
+	f.keys[key] = append([]string(nil), values...)
+	return s
+}
+
+// each calls fn for every series in a stable order.
+func (f *family) each(fn func(labels string, s any)) {
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	type entry struct {
+		labels string
+		s      any
+	}
+	entries := make([]entry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, entry{labels: formatLabels(f.labels, f.keys[key]), s: f.series[key]})
+	}
+	f.mu.Unlock()
+
+	for _, e := range entries {
+		fn(e.labels, e.s)
+	}
+}
+
+func (f *family) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+}
+
+// CounterVec is a family of counters partitioned by label values.
+type CounterVec struct {
+	*family
+}
+
+// With returns the counter for the given label values.
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.child(values, func() any { return &Counter{} }).(*Counter)
+}
+
+func (v *CounterVec) write(w io.Writer) {
+	v.writeHeader(w)
+	v.each(func(labels string, s any) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, labels, formatFloat(s.(*Counter).Value()))
+	})
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increments the counter by delta. Negative deltas are ignored.
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.value += delta
+	c.mu.Unlock()
+}
+
+// Value returns the current counter value.
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+// GaugeVec is a family of gauges partitioned by label values.
+type GaugeVec struct {
+	*family
+}
+
+// With returns the gauge for the given label values.
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.child(values, func() any { return &Gauge{} }).(*Gauge)
+}
+
+func (v *GaugeVec) write(w io.Writer) {
+	v.writeHeader(w)
+	v.each(func(labels string, s any) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, labels, formatFloat(s.(*Gauge).Value()))
+	})
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	g.value = v
+	g.mu.Unlock()
+}
+
+// Inc increments the gauge by one.
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec decrements the gauge by one.
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// Add adds delta to the gauge.
+func (g *Gauge) Add(delta float64) {
+	g.mu.Lock()
+	g.value += delta
+	g.mu.Unlock()
+}
+
+// Value returns the current gauge value.
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+// gaugeFunc is a gauge computed on demand.
+type gaugeFunc struct {
+	name string
+	help string
+	fn   func() float64
+}
+
+func (g *gaugeFunc) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, g.help, g.name, g.name, formatFloat(g.fn()))
+}
+
+// GaugeFuncVec is a family of gauges computed on demand, partitioned by
+// label values.
+type GaugeFuncVec struct {
+	*family
+}
+
+// Set makes fn compute the gauge for the given label values, replacing the
+// function set before.
+func (v *GaugeFuncVec) Set(fn func() float64, values ...string) {
+	g := v.child(values, func() any { return &gaugeFuncValue{} }).(*gaugeFuncValue)
+	g.mu.Lock()
+	g.fn = fn
+	g.mu.Unlock()
+}
+
+func (v *GaugeFuncVec) write(w io.Writer) {
+	v.writeHeader(w)
+	v.each(func(labels string, s any) {
+		g := s.(*gaugeFuncValue)
+		g.mu.Lock()
+		fn := g.fn
+		g.mu.Unlock()
+		fmt.Fprintf(w, "%s%s %s\n", v.name, labels, formatFloat(fn()))
+	})
+}
+
+// gaugeFuncValue is the function of one gauge of a GaugeFuncVec.
+type gaugeFuncValue struct {
+	mu sync.Mutex
+	fn func() float64
+}
+
+// HistogramVec is a family of histograms partitioned by label values.
+type HistogramVec struct {
+	*family
+	buckets []float64
+}
+
+// With returns the histogram for the given label values.
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.child(values, func() any {
+		return &Histogram{buckets: v.buckets, counts: make([]uint64, len(v.buckets))}
+	}).(*Histogram)
+}
+
+func (v *HistogramVec) write(w io.Writer) {
+	v.writeHeader(w)
+	v.each(func(labels string, s any) {
+		h := s.(*Histogram)
+		snap := h.Snapshot()
+
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += snap.Buckets[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, withLabel(labels, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, withLabel(labels, "le", "+Inf"), snap.Count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.name, labels, formatFloat(snap.Sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.name, labels, snap.Count)
+	})
+}
+
+// Histogram samples observations into configurable buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// HistogramSnapshot is a point-in-time copy of a histogram.
+type HistogramSnapshot struct {
+	Count   uint64
+	Sum     float64
+	Buckets []uint64 // Non-cumulative counts per bucket
+}
+
+// Observe records a single observation.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.count++
+	h.sum += v
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+// Snapshot returns a consistent copy of the histogram state.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return HistogramSnapshot{
+		Count:   h.count,
+		Sum:     h.sum,
+		Buckets: append([]uint64(nil), h.counts...),
+	}
+}
+
+// formatLabels renders label pairs as {a="x",b="y"}.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%q", name, values[i])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLabel appends an extra label pair to an already formatted label set.
+func withLabel(labels, name, value string) string {
+	pair := fmt.Sprintf("%s=%q", name, value)
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return fmt.Sprintf("%g", v)
+	}
+}
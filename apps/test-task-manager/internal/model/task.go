@@ -1,14 +1,32 @@
 // Package model defines the data models for the task manager.
 package model
 
-import "time"
+import (
+	"encoding/xml"
+	"time"
+)
 
 // Task represents a single task item in the task manager with priority indicators.
 type Task struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Completed bool      `json:"completed"`
-	CreatedAt time.Time `json:"createdAt"`
-	Priority  string    `json:"priority"` // Emoticon representing priority (🔥, ⭐, ⚡, 💡, 📋)
-	Color     string    `json:"color"`    // Hex color code for visual display
+	XMLName     xml.Name   `json:"-" xml:"task"`
+	ID          string     `json:"id" xml:"id"`
+	Title       string     `json:"title" xml:"title"`
+	Completed   bool       `json:"completed" xml:"completed"`
+	CreatedAt   time.Time  `json:"createdAt" xml:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" xml:"completedAt,omitempty"` // Set when the task was last marked complete
+	Priority    string     `json:"priority" xml:"priority"`                           // Emoticon representing priority (🔥, ⭐, ⚡, 💡, 📋)
+	Color       string     `json:"color" xml:"color"`                                 // Hex color code for visual display
+	DueDate     *time.Time `json:"dueDate,omitempty" xml:"dueDate,omitempty"`         // Optional deadline
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty" xml:"updatedAt,omitempty"`     // Set when the task was last changed after creation
+	CreatedBy   string     `json:"createdBy,omitempty" xml:"createdBy,omitempty"`     // ID of the user who created the task; empty when created anonymously
+}
+
+// ChangedAt returns when the task was last changed: UpdatedAt, or CreatedAt
+// for tasks not changed since they were created, or before changes were
+// recorded.
+func (t Task) ChangedAt() time.Time {
+	if t.UpdatedAt != nil {
+		return *t.UpdatedAt
+	}
+	return t.CreatedAt
 }
@@ -0,0 +1,68 @@
+// Package netguard keeps outbound requests to URLs chosen by users away
+// from the host and its private networks.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// ErrForbiddenAddress is returned for hosts that resolve to loopback,
+// private, link-local, multicast or unspecified addresses.
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// Public reports whether ip is a publicly routable unicast address.
+func Public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which
+// IsPrivate leaves out.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// CheckHost returns ErrForbiddenAddress when host is, or resolves to, an
+// address that is not Public. Hosts that cannot be resolved are let through:
+// Control refuses them again when they are dialed.
+func CheckHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return check(host, ip)
+	}
+	if name := strings.ToLower(strings.TrimSuffix(host, ".")); name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if err := check(host, ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control function refusing connections to
+// addresses that are not Public. It runs after name resolution, so a host
+// that resolves to another address than when it was checked is caught.
+func Control(network, address string, _ syscall.RawConn) error {
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	return check(address, addr.Addr())
+}
+
+func check(host string, ip netip.Addr) error {
+	if !Public(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := Public(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("Public(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "[::1]", "169.254.169.254", "10.0.0.8", "0.0.0.0", "localhost", "api.localhost."} {
+		if err := CheckHost(context.Background(), host); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("CheckHost(%q): expected ErrForbiddenAddress, got %v", host, err)
+		}
+	}
+	if err := CheckHost(context.Background(), "93.184.216.34"); err != nil {
+		t.Errorf("expected a public address to be allowed, got %v", err)
+	}
+}
+
+func TestControl(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	dialer := &net.Dialer{Timeout: time.Second, Control: Control}
+	client := &http.Client{Transport: &http.Transport{DialContext: dialer.DialContext}}
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("expected the loopback server to be refused at dial time, got %v", err)
+	}
+}
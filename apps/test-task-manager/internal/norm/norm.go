@@ -0,0 +1,157 @@
+// Package norm converts text to Unicode Normalization Form C (NFC), in which
+// characters are composed where Unicode allows, so that text that looks the
+// same is stored the same however it was typed: "é" as one code point or as
+// "e" followed by a combining acute accent.
+package norm
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Hangul syllables are composed and decomposed algorithmically, rather than
+// through the tables.
+const (
+	hangulBase    = 0xAC00 // First syllable
+	leadBase      = 0x1100 // First leading consonant
+	vowelBase     = 0x1161 // First vowel
+	trailBase     = 0x11A7 // One before the first trailing consonant
+	leadCount     = 19
+	vowelCount    = 21
+	trailCount    = 28 // Including none
+	syllableCount = leadCount * vowelCount * trailCount
+)
+
+// compositions maps pairs of code points to the code point NFC composes
+// them into.
+var compositions = func() map[[2]rune]rune {
+	m := make(map[[2]rune]rune, len(decompositions))
+	for r, d := range decompositions {
+		if d[1] != 0 && !slices.Contains(compositionExclusions, r) {
+			m[d] = r
+		}
+	}
+	return m
+}()
+
+// NFC returns s, which must be valid UTF-8, in Normalization Form C.
+func NFC(s string) string {
+	if stable(s) {
+		return s
+	}
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		runes = decompose(runes, r)
+	}
+	reorder(runes)
+	return string(compose(runes))
+}
+
+// stable reports whether s only holds code points below U+0300, which NFC
+// leaves alone, as is the case for most titles.
+func stable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		// U+0300 is the first code point encoded with a lead byte of 0xCC.
+		if s[i] >= 0xCC {
+			return false
+		}
+	}
+	return true
+}
+
+// decompose appends the full canonical decomposition of r to dst.
+func decompose(dst []rune, r rune) []rune {
+	if r >= hangulBase && r < hangulBase+syllableCount {
+		i := r - hangulBase
+		dst = append(dst, leadBase+i/(vowelCount*trailCount), vowelBase+i%(vowelCount*trailCount)/trailCount)
+		if trail := i % trailCount; trail != 0 {
+			dst = append(dst, trailBase+trail)
+		}
+		return dst
+	}
+	if d, ok := decompositions[r]; ok {
+		dst = decompose(dst, d[0])
+		if d[1] != 0 {
+			dst = decompose(dst, d[1])
+		}
+		return dst
+	}
+	return append(dst, r)
+}
+
+// reorder puts every run of combining marks in the order of their
+// combining classes, keeping the order of marks of the same class.
+func reorder(runes []rune) {
+	for start := 0; start < len(runes); start++ {
+		if combiningClass(runes[start]) == 0 {
+			continue
+		}
+		end := start + 1
+		for end < len(runes) && combiningClass(runes[end]) != 0 {
+			end++
+		}
+		slices.SortStableFunc(runes[start:end], func(a, b rune) int {
+			return cmp.Compare(combiningClass(a), combiningClass(b))
+		})
+		start = end
+	}
+}
+
+// compose composes the decomposed and reordered runes in place, combining
+// each mark with the last starter unless a mark of the same or a higher
+// class comes between them.
+func compose(runes []rune) []rune {
+	if len(runes) == 0 {
+		return runes
+	}
+	starter, written := 0, 1
+	lastClass := combiningClass(runes[0])
+	if lastClass != 0 {
+		lastClass = 256 // Marks cannot compose with a mark
+	}
+	for _, r := range runes[1:] {
+		class := combiningClass(r)
+		if composite, ok := composePair(runes[starter], r); ok && (lastClass < class || lastClass == 0) {
+			runes[starter] = composite
+			continue
+		}
+		if class == 0 {
+			starter = written
+		}
+		lastClass = class
+		runes[written] = r
+		written++
+	}
+	return runes[:written]
+}
+
+// composePair returns the code point a and b compose into, if any.
+func composePair(a, b rune) (rune, bool) {
+	if a >= leadBase && a < leadBase+leadCount && b >= vowelBase && b < vowelBase+vowelCount {
+		return hangulBase + ((a-leadBase)*vowelCount+b-vowelBase)*trailCount, true
+	}
+	if a >= hangulBase && a < hangulBase+syllableCount && (a-hangulBase)%trailCount == 0 && b > trailBase && b < trailBase+trailCount {
+		return a + b - trailBase, true
+	}
+	r, ok := compositions[[2]rune{a, b}]
+	return r, ok
+}
+
+// combiningClass returns the canonical combining class of r: 0 for
+// starters, which most code points are.
+func combiningClass(r rune) rune {
+	i, found := slices.BinarySearchFunc(combiningClasses, r, func(entry [3]rune, r rune) int {
+		switch {
+		case r < entry[0]:
+			return 1
+		case r > entry[1]:
+			return -1
+		default:
+			return 0
+		}
+	})
+	if !found {
+		return 0
+	}
+	return combiningClasses[i][2]
+}
@@ -0,0 +1,25 @@
+package norm
+
+import "testing"
+
+func TestNFC(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"ASCII", "Buy milk", "Buy milk"},
+		{"composed", "Caf\u00e9", "Caf\u00e9"},
+		{"combining accent", "Cafe\u0301", "Caf\u00e9"},
+		{"singleton", "5 \u2126", "5 \u03a9"},                // Ohm sign to Greek capital omega
+		{"reordered marks", "a\u0301\u0323", "\u1ea1\u0301"}, // Dot below (220) composes before acute (230)
+		{"blocked mark", "e\u0301\u0301", "\u00e9\u0301"},    // The second acute cannot compose
+		{"excluded composition", "\u0958", "\u0915\u093c"},   // Devanagari qa stays decomposed
+		{"Hangul", "\u1112\u1161\u11ab", "\ud55c"},           // From its jamo
+		{"emoji", "Ship \U0001F525 release", "Ship \U0001F525 release"},
+		{"leading mark", "\u0301e", "\u0301e"},
+	}
+	for _, tt := range tests {
+		if got := NFC(tt.in); got != tt.want {
+			t.Errorf("%s: NFC(%+q): expected %+q, got %+q", tt.name, tt.in, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/cron"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/health"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"go.uber.org/zap"
+)
+
+// Digest summarizes the tasks on the day of Date.
+type Digest struct {
+	Date               time.Time    `json:"date"`     // Start of the day, in the time zone of the digest
+	New                []model.Task `json:"new"`      // Created in the 24 hours before the digest
+	DueToday           []model.Task `json:"dueToday"` // Open and due later that day
+	Overdue            []model.Task `json:"overdue"`  // Open and past their due date
+	CompletedYesterday []model.Task `json:"completedYesterday"`
+}
+
+// NewDigest builds the digest of tasks at now. Days start at midnight in
+// the location of now.
+func NewDigest(tasks []model.Task, now time.Time) Digest {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	tomorrow, yesterday := today.AddDate(0, 0, 1), today.AddDate(0, 0, -1)
+
+	d := Digest{Date: today}
+	for _, task := range tasks {
+		if task.CreatedAt.After(now.Add(-24 * time.Hour)) {
+			d.New = append(d.New, task)
+		}
+		switch {
+		case task.Completed:
+			if task.CompletedAt != nil && !task.CompletedAt.Before(yesterday) && task.CompletedAt.Before(today) {
+				d.CompletedYesterday = append(d.CompletedYesterday, task)
+			}
+		case task.DueDate == nil:
+		case task.DueDate.Before(now):
+			d.Overdue = append(d.Overdue, task)
+		case task.DueDate.Before(tomorrow):
+			d.DueToday = append(d.DueToday, task)
+		}
+	}
+	return d
+}
+
+// Empty reports whether there is nothing to tell.
+func (d Digest) Empty() bool {
+	return len(d.New)+len(d.DueToday)+len(d.Overdue)+len(d.CompletedYesterday) == 0
+}
+
+// Digester queues the daily digest for every recipient on a schedule.
+// Tasks have no owner, so every recipient gets the digest of all tasks.
+type Digester struct {
+	tasks      func(ctx context.Context) ([]model.Task, error)
+	recipients func() ([]string, error)
+	dispatcher *Dispatcher
+	logger     *zap.SugaredLogger
+	outcome    health.Outcome
+}
+
+// NewDigester creates a digester reading all tasks with tasks and the
+// addresses to send the digest to with recipients.
+func NewDigester(tasks func(ctx context.Context) ([]model.Task, error), recipients func() ([]string, error), dispatcher *Dispatcher, logger *zap.SugaredLogger) *Digester {
+	return &Digester{tasks: tasks, recipients: recipients, dispatcher: dispatcher, logger: logger}
+}
+
+// Run sends the digest at every time of schedule in loc until ctx is done.
+func (d *Digester) Run(ctx context.Context, schedule cron.Schedule, loc *time.Location) {
+	cron.RunIn(ctx, schedule, loc, func(now time.Time) {
+		err := d.Send(ctx, now)
+		d.outcome.Record(err)
+		if err != nil {
+			d.logger.Warnw("failed to send daily digest", "error", err)
+		}
+	})
+}
+
+// Check is a health.Check reporting the error of the last scheduled
+// digest.
+func (d *Digester) Check(ctx context.Context) error {
+	return d.outcome.Check(ctx)
+}
+
+// Send queues the digest at now for every recipient. Nothing is sent when
+// the digest is empty.
+func (d *Digester) Send(ctx context.Context, now time.Time) error {
+	tasks, err := d.tasks(ctx)
+	if err != nil {
+		return err
+	}
+	digest := NewDigest(tasks, now)
+	if digest.Empty() {
+		d.logger.Infow("daily digest skipped, nothing to report")
+		return nil
+	}
+
+	recipients, err := d.recipients()
+	if err != nil {
+		return err
+	}
+	queued := 0
+	for _, to := range recipients {
+		if d.dispatcher.Enqueue(Notification{Event: EventDigest, Digest: &digest, At: now, To: to}) {
+			queued++
+		}
+	}
+	d.logger.Infow("daily digest queued", "recipients", len(recipients), "queued", queued)
+	return nil
+}
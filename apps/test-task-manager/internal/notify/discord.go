@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// discordEmbed is a rich message block of a Discord webhook message.
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Color       int            `json:"color"` // RGB as integer
+	Timestamp   string         `json:"timestamp"`
+	Fields      []discordField `json:"fields,omitempty"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// Discord posts notifications to a Discord channel through an incoming
+// webhook, as an embed in the color of the task with its priority and due
+// date as fields.
+type Discord struct {
+	webhook string // Holds the webhook token, so it is never logged
+	events  []Event
+	client  *http.Client
+}
+
+// NewDiscord creates a notifier posting events to the webhook URL.
+func NewDiscord(webhook string, events []Event, client *http.Client) (*Discord, error) {
+	u, err := url.Parse(webhook)
+	if err != nil || u.Scheme != "https" || !strings.Contains(u.Path, "/api/webhooks/") {
+		return nil, fmt.Errorf("the Discord webhook must be an https URL like https://discord.com/api/webhooks/<id>/<token>")
+	}
+	return &Discord{webhook: webhook, events: events, client: client}, nil
+}
+
+// Name implements Notifier.
+func (d *Discord) Name() string {
+	return "discord"
+}
+
+// Sends implements Selective.
+func (d *Discord) Sends(event Event) bool {
+	return slices.Contains(d.events, event)
+}
+
+// Notify implements Notifier.
+func (d *Discord) Notify(ctx context.Context, n Notification) error {
+	title, body := describe(n)
+	embed := discordEmbed{
+		Title:       title,
+		Description: body,
+		Color:       colorValue(n.Task.Color),
+		Timestamp:   n.At.UTC().Format(time.RFC3339),
+		Fields:      []discordField{{Name: "Priority", Value: n.Task.Priority, Inline: true}},
+	}
+	if n.Task.DueDate != nil {
+		// Discord renders <t:unix:f> in the time zone of each reader.
+		embed.Fields = append(embed.Fields, discordField{Name: "Due", Value: fmt.Sprintf("<t:%d:f>", n.Task.DueDate.Unix()), Inline: true})
+	}
+	return postJSON(ctx, d.client, d.webhook, "Discord webhook", map[string]any{"embeds": []discordEmbed{embed}})
+}
+
+// colorValue returns the hex color "#rrggbb" as integer, or 0 (no color).
+func colorValue(hex string) int {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil || len(hex) != 7 {
+		return 0
+	}
+	return int(v)
+}
@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/jobs"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"go.uber.org/zap"
+)
+
+// Dispatcher queues a background job per notifier for every notification,
+// so slow or failing notifiers never block the caller and are retried on
+// their own.
+type Dispatcher struct {
+	queue     *jobs.Queue
+	notifiers []Notifier
+	owners    Owners // Nil when owners are not told about their tasks
+	logger    *zap.SugaredLogger
+
+	sent    *metrics.CounterVec
+	dropped *metrics.Counter
+}
+
+// NewDispatcher creates a dispatcher queueing notifications on queue and
+// registers the job types of its notifiers, which get up to timeout per
+// delivery attempt.
+func NewDispatcher(queue *jobs.Queue, timeout time.Duration, logger *zap.SugaredLogger, reg *metrics.Registry, notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{
+		queue:     queue,
+		notifiers: notifiers,
+		logger:    logger,
+		sent:      reg.CounterVec("notifications_sent_total", "Notification deliveries by notifier, event and result.", "notifier", "event", "result"),
+		dropped:   reg.Counter("notifications_dropped_total", "Notifications that could not be queued."),
+	}
+	for _, notifier := range notifiers {
+		queue.Register(jobType(notifier), func(ctx context.Context, job jobs.Job) error {
+			var n Notification
+			if err := job.Decode(&n); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			err := notifier.Notify(ctx, n)
+
+			result := "success"
+			if err != nil {
+				result = "failure"
+			}
+			d.sent.With(notifier.Name(), string(n.Event), result).Inc()
+			return err
+		})
+	}
+	return d
+}
+
+// NotifyOwners makes the dispatcher also tell the users who created tasks
+// about them, through the channels owners returns.
+func (d *Dispatcher) NotifyOwners(owners Owners) {
+	d.owners = owners
+}
+
+// Enqueue queues n for delivery by every notifier sending its event, or by
+// those that can deliver to n.To when set. Notifications to everyone about
+// a task are also queued for the user who created it, when NotifyOwners
+// was called, through the channels of the user. It reports false when it
+// could not be queued for some of them.
+func (d *Dispatcher) Enqueue(n Notification) bool {
+	queued := d.enqueue(n)
+	if n.To != "" || d.owners == nil || n.Task.CreatedBy == "" || !slices.Contains(ownerEvents, n.Event) {
+		return queued
+	}
+	owner, ok := d.owners(n.Task.CreatedBy)
+	if !ok {
+		return queued
+	}
+	for _, address := range owner.addresses(n.Task.CreatedBy) {
+		personal := n
+		personal.To = address
+		queued = d.enqueue(personal) && queued
+	}
+	return queued
+}
+
+// enqueue queues n for the notifiers Enqueue describes, leaving out the
+// owner of its task.
+func (d *Dispatcher) enqueue(n Notification) bool {
+	queued := true
+	for _, notifier := range d.notifiers {
+		if s, ok := notifier.(Selective); ok && !s.Sends(n.Event) {
+			continue
+		}
+		if n.To != "" {
+			if a, ok := notifier.(Addresser); !ok || !a.CanDeliverTo(n.To) {
+				continue
+			}
+		} else if _, ok := notifier.(addressedOnly); ok {
+			continue
+		}
+		if err := d.queue.Enqueue(jobType(notifier), n); err != nil {
+			d.dropped.Inc()
+			d.logger.Warnw("failed to queue notification", "notifier", notifier.Name(), "event", n.Event, "task", n.Task.ID, "error", err)
+			queued = false
+		}
+	}
+	return queued
+}
+
+// Observe queues the notifications about a change to a task, reported by
+// the task service with the store event type of the change.
+func (d *Dispatcher) Observe(change string, task model.Task) {
+	n := Notification{Task: task, At: time.Now()}
+	switch change {
+	case store.EventTaskCreated:
+		n.Event = EventCreated
+	case store.EventTaskCompleted:
+		n.Event = EventCompleted
+	default:
+		return
+	}
+	d.Enqueue(n)
+}
+
+// jobType is the background job type delivering notifications through notifier.
+func jobType(notifier Notifier) string {
+	return "notify." + notifier.Name()
+}
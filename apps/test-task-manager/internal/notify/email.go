@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultEmailTemplates are used for events without a template file. Every
+// template defines a "subject" and a "body" and is executed with the
+// Notification. Events without either are not emailed.
+var defaultEmailTemplates = map[Event]string{
+	EventDueSoon: `{{define "subject"}}Task due soon: {{.Task.Title}}{{end}}
+{{define "body"}}{{.Task.Priority}} {{.Task.Title}} is due on {{.Task.DueDate.Format "Mon 2 Jan 2006 15:04 MST"}}.
+{{end}}`,
+	EventOverdue: `{{define "subject"}}Task overdue: {{.Task.Title}}{{end}}
+{{define "body"}}{{.Task.Priority}} {{.Task.Title}} was due on {{.Task.DueDate.Format "Mon 2 Jan 2006 15:04 MST"}} and is still open.
+{{end}}`,
+	EventEscalated: `{{define "subject"}}Task escalated: {{.Task.Title}}{{end}}
+{{define "body"}}{{.Task.Priority}} {{.Task.Title}} was due on {{.Task.DueDate.Format "Mon 2 Jan 2006 15:04 MST"}} and is still open, so it has been escalated to you.
+{{end}}`,
+	EventDigest: `{{define "subject"}}Your tasks for {{.Digest.Date.Format "Mon 2 Jan"}}{{end}}
+{{define "list"}}{{range .}}- {{.Priority}} {{.Title}}{{with .DueDate}} (due {{.Format "Mon 2 Jan 15:04"}}){{end}}
+{{else}}Nothing.
+{{end}}{{end}}
+{{define "body"}}Overdue:
+{{template "list" .Digest.Overdue}}
+Due today:
+{{template "list" .Digest.DueToday}}
+New since yesterday:
+{{template "list" .Digest.New}}
+Completed yesterday:
+{{template "list" .Digest.CompletedYesterday}}{{end}}`,
+}
+
+// EmailConfig configures the SMTP server and the messages sent through it.
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string // No authentication when empty
+	Password string
+	From     string
+	To       []string
+	// TemplateDir optionally holds <event>.tmpl files, e.g. overdue.tmpl,
+	// replacing the default template of that event.
+	TemplateDir string
+}
+
+// Email sends notifications as plain text emails over SMTP. STARTTLS is
+// used when the server offers it.
+type Email struct {
+	config    EmailConfig
+	templates map[Event]*template.Template
+}
+
+// NewEmail creates an email notifier, loading the templates of config.
+func NewEmail(config EmailConfig) (*Email, error) {
+	if config.Host == "" || config.From == "" || len(config.To) == 0 {
+		return nil, errors.New("an SMTP host, a sender and at least one recipient are required")
+	}
+
+	e := &Email{config: config, templates: make(map[Event]*template.Template)}
+	for _, event := range Events {
+		text, ok := defaultEmailTemplates[event]
+		if config.TemplateDir != "" {
+			content, err := os.ReadFile(filepath.Join(config.TemplateDir, string(event)+".tmpl"))
+			if err == nil {
+				text, ok = string(content), true
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		tmpl, err := template.New(string(event)).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s email template: %w", event, err)
+		}
+		if tmpl.Lookup("subject") == nil || tmpl.Lookup("body") == nil {
+			return nil, fmt.Errorf("the %s email template must define a subject and a body", event)
+		}
+		e.templates[event] = tmpl
+	}
+	return e, nil
+}
+
+// Name implements Notifier.
+func (e *Email) Name() string {
+	return "email"
+}
+
+// Sends implements Selective: events with a template are sent.
+func (e *Email) Sends(event Event) bool {
+	_, ok := e.templates[event]
+	return ok
+}
+
+// CanDeliverTo implements Addresser.
+func (e *Email) CanDeliverTo(address string) bool {
+	_, err := mail.ParseAddress(address)
+	return err == nil
+}
+
+// recipients returns the addresses n is sent to.
+func (e *Email) recipients(n Notification) []string {
+	if n.To != "" {
+		return []string{n.To}
+	}
+	return e.config.To
+}
+
+// Notify implements Notifier.
+func (e *Email) Notify(ctx context.Context, n Notification) error {
+	message, err := e.message(n)
+	if err != nil {
+		return err
+	}
+	return e.send(ctx, e.recipients(n), message)
+}
+
+// message renders the email for n, headers included.
+func (e *Email) message(n Notification) ([]byte, error) {
+	tmpl, ok := e.templates[n.Event]
+	if !ok {
+		return nil, fmt.Errorf("no email template for event %q", n.Event)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", n); err != nil {
+		return nil, err
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", n); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.recipients(n), ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.At.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body.String(), "\n", "\r\n"))
+	return msg.Bytes(), nil
+}
+
+// send delivers message to recipients, within the deadline of ctx.
+func (e *Email) send(ctx context.Context, recipients []string, message []byte) error {
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: e.config.Host}); err != nil {
+			return err
+		}
+	}
+	if e.config.Username != "" {
+		// PlainAuth refuses to send credentials over unencrypted
+		// connections to hosts other than localhost.
+		if err := client.Auth(smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(e.config.From); err != nil {
+		return err
+	}
+	for _, to := range recipients {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+)
+
+// EscalationRule escalates the open tasks that have been overdue for at
+// least After. A rule with priorities bumps tasks of priority From to To,
+// a rule without sends an EventEscalated notification.
+type EscalationRule struct {
+	After    time.Duration
+	From, To string // Empty for a notification
+}
+
+// ParseEscalationRule parses a rule written as "<after>:<from>><to>", such
+// as "24h:⭐>🔥", or as "<after>:notify", such as "72h:notify".
+func ParseEscalationRule(s string) (EscalationRule, error) {
+	after, action, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return EscalationRule{}, fmt.Errorf("escalation rule %q must be <after>:<from>><to> or <after>:notify", s)
+	}
+	d, err := time.ParseDuration(after)
+	if err != nil || d <= 0 {
+		return EscalationRule{}, fmt.Errorf("escalation rule %q needs a positive duration such as 24h", s)
+	}
+
+	rule := EscalationRule{After: d}
+	if action == "notify" {
+		return rule, nil
+	}
+	from, to, ok := strings.Cut(action, ">")
+	if !ok || !slices.Contains(service.Priorities, from) || !slices.Contains(service.Priorities, to) || from == to {
+		return EscalationRule{}, fmt.Errorf("escalation rule %q must bump between two different priorities of %s", s, strings.Join(service.Priorities, " "))
+	}
+	rule.From, rule.To = from, to
+	return rule, nil
+}
+
+// Notifies reports whether the rule sends a notification rather than
+// bumping the priority.
+func (r EscalationRule) Notifies() bool {
+	return r.From == ""
+}
+
+// String returns the rule as it is parsed.
+func (r EscalationRule) String() string {
+	if r.Notifies() {
+		return r.After.String() + ":notify"
+	}
+	return r.After.String() + ":" + r.From + ">" + r.To
+}
+
+// event is the event under which the notification of the rule is recorded
+// in the Reminders, so every rule notifies once per task.
+func (r EscalationRule) event() Event {
+	return Event(string(EventEscalated) + ":" + r.After.String())
+}
+
+// Escalate makes the watcher apply rules to the overdue tasks on every
+// scan, in the order of their thresholds, so a rule may bump the priority
+// a shorter one bumped to. Priorities are changed with setPriority, and
+// notifications are sent to the address to, or to the configured
+// recipients when it is empty. It must be called before Run.
+func (w *DueWatcher) Escalate(rules []EscalationRule, setPriority func(ctx context.Context, id, priority string) (model.Task, error), to string) {
+	w.rules = slices.SortedStableFunc(slices.Values(rules), func(a, b EscalationRule) int {
+		return int(a.After - b.After)
+	})
+	w.setPriority = setPriority
+	w.escalateTo = to
+}
+
+// escalate applies the rules to a task that is overdue at now and returns
+// the task as it is afterwards.
+func (w *DueWatcher) escalate(ctx context.Context, task model.Task, now time.Time) model.Task {
+	overdue := now.Sub(*task.DueDate)
+	for _, rule := range w.rules {
+		if overdue < rule.After {
+			break
+		}
+
+		if !rule.Notifies() {
+			if task.Priority != rule.From {
+				continue
+			}
+			updated, err := w.setPriority(ctx, task.ID, rule.To)
+			if err != nil {
+				w.logger.Warnw("failed to escalate task", "task", task.ID, "rule", rule.String(), "error", err)
+				continue
+			}
+			w.logger.Infow("task escalated", "task", task.ID, "rule", rule.String(), "from", rule.From, "to", rule.To)
+			task = updated
+			continue
+		}
+
+		if w.reminders.Sent(task.ID, rule.event()) {
+			continue
+		}
+		if !w.dispatcher.Enqueue(Notification{Event: EventEscalated, Task: task, At: now, To: w.escalateTo}) {
+			continue // Retried on the next scan
+		}
+		w.logger.Infow("task escalated", "task", task.ID, "rule", rule.String(), "to", w.escalateTo)
+		w.reminders.Mark(task.ID, rule.event(), now)
+	}
+	return task
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// describe returns a short title and a one-line body for n, for channels
+// without templates.
+func describe(n Notification) (title, body string) {
+	task := n.Task.Priority + " " + n.Task.Title
+	switch n.Event {
+	case EventDueSoon:
+		return "Task due soon", task + " is due " + n.Task.DueDate.Format("Mon 2 Jan 15:04")
+	case EventOverdue:
+		return "Task overdue", task + " was due " + n.Task.DueDate.Format("Mon 2 Jan 15:04")
+	case EventEscalated:
+		return "Task escalated", task + " is still open, it was due " + n.Task.DueDate.Format("Mon 2 Jan 15:04")
+	case EventCreated:
+		if n.Task.DueDate != nil {
+			return "Task created", task + ", due " + n.Task.DueDate.Format("Mon 2 Jan 15:04")
+		}
+		return "Task created", task
+	case EventCompleted:
+		return "Task completed", task
+	case EventDigest:
+		d := n.Digest
+		var parts []string
+		for _, part := range []struct {
+			n     int
+			label string
+		}{
+			{len(d.Overdue), "overdue"},
+			{len(d.DueToday), "due today"},
+			{len(d.New), "new"},
+			{len(d.CompletedYesterday), "completed yesterday"},
+		} {
+			if part.n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", part.n, part.label))
+			}
+		}
+		return "Your tasks for " + d.Date.Format("Mon 2 Jan"), strings.Join(parts, ", ")
+	default:
+		return string(n.Event), task
+	}
+}
@@ -0,0 +1,79 @@
+// Package notify tells people about tasks that need their attention. Events
+// are queued on a Dispatcher, which hands them to every configured Notifier
+// from background jobs, so slow delivery never blocks requests.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// Event is the reason for a notification.
+type Event string
+
+// Events notifications are sent for.
+const (
+	// EventDueSoon is sent once when an open task gets close to its due date.
+	EventDueSoon Event = "due_soon"
+	// EventOverdue is sent once when an open task passes its due date.
+	EventOverdue Event = "overdue"
+	// EventDigest is the daily summary of the tasks, sent to every user.
+	EventDigest Event = "digest"
+	// EventEscalated is sent once per notifying EscalationRule when an open
+	// task stays overdue for longer than the rule allows.
+	EventEscalated Event = "escalated"
+	// EventCreated is sent when a task is created.
+	EventCreated Event = "created"
+	// EventCompleted is sent when a task is marked complete.
+	EventCompleted Event = "completed"
+)
+
+// Events lists every event.
+var Events = []Event{EventDueSoon, EventOverdue, EventDigest, EventEscalated, EventCreated, EventCompleted}
+
+// ParseEvents returns the events called names.
+func ParseEvents(names []string) ([]Event, error) {
+	events := make([]Event, 0, len(names))
+	for _, name := range names {
+		i := slices.Index(Events, Event(name))
+		if i < 0 {
+			return nil, fmt.Errorf("unknown notification event %q", name)
+		}
+		events = append(events, Events[i])
+	}
+	return events, nil
+}
+
+// Notification is a single event about a task, or a digest of several.
+type Notification struct {
+	Event  Event      `json:"event"`
+	Task   model.Task `json:"task"`
+	Digest *Digest    `json:"digest,omitempty"` // Set for EventDigest only
+	At     time.Time  `json:"at"`               // When the event was detected
+	// To is the address of a single recipient instead of the configured
+	// ones. Only notifiers implementing Addresser send such notifications.
+	To string `json:"to,omitempty"`
+}
+
+// Notifier delivers notifications through one channel, such as email.
+type Notifier interface {
+	// Name identifies the notifier in logs and metrics.
+	Name() string
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Selective is implemented by notifiers that send some events only. The
+// others are not queued for them.
+type Selective interface {
+	Sends(event Event) bool
+}
+
+// Addresser is implemented by notifiers that can deliver to the address of
+// a single recipient, such as email.
+type Addresser interface {
+	CanDeliverTo(address string) bool
+}
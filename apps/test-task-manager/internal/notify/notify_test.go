@@ -0,0 +1,386 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/jobs"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"go.uber.org/zap"
+)
+
+// fakeSMTP accepts a single message on a local port and returns it.
+func fakeSMTP(t *testing.T) (host string, port int, message <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					received <- data.String()
+					reply("250 OK")
+				} else {
+					data.WriteString(line)
+				}
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, received
+}
+
+func TestEmail_Notify(t *testing.T) {
+	host, port, received := fakeSMTP(t)
+	email, err := NewEmail(EmailConfig{Host: host, Port: port, From: "tasks@example.com", To: []string{"team@example.com"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	due := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	n := Notification{Event: EventOverdue, Task: model.Task{ID: "1", Title: "Pay invoice", Priority: "🔥", DueDate: &due}, At: due.Add(time.Hour)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := email.Notify(ctx, n); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	message := <-received
+	for _, want := range []string{"To: team@example.com", "Subject: Task overdue: Pay invoice", "was due on Fri 1 Mar 2024 09:00 UTC"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("expected message to contain %q, got:\n%s", want, message)
+		}
+	}
+}
+
+// recorder is a Notifier remembering what it was asked to send.
+type recorder struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n.Task.ID+":"+string(n.Event))
+	return nil
+}
+
+func TestDueWatcher_NotifiesOncePerEvent(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := store.NewTaskStore()
+	for i, due := range []time.Duration{-time.Hour, 2 * time.Hour, 48 * time.Hour} {
+		d := now.Add(due)
+		s.Create(t.Context(), model.Task{Title: "Task " + strconv.Itoa(i), Priority: "⭐", DueDate: &d})
+	}
+	done := time.Now()
+	s.Create(t.Context(), model.Task{Title: "Done", Priority: "⭐", DueDate: &now, Completed: true, CompletedAt: &done})
+
+	notifier := &recorder{}
+	queue := jobs.New(jobs.NewMemory(10), 1, jobs.RetryPolicy{MaxAttempts: 1}, zap.NewNop().Sugar(), metrics.NewRegistry())
+	dispatcher := NewDispatcher(queue, time.Second, zap.NewNop().Sugar(), metrics.NewRegistry(), notifier)
+	queue.Start()
+	path := filepath.Join(t.TempDir(), "reminders.json")
+	reminders, _ := NewReminders(path)
+	watcher := NewDueWatcher(s.Find, dispatcher, 24*time.Hour, reminders, zap.NewNop().Sugar())
+
+	for _, at := range []time.Time{now, now, now.Add(3 * time.Hour)} {
+		if err := watcher.Scan(t.Context(), at); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	// After a restart, the persisted reminders prevent sending them again.
+	reopened, err := NewReminders(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := NewDueWatcher(s.Find, dispatcher, 24*time.Hour, reopened, zap.NewNop().Sugar()).Scan(t.Context(), now.Add(4*time.Hour)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	queue.Shutdown(time.Second)
+
+	want := []string{"1:overdue", "2:due_soon", "2:overdue"}
+	if strings.Join(notifier.sent, ",") != strings.Join(want, ",") {
+		t.Errorf("expected notifications %v, got %v", want, notifier.sent)
+	}
+}
+
+func TestDigest_SentToRecipient(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, loc)
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+
+	digest := NewDigest([]model.Task{
+		{ID: "1", Title: "Call the bank", CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "2", Title: "Pay rent", CreatedAt: now.Add(-72 * time.Hour), DueDate: at(-time.Hour)},
+		{ID: "3", Title: "Book flights", CreatedAt: now.Add(-72 * time.Hour), DueDate: at(4 * time.Hour)},
+		{ID: "4", Title: "File taxes", CreatedAt: now.Add(-72 * time.Hour), Completed: true, CompletedAt: at(-12 * time.Hour)},
+		{ID: "5", Title: "Renew passport", CreatedAt: now.Add(-72 * time.Hour), DueDate: at(48 * time.Hour)},
+	}, now)
+	if len(digest.New) != 1 || len(digest.Overdue) != 1 || len(digest.DueToday) != 1 || len(digest.CompletedYesterday) != 1 {
+		t.Fatalf("unexpected digest %+v", digest)
+	}
+
+	host, port, message := fakeSMTP(t)
+	email, err := NewEmail(EmailConfig{Host: host, Port: port, From: "ttm@example.com", To: []string{"ops@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := email.Notify(context.Background(), Notification{Event: EventDigest, Digest: &digest, At: now, To: "ann@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := <-message
+	for _, want := range []string{"To: ann@example.com", "Your tasks for Tue 10 Mar", "Pay rent", "Book flights (due Tue 10 Mar 12:00)", "File taxes"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected the digest to contain %q, got:\n%s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "Renew passport") {
+		t.Errorf("expected tasks due later not to be listed, got:\n%s", msg)
+	}
+}
+
+func TestDueWatcher_Escalates(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := store.NewTaskStore()
+	due := now.Add(-time.Hour)
+	task, _ := s.Create(t.Context(), model.Task{Title: "Late", Priority: "⭐", DueDate: &due})
+
+	notifier := &recorder{}
+	queue := jobs.New(jobs.NewMemory(10), 1, jobs.RetryPolicy{MaxAttempts: 1}, zap.NewNop().Sugar(), metrics.NewRegistry())
+	dispatcher := NewDispatcher(queue, time.Second, zap.NewNop().Sugar(), metrics.NewRegistry(), notifier)
+	queue.Start()
+	reminders, _ := NewReminders("")
+	watcher := NewDueWatcher(s.Find, dispatcher, 0, reminders, zap.NewNop().Sugar())
+
+	var rules []EscalationRule
+	for _, text := range []string{"48h:⚡>🔥", "72h:notify", "24h:⭐>⚡"} {
+		rule, err := ParseEscalationRule(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, rule)
+	}
+	watcher.Escalate(rules, func(ctx context.Context, id, priority string) (model.Task, error) {
+		task, _ := s.GetByID(ctx, id)
+		task.Priority = priority
+		return s.Update(ctx, task)
+	}, "")
+
+	for _, at := range []time.Time{now, now.Add(30 * time.Hour), now.Add(50 * time.Hour), now.Add(80 * time.Hour), now.Add(90 * time.Hour)} {
+		if err := watcher.Scan(t.Context(), at); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	queue.Shutdown(time.Second)
+
+	if got, _ := s.GetByID(t.Context(), task.ID); got.Priority != "🔥" {
+		t.Errorf("expected the priority to be bumped twice, got %s", got.Priority)
+	}
+	want := []string{task.ID + ":overdue", task.ID + ":escalated"}
+	if strings.Join(notifier.sent, ",") != strings.Join(want, ",") {
+		t.Errorf("expected notifications %v, got %v", want, notifier.sent)
+	}
+	if _, err := ParseEscalationRule("24h:⭐>⭐"); err == nil {
+		t.Error("expected a rule keeping the priority to be rejected")
+	}
+}
+
+func TestNtfy_PublishesSelectedEvents(t *testing.T) {
+	published := make(chan *http.Request, 2)
+	bodies := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		published <- r
+		bodies <- string(body)
+	}))
+	defer srv.Close()
+
+	ntfy, err := NewNtfy(srv.URL+"/tasks", "tk_secret", []Event{EventCompleted}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue := jobs.New(jobs.NewMemory(10), 1, jobs.RetryPolicy{MaxAttempts: 1}, zap.NewNop().Sugar(), metrics.NewRegistry())
+	dispatcher := NewDispatcher(queue, time.Second, zap.NewNop().Sugar(), metrics.NewRegistry(), ntfy)
+	queue.Start()
+
+	task := model.Task{ID: "1", Title: "Pay rent", Priority: "🔥"}
+	dispatcher.Observe(store.EventTaskCreated, task)
+	task.Completed = true
+	dispatcher.Observe(store.EventTaskCompleted, task)
+	queue.Shutdown(time.Second)
+
+	if len(published) != 1 {
+		t.Fatalf("expected only the completion to be published, got %d requests", len(published))
+	}
+	r := <-published
+	if r.Header.Get("Title") != "Task completed" || r.Header.Get("Authorization") != "Bearer tk_secret" {
+		t.Errorf("unexpected headers %v", r.Header)
+	}
+	if body := <-bodies; body != "🔥 Pay rent" {
+		t.Errorf("expected the task as message, got %q", body)
+	}
+}
+
+func TestDiscord_EmbedInTaskColor(t *testing.T) {
+	var payload struct {
+		Embeds []discordEmbed `json:"embeds"`
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	discord, err := NewDiscord(srv.URL+"/api/webhooks/1/token", []Event{EventOverdue}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	task := model.Task{ID: "1", Title: "Pay rent", Priority: "🔥", Color: "#dc3545", DueDate: &due}
+	if err := discord.Notify(context.Background(), Notification{Event: EventOverdue, Task: task, At: due.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payload.Embeds) != 1 {
+		t.Fatalf("expected one embed, got %+v", payload)
+	}
+	embed := payload.Embeds[0]
+	if embed.Title != "Task overdue" || embed.Color != 0xdc3545 {
+		t.Errorf("expected an overdue embed in the task color, got %+v", embed)
+	}
+	if len(embed.Fields) != 2 || embed.Fields[0].Value != "🔥" || embed.Fields[1].Value != "<t:1772355600:f>" {
+		t.Errorf("expected priority and due date fields, got %+v", embed.Fields)
+	}
+}
+
+func TestTeams_AdaptiveCard(t *testing.T) {
+	var payload struct {
+		Type        string `json:"type"`
+		Attachments []struct {
+			ContentType string `json:"contentType"`
+			Content     struct {
+				Type string            `json:"type"`
+				Body []json.RawMessage `json:"body"`
+			} `json:"content"`
+		} `json:"attachments"`
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer srv.Close()
+
+	teams, err := NewTeams(srv.URL+"/webhookb2/abc", []Event{EventCompleted}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teams.Sends(EventDueSoon) || !teams.Sends(EventCompleted) {
+		t.Error("expected only the selected events to be sent")
+	}
+	task := model.Task{ID: "1", Title: "Pay rent", Priority: "🔥", Completed: true}
+	if err := teams.Notify(context.Background(), Notification{Event: EventCompleted, Task: task, At: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.Type != "message" || len(payload.Attachments) != 1 || payload.Attachments[0].ContentType != "application/vnd.microsoft.card.adaptive" {
+		t.Fatalf("expected a message with one Adaptive Card, got %+v", payload)
+	}
+	body := payload.Attachments[0].Content.Body
+	var title cardText
+	var facts cardFactSet
+	if len(body) != 3 || json.Unmarshal(body[0], &title) != nil || json.Unmarshal(body[2], &facts) != nil {
+		t.Fatalf("expected a title, body and facts, got %s", body)
+	}
+	if title.Color != "Good" || len(facts.Facts) != 1 || facts.Facts[0].Value != "🔥" {
+		t.Errorf("expected a completed card with only the priority fact, got %+v %+v", title, facts)
+	}
+}
+
+func TestDispatcher_NotifiesOwners(t *testing.T) {
+	posted := make(chan Notification, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		json.NewDecoder(r.Body).Decode(&n)
+		posted <- n
+	}))
+	defer srv.Close()
+
+	broadcast := &recorder{}
+	queue := jobs.New(jobs.NewMemory(10), 1, jobs.RetryPolicy{MaxAttempts: 1}, zap.NewNop().Sugar(), metrics.NewRegistry())
+	dispatcher := NewDispatcher(queue, time.Second, zap.NewNop().Sugar(), metrics.NewRegistry(), broadcast, NewUserWebhook(srv.Client()))
+	dispatcher.NotifyOwners(func(userID string) (Owner, bool) {
+		if userID != "usr_ann" {
+			return Owner{}, false
+		}
+		return Owner{Webhook: srv.URL + "/ann"}, true
+	})
+	queue.Start()
+
+	dispatcher.Observe(store.EventTaskCreated, model.Task{ID: "1", Title: "Pay rent", CreatedBy: "usr_ann"})
+	dispatcher.Observe(store.EventTaskCompleted, model.Task{ID: "1", Title: "Pay rent", Completed: true, CreatedBy: "usr_ann"})
+	dispatcher.Observe(store.EventTaskCompleted, model.Task{ID: "2", Title: "Book flights", Completed: true, CreatedBy: "usr_bob"})
+	queue.Shutdown(time.Second)
+
+	if len(posted) != 1 {
+		t.Fatalf("expected only the completion of the task of the owner to be posted, got %d", len(posted))
+	}
+	if n := <-posted; n.Event != EventCompleted || n.Task.ID != "1" || n.To != srv.URL+"/ann" {
+		t.Errorf("unexpected notification %+v", n)
+	}
+	want := []string{"1:created", "1:completed", "2:completed"}
+	if strings.Join(broadcast.sent, ",") != strings.Join(want, ",") {
+		t.Errorf("expected broadcast notifications %v, got %v", want, broadcast.sent)
+	}
+}
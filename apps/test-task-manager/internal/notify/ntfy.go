@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Ntfy publishes notifications to an ntfy topic (https://ntfy.sh), or to
+// any endpoint accepting a plain text POST: the body is the message and
+// the Title, Priority and Tags headers are set as ntfy reads them.
+type Ntfy struct {
+	topic  string // URL of the topic, e.g. https://ntfy.sh/team-tasks
+	token  string // Access token, sent as bearer token when set
+	events []Event
+	client *http.Client
+}
+
+// NewNtfy creates a notifier publishing events to the topic URL.
+func NewNtfy(topic, token string, events []Event, client *http.Client) (*Ntfy, error) {
+	u, err := url.Parse(topic)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("ntfy topic %q must be an http or https URL ending in the topic name", topic)
+	}
+	return &Ntfy{topic: topic, token: token, events: events, client: client}, nil
+}
+
+// Name implements Notifier.
+func (n *Ntfy) Name() string {
+	return "ntfy"
+}
+
+// Sends implements Selective.
+func (n *Ntfy) Sends(event Event) bool {
+	return slices.Contains(n.events, event)
+}
+
+// Notify implements Notifier.
+func (n *Ntfy) Notify(ctx context.Context, notification Notification) error {
+	title, body := describe(notification)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.topic, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Title", title)
+	req.Header.Set("Tags", string(notification.Event))
+	req.Header.Set("Priority", ntfyPriority(notification.Event))
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ntfy topic responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// ntfyPriority returns the ntfy priority (1 to 5) of event.
+func ntfyPriority(event Event) string {
+	switch event {
+	case EventEscalated:
+		return "5"
+	case EventOverdue:
+		return "4"
+	case EventCreated, EventCompleted, EventDigest:
+		return "2"
+	default:
+		return "3"
+	}
+}
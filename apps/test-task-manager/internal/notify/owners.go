@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// pushAddressPrefix starts the addresses of users for WebPush, so they do
+// not mix up with email addresses and webhook URLs.
+const pushAddressPrefix = "push:"
+
+// ownerEvents are the events sent to the owners of tasks. Owners created
+// the task themselves, so they are not told of its creation.
+var ownerEvents = []Event{EventDueSoon, EventOverdue, EventEscalated, EventCompleted}
+
+// Owner is how the user who created a task hears about it. Empty fields
+// are channels the user did not choose.
+type Owner struct {
+	Email   string // Email address
+	Webhook string // URL notifications are posted to as JSON
+	Push    bool   // To the browsers the user subscribed to web push
+}
+
+// Owners returns how the user with the ID hears about the tasks they
+// created, reporting false when not at all, such as for users who muted
+// notifications or only get the digest.
+type Owners func(userID string) (Owner, bool)
+
+// PushAddress returns the address WebPush delivers to the browsers the
+// user with the ID subscribed.
+func PushAddress(userID string) string {
+	return pushAddressPrefix + userID
+}
+
+// addresses returns the addresses of the channels of o, the user with the
+// ID.
+func (o Owner) addresses(userID string) []string {
+	var addresses []string
+	for _, address := range []string{o.Email, o.Webhook} {
+		if address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	if o.Push {
+		addresses = append(addresses, PushAddress(userID))
+	}
+	return addresses
+}
+
+// UserWebhook posts notifications to the webhook URLs users chose for the
+// tasks they created, with the Notification as JSON body. It only sends
+// notifications addressed to a URL.
+type UserWebhook struct {
+	client *http.Client
+}
+
+// NewUserWebhook creates a notifier posting to the webhook URLs of users.
+func NewUserWebhook(client *http.Client) *UserWebhook {
+	return &UserWebhook{client: client}
+}
+
+// Name implements Notifier.
+func (u *UserWebhook) Name() string {
+	return "user-webhook"
+}
+
+// Sends implements Selective: the events sent to owners.
+func (u *UserWebhook) Sends(event Event) bool {
+	return slices.Contains(ownerEvents, event)
+}
+
+// CanDeliverTo implements Addresser: http and https URLs.
+func (u *UserWebhook) CanDeliverTo(address string) bool {
+	parsed, err := url.Parse(address)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// addressedOnly implements addressedOnly.
+func (u *UserWebhook) addressedOnly() {}
+
+// Notify implements Notifier.
+func (u *UserWebhook) Notify(ctx context.Context, n Notification) error {
+	return postJSON(ctx, u.client, n.To, "webhook", n)
+}
+
+// addressedOnly is implemented by notifiers without recipients of their
+// own, which only send notifications addressed to a single recipient.
+type addressedOnly interface {
+	addressedOnly()
+}
+
+// pushUser returns the ID of the user a push address is of.
+func pushUser(address string) (string, bool) {
+	return strings.CutPrefix(address, pushAddressPrefix)
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/atomicfile"
+)
+
+// Reminders records which events were sent for which tasks, optionally
+// persisted to a JSON file, so a restart does not send them again.
+type Reminders struct {
+	path string // Empty when kept in memory only
+
+	mu    sync.Mutex
+	sent  map[string]map[Event]time.Time // When each event was sent, by task ID
+	dirty bool                           // Changed since the last save
+}
+
+// NewReminders opens the reminders persisted at path. The file is created
+// on the first save. With an empty path nothing is persisted.
+func NewReminders(path string) (*Reminders, error) {
+	r := &Reminders{path: path, sent: make(map[string]map[Event]time.Time)}
+	if path == "" {
+		return r, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &r.sent); err != nil {
+		return nil, fmt.Errorf("failed to parse reminders file %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Sent reports whether event was sent for the task.
+func (r *Reminders) Sent(taskID string, event Event) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.sent[taskID][event]
+	return ok
+}
+
+// Mark records that event was sent for the task at the given time.
+func (r *Reminders) Mark(taskID string, event Event, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sent[taskID] == nil {
+		r.sent[taskID] = make(map[Event]time.Time)
+	}
+	r.sent[taskID][event] = at
+	r.dirty = true
+}
+
+// Retain forgets the tasks for which keep returns false.
+func (r *Reminders) Retain(keep func(taskID string) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id := range r.sent {
+		if !keep(id) {
+			delete(r.sent, id)
+			r.dirty = true
+		}
+	}
+}
+
+// Save persists the reminders when they changed.
+func (r *Reminders) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.path == "" || !r.dirty {
+		return nil
+	}
+	content, err := json.MarshalIndent(r.sent, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.Write(r.path, content); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
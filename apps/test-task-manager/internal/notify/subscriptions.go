@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/atomicfile"
+)
+
+// Subscription is a browser's push subscription, in the format of
+// PushSubscription.toJSON().
+type Subscription struct {
+	Endpoint  string           `json:"endpoint"`
+	Keys      SubscriptionKeys `json:"keys"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UserID    string           `json:"userId,omitempty"` // User who subscribed the browser; empty when anonymous
+}
+
+// SubscriptionKeys are the base64url encoded keys messages to a
+// subscription are encrypted with.
+type SubscriptionKeys struct {
+	P256dh string `json:"p256dh"` // The browser's P-256 public key
+	Auth   string `json:"auth"`   // The 16 byte authentication secret
+}
+
+// Validate checks the endpoint and keys of s.
+func (s Subscription) Validate() error {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("endpoint must be an https URL")
+	}
+	if _, err := s.publicKey(); err != nil {
+		return errors.New("keys.p256dh must be a base64url encoded P-256 public key")
+	}
+	if auth, err := decodeBase64URL(s.Keys.Auth); err != nil || len(auth) != 16 {
+		return errors.New("keys.auth must be a base64url encoded 16 byte secret")
+	}
+	return nil
+}
+
+// Subscriptions keeps the push subscriptions, optionally persisted to a
+// JSON file.
+type Subscriptions struct {
+	path string // Empty when kept in memory only
+
+	mu   sync.RWMutex
+	subs []Subscription
+}
+
+// NewSubscriptions opens the subscriptions persisted at path. The file is
+// created on the first change. With an empty path nothing is persisted.
+func NewSubscriptions(path string) (*Subscriptions, error) {
+	s := &Subscriptions{path: path}
+	if path == "" {
+		return s, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &s.subs); err != nil {
+		return nil, fmt.Errorf("failed to parse push subscriptions file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Add stores sub, replacing an earlier subscription with the same endpoint.
+func (s *Subscriptions) Add(sub Subscription) error {
+	if err := sub.Validate(); err != nil {
+		return err
+	}
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := slices.DeleteFunc(slices.Clone(s.subs), func(old Subscription) bool { return old.Endpoint == sub.Endpoint })
+	subs = append(subs, sub)
+	return s.replace(subs)
+}
+
+// Remove deletes the subscription with endpoint. It reports whether there
+// was one.
+func (s *Subscriptions) Remove(endpoint string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := slices.DeleteFunc(slices.Clone(s.subs), func(old Subscription) bool { return old.Endpoint == endpoint })
+	if len(subs) == len(s.subs) {
+		return false, nil
+	}
+	return true, s.replace(subs)
+}
+
+// All returns every subscription.
+func (s *Subscriptions) All() []Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.subs)
+}
+
+// Len returns the number of subscriptions.
+func (s *Subscriptions) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.subs)
+}
+
+// replace persists subs and makes them the current subscriptions. Callers
+// must hold the write lock.
+func (s *Subscriptions) replace(subs []Subscription) error {
+	if s.path != "" {
+		content, err := json.MarshalIndent(subs, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := atomicfile.Write(s.path, content); err != nil {
+			return err
+		}
+	}
+
+	s.subs = subs
+	return nil
+}
+
+// decodeBase64URL decodes base64url, with or without padding, as browsers
+// and key generators disagree on it.
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// adaptiveCard is an Adaptive Card (https://adaptivecards.io), the message
+// format of Teams incoming webhooks.
+type adaptiveCard struct {
+	Schema  string `json:"$schema"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	Body    []any  `json:"body"`
+}
+
+type cardText struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Wrap   bool   `json:"wrap,omitempty"`
+}
+
+type cardFactSet struct {
+	Type  string     `json:"type"`
+	Facts []cardFact `json:"facts"`
+}
+
+type cardFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Teams posts notifications to a Microsoft Teams channel through an
+// incoming webhook, as an Adaptive Card with the priority and due date as
+// facts.
+type Teams struct {
+	webhook string // Holds the webhook signature, so it is never logged
+	events  []Event
+	client  *http.Client
+}
+
+// NewTeams creates a notifier posting events to the webhook URL.
+func NewTeams(webhook string, events []Event, client *http.Client) (*Teams, error) {
+	u, err := url.Parse(webhook)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("the Teams webhook must be an https URL")
+	}
+	return &Teams{webhook: webhook, events: events, client: client}, nil
+}
+
+// Name implements Notifier.
+func (t *Teams) Name() string {
+	return "teams"
+}
+
+// Sends implements Selective.
+func (t *Teams) Sends(event Event) bool {
+	return slices.Contains(t.events, event)
+}
+
+// Notify implements Notifier.
+func (t *Teams) Notify(ctx context.Context, n Notification) error {
+	title, body := describe(n)
+	facts := []cardFact{{Title: "Priority", Value: n.Task.Priority}}
+	if n.Task.DueDate != nil {
+		// Teams formats DATE and TIME in the locale and time zone of each reader.
+		due := n.Task.DueDate.UTC().Format(time.RFC3339)
+		facts = append(facts, cardFact{Title: "Due", Value: "{{DATE(" + due + ", SHORT)}} {{TIME(" + due + ")}}"})
+	}
+
+	card := adaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []any{
+			cardText{Type: "TextBlock", Text: title, Weight: "Bolder", Size: "Medium", Color: cardColor(n.Event)},
+			cardText{Type: "TextBlock", Text: body, Wrap: true},
+			cardFactSet{Type: "FactSet", Facts: facts},
+		},
+	}
+	return postJSON(ctx, t.client, t.webhook, "Teams webhook", map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	})
+}
+
+// cardColor returns the Adaptive Card color of the title for event.
+func cardColor(event Event) string {
+	switch event {
+	case EventOverdue, EventEscalated:
+		return "Attention"
+	case EventDueSoon:
+		return "Warning"
+	case EventCompleted:
+		return "Good"
+	default:
+		return "Default"
+	}
+}
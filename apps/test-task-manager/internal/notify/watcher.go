@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/cron"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/health"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"go.uber.org/zap"
+)
+
+// DueWatcher scans the open tasks with a due date on a schedule and queues
+// a notification when one gets due within the due soon window, and when it
+// becomes overdue. Each event is sent once per task, as recorded in its
+// Reminders. Overdue tasks are escalated by the rules set with Escalate.
+type DueWatcher struct {
+	tasks      func(ctx context.Context, q store.Query) ([]model.Task, error)
+	dispatcher *Dispatcher
+	dueSoon    time.Duration
+	reminders  *Reminders
+	logger     *zap.SugaredLogger
+	outcome    health.Outcome
+
+	rules       []EscalationRule
+	setPriority func(ctx context.Context, id, priority string) (model.Task, error)
+	escalateTo  string
+}
+
+// NewDueWatcher creates a watcher reading tasks with find.
+func NewDueWatcher(find func(ctx context.Context, q store.Query) ([]model.Task, error), dispatcher *Dispatcher, dueSoon time.Duration, reminders *Reminders, logger *zap.SugaredLogger) *DueWatcher {
+	return &DueWatcher{
+		tasks:      find,
+		dispatcher: dispatcher,
+		dueSoon:    dueSoon,
+		reminders:  reminders,
+		logger:     logger,
+	}
+}
+
+// Run scans right away, to catch up on the time the process was not
+// running, and then at every time of schedule until ctx is done.
+func (w *DueWatcher) Run(ctx context.Context, schedule cron.Schedule) {
+	w.scan(ctx, time.Now())
+	cron.Run(ctx, schedule, func(now time.Time) { w.scan(ctx, now) })
+}
+
+func (w *DueWatcher) scan(ctx context.Context, now time.Time) {
+	err := w.Scan(ctx, now)
+	w.outcome.Record(err)
+	if err != nil {
+		w.logger.Warnw("failed to scan for due tasks", "error", err)
+	}
+}
+
+// Check is a health.Check reporting the error of the last scheduled scan.
+func (w *DueWatcher) Check(ctx context.Context) error {
+	return w.outcome.Check(ctx)
+}
+
+// Scan queues the notifications due at now.
+func (w *DueWatcher) Scan(ctx context.Context, now time.Time) error {
+	open := false
+	until := now.Add(w.dueSoon)
+	tasks, err := w.tasks(ctx, store.Query{Completed: &open, DueBefore: &until})
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		seen[task.ID] = true
+
+		event := EventDueSoon
+		if !task.DueDate.After(now) {
+			event = EventOverdue
+			task = w.escalate(ctx, task, now)
+		}
+		if w.reminders.Sent(task.ID, event) {
+			continue
+		}
+		if !w.dispatcher.Enqueue(Notification{Event: event, Task: task, At: now}) {
+			continue // Retried on the next scan
+		}
+		w.reminders.Mark(task.ID, event, now)
+	}
+
+	// Forget completed and deleted tasks, so they are notified again should
+	// they become open and due again.
+	w.reminders.Retain(func(id string) bool { return seen[id] })
+	return w.reminders.Save()
+}
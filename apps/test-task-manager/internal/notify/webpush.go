@@ -0,0 +1,301 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// pushTTL is how long push services keep undelivered messages.
+	pushTTL = 24 * time.Hour
+	// vapidExpiry is the lifetime of the VAPID tokens; push services accept
+	// at most 24 hours.
+	vapidExpiry = 12 * time.Hour
+	// pushRecordSize is the record size of the encrypted content. Messages
+	// are smaller than a single record.
+	pushRecordSize = 4096
+)
+
+// VAPIDKeys identify this server to push services (RFC 8292).
+type VAPIDKeys struct {
+	private *ecdsa.PrivateKey
+}
+
+// GenerateVAPIDKeys creates a new key pair.
+func GenerateVAPIDKeys() (VAPIDKeys, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return VAPIDKeys{}, err
+	}
+	return VAPIDKeys{private: key}, nil
+}
+
+// ParseVAPIDKeys parses a base64url encoded P-256 private key and checks it
+// belongs to the public key, in the uncompressed form browsers expect.
+func ParseVAPIDKeys(publicKey, privateKey string) (VAPIDKeys, error) {
+	raw, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return VAPIDKeys{}, errors.New("VAPID private key must be base64url encoded")
+	}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	if err != nil {
+		return VAPIDKeys{}, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	keys := VAPIDKeys{private: key}
+	if public, err := decodeBase64URL(publicKey); err != nil || !bytes.Equal(public, keys.publicBytes()) {
+		return VAPIDKeys{}, errors.New("VAPID public key does not belong to the private key")
+	}
+	return keys, nil
+}
+
+// PublicKey returns the base64url encoded public key, the
+// applicationServerKey browsers subscribe with.
+func (k VAPIDKeys) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.publicBytes())
+}
+
+// PrivateKey returns the base64url encoded private key.
+func (k VAPIDKeys) PrivateKey() string {
+	raw, _ := k.private.Bytes()
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func (k VAPIDKeys) publicBytes() []byte {
+	raw, _ := k.private.PublicKey.Bytes()
+	return raw
+}
+
+// authorization returns the Authorization header value for requests to
+// endpoint: a signed JWT naming the push service as its audience.
+func (k VAPIDKeys) authorization(endpoint, subject string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidExpiry).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return "vapid t=" + token + ", k=" + k.PublicKey(), nil
+}
+
+// pushMessage is the JSON payload the service worker shows as notification.
+type pushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Tag   string `json:"tag"` // Replaces earlier notifications about the same task
+	URL   string `json:"url"` // Opened when the notification is clicked
+}
+
+// WebPush sends notifications to the browsers subscribed in Subscriptions.
+// Subscriptions the push service reports as gone are removed.
+type WebPush struct {
+	keys    VAPIDKeys
+	subject string // Contact for push service operators, a mailto: or https: URL
+	subs    *Subscriptions
+	client  *http.Client
+	logger  *zap.SugaredLogger
+}
+
+// NewWebPush creates a web push notifier.
+func NewWebPush(keys VAPIDKeys, subject string, subs *Subscriptions, client *http.Client, logger *zap.SugaredLogger) *WebPush {
+	return &WebPush{keys: keys, subject: subject, subs: subs, client: client, logger: logger}
+}
+
+// Name implements Notifier.
+func (p *WebPush) Name() string {
+	return "webpush"
+}
+
+// Sends implements Selective: browsers are told about due and overdue
+// tasks only.
+func (p *WebPush) Sends(event Event) bool {
+	return event == EventDueSoon || event == EventOverdue || event == EventEscalated
+}
+
+// CanDeliverTo implements Addresser: addresses made with PushAddress.
+func (p *WebPush) CanDeliverTo(address string) bool {
+	_, ok := pushUser(address)
+	return ok
+}
+
+// Notify implements Notifier. Notifications addressed to a user, with
+// PushAddress, go to the browsers the user subscribed; the others go to
+// those subscribed without a user, as users choose themselves how they
+// hear about tasks. It fails when any subscription could not be reached.
+func (p *WebPush) Notify(ctx context.Context, n Notification) error {
+	msg := pushMessage{Tag: "task-" + n.Task.ID, URL: "/"}
+	msg.Title, msg.Body = describe(n)
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	urgency := "normal"
+	if n.Event == EventOverdue || n.Event == EventEscalated {
+		urgency = "high"
+	}
+
+	user, _ := pushUser(n.To)
+	var errs []error
+	for _, sub := range p.subs.All() {
+		if sub.UserID != user {
+			continue
+		}
+		if err := p.send(ctx, sub, payload, urgency, n.At); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send pushes payload to sub, removing sub when it has expired.
+func (p *WebPush) send(ctx context.Context, sub Subscription, payload []byte, urgency string, now time.Time) error {
+	body, err := encryptPush(sub, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := p.keys.authorization(sub.Endpoint, p.subject, now)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(pushTTL.Seconds())))
+	req.Header.Set("Urgency", urgency)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		// The browser unsubscribed or the subscription expired.
+		if _, err := p.subs.Remove(sub.Endpoint); err != nil {
+			return err
+		}
+		p.logger.Infow("removed stale push subscription", "endpoint", sub.Endpoint, "status", resp.StatusCode)
+		return nil
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("push service %s responded with %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// encryptPush encrypts payload for sub with the aes128gcm content encoding
+// of Web Push (RFC 8291), as a single record.
+func encryptPush(sub Subscription, payload []byte) ([]byte, error) {
+	uaPublic, err := sub.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	authSecret, err := decodeBase64URL(sub.Keys.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	keyInfo := "WebPush: info\x00" + string(uaPublic.Bytes()) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, secret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt, record size, key ID length and the key ID, which is the
+	// sender's public key. 0x02 marks the last (and only) record.
+	body := make([]byte, 0, 16+4+1+len(asPublic)+len(payload)+1+gcm.Overhead())
+	body = append(body, salt...)
+	body = binary.BigEndian.AppendUint32(body, pushRecordSize)
+	body = append(body, byte(len(asPublic)))
+	body = append(body, asPublic...)
+	return gcm.Seal(body, nonce, append(slices.Clip(payload), 0x02), nil), nil
+}
+
+// publicKey returns the browser's public key of s.
+func (s Subscription) publicKey() (*ecdh.PublicKey, error) {
+	raw, err := decodeBase64URL(s.Keys.P256dh)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.P256().NewPublicKey(raw)
+}
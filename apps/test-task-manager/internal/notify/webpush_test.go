@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"go.uber.org/zap"
+)
+
+// browser is the receiving end of a push subscription.
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T) browser {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return browser{key: key, auth: auth}
+}
+
+func (b browser) subscription(endpoint string) Subscription {
+	return Subscription{Endpoint: endpoint, Keys: SubscriptionKeys{
+		P256dh: base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+		Auth:   base64.RawURLEncoding.EncodeToString(b.auth),
+	}}
+}
+
+// decrypt reverses encryptPush the way browsers do.
+func (b browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	salt, keyID := body[:16], body[21:21+int(body[20])]
+	asPublic, err := ecdh.P256().NewPublicKey(keyID)
+	if err != nil {
+		t.Fatalf("invalid sender key: %v", err)
+	}
+	secret, _ := b.key.ECDH(asPublic)
+
+	ikm, _ := hkdf.Key(sha256.New, secret, b.auth, "WebPush: info\x00"+string(b.key.PublicKey().Bytes())+string(keyID), 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+
+	plain, err := gcm.Open(nil, nonce, body[21+len(keyID):], nil)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if plain[len(plain)-1] != 0x02 {
+		t.Fatalf("expected last record delimiter, got %x", plain[len(plain)-1])
+	}
+	return plain[:len(plain)-1]
+}
+
+func TestWebPush_Notify(t *testing.T) {
+	b := newBrowser(t)
+	var body []byte
+	var header http.Header
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	keys, _ := GenerateVAPIDKeys()
+	subs, _ := NewSubscriptions("")
+	if err := subs.Add(b.subscription(srv.URL + "/push/1")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	push := NewWebPush(keys, "mailto:ops@example.com", subs, srv.Client(), zap.NewNop().Sugar())
+
+	due := time.Now()
+	err := push.Notify(context.Background(), Notification{Event: EventOverdue, Task: model.Task{ID: "7", Title: "Renew domain", Priority: "🔥", DueDate: &due}, At: due})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var msg pushMessage
+	if err := json.Unmarshal(b.decrypt(t, body), &msg); err != nil {
+		t.Fatalf("expected a JSON message, got %v", err)
+	}
+	if msg.Title != "Task overdue" || !strings.Contains(msg.Body, "Renew domain") || msg.Tag != "task-7" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	if !strings.HasPrefix(header.Get("Authorization"), "vapid t=") || !strings.HasSuffix(header.Get("Authorization"), ", k="+keys.PublicKey()) {
+		t.Errorf("unexpected Authorization header %q", header.Get("Authorization"))
+	}
+	if header.Get("Content-Encoding") != "aes128gcm" || header.Get("Urgency") != "high" {
+		t.Errorf("unexpected headers %v", header)
+	}
+}
+
+func TestWebPush_RemovesStaleSubscriptions(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	keys, _ := GenerateVAPIDKeys()
+	subs, _ := NewSubscriptions("")
+	subs.Add(newBrowser(t).subscription(srv.URL + "/gone"))
+	subs.Add(newBrowser(t).subscription(srv.URL + "/active"))
+	push := NewWebPush(keys, "mailto:ops@example.com", subs, srv.Client(), zap.NewNop().Sugar())
+
+	due := time.Now()
+	if err := push.Notify(context.Background(), Notification{Event: EventDueSoon, Task: model.Task{ID: "1", Title: "Task", DueDate: &due}, At: due}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	remaining := subs.All()
+	if len(remaining) != 1 || remaining[0].Endpoint != srv.URL+"/active" {
+		t.Errorf("expected only the active subscription to remain, got %v", remaining)
+	}
+}
+
+func TestParseVAPIDKeys(t *testing.T) {
+	keys, _ := GenerateVAPIDKeys()
+	parsed, err := ParseVAPIDKeys(keys.PublicKey(), keys.PrivateKey())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if parsed.PublicKey() != keys.PublicKey() {
+		t.Errorf("expected public key %s, got %s", keys.PublicKey(), parsed.PublicKey())
+	}
+
+	other, _ := GenerateVAPIDKeys()
+	if _, err := ParseVAPIDKeys(other.PublicKey(), keys.PrivateKey()); err == nil {
+		t.Error("expected an error for a public key of another pair")
+	}
+}
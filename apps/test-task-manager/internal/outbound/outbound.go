@@ -0,0 +1,102 @@
+// Package outbound builds the HTTP clients used for calls to external systems.
+// Every client propagates the trace context and request ID of the calling
+// request and enforces a per-call timeout.
+package outbound
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/http/middleware"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/netguard"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/tracing"
+)
+
+// Factory creates outbound HTTP clients sharing one connection pool.
+type Factory struct {
+	defaultTimeout time.Duration
+	base           http.RoundTripper
+	public         http.RoundTripper // Only dials publicly routable addresses
+	requests       *metrics.CounterVec
+	duration       *metrics.HistogramVec
+}
+
+// NewFactory creates a Factory whose clients time out after defaultTimeout
+// unless a client-specific timeout is given.
+func NewFactory(defaultTimeout time.Duration, reg *metrics.Registry) *Factory {
+	// A proxy would be dialed instead of the host, so the public transport
+	// connects directly.
+	public := http.DefaultTransport.(*http.Transport).Clone()
+	public.Proxy = nil
+	public.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: netguard.Control}).DialContext
+
+	return &Factory{
+		defaultTimeout: defaultTimeout,
+		base:           http.DefaultTransport.(*http.Transport).Clone(),
+		public:         public,
+		requests:       reg.CounterVec("outbound_requests_total", "Total number of outbound HTTP requests.", "client", "status"),
+		duration:       reg.HistogramVec("outbound_request_duration_seconds", "Duration of outbound HTTP requests.", nil, "client"),
+	}
+}
+
+// Client returns an HTTP client for the named integration.
+// A timeout of zero uses the factory default.
+func (f *Factory) Client(name string, timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = f.defaultTimeout
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &transport{
+			name:    name,
+			base:    f.base,
+			factory: f,
+		},
+	}
+}
+
+// PublicClient returns an HTTP client like Client that refuses to connect
+// to loopback, private and link-local addresses, for URLs chosen by users.
+func (f *Factory) PublicClient(name string, timeout time.Duration) *http.Client {
+	client := f.Client(name, timeout)
+	client.Transport.(*transport).base = f.public
+	return client
+}
+
+// transport adds propagation headers and records metrics.
+type transport struct {
+	name    string
+	base    http.RoundTripper
+	factory *Factory
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+
+	if sc, ok := tracing.FromContext(req.Context()); ok {
+		req.Header.Set(tracing.Header, sc.Child().String())
+	} else {
+		req.Header.Set(tracing.Header, tracing.New().String())
+	}
+	if id := middleware.RequestIDFromContext(req.Context()); id != "" && req.Header.Get(middleware.RequestIDHeader) == "" {
+		req.Header.Set(middleware.RequestIDHeader, id)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	t.factory.duration.With(t.name).Observe(time.Since(start).Seconds())
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	t.factory.requests.With(t.name, status).Inc()
+
+	return resp, err
+}
@@ -0,0 +1,72 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/netguard"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/tracing"
+)
+
+func TestClient_PropagatesTraceContext(t *testing.T) {
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get(tracing.Header)
+	}))
+	defer srv.Close()
+
+	client := NewFactory(time.Second, metrics.NewRegistry()).Client("test", 0)
+
+	parent := tracing.New()
+	ctx := tracing.NewContext(context.Background(), parent)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	sc, ok := tracing.Parse(traceparent)
+	if !ok {
+		t.Fatalf("expected valid traceparent, got %q", traceparent)
+	}
+	if sc.TraceID != parent.TraceID {
+		t.Errorf("expected trace ID %s, got %s", parent.TraceIDString(), sc.TraceIDString())
+	}
+	if sc.SpanID == parent.SpanID {
+		t.Error("expected a new span ID for the outbound call")
+	}
+}
+
+func TestClient_EnforcesTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	client := NewFactory(time.Second, metrics.NewRegistry()).Client("test", 20*time.Millisecond)
+
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Error("expected timeout error")
+	}
+}
+
+func TestPublicClient_RefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	factory := NewFactory(time.Second, metrics.NewRegistry())
+	if _, err := factory.PublicClient("test", 0).Get(srv.URL); !errors.Is(err, netguard.ErrForbiddenAddress) {
+		t.Errorf("expected the loopback server to be refused, got %v", err)
+	}
+	resp, err := factory.Client("test", 0).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected other clients to reach it, got %v", err)
+	}
+	resp.Body.Close()
+}
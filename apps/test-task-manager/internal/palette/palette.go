@@ -0,0 +1,164 @@
+// Package palette defines the colors tasks can have: each has a semantic
+// name, such as red, a variant for light and one for dark backgrounds, and
+// is shown with the text color, black or white, contrasting most with it.
+// That text always reaches a contrast of 4.58:1, above the 4.5:1 of WCAG 2
+// level AA for normal text, so every color of a palette is readable.
+package palette
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Text colors shown on the colors of a palette.
+const (
+	TextBlack = "#000000"
+	TextWhite = "#ffffff"
+)
+
+// Color is a color of a palette. Tasks are stored with its Light code.
+type Color struct {
+	Name      string `json:"name"`
+	Light     string `json:"light"`     // Hex code on light backgrounds
+	Dark      string `json:"dark"`      // Hex code on dark backgrounds
+	LightText string `json:"lightText"` // Text color on Light
+	DarkText  string `json:"darkText"`  // Text color on Dark
+}
+
+// Palette is an ordered set of colors with unique names and codes.
+type Palette struct {
+	colors []Color
+}
+
+// defaultColors are the colors of Default: Bootstrap theme colors, with
+// lighter tints of them for dark backgrounds.
+var defaultColors = []string{
+	"red=#dc3545/#ea868f",
+	"blue=#0d6efd/#6ea8fe",
+	"yellow=#ffc107/#ffda6a",
+	"green=#28a745/#75b798",
+	"purple=#6f42c1/#a98eda",
+	"orange=#fd7e14/#feb272",
+	"grey=#6c757d/#adb5bd",
+}
+
+// Default returns the palette of instances that configure none.
+func Default() *Palette {
+	p, err := Parse(defaultColors)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+var (
+	namePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	hexPattern  = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+)
+
+// Parse returns the palette of the colors in specs, each name=#light/#dark,
+// or name=#light for a color without a dark variant. Names are lowercase
+// words.
+func Parse(specs []string) (*Palette, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("palette needs at least one color")
+	}
+	p := &Palette{}
+	for _, spec := range specs {
+		name, codes, ok := strings.Cut(strings.TrimSpace(spec), "=")
+		if !ok || !namePattern.MatchString(name) {
+			return nil, fmt.Errorf("color %q must be name=#light/#dark with a lowercase name", spec)
+		}
+		light, dark, ok := strings.Cut(strings.ToLower(codes), "/")
+		if !ok {
+			dark = light
+		}
+		if !hexPattern.MatchString(light) || !hexPattern.MatchString(dark) {
+			return nil, fmt.Errorf("color %q must have #rrggbb hex codes", spec)
+		}
+		if _, found := p.Lookup(name); found {
+			return nil, fmt.Errorf("color %q is defined twice", name)
+		}
+		if _, found := p.Lookup(light); found {
+			return nil, fmt.Errorf("color %s of %q is in the palette already", light, name)
+		}
+		p.colors = append(p.colors, Color{Name: name, Light: light, Dark: dark, LightText: TextColor(light), DarkText: TextColor(dark)})
+	}
+	return p, nil
+}
+
+// Colors returns the colors in order.
+func (p *Palette) Colors() []Color {
+	return append([]Color(nil), p.colors...)
+}
+
+// Codes returns the Light codes of the colors, which tasks are stored with.
+func (p *Palette) Codes() []string {
+	codes := make([]string, len(p.colors))
+	for i, c := range p.colors {
+		codes[i] = c.Light
+	}
+	return codes
+}
+
+// Lookup returns the color with ref as its name or as the code of either
+// variant, in any case.
+func (p *Palette) Lookup(ref string) (Color, bool) {
+	ref = strings.ToLower(ref)
+	for _, c := range p.colors {
+		if c.Name == ref || c.Light == ref || c.Dark == ref {
+			return c, true
+		}
+	}
+	return Color{}, false
+}
+
+// Resolve returns the color of a stored hex code: the color of the palette
+// with it, or, for codes of colors since dropped from the palette, one
+// without a name and with code as both variants.
+func (p *Palette) Resolve(code string) Color {
+	if c, ok := p.Lookup(code); ok {
+		return c
+	}
+	code = strings.ToLower(code)
+	if !hexPattern.MatchString(code) {
+		return Color{}
+	}
+	return Color{Light: code, Dark: code, LightText: TextColor(code), DarkText: TextColor(code)}
+}
+
+// TextColor returns TextBlack or TextWhite, whichever contrasts most with
+// the background hex code.
+func TextColor(background string) string {
+	if Contrast(background, TextBlack) >= Contrast(background, TextWhite) {
+		return TextBlack
+	}
+	return TextWhite
+}
+
+// Contrast returns the WCAG 2 contrast ratio of two hex codes, from 1 for
+// equal luminance to 21 for black and white.
+func Contrast(a, b string) float64 {
+	la, lb := luminance(a), luminance(b)
+	return (math.Max(la, lb) + 0.05) / (math.Min(la, lb) + 0.05)
+}
+
+// luminance returns the WCAG 2 relative luminance of a #rrggbb code, or 0
+// for an invalid one.
+func luminance(hex string) float64 {
+	rgb, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil || len(hex) != 7 {
+		return 0
+	}
+	linear := func(v uint64) float64 {
+		c := float64(v) / 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(rgb>>16&0xff) + 0.7152*linear(rgb>>8&0xff) + 0.0722*linear(rgb&0xff)
+}
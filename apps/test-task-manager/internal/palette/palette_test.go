@@ -0,0 +1,72 @@
+package palette
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestContrast(t *testing.T) {
+	if got := Contrast(TextBlack, TextWhite); math.Abs(got-21) > 0.01 {
+		t.Errorf("expected 21:1 for black on white, got %.2f", got)
+	}
+	if got := Contrast("#777777", "#777777"); got != 1 {
+		t.Errorf("expected 1:1 for equal colors, got %.2f", got)
+	}
+	if got := TextColor("#ffc107"); got != TextBlack {
+		t.Errorf("expected black text on yellow, got %s", got)
+	}
+	if got := TextColor("#6f42c1"); got != TextWhite {
+		t.Errorf("expected white text on purple, got %s", got)
+	}
+	// The text on any color reaches level AA, even on the grey where black
+	// and white contrast equally.
+	for _, background := range []string{"#000000", "#777777", "#767676", "#0d6efd", "#ffffff"} {
+		if got := Contrast(background, TextColor(background)); got < 4.5 {
+			t.Errorf("expected text contrasting at least 4.5:1 on %s, got %.2f", background, got)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	p := Default()
+	if len(p.Codes()) != 7 || p.Codes()[0] != "#dc3545" {
+		t.Fatalf("unexpected default codes %v", p.Codes())
+	}
+	for _, ref := range []string{"red", "RED", "#DC3545", "#ea868f"} {
+		if c, ok := p.Lookup(ref); !ok || c.Name != "red" {
+			t.Errorf("expected %q to be red, got %+v", ref, c)
+		}
+	}
+	if _, ok := p.Lookup("teal"); ok {
+		t.Error("expected teal not to be in the default palette")
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]string{"ink=#1F2937/#e5e7eb", "paper=#f9fafb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ink, _ := p.Lookup("ink")
+	if ink.Light != "#1f2937" || ink.LightText != TextWhite || ink.DarkText != TextBlack {
+		t.Errorf("unexpected color %+v", ink)
+	}
+	if paper, _ := p.Lookup("paper"); paper.Dark != paper.Light {
+		t.Errorf("expected the light code as dark variant, got %+v", paper)
+	}
+
+	for spec, want := range map[string]string{
+		"":                   "name=#light/#dark",
+		"Red=#dc3545":        "lowercase name",
+		"red=dc3545":         "#rrggbb",
+		"red=#dc3545/#12345": "#rrggbb",
+	} {
+		if _, err := Parse([]string{spec}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q): expected an error about %q, got %v", spec, want, err)
+		}
+	}
+	if _, err := Parse([]string{"red=#dc3545", "red=#0d6efd"}); err == nil {
+		t.Error("expected names defined twice to be rejected")
+	}
+}
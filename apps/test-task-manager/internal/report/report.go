@@ -0,0 +1,184 @@
+// Package report summarizes recent task activity for standups and status
+// emails.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+)
+
+// Supported formats.
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{FormatText, FormatMarkdown, FormatJSON}
+
+// priorities orders the open-by-priority counts.
+var priorities = []string{
+	service.PriorityUrgentImportant,
+	service.PriorityImportant,
+	service.PriorityUrgent,
+	service.PriorityLow,
+	service.PriorityDefault,
+}
+
+// Report is the summary of a period ending at GeneratedAt.
+type Report struct {
+	GeneratedAt     time.Time       `json:"generatedAt"`
+	From            time.Time       `json:"from"`
+	CompletedPerDay []DayCount      `json:"completedPerDay"`
+	CompletedTotal  int             `json:"completedTotal"`
+	OpenByPriority  []PriorityCount `json:"openByPriority"`
+	OpenTotal       int             `json:"openTotal"`
+	Overdue         []model.Task    `json:"overdue"`
+}
+
+// DayCount is the number of tasks completed on a day.
+type DayCount struct {
+	Date  string `json:"date"` // YYYY-MM-DD in the report's time zone
+	Count int    `json:"count"`
+}
+
+// PriorityCount is the number of open tasks with a priority.
+type PriorityCount struct {
+	Priority string `json:"priority"`
+	Count    int    `json:"count"`
+}
+
+// Build summarizes the days up to and including the day of now, in the
+// time zone of now. Tasks only record their latest completion, so a task
+// completed, reopened and completed again counts once.
+func Build(tasks []model.Task, now time.Time, days int) Report {
+	days = max(days, 1)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	from := today.AddDate(0, 0, -(days - 1))
+
+	r := Report{GeneratedAt: now, From: from, Overdue: make([]model.Task, 0)}
+
+	perDay := make(map[string]int, days)
+	open := make(map[string]int, len(priorities))
+	for _, task := range tasks {
+		if task.Completed {
+			if task.CompletedAt != nil && !task.CompletedAt.Before(from) {
+				perDay[task.CompletedAt.In(now.Location()).Format(time.DateOnly)]++
+				r.CompletedTotal++
+			}
+			continue
+		}
+
+		open[task.Priority]++
+		r.OpenTotal++
+		if task.DueDate != nil && task.DueDate.Before(now) {
+			r.Overdue = append(r.Overdue, task)
+		}
+	}
+
+	for d := from; !d.After(today); d = d.AddDate(0, 0, 1) {
+		date := d.Format(time.DateOnly)
+		r.CompletedPerDay = append(r.CompletedPerDay, DayCount{Date: date, Count: perDay[date]})
+	}
+	for _, p := range priorities {
+		r.OpenByPriority = append(r.OpenByPriority, PriorityCount{Priority: p, Count: open[p]})
+	}
+	sort.SliceStable(r.Overdue, func(i, j int) bool {
+		return r.Overdue[i].DueDate.Before(*r.Overdue[j].DueDate)
+	})
+	return r
+}
+
+// Write renders r to w in format.
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case FormatText:
+		_, err := io.WriteString(w, r.text())
+		return err
+	case FormatMarkdown:
+		_, err := io.WriteString(w, r.markdown())
+		return err
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+func (r Report) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Task report %s to %s\n\n", r.From.Format(time.DateOnly), r.GeneratedAt.Format(time.DateOnly))
+
+	fmt.Fprintf(&b, "Completed (%d)\n", r.CompletedTotal)
+	for _, day := range r.CompletedPerDay {
+		line := fmt.Sprintf("  %s  %-3s %3d  %s", day.Date, weekday(day.Date), day.Count, strings.Repeat("#", day.Count))
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	fmt.Fprintf(&b, "\nOpen (%d)\n", r.OpenTotal)
+	for _, p := range r.OpenByPriority {
+		fmt.Fprintf(&b, "  %s  %3d\n", p.Priority, p.Count)
+	}
+
+	fmt.Fprintf(&b, "\nOverdue (%d)\n", len(r.Overdue))
+	for _, task := range r.Overdue {
+		fmt.Fprintf(&b, "  %s %s (due %s, %s late)\n", task.Priority, task.Title, task.DueDate.Format(time.DateOnly), lateness(r.GeneratedAt.Sub(*task.DueDate)))
+	}
+	return b.String()
+}
+
+func (r Report) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Task report %s to %s\n\n", r.From.Format(time.DateOnly), r.GeneratedAt.Format(time.DateOnly))
+
+	fmt.Fprintf(&b, "## Completed (%d)\n\n| Day | Completed |\n| --- | ---: |\n", r.CompletedTotal)
+	for _, day := range r.CompletedPerDay {
+		fmt.Fprintf(&b, "| %s %s | %d |\n", weekday(day.Date), day.Date, day.Count)
+	}
+
+	fmt.Fprintf(&b, "\n## Open (%d)\n\n| Priority | Open |\n| --- | ---: |\n", r.OpenTotal)
+	for _, p := range r.OpenByPriority {
+		fmt.Fprintf(&b, "| %s | %d |\n", p.Priority, p.Count)
+	}
+
+	fmt.Fprintf(&b, "\n## Overdue (%d)\n\n", len(r.Overdue))
+	if len(r.Overdue) == 0 {
+		b.WriteString("Nothing is overdue.\n")
+	}
+	for _, task := range r.Overdue {
+		fmt.Fprintf(&b, "- %s %s (due %s, %s late)\n", task.Priority, escapeMarkdown(task.Title), task.DueDate.Format(time.DateOnly), lateness(r.GeneratedAt.Sub(*task.DueDate)))
+	}
+	return b.String()
+}
+
+func weekday(date string) string {
+	d, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return ""
+	}
+	return d.Weekday().String()[:3]
+}
+
+// lateness formats how long ago a due date passed, in days or hours.
+func lateness(d time.Duration) string {
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+	return fmt.Sprintf("%dh", int(d/time.Hour))
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "|", `\|`)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
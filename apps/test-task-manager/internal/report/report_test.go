@@ -0,0 +1,47 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2026, 3, 13, 15, 0, 0, 0, time.UTC) // A Friday
+	at := func(days, hours int) *time.Time {
+		t := now.AddDate(0, 0, days).Add(time.Duration(hours) * time.Hour)
+		return &t
+	}
+
+	r := Build([]model.Task{
+		{Title: "done today", Completed: true, CompletedAt: at(0, -1), Priority: "🔥"},
+		{Title: "done monday", Completed: true, CompletedAt: at(-4, 0), Priority: "⭐"},
+		{Title: "done last week", Completed: true, CompletedAt: at(-8, 0), Priority: "⭐"},
+		{Title: "late", Priority: "🔥", DueDate: at(-2, 0)},
+		{Title: "later", Priority: "💡", DueDate: at(-3, 0)},
+		{Title: "upcoming", Priority: "🔥", DueDate: at(1, 0)},
+	}, now, 7)
+
+	if r.CompletedTotal != 2 || len(r.CompletedPerDay) != 7 {
+		t.Fatalf("expected 2 completions over 7 days, got %d over %d", r.CompletedTotal, len(r.CompletedPerDay))
+	}
+	if r.CompletedPerDay[0].Date != "2026-03-07" || r.CompletedPerDay[6].Count != 1 || r.CompletedPerDay[2].Count != 1 {
+		t.Errorf("unexpected completions per day: %+v", r.CompletedPerDay)
+	}
+	if r.OpenTotal != 3 || r.OpenByPriority[0] != (PriorityCount{Priority: "🔥", Count: 2}) {
+		t.Errorf("unexpected open counts: %d, %+v", r.OpenTotal, r.OpenByPriority)
+	}
+	if len(r.Overdue) != 2 || r.Overdue[0].Title != "later" {
+		t.Errorf("expected overdue tasks oldest first, got %+v", r.Overdue)
+	}
+
+	var b strings.Builder
+	if err := r.Write(&b, FormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "| Mon 2026-03-09 | 1 |") || !strings.Contains(b.String(), "- 💡 later (due 2026-03-10, 3d late)") {
+		t.Errorf("unexpected markdown:\n%s", b.String())
+	}
+}
@@ -0,0 +1,299 @@
+// Package restart implements zero-downtime restarts by re-executing the
+// binary and handing it the open listening sockets. The new process starts
+// serving on the inherited sockets and signals readiness; only then does the
+// old process shut down gracefully, so no connection is refused and
+// in-flight requests complete.
+package restart
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// listenFDsEnv lists the "network:address" keys of the inherited listeners,
+	// in the order of their file descriptors starting at 3.
+	listenFDsEnv = "TTM_LISTEN_FDS"
+	// readyFDEnv holds the file descriptor the new process writes to when ready.
+	readyFDEnv = "TTM_READY_FD"
+)
+
+// ReadyTimeout bounds how long the old process waits for the new one.
+const ReadyTimeout = 30 * time.Second
+
+// ErrInProgress is returned when a restart is requested while one is running.
+var ErrInProgress = errors.New("restart already in progress")
+
+// ErrRefused is returned when a restart is requested after Refuse.
+var ErrRefused = errors.New("restart refused")
+
+// fileListener is implemented by the TCP and unix listeners of package net.
+type fileListener interface {
+	net.Listener
+	File() (*os.File, error)
+}
+
+// Upgrader hands listeners over to a re-executed copy of the process.
+type Upgrader struct {
+	logger *zap.SugaredLogger
+
+	mu        sync.Mutex
+	inherited map[string]*os.File
+	listeners map[string]fileListener
+	ready     *os.File
+	upgrading bool
+	refusal   string // Why restarts are refused; empty when they are not
+}
+
+// New creates an Upgrader, picking up the listeners and readiness pipe
+// passed by a parent process, if any.
+func New(logger *zap.SugaredLogger) *Upgrader {
+	u := &Upgrader{
+		logger:    logger,
+		inherited: make(map[string]*os.File),
+		listeners: make(map[string]fileListener),
+	}
+
+	if keys := os.Getenv(listenFDsEnv); keys != "" {
+		for i, key := range strings.Split(keys, ",") {
+			u.inherited[key] = os.NewFile(uintptr(3+i), key)
+		}
+	}
+	if fd, err := strconv.Atoi(os.Getenv(readyFDEnv)); err == nil {
+		u.ready = os.NewFile(uintptr(fd), "ready")
+	}
+	os.Unsetenv(listenFDsEnv)
+	os.Unsetenv(readyFDEnv)
+
+	return u
+}
+
+// Listen returns the listener inherited from the parent process for the
+// given network and address, or opens a new one. A stale unix socket file
+// left behind by a crashed process is removed before listening.
+func (u *Upgrader) Listen(network, addr string) (net.Listener, error) {
+	key := network + ":" + addr
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var ln net.Listener
+	var err error
+	if f, ok := u.inherited[key]; ok {
+		delete(u.inherited, key)
+		ln, err = net.FileListener(f)
+		f.Close()
+		if err == nil {
+			u.logger.Infow("inherited listener from previous process", "network", network, "addr", addr)
+		}
+	} else {
+		if network == "unix" {
+			removeStaleSocket(addr, u.logger)
+		}
+		ln, err = net.Listen(network, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if fl, ok := ln.(fileListener); ok {
+		u.listeners[key] = fl
+	}
+	return ln, nil
+}
+
+// Ready tells the parent process, if any, that this process is serving
+// and the parent can shut down. Listeners that were not claimed are closed.
+func (u *Upgrader) Ready() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for key, f := range u.inherited {
+		u.logger.Warnw("closing unused inherited listener", "listener", key)
+		f.Close()
+		delete(u.inherited, key)
+	}
+
+	if u.ready == nil {
+		return
+	}
+	if _, err := u.ready.Write([]byte{1}); err != nil {
+		u.logger.Warnw("failed to signal readiness to parent process", "error", err)
+	}
+	u.ready.Close()
+	u.ready = nil
+}
+
+// Refuse makes Upgrade refuse to restart for reason, such as state that
+// only this process holds and a new one would start without.
+func (u *Upgrader) Refuse(reason string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.refusal = reason
+}
+
+// Upgrade starts a new copy of the binary with the same arguments and
+// environment, handing it every listener, and waits until it is ready.
+// On success the caller must shut down; on failure it keeps serving.
+func (u *Upgrader) Upgrade() error {
+	u.mu.Lock()
+	if u.refusal != "" {
+		u.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrRefused, u.refusal)
+	}
+	if u.upgrading {
+		u.mu.Unlock()
+		return ErrInProgress
+	}
+	u.upgrading = true
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+	}()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	u.mu.Lock()
+	keys := make([]string, 0, len(u.listeners))
+	files := make([]*os.File, 0, len(u.listeners)+1)
+	for key, ln := range u.listeners {
+		f, err := ln.File()
+		if err != nil {
+			u.mu.Unlock()
+			closeAll(files)
+			return fmt.Errorf("failed to duplicate listener %s: %w", key, err)
+		}
+		keys = append(keys, key)
+		files = append(files, f)
+	}
+	u.mu.Unlock()
+	defer closeAll(files)
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create readiness pipe: %w", err)
+	}
+	defer readyR.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(),
+		listenFDsEnv+"="+strings.Join(keys, ","),
+		readyFDEnv+"="+strconv.Itoa(3+len(files)),
+	)
+	cmd.ExtraFiles = append(files, readyW)
+
+	err = cmd.Start()
+	readyW.Close()
+	if err != nil {
+		return fmt.Errorf("failed to start new process: %w", err)
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := readyR.Read(buf); err != nil {
+			ready <- fmt.Errorf("new process exited before becoming ready: %w", err)
+			return
+		}
+		ready <- nil
+	}()
+
+	select {
+	case err = <-ready:
+	case <-time.After(ReadyTimeout):
+		err = fmt.Errorf("new process not ready after %s", ReadyTimeout)
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		go cmd.Wait()
+		return err
+	}
+
+	// The new process owns the unix socket files now; closing our
+	// listeners during shutdown must not remove them.
+	u.mu.Lock()
+	for _, ln := range u.listeners {
+		if ul, ok := ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	u.mu.Unlock()
+
+	u.logger.Infow("new process is ready", "pid", cmd.Process.Pid)
+	return nil
+}
+
+// Watch restarts the process on SIGHUP until stop is called. After a
+// successful upgrade it sends SIGTERM to the current process so it shuts
+// down through its regular, graceful path.
+func (u *Upgrader) Watch() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				u.logger.Info("received SIGHUP, restarting")
+				if err := u.Upgrade(); err != nil {
+					u.logger.Errorw("restart failed, continuing with current process", "error", err)
+					continue
+				}
+				if err := terminate(); err != nil {
+					u.logger.Errorw("failed to stop the current process", "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// removeStaleSocket removes a socket file left behind by a previous process
+// that did not shut down cleanly. Sockets a process still accepts
+// connections on are left alone, so listening on them fails rather than
+// taking them over, as are other kinds of files.
+func removeStaleSocket(path string, logger *zap.SugaredLogger) {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		logger.Warnw("socket is in use by another process, not removing it", "path", path)
+		return
+	}
+	if err := os.Remove(path); err != nil {
+		logger.Warnw("failed to remove stale socket", "path", path, "error", err)
+	}
+}
+
+func closeAll(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
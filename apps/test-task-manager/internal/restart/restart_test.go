@@ -0,0 +1,78 @@
+package restart
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestListen_LiveSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ttm.sock")
+	live, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close()
+
+	if _, err := New(zap.NewNop().Sugar()).Listen("unix", path); err == nil {
+		t.Fatal("expected listening on a socket in use to fail")
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("expected the socket in use to be left alone: %v", err)
+	}
+	conn.Close()
+}
+
+func TestListen_StaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ttm.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Leave the socket file behind, as a process that did not shut down cleanly does.
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := New(zap.NewNop().Sugar()).Listen("unix", path)
+	if err != nil {
+		t.Fatalf("expected the stale socket to be replaced: %v", err)
+	}
+	defer ln.Close()
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("expected the new listener to accept connections: %v", err)
+	}
+	conn.Close()
+}
+
+func TestListen_RegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ttm.sock")
+	if err := os.WriteFile(path, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(zap.NewNop().Sugar()).Listen("unix", path); err == nil {
+		t.Fatal("expected listening on a regular file to fail")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "keep" {
+		t.Errorf("expected the regular file to be left alone, got %q (%v)", data, err)
+	}
+}
+
+func TestUpgrade_Refused(t *testing.T) {
+	u := New(zap.NewNop().Sugar())
+	u.Refuse("tasks live in memory")
+
+	err := u.Upgrade()
+	if !errors.Is(err, ErrRefused) {
+		t.Fatalf("expected the restart to be refused, got %v", err)
+	}
+	if err.Error() != "restart refused: tasks live in memory" {
+		t.Errorf("expected the reason in the error, got %q", err)
+	}
+}
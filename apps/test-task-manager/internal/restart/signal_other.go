@@ -0,0 +1,11 @@
+//go:build !unix
+
+package restart
+
+import "errors"
+
+// terminate fails on platforms that cannot signal processes, where
+// restarting is not supported.
+func terminate() error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,13 @@
+//go:build unix
+
+package restart
+
+import (
+	"os"
+	"syscall"
+)
+
+// terminate sends SIGTERM to the current process.
+func terminate() error {
+	return syscall.Kill(os.Getpid(), syscall.SIGTERM)
+}
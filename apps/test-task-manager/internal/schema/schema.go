@@ -0,0 +1,308 @@
+// Package schema holds the JSON Schemas of the API request bodies and
+// validates payloads against them.
+//
+// Only the keywords the schemas use are supported: type, enum, format
+// date-time, minLength, maxLength, pattern, minimum, maximum, properties,
+// required, additionalProperties, minProperties and items.
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed schemas/*.json
+var files embed.FS
+
+// Schema is a JSON Schema, or a subschema of one.
+type Schema struct {
+	ID                   string             `json:"$id"`
+	Title                string             `json:"title"`
+	Description          string             `json:"description"`
+	Type                 types              `json:"type"`
+	Format               string             `json:"format"`
+	Enum                 []any              `json:"enum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Pattern              string             `json:"pattern"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	MinProperties        *int               `json:"minProperties"`
+	Items                *Schema            `json:"items"`
+
+	pattern *regexp.Regexp
+}
+
+// types is the type keyword, which is a type name or a list of them.
+type types []string
+
+func (t *types) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = types{name}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// FieldError is a value of a payload not matching its schema.
+type FieldError struct {
+	Field   string `json:"field" xml:"name,attr"` // JSON Pointer to the value; "" for the payload itself
+	Message string `json:"message" xml:",chardata"`
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + " " + e.Message
+}
+
+// schema documents and their compiled schemas by name.
+var (
+	documents = make(map[string][]byte)
+	schemas   = make(map[string]*Schema)
+)
+
+func init() {
+	entries, err := files.ReadDir("schemas")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		content, err := files.ReadFile("schemas/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		s, err := Parse(content)
+		if err != nil {
+			panic(fmt.Sprintf("schema %s: %v", entry.Name(), err))
+		}
+		name := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		documents[name], schemas[name] = content, s
+	}
+}
+
+// Parse parses the JSON Schema in data.
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// compile compiles the patterns of s and its subschemas.
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, prop := range s.Properties {
+		if err := prop.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// Names returns the names of the request body schemas, sorted.
+func Names() []string {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the request body schema called name, such as "new-task".
+func Lookup(name string) (*Schema, bool) {
+	s, ok := schemas[name]
+	return s, ok
+}
+
+// MustLookup is like Lookup but panics when there is no such schema, for
+// routes naming the schema of their body.
+func MustLookup(name string) *Schema {
+	s, ok := Lookup(name)
+	if !ok {
+		panic("schema: no schema " + name)
+	}
+	return s
+}
+
+// Document returns the JSON document of the schema called name, as served
+// to clients.
+func Document(name string) ([]byte, bool) {
+	doc, ok := documents[name]
+	return doc, ok
+}
+
+// Validate checks the decoded JSON value v against s and returns the field
+// errors, sorted by field, with English messages.
+func (s *Schema) Validate(v any) []FieldError {
+	return s.ValidateWith(v, fmt.Sprintf)
+}
+
+// ValidateWith is Validate with the messages made by message from their
+// English format and arguments, so they can be translated.
+func (s *Schema) ValidateWith(v any, message func(format string, args ...any) string) []FieldError {
+	errs := s.validate(v, "", message)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+func (s *Schema) validate(v any, at string, message func(string, ...any) string) []FieldError {
+	fail := func(format string, args ...any) []FieldError {
+		return []FieldError{{Field: at, Message: message(format, args...)}}
+	}
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(v, t) }) {
+		return fail("must be %s", article(s.Type, message))
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return e == v }) {
+		return fail("must be one of %s", enumeration(s.Enum))
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		return s.validateObject(v, at, message)
+	case []any:
+		if s.Items == nil {
+			return nil
+		}
+		var errs []FieldError
+		for i, item := range v {
+			errs = append(errs, s.Items.validate(item, fmt.Sprintf("%s/%d", at, i), message)...)
+		}
+		return errs
+	case string:
+		length := len([]rune(v))
+		switch {
+		case s.MinLength != nil && length < *s.MinLength:
+			if *s.MinLength == 1 {
+				return fail("must not be empty")
+			}
+			return fail("must be at least %d characters", *s.MinLength)
+		case s.MaxLength != nil && length > *s.MaxLength:
+			return fail("must be at most %d characters", *s.MaxLength)
+		case s.pattern != nil && !s.pattern.MatchString(v):
+			return fail("must match %s", s.Pattern)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				return fail("must be an RFC 3339 date-time")
+			}
+		}
+	case float64:
+		switch {
+		case s.Minimum != nil && v < *s.Minimum:
+			return fail("must be at least %v", *s.Minimum)
+		case s.Maximum != nil && v > *s.Maximum:
+			return fail("must be at most %v", *s.Maximum)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(obj map[string]any, at string, message func(string, ...any) string) []FieldError {
+	var errs []FieldError
+	if s.MinProperties != nil && len(obj) < *s.MinProperties {
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		errs = append(errs, FieldError{Field: at, Message: message("must have at least one of %s", strings.Join(names, ", "))})
+	}
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			errs = append(errs, FieldError{Field: at + "/" + escape(name), Message: message("is required")})
+		}
+	}
+	for name, value := range obj {
+		prop, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				errs = append(errs, FieldError{Field: at + "/" + escape(name), Message: message("is not allowed")})
+			}
+			continue
+		}
+		errs = append(errs, prop.validate(value, at+"/"+escape(name), message)...)
+	}
+	return errs
+}
+
+// hasType reports whether the decoded JSON value v is of the JSON Schema
+// type t.
+func hasType(v any, t string) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || t == "integer" && v == math.Trunc(v)
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	}
+	return false
+}
+
+// article names the types of a type keyword for messages, such as "a
+// string" or "a number or null".
+func article(ts types, message func(string, ...any) string) string {
+	var names string
+	for i, t := range ts {
+		name := "a " + t
+		switch t {
+		case "null":
+			name = "null"
+		case "integer", "array", "object":
+			name = "an " + t
+		}
+		if i == 0 {
+			names = message(name)
+		} else {
+			names = message("%s or %s", names, message(name))
+		}
+	}
+	return names
+}
+
+func enumeration(values []any) string {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = fmt.Sprint(v)
+	}
+	return strings.Join(names, ", ")
+}
+
+// escape escapes a property name for a JSON Pointer (RFC 6901).
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		schema  string
+		payload string
+		want    []FieldError
+	}{
+		{"new-task", `{"title": "Ship release", "priority": "p1", "dueDate": "2026-03-01T09:00:00Z"}`, nil},
+		{"new-task", `{"priority": 1, "dueDate": "tomorrow"}`, []FieldError{
+			{"/dueDate", "must be an RFC 3339 date-time"},
+			{"/priority", "must be a string"},
+			{"/title", "is required"},
+		}},
+		{"new-task", `[]`, []FieldError{{"", "must be an object"}}},
+		{"reprioritize", `{}`, []FieldError{{"", "must have at least one of color, priority"}}},
+		{"reprioritize", `{"priority": "p2", "due": "soon"}`, []FieldError{{"/due", "is not allowed"}}},
+		{"merge", `{"source": ""}`, []FieldError{{"/source", "must not be empty"}}},
+		{"priority", `{"priority": "🔥", "color": "red"}`, []FieldError{{"/color", "is not allowed"}}},
+		{"lock", `{"ttl": 7200}`, []FieldError{{"/ttl", "must be at most 3600"}}},
+		{"lock", `{"ttl": 1.5}`, []FieldError{{"/ttl", "must be an integer"}}},
+		{"preferences", `{"theme": "blue", "pageSize": 25}`, []FieldError{{"/theme", "must be one of light, dark"}}},
+		{"notification-preferences", `{"mode": "digest-only", "channels": ["push", "sms"]}`, []FieldError{{"/channels/1", "must be one of email, webhook, push"}}},
+		{"push-subscription", `{"endpoint": "https://push.example.com/1", "expirationTime": null, "keys": {"p256dh": "k"}}`, []FieldError{{"/keys/auth", "is required"}}},
+		{"push-subscription", `{"endpoint": "https://push.example.com/1", "expirationTime": "never", "keys": {"p256dh": "k", "auth": "a"}}`, []FieldError{{"/expirationTime", "must be a number or null"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			var v any
+			if err := json.Unmarshal([]byte(tt.payload), &v); err != nil {
+				t.Fatal(err)
+			}
+			if got := MustLookup(tt.schema).Validate(v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: expected %v, got %v", tt.payload, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	if len(Names()) == 0 {
+		t.Fatal("expected embedded schemas")
+	}
+	for _, name := range Names() {
+		doc, ok := Document(name)
+		if !ok {
+			t.Fatalf("expected a document for %s", name)
+		}
+		s, err := Parse(doc)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if s.ID != "/api/schemas/"+name {
+			t.Errorf("%s: expected $id /api/schemas/%s, got %q", name, name, s.ID)
+		}
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Error("expected no schema nope")
+	}
+}
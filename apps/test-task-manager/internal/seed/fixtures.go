@@ -0,0 +1,102 @@
+package seed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+)
+
+// Fixture is a task in a fixture file, which holds a JSON array of them.
+// DueInDays sets the due date relative to the day the fixtures are loaded,
+// at 17:00, so fixtures do not all become overdue; DueDate sets an exact
+// one.
+type Fixture struct {
+	Title     string     `json:"title"`
+	Priority  string     `json:"priority,omitempty"`
+	Color     string     `json:"color,omitempty"`
+	DueDate   *time.Time `json:"dueDate,omitempty"`
+	DueInDays *int       `json:"dueInDays,omitempty"`
+	Completed bool       `json:"completed,omitempty"`
+}
+
+// LoadFixtures adds the tasks of the fixture files matching pattern, such
+// as fixtures/*.json, in file name order. Every task is validated like one
+// created through the API, and none is added when one is invalid. Tasks
+// whose title already exists are skipped, so loading the fixtures into a
+// store that kept them does not create duplicates.
+func LoadFixtures(ctx context.Context, taskService *service.TaskService, pattern string, now time.Time) (Result, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid fixture pattern %q: %w", pattern, err)
+	}
+
+	tasks, err := taskService.GetAll(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	existing := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		existing[task.Title] = true
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 17, 0, 0, 0, now.Location())
+	var result Result
+	var missing []model.Task
+	for _, file := range files {
+		fixtures, err := readFixtures(file)
+		if err != nil {
+			return Result{}, err
+		}
+		for i, f := range fixtures {
+			due := f.DueDate
+			if f.DueInDays != nil {
+				if due != nil {
+					return Result{}, fmt.Errorf("%s: task %d: set dueDate or dueInDays, not both", file, i+1)
+				}
+				d := today.AddDate(0, 0, *f.DueInDays)
+				due = &d
+			}
+			task, err := taskService.Validate(f.Title, f.Priority, f.Color, due)
+			if err != nil {
+				return Result{}, fmt.Errorf("%s: task %d: %w", file, i+1, err)
+			}
+			if existing[task.Title] {
+				result.Skipped++
+				continue
+			}
+			existing[task.Title] = true
+			task.Completed = f.Completed
+			missing = append(missing, task)
+		}
+	}
+
+	created, err := taskService.CreateMany(ctx, missing)
+	if err != nil {
+		return result, err
+	}
+	result.Created = len(created)
+	return result, nil
+}
+
+// readFixtures decodes a fixture file, rejecting unknown fields so typos
+// do not go unnoticed.
+func readFixtures(file string) ([]Fixture, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.DisallowUnknownFields()
+	var fixtures []Fixture
+	if err := dec.Decode(&fixtures); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return fixtures, nil
+}
@@ -0,0 +1,96 @@
+package seed
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	dir := t.TempDir()
+	writeFixtures(t, dir, "a.json", `[
+		{"title": "Renew certificate", "priority": "🔥", "color": "#dc3545", "dueInDays": -1},
+		{"title": "Send report", "dueDate": "2024-05-10T12:00:00Z", "completed": true}
+	]`)
+	writeFixtures(t, dir, "b.json", `[{"title": "Plan offsite", "priority": "⭐"}]`)
+	writeFixtures(t, dir, "notes.txt", `not a fixture`)
+
+	taskService := service.NewTaskService(store.NewTaskStore())
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	result, err := LoadFixtures(t.Context(), taskService, filepath.Join(dir, "*.json"), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 3 || result.Skipped != 0 {
+		t.Fatalf("expected 3 fixtures to be created, got %+v", result)
+	}
+
+	tasks, _ := taskService.GetAll(t.Context())
+	if tasks[0].Title != "Renew certificate" || !tasks[0].DueDate.Equal(time.Date(2024, 4, 30, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the first task to be due yesterday at 17:00, got %+v", tasks[0])
+	}
+	if !tasks[1].Completed || tasks[1].Color != service.ColorGrey || tasks[2].Title != "Plan offsite" {
+		t.Errorf("expected the fixtures in file order with defaults applied, got %+v", tasks[1:])
+	}
+
+	result, err = LoadFixtures(t.Context(), taskService, filepath.Join(dir, "*.json"), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 0 || result.Skipped != 3 {
+		t.Errorf("expected loading again to skip every fixture, got %+v", result)
+	}
+}
+
+func TestLoadFixtures_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"invalid task":  `[{"title": "ok"}, {"title": "  "}]`,
+		"unknown field": `[{"title": "ok", "due": "tomorrow"}]`,
+		"both dues":     `[{"title": "ok", "dueDate": "2024-05-10T12:00:00Z", "dueInDays": 1}]`,
+		"not an array":  `{"title": "ok"}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFixtures(t, dir, "tasks.json", content)
+			taskService := service.NewTaskService(store.NewTaskStore())
+
+			if _, err := LoadFixtures(t.Context(), taskService, filepath.Join(dir, "*.json"), time.Now()); err == nil {
+				t.Fatal("expected an error")
+			}
+			if tasks, _ := taskService.GetAll(t.Context()); len(tasks) != 0 {
+				t.Errorf("expected no task to be added, got %+v", tasks)
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	writeFixtures(t, dir, "tasks.json", `[{"title": "ok"}, {"title": "  "}]`)
+	_, err := LoadFixtures(t.Context(), service.NewTaskService(store.NewTaskStore()), filepath.Join(dir, "*.json"), time.Now())
+	if !errors.Is(err, service.ErrEmptyTitle) {
+		t.Errorf("expected ErrEmptyTitle, got %v", err)
+	}
+}
+
+func TestLoadFixtures_Repository(t *testing.T) {
+	taskService := service.NewTaskService(store.NewTaskStore())
+	result, err := LoadFixtures(t.Context(), taskService, "../../fixtures/*.json", time.Now())
+	if err != nil {
+		t.Fatalf("expected the fixtures of the repository to be valid, got %v", err)
+	}
+	if result.Created == 0 {
+		t.Error("expected the repository to have fixtures")
+	}
+}
+
+func writeFixtures(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package seed generates sample tasks for demos and UI development.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+)
+
+// MaxCount bounds the number of sample tasks added at once.
+const MaxCount = 1000
+
+// Sample is a generated task.
+type Sample struct {
+	Title     string
+	Priority  string
+	Color     string
+	DueDate   *time.Time
+	Completed bool
+}
+
+// Result reports what Run did.
+type Result struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"` // Samples that already existed
+}
+
+// template is a sample title with the priority it typically gets.
+type template struct {
+	title    string
+	priority string
+}
+
+var templates = []template{
+	{"Fix login redirect loop on Safari", service.PriorityUrgentImportant},
+	{"Prepare quarterly roadmap review", service.PriorityImportant},
+	{"Reply to supplier about invoice", service.PriorityUrgent},
+	{"Try the new keyboard shortcuts", service.PriorityLow},
+	{"Clean up the shared drive", service.PriorityDefault},
+	{"Renew TLS certificate for the API", service.PriorityUrgentImportant},
+	{"Write onboarding guide for new hires", service.PriorityImportant},
+	{"Book meeting room for Friday demo", service.PriorityUrgent},
+	{"Read article on event sourcing", service.PriorityLow},
+	{"Order new office chairs", service.PriorityDefault},
+	{"Patch production database", service.PriorityUrgentImportant},
+	{"Plan team offsite", service.PriorityImportant},
+	{"Answer customer support escalation", service.PriorityUrgent},
+	{"Explore dark mode for the dashboard", service.PriorityLow},
+	{"Archive last year's tickets", service.PriorityDefault},
+	{"Rotate leaked API key", service.PriorityUrgentImportant},
+	{"Draft performance review notes", service.PriorityImportant},
+	{"Send expense report", service.PriorityUrgent},
+	{"Update profile picture", service.PriorityLow},
+	{"Sort out the bookmarks bar", service.PriorityDefault},
+}
+
+// priorityColors are the colors the UI pairs with each priority.
+var priorityColors = map[string]string{
+	service.PriorityUrgentImportant: service.ColorRed,
+	service.PriorityImportant:       service.ColorBlue,
+	service.PriorityUrgent:          service.ColorYellow,
+	service.PriorityLow:             service.ColorGreen,
+	service.PriorityDefault:         service.ColorGrey,
+}
+
+// dueOffsets are cycled through to spread due dates from overdue to next
+// month; nil means no due date.
+var dueOffsets = []*int{days(-3), days(0), nil, days(1), days(7), days(-1), days(14), nil, days(30)}
+
+func days(n int) *int {
+	return &n
+}
+
+// Samples returns count sample tasks with due dates relative to now. The
+// same count always yields the same titles, which makes seeding idempotent.
+func Samples(count int, now time.Time) []Sample {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 17, 0, 0, 0, now.Location())
+
+	samples := make([]Sample, count)
+	for i := range samples {
+		t := templates[i%len(templates)]
+		s := Sample{
+			Title:    t.title,
+			Priority: t.priority,
+			Color:    priorityColors[t.priority],
+			// Roughly a quarter of the tasks is done.
+			Completed: i%4 == 3,
+		}
+		if round := i / len(templates); round > 0 {
+			s.Title = fmt.Sprintf("%s (%d)", t.title, round+1)
+		}
+		// Mix in the colors no priority uses by default.
+		switch {
+		case i%5 == 4:
+			s.Color = service.ColorPurple
+		case i%7 == 6:
+			s.Color = service.ColorOrange
+		}
+		if offset := dueOffsets[i%len(dueOffsets)]; offset != nil {
+			due := today.AddDate(0, 0, *offset)
+			s.DueDate = &due
+		}
+		samples[i] = s
+	}
+	return samples
+}
+
+// Run adds the first count samples that do not exist yet, matched by title,
+// so running it again does not create duplicates.
+func Run(ctx context.Context, taskService *service.TaskService, count int, now time.Time) (Result, error) {
+	if count < 1 || count > MaxCount {
+		return Result{}, fmt.Errorf("count must be between 1 and %d", MaxCount)
+	}
+
+	tasks, err := taskService.GetAll(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	existing := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		existing[task.Title] = true
+	}
+
+	var result Result
+	var missing []model.Task
+	for _, s := range Samples(count, now) {
+		if existing[s.Title] {
+			result.Skipped++
+			continue
+		}
+		missing = append(missing, model.Task{Title: s.Title, Priority: s.Priority, Color: s.Color, DueDate: s.DueDate, Completed: s.Completed})
+	}
+
+	created, err := taskService.CreateMany(ctx, missing)
+	if err != nil {
+		return result, err
+	}
+	result.Created = len(created)
+	return result, nil
+}
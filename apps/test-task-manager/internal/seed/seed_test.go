@@ -0,0 +1,47 @@
+package seed
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/service"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestRun_Idempotent(t *testing.T) {
+	taskService := service.NewTaskService(store.NewTaskStore())
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	result, err := Run(t.Context(), taskService, 10, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 10 || result.Skipped != 0 {
+		t.Fatalf("unexpected first run result: %+v", result)
+	}
+
+	result, err = Run(t.Context(), taskService, 25, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 15 || result.Skipped != 10 {
+		t.Fatalf("expected only the missing samples to be added, got %+v", result)
+	}
+
+	tasks, _ := taskService.GetAll(t.Context())
+	var completed, due, overdue int
+	for _, task := range tasks {
+		if task.Completed {
+			completed++
+		}
+		if task.DueDate != nil {
+			due++
+			if task.DueDate.Before(now) {
+				overdue++
+			}
+		}
+	}
+	if len(tasks) != 25 || completed == 0 || due == 0 || overdue == 0 || due == len(tasks) {
+		t.Errorf("expected a mix of statuses and due dates, got %d tasks, %d completed, %d due, %d overdue", len(tasks), completed, due, overdue)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Kinds of Activity.
+const (
+	ActivityCreated    = "created"
+	ActivityUpdated    = "updated"
+	ActivityReassigned = "reassigned" // The priority changed, see ChangePriority
+	ActivityCompleted  = "completed"
+	ActivityReopened   = "reopened"
+	ActivityDeleted    = "deleted"
+)
+
+// activityKinds maps the types of the changes in the change feed to the
+// kinds of activity they are.
+var activityKinds = map[string]string{
+	store.EventTaskCreated:         ActivityCreated,
+	store.EventTaskUpdated:         ActivityUpdated,
+	store.EventTaskPriorityChanged: ActivityReassigned,
+	store.EventTaskCompleted:       ActivityCompleted,
+	store.EventTaskReopened:        ActivityReopened,
+	store.EventTaskDeleted:         ActivityDeleted,
+}
+
+// Activity is a change made to a task, as the activity feed shows it.
+type Activity struct {
+	Seq              int64     `json:"seq"` // Of the change in the change feed
+	Kind             string    `json:"kind"`
+	TaskID           string    `json:"taskId"`
+	Title            string    `json:"title"`    // Of the task after the change
+	Priority         string    `json:"priority"` // Of the task after the change
+	PreviousPriority string    `json:"previousPriority,omitempty"`
+	At               time.Time `json:"at"`
+}
+
+// Activity returns up to limit of the latest changes made through the
+// service before sequence number before, or the latest ones when before is
+// 0, newest first, and whether older ones are kept. The activity comes from
+// the change feed of Changes, so it holds the latest 1000 changes since the
+// process started.
+func (s *TaskService) Activity(before int64, limit int) (activity []Activity, more bool) {
+	events, more := s.feed.before(before, limit)
+	activity = make([]Activity, len(events))
+	for i, e := range events {
+		activity[i] = Activity{
+			Seq:              e.Seq,
+			Kind:             activityKinds[e.Type],
+			TaskID:           e.Task.ID,
+			Title:            e.Task.Title,
+			Priority:         e.Task.Priority,
+			PreviousPriority: e.PreviousPriority,
+			At:               e.At,
+		}
+	}
+	return activity, more
+}
+
+// before returns up to limit of the changes before seq, or the latest ones
+// when seq is 0, newest first, and whether older ones are kept.
+func (f *changeFeed) before(seq int64, limit int) (events []store.Event, more bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	first := f.seq - int64(len(f.events)) + 1 // Seq of events[0]
+	end := len(f.events)                      // Of the changes before seq
+	if seq > 0 && seq <= f.seq {
+		end = max(int(seq-first), 0)
+	}
+	for i := end - 1; i >= 0 && len(events) < limit; i-- {
+		events = append(events, f.events[i])
+	}
+	return events, end > len(events)
+}
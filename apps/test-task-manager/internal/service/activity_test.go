@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestTaskService_Activity(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	if activity, more := service.Activity(0, 10); len(activity) != 0 || more {
+		t.Fatalf("expected no activity, got %+v, %v", activity, more)
+	}
+
+	task, err := service.Create(t.Context(), "Plan sprint", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ChangePriority(t.Context(), task.ID, PriorityUrgentImportant, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Toggle(t.Context(), task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	activity, more := service.Activity(0, 2)
+	if len(activity) != 2 || !more || activity[0].Kind != ActivityCompleted || activity[1].Kind != ActivityReassigned {
+		t.Fatalf("expected the completion and the priority change, newest first, with more, got %+v, %v", activity, more)
+	}
+	if reassigned := activity[1]; reassigned.TaskID != task.ID || reassigned.Priority != PriorityUrgentImportant || reassigned.PreviousPriority != PriorityDefault {
+		t.Errorf("expected the priority change from %s to %s, got %+v", PriorityDefault, PriorityUrgentImportant, reassigned)
+	}
+
+	older, more := service.Activity(activity[1].Seq, 2)
+	if len(older) != 1 || more || older[0].Kind != ActivityCreated || older[0].Title != "Plan sprint" {
+		t.Errorf("expected only the creation before the priority change, got %+v, %v", older, more)
+	}
+	if none, more := service.Activity(older[0].Seq, 2); len(none) != 0 || more {
+		t.Errorf("expected no activity before the creation, got %+v, %v", none, more)
+	}
+}
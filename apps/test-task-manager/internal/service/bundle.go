@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Format and version of the bundles ExportBundle writes and ImportBundle
+// reads.
+const (
+	BundleFormat  = "ttm-workspace"
+	BundleVersion = 1
+)
+
+// ErrUnsupportedBundle is returned by ImportBundle for bundles of another
+// format or a later version.
+var ErrUnsupportedBundle = errors.New("unsupported bundle format or version")
+
+// Bundle is a portable copy of the tasks of a workspace, for moving them to
+// another store backend or environment. Unlike the plain task list it keeps
+// the IDs, timestamps, creators and priority history of the tasks.
+type Bundle struct {
+	Format     string        `json:"format"`
+	Version    int           `json:"version"`
+	Workspace  string        `json:"workspace,omitempty"` // Exported from
+	ExportedAt time.Time     `json:"exportedAt"`
+	Tasks      []BundledTask `json:"tasks"`
+}
+
+// BundledTask is a task of a Bundle with its History.
+type BundledTask struct {
+	model.Task
+	History []Transition `json:"history,omitempty"`
+}
+
+// ExportBundle returns a Bundle of every task in creation order.
+func (s *TaskService) ExportBundle(ctx context.Context) (Bundle, error) {
+	tasks, err := s.GetAll(ctx)
+	if err != nil {
+		return Bundle{}, err
+	}
+	b := Bundle{Format: BundleFormat, Version: BundleVersion, ExportedAt: time.Now().UTC(), Tasks: make([]BundledTask, len(tasks))}
+	for i, task := range tasks {
+		b.Tasks[i] = BundledTask{Task: task, History: s.history.get(task.ID)}
+	}
+	return b, nil
+}
+
+// ImportBundle validates the tasks of b like Validate and adds them to the
+// store in one batch, all or none of them, keeping their creation,
+// completion and update times, creators and history. The store assigns new
+// IDs; the result maps the IDs of the bundle to them. Imports are held to
+// the task quota of the plan but not to WIP limits or unique titles, and
+// are not given the default due date.
+func (s *TaskService) ImportBundle(ctx context.Context, b Bundle) (map[string]string, error) {
+	if b.Format != BundleFormat || b.Version < 1 || b.Version > BundleVersion {
+		return nil, fmt.Errorf("%w: %q version %d", ErrUnsupportedBundle, b.Format, b.Version)
+	}
+	tasks := make([]model.Task, len(b.Tasks))
+	for i, t := range b.Tasks {
+		task, err := s.Validate(t.Title, t.Priority, t.Color, t.DueDate)
+		if err != nil {
+			return nil, fmt.Errorf("task %d (%s): %w", i+1, t.ID, err)
+		}
+		task.Completed, task.CompletedAt = t.Completed, t.CompletedAt
+		task.CreatedAt, task.UpdatedAt, task.CreatedBy = t.CreatedAt, t.UpdatedAt, t.CreatedBy
+		tasks[i] = task
+	}
+	if err := s.checkQuota(ctx, len(tasks)); err != nil {
+		return nil, err
+	}
+
+	created, err := s.store.CreateMany(ctx, tasks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import tasks: %w", storeError(err))
+	}
+	ids := make(map[string]string, len(created))
+	completed := 0
+	for i, task := range created {
+		ids[b.Tasks[i].ID] = task.ID
+		for _, transition := range b.Tasks[i].History {
+			s.history.add(task.ID, transition)
+		}
+		if task.Completed {
+			completed++
+		}
+	}
+	s.metrics.created.Add(float64(len(created)))
+	s.metrics.completed.Add(float64(completed))
+	s.generation.Add(1)
+	s.changed(store.EventTaskCreated, created...)
+	return ids, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestTaskService_Bundle(t *testing.T) {
+	source := NewTaskService(store.NewTaskStore())
+	first, err := source.CreateBy(t.Context(), "u1", "Plan sprint", "", "blue", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.ChangePriority(t.Context(), first.ID, PriorityUrgent, "", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := source.Create(t.Context(), "Write notes", "", "", nil)
+	if _, err := source.Toggle(t.Context(), second.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := source.ExportBundle(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Format != BundleFormat || len(bundle.Tasks) != 2 || len(bundle.Tasks[0].History) != 1 {
+		t.Fatalf("expected both tasks and the priority change, got %+v", bundle)
+	}
+
+	target := NewTaskService(store.NewTaskStore())
+	target.Create(t.Context(), "Already there", "", "", nil)
+	ids, err := target.ImportBundle(t.Context(), bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[first.ID] == "" || ids[first.ID] == first.ID {
+		t.Fatalf("expected new IDs for both tasks, got %v", ids)
+	}
+
+	imported, err := target.Get(t.Context(), ids[first.ID])
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported := bundle.Tasks[0].Task
+	if imported.Priority != PriorityUrgent || imported.CreatedBy != "u1" || !imported.CreatedAt.Equal(exported.CreatedAt) || imported.UpdatedAt == nil {
+		t.Errorf("expected the task as exported, got %+v", imported)
+	}
+	if history, _ := target.History(t.Context(), imported.ID); len(history) != 1 || history[0].To != PriorityUrgent || history[0].By != "u1" {
+		t.Errorf("expected the priority history, got %+v", history)
+	}
+	if done, _ := target.Get(t.Context(), ids[second.ID]); !done.Completed || done.CompletedAt == nil || !done.CompletedAt.Equal(*bundle.Tasks[1].CompletedAt) {
+		t.Errorf("expected the completed task with its completion time, got %+v", done)
+	}
+}
+
+func TestTaskService_ImportBundleInvalid(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+
+	if _, err := service.ImportBundle(t.Context(), Bundle{Format: BundleFormat, Version: BundleVersion + 1}); !errors.Is(err, ErrUnsupportedBundle) {
+		t.Errorf("expected later versions to be rejected, got %v", err)
+	}
+	bundle := Bundle{Format: BundleFormat, Version: BundleVersion, Tasks: []BundledTask{{}, {}}}
+	bundle.Tasks[0].Title, bundle.Tasks[1].Title, bundle.Tasks[1].Color = "Valid", "Teal", "teal"
+	if _, err := service.ImportBundle(t.Context(), bundle); !errors.Is(err, ErrInvalidColor) {
+		t.Errorf("expected the invalid color to be rejected, got %v", err)
+	}
+	if tasks, _ := service.GetAll(t.Context()); len(tasks) != 0 {
+		t.Errorf("expected nothing to be imported, got %+v", tasks)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// changeFeedSize is the number of latest changes kept for Changes.
+const changeFeedSize = 1000
+
+// ChangeSet holds the changes Changes returns and the cursor to ask for
+// the next ones with.
+type ChangeSet struct {
+	Changes []store.Event `json:"changes"`
+	Cursor  string        `json:"cursor"`
+	// Reset is set when changes after the cursor were missed, as it is
+	// from before a restart or too old; clients reload the tasks.
+	Reset bool `json:"reset,omitempty"`
+}
+
+// changeFeed keeps the latest changes made through the service, numbered
+// from 1. Like locks it is kept in memory, so changes made by instances
+// sharing the store are not in it.
+type changeFeed struct {
+	mu     sync.Mutex
+	epoch  string        // Tells cursors of this process from those of others
+	seq    int64         // Of the latest change
+	events []store.Event // The latest changeFeedSize changes, oldest first
+	wake   chan struct{} // Closed and replaced on every change
+}
+
+func newChangeFeed() *changeFeed {
+	return &changeFeed{epoch: strconv.FormatInt(time.Now().UnixNano(), 36), wake: make(chan struct{})}
+}
+
+// add records changes of tasks of type change and wakes the waiters.
+func (f *changeFeed) add(change string, tasks ...model.Task) {
+	events := make([]store.Event, len(tasks))
+	for i, task := range tasks {
+		events[i] = store.Event{Type: change, Task: task}
+	}
+	f.record(events...)
+}
+
+// record numbers and timestamps events, records them and wakes the
+// waiters.
+func (f *changeFeed) record(events ...store.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	for _, e := range events {
+		f.seq++
+		e.Seq, e.At = f.seq, now
+		f.events = append(f.events, e)
+	}
+	if n := len(f.events) - changeFeedSize; n > 0 {
+		f.events = append(f.events[:0:0], f.events[n:]...)
+	}
+	close(f.wake)
+	f.wake = make(chan struct{})
+}
+
+// since returns the changes after seq, whether seq is too old for all of
+// them to be kept, the cursor of the latest change and a channel closed
+// on the next change.
+func (f *changeFeed) since(seq int64) (events []store.Event, missed bool, cursor string, wake <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cursor = f.epoch + "." + strconv.FormatInt(f.seq, 10)
+	if seq > f.seq {
+		return nil, true, cursor, f.wake
+	}
+	first := f.seq - int64(len(f.events)) + 1 // Seq of events[0]
+	if seq+1 < first {
+		return nil, true, cursor, f.wake
+	}
+	return append([]store.Event(nil), f.events[seq+1-first:]...), false, cursor, f.wake
+}
+
+// Changes returns the changes made through the service after cursor, as
+// returned in an earlier ChangeSet, waiting up to wait for one when there
+// are none yet. Without cursor it returns the cursor of the latest change
+// at once, so clients take it before loading the tasks and miss nothing.
+// A cursor that is not one of ours returns ErrInvalidCursor.
+func (s *TaskService) Changes(ctx context.Context, cursor string, wait time.Duration) (ChangeSet, error) {
+	seq := int64(-1)
+	reset := false
+	if cursor != "" {
+		epoch, n, ok := strings.Cut(cursor, ".")
+		var err error
+		if seq, err = strconv.ParseInt(n, 10, 64); !ok || err != nil || seq < 0 {
+			return ChangeSet{}, ErrInvalidCursor
+		}
+		// Cursors from before a restart are reset to the latest change
+		reset = epoch != s.feed.epoch
+	}
+
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	for {
+		events, missed, next, wake := s.feed.since(seq)
+		switch {
+		case cursor == "":
+			return ChangeSet{Changes: []store.Event{}, Cursor: next}, nil
+		case reset || missed:
+			return ChangeSet{Changes: []store.Event{}, Cursor: next, Reset: true}, nil
+		case len(events) > 0:
+			return ChangeSet{Changes: events, Cursor: next}, nil
+		}
+
+		select {
+		case <-wake:
+		case <-timeout.C:
+			return ChangeSet{Changes: []store.Event{}, Cursor: next}, nil
+		case <-ctx.Done():
+			return ChangeSet{}, ctx.Err()
+		}
+	}
+}
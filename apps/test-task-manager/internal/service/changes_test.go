@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestTaskService_Changes(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	ctx := context.Background()
+
+	// Without cursor the latest one is returned at once
+	start, err := service.Changes(ctx, "", time.Minute)
+	if err != nil || start.Cursor == "" || len(start.Changes) != 0 || start.Reset {
+		t.Fatalf("expected only a cursor, got %+v, %v", start, err)
+	}
+
+	task, err := service.Create(t.Context(), "Sync me", "", "", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := service.Delete(t.Context(), task.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	set, err := service.Changes(ctx, start.Cursor, time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(set.Changes) != 2 || set.Changes[0].Type != store.EventTaskCreated || set.Changes[1].Type != store.EventTaskDeleted {
+		t.Fatalf("expected the create and delete, got %+v", set.Changes)
+	}
+	if set.Changes[1].Task.ID != task.ID || set.Cursor == start.Cursor {
+		t.Errorf("expected the deleted task and a new cursor, got %+v", set)
+	}
+
+	// With no changes yet it waits for the next one
+	done := make(chan ChangeSet)
+	go func() {
+		next, _ := service.Changes(ctx, set.Cursor, time.Minute)
+		done <- next
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := service.Create(t.Context(), "Wake up", "", "", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	select {
+	case next := <-done:
+		if len(next.Changes) != 1 || next.Changes[0].Task.Title != "Wake up" {
+			t.Errorf("expected the new task, got %+v", next.Changes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Changes to return on the change")
+	}
+}
+
+func TestTaskService_ChangesTimeout(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	start, _ := service.Changes(context.Background(), "", 0)
+
+	set, err := service.Changes(context.Background(), start.Cursor, 10*time.Millisecond)
+	if err != nil || len(set.Changes) != 0 || set.Cursor != start.Cursor {
+		t.Errorf("expected no changes and the same cursor, got %+v, %v", set, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := service.Changes(ctx, start.Cursor, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestTaskService_ChangesReset(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	ctx := context.Background()
+
+	// Cursors of another process, or too old to have all changes since
+	set, err := service.Changes(ctx, "other.0", time.Minute)
+	if err != nil || !set.Reset {
+		t.Errorf("expected a reset for a foreign cursor, got %+v, %v", set, err)
+	}
+	start, _ := service.Changes(ctx, "", 0)
+	for range changeFeedSize + 1 {
+		service.feed.add(store.EventTaskUpdated, model.Task{})
+	}
+	if set, err = service.Changes(ctx, start.Cursor, time.Minute); err != nil || !set.Reset {
+		t.Errorf("expected a reset for a cursor too old, got %+v, %v", set, err)
+	}
+	if set, err = service.Changes(ctx, set.Cursor, 0); err != nil || set.Reset {
+		t.Errorf("expected the reset cursor to pass, got %+v, %v", set, err)
+	}
+
+	for _, cursor := range []string{"nodot", "x.-1", "x.y"} {
+		if _, err := service.Changes(ctx, cursor, 0); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Changes(%q): expected ErrInvalidCursor, got %v", cursor, err)
+		}
+	}
+}
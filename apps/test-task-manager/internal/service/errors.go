@@ -1,14 +1,59 @@
 package service
 
-import "errors"
+import (
+	"context"
+	"errors"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/apperr"
+)
 
 var (
 	// ErrEmptyTitle is returned when a task title is empty.
-	ErrEmptyTitle = errors.New("task title cannot be empty")
-	// ErrTitleTooLong is returned when a task title exceeds 255 characters.
-	ErrTitleTooLong = errors.New("task title cannot exceed 255 characters")
+	ErrEmptyTitle = apperr.New(apperr.EmptyTitle, "task title cannot be empty")
+	// ErrTitleTooShort is returned when a task title is shorter than the
+	// minimum of the TitleLimits.
+	ErrTitleTooShort = apperr.New(apperr.TitleTooShort, "task title is too short")
+	// ErrTitleTooLong is returned when a task title is longer than the
+	// maximum of the TitleLimits.
+	ErrTitleTooLong = apperr.New(apperr.TitleTooLong, "task title is too long")
+	// ErrInvalidTitle is returned when a task title is not valid UTF-8.
+	ErrInvalidTitle = apperr.New(apperr.InvalidTitle, "task title must be UTF-8 text")
+	// ErrDuplicateTitle is returned by services made WithUniqueTitles when an
+	// open task already has the title.
+	ErrDuplicateTitle = apperr.New(apperr.DuplicateTitle, "an open task with this title already exists")
+	// ErrWIPLimit is returned by services made WithWIPLimits when a change
+	// takes the open tasks over a limit.
+	ErrWIPLimit = apperr.New(apperr.WIPLimit, "the work in progress limit of open tasks is reached")
+	// ErrQuotaExceeded is returned by services made WithTaskQuota when
+	// creating tasks takes them over the quota.
+	ErrQuotaExceeded = apperr.New(apperr.QuotaExceeded, "the task quota of the plan is reached")
+	// ErrUserQuotaExceeded is returned, as the cause of a *UserQuotaError,
+	// by services made WithUserQuota when a user creating a task has the
+	// quota of open tasks.
+	ErrUserQuotaExceeded = apperr.New(apperr.UserQuotaExceeded, "the quota of open tasks per user is reached")
+	// ErrTaskLocked is returned when a task is locked by someone else.
+	ErrTaskLocked = apperr.New(apperr.TaskLocked, "task is locked")
+	// ErrInvalidCursor is returned by Changes for cursors it did not make.
+	ErrInvalidCursor = apperr.New(apperr.InvalidCursor, "invalid changes cursor")
+	// ErrMergeWithSelf is returned when a task is merged into itself.
+	ErrMergeWithSelf = apperr.New(apperr.MergeWithSelf, "a task cannot be merged into itself")
 	// ErrInvalidPriority is returned when a priority emoticon is not valid.
-	ErrInvalidPriority = errors.New("invalid priority emoticon")
+	ErrInvalidPriority = apperr.New(apperr.InvalidPriority, "invalid priority")
 	// ErrInvalidColor is returned when a color code is not valid.
-	ErrInvalidColor = errors.New("invalid color code")
+	ErrInvalidColor = apperr.New(apperr.InvalidColor, "invalid color code")
 )
+
+// storeError marks err, returned by the store, as STORE_UNAVAILABLE unless
+// the store gave it a code, such as TASK_NOT_FOUND. Errors without one are
+// failures to reach or use the backend, e.g. a lost database connection.
+// The store giving up because the context of the call was canceled or
+// timed out is no failure of the backend, and marked STORE_TIMEOUT.
+func storeError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return apperr.Wrap(apperr.StoreTimeout, "the request ended before the task store answered", err)
+	}
+	if apperr.CodeOf(err) != apperr.Internal {
+		return err
+	}
+	return apperr.Wrap(apperr.StoreUnavailable, "task store is unavailable", err)
+}
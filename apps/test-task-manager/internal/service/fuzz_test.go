@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/palette"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// validationErrors are the errors NewTask rejects fields with.
+var validationErrors = []error{ErrEmptyTitle, ErrTitleTooShort, ErrTitleTooLong, ErrInvalidTitle, ErrInvalidPriority, ErrInvalidColor}
+
+func FuzzNewTask(f *testing.F) {
+	f.Add("Buy milk", "🔥", "#dc3545")
+	f.Add("  padded  ", "", "")
+	f.Add(strings.Repeat("é", 200), "⭐", "#0d6efd")
+	f.Add("​", "📋", "#6c757D")
+	f.Add("a\x00b", "\U0001F525️", "#dc3545 ")
+	f.Add("\xff\xfe", "💡", "red")
+
+	f.Fuzz(func(t *testing.T, title, priority, color string) {
+		task, err := NewTask(title, priority, color, nil)
+		if err != nil {
+			if !slices.ContainsFunc(validationErrors, func(target error) bool { return errors.Is(err, target) }) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			return
+		}
+		checkValid(t, task.Title, task.Priority, task.Color)
+		if task.Title != sanitizeTitle(title) || sanitizeTitle(task.Title) != task.Title {
+			t.Errorf("expected the title to be sanitized once and for all, got %q from %q", task.Title, title)
+		}
+	})
+}
+
+func FuzzTaskService_Update(f *testing.F) {
+	f.Add("Buy milk", "🔥", "#dc3545", int64(0))
+	f.Add("", "⭐", "", int64(1767225600))
+	f.Add("\t\n", "nope", "#ffc107", int64(-1))
+
+	f.Fuzz(func(t *testing.T, title, priority, color string, due int64) {
+		service := NewTaskService(store.NewTaskStore())
+		original, err := service.Create(t.Context(), "original", PriorityLow, ColorGreen, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dueDate := time.Unix(due, 0).UTC()
+
+		updated, err := service.Update(t.Context(), original.ID, title, priority, color, &dueDate)
+		stored, getErr := service.Get(t.Context(), original.ID)
+		if getErr != nil {
+			t.Fatal(getErr)
+		}
+		if err != nil {
+			if stored != original || service.Generation() != 1 {
+				t.Errorf("expected a rejected update to change nothing, got %+v", stored)
+			}
+			return
+		}
+		checkValid(t, stored.Title, stored.Priority, stored.Color)
+		if stored.Title != updated.Title || !stored.CreatedAt.Equal(original.CreatedAt) || stored.Completed {
+			t.Errorf("expected the update to be stored with creation and completion kept, got %+v", stored)
+		}
+	})
+}
+
+// checkValid fails the test unless the fields are those of a valid task.
+func checkValid(t *testing.T, title, priority, color string) {
+	t.Helper()
+	if title == "" || title != strings.TrimSpace(title) || utf8.RuneCountInString(title) > 255 || !utf8.ValidString(title) || strings.ContainsFunc(title, unicode.IsControl) {
+		t.Errorf("invalid title %q accepted", title)
+	}
+	if !isValidPriority(priority) {
+		t.Errorf("invalid priority %q accepted", priority)
+	}
+	if !slices.Contains(palette.Default().Codes(), color) {
+		t.Errorf("invalid color %q accepted", color)
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// maxTaskHistory is the number of latest transitions kept per task.
+const maxTaskHistory = 100
+
+// Transition is a change recorded in the history of a task. Only changes
+// of the priority made on their own, through ChangePriority and
+// SetPriority, are recorded, so priority churn can be analyzed apart from
+// other edits. Like locks the history is kept in memory, so it starts
+// empty after a restart and is not shared by instances of a shared store.
+type Transition struct {
+	Field string    `json:"field"` // What changed: priority
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	At    time.Time `json:"at"`
+	By    string    `json:"by,omitempty"` // ID of the user, empty for anonymous clients and escalations
+}
+
+// historyTable holds the latest transitions by task ID, oldest first.
+type historyTable struct {
+	mu    sync.Mutex
+	tasks map[string][]Transition
+}
+
+// add records a transition of the task with id.
+func (t *historyTable) add(id string, transition Transition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tasks == nil {
+		t.tasks = make(map[string][]Transition)
+	}
+	transitions := append(t.tasks[id], transition)
+	if n := len(transitions) - maxTaskHistory; n > 0 {
+		transitions = append(transitions[:0:0], transitions[n:]...)
+	}
+	t.tasks[id] = transitions
+}
+
+// get returns the transitions of the task with id.
+func (t *historyTable) get(id string) []Transition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append(make([]Transition, 0, len(t.tasks[id])), t.tasks[id]...)
+}
+
+// merge adds the transitions of the task with source to those of the task
+// with target, keeping them oldest first. Transitions target has already,
+// from merging before, are not added again.
+func (t *historyTable) merge(target, source string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	transitions := slices.Clone(t.tasks[target])
+	for _, transition := range t.tasks[source] {
+		if !slices.Contains(transitions, transition) {
+			transitions = append(transitions, transition)
+		}
+	}
+	if len(transitions) == 0 {
+		return
+	}
+	slices.SortStableFunc(transitions, func(a, b Transition) int { return a.At.Compare(b.At) })
+	if n := len(transitions) - maxTaskHistory; n > 0 {
+		transitions = transitions[n:]
+	}
+	if t.tasks == nil {
+		t.tasks = make(map[string][]Transition)
+	}
+	t.tasks[target] = transitions
+}
+
+// drop forgets the history of a deleted task.
+func (t *historyTable) drop(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.tasks, id)
+}
+
+// History returns the recorded transitions of the task with id, oldest
+// first.
+func (s *TaskService) History(ctx context.Context, id string) ([]Transition, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.history.get(id), nil
+}
+
+// ChangePriority changes only the priority of the task with id, for the
+// client owner, who must hold its lock when it is locked, and user, the ID
+// of the user making the change or "" when anonymous. Raising the
+// priority of an open task is held to the WIP limits. The change is
+// recorded in the History of the task and reported as
+// store.EventTaskPriorityChanged. Setting the priority the task already
+// has changes nothing.
+func (s *TaskService) ChangePriority(ctx context.Context, id, priority, owner, user string) (model.Task, error) {
+	priority, ok := canonicalPriority(priority)
+	if !ok {
+		return model.Task{}, ErrInvalidPriority
+	}
+	if err := s.CheckLock(id, owner); err != nil {
+		return model.Task{}, err
+	}
+
+	task, err := s.Get(ctx, id)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if task.Priority == priority {
+		return task, nil
+	}
+	if !task.Completed {
+		if err := s.checkWIP(ctx, priority, 0, map[string]int{task.Priority: 1}); err != nil {
+			return model.Task{}, err
+		}
+	}
+	return s.setPriority(ctx, task, priority, user)
+}
+
+// SetPriority changes the priority of a task like ChangePriority, without
+// checking locks and WIP limits, for escalations.
+func (s *TaskService) SetPriority(ctx context.Context, id, priority string) (model.Task, error) {
+	priority, ok := canonicalPriority(priority)
+	if !ok {
+		return model.Task{}, ErrInvalidPriority
+	}
+
+	task, err := s.Get(ctx, id)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if task.Priority == priority {
+		return task, nil
+	}
+	return s.setPriority(ctx, task, priority, "")
+}
+
+// setPriority stores task with priority and records the transition.
+func (s *TaskService) setPriority(ctx context.Context, task model.Task, priority, user string) (model.Task, error) {
+	from := task.Priority
+	task.Priority = priority
+	task, err := s.store.Update(ctx, task)
+	if err != nil {
+		return model.Task{}, fmt.Errorf("failed to update task: %w", storeError(err))
+	}
+
+	s.history.add(task.ID, Transition{Field: "priority", From: from, To: task.Priority, At: time.Now(), By: user})
+	s.generation.Add(1)
+	s.feed.record(store.Event{Type: store.EventTaskPriorityChanged, Task: task, PreviousPriority: from})
+	s.notify(store.EventTaskPriorityChanged, task)
+	return task, nil
+}
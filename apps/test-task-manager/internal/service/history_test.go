@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestTaskService_ChangePriority(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	var observed []string
+	service.Observe(func(change string, _ model.Task) { observed = append(observed, change) })
+	task, err := service.Create(t.Context(), "Ship release", "⭐", "", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	start, _ := service.Changes(t.Context(), "", 0)
+
+	changed, err := service.ChangePriority(t.Context(), task.ID, "urgent", "", "u1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if changed.Priority != PriorityUrgentImportant || changed.Title != task.Title {
+		t.Errorf("expected only the priority to change, got %+v", changed)
+	}
+	// The same priority again changes nothing
+	if _, err := service.ChangePriority(t.Context(), task.ID, "🔥", "", "u1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	history, err := service.History(t.Context(), task.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(history) != 1 || history[0].Field != "priority" || history[0].From != "⭐" || history[0].To != "🔥" || history[0].By != "u1" || history[0].At.IsZero() {
+		t.Errorf("expected the transition from ⭐ to 🔥 by u1, got %+v", history)
+	}
+
+	set, err := service.Changes(t.Context(), start.Cursor, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(set.Changes) != 1 || set.Changes[0].Type != store.EventTaskPriorityChanged || set.Changes[0].PreviousPriority != "⭐" {
+		t.Errorf("expected one priority change from ⭐, got %+v", set.Changes)
+	}
+	if len(observed) != 2 || observed[1] != store.EventTaskPriorityChanged {
+		t.Errorf("expected the observers to see the priority change, got %v", observed)
+	}
+
+	if _, err := service.ChangePriority(t.Context(), task.ID, "someday", "", ""); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("expected ErrInvalidPriority, got %v", err)
+	}
+	if _, err := service.ChangePriority(t.Context(), "404", "⭐", "", ""); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+	if _, err := service.Lock(t.Context(), task.ID, "user:alice", "alice", time.Minute); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.ChangePriority(t.Context(), task.ID, "⭐", "user:bob", "bob"); !errors.Is(err, ErrTaskLocked) {
+		t.Errorf("expected ErrTaskLocked, got %v", err)
+	}
+
+	if err := service.Delete(t.Context(), task.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.History(t.Context(), task.ID); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound for a deleted task, got %v", err)
+	}
+}
+
+func TestTaskService_ChangePriorityWIPLimits(t *testing.T) {
+	limits := WIPLimits{Priority: map[string]int{PriorityUrgentImportant: 1}}
+	service := NewTaskService(store.NewTaskStore(), WithWIPLimits(limits, false))
+	if _, err := service.Create(t.Context(), "Fix outage", "🔥", "", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	task, _ := service.Create(t.Context(), "Plan roadmap", "⭐", "", nil)
+
+	if _, err := service.ChangePriority(t.Context(), task.ID, "🔥", "", ""); !errors.Is(err, ErrWIPLimit) {
+		t.Errorf("expected ErrWIPLimit, got %v", err)
+	}
+	if history, _ := service.History(t.Context(), task.ID); len(history) != 0 {
+		t.Errorf("expected no transition for a rejected change, got %+v", history)
+	}
+	// Escalations are not held to the limits
+	if _, err := service.SetPriority(t.Context(), task.ID, "🔥"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if history, _ := service.History(t.Context(), task.ID); len(history) != 1 || history[0].By != "" {
+		t.Errorf("expected the escalation without user, got %+v", history)
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLockTTL is how long a lock lasts when no TTL is asked for.
+	DefaultLockTTL = 5 * time.Minute
+	// MaxLockTTL is the longest TTL a lock may be taken or renewed for.
+	MaxLockTTL = time.Hour
+)
+
+// Lock marks a task as being edited by a client. Locks are advisory: the
+// store does not know about them, and handlers changing tasks on behalf of
+// clients check them with CheckLock. They are kept in memory, so they are
+// not shared by instances of a shared store.
+//
+// The client holding a lock is its owner, a string identifying it to the
+// service, such as its user. Clients Lock identifies no other way get a
+// token to pass as owner.
+type Lock struct {
+	TaskID    string    `json:"taskId"`
+	Token     string    `json:"token,omitempty"`  // Owner of locks taken without one
+	Holder    string    `json:"holder,omitempty"` // Name of the user who took the lock, if known
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	owner string
+}
+
+// lockTable holds the unexpired locks by task ID.
+type lockTable struct {
+	mu    sync.Mutex
+	locks map[string]Lock
+}
+
+// held returns the unexpired lock of the task with id, dropping an
+// expired one. The caller holds mu.
+func (t *lockTable) held(id string, now time.Time) (Lock, bool) {
+	lock, ok := t.locks[id]
+	if ok && !now.Before(lock.ExpiresAt) {
+		delete(t.locks, id)
+		return Lock{}, false
+	}
+	return lock, ok
+}
+
+// Lock locks the task with id for owner for ttl, 0 meaning DefaultLockTTL,
+// up to MaxLockTTL, renewing the lock owner already holds. Without owner a
+// new token is made the owner. It returns ErrTaskLocked when someone else
+// holds an unexpired lock.
+func (s *TaskService) Lock(ctx context.Context, id, owner, holder string, ttl time.Duration) (Lock, error) {
+	if ttl <= 0 {
+		ttl = DefaultLockTTL
+	}
+	ttl = min(ttl, MaxLockTTL)
+	if _, err := s.Get(ctx, id); err != nil {
+		return Lock{}, err
+	}
+
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+	now := time.Now()
+	lock, ok := s.locks.held(id, now)
+	if ok && lock.owner != owner {
+		return Lock{}, ErrTaskLocked
+	}
+	if !ok {
+		lock = Lock{TaskID: id, Holder: holder, owner: owner}
+		if owner == "" {
+			lock.Token = newLockToken()
+			lock.owner = lock.Token
+		}
+	}
+	lock.ExpiresAt = now.Add(ttl)
+	if s.locks.locks == nil {
+		s.locks.locks = make(map[string]Lock)
+	}
+	s.locks.locks[id] = lock
+	return lock, nil
+}
+
+// Unlock releases the lock owner holds on the task with id. Releasing a
+// task that is not locked succeeds; one locked by someone else returns
+// ErrTaskLocked.
+func (s *TaskService) Unlock(id, owner string) error {
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+	lock, ok := s.locks.held(id, time.Now())
+	if !ok {
+		return nil
+	}
+	if lock.owner != owner {
+		return ErrTaskLocked
+	}
+	delete(s.locks.locks, id)
+	return nil
+}
+
+// CheckLock returns ErrTaskLocked when the task with id is locked by
+// another owner than owner, which is empty for anonymous clients holding
+// no lock.
+func (s *TaskService) CheckLock(id, owner string) error {
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+	if lock, ok := s.locks.held(id, time.Now()); ok && lock.owner != owner {
+		return ErrTaskLocked
+	}
+	return nil
+}
+
+// dropLock forgets the lock of a deleted task.
+func (s *TaskService) dropLock(id string) {
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+	delete(s.locks.locks, id)
+}
+
+func newLockToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "lock_" + hex.EncodeToString(b)
+}
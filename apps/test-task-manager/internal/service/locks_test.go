@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestTaskService_Locks(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	task, err := service.Create(t.Context(), "Edit me", "", "", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	lock, err := service.Lock(t.Context(), task.ID, "user:alice", "alice", time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if lock.Token != "" || lock.Holder != "alice" {
+		t.Errorf("expected a lock of alice without token, got %+v", lock)
+	}
+	if err := service.CheckLock(task.ID, "user:alice"); err != nil {
+		t.Errorf("expected the holder to pass, got %v", err)
+	}
+	for _, owner := range []string{"", "user:bob"} {
+		if err := service.CheckLock(task.ID, owner); !errors.Is(err, ErrTaskLocked) {
+			t.Errorf("CheckLock(%q): expected ErrTaskLocked, got %v", owner, err)
+		}
+	}
+	if _, err := service.Lock(t.Context(), task.ID, "", "", 0); !errors.Is(err, ErrTaskLocked) {
+		t.Errorf("expected ErrTaskLocked locking a locked task, got %v", err)
+	}
+	if err := service.Unlock(task.ID, "user:bob"); !errors.Is(err, ErrTaskLocked) {
+		t.Errorf("expected ErrTaskLocked unlocking the lock of another, got %v", err)
+	}
+	if err := service.Unlock(task.ID, "user:alice"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Anonymous clients are given a token to hold the lock with
+	lock, err = service.Lock(t.Context(), task.ID, "", "", 0)
+	if err != nil || lock.Token == "" {
+		t.Fatalf("expected a lock with token, got %+v, %v", lock, err)
+	}
+	if got := time.Until(lock.ExpiresAt); got <= DefaultLockTTL-time.Minute || got > DefaultLockTTL {
+		t.Errorf("expected the lock to last %s, expires in %s", DefaultLockTTL, got)
+	}
+	if err := service.CheckLock(task.ID, lock.Token); err != nil {
+		t.Errorf("expected the token to pass, got %v", err)
+	}
+	renewed, err := service.Lock(t.Context(), task.ID, lock.Token, "", 2*MaxLockTTL)
+	if err != nil || renewed.Token != lock.Token {
+		t.Fatalf("expected the lock to be renewed, got %+v, %v", renewed, err)
+	}
+	if renewed.ExpiresAt.After(time.Now().Add(MaxLockTTL)) {
+		t.Errorf("expected the TTL to be capped at %s, expires at %s", MaxLockTTL, renewed.ExpiresAt)
+	}
+
+	// Deleting the task drops its lock
+	if err := service.Delete(t.Context(), task.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := service.CheckLock(task.ID, ""); err != nil {
+		t.Errorf("expected the lock of a deleted task to be dropped, got %v", err)
+	}
+	if _, err := service.Lock(t.Context(), task.ID, "", "", 0); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound locking a deleted task, got %v", err)
+	}
+}
+
+func TestTaskService_LockExpires(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	task, err := service.Create(t.Context(), "Edit me", "", "", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := service.Lock(t.Context(), task.ID, "user:alice", "", time.Millisecond); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := service.CheckLock(task.ID, "user:bob"); err != nil {
+		t.Errorf("expected the expired lock to pass, got %v", err)
+	}
+	if _, err := service.Lock(t.Context(), task.ID, "user:bob", "", 0); err != nil {
+		t.Errorf("expected the expired lock to be taken over, got %v", err)
+	}
+}
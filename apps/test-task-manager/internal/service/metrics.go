@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// completionLatencyBuckets spans one minute up to thirty days, in seconds.
+var completionLatencyBuckets = []float64{60, 300, 900, 3600, 4 * 3600, 24 * 3600, 3 * 24 * 3600, 7 * 24 * 3600, 30 * 24 * 3600}
+
+// Stats summarizes task activity since the process started.
+type Stats struct {
+	Created           int64             `json:"created" xml:"created"`
+	Completed         int64             `json:"completed" xml:"completed"`
+	Deleted           int64             `json:"deleted" xml:"deleted"`
+	Open              int               `json:"open" xml:"open"`
+	CompletionLatency CompletionLatency `json:"completionLatency" xml:"completionLatency"`
+}
+
+// CompletionLatency describes the time between task creation and completion.
+type CompletionLatency struct {
+	Count          uint64  `json:"count" xml:"count"`
+	AverageSeconds float64 `json:"averageSeconds" xml:"averageSeconds"`
+}
+
+// taskMetrics holds the business metrics emitted by TaskService.
+type taskMetrics struct {
+	created           *metrics.Counter
+	completed         *metrics.Counter
+	deleted           *metrics.Counter
+	completionLatency *metrics.Histogram
+	wipExceeded       *metrics.CounterVec
+	workspace         string // The metrics are labeled with
+}
+
+// newTaskMetrics registers the task metrics of workspace on reg.
+// The open and stale task gauges are computed from the store at collection
+// time, and reported as NaN when the store cannot be read.
+func newTaskMetrics(reg *metrics.Registry, workspace string, openCount, staleCount func(context.Context) (int, error)) *taskMetrics {
+	reg.GaugeFuncVec("tasks_open", "Number of tasks that are not completed.", "workspace").Set(countGauge(openCount), workspace)
+	reg.GaugeFuncVec("tasks_stale", "Number of open tasks not changed for the stale period.", "workspace").Set(countGauge(staleCount), workspace)
+
+	return &taskMetrics{
+		created:           reg.CounterVec("tasks_created_total", "Total number of tasks created.", "workspace").With(workspace),
+		completed:         reg.CounterVec("tasks_completed_total", "Total number of times a task was marked complete.", "workspace").With(workspace),
+		deleted:           reg.CounterVec("tasks_deleted_total", "Total number of tasks deleted.", "workspace").With(workspace),
+		completionLatency: reg.HistogramVec("task_completion_latency_seconds", "Time between task creation and completion.", completionLatencyBuckets, "workspace").With(workspace),
+		wipExceeded:       reg.CounterVec("tasks_wip_limit_exceeded_total", "Total number of changes going over a WIP limit, by limit and whether they were rejected or warned about.", "workspace", "limit", "action"),
+		workspace:         workspace,
+	}
+}
+
+// countGauge returns the value of a gauge counting tasks with count.
+func countGauge(count func(context.Context) (int, error)) func() float64 {
+	return func() float64 {
+		n, err := count(context.Background())
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(n)
+	}
+}
+
+// observeToggle records a completion when the toggled task became completed.
+func (m *taskMetrics) observeToggle(task model.Task) {
+	if !task.Completed {
+		return
+	}
+
+	m.completed.Inc()
+
+	completedAt := time.Now()
+	if task.CompletedAt != nil {
+		completedAt = *task.CompletedAt
+	}
+	m.completionLatency.Observe(completedAt.Sub(task.CreatedAt).Seconds())
+}
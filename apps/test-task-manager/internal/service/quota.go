@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Plan is a tier of limits workspaces are served with. Limits of 0 are
+// unlimited.
+type Plan struct {
+	Name      string  `json:"name"`
+	RateLimit float64 `json:"rateLimit"` // API requests per second to the workspace
+	RateBurst int     `json:"rateBurst"` // API requests the workspace may make at once
+	MaxTasks  int     `json:"maxTasks"`  // Tasks the workspace may hold, open or completed
+}
+
+// ParsePlan parses a plan written as "<name>=<requests per second>/<burst>/<max tasks>",
+// such as "free=5/20/100" or "pro=50/100/0".
+func ParsePlan(s string) (Plan, error) {
+	name, limits, ok := strings.Cut(strings.TrimSpace(s), "=")
+	parts := strings.Split(limits, "/")
+	if !ok || name == "" || len(parts) != 3 {
+		return Plan{}, fmt.Errorf("plan %q must be <name>=<requests per second>/<burst>/<max tasks>", s)
+	}
+	rate, rateErr := strconv.ParseFloat(parts[0], 64)
+	burst, burstErr := strconv.Atoi(parts[1])
+	tasks, tasksErr := strconv.Atoi(parts[2])
+	if rateErr != nil || burstErr != nil || tasksErr != nil || rate < 0 || burst < 0 || tasks < 0 {
+		return Plan{}, fmt.Errorf("plan %q must have limits of at least 0", s)
+	}
+	if rate > 0 && burst < 1 {
+		return Plan{}, fmt.Errorf("plan %q must allow a burst of at least 1 request", s)
+	}
+	return Plan{Name: name, RateLimit: rate, RateBurst: burst, MaxTasks: tasks}, nil
+}
+
+// WithTaskQuota makes creating tasks, imports included, return
+// ErrQuotaExceeded when it takes the tasks of the store over max. A max of
+// 0 means no quota.
+func WithTaskQuota(max int) Option {
+	return func(s *TaskService) {
+		s.taskQuota = max
+	}
+}
+
+// TaskUsage returns the number of tasks in the store and the quota of
+// WithTaskQuota, which is 0 without one.
+func (s *TaskService) TaskUsage(ctx context.Context) (used, quota int, err error) {
+	tasks, err := s.GetAll(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(tasks), s.taskQuota, nil
+}
+
+// checkQuota returns ErrQuotaExceeded when adding tasks takes the store
+// over the quota. Like the WIP limits it is not atomic with the change, so
+// concurrent requests may together go over it.
+func (s *TaskService) checkQuota(ctx context.Context, added int) error {
+	if s.taskQuota == 0 {
+		return nil
+	}
+	used, _, err := s.TaskUsage(ctx)
+	if err != nil {
+		return err
+	}
+	if used+added > s.taskQuota {
+		return fmt.Errorf("%w: %d tasks would exceed the quota of %d", ErrQuotaExceeded, used+added, s.taskQuota)
+	}
+	return nil
+}
+
+// WithUserQuota makes CreateBy return a *UserQuotaError when the user
+// creating the task already has max open tasks they created. Tasks created
+// anonymously, and imported ones, are not held to it. A max of 0 means no
+// quota.
+func WithUserQuota(max int) Option {
+	return func(s *TaskService) {
+		s.userQuota = max
+	}
+}
+
+// UserQuotaError is returned by CreateBy for users at the quota of
+// WithUserQuota. It is an ErrUserQuotaExceeded holding their usage.
+type UserQuotaError struct {
+	Open  int // Open tasks the user created
+	Limit int
+}
+
+func (e *UserQuotaError) Error() string {
+	return fmt.Sprintf("%v: %d of %d open tasks", ErrUserQuotaExceeded, e.Open, e.Limit)
+}
+
+func (e *UserQuotaError) Unwrap() error {
+	return ErrUserQuotaExceeded
+}
+
+// UserTaskUsage returns the number of open tasks user created and the
+// quota of WithUserQuota, which is 0 without one.
+func (s *TaskService) UserTaskUsage(ctx context.Context, user string) (open, quota int, err error) {
+	completed := false
+	tasks, err := s.store.Find(ctx, store.Query{Completed: &completed})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to find tasks: %w", storeError(err))
+	}
+	for _, task := range tasks {
+		if task.CreatedBy == user {
+			open++
+		}
+	}
+	return open, s.userQuota, nil
+}
+
+// checkUserQuota returns a *UserQuotaError when user has the quota of open
+// tasks. Like checkQuota it is not atomic with the change.
+func (s *TaskService) checkUserQuota(ctx context.Context, user string) error {
+	if s.userQuota == 0 || user == "" {
+		return nil
+	}
+	open, _, err := s.UserTaskUsage(ctx, user)
+	if err != nil {
+		return err
+	}
+	if open >= s.userQuota {
+		return &UserQuotaError{Open: open, Limit: s.userQuota}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestParsePlan(t *testing.T) {
+	plan, err := ParsePlan(" free=2.5/10/100 ")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if plan != (Plan{Name: "free", RateLimit: 2.5, RateBurst: 10, MaxTasks: 100}) {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+
+	for _, s := range []string{"free", "=1/1/1", "free=1/1", "free=1/1/-1", "free=fast/1/1", "free=1/0/1"} {
+		if _, err := ParsePlan(s); err == nil {
+			t.Errorf("ParsePlan(%q): expected error", s)
+		}
+	}
+}
+
+func TestTaskService_TaskQuota(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore(), WithTaskQuota(2))
+
+	if _, err := service.Create(t.Context(), "Water plants", "💡", "", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.CreateMany(t.Context(), []model.Task{{Title: "Sort mail"}, {Title: "Pay rent"}}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded importing over the quota, got %v", err)
+	}
+	if _, err := service.Create(t.Context(), "Sort mail", "💡", "", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Create(t.Context(), "Pay rent", "💡", "", nil); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded going over 2 tasks, got %v", err)
+	}
+
+	if used, quota, err := service.TaskUsage(t.Context()); err != nil || used != 2 || quota != 2 {
+		t.Errorf("expected 2 of 2 tasks used, got %d of %d, %v", used, quota, err)
+	}
+}
+
+func TestTaskService_UserQuota(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore(), WithUserQuota(1))
+
+	first, err := service.CreateBy(t.Context(), "u1", "Water plants", "💡", "", nil)
+	if err != nil || first.CreatedBy != "u1" {
+		t.Fatalf("expected a task created by u1, got %+v, %v", first, err)
+	}
+	var quotaErr *UserQuotaError
+	if _, err := service.CreateBy(t.Context(), "u1", "Sort mail", "💡", "", nil); !errors.As(err, &quotaErr) || !errors.Is(err, ErrUserQuotaExceeded) {
+		t.Fatalf("expected a UserQuotaError going over 1 open task, got %v", err)
+	}
+	if *quotaErr != (UserQuotaError{Open: 1, Limit: 1}) {
+		t.Errorf("expected 1 of 1 open tasks in the error, got %+v", quotaErr)
+	}
+
+	// Other users and anonymous tasks have quotas of their own
+	if _, err := service.CreateBy(t.Context(), "u2", "Sort mail", "💡", "", nil); err != nil {
+		t.Errorf("expected u2 to create a task, got %v", err)
+	}
+	if _, err := service.Create(t.Context(), "Pay rent", "💡", "", nil); err != nil {
+		t.Errorf("expected an anonymous task to be created, got %v", err)
+	}
+
+	// Completing a task makes room
+	if _, err := service.Toggle(t.Context(), first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CreateBy(t.Context(), "u1", "Sort mail again", "💡", "", nil); err != nil {
+		t.Errorf("expected u1 to create a task after completing one, got %v", err)
+	}
+	if open, quota, err := service.UserTaskUsage(t.Context(), "u1"); err != nil || open != 1 || quota != 1 {
+		t.Errorf("expected 1 of 1 open tasks used, got %d of %d, %v", open, quota, err)
+	}
+}
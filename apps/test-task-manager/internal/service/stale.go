@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// DefaultStaleAfter is how long open tasks may go unchanged before they
+// count as stale, for services not configured WithStaleAfter.
+const DefaultStaleAfter = 14 * 24 * time.Hour
+
+// WithStaleAfter makes open tasks count as stale when they have not been
+// changed for d, rather than for DefaultStaleAfter.
+func WithStaleAfter(d time.Duration) Option {
+	return func(s *TaskService) {
+		s.staleAfter = d
+	}
+}
+
+// StaleAfter returns how long open tasks may go unchanged before they
+// count as stale.
+func (s *TaskService) StaleAfter() time.Duration {
+	return s.staleAfter
+}
+
+// StaleCutoff returns the time open tasks last changed before, at now,
+// are stale; see model.Task.ChangedAt.
+func (s *TaskService) StaleCutoff(now time.Time) time.Time {
+	return now.Add(-s.staleAfter)
+}
+
+// IsStale reports whether task is open and has not been changed for the
+// StaleAfter duration at now.
+func (s *TaskService) IsStale(task model.Task, now time.Time) bool {
+	return !task.Completed && task.ChangedAt().Before(s.StaleCutoff(now))
+}
+
+// staleCount returns the number of stale tasks.
+func (s *TaskService) staleCount(ctx context.Context) (int, error) {
+	open, cutoff := false, s.StaleCutoff(time.Now())
+	tasks, err := s.store.Find(ctx, store.Query{Completed: &open, ChangedBefore: &cutoff})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find tasks: %w", storeError(err))
+	}
+	return len(tasks), nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestTaskService_Stale(t *testing.T) {
+	taskStore := store.NewTaskStore()
+	reg := metrics.NewRegistry()
+	service := NewTaskService(taskStore, WithMetrics(reg), WithStaleAfter(7*24*time.Hour))
+
+	old := time.Now().AddDate(0, 0, -10)
+	neglected, _ := taskStore.Create(t.Context(), model.Task{Title: "Neglected", Priority: "📋", Color: "#6c757d", CreatedAt: old})
+	edited, _ := taskStore.Create(t.Context(), model.Task{Title: "Edited", Priority: "📋", Color: "#6c757d", CreatedAt: old})
+	done, _ := taskStore.Create(t.Context(), model.Task{Title: "Done", Priority: "📋", Color: "#6c757d", CreatedAt: old})
+	fresh, _ := service.Create(t.Context(), "Fresh", "", "", nil)
+	edited, _ = service.Update(t.Context(), edited.ID, "Edited again", "", "", nil)
+	done, _ = service.Toggle(t.Context(), done.ID)
+
+	now := time.Now()
+	for _, tt := range []struct {
+		task  model.Task
+		stale bool
+	}{{neglected, true}, {edited, false}, {done, false}, {fresh, false}} {
+		if got := service.IsStale(tt.task, now); got != tt.stale {
+			t.Errorf("%s: expected stale %t, got %t", tt.task.Title, tt.stale, got)
+		}
+	}
+
+	var out strings.Builder
+	reg.Write(&out)
+	if !strings.Contains(out.String(), "tasks_stale{workspace=\"default\"} 1\n") {
+		t.Errorf("expected 1 stale task in the metrics, got:\n%s", out.String())
+	}
+}
@@ -2,10 +2,20 @@
 package service
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/norm"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/palette"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
 )
 
@@ -17,7 +27,7 @@ const (
 	PriorityLow             = "💡" // Not Urgent, Not Important
 	PriorityDefault         = "📋" // Default/Uncategorized
 
-	// Valid color hex codes.
+	// Color hex codes of the default palette, see palette.Default.
 	ColorRed    = "#dc3545"
 	ColorBlue   = "#0d6efd"
 	ColorYellow = "#ffc107"
@@ -27,85 +37,730 @@ const (
 	ColorGrey   = "#6c757d"
 )
 
-// TaskService handles business logic for tasks.
+// Priorities lists the valid priority emoticons.
+var Priorities = []string{
+	PriorityUrgentImportant,
+	PriorityImportant,
+	PriorityUrgent,
+	PriorityLow,
+	PriorityDefault,
+}
+
+// priorityAliases maps names of the priorities, for clients that cannot
+// easily type emoticons, to their emoticon. p1 to p5 follow the order of
+// Priorities.
+var priorityAliases = map[string]string{
+	"urgent": PriorityUrgentImportant,
+	"high":   PriorityImportant,
+	"low":    PriorityLow,
+	"p1":     PriorityUrgentImportant,
+	"p2":     PriorityImportant,
+	"p3":     PriorityUrgent,
+	"p4":     PriorityLow,
+	"p5":     PriorityDefault,
+}
+
+// TitleLimits are the shortest and longest titles of tasks, in characters
+// (Unicode code points, so an emoji counts as one), after sanitizing.
+type TitleLimits struct {
+	Min int `json:"minLength"`
+	Max int `json:"maxLength"`
+}
+
+// DefaultTitleLimits are the title limits of services not configured with
+// others, and of NewTask.
+var DefaultTitleLimits = TitleLimits{Min: 1, Max: 255}
+
+// TaskDefaults are the values tasks are created with when they are given
+// none. The zero value gives them PriorityDefault, the default color of the
+// palette and no due date.
+type TaskDefaults struct {
+	Priority string        // Emoticon
+	Color    string        // Hex code of a color of the palette
+	DueIn    time.Duration // Due date of tasks created without one, from their creation; 0 for none
+}
+
+// NewTaskDefaults returns the defaults of priority, an emoticon or alias,
+// color, a name or hex code of colors, and dueIn, canonicalized. Empty
+// values keep the built-in defaults.
+func NewTaskDefaults(priority, color string, dueIn time.Duration, colors *palette.Palette) (TaskDefaults, error) {
+	d := TaskDefaults{DueIn: dueIn}
+	if priority != "" {
+		p, ok := canonicalPriority(priority)
+		if !ok {
+			return TaskDefaults{}, fmt.Errorf("default priority %q is not a priority", priority)
+		}
+		d.Priority = p
+	}
+	if color != "" {
+		c, ok := canonicalColor(colors, color)
+		if !ok {
+			return TaskDefaults{}, fmt.Errorf("default color %q is not in the palette", color)
+		}
+		d.Color = c
+	}
+	if dueIn < 0 {
+		return TaskDefaults{}, fmt.Errorf("default due in cannot be negative")
+	}
+	return d, nil
+}
+
+// TaskService handles business logic for tasks. Its methods reading or
+// changing tasks take the context of the request or job they serve and
+// pass it on to the store, which gives up once it is canceled or past its
+// deadline; such errors are marked STORE_TIMEOUT.
 type TaskService struct {
-	store *store.TaskStore
+	store     store.Store
+	registry  *metrics.Registry
+	metrics   *taskMetrics
+	workspace string // Labels the metrics
+	titles    TitleLimits
+	palette   *palette.Palette
+	defaults  TaskDefaults
+
+	// uniqueTitles makes Create reject titles of open tasks.
+	uniqueTitles bool
+
+	// wip caps the open tasks; wipWarn lets changes go over the caps.
+	wip     WIPLimits
+	wipWarn bool
+
+	// taskQuota caps the tasks in the store; 0 means no quota.
+	taskQuota int
+	// userQuota caps the open tasks each user created; 0 means no quota.
+	userQuota int
+
+	staleAfter time.Duration
+
+	locks   lockTable
+	history historyTable
+	feed    *changeFeed
+
+	// generation counts the mutations made through this service.
+	generation atomic.Uint64
+
+	observers []func(change string, task model.Task)
+}
+
+// Option configures optional TaskService behavior.
+type Option func(*TaskService)
+
+// WithMetrics registers the task business metrics on the given registry.
+func WithMetrics(reg *metrics.Registry) Option {
+	return func(s *TaskService) {
+		s.registry = reg
+	}
+}
+
+// WithWorkspace labels the metrics of the service with the workspace it
+// serves, rather than with store.DefaultWorkspace, so the services of
+// several workspaces can share a registry.
+func WithWorkspace(name string) Option {
+	return func(s *TaskService) {
+		s.workspace = name
+	}
+}
+
+// WithTitleLimits validates titles against limits rather than the
+// DefaultTitleLimits.
+func WithTitleLimits(limits TitleLimits) Option {
+	return func(s *TaskService) {
+		s.titles = limits
+	}
+}
+
+// WithPalette makes tasks take the colors of p rather than those of
+// palette.Default.
+func WithPalette(p *palette.Palette) Option {
+	return func(s *TaskService) {
+		s.palette = p
+	}
+}
+
+// WithDefaults makes tasks created without a priority, color or due date
+// take those of d, which NewTaskDefaults checks against the palette of
+// WithPalette.
+func WithDefaults(d TaskDefaults) Option {
+	return func(s *TaskService) {
+		s.defaults = d
+	}
+}
+
+// WithUniqueTitles makes Create return ErrDuplicateTitle for tasks with
+// the title of an open task, compared ignoring case.
+func WithUniqueTitles() Option {
+	return func(s *TaskService) {
+		s.uniqueTitles = true
+	}
+}
+
+// WithWIPLimits makes creating, reopening and changing the priority of
+// tasks return ErrWIPLimit when it takes the open tasks over limits, or
+// with warn only count it in the tasks_wip_limit_exceeded_total metric.
+// Imports and escalations are not limited.
+func WithWIPLimits(limits WIPLimits, warn bool) Option {
+	return func(s *TaskService) {
+		s.wip, s.wipWarn = limits, warn
+	}
 }
 
 // NewTaskService creates a new TaskService.
-func NewTaskService(store *store.TaskStore) *TaskService {
-	return &TaskService{store: store}
+func NewTaskService(taskStore store.Store, opts ...Option) *TaskService {
+	s := &TaskService{store: taskStore, workspace: store.DefaultWorkspace, titles: DefaultTitleLimits, palette: palette.Default(), staleAfter: DefaultStaleAfter, feed: newChangeFeed()}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// Metrics are always collected so Stats works without a shared registry.
+	if s.registry == nil {
+		s.registry = metrics.NewRegistry()
+	}
+	s.metrics = newTaskMetrics(s.registry, s.workspace, s.openCount, s.staleCount)
+
+	return s
+}
+
+// Observe makes the service call fn after every task it creates, updates,
+// completes or reopens, with the store event type of the change, such as
+// store.EventTaskCreated. It must be called before the service is used.
+func (s *TaskService) Observe(fn func(change string, task model.Task)) {
+	s.observers = append(s.observers, fn)
+}
+
+func (s *TaskService) changed(change string, tasks ...model.Task) {
+	s.feed.add(change, tasks...)
+	s.notify(change, tasks...)
+}
+
+// notify calls the observers with the changed tasks.
+func (s *TaskService) notify(change string, tasks ...model.Task) {
+	for _, fn := range s.observers {
+		for _, task := range tasks {
+			fn(change, task)
+		}
+	}
 }
 
 // GetAll retrieves all tasks.
-func (s *TaskService) GetAll() []model.Task {
-	return s.store.GetAll()
+func (s *TaskService) GetAll(ctx context.Context) ([]model.Task, error) {
+	tasks, err := s.store.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", storeError(err))
+	}
+	return tasks, nil
+}
+
+// Find retrieves the tasks matching q.
+func (s *TaskService) Find(ctx context.Context, q store.Query) ([]model.Task, error) {
+	q, err := canonicalQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.store.Find(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks: %w", storeError(err))
+	}
+	return tasks, nil
+}
+
+// Each calls fn with the tasks matching q in creation order, like Find,
+// stopping at the first error fn returns, which Each returns. Stores that
+// are a store.Streamer are read a batch at a time, so exports of large
+// stores need not hold all tasks in memory.
+func (s *TaskService) Each(ctx context.Context, q store.Query, fn func(model.Task) error) error {
+	q, err := canonicalQuery(q)
+	if err != nil {
+		return err
+	}
+
+	streamer, ok := s.store.(store.Streamer)
+	if !ok {
+		tasks, err := s.store.Find(ctx, q)
+		if err != nil {
+			return fmt.Errorf("failed to find tasks: %w", storeError(err))
+		}
+		for _, task := range tasks {
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var fnErr error
+	err = streamer.Each(ctx, q, func(task model.Task) error {
+		fnErr = fn(task)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find tasks: %w", storeError(err))
+	}
+	return nil
+}
+
+// canonicalQuery returns q with its priority, which may be a name for it,
+// as emoticon.
+func canonicalQuery(q store.Query) (store.Query, error) {
+	if q.Priority != "" {
+		priority, ok := canonicalPriority(q.Priority)
+		if !ok {
+			return q, ErrInvalidPriority
+		}
+		q.Priority = priority
+	}
+	return q, nil
 }
 
-// Create creates a new task with validation.
-func (s *TaskService) Create(title, priority, color string) (model.Task, error) {
-	// Trim whitespace
-	title = strings.TrimSpace(title)
+// Get returns the task with id.
+func (s *TaskService) Get(ctx context.Context, id string) (model.Task, error) {
+	task, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return model.Task{}, fmt.Errorf("failed to get task: %w", storeError(err))
+	}
+	return task, nil
+}
+
+// Create creates a new task with validation. dueDate is optional.
+func (s *TaskService) Create(ctx context.Context, title, priority, color string, dueDate *time.Time) (model.Task, error) {
+	return s.CreateBy(ctx, "", title, priority, color, dueDate)
+}
+
+// CreateBy creates a new task like Create, recording user, the ID of the
+// user creating it, as its CreatedBy and holding it to the quota of
+// WithUserQuota. An empty user creates the task anonymously.
+func (s *TaskService) CreateBy(ctx context.Context, user, title, priority, color string, dueDate *time.Time) (model.Task, error) {
+	task, err := s.Validate(title, priority, color, dueDate)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if task.DueDate == nil && s.defaults.DueIn > 0 {
+		due := time.Now().Add(s.defaults.DueIn).UTC().Truncate(time.Second)
+		task.DueDate = &due
+	}
+	task.CreatedBy = user
+	if s.uniqueTitles {
+		if err := s.checkDuplicateTitle(ctx, task.Title); err != nil {
+			return model.Task{}, err
+		}
+	}
+	if err := s.checkWIP(ctx, task.Priority, 1, nil); err != nil {
+		return model.Task{}, err
+	}
+	if err := s.checkQuota(ctx, 1); err != nil {
+		return model.Task{}, err
+	}
+	if err := s.checkUserQuota(ctx, user); err != nil {
+		return model.Task{}, err
+	}
+
+	task, err = s.store.Create(ctx, task)
+	if err != nil {
+		return model.Task{}, fmt.Errorf("failed to create task: %w", storeError(err))
+	}
+	s.metrics.created.Inc()
+	s.generation.Add(1)
+	s.changed(store.EventTaskCreated, task)
+	return task, nil
+}
+
+// checkDuplicateTitle returns ErrDuplicateTitle when an open task has title,
+// which is sanitized. Titles stored before sanitizing was added may still
+// have surrounding whitespace, so it is trimmed from those. The check and
+// the create that follows are not atomic: concurrent requests may still
+// create the same title twice.
+func (s *TaskService) checkDuplicateTitle(ctx context.Context, title string) error {
+	open := false
+	tasks, err := s.store.Find(ctx, store.Query{Completed: &open})
+	if err != nil {
+		return fmt.Errorf("failed to find tasks: %w", storeError(err))
+	}
+	for _, task := range tasks {
+		if strings.EqualFold(strings.TrimSpace(task.Title), title) {
+			return fmt.Errorf("%w: task %s", ErrDuplicateTitle, task.ID)
+		}
+	}
+	return nil
+}
 
+// CreateMany validates and creates tasks in one batch: either all of them
+// are created or, when one is invalid or the store fails, none. Only the
+// fields Validate takes and the completion status are used.
+func (s *TaskService) CreateMany(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	valid := make([]model.Task, len(tasks))
+	completed := 0
+	now := time.Now()
+	for i, t := range tasks {
+		task, err := s.Validate(t.Title, t.Priority, t.Color, t.DueDate)
+		if err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		if t.Completed {
+			task.Completed, task.CompletedAt = true, &now
+			completed++
+		}
+		valid[i] = task
+	}
+	if err := s.checkQuota(ctx, len(valid)); err != nil {
+		return nil, err
+	}
+
+	created, err := s.store.CreateMany(ctx, valid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tasks: %w", storeError(err))
+	}
+	s.metrics.created.Add(float64(len(created)))
+	s.metrics.completed.Add(float64(completed))
+	s.generation.Add(1)
+	s.changed(store.EventTaskCreated, created...)
+	return created, nil
+}
+
+// TitleLimits returns the limits titles are validated against.
+func (s *TaskService) TitleLimits() TitleLimits {
+	return s.titles
+}
+
+// Palette returns the colors tasks can have.
+func (s *TaskService) Palette() *palette.Palette {
+	return s.palette
+}
+
+// Defaults returns the values tasks are created with when they are given
+// none, with the built-in priority and color filled in.
+func (s *TaskService) Defaults() TaskDefaults {
+	d := s.defaults
+	d.Priority = cmp.Or(d.Priority, PriorityDefault)
+	d.Color = cmp.Or(d.Color, defaultColor(s.palette))
+	return d
+}
+
+// Validate validates the fields of a new task and returns the task Create
+// would store, with the default priority and color applied but without ID,
+// creation time and default due date, which only Create sets. It lets
+// importers check their input without touching the store.
+func (s *TaskService) Validate(title, priority, color string, dueDate *time.Time) (model.Task, error) {
+	return newTask(s.titles, s.palette, s.defaults, title, priority, color, dueDate)
+}
+
+// NewTask validates the fields of a new task like Validate does, with the
+// DefaultTitleLimits, the colors of palette.Default and the built-in
+// defaults.
+func NewTask(title, priority, color string, dueDate *time.Time) (model.Task, error) {
+	return newTask(DefaultTitleLimits, palette.Default(), TaskDefaults{}, title, priority, color, dueDate)
+}
+
+func newTask(titles TitleLimits, colors *palette.Palette, defaults TaskDefaults, title, priority, color string, dueDate *time.Time) (model.Task, error) {
 	// Validate title
+	if !utf8.ValidString(title) {
+		return model.Task{}, ErrInvalidTitle
+	}
+	title = sanitizeTitle(title)
+
 	if title == "" {
 		return model.Task{}, ErrEmptyTitle
 	}
 
-	if len(title) > 255 {
-		return model.Task{}, ErrTitleTooLong
+	// Lengths are counted in runes: limits in bytes would allow fewer
+	// characters of accented or non-Latin titles.
+	if n := utf8.RuneCountInString(title); n < titles.Min {
+		return model.Task{}, fmt.Errorf("%w: at least %d characters", ErrTitleTooShort, titles.Min)
+	} else if n > titles.Max {
+		return model.Task{}, fmt.Errorf("%w: at most %d characters", ErrTitleTooLong, titles.Max)
 	}
 
 	// Apply defaults if not provided
 	if priority == "" {
-		priority = PriorityDefault
+		priority = cmp.Or(defaults.Priority, PriorityDefault)
 	}
 	if color == "" {
-		color = ColorGrey
+		color = cmp.Or(defaults.Color, defaultColor(colors))
 	}
 
 	// Validate priority
-	if !isValidPriority(priority) {
+	priority, ok := canonicalPriority(priority)
+	if !ok {
 		return model.Task{}, ErrInvalidPriority
 	}
 
 	// Validate color
-	if !isValidColor(color) {
+	color, ok = canonicalColor(colors, color)
+	if !ok {
 		return model.Task{}, ErrInvalidColor
 	}
 
-	// Create task with priority and color
-	task := s.store.Create(title, priority, color)
-	return task, nil
+	return model.Task{
+		Title:    title,
+		Priority: priority,
+		Color:    color,
+		DueDate:  dueDate,
+	}, nil
+}
+
+// sanitizeTitle cleans a title the same way whichever entry point it came
+// through, the API, the pages, imports or CalDAV: it is composed to Unicode
+// NFC, so titles that look the same compare the same, line breaks and tabs
+// become spaces, other control characters are dropped, and surrounding
+// whitespace is trimmed. Titles are stored as plain text; templates and
+// encoders escape them for the HTML or JSON they are shown in.
+func sanitizeTitle(title string) string {
+	title = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r) && unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, title)
+	return strings.TrimSpace(norm.NFC(title))
 }
 
 // Toggle toggles task completion status.
-func (s *TaskService) Toggle(id string) (model.Task, error) {
-	task, err := s.store.Toggle(id)
+func (s *TaskService) Toggle(ctx context.Context, id string) (model.Task, error) {
+	if !s.wip.empty() {
+		task, err := s.Get(ctx, id)
+		if err != nil {
+			return model.Task{}, err
+		}
+		if task.Completed {
+			if err := s.checkWIP(ctx, task.Priority, 1, nil); err != nil {
+				return model.Task{}, err
+			}
+		}
+	}
+
+	task, err := s.store.Toggle(ctx, id)
 	if err != nil {
-		return model.Task{}, fmt.Errorf("failed to toggle task: %w", err)
+		return model.Task{}, fmt.Errorf("failed to toggle task: %w", storeError(err))
+	}
+	s.metrics.observeToggle(task)
+	s.generation.Add(1)
+	if task.Completed {
+		s.changed(store.EventTaskCompleted, task)
+	} else {
+		s.changed(store.EventTaskReopened, task)
 	}
 	return task, nil
 }
 
+// Update replaces the title, priority, color and due date of a task, with
+// the validation and defaults of Create. The completion status is kept.
+func (s *TaskService) Update(ctx context.Context, id, title, priority, color string, dueDate *time.Time) (model.Task, error) {
+	fields, err := s.Validate(title, priority, color, dueDate)
+	if err != nil {
+		return model.Task{}, err
+	}
+
+	task, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return model.Task{}, fmt.Errorf("failed to get task: %w", storeError(err))
+	}
+	if !task.Completed {
+		if err := s.checkWIP(ctx, fields.Priority, 0, map[string]int{task.Priority: 1}); err != nil {
+			return model.Task{}, err
+		}
+	}
+	task.Title, task.Priority, task.Color, task.DueDate = fields.Title, fields.Priority, fields.Color, fields.DueDate
+	task, err = s.store.Update(ctx, task)
+	if err != nil {
+		return model.Task{}, fmt.Errorf("failed to update task: %w", storeError(err))
+	}
+	s.generation.Add(1)
+	s.changed(store.EventTaskUpdated, task)
+	return task, nil
+}
+
+// Reprioritize sets the priority and color of the tasks matching q in one
+// batch, all or none of them, and returns the changed tasks. An empty
+// priority or color is left as it is. When a matching task is locked by
+// another owner than owner, no task is changed and ErrTaskLocked is
+// returned.
+func (s *TaskService) Reprioritize(ctx context.Context, q store.Query, priority, color, owner string) ([]model.Task, error) {
+	if priority != "" {
+		var ok bool
+		if priority, ok = canonicalPriority(priority); !ok {
+			return nil, ErrInvalidPriority
+		}
+	}
+	if color != "" {
+		var ok bool
+		if color, ok = canonicalColor(s.palette, color); !ok {
+			return nil, ErrInvalidColor
+		}
+	}
+
+	matching, err := s.Find(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	moved := make(map[string]int)
+	for _, task := range matching {
+		if err := s.CheckLock(task.ID, owner); err != nil {
+			return nil, fmt.Errorf("task %s: %w", task.ID, err)
+		}
+		if !task.Completed {
+			moved[task.Priority]++
+		}
+	}
+	if priority != "" {
+		if err := s.checkWIP(ctx, priority, 0, moved); err != nil {
+			return nil, err
+		}
+	}
+	if q.Priority != "" {
+		q.Priority, _ = canonicalPriority(q.Priority) // Checked by Find
+	}
+
+	tasks, err := s.store.Reassign(ctx, q, priority, color)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reprioritize tasks: %w", storeError(err))
+	}
+	s.generation.Add(1)
+	s.changed(store.EventTaskUpdated, tasks...)
+	return tasks, nil
+}
+
+// Merge folds the task with sourceID into the task with targetID, for
+// cleaning up duplicates: the target gets the more urgent priority and the
+// earlier due date of both, and the source is completed, so it stays
+// linked to the UID it was imported or synced with. The History of the
+// target gets the transitions of the source. Tasks have no other fields to
+// combine. Either task being locked by another owner than owner
+// returns ErrTaskLocked. The target is changed before the source is
+// completed; when completing fails, the changed target is kept.
+func (s *TaskService) Merge(ctx context.Context, targetID, sourceID, owner string) (target, source model.Task, err error) {
+	if targetID == sourceID {
+		return model.Task{}, model.Task{}, ErrMergeWithSelf
+	}
+	for _, id := range []string{targetID, sourceID} {
+		if err := s.CheckLock(id, owner); err != nil {
+			return model.Task{}, model.Task{}, err
+		}
+	}
+	if target, err = s.Get(ctx, targetID); err != nil {
+		return model.Task{}, model.Task{}, err
+	}
+	if source, err = s.Get(ctx, sourceID); err != nil {
+		return model.Task{}, model.Task{}, err
+	}
+
+	if slices.Index(Priorities, source.Priority) < slices.Index(Priorities, target.Priority) {
+		// An open source is completed below, making room in its priority
+		// for the target
+		if !target.Completed && source.Completed {
+			if err := s.checkWIP(ctx, source.Priority, 0, map[string]int{target.Priority: 1}); err != nil {
+				return model.Task{}, model.Task{}, err
+			}
+		}
+		target.Priority = source.Priority
+	}
+	if source.DueDate != nil && (target.DueDate == nil || source.DueDate.Before(*target.DueDate)) {
+		target.DueDate = source.DueDate
+	}
+	target, err = s.store.Update(ctx, target)
+	if err != nil {
+		return model.Task{}, model.Task{}, fmt.Errorf("failed to update task: %w", storeError(err))
+	}
+	s.history.merge(target.ID, source.ID)
+	s.generation.Add(1)
+	s.changed(store.EventTaskUpdated, target)
+
+	if !source.Completed {
+		if source, err = s.Toggle(ctx, sourceID); err != nil {
+			return model.Task{}, model.Task{}, err
+		}
+	}
+	return target, source, nil
+}
+
 // Delete removes a task.
-func (s *TaskService) Delete(id string) error {
-	if err := s.store.Delete(id); err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
+func (s *TaskService) Delete(ctx context.Context, id string) error {
+	// Read first, so the change feed holds the task as it was
+	task, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", storeError(err))
+	}
+	if err := s.store.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete task: %w", storeError(err))
 	}
+	s.feed.add(store.EventTaskDeleted, task)
+	s.dropLock(id)
+	s.history.drop(id)
+	s.metrics.deleted.Inc()
+	s.generation.Add(1)
 	return nil
 }
 
+// Tombstones returns the tombstones of the tasks deleted at or after since,
+// oldest first, so clients syncing tasks learn about deletions. It returns
+// store.ErrTombstonesExpired when since is before the tombstones the store
+// keeps, or the store keeps none.
+func (s *TaskService) Tombstones(ctx context.Context, since time.Time) ([]store.Tombstone, error) {
+	tombstoner, ok := s.store.(store.Tombstoner)
+	if !ok {
+		return nil, store.ErrTombstonesExpired
+	}
+	tombstones, err := tombstoner.Tombstones(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tombstones: %w", storeError(err))
+	}
+	return tombstones, nil
+}
+
+// Generation returns a counter that changes with every mutation made
+// through the service, for caches of derived data. Changes made to a shared
+// store by other processes are not counted.
+func (s *TaskService) Generation() uint64 {
+	return s.generation.Load()
+}
+
+// Stats returns task activity counters and the current number of open tasks.
+func (s *TaskService) Stats(ctx context.Context) (Stats, error) {
+	open, err := s.openCount(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	latency := s.metrics.completionLatency.Snapshot()
+
+	stats := Stats{
+		Created:   int64(s.metrics.created.Value()),
+		Completed: int64(s.metrics.completed.Value()),
+		Deleted:   int64(s.metrics.deleted.Value()),
+		Open:      open,
+		CompletionLatency: CompletionLatency{
+			Count: latency.Count,
+		},
+	}
+	if latency.Count > 0 {
+		stats.CompletionLatency.AverageSeconds = latency.Sum / float64(latency.Count)
+	}
+
+	return stats, nil
+}
+
+// openCount returns the number of tasks that are not completed.
+func (s *TaskService) openCount(ctx context.Context) (int, error) {
+	tasks, err := s.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	open := 0
+	for _, task := range tasks {
+		if !task.Completed {
+			open++
+		}
+	}
+	return open, nil
+}
+
 // isValidPriority checks if the given priority emoticon is valid.
 func isValidPriority(p string) bool {
-	validPriorities := []string{
-		PriorityUrgentImportant,
-		PriorityImportant,
-		PriorityUrgent,
-		PriorityLow,
-		PriorityDefault,
-	}
-	for _, valid := range validPriorities {
+	for _, valid := range Priorities {
 		if p == valid {
 			return true
 		}
@@ -113,16 +768,29 @@ func isValidPriority(p string) bool {
 	return false
 }
 
-// isValidColor checks if the given color hex code is valid.
-func isValidColor(c string) bool {
-	validColors := []string{
-		ColorRed, ColorBlue, ColorYellow, ColorGreen,
-		ColorPurple, ColorOrange, ColorGrey,
+// canonicalPriority returns the emoticon of p, which is either an emoticon
+// or one of the priorityAliases in any case.
+func canonicalPriority(p string) (string, bool) {
+	if isValidPriority(p) {
+		return p, true
 	}
-	for _, valid := range validColors {
-		if c == valid {
-			return true
-		}
+	priority, ok := priorityAliases[strings.ToLower(p)]
+	return priority, ok
+}
+
+// canonicalColor returns the hex code tasks are stored with for c, the
+// name or a hex code of a color of colors, in any case, and whether it is
+// one.
+func canonicalColor(colors *palette.Palette, c string) (string, bool) {
+	color, ok := colors.Lookup(c)
+	return color.Light, ok
+}
+
+// defaultColor returns the color of tasks created without one: grey, or
+// the first color of palettes without it.
+func defaultColor(colors *palette.Palette) string {
+	if color, ok := colors.Lookup(ColorGrey); ok {
+		return color.Light
 	}
-	return false
+	return colors.Codes()[0]
 }
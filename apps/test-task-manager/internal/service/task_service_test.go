@@ -1,17 +1,26 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"gitlab.com/btcdirect-api/test-task-manager/internal/apperr"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/palette"
 	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/storetest"
 )
 
 func TestTaskService_CreateWithPriority(t *testing.T) {
 	taskStore := store.NewTaskStore()
 	service := NewTaskService(taskStore)
 
-	task, err := service.Create("Test task", "🔥", "#dc3545")
+	task, err := service.Create(t.Context(), "Test task", "🔥", "#dc3545", nil)
 
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
@@ -31,7 +40,7 @@ func TestTaskService_CreateWithDefaults(t *testing.T) {
 	taskStore := store.NewTaskStore()
 	service := NewTaskService(taskStore)
 
-	task, err := service.Create("Test task", "", "")
+	task, err := service.Create(t.Context(), "Test task", "", "", nil)
 
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
@@ -48,18 +57,37 @@ func TestTaskService_CreateInvalidPriority(t *testing.T) {
 	taskStore := store.NewTaskStore()
 	service := NewTaskService(taskStore)
 
-	_, err := service.Create("Test task", "❌", "#dc3545")
+	_, err := service.Create(t.Context(), "Test task", "❌", "#dc3545", nil)
 
 	if !errors.Is(err, ErrInvalidPriority) {
 		t.Errorf("expected ErrInvalidPriority, got %v", err)
 	}
 }
 
+func TestTaskService_PriorityAliases(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+
+	for alias, want := range map[string]string{"urgent": "🔥", "High": "⭐", "LOW": "💡", "p3": "⚡", "P5": "📋"} {
+		task, err := service.Create(t.Context(), "Test task", alias, "", nil)
+		if err != nil || task.Priority != want {
+			t.Errorf("Create with priority %q: expected %s, got %q (%v)", alias, want, task.Priority, err)
+		}
+	}
+
+	tasks, err := service.Find(t.Context(), store.Query{Priority: "p1"})
+	if err != nil || len(tasks) != 1 || tasks[0].Priority != "🔥" {
+		t.Errorf("expected the p1 filter to find the 🔥 task, got %+v (%v)", tasks, err)
+	}
+	if _, err := service.SetPriority(t.Context(), tasks[0].ID, "p6"); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("expected ErrInvalidPriority for p6, got %v", err)
+	}
+}
+
 func TestTaskService_CreateInvalidColor(t *testing.T) {
 	taskStore := store.NewTaskStore()
 	service := NewTaskService(taskStore)
 
-	_, err := service.Create("Test task", "🔥", "#invalid")
+	_, err := service.Create(t.Context(), "Test task", "🔥", "#invalid", nil)
 
 	if !errors.Is(err, ErrInvalidColor) {
 		t.Errorf("expected ErrInvalidColor, got %v", err)
@@ -70,7 +98,7 @@ func TestTaskService_CreateEmptyTitle(t *testing.T) {
 	taskStore := store.NewTaskStore()
 	service := NewTaskService(taskStore)
 
-	_, err := service.Create("", "🔥", "#dc3545")
+	_, err := service.Create(t.Context(), "", "🔥", "#dc3545", nil)
 
 	if !errors.Is(err, ErrEmptyTitle) {
 		t.Errorf("expected ErrEmptyTitle, got %v", err)
@@ -86,13 +114,263 @@ func TestTaskService_CreateTitleTooLong(t *testing.T) {
 		longTitle[i] = 'a'
 	}
 
-	_, err := service.Create(string(longTitle), "🔥", "#dc3545")
+	_, err := service.Create(t.Context(), string(longTitle), "🔥", "#dc3545", nil)
 
 	if !errors.Is(err, ErrTitleTooLong) {
 		t.Errorf("expected ErrTitleTooLong, got %v", err)
 	}
 }
 
+func TestTaskService_TitleLimits(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore(), WithTitleLimits(TitleLimits{Min: 3, Max: 5}))
+
+	tests := []struct {
+		title   string
+		wantErr error
+	}{
+		{"ab", ErrTitleTooShort},
+		{"abc", nil},
+		{"ab\u0301c", nil},       // Composed to three characters
+		{"🔥🔥🔥🔥🔥", nil},           // Five characters of four bytes
+		{"\u00e9t\u00e9s!", nil}, // Five characters of seven bytes
+		{"abcdef", ErrTitleTooLong},
+	}
+	for _, tt := range tests {
+		_, err := service.Create(t.Context(), tt.title, "", "", nil)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Create(%q): expected %v, got %v", tt.title, tt.wantErr, err)
+		}
+	}
+	if got := service.TitleLimits(); got != (TitleLimits{Min: 3, Max: 5}) {
+		t.Errorf("expected the configured limits, got %+v", got)
+	}
+}
+
+func TestTaskService_UniqueTitles(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore(), WithUniqueTitles())
+
+	task, err := service.Create(t.Context(), "Buy milk", "", "", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Create(t.Context(), "  buy MILK ", "", "", nil); !errors.Is(err, ErrDuplicateTitle) {
+		t.Errorf("expected ErrDuplicateTitle, got %v", err)
+	}
+	if apperr.CodeOf(ErrDuplicateTitle) != apperr.DuplicateTitle {
+		t.Errorf("expected code %s", apperr.DuplicateTitle)
+	}
+
+	// Completed tasks do not count
+	if _, err := service.Toggle(t.Context(), task.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Create(t.Context(), "Buy milk", "", "", nil); err != nil {
+		t.Errorf("expected the title of a completed task to be allowed, got %v", err)
+	}
+
+	// Without the option titles may repeat
+	plain := NewTaskService(store.NewTaskStore())
+	for range 2 {
+		if _, err := plain.Create(t.Context(), "Buy milk", "", "", nil); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	}
+}
+
+func TestTaskService_Reprioritize(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	for _, priority := range []string{"🔥", "🔥", "💡"} {
+		if _, err := service.Create(t.Context(), "Task", priority, "", nil); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	generation := service.Generation()
+
+	tasks, err := service.Reprioritize(t.Context(), store.Query{Priority: "urgent"}, "high", "", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Priority != PriorityImportant || tasks[0].Color != ColorGrey {
+		t.Errorf("expected the 🔥 tasks to become ⭐, got %+v", tasks)
+	}
+	if service.Generation() == generation {
+		t.Error("expected the generation to change")
+	}
+
+	if _, err := service.Reprioritize(t.Context(), store.Query{}, "p9", "", ""); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("expected ErrInvalidPriority, got %v", err)
+	}
+	if _, err := service.Reprioritize(t.Context(), store.Query{}, "", "#000000", ""); !errors.Is(err, ErrInvalidColor) {
+		t.Errorf("expected ErrInvalidColor, got %v", err)
+	}
+
+	// A locked task keeps all matching tasks from changing
+	if _, err := service.Lock(t.Context(), tasks[1].ID, "user:alice", "", 0); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Reprioritize(t.Context(), store.Query{Priority: PriorityImportant}, "", ColorRed, "user:bob"); !errors.Is(err, ErrTaskLocked) {
+		t.Errorf("expected ErrTaskLocked, got %v", err)
+	}
+	if task, _ := service.Get(t.Context(), tasks[0].ID); task.Color != ColorGrey {
+		t.Errorf("expected no task to change, got %+v", task)
+	}
+	if tasks, err := service.Reprioritize(t.Context(), store.Query{Priority: PriorityImportant}, "", ColorRed, "user:alice"); err != nil || len(tasks) != 2 {
+		t.Errorf("expected the holder to change both tasks, got %+v, %v", tasks, err)
+	}
+}
+
+func TestTaskService_Merge(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	early, late := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	target, _ := service.Create(t.Context(), "Ship release", "⭐", "", &late)
+	source, _ := service.Create(t.Context(), "Ship the release", "🔥", "", &early)
+	for _, change := range []struct{ id, priority string }{{source.ID, "⚡"}, {target.ID, "💡"}, {source.ID, "🔥"}} {
+		if _, err := service.ChangePriority(t.Context(), change.id, change.priority, "", "u1"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	merged, closed, err := service.Merge(t.Context(), target.ID, source.ID, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if merged.Title != "Ship release" || merged.Priority != PriorityUrgentImportant || !merged.DueDate.Equal(early) || merged.Completed {
+		t.Errorf("expected the target with the priority and due date of the source, got %+v", merged)
+	}
+	if !closed.Completed {
+		t.Errorf("expected the source to be completed, got %+v", closed)
+	}
+
+	// The target gets the priority history of the source, oldest first
+	wantHistory := func() {
+		t.Helper()
+		history, _ := service.History(t.Context(), target.ID)
+		var got []string
+		for _, transition := range history {
+			got = append(got, transition.From+"→"+transition.To)
+		}
+		if want := []string{"🔥→⚡", "⭐→💡", "⚡→🔥"}; !slices.Equal(got, want) {
+			t.Errorf("expected the target history %v, got %v", want, got)
+		}
+	}
+	wantHistory()
+
+	// Merging a completed source keeps it completed
+	if _, closed, err = service.Merge(t.Context(), target.ID, source.ID, ""); err != nil || !closed.Completed {
+		t.Errorf("expected the source to stay completed, got %+v, %v", closed, err)
+	}
+	wantHistory() // Without the transitions of the source twice
+
+	if _, _, err := service.Merge(t.Context(), target.ID, target.ID, ""); !errors.Is(err, ErrMergeWithSelf) {
+		t.Errorf("expected ErrMergeWithSelf, got %v", err)
+	}
+	if _, _, err := service.Merge(t.Context(), target.ID, "404", ""); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+	if _, err := service.Lock(t.Context(), source.ID, "user:alice", "", 0); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, _, err := service.Merge(t.Context(), target.ID, source.ID, "user:bob"); !errors.Is(err, ErrTaskLocked) {
+		t.Errorf("expected ErrTaskLocked, got %v", err)
+	}
+}
+
+func TestTaskService_Each(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	for _, title := range []string{"Fix outage", "Plan roadmap", "Fix another outage"} {
+		priority := "🔥"
+		if title == "Plan roadmap" {
+			priority = "⭐"
+		}
+		if _, err := service.Create(t.Context(), title, priority, "", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var titles []string
+	err := service.Each(t.Context(), store.Query{Priority: "urgent"}, func(task model.Task) error {
+		titles = append(titles, task.Title)
+		return nil
+	})
+	if err != nil || len(titles) != 2 || titles[0] != "Fix outage" || titles[1] != "Fix another outage" {
+		t.Errorf("expected the 🔥 tasks in creation order, got %v, %v", titles, err)
+	}
+
+	stop := errors.New("stop")
+	if err := service.Each(t.Context(), store.Query{}, func(model.Task) error { return stop }); err != stop {
+		t.Errorf("expected the error of fn, got %v", err)
+	}
+	if err := service.Each(t.Context(), store.Query{Priority: "none"}, func(model.Task) error { return nil }); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("expected ErrInvalidPriority, got %v", err)
+	}
+}
+
+func TestNewTask_SanitizesTitle(t *testing.T) {
+	tests := map[string]string{
+		"Cafe\u0301 cr\u00e8me": "Caf\u00e9 cr\u00e8me", // Composed to NFC
+		"Buy\nmilk\tand eggs":   "Buy milk and eggs",
+		"a\x00b\x7f":            "ab",
+		"\u200b Zero width \r":  "\u200b Zero width", // Format characters are kept
+		"<b>Bold</b> & co":      "<b>Bold</b> & co",  // Escaped when shown, not stored escaped
+	}
+	for title, want := range tests {
+		task, err := NewTask(title, "", "", nil)
+		if err != nil || task.Title != want {
+			t.Errorf("NewTask(%+q): expected title %+q, got %+q (%v)", title, want, task.Title, err)
+		}
+	}
+	if _, err := NewTask("\xff\xfe", "", "", nil); !errors.Is(err, ErrInvalidTitle) {
+		t.Errorf("expected ErrInvalidTitle for invalid UTF-8, got %v", err)
+	}
+}
+
+func TestNewTask_DoesNotTouchStore(t *testing.T) {
+	taskStore := store.NewTaskStore()
+	service := NewTaskService(taskStore)
+
+	task, err := NewTask("  Planned task ", "", "", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if task.Title != "Planned task" || task.Priority != PriorityDefault || task.Color != ColorGrey {
+		t.Errorf("expected trimmed title and defaults, got %+v", task)
+	}
+	if _, err := NewTask("Planned task", "nope", "", nil); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("expected ErrInvalidPriority, got %v", err)
+	}
+
+	tasks, _ := service.GetAll(t.Context())
+	if len(tasks) != 0 {
+		t.Errorf("expected an empty store, got %d task(s)", len(tasks))
+	}
+}
+
+func TestTaskService_CreateMany(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+
+	_, err := service.CreateMany(t.Context(), []model.Task{{Title: "valid"}, {Title: "invalid", Priority: "nope"}})
+	if !errors.Is(err, ErrInvalidPriority) {
+		t.Fatalf("expected ErrInvalidPriority, got %v", err)
+	}
+	if tasks, _ := service.GetAll(t.Context()); len(tasks) != 0 {
+		t.Fatalf("expected no task to be created from an invalid batch, got %d", len(tasks))
+	}
+
+	created, err := service.CreateMany(t.Context(), []model.Task{{Title: " first "}, {Title: "second", Priority: PriorityUrgent, Completed: true}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(created) != 2 || created[0].Title != "first" || created[0].Priority != PriorityDefault {
+		t.Fatalf("expected validated tasks in order, got %+v", created)
+	}
+	if !created[1].Completed || created[1].CompletedAt == nil {
+		t.Errorf("expected the second task to be completed, got %+v", created[1])
+	}
+	if service.Generation() != 1 {
+		t.Errorf("expected a batch to bump the generation once, got %d", service.Generation())
+	}
+}
+
 func TestIsValidPriority(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -119,30 +397,218 @@ func TestIsValidPriority(t *testing.T) {
 	}
 }
 
-func TestIsValidColor(t *testing.T) {
+func TestCanonicalColor(t *testing.T) {
 	tests := []struct {
 		name  string
 		color string
-		want  bool
+		want  string // Empty when invalid
 	}{
-		{"red", "#dc3545", true},
-		{"blue", "#0d6efd", true},
-		{"yellow", "#ffc107", true},
-		{"green", "#28a745", true},
-		{"purple", "#6f42c1", true},
-		{"orange", "#fd7e14", true},
-		{"grey", "#6c757d", true},
-		{"invalid hex", "#invalid", false},
-		{"empty string", "", false},
-		{"random text", "red", false},
+		{"red", "#dc3545", ColorRed},
+		{"blue", "#0d6efd", ColorBlue},
+		{"yellow", "#ffc107", ColorYellow},
+		{"green", "#28a745", ColorGreen},
+		{"purple", "#6f42c1", ColorPurple},
+		{"orange", "#fd7e14", ColorOrange},
+		{"grey", "#6c757d", ColorGrey},
+		{"upper case hex", "#DC3545", ColorRed},
+		{"name", "red", ColorRed},
+		{"upper case name", "Purple", ColorPurple},
+		{"dark variant", "#6ea8fe", ColorBlue},
+		{"invalid hex", "#invalid", ""},
+		{"empty string", "", ""},
+		{"random text", "teal", ""},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := isValidColor(tt.color)
-			if got != tt.want {
-				t.Errorf("isValidColor(%q) = %v, want %v", tt.color, got, tt.want)
+			got, ok := canonicalColor(palette.Default(), tt.color)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("canonicalColor(%q) = %q, %v, want %q", tt.color, got, ok, tt.want)
 			}
 		})
 	}
 }
+
+func TestTaskService_WithPalette(t *testing.T) {
+	colors, err := palette.Parse([]string{"ink=#1f2937/#e5e7eb", "sky=#0284c7/#7dd3fc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewTaskService(store.NewTaskStore(), WithPalette(colors))
+
+	task, err := service.Create(t.Context(), "Default color", "", "", nil)
+	if err != nil || task.Color != "#1f2937" {
+		t.Fatalf("expected the first color of palettes without grey, got %q (%v)", task.Color, err)
+	}
+	task, err = service.Create(t.Context(), "Named color", "", "Sky", nil)
+	if err != nil || task.Color != "#0284c7" {
+		t.Fatalf("expected the light code of sky, got %q (%v)", task.Color, err)
+	}
+	if _, err := service.Create(t.Context(), "Default palette color", "", ColorRed, nil); !errors.Is(err, ErrInvalidColor) {
+		t.Errorf("expected colors of other palettes to be rejected, got %v", err)
+	}
+}
+
+func TestTaskService_WithDefaults(t *testing.T) {
+	defaults, err := NewTaskDefaults("p2", "blue", 48*time.Hour, palette.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaults.Priority != PriorityImportant || defaults.Color != "#0d6efd" {
+		t.Fatalf("expected canonical defaults, got %+v", defaults)
+	}
+	service := NewTaskService(store.NewTaskStore(), WithDefaults(defaults))
+
+	before := time.Now()
+	task, err := service.Create(t.Context(), "Defaults", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Priority != PriorityImportant || task.Color != "#0d6efd" {
+		t.Errorf("expected the configured priority and color, got %+v", task)
+	}
+	if task.DueDate == nil || task.DueDate.Before(before.Add(47*time.Hour)) || task.DueDate.After(time.Now().Add(48*time.Hour)) {
+		t.Errorf("expected a due date in 48h, got %v", task.DueDate)
+	}
+
+	due := time.Date(2030, 1, 1, 17, 0, 0, 0, time.UTC)
+	task, err = service.Create(t.Context(), "Given", PriorityLow, "green", &due)
+	if err != nil || task.Priority != PriorityLow || task.Color != "#28a745" || !task.DueDate.Equal(due) {
+		t.Errorf("expected given values to be kept, got %+v (%v)", task, err)
+	}
+	if imported, _ := service.Validate("Imported", "", "", nil); imported.DueDate != nil {
+		t.Errorf("expected no default due date outside Create, got %v", imported.DueDate)
+	}
+
+	if got := NewTaskService(store.NewTaskStore()).Defaults(); got != (TaskDefaults{Priority: PriorityDefault, Color: ColorGrey}) {
+		t.Errorf("expected the built-in defaults, got %+v", got)
+	}
+	for _, bad := range [][2]string{{"p9", ""}, {"", "teal"}} {
+		if _, err := NewTaskDefaults(bad[0], bad[1], 0, palette.Default()); err == nil {
+			t.Errorf("expected defaults %q to be rejected", bad)
+		}
+	}
+	if _, err := NewTaskDefaults("", "", -time.Hour, palette.Default()); err == nil {
+		t.Error("expected a negative due in to be rejected")
+	}
+}
+
+func TestTaskService_Stats(t *testing.T) {
+	taskStore := store.NewTaskStore()
+	service := NewTaskService(taskStore)
+
+	first, _ := service.Create(t.Context(), "First", "", "", nil)
+	second, _ := service.Create(t.Context(), "Second", "", "", nil)
+	if _, err := service.Toggle(t.Context(), first.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := service.Delete(t.Context(), second.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	service.Create(t.Context(), "Third", "", "", nil)
+
+	stats, err := service.Stats(t.Context())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if stats.Created != 3 {
+		t.Errorf("expected 3 created, got %d", stats.Created)
+	}
+	if stats.Completed != 1 {
+		t.Errorf("expected 1 completed, got %d", stats.Completed)
+	}
+	if stats.Deleted != 1 {
+		t.Errorf("expected 1 deleted, got %d", stats.Deleted)
+	}
+	if stats.Open != 1 {
+		t.Errorf("expected 1 open, got %d", stats.Open)
+	}
+	if stats.CompletionLatency.Count != 1 {
+		t.Errorf("expected 1 completion latency observation, got %d", stats.CompletionLatency.Count)
+	}
+}
+
+func TestTaskService_WorkspaceStats(t *testing.T) {
+	reg := metrics.NewRegistry()
+	defaults := NewTaskService(store.NewTaskStore(), WithMetrics(reg))
+	team := NewTaskService(store.NewTaskStore(), WithMetrics(reg), WithWorkspace("team"))
+
+	defaults.Create(t.Context(), "Default", "", "", nil)
+	for _, title := range []string{"First", "Second"} {
+		team.Create(t.Context(), title, "", "", nil)
+	}
+
+	for _, tt := range []struct {
+		service *TaskService
+		want    int
+	}{{defaults, 1}, {team, 2}} {
+		stats, err := tt.service.Stats(t.Context())
+		if err != nil || stats.Created != int64(tt.want) || stats.Open != tt.want {
+			t.Errorf("expected %d created and open tasks, got %+v, %v", tt.want, stats, err)
+		}
+	}
+
+	var out strings.Builder
+	reg.Write(&out)
+	for _, want := range []string{`tasks_created_total{workspace="default"} 1`, `tasks_created_total{workspace="team"} 2`, `tasks_open{workspace="team"} 2`} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestTaskService_StoreFailure(t *testing.T) {
+	fake := storetest.NewFake(storetest.NewTask("seeded"))
+	service := NewTaskService(fake)
+	var changes []string
+	service.Observe(func(change string, _ model.Task) { changes = append(changes, change) })
+
+	failure := errors.New("connection reset")
+	fake.Fail("Toggle", failure)
+	_, err := service.Toggle(t.Context(), "1")
+	if !errors.Is(err, failure) || apperr.CodeOf(err) != apperr.StoreUnavailable {
+		t.Fatalf("expected the store error to be wrapped as STORE_UNAVAILABLE, got %v", err)
+	}
+	if _, err := service.Toggle(t.Context(), "42"); apperr.CodeOf(err) != apperr.StoreUnavailable {
+		t.Errorf("expected the failure to take precedence, got %v", err)
+	}
+	fake.Fail("Toggle", nil)
+	if _, err := service.Toggle(t.Context(), "42"); apperr.CodeOf(err) != apperr.TaskNotFound {
+		t.Errorf("expected the code of the store to be kept, got %v", err)
+	}
+	if service.Generation() != 0 || len(changes) != 0 {
+		t.Errorf("expected a failed toggle to change nothing, got generation %d and changes %v", service.Generation(), changes)
+	}
+	if stats, _ := service.Stats(t.Context()); stats.Completed != 0 {
+		t.Errorf("expected no completion to be counted, got %d", stats.Completed)
+	}
+}
+
+func TestTaskService_StoreTimeout(t *testing.T) {
+	service := NewTaskService(store.NewTaskStore())
+	ctx, cancel := context.WithTimeout(t.Context(), -time.Second)
+	defer cancel()
+
+	_, err := service.Create(ctx, "Too late", "", "", nil)
+	if !errors.Is(err, context.DeadlineExceeded) || apperr.CodeOf(err) != apperr.StoreTimeout {
+		t.Fatalf("expected an expired context to be wrapped as STORE_TIMEOUT, got %v", err)
+	}
+	if tasks, _ := service.GetAll(t.Context()); len(tasks) != 0 || service.Generation() != 0 {
+		t.Errorf("expected nothing to be created, got %+v", tasks)
+	}
+}
+
+func BenchmarkTaskService_CreateAndToggle(b *testing.B) {
+	service := NewTaskService(store.NewTaskStore())
+	b.ResetTimer()
+	for range b.N {
+		task, err := service.Create(b.Context(), "Benchmark task", PriorityImportant, ColorBlue, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := service.Toggle(b.Context(), task.ID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
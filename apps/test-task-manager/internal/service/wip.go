@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// WIPOpen is the key of the limit on all open tasks in ParseWIPLimit.
+const WIPOpen = "open"
+
+// WIPLimits caps the work in progress: the number of open tasks in total
+// and per priority. A limit of 0 means no limit.
+type WIPLimits struct {
+	Open     int
+	Priority map[string]int // By priority emoticon
+}
+
+// ParseWIPLimit parses a limit written as "<key>=<max>" into limits, where
+// key is WIPOpen or a priority, such as "🔥=5", "urgent=5" or "open=20".
+func ParseWIPLimit(limits *WIPLimits, s string) error {
+	key, value, ok := strings.Cut(strings.TrimSpace(s), "=")
+	max, err := strconv.Atoi(value)
+	if !ok || err != nil || max < 0 {
+		return fmt.Errorf("WIP limit %q must be <priority>=<max> or open=<max>, with max at least 0", s)
+	}
+	if key == WIPOpen {
+		limits.Open = max
+		return nil
+	}
+	priority, ok := canonicalPriority(key)
+	if !ok {
+		return fmt.Errorf("WIP limit %q must limit open or one of the priorities %s", s, strings.Join(Priorities, " "))
+	}
+	if limits.Priority == nil {
+		limits.Priority = make(map[string]int)
+	}
+	limits.Priority[priority] = max
+	return nil
+}
+
+func (l WIPLimits) empty() bool {
+	return l.Open == 0 && len(l.Priority) == 0
+}
+
+// exceeded describes the limit the open tasks, counted per priority in
+// open, exceed: the limit on all open tasks when total is set, or else
+// the one on priority. It returns "" when they exceed neither, and the
+// limit as WIPOpen or the priority, for metrics.
+func (l WIPLimits) exceeded(open map[string]int, priority string, total bool) (limit, description string) {
+	if n := sumCounts(open); total && l.Open > 0 && n > l.Open {
+		return WIPOpen, fmt.Sprintf("%d open tasks exceed the WIP limit of %d", n, l.Open)
+	}
+	if max := l.Priority[priority]; max > 0 && open[priority] > max {
+		// Named p1 to p5 rather than by emoticon, as the description may
+		// end up in a header
+		name := "p" + strconv.Itoa(slices.Index(Priorities, priority)+1)
+		return priority, fmt.Sprintf("%d open %s tasks exceed the WIP limit of %d", open[priority], name, max)
+	}
+	return "", ""
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// openByPriority counts the open tasks per priority.
+func (s *TaskService) openByPriority(ctx context.Context) (map[string]int, error) {
+	open := false
+	tasks, err := s.store.Find(ctx, store.Query{Completed: &open})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks: %w", storeError(err))
+	}
+	counts := make(map[string]int)
+	for _, task := range tasks {
+		counts[task.Priority]++
+	}
+	return counts, nil
+}
+
+// checkWIP checks the WIP limits before a change adding open tasks to
+// priority: opened counts the tasks that become open, moved the open tasks
+// that change to priority by their old priority. Going over a limit, or
+// adding to one already exceeded because it was lowered, returns
+// ErrWIPLimit, or with WithWIPLimits in warn mode is only counted. Like the
+// duplicate title check it is not atomic with the change, so concurrent
+// requests may together go over a limit.
+func (s *TaskService) checkWIP(ctx context.Context, priority string, opened int, moved map[string]int) error {
+	if s.wip.empty() {
+		return nil
+	}
+	open, err := s.openByPriority(ctx)
+	if err != nil {
+		return err
+	}
+	added := opened
+	for from, n := range moved {
+		if from != priority {
+			open[from] -= n
+			added += n
+		}
+	}
+	if added == 0 {
+		return nil
+	}
+	open[priority] += added
+
+	limit, description := s.wip.exceeded(open, priority, opened > 0)
+	if limit == "" {
+		return nil
+	}
+	if s.wipWarn {
+		s.metrics.wipExceeded.With(s.metrics.workspace, limit, "warned").Inc()
+		return nil
+	}
+	s.metrics.wipExceeded.With(s.metrics.workspace, limit, "rejected").Inc()
+	return fmt.Errorf("%w: %s", ErrWIPLimit, description)
+}
+
+// WIPWarning describes the WIP limit the open tasks of priority, or all
+// open tasks, exceed, or returns "" when they exceed neither. Handlers use
+// it to warn clients after changes made in warn mode.
+func (s *TaskService) WIPWarning(ctx context.Context, priority string) string {
+	if s.wip.empty() {
+		return ""
+	}
+	open, err := s.openByPriority(ctx)
+	if err != nil {
+		return ""
+	}
+	_, description := s.wip.exceeded(open, priority, true)
+	return description
+}
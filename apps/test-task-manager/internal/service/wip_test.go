@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestParseWIPLimit(t *testing.T) {
+	var limits WIPLimits
+	for _, s := range []string{"🔥=5", "high=3", " open=20 "} {
+		if err := ParseWIPLimit(&limits, s); err != nil {
+			t.Fatalf("ParseWIPLimit(%q): expected no error, got %v", s, err)
+		}
+	}
+	if limits.Open != 20 || limits.Priority[PriorityUrgentImportant] != 5 || limits.Priority[PriorityImportant] != 3 {
+		t.Errorf("unexpected limits %+v", limits)
+	}
+
+	for _, s := range []string{"🔥", "🔥=-1", "🔥=five", "done=5"} {
+		if err := ParseWIPLimit(&limits, s); err == nil {
+			t.Errorf("ParseWIPLimit(%q): expected error", s)
+		}
+	}
+}
+
+func TestTaskService_WIPLimits(t *testing.T) {
+	limits := WIPLimits{Open: 3, Priority: map[string]int{PriorityUrgentImportant: 1}}
+	service := NewTaskService(store.NewTaskStore(), WithWIPLimits(limits, false))
+
+	urgent, err := service.Create(t.Context(), "Fix outage", "🔥", "", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Create(t.Context(), "Fix another outage", "urgent", "", nil); !errors.Is(err, ErrWIPLimit) {
+		t.Errorf("expected ErrWIPLimit creating a second 🔥 task, got %v", err)
+	}
+	other, err := service.Create(t.Context(), "Plan roadmap", "⭐", "", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Update(t.Context(), other.ID, other.Title, "🔥", "", nil); !errors.Is(err, ErrWIPLimit) {
+		t.Errorf("expected ErrWIPLimit moving a task to 🔥, got %v", err)
+	}
+	if _, err := service.Update(t.Context(), urgent.ID, "Fix the outage", "🔥", "", nil); err != nil {
+		t.Errorf("expected editing a task within its priority to pass, got %v", err)
+	}
+
+	// Completing a task makes room; reopening it takes the room again
+	if _, err := service.Toggle(t.Context(), urgent.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Create(t.Context(), "Fix outage again", "🔥", "", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Toggle(t.Context(), urgent.ID); !errors.Is(err, ErrWIPLimit) {
+		t.Errorf("expected ErrWIPLimit reopening a 🔥 task, got %v", err)
+	}
+
+	if _, err := service.Create(t.Context(), "Water plants", "💡", "", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Create(t.Context(), "Sort mail", "💡", "", nil); !errors.Is(err, ErrWIPLimit) {
+		t.Errorf("expected ErrWIPLimit going over 3 open tasks, got %v", err)
+	}
+	if got := service.WIPWarning(t.Context(), PriorityLow); got != "" {
+		t.Errorf("expected no warning at the limits, got %q", got)
+	}
+}
+
+func TestTaskService_WIPLimitsWarn(t *testing.T) {
+	reg := metrics.NewRegistry()
+	limits := WIPLimits{Priority: map[string]int{PriorityUrgentImportant: 1}}
+	service := NewTaskService(store.NewTaskStore(), WithMetrics(reg), WithWIPLimits(limits, true))
+
+	for _, title := range []string{"Fix outage", "Fix another outage"} {
+		if _, err := service.Create(t.Context(), title, "🔥", "", nil); err != nil {
+			t.Fatalf("expected changes over the limit to pass, got %v", err)
+		}
+	}
+	if got := service.WIPWarning(t.Context(), PriorityUrgentImportant); got != "2 open p1 tasks exceed the WIP limit of 1" {
+		t.Errorf("unexpected warning %q", got)
+	}
+	if got := service.WIPWarning(t.Context(), PriorityImportant); got != "" {
+		t.Errorf("expected no warning for ⭐, got %q", got)
+	}
+
+	var out strings.Builder
+	reg.Write(&out)
+	if want := `tasks_wip_limit_exceeded_total{workspace="default",limit="🔥",action="warned"} 1`; !strings.Contains(out.String(), want) {
+		t.Errorf("expected metrics to contain %q, got:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,147 @@
+//go:build integration
+
+package store_test
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/lib/pq" // Registers the postgres driver
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/modules/redis"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/storebench"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/storetest"
+)
+
+// These tests run the conformance and stress suites against real databases
+// in containers. They need Docker and run with:
+//
+//	go test -tags integration -run Backend ./internal/store
+
+func TestBackendPostgres_Conforms(t *testing.T) {
+	storetest.Run(t, startPostgres(t))
+}
+
+func TestBackendPostgres_Stress(t *testing.T) {
+	storetest.Stress(t, 50, startPostgres(t))
+}
+
+func TestBackendRedis_Conforms(t *testing.T) {
+	storetest.Run(t, startRedis(t))
+}
+
+func TestBackendRedis_Stress(t *testing.T) {
+	storetest.Stress(t, 50, startRedis(t))
+}
+
+// TestBackend_Compare runs the storebench workload against the memory, file
+// and postgres stores and logs the comparison; run it with -v.
+func TestBackend_Compare(t *testing.T) {
+	file, err := store.NewFileStore(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := []struct {
+		backend string
+		store   store.Store
+	}{
+		{store.BackendMemory, store.NewTaskStore()},
+		{store.BackendFile, file},
+		{store.BackendPostgres, startPostgres(t)(t)},
+	}
+
+	var results []storebench.Result
+	for _, s := range stores {
+		result, err := storebench.Run(t.Context(), s.backend, s.store, storebench.DefaultWorkload)
+		if err != nil {
+			t.Fatalf("%s: %v", s.backend, err)
+		}
+		if result.Errors > 0 {
+			t.Errorf("%s: %d operations failed", s.backend, result.Errors)
+		}
+		results = append(results, result)
+	}
+
+	var table strings.Builder
+	if err := storebench.WriteTable(&table, results); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%s", table.String())
+}
+
+// startPostgres starts a PostgreSQL container for the test and returns a
+// function opening a migrated store on it, emptied first.
+func startPostgres(t *testing.T) func(t *testing.T) store.Store {
+	ctx := context.Background()
+	container, err := postgres.Run(ctx, "postgres:16-alpine",
+		postgres.WithDatabase("tasks"),
+		postgres.WithUsername("tasks"),
+		postgres.WithPassword("tasks"),
+		postgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(t, container)
+	if err != nil {
+		t.Fatalf("failed to start postgres: %v", err)
+	}
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return func(t *testing.T) store.Store {
+		s, err := store.OpenSQLStore(store.BackendPostgres, dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.Close(s) })
+		if _, err := s.Migrate(); err != nil {
+			t.Fatal(err)
+		}
+		exec(t, container, "psql", "-U", "tasks", "-d", "tasks", "-c", "TRUNCATE tasks, outbox RESTART IDENTITY")
+		return s
+	}
+}
+
+// startRedis starts a Redis container for the test and returns a function
+// opening a store on it, emptied first.
+func startRedis(t *testing.T) func(t *testing.T) store.Store {
+	ctx := context.Background()
+	container, err := redis.Run(ctx, "redis:7-alpine")
+	testcontainers.CleanupContainer(t, container)
+	if err != nil {
+		t.Fatalf("failed to start redis: %v", err)
+	}
+	dsn, err := container.ConnectionString(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return func(t *testing.T) store.Store {
+		exec(t, container, "redis-cli", "FLUSHDB")
+		s, err := store.NewRedisStore(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.Close(s) })
+		return s
+	}
+}
+
+// exec runs cmd in container and fails the test unless it succeeds.
+func exec(t *testing.T, container testcontainers.Container, cmd ...string) {
+	t.Helper()
+	code, output, err := container.Exec(context.Background(), cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 0 {
+		out, _ := io.ReadAll(output)
+		t.Fatalf("%v exited with %d: %s", cmd, code, out)
+	}
+}
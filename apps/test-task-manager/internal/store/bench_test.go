@@ -0,0 +1,161 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// benchSizes are the store sizes the benchmarks run at. Run them with
+// go test -bench . -benchmem ./internal/store.
+var benchSizes = []int{1_000, 100_000, 1_000_000}
+
+// benchTask returns the i-th task of a benchmark data set: a mix of
+// priorities, completion states and due dates.
+func benchTask(i int) model.Task {
+	priorities := []string{"🔥", "⭐", "⚡", "💡", "📋"}
+	task := model.Task{
+		Title:     "Benchmark task " + strconv.Itoa(i),
+		Priority:  priorities[i%len(priorities)],
+		Color:     "#6c757d",
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if i%3 == 0 {
+		due := task.CreatedAt.Add(time.Duration(i%90) * 24 * time.Hour)
+		task.DueDate = &due
+	}
+	return task
+}
+
+func populate(b *testing.B, s Store, n int) []string {
+	b.Helper()
+	ids := make([]string, n)
+	for i := range n {
+		task, err := s.Create(b.Context(), benchTask(i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+// benchmarkStore runs the hot paths against stores of every size created by newStore.
+func benchmarkStore(b *testing.B, sizes []int, newStore func(b *testing.B) Store) {
+	for _, n := range sizes {
+		b.Run(fmt.Sprintf("Create/%d", n), func(b *testing.B) {
+			s := newStore(b)
+			populate(b, s, n)
+			b.ResetTimer()
+			for i := range b.N {
+				if _, err := s.Create(b.Context(), benchTask(i)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("GetAll/%d", n), func(b *testing.B) {
+			s := newStore(b)
+			populate(b, s, n)
+			b.ResetTimer()
+			for range b.N {
+				if _, err := s.GetAll(b.Context()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Find/%d", n), func(b *testing.B) {
+			s := newStore(b)
+			populate(b, s, n)
+			open := false
+			q := Query{Priority: "🔥", Completed: &open}
+			b.ResetTimer()
+			for range b.N {
+				if _, err := s.Find(b.Context(), q); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Toggle/%d", n), func(b *testing.B) {
+			s := newStore(b)
+			ids := populate(b, s, n)
+			b.ResetTimer()
+			for i := range b.N {
+				if _, err := s.Toggle(b.Context(), ids[i%len(ids)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Delete/%d", n), func(b *testing.B) {
+			s := newStore(b)
+			ids := populate(b, s, n)
+			b.ResetTimer()
+			for i := range b.N {
+				// Keep the size stable: every delete is paired with a create
+				// outside the measured time.
+				b.StopTimer()
+				created, err := s.Create(b.Context(), benchTask(i))
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if err := s.Delete(b.Context(), ids[i%len(ids)]); err != nil {
+					b.Fatal(err)
+				}
+				ids[i%len(ids)] = created.ID
+			}
+		})
+	}
+}
+
+func BenchmarkTaskStore(b *testing.B) {
+	benchmarkStore(b, benchSizes, func(*testing.B) Store { return NewTaskStore() })
+}
+
+// BenchmarkFileStore only runs at the smallest size, as every change
+// rewrites the whole file.
+func BenchmarkFileStore(b *testing.B) {
+	benchmarkStore(b, benchSizes[:1], func(b *testing.B) Store {
+		s, err := NewFileStore(filepath.Join(b.TempDir(), "tasks.json"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		return s
+	})
+}
+
+// BenchmarkTaskStoreParallel measures throughput under concurrent writes
+// and reads with a single shard and with the default number of shards; run
+// it with -cpu 1,4,8 to see how it scales.
+func BenchmarkTaskStoreParallel(b *testing.B) {
+	for _, shards := range []int{1, taskStoreShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			s := newShardedTaskStore(shards)
+			ids := populate(b, s, 100_000)
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := int(next.Add(1))
+					var err error
+					switch i % 4 {
+					case 0:
+						_, err = s.Create(b.Context(), benchTask(i))
+					case 1:
+						_, err = s.GetByID(b.Context(), ids[i%len(ids)])
+					default:
+						_, err = s.Toggle(b.Context(), ids[i%len(ids)])
+					}
+					if err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
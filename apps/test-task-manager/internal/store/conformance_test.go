@@ -0,0 +1,49 @@
+package store_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/storetest"
+)
+
+func TestTaskStore_Conforms(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store { return store.NewTaskStore() })
+}
+
+func TestTaskStore_ConformsWithULIDs(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		s := store.NewTaskStore()
+		s.SetIDGenerator(&store.ULIDs{})
+		return s
+	})
+}
+
+func TestTaskStore_Stress(t *testing.T) {
+	storetest.Stress(t, 200, func(t *testing.T) store.Store { return store.NewTaskStore() })
+}
+
+func TestFileStore_Conforms(t *testing.T) {
+	storetest.Run(t, openFileStore)
+}
+
+func TestFileStore_ConformsWithUUIDs(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		s := openFileStore(t)
+		s.(store.IDAssigner).SetIDGenerator(store.UUIDs{})
+		return s
+	})
+}
+
+func TestFileStore_Stress(t *testing.T) {
+	storetest.Stress(t, 20, openFileStore)
+}
+
+func openFileStore(t *testing.T) store.Store {
+	s, err := store.NewFileStore(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
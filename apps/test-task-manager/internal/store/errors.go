@@ -1,6 +1,17 @@
 package store
 
-import "errors"
+import "gitlab.com/btcdirect-api/test-task-manager/internal/apperr"
 
-// ErrTaskNotFound is returned when a task with the given ID doesn't exist.
-var ErrTaskNotFound = errors.New("task not found")
+var (
+	// ErrTaskNotFound is returned when a task with the given ID doesn't exist.
+	ErrTaskNotFound = apperr.New(apperr.TaskNotFound, "task not found")
+	// ErrStoreFull is returned when creating tasks would exceed the memory
+	// limit of the store.
+	ErrStoreFull = apperr.New(apperr.StoreFull, "task store is full")
+	// ErrConflict is returned when a task kept being modified concurrently
+	// while a change to it was retried.
+	ErrConflict = apperr.New(apperr.TaskConflict, "task was modified concurrently too often")
+	// ErrTombstonesExpired is returned for tombstones asked for since before
+	// the retention of the store, as deletions would be missing.
+	ErrTombstonesExpired = apperr.New(apperr.TombstonesExpired, "deletions that long ago are no longer kept")
+)
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package store
+
+import "os"
+
+// lockFile does nothing on platforms without flock: the changes of a
+// FileStore are only kept apart within the process, so the file must not
+// be served by several processes there.
+func lockFile(*os.File) error {
+	return nil
+}
+
+// unlockFile does nothing, as lockFile takes no lock.
+func unlockFile(*os.File) error {
+	return nil
+}
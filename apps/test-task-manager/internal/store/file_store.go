@@ -0,0 +1,519 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/atomicfile"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// FileStore keeps all tasks in memory and persists them to a JSON file
+// after every change. Writes go through a temporary file and a rename, so
+// the file is never left half-written. With the outbox enabled, the events
+// of every change are written to the file along with it, and with
+// tombstones kept, the tombstones of deleted tasks.
+//
+// Several processes may serve the same file, such as the old and the new
+// process of a zero-downtime restart: every change locks <path>.lock and
+// first reloads the file when another process replaced it, so no change is
+// lost. Reads are served from memory and may miss the changes of other
+// processes until the next change. Locking needs flock, so on other
+// platforms than Unix only one process may serve the file.
+type FileStore struct {
+	path      string
+	lock      *os.File // Of <path>.lock, locked by every change
+	outbox    bool
+	retention time.Duration // Of the tombstones; none are kept when 0
+	ids       IDGenerator
+
+	mu    sync.RWMutex
+	state fileState
+	read  os.FileInfo // Of the file state was last read from or written to
+}
+
+// fileState is the on-disk representation of a FileStore.
+type fileState struct {
+	// NextID is one past the highest numeric ID handed out, so numbers of
+	// deleted tasks are not handed out again after a restart.
+	NextID       int          `json:"nextId"`
+	Tasks        []model.Task `json:"tasks"`
+	NextEventSeq int64        `json:"nextEventSeq,omitempty"`
+	Events       []Event      `json:"events,omitempty"` // Outbox
+	Tombstones   []Tombstone  `json:"tombstones,omitempty"`
+}
+
+// NewFileStore opens the store persisted at path, creating it when it does
+// not exist yet.
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		return nil, errors.New("a file path is required")
+	}
+
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileStore{path: path, lock: lock, state: fileState{NextID: 1, Tasks: make([]model.Task, 0)}}
+
+	// Reads the file, or creates it when it does not exist yet
+	release, err := s.acquire()
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	release()
+
+	s.SetIDGenerator(&Sequence{})
+	return s, nil
+}
+
+// SetIDGenerator makes the store take the IDs of new tasks from ids rather
+// than numbering them. It must be called before the store is used.
+func (s *FileStore) SetIDGenerator(ids IDGenerator) {
+	s.ids = ids
+	s.skipIDs()
+}
+
+// skipIDs makes the ID generator, if it skips IDs, pass those of the state.
+func (s *FileStore) skipIDs() {
+	if skip, ok := s.ids.(skipper); ok {
+		skip.Skip(strconv.Itoa(s.state.NextID - 1))
+		for _, task := range s.state.Tasks {
+			skip.Skip(task.ID)
+		}
+	}
+}
+
+// Close releases the lock file.
+func (s *FileStore) Close() error {
+	return s.lock.Close()
+}
+
+// GetAll returns all tasks.
+func (s *FileStore) GetAll(ctx context.Context) ([]model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasksCopy := make([]model.Task, len(s.state.Tasks))
+	copy(tasksCopy, s.state.Tasks)
+	return tasksCopy, nil
+}
+
+// Find returns the tasks matching q. The file store is meant for small
+// task lists and scans them all.
+func (s *FileStore) Find(ctx context.Context, q Query) ([]model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return filterTasks(s.state.Tasks, q), nil
+}
+
+// GetByID returns a task by ID.
+func (s *FileStore) GetByID(ctx context.Context, id string) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, task := range s.state.Tasks {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+
+	return model.Task{}, ErrTaskNotFound
+}
+
+// Create adds a new task.
+func (s *FileStore) Create(ctx context.Context, task model.Task) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+
+	release, err := s.acquire()
+	if err != nil {
+		return model.Task{}, err
+	}
+	defer release()
+
+	task.ID = s.ids.NewID()
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+
+	next := s.clone()
+	next.Tasks = append(next.Tasks, task)
+	next.handedOut(task.ID)
+	s.record(&next, EventTaskCreated, task)
+
+	if err := s.commit(next); err != nil {
+		return model.Task{}, err
+	}
+	return task, nil
+}
+
+// CreateMany adds tasks with a single write of the file.
+func (s *FileStore) CreateMany(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	release, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	next := s.clone()
+	created := make([]model.Task, len(tasks))
+	now := time.Now()
+	for i, task := range tasks {
+		task.ID = s.ids.NewID()
+		if task.CreatedAt.IsZero() {
+			task.CreatedAt = now
+		}
+		next.Tasks = append(next.Tasks, task)
+		next.handedOut(task.ID)
+		s.record(&next, EventTaskCreated, task)
+		created[i] = task
+	}
+
+	if err := s.commit(next); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Toggle changes completion status.
+func (s *FileStore) Toggle(ctx context.Context, id string) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+
+	release, err := s.acquire()
+	if err != nil {
+		return model.Task{}, err
+	}
+	defer release()
+
+	next := s.clone()
+	for i := range next.Tasks {
+		if next.Tasks[i].ID != id {
+			continue
+		}
+
+		next.Tasks[i].Completed = !next.Tasks[i].Completed
+		now := time.Now()
+		next.Tasks[i].UpdatedAt = &now
+		if next.Tasks[i].Completed {
+			next.Tasks[i].CompletedAt = &now
+		} else {
+			next.Tasks[i].CompletedAt = nil
+		}
+		s.record(&next, toggleEvent(next.Tasks[i]), next.Tasks[i])
+
+		if err := s.commit(next); err != nil {
+			return model.Task{}, err
+		}
+		return next.Tasks[i], nil
+	}
+
+	return model.Task{}, ErrTaskNotFound
+}
+
+// Update replaces the editable fields of a task.
+func (s *FileStore) Update(ctx context.Context, update model.Task) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+
+	release, err := s.acquire()
+	if err != nil {
+		return model.Task{}, err
+	}
+	defer release()
+
+	next := s.clone()
+	for i := range next.Tasks {
+		if next.Tasks[i].ID != update.ID {
+			continue
+		}
+
+		applyUpdate(&next.Tasks[i], update)
+		s.record(&next, EventTaskUpdated, next.Tasks[i])
+		if err := s.commit(next); err != nil {
+			return model.Task{}, err
+		}
+		return next.Tasks[i], nil
+	}
+
+	return model.Task{}, ErrTaskNotFound
+}
+
+// Reassign changes the tasks matching q with a single write of the file.
+func (s *FileStore) Reassign(ctx context.Context, q Query, priority, color string) ([]model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	release, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	next := s.clone()
+	changed := make([]model.Task, 0)
+	for i := range next.Tasks {
+		if !q.Matches(next.Tasks[i]) {
+			continue
+		}
+		applyReassign(&next.Tasks[i], priority, color)
+		s.record(&next, EventTaskUpdated, next.Tasks[i])
+		changed = append(changed, next.Tasks[i])
+	}
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	if err := s.commit(next); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// Delete removes a task.
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	release, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	next := s.clone()
+	for i, task := range next.Tasks {
+		if task.ID == id {
+			next.Tasks = append(next.Tasks[:i], next.Tasks[i+1:]...)
+			s.record(&next, EventTaskDeleted, task)
+			if s.retention > 0 {
+				next.Tombstones = append(pruneTombstones(next.Tombstones, s.retention), Tombstone{ID: id, DeletedAt: time.Now()})
+			}
+			return s.commit(next)
+		}
+	}
+
+	return ErrTaskNotFound
+}
+
+// EnableOutbox starts recording events.
+func (s *FileStore) EnableOutbox() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outbox = true
+}
+
+// PendingEvents returns up to limit unacknowledged events.
+func (s *FileStore) PendingEvents(limit int) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := s.state.Events[:min(limit, len(s.state.Events))]
+	return slices.Clone(events), nil
+}
+
+// AckEvents removes delivered events with a single write of the file.
+func (s *FileStore) AckEvents(seqs ...int64) error {
+	release, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	next := s.clone()
+	next.Events = slices.DeleteFunc(next.Events, func(e Event) bool {
+		return slices.Contains(seqs, e.Seq)
+	})
+	if len(next.Events) == len(s.state.Events) {
+		return nil
+	}
+	return s.commit(next)
+}
+
+// KeepTombstones makes the store keep the tombstones of deleted tasks for
+// retention, in the file.
+func (s *FileStore) KeepTombstones(retention time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retention = retention
+}
+
+// Tombstones returns the tombstones of the tasks deleted at or after since.
+// Those past the retention are dropped from the file with the next delete.
+func (s *FileStore) Tombstones(ctx context.Context, since time.Time) ([]Tombstone, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := checkTombstonesSince(since, s.retention); err != nil {
+		return nil, err
+	}
+	return tombstonesSince(s.state.Tombstones, since), nil
+}
+
+// Ping verifies the file is still readable.
+func (s *FileStore) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := os.Stat(s.path)
+	return err
+}
+
+// Size returns the size of the file.
+func (s *FileStore) Size() (int64, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// acquire takes the write lock and the lock of the file, and reloads the
+// file when another process replaced it since this store last read or wrote
+// it. A missing file is created. release gives up both locks.
+func (s *FileStore) acquire() (release func(), err error) {
+	s.mu.Lock()
+	if err := lockFile(s.lock); err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to lock %s: %w", s.lock.Name(), err)
+	}
+	release = func() {
+		unlockFile(s.lock)
+		s.mu.Unlock()
+	}
+
+	if err := s.reload(); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// reload reads the file when it is not the one the state was last read
+// from or written to. Every write replaces the file, so a file with the
+// same inode, size and modification time is the same. Callers must hold
+// both locks.
+func (s *FileStore) reload() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s.save(s.state)
+	}
+	if err != nil {
+		return err
+	}
+	if s.read != nil && os.SameFile(s.read, info) && s.read.Size() == info.Size() && s.read.ModTime().Equal(info.ModTime()) {
+		return nil
+	}
+
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	state := fileState{NextID: 1}
+	if err := json.Unmarshal(content, &state); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	if state.Tasks == nil {
+		state.Tasks = make([]model.Task, 0)
+	}
+	s.state, s.read = state, info
+	s.skipIDs()
+	return nil
+}
+
+// clone returns a copy of the state that can be modified without
+// affecting readers. Callers must hold the lock.
+func (s *FileStore) clone() fileState {
+	tasks := make([]model.Task, len(s.state.Tasks))
+	copy(tasks, s.state.Tasks)
+	return fileState{
+		NextID:       s.state.NextID,
+		Tasks:        tasks,
+		NextEventSeq: s.state.NextEventSeq,
+		Events:       slices.Clone(s.state.Events),
+		Tombstones:   slices.Clone(s.state.Tombstones),
+	}
+}
+
+// handedOut advances NextID past id when it is a number.
+func (state *fileState) handedOut(id string) {
+	if n, err := strconv.Atoi(id); err == nil && n >= state.NextID {
+		state.NextID = n + 1
+	}
+}
+
+// record adds an event for task to the outbox of state, when enabled.
+// Callers must hold the write lock.
+func (s *FileStore) record(state *fileState, eventType string, task model.Task) {
+	if !s.outbox {
+		return
+	}
+	state.NextEventSeq++
+	state.Events = append(state.Events, Event{Seq: state.NextEventSeq, Type: eventType, Task: task, At: time.Now()})
+}
+
+// commit persists next and makes it the current state. The current state
+// is kept when persisting fails. Callers must hold the write lock.
+func (s *FileStore) commit(next fileState) error {
+	if err := s.save(next); err != nil {
+		return err
+	}
+	s.state = next
+	return nil
+}
+
+// save atomically replaces the file with state. Callers must hold both
+// locks.
+func (s *FileStore) save(state fileState) error {
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := atomicfile.Write(s.path, content); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	s.read = info
+	return nil
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+func TestFileStore_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := s.Create(t.Context(), model.Task{Title: "first", Priority: "🔥", Color: "#dc3545"})
+	second, _ := s.Create(t.Context(), model.Task{Title: "second", Priority: "⭐", Color: "#ffc107"})
+	if _, err := s.Toggle(t.Context(), first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(t.Context(), second.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, _ := reopened.GetAll(t.Context())
+	if len(tasks) != 1 || tasks[0].ID != first.ID || !tasks[0].Completed || tasks[0].CompletedAt == nil {
+		t.Fatalf("unexpected tasks after reopen: %+v", tasks)
+	}
+
+	reopened.SetIDGenerator(&Sequence{}) // As the server does
+	third, _ := reopened.Create(t.Context(), model.Task{Title: "third", Priority: "💡", Color: "#17a2b8"})
+	if third.ID != "3" {
+		t.Errorf("expected IDs to continue after reopen, got %q", third.ID)
+	}
+}
+
+func TestFileStore_Outbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.EnableOutbox()
+	task, _ := s.Create(t.Context(), model.Task{Title: "first", Priority: "🔥", Color: "#dc3545"})
+	if _, err := s.Toggle(t.Context(), task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(t.Context(), task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, _ := reopened.PendingEvents(10)
+	want := []string{EventTaskCreated, EventTaskCompleted, EventTaskDeleted}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d pending events, got %+v", len(want), events)
+	}
+	for i, e := range events {
+		if e.Type != want[i] || e.Seq != int64(i+1) || e.Task.ID != task.ID {
+			t.Errorf("event %d: unexpected %+v", i, e)
+		}
+	}
+
+	if err := reopened.AckEvents(events[0].Seq, events[1].Seq); err != nil {
+		t.Fatal(err)
+	}
+	events, _ = reopened.PendingEvents(10)
+	if len(events) != 1 || events[0].Type != EventTaskDeleted {
+		t.Errorf("expected only the deletion to be pending, got %+v", events)
+	}
+}
+
+func TestFileStore_Tombstones(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.KeepTombstones(time.Hour)
+	old, _ := s.Create(t.Context(), model.Task{Title: "old", Priority: "🔥", Color: "#dc3545"})
+	task, _ := s.Create(t.Context(), model.Task{Title: "new", Priority: "⭐", Color: "#ffc107"})
+	if err := s.Delete(t.Context(), old.ID); err != nil {
+		t.Fatal(err)
+	}
+	s.state.Tombstones[0].DeletedAt = time.Now().Add(-2 * time.Hour) // Past the retention
+	if err := s.Delete(t.Context(), task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.KeepTombstones(time.Hour)
+	if len(reopened.state.Tombstones) != 1 {
+		t.Errorf("expected the tombstone past the retention to be dropped, got %+v", reopened.state.Tombstones)
+	}
+	tombstones, err := reopened.Tombstones(t.Context(), time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tombstones) != 1 || tombstones[0].ID != task.ID {
+		t.Errorf("expected the tombstone of %q after reopen, got %+v", task.ID, tombstones)
+	}
+}
+
+func TestFileStore_SharedBetweenProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	// Two stores on one file stand in for the old and the new process of
+	// a restart.
+	old, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	first, _ := old.Create(t.Context(), model.Task{Title: "first", Priority: "🔥", Color: "#dc3545"})
+
+	next, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer next.Close()
+	second, err := next.Create(t.Context(), model.Task{Title: "second", Priority: "⭐", Color: "#ffc107"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The old process changes the file after the new one did, without
+	// dropping the task of the new one or handing out its ID again.
+	third, err := old.Create(t.Context(), model.Task{Title: "third", Priority: "💡", Color: "#17a2b8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.ID == second.ID {
+		t.Fatalf("expected a new ID, got %q twice", third.ID)
+	}
+	if _, err := old.Toggle(t.Context(), second.ID); err != nil {
+		t.Fatalf("expected the task of the other store to be found: %v", err)
+	}
+	if err := next.Delete(t.Context(), first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	tasks, _ := reopened.GetAll(t.Context())
+	if len(tasks) != 2 || tasks[0].ID != second.ID || !tasks[0].Completed || tasks[1].ID != third.ID {
+		t.Errorf("expected the changes of both stores, got %+v", tasks)
+	}
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ID strategies selectable through configuration.
+const (
+	IDSequential = "sequential"
+	IDUUID       = "uuid"
+	IDULID       = "ulid"
+)
+
+// IDStrategies lists every supported ID strategy.
+var IDStrategies = []string{IDSequential, IDUUID, IDULID}
+
+// IDGenerator hands out the IDs of new tasks. It must be safe for
+// concurrent use and never return the same ID twice.
+//
+// Generators whose IDs a store may already hold, such as a Sequence after a
+// restart, implement Skip(id string); stores call it with the IDs they
+// load so those are not handed out again.
+type IDGenerator interface {
+	NewID() string
+}
+
+// skipper is implemented by generators whose IDs a store may already hold.
+type skipper interface {
+	Skip(id string)
+}
+
+// IDAssigner is implemented by stores that assign task IDs themselves
+// rather than leaving that to the database.
+type IDAssigner interface {
+	// SetIDGenerator makes the store take the IDs of new tasks from ids.
+	// It must be called before the store is used.
+	SetIDGenerator(ids IDGenerator)
+}
+
+// NewIDGenerator returns the generator of strategy.
+func NewIDGenerator(strategy string) (IDGenerator, error) {
+	switch strategy {
+	case IDSequential:
+		return &Sequence{}, nil
+	case IDUUID:
+		return UUIDs{}, nil
+	case IDULID:
+		return &ULIDs{}, nil
+	default:
+		return nil, fmt.Errorf("unknown ID strategy %q", strategy)
+	}
+}
+
+// Sequence hands out the IDs 1, 2, 3 and so on. Its zero value starts at 1.
+type Sequence struct {
+	last atomic.Int64
+}
+
+// NewID returns the next number.
+func (s *Sequence) NewID() string {
+	return strconv.FormatInt(s.last.Add(1), 10)
+}
+
+// Skip makes the sequence continue after id when id is a number it has not
+// passed yet.
+func (s *Sequence) Skip(id string) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return
+	}
+	for {
+		last := s.last.Load()
+		if n <= last || s.last.CompareAndSwap(last, n) {
+			return
+		}
+	}
+}
+
+// UUIDs hands out random (version 4) UUIDs, such as
+// 0b5fc3a2-4d1e-4c57-9a0e-2f1c7d9b8e61.
+type UUIDs struct{}
+
+// NewID returns a new random UUID.
+func (UUIDs) NewID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 9562 variant
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	hex.Encode(s[9:13], b[4:6])
+	hex.Encode(s[14:18], b[6:8])
+	hex.Encode(s[19:23], b[8:10])
+	hex.Encode(s[24:], b[10:])
+	s[8], s[13], s[18], s[23] = '-', '-', '-', '-'
+	return string(s[:])
+}
+
+// crockford is the alphabet of ULIDs, Crockford's base 32.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDs hands out ULIDs, such as 01JD3M2Q8Z4X7V6T5R3P1N0K9H: 26 characters
+// holding the creation time in milliseconds and 80 random bits, which sort
+// in the order they were handed out. IDs within the same millisecond
+// increment the last one. The zero value is ready to use.
+type ULIDs struct {
+	// Now returns the current time; time.Now when nil.
+	Now func() time.Time
+
+	mu     sync.Mutex
+	hi, lo uint64 // Last ULID as a 128-bit number
+}
+
+// NewID returns the next ULID.
+func (u *ULIDs) NewID() string {
+	now := time.Now
+	if u.Now != nil {
+		now = u.Now
+	}
+	ms := uint64(now().UnixMilli()) & (1<<48 - 1)
+
+	u.mu.Lock()
+	if last := u.hi >> 16; ms > last || u.hi == 0 && u.lo == 0 {
+		var b [10]byte
+		rand.Read(b[:])
+		u.hi = ms<<16 | uint64(b[0])<<8 | uint64(b[1])
+		u.lo = 0
+		for _, c := range b[2:] {
+			u.lo = u.lo<<8 | uint64(c)
+		}
+	} else {
+		// Same millisecond, or the clock went back: keep them ordered.
+		u.lo++
+		if u.lo == 0 {
+			u.hi++
+		}
+	}
+	hi, lo := u.hi, u.lo
+	u.mu.Unlock()
+
+	var s [26]byte
+	for i := len(s) - 1; i >= 0; i-- {
+		s[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
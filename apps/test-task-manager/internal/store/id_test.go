@@ -0,0 +1,55 @@
+package store
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSequence_Skip(t *testing.T) {
+	var s Sequence
+	s.Skip("41")
+	s.Skip("7")
+	s.Skip("not a number")
+	if id := s.NewID(); id != "42" {
+		t.Errorf("expected 42 after skipping 41, got %s", id)
+	}
+}
+
+func TestUUIDs(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, b := UUIDs{}.NewID(), UUIDs{}.NewID()
+	if !format.MatchString(a) || a == b {
+		t.Errorf("expected two different version 4 UUIDs, got %s and %s", a, b)
+	}
+}
+
+func TestULIDs(t *testing.T) {
+	now := time.UnixMilli(1767225600000)
+	u := &ULIDs{Now: func() time.Time { return now }}
+
+	var ids []string
+	for i := range 1000 {
+		if i == 500 {
+			now = now.Add(-time.Second) // The clock going back keeps the order
+		}
+		if i == 700 {
+			now = now.Add(time.Hour)
+		}
+		ids = append(ids, u.NewID())
+	}
+
+	format := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	for _, id := range ids {
+		if !format.MatchString(id) {
+			t.Fatalf("expected a ULID, got %s", id)
+		}
+	}
+	if !slices.IsSorted(ids) || len(slices.Compact(slices.Clone(ids))) != len(ids) {
+		t.Error("expected unique ULIDs in ascending order")
+	}
+	if got := ids[0][:10]; got != "01KDVDNA00" {
+		t.Errorf("expected the time to be encoded as 01KDVDNA00, got %s", got)
+	}
+}
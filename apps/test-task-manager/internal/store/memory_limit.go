@@ -0,0 +1,63 @@
+package store
+
+import (
+	"unsafe"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// taskOverhead approximates the memory a task takes in a TaskStore besides
+// its strings: the task itself, its entries in the task map, the creation
+// order and the priority and completion indexes, and the times its pointer
+// fields refer to.
+const taskOverhead = int64(unsafe.Sizeof(model.Task{})) + 8 + 3*48 + 2*int64(unsafe.Sizeof(dueEntry{}))
+
+// MemoryLimits bounds the approximate memory used by a TaskStore, in bytes.
+// Zero disables a limit.
+type MemoryLimits struct {
+	// Soft is the usage above which OnSoftLimit is called.
+	Soft int64
+	// Hard is the usage above which creating tasks fails with ErrStoreFull.
+	Hard int64
+	// OnSoftLimit is called with the usage when it rises above Soft, once
+	// until it drops below Soft again.
+	OnSoftLimit func(used int64)
+}
+
+// taskSize returns the approximate memory task takes in a TaskStore.
+func taskSize(task model.Task) int64 {
+	return taskOverhead + int64(len(task.ID)+len(task.Title)+len(task.Priority)+len(task.Color))
+}
+
+// SetMemoryLimits configures the memory limits. It must be called before
+// the store is used.
+func (s *TaskStore) SetMemoryLimits(limits MemoryLimits) {
+	s.limits = limits
+}
+
+// MemoryUsage returns the approximate memory used by the tasks, in bytes.
+func (s *TaskStore) MemoryUsage() int64 {
+	return s.used.Load()
+}
+
+// reserve accounts for size more bytes of tasks, failing with ErrStoreFull
+// when that exceeds the hard limit.
+func (s *TaskStore) reserve(size int64) error {
+	for {
+		used := s.used.Load()
+		if s.limits.Hard > 0 && used+size > s.limits.Hard {
+			return ErrStoreFull
+		}
+		if s.used.CompareAndSwap(used, used+size) {
+			if soft := s.limits.Soft; soft > 0 && used <= soft && used+size > soft && s.limits.OnSoftLimit != nil {
+				s.limits.OnSoftLimit(used + size)
+			}
+			return nil
+		}
+	}
+}
+
+// release accounts for size fewer bytes of tasks.
+func (s *TaskStore) release(size int64) {
+	s.used.Add(-size)
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Store backends selectable through configuration.
+const (
+	BackendMemory   = "memory"
+	BackendFile     = "file"
+	BackendSQLite   = "sqlite"
+	BackendPostgres = "postgres"
+	BackendRedis    = "redis"
+)
+
+// Backends lists every supported store backend.
+var Backends = []string{BackendMemory, BackendFile, BackendSQLite, BackendPostgres, BackendRedis}
+
+// DefaultWorkspace is the workspace kept in the store configured by the
+// backend and DSN as they are.
+const DefaultWorkspace = "default"
+
+// Open constructs the store for backend, connecting with dsn, and verifies
+// it can serve requests. The memory backend ignores dsn; the file backend
+// takes a path, sqlite a database file and postgres and redis a URL.
+func Open(backend, dsn string) (Store, error) {
+	return OpenWorkspace(backend, dsn, DefaultWorkspace)
+}
+
+// OpenWorkspace is Open for the tasks of workspace, which are kept apart
+// from those of other workspaces: the memory backend keeps them in a store
+// of their own, the file and sqlite backends in a file next to the one dsn
+// names (see WorkspaceDSN) and redis under keys of their own. The postgres
+// backend only keeps the default workspace.
+func OpenWorkspace(backend, dsn, workspace string) (Store, error) {
+	var s Store
+	var err error
+
+	if workspace != DefaultWorkspace && backend == BackendPostgres {
+		return nil, fmt.Errorf("the %s store does not support workspaces", backend)
+	}
+	dsn = WorkspaceDSN(backend, dsn, workspace)
+	switch backend {
+	case BackendMemory:
+		return NewTaskStore(), nil
+	case BackendFile:
+		s, err = NewFileStore(dsn)
+	case BackendSQLite, BackendPostgres:
+		s, err = OpenSQLStore(backend, dsn)
+	case BackendRedis:
+		prefix := redisKeyPrefix
+		if workspace != DefaultWorkspace {
+			prefix += "w:" + workspace + ":"
+		}
+		s, err = newRedisStore(dsn, prefix)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", backend)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s store: %w", backend, err)
+	}
+
+	if err := s.Ping(context.Background()); err != nil {
+		Close(s)
+		return nil, fmt.Errorf("%s store is not reachable: %w", backend, err)
+	}
+	return s, nil
+}
+
+// ValidateDSN parses dsn as a DSN of backend without connecting, so
+// malformed DSNs are reported with the other configuration problems rather
+// than when the store is opened. File paths are not checked; postgres
+// takes a postgres:// URL or key=value pairs.
+func ValidateDSN(backend, dsn string) error {
+	switch backend {
+	case BackendSQLite:
+		if _, params, ok := strings.Cut(dsn, "?"); ok {
+			if _, err := url.ParseQuery(params); err != nil {
+				return fmt.Errorf("invalid sqlite DSN parameters: %w", err)
+			}
+		}
+	case BackendPostgres:
+		if !validPostgresDSN(dsn) {
+			return fmt.Errorf("invalid postgres DSN, expected postgres://[user:password@]host[:port]/database or key=value pairs")
+		}
+	case BackendRedis:
+		if _, err := newRedisPool(dsn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validPostgresDSN reports whether dsn is a postgres URL or a list of
+// key=value pairs, in which values may be quoted with single quotes.
+func validPostgresDSN(dsn string) bool {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		return err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql")
+	}
+
+	pairs := 0
+	for rest := strings.TrimSpace(dsn); rest != ""; rest = strings.TrimSpace(rest) {
+		key, value, ok := strings.Cut(rest, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" || strings.ContainsAny(key, " \t'") {
+			return false
+		}
+		value = strings.TrimLeft(value, " \t")
+		if quoted, ok := strings.CutPrefix(value, "'"); ok {
+			end := strings.Index(strings.ReplaceAll(quoted, `\'`, "  "), "'")
+			if end < 0 {
+				return false // Unterminated quote
+			}
+			value = quoted[end+1:]
+		}
+		rest = ""
+		if i := strings.IndexAny(value, " \t"); i >= 0 {
+			rest = value[i:]
+		}
+		pairs++
+	}
+	return pairs > 0
+}
+
+// ValidWorkspaceName reports whether name can name a workspace: up to 63
+// lowercase letters, digits and dashes, starting with a letter or digit.
+// Such names are safe in paths, file names and Redis keys.
+func ValidWorkspaceName(name string) bool {
+	if name == "" || len(name) > 63 || name[0] == '-' {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// WorkspaceDSN returns the DSN the tasks of workspace are kept at. For the
+// file and sqlite backends the workspace is inserted before the extension
+// of the file name, so tasks.json keeps the default workspace and
+// tasks.team.json the team workspace; other DSNs are returned as they are.
+func WorkspaceDSN(backend, dsn, workspace string) string {
+	if workspace == DefaultWorkspace || backend != BackendFile && backend != BackendSQLite {
+		return dsn
+	}
+	path, params, hasParams := strings.Cut(dsn, "?")
+	ext := filepath.Ext(path)
+	path = strings.TrimSuffix(path, ext) + "." + workspace + ext
+	if hasParams {
+		return path + "?" + params
+	}
+	return path
+}
+
+// Close releases the connections held by s, if any.
+func Close(s Store) error {
+	if c, ok := s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
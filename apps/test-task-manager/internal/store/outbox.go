@@ -0,0 +1,57 @@
+package store
+
+import (
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// Types of the events recorded in the outbox.
+const (
+	EventTaskCreated   = "task.created"
+	EventTaskUpdated   = "task.updated"
+	EventTaskCompleted = "task.completed"
+	EventTaskReopened  = "task.reopened"
+	EventTaskDeleted   = "task.deleted"
+)
+
+// EventTaskPriorityChanged is reported by the task service, in its change
+// feed and to its observers, for changes of only the priority of a task.
+// The outbox records those as EventTaskUpdated, like every other update.
+const EventTaskPriorityChanged = "task.priority_changed"
+
+// Event is a change to a task. Deleted tasks are recorded as they were
+// before the deletion.
+type Event struct {
+	Seq  int64      `json:"seq"` // Ascending in the order the events were recorded
+	Type string     `json:"type"`
+	Task model.Task `json:"task"`
+	At   time.Time  `json:"at"`
+	// PreviousPriority is the priority before an EventTaskPriorityChanged.
+	PreviousPriority string `json:"previousPriority,omitempty"`
+	// Workspace is the workspace of the task when it is not the default one.
+	// Stores leave it empty; it is set when the events are relayed.
+	Workspace string `json:"workspace,omitempty"`
+}
+
+// Outbox is implemented by stores that record an Event with every change
+// to a task, in the same write as the change itself, so no change is lost
+// between storing it and publishing it.
+type Outbox interface {
+	// EnableOutbox starts recording events. It must be called before the
+	// store is used.
+	EnableOutbox()
+	// PendingEvents returns up to limit recorded events that have not been
+	// acknowledged yet, in the order they were recorded.
+	PendingEvents(limit int) ([]Event, error)
+	// AckEvents removes delivered events from the outbox.
+	AckEvents(seqs ...int64) error
+}
+
+// toggleEvent returns the type of the event recording that task was toggled.
+func toggleEvent(task model.Task) string {
+	if task.Completed {
+		return EventTaskCompleted
+	}
+	return EventTaskReopened
+}
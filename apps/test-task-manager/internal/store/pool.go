@@ -0,0 +1,46 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+)
+
+// PoolConfig sizes the connection pool of a store. Zero values leave the
+// database/sql defaults in place, except MaxIdleConns, where 0 keeps no
+// idle connections.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// Pooled is implemented by stores with a database/sql connection pool.
+type Pooled interface {
+	ConfigurePool(c PoolConfig)
+	PoolStats() sql.DBStats
+}
+
+// RegisterPoolMetrics exposes the connection pool statistics of p, the
+// store of workspace, on reg. They are read at collection time.
+func RegisterPoolMetrics(reg *metrics.Registry, p Pooled, workspace string) {
+	gauges := []struct {
+		name, help string
+		value      func(s sql.DBStats) float64
+	}{
+		{"db_pool_max_open_connections", "Maximum number of open database connections.", func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+		{"db_pool_open_connections", "Number of open database connections.", func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+		{"db_pool_in_use_connections", "Number of database connections in use.", func(s sql.DBStats) float64 { return float64(s.InUse) }},
+		{"db_pool_idle_connections", "Number of idle database connections.", func(s sql.DBStats) float64 { return float64(s.Idle) }},
+		{"db_pool_wait_count", "Total number of connections waited for.", func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+		{"db_pool_wait_duration_seconds", "Total time spent waiting for a connection.", func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+		{"db_pool_max_idle_closed", "Total number of connections closed because of the idle connection limit.", func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+		{"db_pool_max_idle_time_closed", "Total number of connections closed because they were idle for too long.", func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+		{"db_pool_max_lifetime_closed", "Total number of connections closed because they reached their maximum lifetime.", func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+	}
+	for _, g := range gauges {
+		reg.GaugeFuncVec(g.name, g.help, "workspace").Set(func() float64 { return g.value(p.PoolStats()) }, workspace)
+	}
+}
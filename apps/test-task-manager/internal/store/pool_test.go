@@ -0,0 +1,64 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+)
+
+// fakePool reports fixed statistics.
+type fakePool struct {
+	config PoolConfig
+	stats  sql.DBStats
+}
+
+func (p *fakePool) ConfigurePool(c PoolConfig) { p.config = c }
+
+func (p *fakePool) PoolStats() sql.DBStats { return p.stats }
+
+func TestRegisterPoolMetrics(t *testing.T) {
+	pool := &fakePool{stats: sql.DBStats{
+		MaxOpenConnections: 25,
+		OpenConnections:    4,
+		InUse:              3,
+		Idle:               1,
+		WaitCount:          7,
+		WaitDuration:       1500 * time.Millisecond,
+		MaxIdleClosed:      2,
+		MaxIdleTimeClosed:  5,
+		MaxLifetimeClosed:  6,
+	}}
+	reg := metrics.NewRegistry()
+	RegisterPoolMetrics(reg, pool, DefaultWorkspace)
+
+	read := func() string {
+		var out strings.Builder
+		reg.Write(&out)
+		return out.String()
+	}
+	exposed := read()
+	for _, line := range []string{
+		"db_pool_max_open_connections{workspace=\"default\"} 25",
+		"db_pool_open_connections{workspace=\"default\"} 4",
+		"db_pool_in_use_connections{workspace=\"default\"} 3",
+		"db_pool_idle_connections{workspace=\"default\"} 1",
+		"db_pool_wait_count{workspace=\"default\"} 7",
+		"db_pool_wait_duration_seconds{workspace=\"default\"} 1.5",
+		"db_pool_max_idle_closed{workspace=\"default\"} 2",
+		"db_pool_max_idle_time_closed{workspace=\"default\"} 5",
+		"db_pool_max_lifetime_closed{workspace=\"default\"} 6",
+	} {
+		if !strings.Contains(exposed, line+"\n") {
+			t.Errorf("expected %q in\n%s", line, exposed)
+		}
+	}
+
+	// The statistics are read when the metrics are collected.
+	pool.stats.InUse = 9
+	if exposed := read(); !strings.Contains(exposed, "db_pool_in_use_connections{workspace=\"default\"} 9\n") {
+		t.Errorf("expected the current statistics, got\n%s", exposed)
+	}
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"strconv"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// Query selects tasks. Zero fields match every task; set fields must all match.
+type Query struct {
+	Priority  string
+	Completed *bool
+	// DueAfter and DueBefore bound the due date, inclusive and exclusive
+	// respectively. Tasks without a due date never match a bound.
+	DueAfter  *time.Time
+	DueBefore *time.Time
+	// CreatedBefore selects tasks created before it, exclusive.
+	CreatedBefore *time.Time
+	// ChangedBefore selects tasks last changed before it, exclusive; see
+	// model.Task.ChangedAt.
+	ChangedBefore *time.Time
+}
+
+// Matches reports whether task is selected by q.
+func (q Query) Matches(task model.Task) bool {
+	if q.Priority != "" && task.Priority != q.Priority {
+		return false
+	}
+	if q.Completed != nil && task.Completed != *q.Completed {
+		return false
+	}
+	if q.DueAfter != nil && (task.DueDate == nil || task.DueDate.Before(*q.DueAfter)) {
+		return false
+	}
+	if q.DueBefore != nil && (task.DueDate == nil || !task.DueDate.Before(*q.DueBefore)) {
+		return false
+	}
+	if q.CreatedBefore != nil && !task.CreatedAt.Before(*q.CreatedBefore) {
+		return false
+	}
+	if q.ChangedBefore != nil && !task.ChangedAt().Before(*q.ChangedBefore) {
+		return false
+	}
+	return true
+}
+
+// filterTasks returns the tasks matching q, for backends without indexes.
+func filterTasks(tasks []model.Task, q Query) []model.Task {
+	matched := make([]model.Task, 0)
+	for _, task := range tasks {
+		if q.Matches(task) {
+			matched = append(matched, task)
+		}
+	}
+	return matched
+}
+
+// compareIDs orders numeric task IDs numerically, which is creation order.
+func compareIDs(a, b string) int {
+	x, _ := strconv.Atoi(a)
+	y, _ := strconv.Atoi(b)
+	return x - y
+}
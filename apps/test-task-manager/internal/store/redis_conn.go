@@ -0,0 +1,257 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// redisTimeout bounds dialing and every command round trip.
+	redisTimeout = 5 * time.Second
+	// redisMaxIdle is the number of idle connections kept for reuse.
+	redisMaxIdle = 16
+)
+
+// redisError is an error reply sent by the server. The connection stays usable.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn is a single connection speaking the RESP protocol.
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// do sends a command and reads its reply. Replies are decoded to string
+// (status), int64, []byte (bulk), []any (array) or nil.
+func (c *redisConn) do(ctx context.Context, args ...string) (any, error) {
+	replies, err := c.pipeline(ctx, [][]string{args})
+	if replies == nil {
+		return nil, err
+	}
+	return replies[0], err
+}
+
+// pipeline sends commands in one write and reads all their replies. It
+// returns the first error reply, after reading every reply so the
+// connection stays usable. The round trip ends at the deadline of ctx when
+// it is sooner than redisTimeout, and is interrupted when ctx is canceled,
+// returning the error of ctx.
+func (c *redisConn) pipeline(ctx context.Context, commands [][]string) (replies []any, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(redisTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { c.conn.SetDeadline(time.Now()) })
+	defer func() {
+		stop()
+		if err != nil && !isRedisError(err) && ctx.Err() != nil {
+			replies, err = nil, ctx.Err()
+		}
+	}()
+
+	for _, args := range commands {
+		fmt.Fprintf(c.w, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies = make([]any, len(commands))
+	var firstErr error
+	for i := range commands {
+		reply, err := c.readReply()
+		if err != nil && !isRedisError(err) {
+			return nil, err
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		replies[i] = reply
+	}
+	return replies, firstErr
+}
+
+func (c *redisConn) readReply() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch kind, rest := line[0], line[1:]; kind {
+	case '+':
+		return rest, nil
+	case '-':
+		return nil, redisError(rest)
+	case ':':
+		return strconv.ParseInt(rest, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			// Errors inside an EXEC reply belong to a single queued command.
+			if items[i], err = c.readReply(); err != nil && !isRedisError(err) {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// redisPool hands out connections to a single Redis server.
+type redisPool struct {
+	addr     string
+	username string
+	password string
+	db       string
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+// newRedisPool parses a redis://[user:password@]host[:port][/db] DSN.
+func newRedisPool(dsn string) (*redisPool, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("invalid redis DSN, expected redis://[user:password@]host[:port][/db]")
+	}
+
+	p := &redisPool{addr: u.Host, db: strings.TrimPrefix(u.Path, "/")}
+	if u.Port() == "" {
+		p.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if p.db != "" {
+		if _, err := strconv.Atoi(p.db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", p.db)
+		}
+	}
+	if u.User != nil {
+		p.username = u.User.Username()
+		p.password, _ = u.User.Password()
+	}
+	return p, nil
+}
+
+// do runs a single command on a pooled connection.
+func (p *redisPool) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(ctx, args...)
+	p.put(conn, err)
+	return reply, err
+}
+
+// get returns an idle connection or dials a new one.
+func (p *redisPool) get(ctx context.Context) (*redisConn, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		conn := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return conn, nil
+	}
+	p.mu.Unlock()
+
+	return p.dial(ctx)
+}
+
+// put returns conn to the pool unless err left it in an unknown state.
+func (p *redisPool) put(conn *redisConn, err error) {
+	if err != nil && !isRedisError(err) && !errors.Is(err, ErrTaskNotFound) {
+		conn.conn.Close()
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.idle) >= redisMaxIdle {
+		conn.conn.Close()
+		return
+	}
+	p.idle = append(p.idle, conn)
+}
+
+// close closes every idle connection.
+func (p *redisPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.idle {
+		conn.conn.Close()
+	}
+	p.idle = nil
+}
+
+func (p *redisPool) dial(ctx context.Context) (*redisConn, error) {
+	dialer := net.Dialer{Timeout: redisTimeout}
+	nc, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	if p.password != "" {
+		args := []string{"AUTH", p.password}
+		if p.username != "" {
+			args = []string{"AUTH", p.username, p.password}
+		}
+		if _, err := conn.do(ctx, args...); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	if p.db != "" {
+		if _, err := conn.do(ctx, "SELECT", p.db); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func isRedisError(err error) bool {
+	var re redisError
+	return errors.As(err, &re)
+}
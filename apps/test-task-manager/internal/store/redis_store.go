@@ -0,0 +1,431 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+const (
+	// redisKeyPrefix prefixes the keys of the default workspace; those of
+	// other workspaces add "w:<workspace>:" to it.
+	redisKeyPrefix = "ttm:"
+	// redisToggleRetries bounds the optimistic transaction retries of Toggle
+	// and Update.
+	redisToggleRetries = 10
+	// redisBatchSize is the number of tasks written per HSET of CreateMany.
+	redisBatchSize = 500
+)
+
+// RedisStore stores tasks in a Redis hash, and with tombstones kept the
+// tombstones of deleted tasks in a sorted set.
+type RedisStore struct {
+	pool          *redisPool
+	tasksKey      string // Hash holding every task as JSON, keyed by ID
+	nextIDKey     string // Counter used to assign task IDs
+	tombstonesKey string // Sorted set of the IDs of deleted tasks, scored by deletion time in milliseconds
+	retention     time.Duration
+}
+
+// NewRedisStore connects to the Redis server at dsn, e.g.
+// redis://:password@localhost:6379/0.
+func NewRedisStore(dsn string) (*RedisStore, error) {
+	return newRedisStore(dsn, redisKeyPrefix)
+}
+
+// newRedisStore connects to the Redis server at dsn, keeping the tasks
+// under keys starting with prefix.
+func newRedisStore(dsn, prefix string) (*RedisStore, error) {
+	pool, err := newRedisPool(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{pool: pool, tasksKey: prefix + "tasks", nextIDKey: prefix + "tasks:next_id", tombstonesKey: prefix + "tombstones"}, nil
+}
+
+// GetAll returns all tasks in creation order.
+func (s *RedisStore) GetAll(ctx context.Context) ([]model.Task, error) {
+	reply, err := s.pool.do(ctx, "HVALS", s.tasksKey)
+	if err != nil {
+		return nil, err
+	}
+	values, _ := reply.([]any)
+
+	tasks := make([]model.Task, 0, len(values))
+	for _, v := range values {
+		var task model.Task
+		if err := json.Unmarshal(v.([]byte), &task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		a, _ := strconv.Atoi(tasks[i].ID)
+		b, _ := strconv.Atoi(tasks[j].ID)
+		return a < b
+	})
+	return tasks, nil
+}
+
+// Find returns the tasks matching q. The hash has no secondary indexes,
+// so all tasks are fetched and filtered.
+func (s *RedisStore) Find(ctx context.Context, q Query) ([]model.Task, error) {
+	tasks, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return filterTasks(tasks, q), nil
+}
+
+// GetByID returns a task by ID.
+func (s *RedisStore) GetByID(ctx context.Context, id string) (model.Task, error) {
+	reply, err := s.pool.do(ctx, "HGET", s.tasksKey, id)
+	if err != nil {
+		return model.Task{}, err
+	}
+	return decodeRedisTask(reply)
+}
+
+// Create adds a new task.
+func (s *RedisStore) Create(ctx context.Context, task model.Task) (model.Task, error) {
+	reply, err := s.pool.do(ctx, "INCR", s.nextIDKey)
+	if err != nil {
+		return model.Task{}, err
+	}
+
+	task.ID = strconv.FormatInt(reply.(int64), 10)
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+
+	content, err := json.Marshal(task)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if _, err := s.pool.do(ctx, "HSET", s.tasksKey, task.ID, string(content)); err != nil {
+		return model.Task{}, err
+	}
+	return task, nil
+}
+
+// CreateMany adds tasks. The IDs are reserved with a single INCRBY and the
+// tasks written with HSETs of up to redisBatchSize tasks, sent in one
+// pipeline inside MULTI/EXEC so they are stored together.
+func (s *RedisStore) CreateMany(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	if len(tasks) == 0 {
+		return []model.Task{}, nil
+	}
+
+	reply, err := s.pool.do(ctx, "INCRBY", s.nextIDKey, strconv.Itoa(len(tasks)))
+	if err != nil {
+		return nil, err
+	}
+	first := reply.(int64) - int64(len(tasks)) + 1
+
+	created := make([]model.Task, len(tasks))
+	now := time.Now()
+	for i, task := range tasks {
+		task.ID = strconv.FormatInt(first+int64(i), 10)
+		if task.CreatedAt.IsZero() {
+			task.CreatedAt = now
+		}
+		created[i] = task
+	}
+
+	commands := [][]string{{"MULTI"}}
+	for batch := range slices.Chunk(created, redisBatchSize) {
+		hset := []string{"HSET", s.tasksKey}
+		for _, task := range batch {
+			content, err := json.Marshal(task)
+			if err != nil {
+				return nil, err
+			}
+			hset = append(hset, task.ID, string(content))
+		}
+		commands = append(commands, hset)
+	}
+	commands = append(commands, []string{"EXEC"})
+
+	conn, err := s.pool.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.pipeline(ctx, commands)
+	s.pool.put(conn, err)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Toggle changes completion status. The update runs in an optimistic
+// transaction, so concurrent toggles of the same task are not lost.
+func (s *RedisStore) Toggle(ctx context.Context, id string) (model.Task, error) {
+	conn, err := s.pool.get(ctx)
+	if err != nil {
+		return model.Task{}, err
+	}
+
+	for range redisToggleRetries {
+		var task model.Task
+		var committed bool
+		task, committed, err = s.modifyOnce(ctx, conn, id, func(task *model.Task) {
+			task.Completed = !task.Completed
+			now := time.Now()
+			task.UpdatedAt = &now
+			if task.Completed {
+				task.CompletedAt = &now
+			} else {
+				task.CompletedAt = nil
+			}
+		})
+		if err != nil || committed {
+			s.pool.put(conn, err)
+			return task, err
+		}
+	}
+
+	s.pool.put(conn, nil)
+	return model.Task{}, ErrConflict
+}
+
+// Update replaces the editable fields of a task.
+func (s *RedisStore) Update(ctx context.Context, update model.Task) (model.Task, error) {
+	conn, err := s.pool.get(ctx)
+	if err != nil {
+		return model.Task{}, err
+	}
+
+	for range redisToggleRetries {
+		var task model.Task
+		var committed bool
+		task, committed, err = s.modifyOnce(ctx, conn, update.ID, func(task *model.Task) {
+			applyUpdate(task, update)
+		})
+		if err != nil || committed {
+			s.pool.put(conn, err)
+			return task, err
+		}
+	}
+
+	s.pool.put(conn, nil)
+	return model.Task{}, ErrConflict
+}
+
+// Reassign changes the tasks matching q in an optimistic transaction, so
+// they are changed together and changes made meanwhile are not lost.
+func (s *RedisStore) Reassign(ctx context.Context, q Query, priority, color string) ([]model.Task, error) {
+	conn, err := s.pool.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for range redisToggleRetries {
+		var changed []model.Task
+		var committed bool
+		changed, committed, err = s.reassignOnce(ctx, conn, q, priority, color)
+		if err != nil || committed {
+			s.pool.put(conn, err)
+			return changed, err
+		}
+	}
+
+	s.pool.put(conn, nil)
+	return nil, ErrConflict
+}
+
+// reassignOnce changes the tasks matching q in a single WATCH/MULTI/EXEC
+// round. committed is false when a task changed in the meantime and the
+// round must be retried.
+func (s *RedisStore) reassignOnce(ctx context.Context, conn *redisConn, q Query, priority, color string) (changed []model.Task, committed bool, err error) {
+	if _, err := conn.do(ctx, "WATCH", s.tasksKey); err != nil {
+		return nil, false, err
+	}
+
+	reply, err := conn.do(ctx, "HVALS", s.tasksKey)
+	if err != nil {
+		return nil, false, err
+	}
+	values, _ := reply.([]any)
+	changed = make([]model.Task, 0)
+	hset := []string{"HSET", s.tasksKey}
+	for _, v := range values {
+		var task model.Task
+		if err := json.Unmarshal(v.([]byte), &task); err != nil {
+			conn.do(ctx, "UNWATCH")
+			return nil, false, err
+		}
+		if !q.Matches(task) {
+			continue
+		}
+		applyReassign(&task, priority, color)
+		content, err := json.Marshal(task)
+		if err != nil {
+			conn.do(ctx, "UNWATCH")
+			return nil, false, err
+		}
+		hset = append(hset, task.ID, string(content))
+		changed = append(changed, task)
+	}
+	if len(changed) == 0 {
+		_, err := conn.do(ctx, "UNWATCH")
+		return changed, err == nil, err
+	}
+	slices.SortFunc(changed, func(a, b model.Task) int { return compareIDs(a.ID, b.ID) })
+
+	if _, err := conn.do(ctx, "MULTI"); err != nil {
+		return nil, false, err
+	}
+	if _, err := conn.do(ctx, hset...); err != nil {
+		return nil, false, err
+	}
+	reply, err = conn.do(ctx, "EXEC")
+	if err != nil {
+		return nil, false, err
+	}
+	return changed, reply != nil, nil
+}
+
+// modifyOnce applies change to a task in a single WATCH/MULTI/EXEC round.
+// committed is false when the task changed in the meantime and the round
+// must be retried.
+func (s *RedisStore) modifyOnce(ctx context.Context, conn *redisConn, id string, change func(task *model.Task)) (task model.Task, committed bool, err error) {
+	if _, err := conn.do(ctx, "WATCH", s.tasksKey); err != nil {
+		return model.Task{}, false, err
+	}
+
+	reply, err := conn.do(ctx, "HGET", s.tasksKey, id)
+	if err == nil {
+		task, err = decodeRedisTask(reply)
+	}
+	if err != nil {
+		if _, unwatchErr := conn.do(ctx, "UNWATCH"); unwatchErr != nil {
+			return model.Task{}, false, unwatchErr
+		}
+		return model.Task{}, false, err
+	}
+
+	change(&task)
+
+	content, err := json.Marshal(task)
+	if err != nil {
+		return model.Task{}, false, err
+	}
+
+	if _, err := conn.do(ctx, "MULTI"); err != nil {
+		return model.Task{}, false, err
+	}
+	if _, err := conn.do(ctx, "HSET", s.tasksKey, id, string(content)); err != nil {
+		return model.Task{}, false, err
+	}
+	reply, err = conn.do(ctx, "EXEC")
+	if err != nil {
+		return model.Task{}, false, err
+	}
+	return task, reply != nil, nil
+}
+
+// Size returns the memory Redis uses for the hash of tasks.
+func (s *RedisStore) Size() (int64, error) {
+	reply, err := s.pool.do(context.Background(), "MEMORY", "USAGE", s.tasksKey)
+	if err != nil {
+		return 0, err
+	}
+	size, _ := reply.(int64) // Nil without tasks
+	return size, nil
+}
+
+// Delete removes a task.
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	reply, err := s.pool.do(ctx, "HDEL", s.tasksKey, id)
+	if err != nil {
+		return err
+	}
+	if reply.(int64) == 0 {
+		return ErrTaskNotFound
+	}
+	return s.bury(ctx, id)
+}
+
+// KeepTombstones makes the store keep the tombstones of deleted tasks for
+// retention.
+func (s *RedisStore) KeepTombstones(retention time.Duration) {
+	s.retention = retention
+}
+
+// Tombstones returns the tombstones of the tasks deleted at or after since.
+func (s *RedisStore) Tombstones(ctx context.Context, since time.Time) ([]Tombstone, error) {
+	if err := checkTombstonesSince(since, s.retention); err != nil {
+		return nil, err
+	}
+	reply, err := s.pool.do(ctx, "ZRANGEBYSCORE", s.tombstonesKey, strconv.FormatInt(since.UnixMilli(), 10), "+inf", "WITHSCORES")
+	if err != nil {
+		return nil, err
+	}
+	values, _ := reply.([]any) // Member, score, member, score...
+
+	tombstones := make([]Tombstone, 0, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		id, _ := values[i].([]byte)
+		score, _ := values[i+1].([]byte)
+		millis, err := strconv.ParseFloat(string(score), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tombstone score in redis: %w", err)
+		}
+		tombstones = append(tombstones, Tombstone{ID: string(id), DeletedAt: time.UnixMilli(int64(millis))})
+	}
+	return tombstones, nil
+}
+
+// bury adds a tombstone for the deleted task with id, when tombstones are
+// kept, and removes those past the retention. It runs right after the
+// delete, which Redis cannot make conditional on the task existing.
+func (s *RedisStore) bury(ctx context.Context, id string) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	now := time.Now()
+	conn, err := s.pool.get(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = conn.pipeline(ctx, [][]string{
+		{"ZADD", s.tombstonesKey, strconv.FormatInt(now.UnixMilli(), 10), id},
+		{"ZREMRANGEBYSCORE", s.tombstonesKey, "-inf", "(" + strconv.FormatInt(now.Add(-s.retention).UnixMilli(), 10)},
+	})
+	s.pool.put(conn, err)
+	return err
+}
+
+// Ping verifies the connection to Redis.
+func (s *RedisStore) Ping(ctx context.Context) error {
+	_, err := s.pool.do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections.
+func (s *RedisStore) Close() error {
+	s.pool.close()
+	return nil
+}
+
+// decodeRedisTask decodes an HGET reply; a nil reply means the task does not exist.
+func decodeRedisTask(reply any) (model.Task, error) {
+	content, ok := reply.([]byte)
+	if !ok {
+		return model.Task{}, ErrTaskNotFound
+	}
+
+	var task model.Task
+	if err := json.Unmarshal(content, &task); err != nil {
+		return model.Task{}, fmt.Errorf("invalid task in redis: %w", err)
+	}
+	return task, nil
+}
@@ -0,0 +1,693 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// sqlMigrations are the schema changes of each SQL backend, applied in
+// order. Applied migrations must never change; add a new one instead.
+var sqlMigrations = map[string][]string{
+	BackendSQLite: {`CREATE TABLE tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		completed BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP NOT NULL,
+		completed_at TIMESTAMP NULL,
+		priority TEXT NOT NULL,
+		color TEXT NOT NULL
+	)`,
+		`ALTER TABLE tasks ADD COLUMN due_date TIMESTAMP NULL`,
+		`CREATE INDEX tasks_priority ON tasks (priority)`,
+		`CREATE INDEX tasks_completed ON tasks (completed)`,
+		`CREATE INDEX tasks_due_date ON tasks (due_date)`,
+		`CREATE TABLE outbox (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		task TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	)`,
+		`ALTER TABLE tasks ADD COLUMN updated_at TIMESTAMP NULL`,
+		`ALTER TABLE tasks ADD COLUMN created_by TEXT NOT NULL DEFAULT ''`,
+		`CREATE TABLE tombstones (
+		id TEXT NOT NULL,
+		deleted_at TIMESTAMP NOT NULL
+	)`,
+		`CREATE INDEX tombstones_deleted_at ON tombstones (deleted_at)`,
+	},
+	BackendPostgres: {`CREATE TABLE tasks (
+		id BIGSERIAL PRIMARY KEY,
+		title TEXT NOT NULL,
+		completed BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMPTZ NOT NULL,
+		completed_at TIMESTAMPTZ NULL,
+		priority TEXT NOT NULL,
+		color TEXT NOT NULL
+	)`,
+		`ALTER TABLE tasks ADD COLUMN due_date TIMESTAMPTZ NULL`,
+		`CREATE INDEX tasks_priority ON tasks (priority)`,
+		`CREATE INDEX tasks_completed ON tasks (completed)`,
+		`CREATE INDEX tasks_due_date ON tasks (due_date)`,
+		`CREATE TABLE outbox (
+		seq BIGSERIAL PRIMARY KEY,
+		type TEXT NOT NULL,
+		task TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	)`,
+		`ALTER TABLE tasks ADD COLUMN updated_at TIMESTAMPTZ NULL`,
+		`ALTER TABLE tasks ADD COLUMN created_by TEXT NOT NULL DEFAULT ''`,
+		`CREATE TABLE tombstones (
+		id TEXT NOT NULL,
+		deleted_at TIMESTAMPTZ NOT NULL
+	)`,
+		`CREATE INDEX tombstones_deleted_at ON tombstones (deleted_at)`,
+	},
+}
+
+// sqlMigrationsTable records the version of every applied migration.
+const sqlMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	applied_at TIMESTAMP NOT NULL
+)`
+
+const taskColumns = "id, title, completed, created_at, completed_at, priority, color, due_date, updated_at, created_by"
+
+// sqlBatchSize is the number of rows per INSERT of CreateMany, which keeps
+// the statements below the bind parameter limits of the databases.
+const sqlBatchSize = 100
+
+// SQLStore stores tasks in a SQL database through database/sql. The
+// database driver is registered under the backend name ("sqlite" or
+// "postgres") by importing it in the main package. With the outbox
+// enabled, the events of every change are inserted into the outbox table
+// in the transaction of the change, and with tombstones kept, the
+// tombstones of deleted tasks into the tombstones table.
+type SQLStore struct {
+	db        *sql.DB
+	backend   string
+	outbox    bool
+	retention time.Duration // Of the tombstones; none are kept when 0
+}
+
+// sqlQuerier is implemented by *sql.DB and *sql.Tx.
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// OpenSQLStore connects to the database of backend. The schema must be
+// brought up to date with Migrate before the store is used.
+func OpenSQLStore(backend, dsn string) (*SQLStore, error) {
+	if _, ok := sqlMigrations[backend]; !ok {
+		return nil, fmt.Errorf("unsupported SQL backend %q", backend)
+	}
+	if dsn == "" {
+		return nil, errors.New("a DSN is required")
+	}
+	if !slices.Contains(sql.Drivers(), backend) {
+		return nil, fmt.Errorf("no database/sql driver registered as %q in this build", backend)
+	}
+
+	db, err := sql.Open(backend, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLStore{db: db, backend: backend}, nil
+}
+
+// GetAll returns all tasks in creation order.
+func (s *SQLStore) GetAll(ctx context.Context) ([]model.Task, error) {
+	return s.queryTasks(ctx, "SELECT "+taskColumns+" FROM tasks ORDER BY id")
+}
+
+// queryTasks runs a query returning task rows.
+func (s *SQLStore) queryTasks(ctx context.Context, query string, args ...any) ([]model.Task, error) {
+	return s.queryTasksTx(ctx, s.db, query, args...)
+}
+
+// queryTasksTx runs a query returning task rows on db, which may be a transaction.
+func (s *SQLStore) queryTasksTx(ctx context.Context, db sqlQuerier, query string, args ...any) ([]model.Task, error) {
+	rows, err := db.QueryContext(ctx, s.bind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := make([]model.Task, 0)
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// Find returns the tasks matching q in creation order, using the indexes
+// on priority, completed and due_date.
+func (s *SQLStore) Find(ctx context.Context, q Query) ([]model.Task, error) {
+	where, args := queryConditions(q)
+	return s.queryTasks(ctx, "SELECT "+taskColumns+" FROM tasks"+where+" ORDER BY id", args...)
+}
+
+// eachBatchSize is the number of tasks Each reads per query.
+const eachBatchSize = 500
+
+// Each calls fn with the tasks matching q in creation order. They are read
+// in batches by ID, so no connection is held while fn runs, which may take
+// long when it writes to a slow client.
+func (s *SQLStore) Each(ctx context.Context, q Query, fn func(model.Task) error) error {
+	where, args := queryConditions(q)
+	if where == "" {
+		where = " WHERE id > ?"
+	} else {
+		where += " AND id > ?"
+	}
+	query := "SELECT " + taskColumns + " FROM tasks" + where + " ORDER BY id LIMIT " + strconv.Itoa(eachBatchSize)
+	var after int64
+	for {
+		tasks, err := s.queryTasks(ctx, query, append(args[:len(args):len(args)], after)...)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+		if len(tasks) < eachBatchSize {
+			return nil
+		}
+		after, _ = parseID(tasks[len(tasks)-1].ID)
+	}
+}
+
+// queryConditions returns the WHERE clause selecting the tasks matching q,
+// empty when q matches every task, and its arguments.
+func queryConditions(q Query) (string, []any) {
+	var conditions []string
+	var args []any
+	if q.Priority != "" {
+		conditions = append(conditions, "priority = ?")
+		args = append(args, q.Priority)
+	}
+	if q.Completed != nil {
+		conditions = append(conditions, "completed = ?")
+		args = append(args, *q.Completed)
+	}
+	if q.DueAfter != nil {
+		conditions = append(conditions, "due_date >= ?")
+		args = append(args, q.DueAfter.UTC())
+	}
+	if q.DueBefore != nil {
+		conditions = append(conditions, "due_date < ?")
+		args = append(args, q.DueBefore.UTC())
+	}
+	if q.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, q.CreatedBefore.UTC())
+	}
+	if q.ChangedBefore != nil {
+		conditions = append(conditions, "COALESCE(updated_at, created_at) < ?")
+		args = append(args, q.ChangedBefore.UTC())
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetByID returns a task by ID.
+func (s *SQLStore) GetByID(ctx context.Context, id string) (model.Task, error) {
+	key, ok := parseID(id)
+	if !ok {
+		return model.Task{}, ErrTaskNotFound
+	}
+	return s.queryTask(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = ?", key)
+}
+
+// Create adds a new task.
+func (s *SQLStore) Create(ctx context.Context, task model.Task) (model.Task, error) {
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+
+	var created model.Task
+	err := s.write(ctx, func(db sqlQuerier) (err error) {
+		created, err = s.queryTaskTx(ctx, db,
+			"INSERT INTO tasks (title, completed, created_at, completed_at, priority, color, due_date, updated_at, created_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING "+taskColumns,
+			task.Title, task.Completed, task.CreatedAt.UTC(), nullTime(task.CompletedAt), task.Priority, task.Color, nullTime(task.DueDate), nullTime(task.UpdatedAt), task.CreatedBy,
+		)
+		if err != nil {
+			return err
+		}
+		return s.record(ctx, db, EventTaskCreated, created)
+	})
+	return created, err
+}
+
+// CreateMany adds tasks in a single transaction, with multi-row INSERTs of
+// up to sqlBatchSize tasks.
+func (s *SQLStore) CreateMany(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created := make([]model.Task, 0, len(tasks))
+	now := time.Now()
+	for batch := range slices.Chunk(tasks, sqlBatchSize) {
+		rows := make([]string, len(batch))
+		args := make([]any, 0, len(batch)*9)
+		for i, task := range batch {
+			if task.CreatedAt.IsZero() {
+				task.CreatedAt = now
+			}
+			rows[i] = "(?, ?, ?, ?, ?, ?, ?, ?, ?)"
+			args = append(args, task.Title, task.Completed, task.CreatedAt.UTC(), nullTime(task.CompletedAt), task.Priority, task.Color, nullTime(task.DueDate), nullTime(task.UpdatedAt), task.CreatedBy)
+		}
+
+		inserted, err := s.queryTasksTx(ctx, tx,
+			"INSERT INTO tasks (title, completed, created_at, completed_at, priority, color, due_date, updated_at, created_by) VALUES "+strings.Join(rows, ", ")+" RETURNING "+taskColumns,
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		// Rows get ascending IDs in VALUES order, whatever order they are returned in.
+		slices.SortFunc(inserted, func(a, b model.Task) int { return compareIDs(a.ID, b.ID) })
+		created = append(created, inserted...)
+	}
+	if err := s.record(ctx, tx, EventTaskCreated, created...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Toggle changes completion status.
+func (s *SQLStore) Toggle(ctx context.Context, id string) (model.Task, error) {
+	key, ok := parseID(id)
+	if !ok {
+		return model.Task{}, ErrTaskNotFound
+	}
+
+	var toggled model.Task
+	now := time.Now().UTC()
+	err := s.write(ctx, func(db sqlQuerier) (err error) {
+		// completed still refers to the old value on the right-hand side.
+		toggled, err = s.queryTaskTx(ctx, db,
+			"UPDATE tasks SET completed = NOT completed, completed_at = CASE WHEN completed THEN NULL ELSE ? END, updated_at = ? WHERE id = ? RETURNING "+taskColumns,
+			now, now, key,
+		)
+		if err != nil {
+			return err
+		}
+		return s.record(ctx, db, toggleEvent(toggled), toggled)
+	})
+	return toggled, err
+}
+
+// Update replaces the editable fields of a task.
+func (s *SQLStore) Update(ctx context.Context, update model.Task) (model.Task, error) {
+	key, ok := parseID(update.ID)
+	if !ok {
+		return model.Task{}, ErrTaskNotFound
+	}
+
+	var updated model.Task
+	err := s.write(ctx, func(db sqlQuerier) (err error) {
+		updated, err = s.queryTaskTx(ctx, db,
+			"UPDATE tasks SET title = ?, priority = ?, color = ?, due_date = ?, updated_at = ? WHERE id = ? RETURNING "+taskColumns,
+			update.Title, update.Priority, update.Color, nullTime(update.DueDate), time.Now().UTC(), key,
+		)
+		if err != nil {
+			return err
+		}
+		return s.record(ctx, db, EventTaskUpdated, updated)
+	})
+	return updated, err
+}
+
+// Reassign changes the tasks matching q with a single UPDATE.
+func (s *SQLStore) Reassign(ctx context.Context, q Query, priority, color string) ([]model.Task, error) {
+	var set []string
+	var args []any
+	if priority != "" {
+		set = append(set, "priority = ?")
+		args = append(args, priority)
+	}
+	if color != "" {
+		set = append(set, "color = ?")
+		args = append(args, color)
+	}
+	if len(set) == 0 {
+		return s.Find(ctx, q)
+	}
+	set = append(set, "updated_at = ?")
+	args = append(args, time.Now().UTC())
+	where, whereArgs := queryConditions(q)
+
+	var changed []model.Task
+	err := s.write(ctx, func(db sqlQuerier) (err error) {
+		changed, err = s.queryTasksTx(ctx, db, "UPDATE tasks SET "+strings.Join(set, ", ")+where+" RETURNING "+taskColumns, append(args, whereArgs...)...)
+		if err != nil {
+			return err
+		}
+		slices.SortFunc(changed, func(a, b model.Task) int { return compareIDs(a.ID, b.ID) })
+		return s.record(ctx, db, EventTaskUpdated, changed...)
+	})
+	return changed, err
+}
+
+// Delete removes a task.
+func (s *SQLStore) Delete(ctx context.Context, id string) error {
+	key, ok := parseID(id)
+	if !ok {
+		return ErrTaskNotFound
+	}
+
+	return s.write(ctx, func(db sqlQuerier) error {
+		deleted, err := s.queryTaskTx(ctx, db, "DELETE FROM tasks WHERE id = ? RETURNING "+taskColumns, key)
+		if err != nil {
+			return err
+		}
+		if err := s.bury(ctx, db, deleted.ID); err != nil {
+			return err
+		}
+		return s.record(ctx, db, EventTaskDeleted, deleted)
+	})
+}
+
+// KeepTombstones makes the store keep the tombstones of deleted tasks for
+// retention. The tombstones table is created by the migrations.
+func (s *SQLStore) KeepTombstones(retention time.Duration) {
+	s.retention = retention
+}
+
+// Tombstones returns the tombstones of the tasks deleted at or after since.
+func (s *SQLStore) Tombstones(ctx context.Context, since time.Time) ([]Tombstone, error) {
+	if err := checkTombstonesSince(since, s.retention); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, s.bind("SELECT id, deleted_at FROM tombstones WHERE deleted_at >= ? ORDER BY deleted_at"), since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tombstones := make([]Tombstone, 0)
+	for rows.Next() {
+		var t Tombstone
+		if err := rows.Scan(&t.ID, &t.DeletedAt); err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, t)
+	}
+	return tombstones, rows.Err()
+}
+
+// bury inserts a tombstone for the deleted task with id, when tombstones
+// are kept, and deletes those past the retention, in the transaction of
+// the delete.
+func (s *SQLStore) bury(ctx context.Context, db sqlQuerier, id string) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	if _, err := db.ExecContext(ctx, s.bind("DELETE FROM tombstones WHERE deleted_at < ?"), now.Add(-s.retention)); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, s.bind("INSERT INTO tombstones (id, deleted_at) VALUES (?, ?)"), id, now)
+	return err
+}
+
+// EnableOutbox starts recording events. The outbox table is created by the
+// migrations.
+func (s *SQLStore) EnableOutbox() {
+	s.outbox = true
+}
+
+// PendingEvents returns up to limit unacknowledged events.
+func (s *SQLStore) PendingEvents(limit int) ([]Event, error) {
+	rows, err := s.db.Query(s.bind("SELECT seq, type, task, created_at FROM outbox ORDER BY seq LIMIT ?"), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]Event, 0)
+	for rows.Next() {
+		var e Event
+		var task string
+		if err := rows.Scan(&e.Seq, &e.Type, &task, &e.At); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(task), &e.Task); err != nil {
+			return nil, fmt.Errorf("invalid task in outbox event %d: %w", e.Seq, err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// AckEvents removes delivered events.
+func (s *SQLStore) AckEvents(seqs ...int64) error {
+	for batch := range slices.Chunk(seqs, sqlBatchSize) {
+		args := make([]any, len(batch))
+		for i, seq := range batch {
+			args[i] = seq
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ")
+		if _, err := s.db.Exec(s.bind("DELETE FROM outbox WHERE seq IN ("+placeholders+")"), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write runs fn in a transaction when the outbox is enabled or tombstones
+// are kept, so the events and tombstones fn records are stored together
+// with the change, and directly on the database otherwise.
+func (s *SQLStore) write(ctx context.Context, fn func(db sqlQuerier) error) error {
+	if !s.outbox && s.retention <= 0 {
+		return fn(s.db)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// record inserts an event for each of tasks into the outbox, when enabled.
+// It must run after the change to the tasks within the same transaction:
+// the changed rows stay locked until the commit, so the events of a task
+// get their sequence numbers in the order of its changes.
+func (s *SQLStore) record(ctx context.Context, db sqlQuerier, eventType string, tasks ...model.Task) error {
+	if !s.outbox {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	for batch := range slices.Chunk(tasks, sqlBatchSize) {
+		rows := make([]string, len(batch))
+		args := make([]any, 0, len(batch)*3)
+		for i, task := range batch {
+			content, err := json.Marshal(task)
+			if err != nil {
+				return err
+			}
+			rows[i] = "(?, ?, ?)"
+			args = append(args, eventType, string(content), now)
+		}
+		if _, err := db.ExecContext(ctx, s.bind("INSERT INTO outbox (type, task, created_at) VALUES "+strings.Join(rows, ", ")), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigurePool sizes the connection pool.
+func (s *SQLStore) ConfigurePool(c PoolConfig) {
+	s.db.SetMaxOpenConns(c.MaxOpenConns)
+	s.db.SetMaxIdleConns(c.MaxIdleConns)
+	s.db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	s.db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+}
+
+// PoolStats returns the connection pool statistics.
+func (s *SQLStore) PoolStats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// Ping verifies the database connection.
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Size returns the size of the database file for sqlite, and of the tasks
+// table with its indexes for postgres.
+func (s *SQLStore) Size() (int64, error) {
+	query := "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()"
+	if s.backend == BackendPostgres {
+		query = "SELECT pg_total_relation_size('tasks')"
+	}
+	var size int64
+	err := s.db.QueryRow(query).Scan(&size)
+	return size, err
+}
+
+// Close closes the database connections.
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// Migrate applies the pending schema migrations, each in its own
+// transaction, and returns how many were applied.
+func (s *SQLStore) Migrate() (int, error) {
+	current, err := s.schemaVersion()
+	if err != nil {
+		return 0, err
+	}
+
+	migrations := sqlMigrations[s.backend]
+	for version := current + 1; version <= len(migrations); version++ {
+		if err := s.applyMigration(version, migrations[version-1]); err != nil {
+			return version - current - 1, fmt.Errorf("migration %d failed: %w", version, err)
+		}
+	}
+	return len(migrations) - current, nil
+}
+
+// PendingMigrations returns the number of migrations Migrate would apply.
+func (s *SQLStore) PendingMigrations() (int, error) {
+	current, err := s.schemaVersion()
+	if err != nil {
+		return 0, err
+	}
+	return len(sqlMigrations[s.backend]) - current, nil
+}
+
+// schemaVersion returns the version of the last applied migration.
+func (s *SQLStore) schemaVersion() (int, error) {
+	if _, err := s.db.Exec(sqlMigrationsTable); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := s.db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+func (s *SQLStore) applyMigration(version int, statement string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(statement); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(s.bind("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"), version, time.Now().UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// queryTask runs a query returning a single task row.
+func (s *SQLStore) queryTask(ctx context.Context, query string, args ...any) (model.Task, error) {
+	return s.queryTaskTx(ctx, s.db, query, args...)
+}
+
+// queryTaskTx runs a query returning a single task row on db, which may be a transaction.
+func (s *SQLStore) queryTaskTx(ctx context.Context, db sqlQuerier, query string, args ...any) (model.Task, error) {
+	task, err := scanTask(db.QueryRowContext(ctx, s.bind(query), args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Task{}, ErrTaskNotFound
+	}
+	return task, err
+}
+
+// bind rewrites ? placeholders to the $n style used by postgres.
+func (s *SQLStore) bind(query string) string {
+	if s.backend != BackendPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// scanTask reads a row selected with taskColumns.
+func scanTask(row interface{ Scan(dest ...any) error }) (model.Task, error) {
+	var task model.Task
+	var id int64
+	var completedAt, dueDate, updatedAt sql.NullTime
+
+	if err := row.Scan(&id, &task.Title, &task.Completed, &task.CreatedAt, &completedAt, &task.Priority, &task.Color, &dueDate, &updatedAt, &task.CreatedBy); err != nil {
+		return model.Task{}, err
+	}
+
+	task.ID = strconv.FormatInt(id, 10)
+	if completedAt.Valid {
+		task.CompletedAt = &completedAt.Time
+	}
+	if dueDate.Valid {
+		task.DueDate = &dueDate.Time
+	}
+	if updatedAt.Valid {
+		task.UpdatedAt = &updatedAt.Time
+	}
+	return task, nil
+}
+
+// nullTime converts an optional time to a nullable column value.
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// parseID converts a task ID to the numeric key used by the databases.
+// IDs that are not numeric cannot exist.
+func parseID(id string) (int64, bool) {
+	key, err := strconv.ParseInt(id, 10, 64)
+	return key, err == nil
+}
@@ -0,0 +1,65 @@
+package store_test
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers the sqlite driver, as the main package does
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/metrics"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/storetest"
+)
+
+func TestSQLiteStore_Conforms(t *testing.T) {
+	storetest.Run(t, openSQLite)
+}
+
+func TestSQLiteStore_Stress(t *testing.T) {
+	storetest.Stress(t, 10, openSQLite)
+}
+
+func TestSQLiteStore_ConfigurePool(t *testing.T) {
+	s := openSQLite(t).(*store.SQLStore)
+	s.ConfigurePool(store.PoolConfig{MaxOpenConns: 3, MaxIdleConns: 1, ConnMaxLifetime: time.Minute})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if _, err := s.GetAll(t.Context()); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	stats := s.PoolStats()
+	if stats.MaxOpenConnections != 3 || stats.OpenConnections > 3 || stats.Idle > 1 {
+		t.Errorf("expected at most 3 open and 1 idle connection, got %+v", stats)
+	}
+
+	reg := metrics.NewRegistry()
+	store.RegisterPoolMetrics(reg, s, store.DefaultWorkspace)
+	var exposed strings.Builder
+	reg.Write(&exposed)
+	if !strings.Contains(exposed.String(), "db_pool_max_open_connections{workspace=\"default\"} 3\n") {
+		t.Errorf("expected the pool size in the metrics, got\n%s", exposed.String())
+	}
+}
+
+// openSQLite opens a migrated sqlite store on a new database file.
+func openSQLite(t *testing.T) store.Store {
+	dsn := filepath.Join(t.TempDir(), "tasks.db") + "?_pragma=busy_timeout(5000)"
+	s, err := store.Open(store.BackendSQLite, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close(s) })
+	if _, err := s.(*store.SQLStore).Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
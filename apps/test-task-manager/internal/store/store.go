@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// Store is implemented by every task storage backend. Every method takes
+// the context of the request or job it serves: backends give up once it is
+// canceled or past its deadline, returning its error, and leave the tasks
+// as they were.
+type Store interface {
+	GetAll(ctx context.Context) ([]model.Task, error)
+	// Find returns the tasks matching q in creation order.
+	Find(ctx context.Context, q Query) ([]model.Task, error)
+	GetByID(ctx context.Context, id string) (model.Task, error)
+	// Create stores task under a new ID and returns it. CreatedAt is set
+	// to the current time when zero.
+	Create(ctx context.Context, task model.Task) (model.Task, error)
+	// CreateMany stores tasks under new IDs in one batch, all or none of
+	// them, and returns them in the given order.
+	CreateMany(ctx context.Context, tasks []model.Task) ([]model.Task, error)
+	// Toggle completes an open task or reopens a completed one. Like
+	// Update and Reassign it sets UpdatedAt to the current time.
+	Toggle(ctx context.Context, id string) (model.Task, error)
+	// Update replaces the title, priority, color and due date of the task
+	// with the ID of task and returns the result. Completion and creation
+	// time are kept; completion changes through Toggle.
+	Update(ctx context.Context, task model.Task) (model.Task, error)
+	// Reassign sets the priority and color of the tasks matching q in one
+	// batch, all or none of them, and returns the changed tasks in creation
+	// order. An empty priority or color is left as it is.
+	Reassign(ctx context.Context, q Query, priority, color string) ([]model.Task, error)
+	Delete(ctx context.Context, id string) error
+	Ping(ctx context.Context) error
+}
+
+// Migrator is implemented by stores with a schema that must be migrated
+// before use.
+type Migrator interface {
+	Migrate() (applied int, err error)
+	PendingMigrations() (int, error)
+}
+
+// Streamer is implemented by stores that can go through the tasks matching
+// a query without reading them all into memory at once, for exports of
+// large stores.
+type Streamer interface {
+	// Each calls fn with the tasks matching q in creation order, stopping
+	// at the first error fn returns, which Each returns.
+	Each(ctx context.Context, q Query, fn func(model.Task) error) error
+}
+
+// Sizer is implemented by stores that can tell the storage their tasks
+// take, for the admin overview.
+type Sizer interface {
+	// Size returns the approximate bytes used for the tasks.
+	Size() (int64, error)
+}
+
+// applyUpdate copies the fields Update changes from update to task.
+func applyUpdate(task *model.Task, update model.Task) {
+	task.Title = update.Title
+	task.Priority = update.Priority
+	task.Color = update.Color
+	task.DueDate = update.DueDate
+	touch(task)
+}
+
+// applyReassign sets the priority and color Reassign changes on task.
+func applyReassign(task *model.Task, priority, color string) {
+	if priority != "" {
+		task.Priority = priority
+	}
+	if color != "" {
+		task.Color = color
+	}
+	if priority != "" || color != "" {
+		touch(task)
+	}
+}
+
+// touch records that task changed now.
+func touch(task *model.Task) {
+	now := time.Now()
+	task.UpdatedAt = &now
+}
+
+// Ensure every backend satisfies Store.
+var (
+	_ Store    = (*TaskStore)(nil)
+	_ Store    = (*FileStore)(nil)
+	_ Store    = (*SQLStore)(nil)
+	_ Store    = (*RedisStore)(nil)
+	_ Migrator = (*SQLStore)(nil)
+	_ Pooled   = (*SQLStore)(nil)
+	_ Outbox   = (*FileStore)(nil)
+	_ Outbox   = (*SQLStore)(nil)
+	_ Streamer = (*SQLStore)(nil)
+	_ Sizer    = (*TaskStore)(nil)
+	_ Sizer    = (*FileStore)(nil)
+	_ Sizer    = (*SQLStore)(nil)
+	_ Sizer    = (*RedisStore)(nil)
+
+	_ Tombstoner = (*TaskStore)(nil)
+	_ Tombstoner = (*FileStore)(nil)
+	_ Tombstoner = (*SQLStore)(nil)
+	_ Tombstoner = (*RedisStore)(nil)
+)
@@ -2,100 +2,555 @@
 package store
 
 import (
-	"strconv"
+	"cmp"
+	"context"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
 )
 
-// TaskStore provides thread-safe in-memory task storage.
+// taskStoreShards is the number of shards of a TaskStore. Tasks are spread
+// over the shards by a hash of their ID, so writes to different tasks
+// rarely wait for each other.
+const taskStoreShards = 16
+
+// TaskStore provides thread-safe in-memory task storage. Tasks are spread
+// over shards with their own lock and indexes by ID, priority, completion
+// status and due date, so Find only visits the tasks of the most selective
+// index of every shard.
 type TaskStore struct {
-	tasks  []model.Task
-	nextID int
-	mu     sync.RWMutex
+	shards []*taskShard
+
+	idMu sync.Mutex
+	ids  IDGenerator
+	seq  int64 // Creation sequence number of the last task
+
+	used   atomic.Int64 // Approximate memory used by the tasks, in bytes
+	limits MemoryLimits
+
+	tombMu     sync.Mutex
+	retention  time.Duration // Of the tombstones; none are kept when 0
+	tombstones []Tombstone   // By deletion time
+}
+
+// taskShard holds the tasks whose ID hashes to it. Tasks are keyed by
+// their creation sequence number, which orders them whatever their IDs.
+type taskShard struct {
+	mu sync.RWMutex
+
+	keys  map[string]int64     // Sequence numbers by ID
+	tasks map[int64]model.Task // By sequence number
+	order []int64              // Sequence numbers in creation order
+
+	byPriority  map[string]idSet
+	byCompleted map[bool]idSet
+	byDue       []dueEntry // Tasks with a due date, sorted by due date and ID
+}
+
+// idSet is a set of task sequence numbers.
+type idSet map[int64]struct{}
+
+type dueEntry struct {
+	due time.Time
+	id  int64
 }
 
 // NewTaskStore creates a new TaskStore.
 func NewTaskStore() *TaskStore {
-	return &TaskStore{
-		tasks:  make([]model.Task, 0),
-		nextID: 1,
+	return newShardedTaskStore(taskStoreShards)
+}
+
+// newShardedTaskStore creates a TaskStore with n shards.
+func newShardedTaskStore(n int) *TaskStore {
+	s := &TaskStore{shards: make([]*taskShard, n), ids: &Sequence{}}
+	for i := range s.shards {
+		s.shards[i] = &taskShard{
+			keys:        make(map[string]int64),
+			tasks:       make(map[int64]model.Task),
+			order:       make([]int64, 0),
+			byPriority:  make(map[string]idSet),
+			byCompleted: map[bool]idSet{false: {}, true: {}},
+		}
 	}
+	return s
+}
+
+// SetIDGenerator makes the store take the IDs of new tasks from ids rather
+// than numbering them. It must be called before the store is used.
+func (s *TaskStore) SetIDGenerator(ids IDGenerator) {
+	s.ids = ids
 }
 
 // GetAll returns all tasks.
-func (s *TaskStore) GetAll() []model.Task {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+func (s *TaskStore) GetAll(ctx context.Context) ([]model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.rLockAll()
+	defer s.rUnlockAll()
 
-	// Return a copy to prevent external modification
-	tasksCopy := make([]model.Task, len(s.tasks))
-	copy(tasksCopy, s.tasks)
-	return tasksCopy
+	orders := make([][]int64, len(s.shards))
+	for i, shard := range s.shards {
+		orders[i] = shard.order
+	}
+	return s.collect(orders), nil
+}
+
+// Find returns the tasks matching q in creation order.
+func (s *TaskStore) Find(ctx context.Context, q Query) ([]model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.rLockAll()
+	defer s.rUnlockAll()
+
+	matches := make([][]int64, len(s.shards))
+	for i, shard := range s.shards {
+		matches[i] = shard.find(q)
+	}
+	return s.collect(matches), nil
+}
+
+// Ping verifies the store can serve reads.
+// For the in-memory store this only fails to return when a lock is stuck.
+func (s *TaskStore) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.rLockAll()
+	defer s.rUnlockAll()
+
+	return nil
+}
+
+// Size returns the approximate memory used by the tasks, as MemoryUsage.
+func (s *TaskStore) Size() (int64, error) {
+	return s.MemoryUsage(), nil
 }
 
 // GetByID returns a task by ID.
-func (s *TaskStore) GetByID(id string) (model.Task, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+func (s *TaskStore) GetByID(ctx context.Context, id string) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+
+	shard := s.shardOf(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	task, ok := shard.tasks[shard.keys[id]]
+	if !ok {
+		return model.Task{}, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// Create adds a new task.
+func (s *TaskStore) Create(ctx context.Context, task model.Task) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+
+	var key int64
+	task.ID, key = s.nextID()
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+	if err := s.reserve(taskSize(task)); err != nil {
+		return model.Task{}, err
+	}
+
+	shard := s.shardOf(task.ID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.keys[task.ID] = key
+	shard.tasks[key] = task
+	// Concurrent creates may take the lock out of creation order.
+	i := len(shard.order)
+	for i > 0 && shard.order[i-1] > key {
+		i--
+	}
+	shard.order = slices.Insert(shard.order, i, key)
+	shard.index(key, task)
 
-	for _, task := range s.tasks {
-		if task.ID == id {
-			return task, nil
+	return task, nil
+}
+
+// CreateMany adds tasks. They become visible to readers at once.
+func (s *TaskStore) CreateMany(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	created := make([]model.Task, len(tasks))
+	keys := make([]int64, len(tasks))
+	now := time.Now()
+
+	var size int64
+	for i, task := range tasks {
+		task.ID, keys[i] = s.nextID()
+		if task.CreatedAt.IsZero() {
+			task.CreatedAt = now
 		}
+		created[i] = task
+		size += taskSize(task)
+	}
+	if err := s.reserve(size); err != nil {
+		return nil, err
 	}
 
-	return model.Task{}, ErrTaskNotFound
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
+	}
+	for i, task := range created {
+		key := keys[i]
+		shard := s.shardOf(task.ID)
+		shard.keys[task.ID] = key
+		shard.tasks[key] = task
+		j := len(shard.order)
+		for j > 0 && shard.order[j-1] > key {
+			j--
+		}
+		shard.order = slices.Insert(shard.order, j, key)
+		shard.index(key, task)
+	}
+	return created, nil
 }
 
-// Create adds a new task with priority and color.
-func (s *TaskStore) Create(title, priority, color string) model.Task {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// Toggle changes completion status.
+func (s *TaskStore) Toggle(ctx context.Context, id string) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+
+	shard := s.shardOf(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	task := model.Task{
-		ID:        strconv.Itoa(s.nextID),
-		Title:     title,
-		Completed: false,
-		CreatedAt: time.Now(),
-		Priority:  priority,
-		Color:     color,
+	key := shard.keys[id]
+	task, ok := shard.tasks[key]
+	if !ok {
+		return model.Task{}, ErrTaskNotFound
 	}
 
-	s.tasks = append(s.tasks, task)
-	s.nextID++
+	// Only the completion status changes, so the other indexes stay valid.
+	delete(shard.byCompleted[task.Completed], key)
+	task.Completed = !task.Completed
+	now := time.Now()
+	task.UpdatedAt = &now
+	if task.Completed {
+		task.CompletedAt = &now
+	} else {
+		task.CompletedAt = nil
+	}
+	shard.tasks[key] = task
+	shard.byCompleted[task.Completed][key] = struct{}{}
 
-	return task
+	return task, nil
 }
 
-// Toggle changes completion status.
-func (s *TaskStore) Toggle(id string) (model.Task, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	for i := range s.tasks {
-		if s.tasks[i].ID == id {
-			s.tasks[i].Completed = !s.tasks[i].Completed
-			return s.tasks[i], nil
+// Update replaces the editable fields of a task.
+func (s *TaskStore) Update(ctx context.Context, update model.Task) (model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Task{}, err
+	}
+
+	shard := s.shardOf(update.ID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	key := shard.keys[update.ID]
+	task, ok := shard.tasks[key]
+	if !ok {
+		return model.Task{}, ErrTaskNotFound
+	}
+
+	updated := task
+	applyUpdate(&updated, update)
+	if grown := taskSize(updated) - taskSize(task); grown > 0 {
+		if err := s.reserve(grown); err != nil {
+			return model.Task{}, err
 		}
+	} else {
+		s.release(-grown)
 	}
 
-	return model.Task{}, ErrTaskNotFound
+	shard.unindex(key, task)
+	shard.tasks[key] = updated
+	shard.index(key, updated)
+	return updated, nil
+}
+
+// Reassign changes the tasks matching q with every shard locked, so
+// readers see all of them changed or none.
+func (s *TaskStore) Reassign(ctx context.Context, q Query, priority, color string) ([]model.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
+	}
+
+	matches := make([][]int64, len(s.shards))
+	var grown int64
+	for i, shard := range s.shards {
+		matches[i] = shard.find(q)
+		for _, key := range matches[i] {
+			task := shard.tasks[key]
+			updated := task
+			applyReassign(&updated, priority, color)
+			grown += taskSize(updated) - taskSize(task)
+		}
+	}
+	if grown > 0 {
+		if err := s.reserve(grown); err != nil {
+			return nil, err
+		}
+	} else {
+		s.release(-grown)
+	}
+
+	for i, shard := range s.shards {
+		for _, key := range matches[i] {
+			task := shard.tasks[key]
+			shard.unindex(key, task)
+			applyReassign(&task, priority, color)
+			shard.tasks[key] = task
+			shard.index(key, task)
+		}
+	}
+	return s.collect(matches), nil
 }
 
 // Delete removes a task.
-func (s *TaskStore) Delete(id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	for i, task := range s.tasks {
-		if task.ID == id {
-			// Remove task from slice
-			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
-			return nil
+func (s *TaskStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	shard := s.shardOf(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	key := shard.keys[id]
+	task, ok := shard.tasks[key]
+	if !ok {
+		return ErrTaskNotFound
+	}
+
+	shard.unindex(key, task)
+	delete(shard.keys, id)
+	delete(shard.tasks, key)
+	s.release(taskSize(task))
+	if i, found := slices.BinarySearch(shard.order, key); found {
+		shard.order = slices.Delete(shard.order, i, i+1)
+	}
+	s.bury(id)
+	return nil
+}
+
+// KeepTombstones makes the store keep the tombstones of deleted tasks for
+// retention.
+func (s *TaskStore) KeepTombstones(retention time.Duration) {
+	s.tombMu.Lock()
+	defer s.tombMu.Unlock()
+	s.retention = retention
+}
+
+// Tombstones returns the tombstones of the tasks deleted at or after since.
+func (s *TaskStore) Tombstones(ctx context.Context, since time.Time) ([]Tombstone, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.tombMu.Lock()
+	defer s.tombMu.Unlock()
+
+	if err := checkTombstonesSince(since, s.retention); err != nil {
+		return nil, err
+	}
+	s.tombstones = pruneTombstones(s.tombstones, s.retention)
+	return tombstonesSince(s.tombstones, since), nil
+}
+
+// bury adds a tombstone for the deleted task with id, when tombstones are
+// kept, and drops those past the retention.
+func (s *TaskStore) bury(id string) {
+	s.tombMu.Lock()
+	defer s.tombMu.Unlock()
+
+	if s.retention <= 0 {
+		return
+	}
+	s.tombstones = append(pruneTombstones(s.tombstones, s.retention), Tombstone{ID: id, DeletedAt: time.Now()})
+}
+
+// nextID returns the ID and creation sequence number of a new task. Both
+// are taken together, so IDs handed out in ascending order, such as those
+// of a Sequence, ascend in creation order too.
+func (s *TaskStore) nextID() (id string, key int64) {
+	s.idMu.Lock()
+	defer s.idMu.Unlock()
+
+	s.seq++
+	return s.ids.NewID(), s.seq
+}
+
+// shardOf returns the shard holding the task with id, hashing it with
+// FNV-1a.
+func (s *TaskStore) shardOf(id string) *taskShard {
+	h := uint32(2166136261)
+	for i := range len(id) {
+		h ^= uint32(id[i])
+		h *= 16777619
+	}
+	return s.shards[h%uint32(len(s.shards))]
+}
+
+// rLockAll read-locks every shard, in order, so readers see a consistent
+// view of the whole store.
+func (s *TaskStore) rLockAll() {
+	for _, shard := range s.shards {
+		shard.mu.RLock()
+	}
+}
+
+func (s *TaskStore) rUnlockAll() {
+	for _, shard := range s.shards {
+		shard.mu.RUnlock()
+	}
+}
+
+// collect returns the tasks of the sequence numbers of every shard, keys
+// being indexed like the shards, in creation order. Callers must hold the
+// read locks.
+func (s *TaskStore) collect(keys [][]int64) []model.Task {
+	type entry struct {
+		key   int64
+		shard *taskShard
+	}
+	var all []entry
+	for i, shardKeys := range keys {
+		for _, key := range shardKeys {
+			all = append(all, entry{key, s.shards[i]})
+		}
+	}
+	slices.SortFunc(all, func(a, b entry) int { return cmp.Compare(a.key, b.key) })
+
+	tasks := make([]model.Task, len(all))
+	for i, e := range all {
+		tasks[i] = e.shard.tasks[e.key]
+	}
+	return tasks
+}
+
+// find returns the sorted sequence numbers of the tasks of the shard
+// matching q.
+// Callers must hold the lock.
+func (sh *taskShard) find(q Query) []int64 {
+	candidates, indexed := sh.candidates(q)
+	if !indexed {
+		candidates = sh.order
+	}
+
+	matched := make([]int64, 0, len(candidates))
+	for _, key := range candidates {
+		if q.Matches(sh.tasks[key]) {
+			matched = append(matched, key)
 		}
 	}
+	if indexed {
+		slices.Sort(matched)
+	}
+	return matched
+}
 
-	return ErrTaskNotFound
+// candidates returns the keys of the smallest index matching a condition of
+// q; the other conditions still need checking. indexed is false when q has
+// no indexed condition. Callers must hold the lock.
+func (sh *taskShard) candidates(q Query) (ids []int64, indexed bool) {
+	var set idSet
+	size := -1
+	if q.Priority != "" {
+		set = sh.byPriority[q.Priority]
+		size = len(set)
+	}
+	if q.Completed != nil && (size < 0 || len(sh.byCompleted[*q.Completed]) < size) {
+		set = sh.byCompleted[*q.Completed]
+		size = len(set)
+	}
+
+	if q.DueAfter != nil || q.DueBefore != nil {
+		from, to := 0, len(sh.byDue)
+		if q.DueAfter != nil {
+			from = sort.Search(len(sh.byDue), func(i int) bool { return !sh.byDue[i].due.Before(*q.DueAfter) })
+		}
+		if q.DueBefore != nil {
+			to = max(from, sort.Search(len(sh.byDue), func(i int) bool { return !sh.byDue[i].due.Before(*q.DueBefore) }))
+		}
+		if size < 0 || to-from < size {
+			ids = make([]int64, 0, to-from)
+			for _, e := range sh.byDue[from:to] {
+				ids = append(ids, e.id)
+			}
+			return ids, true
+		}
+	}
+
+	if size < 0 {
+		return nil, false
+	}
+	ids = make([]int64, 0, size)
+	for key := range set {
+		ids = append(ids, key)
+	}
+	return ids, true
+}
+
+// index adds task to the secondary indexes. Callers must hold the write lock.
+func (sh *taskShard) index(key int64, task model.Task) {
+	if sh.byPriority[task.Priority] == nil {
+		sh.byPriority[task.Priority] = make(idSet)
+	}
+	sh.byPriority[task.Priority][key] = struct{}{}
+	sh.byCompleted[task.Completed][key] = struct{}{}
+
+	if task.DueDate != nil {
+		e := dueEntry{due: *task.DueDate, id: key}
+		i := sort.Search(len(sh.byDue), func(i int) bool { return !sh.byDue[i].less(e) })
+		sh.byDue = slices.Insert(sh.byDue, i, e)
+	}
+}
+
+// unindex removes task from the secondary indexes. Callers must hold the
+// write lock.
+func (sh *taskShard) unindex(key int64, task model.Task) {
+	delete(sh.byPriority[task.Priority], key)
+	delete(sh.byCompleted[task.Completed], key)
+
+	if task.DueDate != nil {
+		e := dueEntry{due: *task.DueDate, id: key}
+		i := sort.Search(len(sh.byDue), func(i int) bool { return !sh.byDue[i].less(e) })
+		if i < len(sh.byDue) && sh.byDue[i].id == key {
+			sh.byDue = slices.Delete(sh.byDue, i, i+1)
+		}
+	}
+}
+
+func (e dueEntry) less(other dueEntry) bool {
+	if !e.due.Equal(other.due) {
+		return e.due.Before(other.due)
+	}
+	return e.id < other.id
 }
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// TestTaskStore_FindMatchesScan checks the indexes against a full scan
+// after a random mix of creates, toggles, updates and deletes.
+func TestTaskStore_FindMatchesScan(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	priorities := []string{"🔥", "⭐", "💡"}
+
+	s := NewTaskStore()
+	var ids []string
+	for range 500 {
+		switch n := rng.IntN(10); {
+		case n < 6 || len(ids) == 0:
+			task := model.Task{Title: "task", Priority: priorities[rng.IntN(len(priorities))]}
+			if rng.IntN(2) == 0 {
+				due := base.Add(time.Duration(rng.IntN(30)) * 24 * time.Hour)
+				task.DueDate = &due
+			}
+			created, _ := s.Create(t.Context(), task)
+			ids = append(ids, created.ID)
+		case n < 8:
+			s.Toggle(t.Context(), ids[rng.IntN(len(ids))])
+		case n < 9:
+			task := model.Task{ID: ids[rng.IntN(len(ids))], Title: "updated", Priority: priorities[rng.IntN(len(priorities))]}
+			if rng.IntN(2) == 0 {
+				due := base.Add(time.Duration(rng.IntN(30)) * 24 * time.Hour)
+				task.DueDate = &due
+			}
+			s.Update(t.Context(), task)
+		default:
+			i := rng.IntN(len(ids))
+			s.Delete(t.Context(), ids[i])
+			ids = slices.Delete(ids, i, i+1)
+		}
+	}
+
+	open, completed := false, true
+	after, before := base.AddDate(0, 0, 5), base.AddDate(0, 0, 20)
+	queries := []Query{
+		{},
+		{Priority: "🔥"},
+		{Completed: &open},
+		{Priority: "⭐", Completed: &completed},
+		{DueAfter: &after},
+		{DueBefore: &before},
+		{Priority: "💡", DueAfter: &after, DueBefore: &before},
+		{DueAfter: &before, DueBefore: &after},
+	}
+
+	all, _ := s.GetAll(t.Context())
+	for _, q := range queries {
+		got, err := s.Find(t.Context(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := filterTasks(all, q)
+		if !slices.EqualFunc(got, want, func(a, b model.Task) bool { return a.ID == b.ID && a.Completed == b.Completed }) {
+			t.Errorf("query %+v: indexes returned %d task(s), scan %d", q, len(got), len(want))
+		}
+	}
+}
+
+// TestTaskStore_ConcurrentCreates checks that tasks created concurrently
+// across shards are all listed, in creation order.
+func TestTaskStore_ConcurrentCreates(t *testing.T) {
+	s := NewTaskStore()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				task, err := s.Create(t.Context(), model.Task{Title: "task", Priority: "🔥"})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				s.Toggle(t.Context(), task.ID)
+			}
+		})
+	}
+	wg.Wait()
+
+	all, _ := s.GetAll(t.Context())
+	if len(all) != 800 {
+		t.Fatalf("expected 800 tasks, got %d", len(all))
+	}
+	if !slices.IsSortedFunc(all, func(a, b model.Task) int { return compareIDs(a.ID, b.ID) }) {
+		t.Error("tasks are not in creation order")
+	}
+
+	completed := true
+	if found, _ := s.Find(t.Context(), Query{Completed: &completed}); len(found) != 800 {
+		t.Errorf("expected 800 completed tasks, got %d", len(found))
+	}
+}
+
+func TestTaskStore_CreateMany(t *testing.T) {
+	s := NewTaskStore()
+	s.Create(t.Context(), model.Task{Title: "before"})
+
+	batch := make([]model.Task, 40)
+	for i := range batch {
+		batch[i] = model.Task{Title: "batch", Priority: "⭐"}
+	}
+	created, err := s.CreateMany(t.Context(), batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 40 || created[0].ID != "2" || created[39].ID != "41" {
+		t.Fatalf("expected IDs 2 to 41 in order, got %d task(s)", len(created))
+	}
+
+	all, _ := s.GetAll(t.Context())
+	if len(all) != 41 || all[40].ID != "41" {
+		t.Errorf("expected 41 tasks in creation order, got %d", len(all))
+	}
+	if found, _ := s.Find(t.Context(), Query{Priority: "⭐"}); len(found) != 40 {
+		t.Errorf("expected the batch to be indexed, found %d", len(found))
+	}
+}
+
+func TestTaskStore_MemoryLimits(t *testing.T) {
+	task := model.Task{Title: "task"}
+	size := taskSize(model.Task{ID: "1", Title: "task"})
+
+	var warnings int
+	s := NewTaskStore()
+	s.SetMemoryLimits(MemoryLimits{Soft: size, Hard: 2 * size, OnSoftLimit: func(int64) { warnings++ }})
+
+	first, err := s.Create(t.Context(), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(t.Context(), task); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(t.Context(), task); !errors.Is(err, ErrStoreFull) {
+		t.Fatalf("expected ErrStoreFull above the hard limit, got %v", err)
+	}
+	if _, err := s.CreateMany(t.Context(), []model.Task{task}); !errors.Is(err, ErrStoreFull) {
+		t.Fatalf("expected ErrStoreFull for a batch above the hard limit, got %v", err)
+	}
+	if warnings != 1 {
+		t.Errorf("expected one soft limit warning, got %d", warnings)
+	}
+
+	if err := s.Delete(t.Context(), first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(t.Context(), task); err != nil {
+		t.Errorf("expected deleting a task to free room, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Tombstone records the deletion of a task, so clients syncing tasks learn
+// about it instead of keeping the task around.
+type Tombstone struct {
+	ID        string    `json:"id"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// Tombstoner is implemented by stores that keep a Tombstone of every task
+// they delete for a while.
+type Tombstoner interface {
+	// KeepTombstones makes the store keep the tombstones of deleted tasks
+	// for retention; without, it keeps none. It must be called before the
+	// store is used.
+	KeepTombstones(retention time.Duration)
+	// Tombstones returns the tombstones of the tasks deleted at or after
+	// since, oldest first. It returns ErrTombstonesExpired when since is
+	// more than the retention ago.
+	Tombstones(ctx context.Context, since time.Time) ([]Tombstone, error)
+}
+
+// checkTombstonesSince returns ErrTombstonesExpired when since is more than
+// retention ago.
+func checkTombstonesSince(since time.Time, retention time.Duration) error {
+	if since.Before(time.Now().Add(-retention)) {
+		return ErrTombstonesExpired
+	}
+	return nil
+}
+
+// tombstonesSince returns the tombstones at or after since of tombstones,
+// which are sorted by deletion time.
+func tombstonesSince(tombstones []Tombstone, since time.Time) []Tombstone {
+	i := sort.Search(len(tombstones), func(i int) bool { return !tombstones[i].DeletedAt.Before(since) })
+	return append(make([]Tombstone, 0, len(tombstones)-i), tombstones[i:]...)
+}
+
+// pruneTombstones drops the tombstones more than retention ago from
+// tombstones, which are sorted by deletion time.
+func pruneTombstones(tombstones []Tombstone, retention time.Duration) []Tombstone {
+	cutoff := time.Now().Add(-retention)
+	i := sort.Search(len(tombstones), func(i int) bool { return !tombstones[i].DeletedAt.Before(cutoff) })
+	if i == 0 {
+		return tombstones
+	}
+	return append(tombstones[:0:0], tombstones[i:]...)
+}
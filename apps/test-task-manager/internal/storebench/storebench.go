@@ -0,0 +1,293 @@
+// Package storebench runs the same workload against store backends and
+// compares their throughput, latency and memory use, so the choice of a
+// backend rests on numbers measured with this code base.
+package storebench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Operations, in the order they are reported.
+const (
+	OpCreate = "create"
+	OpGet    = "get"
+	OpFind   = "find"
+	OpToggle = "toggle"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+var operations = []string{OpCreate, OpGet, OpFind, OpToggle, OpUpdate, OpDelete}
+
+// mix is the relative weight of each operation: mostly reads, like the
+// traffic of the API.
+var mix = map[string]int{OpCreate: 2, OpGet: 8, OpFind: 4, OpToggle: 3, OpUpdate: 2, OpDelete: 1}
+
+var priorities = []string{"🔥", "⭐", "⚡", "💡", "📋"}
+
+// Workload describes what is run against every store.
+type Workload struct {
+	Tasks       int    // Created before the operations are measured
+	Operations  int    // Measured, over all workers
+	Concurrency int    // Workers running operations at the same time
+	Seed        uint64 // Of the operations chosen, so every store gets the same ones
+}
+
+// DefaultWorkload is a workload finishing in seconds on the file store.
+var DefaultWorkload = Workload{Tasks: 1000, Operations: 5000, Concurrency: 4, Seed: 1}
+
+// Stats are the measurements of one operation.
+type Stats struct {
+	Operation string
+	Count     int
+	Errors    int
+	P50       time.Duration
+	P99       time.Duration
+}
+
+// Result is the outcome of a workload run against one store.
+type Result struct {
+	Backend     string
+	Elapsed     time.Duration // Of the measured operations
+	Operations  int
+	Errors      int
+	P99         time.Duration // Over all operations
+	AllocPerOp  uint64        // Bytes of Go heap allocated per operation
+	HeapRetains int64         // Bytes of Go heap still in use after the run, compared to before preloading
+	Stats       []Stats
+}
+
+// Throughput returns the operations per second.
+func (r Result) Throughput() float64 {
+	return float64(r.Operations) / r.Elapsed.Seconds()
+}
+
+// Run runs w against s and removes the tasks it created afterwards, leaving
+// those s already held alone. Memory is measured in this process, so it covers
+// stores kept in memory and the clients of databases, not database servers.
+func Run(ctx context.Context, backend string, s store.Store, w Workload) (Result, error) {
+	before := heapInUse()
+	var allocs runtime.MemStats
+
+	preload := make([]model.Task, w.Tasks)
+	for i := range preload {
+		preload[i] = task(i)
+	}
+	created, err := s.CreateMany(ctx, preload)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create %d tasks: %w", w.Tasks, err)
+	}
+	r := &runner{store: s, latencies: make(map[string][]time.Duration), errors: make(map[string]int)}
+	for _, t := range created {
+		r.ids = append(r.ids, t.ID)
+	}
+	for _, op := range operations {
+		for range mix[op] {
+			r.weighted = append(r.weighted, op)
+		}
+	}
+
+	runtime.ReadMemStats(&allocs)
+	allocated := allocs.TotalAlloc
+	workers := max(w.Concurrency, 1)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for worker := range workers {
+		n := w.Operations / workers
+		if worker < w.Operations%workers {
+			n++
+		}
+		rng := rand.New(rand.NewPCG(w.Seed, uint64(worker)))
+		wg.Go(func() {
+			for i := range n {
+				r.step(ctx, rng, worker*w.Operations+i)
+			}
+		})
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&allocs)
+	allocated = allocs.TotalAlloc - allocated
+	retained := int64(heapInUse()) - int64(before)
+
+	for _, id := range r.ids {
+		s.Delete(context.Background(), id)
+	}
+
+	result := Result{Backend: backend, Elapsed: elapsed, HeapRetains: retained}
+	var all []time.Duration
+	for _, op := range operations {
+		latencies := r.latencies[op]
+		all = append(all, latencies...)
+		result.Operations += len(latencies)
+		result.Errors += r.errors[op]
+		result.Stats = append(result.Stats, summarize(op, latencies, r.errors[op]))
+	}
+	if result.Operations > 0 {
+		result.AllocPerOp = allocated / uint64(result.Operations)
+	}
+	slices.Sort(all)
+	result.P99 = percentile(all, 0.99)
+	return result, nil
+}
+
+type runner struct {
+	store    store.Store
+	weighted []string
+
+	mu        sync.Mutex
+	ids       []string // Tasks no operation is using
+	latencies map[string][]time.Duration
+	errors    map[string]int
+}
+
+// step runs one operation chosen by rng. The i-th operation of a run
+// creates the task with that number.
+func (r *runner) step(ctx context.Context, rng *rand.Rand, i int) {
+	op := r.weighted[rng.IntN(len(r.weighted))]
+
+	// A task is taken out of the pool while an operation uses it, so it
+	// cannot be deleted at the same time.
+	var id string
+	if op != OpCreate && op != OpFind {
+		r.mu.Lock()
+		if len(r.ids) == 0 {
+			op = OpCreate
+		} else {
+			j := rng.IntN(len(r.ids))
+			id = r.ids[j]
+			r.ids = slices.Delete(r.ids, j, j+1)
+		}
+		r.mu.Unlock()
+	}
+
+	start := time.Now()
+	var err error
+	switch op {
+	case OpCreate:
+		var created model.Task
+		created, err = r.store.Create(ctx, task(i))
+		id = created.ID
+	case OpGet:
+		_, err = r.store.GetByID(ctx, id)
+	case OpFind:
+		open := false
+		_, err = r.store.Find(ctx, store.Query{Priority: priorities[rng.IntN(len(priorities))], Completed: &open})
+	case OpToggle:
+		_, err = r.store.Toggle(ctx, id)
+	case OpUpdate:
+		update := task(i)
+		update.ID = id
+		_, err = r.store.Update(ctx, update)
+	case OpDelete:
+		err = r.store.Delete(ctx, id)
+	}
+	latency := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies[op] = append(r.latencies[op], latency)
+	if err != nil {
+		r.errors[op]++
+	}
+	if id != "" && op != OpDelete && (err == nil || op != OpCreate) {
+		r.ids = append(r.ids, id)
+	}
+}
+
+// task returns the i-th task of a workload: a mix of priorities and due
+// dates.
+func task(i int) model.Task {
+	t := model.Task{
+		Title:    "Storebench task " + strconv.Itoa(i),
+		Priority: priorities[i%len(priorities)],
+		Color:    "#6c757d",
+	}
+	if i%3 == 0 {
+		due := time.Date(2026, 1, 1, 17, 0, 0, 0, time.UTC).AddDate(0, 0, i%90)
+		t.DueDate = &due
+	}
+	return t
+}
+
+// heapInUse returns the bytes of Go heap in use after a garbage collection.
+func heapInUse() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
+
+func summarize(op string, latencies []time.Duration, errors int) Stats {
+	slices.Sort(latencies)
+	return Stats{
+		Operation: op,
+		Count:     len(latencies),
+		Errors:    errors,
+		P50:       percentile(latencies, 0.50),
+		P99:       percentile(latencies, 0.99),
+	}
+}
+
+// percentile returns the nearest-rank percentile p (0-1) of sorted, or 0
+// when it is empty.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
+
+// WriteTable writes a table comparing results, one row per backend, with
+// the p99 latency of every operation.
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "BACKEND\tOPS/S\tP99\t")
+	for _, op := range operations {
+		fmt.Fprintf(tw, "%s P99\t", op)
+	}
+	fmt.Fprintln(tw, "ALLOC/OP\tHEAP\tERRORS\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%.0f\t%s\t", r.Backend, r.Throughput(), round(r.P99))
+		for _, s := range r.Stats {
+			fmt.Fprintf(tw, "%s\t", round(s.P99))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t\n", bytes(int64(r.AllocPerOp)), bytes(r.HeapRetains), r.Errors)
+	}
+	return tw.Flush()
+}
+
+// round rounds d to three significant digits for the table.
+func round(d time.Duration) time.Duration {
+	for unit := time.Nanosecond; unit < time.Second; unit *= 10 {
+		if d < 1000*unit {
+			return d.Round(unit)
+		}
+	}
+	return d.Round(time.Millisecond)
+}
+
+// bytes formats n bytes in B, KiB or MiB.
+func bytes(n int64) string {
+	switch abs := max(n, -n); {
+	case abs >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case abs >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
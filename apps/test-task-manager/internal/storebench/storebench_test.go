@@ -0,0 +1,44 @@
+package storebench
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestRun(t *testing.T) {
+	memory := store.NewTaskStore()
+	file, err := store.NewFileStore(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := Workload{Tasks: 50, Operations: 200, Concurrency: 4, Seed: 1}
+
+	var results []Result
+	for backend, s := range map[string]store.Store{store.BackendMemory: memory, store.BackendFile: file} {
+		result, err := Run(t.Context(), backend, s, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Operations != w.Operations || result.Errors != 0 || result.P99 <= 0 {
+			t.Errorf("%s: expected %d operations without errors, got %+v", backend, w.Operations, result)
+		}
+		if len(result.Stats) != len(operations) {
+			t.Errorf("%s: expected stats for every operation, got %+v", backend, result.Stats)
+		}
+		if tasks, _ := s.GetAll(t.Context()); len(tasks) != 0 {
+			t.Errorf("%s: expected the tasks of the run to be removed, got %d", backend, len(tasks))
+		}
+		results = append(results, result)
+	}
+
+	var table strings.Builder
+	if err := WriteTable(&table, results); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(table.String()), "\n"); len(lines) != 3 || !strings.Contains(lines[0], "OPS/S") {
+		t.Errorf("expected a header and a row per backend, got\n%s", table.String())
+	}
+}
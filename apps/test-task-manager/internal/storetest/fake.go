@@ -0,0 +1,140 @@
+package storetest
+
+import (
+	"context"
+	"sync"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Fake is an in-memory store that counts the calls to each method and
+// fails the methods it is told to, so tests can reach the error paths of
+// the code using a store.
+type Fake struct {
+	store store.Store
+
+	mu    sync.Mutex
+	errs  map[string]error
+	calls map[string]int
+}
+
+// NewFake creates a fake store holding tasks.
+func NewFake(tasks ...model.Task) *Fake {
+	f := &Fake{store: store.NewTaskStore(), errs: make(map[string]error), calls: make(map[string]int)}
+	if len(tasks) > 0 {
+		f.store.CreateMany(context.Background(), tasks)
+	}
+	return f
+}
+
+// SetIDGenerator makes the tasks created from now on take their IDs from
+// ids, such as those of IDs.
+func (f *Fake) SetIDGenerator(ids store.IDGenerator) {
+	f.store.(store.IDAssigner).SetIDGenerator(ids)
+}
+
+// Fail makes calls to method, such as "Create", return err from now on.
+// A nil err makes them succeed again.
+func (f *Fake) Fail(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs[method] = err
+}
+
+// Calls returns the number of calls to method so far, failed ones included.
+func (f *Fake) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// call counts a call to method and returns the error it must fail with.
+func (f *Fake) call(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[method]++
+	return f.errs[method]
+}
+
+// GetAll implements store.Store.
+func (f *Fake) GetAll(ctx context.Context) ([]model.Task, error) {
+	if err := f.call("GetAll"); err != nil {
+		return nil, err
+	}
+	return f.store.GetAll(ctx)
+}
+
+// Find implements store.Store.
+func (f *Fake) Find(ctx context.Context, q store.Query) ([]model.Task, error) {
+	if err := f.call("Find"); err != nil {
+		return nil, err
+	}
+	return f.store.Find(ctx, q)
+}
+
+// GetByID implements store.Store.
+func (f *Fake) GetByID(ctx context.Context, id string) (model.Task, error) {
+	if err := f.call("GetByID"); err != nil {
+		return model.Task{}, err
+	}
+	return f.store.GetByID(ctx, id)
+}
+
+// Create implements store.Store.
+func (f *Fake) Create(ctx context.Context, task model.Task) (model.Task, error) {
+	if err := f.call("Create"); err != nil {
+		return model.Task{}, err
+	}
+	return f.store.Create(ctx, task)
+}
+
+// CreateMany implements store.Store.
+func (f *Fake) CreateMany(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	if err := f.call("CreateMany"); err != nil {
+		return nil, err
+	}
+	return f.store.CreateMany(ctx, tasks)
+}
+
+// Toggle implements store.Store.
+func (f *Fake) Toggle(ctx context.Context, id string) (model.Task, error) {
+	if err := f.call("Toggle"); err != nil {
+		return model.Task{}, err
+	}
+	return f.store.Toggle(ctx, id)
+}
+
+// Update implements store.Store.
+func (f *Fake) Update(ctx context.Context, task model.Task) (model.Task, error) {
+	if err := f.call("Update"); err != nil {
+		return model.Task{}, err
+	}
+	return f.store.Update(ctx, task)
+}
+
+// Reassign implements store.Store.
+func (f *Fake) Reassign(ctx context.Context, q store.Query, priority, color string) ([]model.Task, error) {
+	if err := f.call("Reassign"); err != nil {
+		return nil, err
+	}
+	return f.store.Reassign(ctx, q, priority, color)
+}
+
+// Delete implements store.Store.
+func (f *Fake) Delete(ctx context.Context, id string) error {
+	if err := f.call("Delete"); err != nil {
+		return err
+	}
+	return f.store.Delete(ctx, id)
+}
+
+// Ping implements store.Store.
+func (f *Fake) Ping(ctx context.Context) error {
+	if err := f.call("Ping"); err != nil {
+		return err
+	}
+	return f.store.Ping(ctx)
+}
+
+var _ store.Store = (*Fake)(nil)
@@ -0,0 +1,41 @@
+package storetest
+
+import (
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestFake_Conforms(t *testing.T) {
+	Run(t, func(t *testing.T) store.Store { return NewFake() })
+}
+
+func TestFake_Fail(t *testing.T) {
+	f := NewFake(NewTask("seeded"))
+	f.Fail("Toggle", store.ErrStoreFull)
+	if _, err := f.Toggle(t.Context(), "1"); err != store.ErrStoreFull {
+		t.Fatalf("expected the configured error, got %v", err)
+	}
+	f.Fail("Toggle", nil)
+	if task, err := f.Toggle(t.Context(), "1"); err != nil || !task.Completed {
+		t.Fatalf("expected Toggle to succeed again, got %+v, %v", task, err)
+	}
+	if f.Calls("Toggle") != 2 || f.Calls("Delete") != 0 {
+		t.Errorf("expected two Toggle calls and no Delete, got %d and %d", f.Calls("Toggle"), f.Calls("Delete"))
+	}
+}
+
+func TestFake_IDs(t *testing.T) {
+	f := NewFake()
+	f.SetIDGenerator(IDs("first"))
+	created, err := f.CreateMany(t.Context(), Tasks(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created[0].ID != "first" || created[1].ID != "id-1" {
+		t.Errorf("expected IDs first and id-1, got %s and %s", created[0].ID, created[1].ID)
+	}
+	if task, err := f.GetByID(t.Context(), "id-1"); err != nil || task.Title != "Task 2" {
+		t.Errorf("expected Task 2 under id-1, got %+v, %v", task, err)
+	}
+}
@@ -0,0 +1,34 @@
+package storetest
+
+import (
+	"fmt"
+	"sync"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// IDs returns a generator handing out ids in order, then "id-1", "id-2"
+// and so on, so tests know the IDs of the tasks they create:
+//
+//	s := store.NewTaskStore()
+//	s.SetIDGenerator(storetest.IDs("first", "second"))
+func IDs(ids ...string) store.IDGenerator {
+	return &fixedIDs{ids: ids}
+}
+
+type fixedIDs struct {
+	mu   sync.Mutex
+	ids  []string
+	next int
+}
+
+func (g *fixedIDs) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.next++
+	if g.next <= len(g.ids) {
+		return g.ids[g.next-1]
+	}
+	return fmt.Sprintf("id-%d", g.next-len(g.ids))
+}
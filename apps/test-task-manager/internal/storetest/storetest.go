@@ -0,0 +1,463 @@
+// Package storetest helps test task stores and the code using them: Run is
+// a conformance suite every store.Store implementation must pass, NewTask
+// builds tasks, and Fake is an in-memory store that fails on demand.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Run checks that the stores open returns behave as store.Store documents.
+// open is called once per subtest and must return an empty store; it may
+// register cleanups with t.
+func Run(t *testing.T, open func(t *testing.T) store.Store) {
+	t.Run("CreateAndGet", func(t *testing.T) { testCreateAndGet(t, open(t)) })
+	t.Run("CreateManyTimes", func(t *testing.T) { testCreateManyTimes(t, open(t)) })
+	t.Run("CreationOrder", func(t *testing.T) { testCreationOrder(t, open(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, open(t)) })
+	t.Run("Toggle", func(t *testing.T) { testToggle(t, open(t)) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, open(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, open(t)) })
+	t.Run("Find", func(t *testing.T) { testFind(t, open(t)) })
+	t.Run("Reassign", func(t *testing.T) { testReassign(t, open(t)) })
+	t.Run("Canceled", func(t *testing.T) { testCanceled(t, open(t)) })
+	t.Run("Tombstones", func(t *testing.T) {
+		s := open(t)
+		tombstoner, ok := s.(store.Tombstoner)
+		if !ok {
+			t.Skip("the store keeps no tombstones")
+		}
+		testTombstones(t, s, tombstoner)
+	})
+	t.Run("Ping", func(t *testing.T) {
+		if err := open(t).Ping(t.Context()); err != nil {
+			t.Errorf("expected an empty store to be reachable, got %v", err)
+		}
+	})
+}
+
+// base is the time the suite's due and creation dates are relative to.
+var base = time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+func testCreateAndGet(t *testing.T, s store.Store) {
+	want := NewTask("Write report", WithPriority("🔥"), WithColor("#dc3545"), DueAt(base.Add(48*time.Hour)), CreatedBy("u1"))
+	before := time.Now()
+	created, err := s.Create(t.Context(), want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" {
+		t.Fatal("expected Create to assign an ID")
+	}
+	if created.CreatedAt.Before(before.Add(-time.Second)) || created.CreatedAt.After(time.Now().Add(time.Second)) {
+		t.Errorf("expected CreatedAt to be set to the current time, got %s", created.CreatedAt)
+	}
+
+	got, err := s.GetByID(t.Context(), created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameTask(got, created) {
+		t.Errorf("expected %+v back, got %+v", created, got)
+	}
+	if got.Title != want.Title || got.Priority != want.Priority || got.Color != want.Color || !sameTime(got.DueDate, want.DueDate) || got.CreatedBy != want.CreatedBy || got.Completed {
+		t.Errorf("expected the fields of %+v, got %+v", want, got)
+	}
+
+	kept, err := s.Create(t.Context(), NewTask("Imported", CreatedAt(base), UpdatedAt(base.Add(time.Hour))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kept.CreatedAt.Equal(base) || kept.UpdatedAt == nil || !kept.UpdatedAt.Equal(base.Add(time.Hour)) {
+		t.Errorf("expected a given CreatedAt and UpdatedAt to be kept, got %+v", kept)
+	}
+}
+
+func testCreateManyTimes(t *testing.T, s store.Store) {
+	want := []model.Task{
+		NewTask("Imported", CreatedAt(base), DueAt(base.Add(72*time.Hour)), UpdatedAt(base.Add(time.Hour))),
+		NewTask("Imported done", CreatedAt(base.Add(time.Minute)), CompletedAt(base.Add(2*time.Hour)), UpdatedAt(base.Add(2*time.Hour))),
+		NewTask("Imported untouched", CreatedAt(base.Add(2*time.Minute))),
+	}
+	created, err := s.CreateMany(t.Context(), want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, task := range created {
+		got, err := s.GetByID(t.Context(), task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.CreatedAt.Equal(want[i].CreatedAt) || !sameTime(got.CompletedAt, want[i].CompletedAt) ||
+			!sameTime(got.DueDate, want[i].DueDate) || !sameTime(got.UpdatedAt, want[i].UpdatedAt) {
+			t.Errorf("expected task %d to keep the times of %+v, got %+v", i, want[i], got)
+		}
+	}
+}
+
+func testCreationOrder(t *testing.T, s store.Store) {
+	var ids []string
+	for _, title := range []string{"first", "second"} {
+		task, err := s.Create(t.Context(), NewTask(title))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.ID)
+	}
+	batch, err := s.CreateMany(t.Context(), Tasks(3, WithPriority("⭐")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 3 {
+		t.Fatalf("expected CreateMany to return 3 tasks, got %d", len(batch))
+	}
+	for i, task := range batch {
+		if want := fmt.Sprintf("Task %d", i+1); task.Title != want || task.ID == "" {
+			t.Errorf("expected task %d to be %q with an ID, got %+v", i, want, task)
+		}
+		ids = append(ids, task.ID)
+	}
+
+	all, err := s.GetAll(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, len(all))
+	for i, task := range all {
+		got[i] = task.ID
+	}
+	if !slices.Equal(got, ids) {
+		t.Errorf("expected IDs %v in creation order, got %v", ids, got)
+	}
+}
+
+func testNotFound(t *testing.T, s store.Store) {
+	deleted, err := s.Create(t.Context(), NewTask("deleted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(t.Context(), deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{deleted.ID, "999999", "missing", ""} {
+		if _, err := s.GetByID(t.Context(), id); !errors.Is(err, store.ErrTaskNotFound) {
+			t.Errorf("GetByID(%q): expected ErrTaskNotFound, got %v", id, err)
+		}
+		if _, err := s.Toggle(t.Context(), id); !errors.Is(err, store.ErrTaskNotFound) {
+			t.Errorf("Toggle(%q): expected ErrTaskNotFound, got %v", id, err)
+		}
+		if _, err := s.Update(t.Context(), NewTask("update", WithID(id))); !errors.Is(err, store.ErrTaskNotFound) {
+			t.Errorf("Update(%q): expected ErrTaskNotFound, got %v", id, err)
+		}
+		if err := s.Delete(t.Context(), id); !errors.Is(err, store.ErrTaskNotFound) {
+			t.Errorf("Delete(%q): expected ErrTaskNotFound, got %v", id, err)
+		}
+	}
+}
+
+func testToggle(t *testing.T, s store.Store) {
+	task, err := s.Create(t.Context(), NewTask("toggle"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.UpdatedAt != nil {
+		t.Errorf("expected a new task without UpdatedAt, got %+v", task)
+	}
+
+	completed, err := s.Toggle(t.Context(), task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !completed.Completed || completed.CompletedAt == nil || completed.UpdatedAt == nil {
+		t.Errorf("expected the task to be completed with CompletedAt and UpdatedAt set, got %+v", completed)
+	}
+	if got, _ := s.GetByID(t.Context(), task.ID); !got.Completed || got.CompletedAt == nil {
+		t.Errorf("expected the completion to be stored, got %+v", got)
+	}
+
+	reopened, err := s.Toggle(t.Context(), task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Completed || reopened.CompletedAt != nil {
+		t.Errorf("expected the task to be reopened with CompletedAt cleared, got %+v", reopened)
+	}
+}
+
+func testUpdate(t *testing.T, s store.Store) {
+	task, err := s.Create(t.Context(), NewTask("before", DueAt(base)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task, err = s.Toggle(t.Context(), task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	update := NewTask("after", WithID(task.ID), WithPriority("⚡"), WithColor("#ffc107"))
+	updated, err := s.Update(t.Context(), update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetByID(t.Context(), task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameTask(got, updated) {
+		t.Errorf("expected Update to return the stored task %+v, got %+v", got, updated)
+	}
+	if got.Title != "after" || got.Priority != "⚡" || got.Color != "#ffc107" || got.DueDate != nil {
+		t.Errorf("expected the editable fields to be replaced, got %+v", got)
+	}
+	if !got.Completed || !got.CreatedAt.Equal(task.CreatedAt) {
+		t.Errorf("expected completion and creation time to be kept, got %+v", got)
+	}
+	if got.UpdatedAt == nil || got.UpdatedAt.Before(*task.UpdatedAt) {
+		t.Errorf("expected UpdatedAt to be set to the time of the update, got %+v", got)
+	}
+}
+
+func testDelete(t *testing.T, s store.Store) {
+	kept, _ := s.Create(t.Context(), NewTask("kept"))
+	deleted, err := s.Create(t.Context(), NewTask("deleted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(t.Context(), deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := s.GetAll(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].ID != kept.ID {
+		t.Errorf("expected only %q to be left, got %+v", kept.ID, all)
+	}
+}
+
+func testReassign(t *testing.T, s store.Store) {
+	created, err := s.CreateMany(t.Context(), []model.Task{
+		NewTask("old", WithPriority("🔥"), CreatedAt(base)),
+		NewTask("old, other priority", WithPriority("⭐"), CreatedAt(base)),
+		NewTask("new", WithPriority("🔥")),
+		NewTask("old too", WithPriority("🔥"), WithColor("#dc3545"), CreatedAt(base.Add(time.Hour))),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cutoff := base.Add(24 * time.Hour)
+	changed, err := s.Reassign(t.Context(), store.Query{Priority: "🔥", CreatedBefore: &cutoff}, "⭐", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 || changed[0].ID != created[0].ID || changed[1].ID != created[3].ID {
+		t.Fatalf("expected the old 🔥 tasks to be changed in creation order, got %+v", changed)
+	}
+	if changed[1].Priority != "⭐" || changed[1].Color != "#dc3545" || changed[1].UpdatedAt == nil {
+		t.Errorf("expected only the priority and UpdatedAt to change, got %+v", changed[1])
+	}
+
+	all, err := s.GetAll(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"⭐", "⭐", "🔥", "⭐"}
+	for i, task := range all {
+		if task.Priority != want[i] || task.Title != created[i].Title {
+			t.Errorf("task %d: expected priority %s, got %+v", i+1, want[i], task)
+		}
+	}
+	if found, err := s.Find(t.Context(), store.Query{Priority: "🔥"}); err != nil || len(found) != 1 {
+		t.Errorf("expected the indexes to follow the change, found %+v, %v", found, err)
+	}
+
+	changed, err = s.Reassign(t.Context(), store.Query{Priority: "💡"}, "⭐", "#0d6efd")
+	if err != nil || len(changed) != 0 {
+		t.Errorf("expected no tasks to change, got %+v, %v", changed, err)
+	}
+}
+
+func testCanceled(t *testing.T, s store.Store) {
+	task, err := s.Create(t.Context(), NewTask("kept"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	calls := map[string]func() error{
+		"GetAll":     func() error { _, err := s.GetAll(ctx); return err },
+		"Find":       func() error { _, err := s.Find(ctx, store.Query{}); return err },
+		"GetByID":    func() error { _, err := s.GetByID(ctx, task.ID); return err },
+		"Create":     func() error { _, err := s.Create(ctx, NewTask("created")); return err },
+		"CreateMany": func() error { _, err := s.CreateMany(ctx, Tasks(2)); return err },
+		"Toggle":     func() error { _, err := s.Toggle(ctx, task.ID); return err },
+		"Update":     func() error { _, err := s.Update(ctx, NewTask("updated", WithID(task.ID))); return err },
+		"Reassign":   func() error { _, err := s.Reassign(ctx, store.Query{}, "🔥", ""); return err },
+		"Delete":     func() error { return s.Delete(ctx, task.ID) },
+		"Ping":       func() error { return s.Ping(ctx) },
+	}
+	for method, call := range calls {
+		if err := call(); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled with a canceled context, got %v", method, err)
+		}
+	}
+
+	all, err := s.GetAll(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || !sameTask(all[0], task) {
+		t.Errorf("expected the canceled calls to leave the tasks as they were, got %+v", all)
+	}
+}
+
+func testTombstones(t *testing.T, s store.Store, tombstoner store.Tombstoner) {
+	tombstoner.KeepTombstones(time.Hour)
+	since := time.Now().Add(-time.Second)
+	kept, _ := s.Create(t.Context(), NewTask("kept"))
+	deleted, err := s.Create(t.Context(), NewTask("deleted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(t.Context(), deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(t.Context(), deleted.ID); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Fatalf("expected ErrTaskNotFound deleting twice, got %v", err)
+	}
+
+	tombstones, err := tombstoner.Tombstones(t.Context(), since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tombstones) != 1 || tombstones[0].ID != deleted.ID || tombstones[0].ID == kept.ID {
+		t.Fatalf("expected only the tombstone of %q, got %+v", deleted.ID, tombstones)
+	}
+	if at := tombstones[0].DeletedAt; at.Before(since) || at.After(time.Now().Add(time.Second)) {
+		t.Errorf("expected the tombstone to hold the time of the delete, got %s", at)
+	}
+
+	if later, err := tombstoner.Tombstones(t.Context(), time.Now().Add(time.Second)); err != nil || len(later) != 0 {
+		t.Errorf("expected no tombstones after the delete, got %+v, %v", later, err)
+	}
+	if _, err := tombstoner.Tombstones(t.Context(), time.Now().Add(-2*time.Hour)); !errors.Is(err, store.ErrTombstonesExpired) {
+		t.Errorf("expected ErrTombstonesExpired before the retention, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := tombstoner.Tombstones(ctx, since); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled with a canceled context, got %v", err)
+	}
+}
+
+func testFind(t *testing.T, s store.Store) {
+	priorities := []string{"🔥", "⭐", "💡"}
+	var tasks []model.Task
+	for i := range 12 {
+		opts := []TaskOption{WithPriority(priorities[i%len(priorities)]), CreatedAt(base)}
+		if i%4 != 0 {
+			opts = append(opts, DueAt(base.AddDate(0, 0, i)))
+		}
+		tasks = append(tasks, NewTask("find", opts...))
+	}
+	created, err := s.CreateMany(t.Context(), tasks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, task := range created {
+		if i%2 == 0 {
+			if _, err := s.Toggle(t.Context(), task.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	open, completed := false, true
+	after, before := base.AddDate(0, 0, 3), base.AddDate(0, 0, 9)
+	// Only the tasks not toggled were last changed at creation
+	changedBefore := base.Add(time.Hour)
+	queries := []store.Query{
+		{},
+		{Priority: "🔥"},
+		{Completed: &open},
+		{Priority: "⭐", Completed: &completed},
+		{DueAfter: &after},
+		{DueBefore: &before},
+		{Priority: "💡", DueAfter: &after, DueBefore: &before},
+		{DueAfter: &before, DueBefore: &after},
+		{ChangedBefore: &changedBefore},
+	}
+
+	all, err := s.GetAll(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range queries {
+		got, err := s.Find(t.Context(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []string
+		for _, task := range all {
+			if q.Matches(task) {
+				want = append(want, task.ID)
+			}
+		}
+		ids := make([]string, 0, len(got))
+		for _, task := range got {
+			ids = append(ids, task.ID)
+		}
+		if !slices.Equal(ids, want) {
+			t.Errorf("query %+v: expected %v in creation order, got %v", q, want, ids)
+		}
+
+		if streamer, ok := s.(store.Streamer); ok {
+			ids = ids[:0]
+			err := streamer.Each(t.Context(), q, func(task model.Task) error {
+				ids = append(ids, task.ID)
+				return nil
+			})
+			if err != nil || !slices.Equal(ids, want) {
+				t.Errorf("query %+v: expected Each to go through %v in creation order, got %v, %v", q, want, ids, err)
+			}
+		}
+	}
+
+	if streamer, ok := s.(store.Streamer); ok {
+		stop := errors.New("stop")
+		calls := 0
+		err := streamer.Each(t.Context(), store.Query{}, func(model.Task) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("expected Each to stop at the error of fn, got %v after %d calls", err, calls)
+		}
+	}
+}
+
+// sameTask reports whether a and b hold the same values, comparing times
+// by instant as stores need not keep locations.
+func sameTask(a, b model.Task) bool {
+	return a.ID == b.ID && a.Title == b.Title && a.Completed == b.Completed && a.CreatedAt.Equal(b.CreatedAt) &&
+		sameTime(a.CompletedAt, b.CompletedAt) && a.Priority == b.Priority && a.Color == b.Color && sameTime(a.DueDate, b.DueDate) &&
+		sameTime(a.UpdatedAt, b.UpdatedAt) && a.CreatedBy == b.CreatedBy
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,171 @@
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// Stress size: writers each create, toggle and delete their own tasks and
+// toggle every shared task twice, while a reader per four writers lists
+// and looks up tasks.
+const (
+	stressReads  = 20 // by every reader
+	stressTasks  = 10 // created by every writer
+	stressShared = 8
+)
+
+// Stress hammers the store open returns from writers goroutines, and
+// readers alongside, and checks that no update is lost or duplicated: IDs
+// are unique, every task that was created and not deleted is listed with
+// the completion its toggles leave, and readers never see a list with
+// duplicates or a task that is half completed. Use hundreds of writers
+// for stores kept in memory, fewer for those writing every change to disk,
+// and run it with the race detector before changing how a store locks.
+// open must return an empty store.
+func Stress(t *testing.T, writers int, open func(t *testing.T) store.Store) {
+	s := open(t)
+	shared, err := s.CreateMany(t.Context(), Tasks(stressShared))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := make([][]model.Task, writers) // by writer, as left after its toggles
+	deleted := make([][]string, writers)
+	var writing, reading sync.WaitGroup
+	for w := range writers {
+		writing.Go(func() {
+			for i := range stressTasks {
+				task, err := s.Create(t.Context(), NewTask(fmt.Sprintf("writer %d task %d", w, i)))
+				if err != nil {
+					t.Errorf("writer %d: Create: %v", w, err)
+					return
+				}
+				if i%2 == 1 {
+					toggled, err := s.Toggle(t.Context(), task.ID)
+					if err != nil {
+						t.Errorf("writer %d: Toggle(%s): %v", w, task.ID, err)
+						return
+					}
+					task = toggled
+				}
+				if i%5 == 4 {
+					if err := s.Delete(t.Context(), task.ID); err != nil {
+						t.Errorf("writer %d: Delete(%s): %v", w, task.ID, err)
+						return
+					}
+					deleted[w] = append(deleted[w], task.ID)
+					continue
+				}
+				created[w] = append(created[w], task)
+			}
+			for range 2 {
+				for _, task := range shared {
+					if _, err := s.Toggle(t.Context(), task.ID); err != nil {
+						t.Errorf("writer %d: Toggle(%s): %v", w, task.ID, err)
+						return
+					}
+				}
+			}
+		})
+	}
+	for r := range max(writers/4, 1) {
+		reading.Go(func() {
+			for range stressReads {
+				all, err := s.GetAll(t.Context())
+				if err != nil {
+					t.Errorf("reader %d: GetAll: %v", r, err)
+					return
+				}
+				if err := checkSnapshot(all); err != nil {
+					t.Errorf("reader %d: %v", r, err)
+					return
+				}
+				if len(all) > 0 {
+					if _, err := s.GetByID(t.Context(), all[len(all)-1].ID); err != nil && !errors.Is(err, store.ErrTaskNotFound) {
+						t.Errorf("reader %d: GetByID: %v", r, err)
+						return
+					}
+				}
+			}
+		})
+	}
+	writing.Wait()
+	reading.Wait()
+	if t.Failed() {
+		return
+	}
+
+	want := make(map[string]model.Task)
+	for _, task := range shared {
+		want[task.ID] = task
+	}
+	for w := range writers {
+		for _, task := range created[w] {
+			if _, ok := want[task.ID]; ok {
+				t.Fatalf("ID %s was assigned twice", task.ID)
+			}
+			want[task.ID] = task
+		}
+		for _, id := range deleted[w] {
+			if _, ok := want[id]; ok {
+				t.Fatalf("ID %s was assigned twice", id)
+			}
+		}
+	}
+
+	all, err := s.GetAll(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSnapshot(all); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(want) {
+		t.Errorf("expected %d tasks, got %d", len(want), len(all))
+	}
+	completed := 0
+	for _, task := range all {
+		expected, ok := want[task.ID]
+		if !ok {
+			t.Errorf("unexpected task %+v", task)
+			continue
+		}
+		if task.Title != expected.Title || task.Completed != expected.Completed {
+			t.Errorf("expected %q to be completed=%t, got %+v", expected.Title, expected.Completed, task)
+		}
+		if task.Completed {
+			completed++
+		}
+	}
+
+	isCompleted := true
+	found, err := s.Find(t.Context(), store.Query{Completed: &isCompleted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != completed {
+		t.Errorf("expected Find to return the %d completed tasks, got %d", completed, len(found))
+	}
+}
+
+// checkSnapshot returns an error if tasks, as listed at one point in time,
+// hold an ID twice or a task completed without completion time or the other
+// way around.
+func checkSnapshot(tasks []model.Task) error {
+	seen := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		if seen[task.ID] {
+			return fmt.Errorf("task %s is listed twice", task.ID)
+		}
+		seen[task.ID] = true
+		if task.Completed != (task.CompletedAt != nil) {
+			return fmt.Errorf("task %s is half completed: %+v", task.ID, task)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package storetest
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// TaskOption sets a field of the tasks NewTask builds.
+type TaskOption func(*model.Task)
+
+// NewTask builds an open task with title and the default priority and
+// color, as service.NewTask would, without ID or creation time.
+func NewTask(title string, opts ...TaskOption) model.Task {
+	task := model.Task{Title: title, Priority: "📋", Color: "#6c757d"}
+	for _, opt := range opts {
+		opt(&task)
+	}
+	return task
+}
+
+// Tasks builds n tasks titled "Task 1" to "Task n".
+func Tasks(n int, opts ...TaskOption) []model.Task {
+	tasks := make([]model.Task, n)
+	for i := range tasks {
+		tasks[i] = NewTask(fmt.Sprintf("Task %d", i+1), opts...)
+	}
+	return tasks
+}
+
+// WithID sets the ID, as needed to update a stored task.
+func WithID(id string) TaskOption {
+	return func(t *model.Task) { t.ID = id }
+}
+
+// WithPriority sets the priority emoticon.
+func WithPriority(priority string) TaskOption {
+	return func(t *model.Task) { t.Priority = priority }
+}
+
+// WithColor sets the hex color.
+func WithColor(color string) TaskOption {
+	return func(t *model.Task) { t.Color = color }
+}
+
+// DueAt sets the due date.
+func DueAt(due time.Time) TaskOption {
+	return func(t *model.Task) { t.DueDate = &due }
+}
+
+// CreatedAt sets the creation time.
+func CreatedAt(created time.Time) TaskOption {
+	return func(t *model.Task) { t.CreatedAt = created }
+}
+
+// CompletedAt marks the task completed at the time.
+func CompletedAt(completed time.Time) TaskOption {
+	return func(t *model.Task) { t.Completed, t.CompletedAt = true, &completed }
+}
+
+// UpdatedAt sets the time the task was last changed.
+func UpdatedAt(updated time.Time) TaskOption {
+	return func(t *model.Task) { t.UpdatedAt = &updated }
+}
+
+// CreatedBy sets the ID of the user who created the task.
+func CreatedBy(user string) TaskOption {
+	return func(t *model.Task) { t.CreatedBy = user }
+}
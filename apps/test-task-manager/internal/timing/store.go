@@ -0,0 +1,115 @@
+package timing
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+// timedStore wraps a store and times every call as the store phase.
+type timedStore struct {
+	inner store.Store
+}
+
+// WrapStore returns a store that times the calls to inner as the "store"
+// phase of the Breakdown of their context.
+func WrapStore(inner store.Store) store.Store {
+	return &timedStore{inner: inner}
+}
+
+func (s *timedStore) GetAll(ctx context.Context) ([]model.Task, error) {
+	defer Track(ctx, "store")()
+	return s.inner.GetAll(ctx)
+}
+
+func (s *timedStore) Find(ctx context.Context, q store.Query) ([]model.Task, error) {
+	defer Track(ctx, "store")()
+	return s.inner.Find(ctx, q)
+}
+
+func (s *timedStore) GetByID(ctx context.Context, id string) (model.Task, error) {
+	defer Track(ctx, "store")()
+	return s.inner.GetByID(ctx, id)
+}
+
+func (s *timedStore) Create(ctx context.Context, task model.Task) (model.Task, error) {
+	defer Track(ctx, "store")()
+	return s.inner.Create(ctx, task)
+}
+
+func (s *timedStore) CreateMany(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	defer Track(ctx, "store")()
+	return s.inner.CreateMany(ctx, tasks)
+}
+
+func (s *timedStore) Toggle(ctx context.Context, id string) (model.Task, error) {
+	defer Track(ctx, "store")()
+	return s.inner.Toggle(ctx, id)
+}
+
+func (s *timedStore) Update(ctx context.Context, task model.Task) (model.Task, error) {
+	defer Track(ctx, "store")()
+	return s.inner.Update(ctx, task)
+}
+
+func (s *timedStore) Reassign(ctx context.Context, q store.Query, priority, color string) ([]model.Task, error) {
+	defer Track(ctx, "store")()
+	return s.inner.Reassign(ctx, q, priority, color)
+}
+
+func (s *timedStore) Delete(ctx context.Context, id string) error {
+	defer Track(ctx, "store")()
+	return s.inner.Delete(ctx, id)
+}
+
+func (s *timedStore) Ping(ctx context.Context) error {
+	defer Track(ctx, "store")()
+	return s.inner.Ping(ctx)
+}
+
+// Each streams the tasks of inner when it is a store.Streamer, and goes
+// through the result of Find otherwise, so the wrapper is a Streamer either
+// way. The time fn takes counts for the "stream" phase rather than the
+// store.
+func (s *timedStore) Each(ctx context.Context, q store.Query, fn func(model.Task) error) error {
+	streamer, ok := s.inner.(store.Streamer)
+	if !ok {
+		tasks, err := s.Find(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	defer Track(ctx, "store")()
+	return streamer.Each(ctx, q, func(task model.Task) error {
+		defer Track(ctx, "stream")()
+		return fn(task)
+	})
+}
+
+// KeepTombstones makes inner keep tombstones when it is a store.Tombstoner.
+func (s *timedStore) KeepTombstones(retention time.Duration) {
+	if tombstoner, ok := s.inner.(store.Tombstoner); ok {
+		tombstoner.KeepTombstones(retention)
+	}
+}
+
+// Tombstones returns the tombstones of inner when it is a
+// store.Tombstoner, and store.ErrTombstonesExpired otherwise, as it keeps
+// none.
+func (s *timedStore) Tombstones(ctx context.Context, since time.Time) ([]store.Tombstone, error) {
+	tombstoner, ok := s.inner.(store.Tombstoner)
+	if !ok {
+		return nil, store.ErrTombstonesExpired
+	}
+	defer Track(ctx, "store")()
+	return tombstoner.Tombstones(ctx, since)
+}
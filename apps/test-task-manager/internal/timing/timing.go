@@ -0,0 +1,97 @@
+// Package timing records how long each phase of a request takes.
+package timing
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+type breakdownKey struct{}
+
+// Breakdown accumulates durations per named phase. Phases nest: the time
+// of a phase started while another one runs counts for the inner phase
+// only, so the phases never add up to more than the request took. It is
+// safe for concurrent use.
+type Breakdown struct {
+	mu      sync.Mutex
+	phases  map[string]time.Duration
+	running []*span // Innermost last
+}
+
+// span is a phase that is running, counted from start.
+type span struct {
+	phase string
+	start time.Time
+}
+
+// NewContext returns a copy of ctx carrying a new, empty Breakdown.
+func NewContext(ctx context.Context) (context.Context, *Breakdown) {
+	b := &Breakdown{phases: make(map[string]time.Duration)}
+	return context.WithValue(ctx, breakdownKey{}, b), b
+}
+
+// Track starts timing phase and returns a function that stops it.
+// It is a no-op when ctx carries no Breakdown.
+//
+//	defer timing.Track(ctx, "template")()
+func Track(ctx context.Context, phase string) func() {
+	b, ok := ctx.Value(breakdownKey{}).(*Breakdown)
+	if !ok {
+		return func() {}
+	}
+	s := b.start(phase)
+	return func() { b.stop(s) }
+}
+
+// start pauses the innermost running phase and starts phase.
+func (b *Breakdown) start(phase string) *span {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if n := len(b.running); n > 0 {
+		outer := b.running[n-1]
+		b.phases[outer.phase] += now.Sub(outer.start)
+	}
+	s := &span{phase: phase, start: now}
+	b.running = append(b.running, s)
+	return s
+}
+
+// stop ends s and resumes the phase it paused, if s was the innermost.
+func (b *Breakdown) stop(s *span) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	i := slices.Index(b.running, s)
+	if i < 0 {
+		return // Stopped before
+	}
+	now := time.Now()
+	b.phases[s.phase] += now.Sub(s.start)
+	b.running = slices.Delete(b.running, i, i+1)
+	if n := len(b.running); i == n && n > 0 {
+		b.running[n-1].start = now
+	}
+}
+
+// Add adds d to the time spent in phase.
+func (b *Breakdown) Add(phase string, d time.Duration) {
+	b.mu.Lock()
+	b.phases[phase] += d
+	b.mu.Unlock()
+}
+
+// Phases returns a copy of the recorded durations.
+func (b *Breakdown) Phases() map[string]time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	phases := make(map[string]time.Duration, len(b.phases))
+	for phase, d := range b.phases {
+		phases[phase] = d
+	}
+	return phases
+}
@@ -0,0 +1,55 @@
+package timing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+	"gitlab.com/btcdirect-api/test-task-manager/internal/store"
+)
+
+func TestTrack_Nested(t *testing.T) {
+	ctx, breakdown := NewContext(context.Background())
+
+	stopService := Track(ctx, "service")
+	time.Sleep(10 * time.Millisecond)
+	stopStore := Track(ctx, "store")
+	time.Sleep(50 * time.Millisecond)
+	stopStore()
+	time.Sleep(10 * time.Millisecond)
+	stopService()
+
+	phases := breakdown.Phases()
+	if phases["store"] < 50*time.Millisecond {
+		t.Errorf("expected the store phase to take 50ms, got %s", phases["store"])
+	}
+	if service := phases["service"]; service < 20*time.Millisecond || service >= phases["store"] {
+		t.Errorf("expected the service phase to leave out the store phase, got %s of %s", service, phases["store"])
+	}
+}
+
+func TestTrack_WithoutBreakdown(t *testing.T) {
+	Track(context.Background(), "store")() // Must not panic
+}
+
+func TestWrapStore(t *testing.T) {
+	s := WrapStore(store.NewTaskStore())
+	ctx, breakdown := NewContext(context.Background())
+
+	created, err := s.Create(ctx, model.Task{Title: "Timed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var streamed []model.Task
+	err = s.(store.Streamer).Each(ctx, store.Query{}, func(task model.Task) error {
+		streamed = append(streamed, task)
+		return nil
+	})
+	if err != nil || len(streamed) != 1 || streamed[0].ID != created.ID {
+		t.Fatalf("expected the task to be streamed, got %+v, %v", streamed, err)
+	}
+	if _, ok := breakdown.Phases()["store"]; !ok {
+		t.Errorf("expected the calls to be timed as the store phase, got %v", breakdown.Phases())
+	}
+}
@@ -0,0 +1,90 @@
+// Package tracing carries W3C trace context (traceparent) through requests.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Header is the W3C trace context header name.
+const Header = "traceparent"
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+type spanContextKey struct{}
+
+// New starts a new sampled trace.
+func New() SpanContext {
+	var sc SpanContext
+	rand.Read(sc.TraceID[:])
+	rand.Read(sc.SpanID[:])
+	sc.Sampled = true
+	return sc
+}
+
+// Parse reads a traceparent header of the form 00-<trace-id>-<span-id>-<flags>.
+func Parse(header string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if n, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil || n != 16 || len(parts[1]) != 32 {
+		return SpanContext{}, false
+	}
+	if n, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil || n != 8 || len(parts[2]) != 16 {
+		return SpanContext{}, false
+	}
+	if sc.TraceID == [16]byte{} || sc.SpanID == [8]byte{} {
+		return SpanContext{}, false
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 == 0x01
+
+	return sc, true
+}
+
+// Child returns a new span in the same trace.
+func (sc SpanContext) Child() SpanContext {
+	child := sc
+	rand.Read(child.SpanID[:])
+	return child
+}
+
+// TraceIDString returns the trace ID as lowercase hex.
+func (sc SpanContext) TraceIDString() string {
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+// String renders the span as a traceparent header value.
+func (sc SpanContext) String() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// NewContext returns a copy of ctx carrying sc.
+func NewContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// FromContext returns the span stored in ctx, if any.
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
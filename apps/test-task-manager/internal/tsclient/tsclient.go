@@ -0,0 +1,517 @@
+// Package tsclient generates a typed TypeScript client of the API from its
+// OpenAPI document: a type per schema and a Client method per operation.
+//
+// It understands the part of OpenAPI 3.0 the document uses, the same the
+// contract tests in internal/integration validate against.
+package tsclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// document is the part of an OpenAPI 3.0 document the client is made of.
+// Paths and schemas are kept as nodes, so the client follows their order.
+type document struct {
+	Paths      yaml.Node `yaml:"paths"`
+	Components struct {
+		Schemas    yaml.Node            `yaml:"schemas"`
+		Parameters map[string]parameter `yaml:"parameters"`
+		Responses  map[string]response  `yaml:"responses"`
+	} `yaml:"components"`
+}
+
+type operation struct {
+	ID          string      `yaml:"operationId"`
+	Summary     string      `yaml:"summary"`
+	Description string      `yaml:"description"`
+	Deprecated  bool        `yaml:"deprecated"`
+	Parameters  []parameter `yaml:"parameters"`
+	RequestBody *struct {
+		Required bool                 `yaml:"required"`
+		Content  map[string]mediaType `yaml:"content"`
+	} `yaml:"requestBody"`
+	Responses map[string]response `yaml:"responses"`
+}
+
+type parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description"`
+	Required    bool    `yaml:"required"`
+	Schema      *schema `yaml:"schema"`
+}
+
+type response struct {
+	Ref     string               `yaml:"$ref"`
+	Content map[string]mediaType `yaml:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `yaml:"schema"`
+}
+
+// schema holds the JSON Schema keywords that shape the TypeScript types.
+type schema struct {
+	Ref                  string     `yaml:"$ref"`
+	Type                 string     `yaml:"type"`
+	Description          string     `yaml:"description"`
+	Enum                 []any      `yaml:"enum"`
+	Nullable             bool       `yaml:"nullable"`
+	Properties           properties `yaml:"properties"`
+	Required             []string   `yaml:"required"`
+	AdditionalProperties *bool      `yaml:"additionalProperties"`
+	Items                *schema    `yaml:"items"`
+}
+
+type property struct {
+	Name   string
+	Schema *schema
+}
+
+// properties keeps the properties of a schema in document order.
+type properties []property
+
+func (p *properties) UnmarshalYAML(n *yaml.Node) error {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		var s schema
+		if err := n.Content[i+1].Decode(&s); err != nil {
+			return fmt.Errorf("property %s: %w", n.Content[i].Value, err)
+		}
+		*p = append(*p, property{Name: n.Content[i].Value, Schema: &s})
+	}
+	return nil
+}
+
+// Generate returns the TypeScript client of the OpenAPI document spec.
+// The output only depends on spec, so it can be checked for drift.
+func Generate(spec []byte) ([]byte, error) {
+	var doc document
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	g := &generator{doc: &doc}
+	g.printf("%s", header)
+	if err := g.schemas(); err != nil {
+		return nil, err
+	}
+	g.printf("%s", runtime)
+	if err := g.operations(); err != nil {
+		return nil, err
+	}
+	g.printf("%s", footer)
+	return g.buf.Bytes(), nil
+}
+
+type generator struct {
+	doc *document
+	buf bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// schemas writes a type per schema of the components.
+func (g *generator) schemas() error {
+	nodes := g.doc.Components.Schemas.Content
+	for i := 0; i+1 < len(nodes); i += 2 {
+		name := nodes[i].Value
+		var s schema
+		if err := nodes[i+1].Decode(&s); err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+		g.printf("\n%s", comment("", s.Description))
+		if s.Type == "object" && len(s.Properties) > 0 && !s.Nullable {
+			g.printf("export interface %s %s\n", name, tsType(&s, ""))
+		} else {
+			g.printf("export type %s = %s;\n", name, tsType(&s, ""))
+		}
+	}
+	return nil
+}
+
+// operations writes a Client method per operation, in document order.
+func (g *generator) operations() error {
+	paths := g.doc.Paths.Content
+	for i := 0; i+1 < len(paths); i += 2 {
+		path, methods := paths[i].Value, paths[i+1].Content
+		for j := 0; j+1 < len(methods); j += 2 {
+			method := strings.ToUpper(methods[j].Value)
+			var op operation
+			if err := methods[j+1].Decode(&op); err != nil {
+				return fmt.Errorf("operation %s %s: %w", method, path, err)
+			}
+			if op.ID == "" {
+				return fmt.Errorf("operation %s %s has no operationId", method, path)
+			}
+			if err := g.operation(method, path, op); err != nil {
+				return fmt.Errorf("operation %s: %w", op.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+func (g *generator) operation(method, path string, op operation) error {
+	var args, query, headers []string
+	for _, p := range op.Parameters {
+		p = g.parameter(p)
+		switch p.In {
+		case "path":
+			args = append(args, fmt.Sprintf("%s: string", identifier(p.Name)))
+		case "query":
+			query = append(query, field(p.Name, p.Schema, p.Required, p.Description, "    "))
+		case "header":
+			headers = append(headers, field(p.Name, p.Schema, p.Required, p.Description, "    "))
+		default:
+			return fmt.Errorf("parameter %s is in %q, which the client does not support", p.Name, p.In)
+		}
+	}
+
+	options := []string{}
+	if op.RequestBody != nil {
+		body, contentType, err := requestBody(op.RequestBody.Content)
+		if err != nil {
+			return err
+		}
+		optional := ""
+		if !op.RequestBody.Required {
+			optional = "?"
+		}
+		args = append(args, fmt.Sprintf("body%s: %s", optional, body))
+		if contentType == "application/json" {
+			options = append(options, "json: body")
+		} else {
+			options = append(options, "body", fmt.Sprintf("contentType: %q", contentType))
+		}
+	}
+	if len(query) > 0 {
+		args = append(args, fmt.Sprintf("query: {\n%s  } = {}", strings.Join(query, "")))
+		options = append(options, "query")
+	}
+	if len(headers) > 0 {
+		args = append(args, fmt.Sprintf("headers: {\n%s  } = {}", strings.Join(headers, "")))
+		options = append(options, "headers")
+	}
+
+	result, raw, err := g.result(op.Responses)
+	if err != nil {
+		return err
+	}
+	target := "`" + pathParam.ReplaceAllStringFunc(path, func(m string) string {
+		return "${encodeURIComponent(" + identifier(m[1:len(m)-1]) + ")}"
+	}) + "`"
+	call := fmt.Sprintf("this.request(%q, %s", method, target)
+	if len(options) > 0 {
+		call += ", { " + strings.Join(options, ", ") + " }"
+	}
+	call += ")"
+
+	text := op.Summary
+	if op.Description != "" {
+		text += "\n\n" + strings.TrimSpace(op.Description)
+	}
+	if raw {
+		text += "\n\nResolves to the response itself, as it is not always JSON."
+	}
+	if op.Deprecated {
+		text += "\n\n@deprecated"
+	}
+	g.printf("\n%s", comment("  ", text))
+	g.printf("  async %s(%s): Promise<%s> {\n", op.ID, strings.Join(args, ", "), result)
+	if raw {
+		g.printf("    return %s;\n", call)
+	} else {
+		g.printf("    const response = await %s;\n", call)
+		g.printf("    return response.json();\n")
+	}
+	g.printf("  }\n")
+	return nil
+}
+
+// requestBody returns the type of a request body and the content type to
+// send it as: JSON when the operation takes it, otherwise a string or, for
+// multipart forms, FormData.
+func requestBody(content map[string]mediaType) (typ, contentType string, err error) {
+	if media, ok := content["application/json"]; ok {
+		return tsType(media.Schema, "  "), "application/json", nil
+	}
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	var union []string
+	for _, t := range types {
+		switch {
+		case t == "multipart/form-data":
+			union = append(union, "FormData")
+		case contentType == "":
+			union = append(union, "string")
+			contentType = t
+		}
+	}
+	if len(union) == 0 {
+		return "", "", fmt.Errorf("request body has no content type the client supports")
+	}
+	if contentType == "" {
+		contentType = "multipart/form-data"
+	}
+	return strings.Join(union, " | "), contentType, nil
+}
+
+// result returns the type the successful responses resolve to, or Response
+// with raw set when one of them is not JSON.
+func (g *generator) result(responses map[string]response) (typ string, raw bool, err error) {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	slices.Sort(codes)
+	if len(codes) == 0 {
+		return "", false, fmt.Errorf("no successful response is documented")
+	}
+	var types []string
+	for _, code := range codes {
+		r := g.response(responses[code])
+		if len(r.Content) == 0 {
+			return "Response", true, nil
+		}
+		for contentType, media := range r.Content {
+			if contentType != "application/json" {
+				return "Response", true, nil
+			}
+			if t := tsType(media.Schema, "  "); !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		}
+	}
+	return strings.Join(types, " | "), false, nil
+}
+
+func (g *generator) parameter(p parameter) parameter {
+	if name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/"); ok {
+		return g.doc.Components.Parameters[name]
+	}
+	return p
+}
+
+func (g *generator) response(r response) response {
+	if name, ok := strings.CutPrefix(r.Ref, "#/components/responses/"); ok {
+		return g.doc.Components.Responses[name]
+	}
+	return r
+}
+
+// tsType returns the TypeScript type of s; object literals are indented
+// to follow indent.
+func tsType(s *schema, indent string) string {
+	if s == nil {
+		return "unknown"
+	}
+	typ := baseType(s, indent)
+	if s.Nullable {
+		typ += " | null"
+	}
+	return typ
+}
+
+func baseType(s *schema, indent string) string {
+	if name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/"); ok {
+		return name
+	}
+	if len(s.Enum) > 0 {
+		literals := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			literals[i] = literal(v)
+		}
+		return strings.Join(literals, " | ")
+	}
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		item := tsType(s.Items, indent)
+		if strings.ContainsAny(item, " |{") {
+			return "Array<" + item + ">"
+		}
+		return item + "[]"
+	case "object":
+		if len(s.Properties) == 0 {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return "Record<string, never>"
+			}
+			return "Record<string, unknown>"
+		}
+		var b strings.Builder
+		b.WriteString("{\n")
+		for _, p := range s.Properties {
+			b.WriteString(field(p.Name, p.Schema, slices.Contains(s.Required, p.Name), p.Schema.Description, indent+"  "))
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	}
+	return "unknown"
+}
+
+// field returns a property of an object literal, on its own line.
+func field(name string, s *schema, required bool, description, indent string) string {
+	optional := "?"
+	if required {
+		optional = ""
+	}
+	return fmt.Sprintf("%s%s%s%s: %s;\n", comment(indent, description), indent, key(name), optional, tsType(s, indent))
+}
+
+// literal returns v as TypeScript literal, keeping emoticons as they are.
+func literal(v any) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "unknown"
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// key returns name as property key, quoted when it is no identifier.
+func key(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	return literal(name)
+}
+
+// identifier returns name as argument name, in lower camel case.
+func identifier(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' || r == '.' })
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// comment returns text as doc comment indented by indent, or "" for no
+// text. Lines of a paragraph are joined, as the document wraps them.
+func comment(indent, text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	var paragraphs []string
+	for _, p := range strings.Split(text, "\n\n") {
+		p = strings.ReplaceAll(oneLine(p), "*/", "*\\/")
+		paragraphs = append(paragraphs, p)
+	}
+	if len(paragraphs) == 1 {
+		return fmt.Sprintf("%s/** %s */\n", indent, paragraphs[0])
+	}
+	var b strings.Builder
+	b.WriteString(indent + "/**\n")
+	for i, p := range paragraphs {
+		if i > 0 {
+			b.WriteString(indent + " *\n")
+		}
+		b.WriteString(indent + " * " + p + "\n")
+	}
+	b.WriteString(indent + " */\n")
+	return b.String()
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+const header = `// Code generated by cmd/tsclient from api/openapi.yaml. DO NOT EDIT.
+//
+// Typed client of the Test Task Manager API. Regenerate it with
+// "go generate ./api" after changing the OpenAPI document.
+`
+
+const runtime = `
+/** An error response of the API. */
+export class ApiError extends globalThis.Error {
+  constructor(
+    readonly status: number,
+    /** Stable, machine-readable kind of the error, such as TASK_NOT_FOUND */
+    readonly code: string,
+    message: string,
+  ) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
+export interface ClientOptions {
+  /** API key, sent as bearer token; needed when TTM_AUTH_REQUIRED is set */
+  apiKey?: string;
+  /** Workspace the requests are for, sent as X-Workspace; the default workspace when unset */
+  workspace?: string;
+  /** Replaces the global fetch, such as in tests */
+  fetch?: typeof fetch;
+}
+
+interface RequestOptions {
+  query?: Record<string, string | number | boolean | undefined>;
+  headers?: Record<string, string | undefined>;
+  json?: unknown;
+  body?: string | FormData;
+  contentType?: string;
+}
+
+/** Client of the API at baseUrl, such as "https://tasks.example.com". */
+export class Client {
+  constructor(
+    private readonly baseUrl = "",
+    private readonly options: ClientOptions = {},
+  ) {}
+
+  private async request(method: string, path: string, init: RequestOptions = {}): Promise<Response> {
+    const query = new URLSearchParams();
+    for (const [name, value] of Object.entries(init.query ?? {})) {
+      if (value !== undefined) query.set(name, String(value));
+    }
+    const headers = new Headers();
+    for (const [name, value] of Object.entries(init.headers ?? {})) {
+      if (value !== undefined) headers.set(name, value);
+    }
+    if (this.options.apiKey) headers.set("Authorization", ` + "`Bearer ${this.options.apiKey}`" + `);
+    if (this.options.workspace) headers.set("X-Workspace", this.options.workspace);
+    let body = init.body;
+    if (init.json !== undefined) {
+      body = JSON.stringify(init.json);
+      headers.set("Content-Type", "application/json");
+    } else if (typeof body === "string" && init.contentType) {
+      headers.set("Content-Type", init.contentType);
+    }
+
+    const search = query.toString();
+    const url = this.baseUrl + path + (search ? "?" + search : "");
+    const response = await (this.options.fetch ?? fetch)(url, { method, headers, body });
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({}));
+      throw new ApiError(response.status, error.code ?? "", error.error ?? response.statusText);
+    }
+    return response;
+  }
+`
+
+const footer = "}\n"
@@ -0,0 +1,99 @@
+package tsclient
+
+import (
+	"strings"
+	"testing"
+)
+
+const spec = `
+paths:
+  /api/things/{id}:
+    patch:
+      operationId: renameThing
+      summary: Rename a thing
+      parameters:
+        - $ref: "#/components/parameters/ThingID"
+        - name: dryRun
+          in: query
+          schema: {type: boolean}
+        - name: If-Match
+          in: header
+          schema: {type: string}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string, description: The new name}
+      responses:
+        "200":
+          description: The renamed thing
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Thing"}
+        "404":
+          description: No such thing
+  /api/things.csv:
+    get:
+      operationId: exportThings
+      summary: Download the things
+      responses:
+        "200":
+          description: The things
+          content:
+            text/csv:
+              schema: {type: string}
+components:
+  parameters:
+    ThingID:
+      name: id
+      in: path
+      required: true
+      schema: {type: string}
+  schemas:
+    Thing:
+      type: object
+      description: A thing
+      required: [id, kind]
+      properties:
+        id: {type: string}
+        kind: {type: string, enum: [🔥, plain]}
+        size: {type: integer, nullable: true}
+        tags:
+          type: array
+          items: {type: string}
+`
+
+func TestGenerate(t *testing.T) {
+	out, err := Generate([]byte(spec))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	client := string(out)
+	for _, want := range []string{
+		"/** A thing */\nexport interface Thing {\n  id: string;\n  kind: \"🔥\" | \"plain\";\n  size?: number | null;\n  tags?: string[];\n}\n",
+		"  /** Rename a thing */\n  async renameThing(id: string, body: {\n    /** The new name */\n    name: string;\n  }, query: {\n    dryRun?: boolean;\n  } = {}, headers: {\n    \"If-Match\"?: string;\n  } = {}): Promise<Thing> {\n",
+		"this.request(\"PATCH\", `/api/things/${encodeURIComponent(id)}`, { json: body, query, headers });",
+		"  async exportThings(): Promise<Response> {\n    return this.request(\"GET\", `/api/things.csv`);\n",
+	} {
+		if !strings.Contains(client, want) {
+			t.Errorf("expected the client to contain\n%s\ngot:\n%s", want, client)
+		}
+	}
+}
+
+func TestGenerate_Invalid(t *testing.T) {
+	for name, spec := range map[string]string{
+		"no operationId": "paths:\n  /a:\n    get:\n      responses: {\"200\": {description: ok}}\n",
+		"no success":     "paths:\n  /a:\n    get:\n      operationId: a\n      responses: {\"404\": {description: no}}\n",
+		"cookie":         "paths:\n  /a:\n    get:\n      operationId: a\n      parameters: [{name: c, in: cookie}]\n      responses: {\"200\": {description: ok}}\n",
+		"not a document": "paths: [",
+	} {
+		if _, err := Generate([]byte(spec)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// changesWait is how long a poll of Watch waits for changes.
+const changesWait = 30 * time.Second
+
+// Client calls the task API of a running server.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+	poll    *http.Client // With a timeout outlasting changesWait, for Watch
+}
+
+// NewClient creates a client for the server at baseURL, authenticating with
+// token (an API key) when it is not empty.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 10 * time.Second},
+		poll:    &http.Client{Timeout: changesWait + 10*time.Second},
+	}
+}
+
+// Tasks returns all tasks, following the Link headers of paged lists.
+func (c *Client) Tasks() ([]model.Task, error) {
+	tasks := make([]model.Task, 0)
+	for path := "/api/tasks"; path != ""; {
+		var page []model.Task
+		header, err := c.doHeader(http.MethodGet, path, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page...)
+		path = nextLink(header.Get("Link"))
+	}
+	return tasks, nil
+}
+
+// Create adds a task with the given title and priority (default when empty).
+func (c *Client) Create(title, priority string) (model.Task, error) {
+	var task model.Task
+	err := c.do(http.MethodPost, "/api/tasks", map[string]string{"title": title, "priority": priority}, &task)
+	return task, err
+}
+
+// Toggle changes the completion status of a task.
+func (c *Client) Toggle(id string) (model.Task, error) {
+	var task model.Task
+	err := c.do(http.MethodPatch, "/api/tasks/"+url.PathEscape(id)+"/toggle", nil, &task)
+	return task, err
+}
+
+// Delete removes a task.
+func (c *Client) Delete(id string) error {
+	return c.do(http.MethodDelete, "/api/tasks/"+url.PathEscape(id), nil, nil)
+}
+
+// Watch long-polls /api/changes and calls changed whenever tasks changed,
+// until ctx is done or a poll fails. connected is called once the first
+// poll succeeded, along with changed to catch up on changes made before.
+func (c *Client) Watch(ctx context.Context, changed, connected func()) error {
+	var set struct {
+		Changes []json.RawMessage `json:"changes"`
+		Cursor  string            `json:"cursor"`
+		Reset   bool              `json:"reset"`
+	}
+	for {
+		path := "/api/changes?wait=" + changesWait.String()
+		if set.Cursor != "" {
+			path += "&since=" + url.QueryEscape(set.Cursor)
+		}
+		first := set.Cursor == ""
+		set.Changes, set.Reset = nil, false
+		if _, err := c.send(ctx, c.poll, http.MethodGet, path, nil, &set); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if first {
+			connected()
+		}
+		if first || set.Reset || len(set.Changes) > 0 {
+			changed()
+		}
+	}
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out, unless out is nil.
+func (c *Client) do(method, path string, in, out any) error {
+	_, err := c.doHeader(method, path, in, out)
+	return err
+}
+
+// doHeader is like do and also returns the response headers.
+func (c *Client) doHeader(method, path string, in, out any) (http.Header, error) {
+	return c.send(context.Background(), c.http, method, path, in, out)
+}
+
+// send is doHeader with a context, over client.
+func (c *Client) send(ctx context.Context, client *http.Client, method, path string, in, out any) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		content, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(content)
+	}
+
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&failure) == nil && failure.Error != "" {
+			return nil, errors.New(failure.Error)
+		}
+		return nil, fmt.Errorf("%s %s: status %d", method, path, resp.StatusCode)
+	}
+	if out == nil {
+		return resp.Header, nil
+	}
+	return resp.Header, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// nextLink returns the target of the rel="next" link of a Link header, or
+// an empty string when there is none.
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if ok && strings.Contains(params, `rel="next"`) {
+			return strings.Trim(strings.TrimSpace(target), "<>")
+		}
+	}
+	return ""
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Watch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/changes" || r.URL.Query().Get("wait") == "" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("since") {
+		case "":
+			w.Write([]byte(`{"changes":[],"cursor":"a"}`))
+		case "a":
+			w.Write([]byte(`{"changes":[],"cursor":"b"}`))
+		case "b":
+			w.Write([]byte(`{"changes":[{"type":"task.created"}],"cursor":"c"}`))
+		case "c":
+			w.Write([]byte(`{"changes":[],"cursor":"d","reset":true}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"shutting down"}`))
+		}
+	}))
+	defer srv.Close()
+
+	connected, changed := 0, 0
+	err := NewClient(srv.URL, "").Watch(context.Background(), func() { changed++ }, func() { connected++ })
+	if err == nil || err.Error() != "shutting down" {
+		t.Errorf("expected the failing poll to end watching, got %v", err)
+	}
+	// Once on connecting, and for the change and the reset.
+	if connected != 1 || changed != 3 {
+		t.Errorf("expected 1 connect and 3 changes, got %d and %d", connected, changed)
+	}
+}
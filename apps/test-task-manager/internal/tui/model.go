@@ -0,0 +1,238 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// priorities are the filter choices, selected with the keys 1 to 5.
+var priorities = []string{"🔥", "⭐", "⚡", "💡", "📋"}
+
+// mode is what keys currently act on.
+type mode int
+
+const (
+	modeList    mode = iota // Navigating the task list
+	modeAdd                 // Typing the title of a new task
+	modeConfirm             // Confirming a deletion
+)
+
+// key is a decoded key press.
+type key struct {
+	name string // Named keys: up, down, enter, esc, backspace, ctrl+c
+	r    rune   // Printable characters when name is empty
+}
+
+// action is a call to the API requested by a key press.
+type action struct {
+	kind     string // create, toggle, delete, reload or quit
+	id       string
+	title    string
+	priority string
+}
+
+// Model is the state of the terminal UI. It holds no I/O: key presses
+// update it and may request an action, and View renders it.
+type Model struct {
+	tasks    []model.Task
+	filter   string // Priority shown, all when empty
+	cursor   int    // Index into visible()
+	mode     mode
+	input    []rune
+	status   string
+	live     bool // Changes are watched rather than polled for
+	selected string
+}
+
+// SetTasks replaces the task list, keeping the cursor on the selected task.
+func (m *Model) SetTasks(tasks []model.Task) {
+	m.tasks = tasks
+	m.cursor = 0
+	for i, task := range m.visible() {
+		if task.ID == m.selected {
+			m.cursor = i
+		}
+	}
+	m.clampCursor()
+}
+
+// SetStatus shows a message in the status line.
+func (m *Model) SetStatus(format string, args ...any) {
+	m.status = fmt.Sprintf(format, args...)
+}
+
+// visible returns the tasks matching the priority filter.
+func (m *Model) visible() []model.Task {
+	if m.filter == "" {
+		return m.tasks
+	}
+	tasks := make([]model.Task, 0, len(m.tasks))
+	for _, task := range m.tasks {
+		if task.Priority == m.filter {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// current returns the task under the cursor.
+func (m *Model) current() (model.Task, bool) {
+	tasks := m.visible()
+	if m.cursor < 0 || m.cursor >= len(tasks) {
+		return model.Task{}, false
+	}
+	return tasks[m.cursor], true
+}
+
+func (m *Model) clampCursor() {
+	n := len(m.visible())
+	m.cursor = max(0, min(m.cursor, n-1))
+	if task, ok := m.current(); ok {
+		m.selected = task.ID
+	}
+}
+
+// Update applies a key press and returns the action it requests, if any.
+func (m *Model) Update(k key) (action, bool) {
+	if k.name == "ctrl+c" {
+		return action{kind: "quit"}, true
+	}
+
+	switch m.mode {
+	case modeAdd:
+		return m.updateAdd(k)
+	case modeConfirm:
+		m.mode = modeList
+		task, ok := m.current()
+		if k.r == 'y' && ok {
+			return action{kind: "delete", id: task.ID}, true
+		}
+		m.status = ""
+		return action{}, false
+	}
+
+	switch {
+	case k.name == "up" || k.r == 'k':
+		m.cursor--
+	case k.name == "down" || k.r == 'j':
+		m.cursor++
+	case k.name == "home" || k.r == 'g':
+		m.cursor = 0
+	case k.name == "end" || k.r == 'G':
+		m.cursor = len(m.visible()) - 1
+	case k.name == "enter" || k.r == ' ' || k.r == 'x':
+		if task, ok := m.current(); ok {
+			return action{kind: "toggle", id: task.ID}, true
+		}
+	case k.r == 'a' || k.r == 'n':
+		m.mode, m.input = modeAdd, nil
+	case k.r == 'd':
+		if task, ok := m.current(); ok {
+			m.mode = modeConfirm
+			m.status = fmt.Sprintf("Delete %q? (y/n)", task.Title)
+		}
+	case k.r >= '1' && k.r <= '5':
+		m.filter = priorities[k.r-'1']
+	case k.r == '0':
+		m.filter = ""
+	case k.r == 'r':
+		return action{kind: "reload"}, true
+	case k.r == 'q':
+		return action{kind: "quit"}, true
+	}
+	m.clampCursor()
+	return action{}, false
+}
+
+func (m *Model) updateAdd(k key) (action, bool) {
+	switch k.name {
+	case "esc":
+		m.mode = modeList
+	case "enter":
+		m.mode = modeList
+		title := strings.TrimSpace(string(m.input))
+		if title != "" {
+			// New tasks get the priority being filtered on.
+			return action{kind: "create", title: title, priority: m.filter}, true
+		}
+	case "backspace":
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case "":
+		m.input = append(m.input, k.r)
+	}
+	return action{}, false
+}
+
+// View renders the model for a terminal of the given size.
+func (m *Model) View(width, height int) string {
+	var b strings.Builder
+
+	filter := "all"
+	if m.filter != "" {
+		filter = m.filter
+	}
+	updates := "polling"
+	if m.live {
+		updates = "live"
+	}
+	open := 0
+	for _, task := range m.tasks {
+		if !task.Completed {
+			open++
+		}
+	}
+	fmt.Fprintf(&b, "\x1b[1mTask Manager\x1b[0m  %d open / %d total  filter: %s  (%s)\n\n", open, len(m.tasks), filter, updates)
+
+	// Header, blank line, footer and status take five lines.
+	rows := max(1, height-5)
+	tasks := m.visible()
+	first := max(0, m.cursor-rows+1)
+	for i := first; i < len(tasks) && i < first+rows; i++ {
+		b.WriteString(m.row(tasks[i], i == m.cursor, width))
+		b.WriteByte('\n')
+	}
+	if len(tasks) == 0 {
+		b.WriteString("  No tasks\n")
+	}
+
+	b.WriteByte('\n')
+	switch m.mode {
+	case modeAdd:
+		fmt.Fprintf(&b, "New task: %s\x1b[7m \x1b[0m  (enter to add, esc to cancel)\n", string(m.input))
+	default:
+		b.WriteString("\x1b[2m↑/↓ move  space toggle  a add  d delete  1-5 priority  0 all  r reload  q quit\x1b[0m\n")
+	}
+	b.WriteString(m.status)
+	return b.String()
+}
+
+func (m *Model) row(task model.Task, selected bool, width int) string {
+	check := "[ ]"
+	if task.Completed {
+		check = "[x]"
+	}
+	due := ""
+	if task.DueDate != nil {
+		due = "  due " + task.DueDate.Local().Format("2 Jan")
+	}
+
+	line := fmt.Sprintf("%s %s %s%s", check, task.Priority, task.Title, due)
+	// Keep the line on one terminal row; emoji take two columns.
+	if limit := width - 4; limit > 0 && utf8.RuneCountInString(line)+1 > limit {
+		line = string([]rune(line)[:max(0, limit-2)]) + "…"
+	}
+
+	switch {
+	case selected:
+		return "\x1b[7m> " + line + "\x1b[0m"
+	case task.Completed:
+		return "  \x1b[2m" + line + "\x1b[0m"
+	default:
+		return "  " + line
+	}
+}
@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+func TestModel_FilterAndActions(t *testing.T) {
+	var m Model
+	m.SetTasks([]model.Task{
+		{ID: "1", Title: "urgent", Priority: "🔥"},
+		{ID: "2", Title: "idea", Priority: "💡"},
+		{ID: "3", Title: "also urgent", Priority: "🔥"},
+	})
+
+	for _, k := range keysOf(runes("1j")) {
+		m.Update(k)
+	}
+	act, ok := m.Update(key{r: ' '})
+	if !ok || act.kind != "toggle" || act.id != "3" {
+		t.Fatalf("expected toggling task 3 in the 🔥 filter, got %+v", act)
+	}
+
+	// New tasks take the priority of the filter.
+	for _, msg := range []tea.KeyMsg{runes("anew"), {Type: tea.KeyBackspace}, runes("w"), {Type: tea.KeyEnter}} {
+		for _, k := range keysOf(msg) {
+			act, ok = m.Update(k)
+		}
+	}
+	if !ok || act.kind != "create" || act.title != "new" || act.priority != "🔥" {
+		t.Fatalf("unexpected create action %+v", act)
+	}
+
+	// Deleting asks for confirmation; anything but y cancels.
+	m.Update(key{r: 'd'})
+	if _, ok := m.Update(key{r: 'n'}); ok {
+		t.Error("expected n to cancel the deletion")
+	}
+	m.Update(key{r: 'd'})
+	if act, ok := m.Update(key{r: 'y'}); !ok || act.kind != "delete" || act.id != "3" {
+		t.Errorf("expected deleting task 3, got %+v", act)
+	}
+}
+
+func TestKeysOf(t *testing.T) {
+	var keys []key
+	for _, msg := range []tea.KeyMsg{{Type: tea.KeyUp}, runes("x"), {Type: tea.KeyDown}, {Type: tea.KeyEsc}, {Type: tea.KeyCtrlC}, {Type: tea.KeySpace}, runes("é!")} {
+		keys = append(keys, keysOf(msg)...)
+	}
+	want := []key{{name: "up"}, {r: 'x'}, {name: "down"}, {name: "esc"}, {name: "ctrl+c"}, {r: ' '}, {r: 'é'}, {r: '!'}}
+	if len(keys) != len(want) {
+		t.Fatalf("expected %v, got %v", want, keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("key %d: expected %+v, got %+v", i, want[i], keys[i])
+		}
+	}
+}
+
+// runes returns the key message of typing s.
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
@@ -0,0 +1,185 @@
+// Package tui implements a keyboard-driven terminal client for the task
+// API of a running server, built on Bubble Tea.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/btcdirect-api/test-task-manager/internal/model"
+)
+
+// pollInterval is how often the task list is reloaded, and watching
+// retried, while the server cannot be watched for changes.
+const pollInterval = 5 * time.Second
+
+// Run shows the task list until the user quits. It needs standard input to
+// be a terminal.
+func Run(client *Client) error {
+	if _, err := client.Tasks(); err != nil {
+		return fmt.Errorf("cannot reach the task API: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := tea.NewProgram(&program{client: client}, tea.WithAltScreen(), tea.WithContext(ctx))
+	go watch(ctx, client, p.Send)
+
+	_, err := p.Run()
+	return err
+}
+
+// Messages of the program besides the key presses and window sizes of
+// Bubble Tea.
+type (
+	// tasksMsg carries the task list after a reload.
+	tasksMsg struct {
+		tasks []model.Task
+		err   error
+	}
+	// doneMsg reports the outcome of the API call for an action.
+	doneMsg struct {
+		kind string
+		err  error
+	}
+	// changedMsg tells that tasks changed on the server.
+	changedMsg struct{}
+	// liveMsg tells whether changes are watched rather than polled for.
+	liveMsg bool
+)
+
+// program runs Model as a Bubble Tea program: it turns key presses into
+// actions on the Model and performs the API calls they request as
+// commands.
+type program struct {
+	m             Model
+	client        *Client
+	width, height int
+}
+
+func (p *program) Init() tea.Cmd {
+	return p.reload
+}
+
+func (p *program) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width, p.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		var cmds []tea.Cmd
+		for _, k := range keysOf(msg) {
+			act, ok := p.m.Update(k)
+			if !ok {
+				continue
+			}
+			if act.kind == "quit" {
+				return p, tea.Quit
+			}
+			cmds = append(cmds, p.perform(act))
+		}
+		return p, tea.Sequence(cmds...)
+	case doneMsg:
+		p.m.status = ""
+		if msg.err != nil {
+			p.m.status = fmt.Sprintf("\x1b[31m%s failed: %v\x1b[0m", msg.kind, msg.err)
+		}
+		return p, p.reload
+	case changedMsg:
+		return p, p.reload
+	case liveMsg:
+		p.m.live = bool(msg)
+	case tasksMsg:
+		if msg.err != nil {
+			p.m.SetStatus("Reload failed: %v", msg.err)
+		} else {
+			p.m.SetTasks(msg.tasks)
+		}
+	}
+	return p, nil
+}
+
+// View renders the Model, for 80x24 until the terminal size is known.
+func (p *program) View() string {
+	if p.width == 0 || p.height == 0 {
+		return p.m.View(80, 24)
+	}
+	return p.m.View(p.width, p.height)
+}
+
+// reload is the command loading the task list.
+func (p *program) reload() tea.Msg {
+	tasks, err := p.client.Tasks()
+	return tasksMsg{tasks: tasks, err: err}
+}
+
+// perform returns the command calling the API for act.
+func (p *program) perform(act action) tea.Cmd {
+	client := p.client
+	return func() tea.Msg {
+		var err error
+		switch act.kind {
+		case "create":
+			_, err = client.Create(act.title, act.priority)
+		case "toggle":
+			_, err = client.Toggle(act.id)
+		case "delete":
+			err = client.Delete(act.id)
+		}
+		return doneMsg{kind: act.kind, err: err}
+	}
+}
+
+// watch sends changedMsg whenever tasks change on the server, long-polling
+// it with Client.Watch. While watching fails it reloads every pollInterval
+// instead, and tries again.
+func watch(ctx context.Context, client *Client, send func(tea.Msg)) {
+	for ctx.Err() == nil {
+		client.Watch(ctx, func() { send(changedMsg{}) }, func() { send(liveMsg(true)) })
+		send(liveMsg(false))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+			send(changedMsg{})
+		}
+	}
+}
+
+// keysOf returns the key presses of msg, one per rune of typed or pasted
+// text.
+func keysOf(msg tea.KeyMsg) []key {
+	switch msg.Type {
+	case tea.KeyUp:
+		return []key{{name: "up"}}
+	case tea.KeyDown:
+		return []key{{name: "down"}}
+	case tea.KeyHome:
+		return []key{{name: "home"}}
+	case tea.KeyEnd:
+		return []key{{name: "end"}}
+	case tea.KeyEnter:
+		return []key{{name: "enter"}}
+	case tea.KeyEsc:
+		return []key{{name: "esc"}}
+	case tea.KeyBackspace:
+		return []key{{name: "backspace"}}
+	case tea.KeyCtrlC:
+		return []key{{name: "ctrl+c"}}
+	case tea.KeySpace:
+		return []key{{r: ' '}}
+	case tea.KeyRunes:
+		if msg.Alt {
+			return nil
+		}
+		keys := make([]key, len(msg.Runes))
+		for i, r := range msg.Runes {
+			keys[i] = key{r: r}
+		}
+		return keys
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+// Package version exposes build information injected at link time, e.g.:
+//
+//	go build -ldflags "-X gitlab.com/btcdirect-api/test-task-manager/internal/version.Version=v1.2.3"
+package version
+
+// Set via -ldflags at build time. The defaults identify local builds.
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+	}
+}
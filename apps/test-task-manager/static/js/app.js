@@ -1,6 +1,6 @@
 // Stimulus.js Application Bootstrap
 import { Application } from "https://unpkg.com/@hotwired/stimulus@3.2.2/dist/stimulus.js"
-import TasksController from "./controllers/tasks_controller.js"
+import PushController from "./controllers/push_controller.js"
 
 // Initialize Stimulus application
 window.Stimulus = Application.start()
@@ -10,6 +10,36 @@ Stimulus.debug = true
 Stimulus.warnings = true
 
 // Register controllers
-Stimulus.register("tasks", TasksController)
+Stimulus.register("push", PushController)
+
+// Failed htmx requests leave the page as it was: show why, and undo the
+// change of a checkbox that toggled a task. The alert holds the messages
+// of failures without one, in the language of the page.
+const pageError = document.getElementById("page-error")
+
+function showPageError(message) {
+    if (!pageError) {
+        return
+    }
+    pageError.textContent = message
+    pageError.classList.remove("d-none")
+    setTimeout(() => pageError.classList.add("d-none"), 5000)
+}
+
+document.body.addEventListener("htmx:responseError", event => {
+    const { elt, xhr } = event.detail
+    if (elt.type === "checkbox") {
+        elt.checked = !elt.checked
+    }
+    showPageError(xhr.responseText.trim() || pageError?.dataset.requestFailed || "Request failed")
+})
+
+document.body.addEventListener("htmx:sendError", event => {
+    const { elt } = event.detail
+    if (elt.type === "checkbox") {
+        elt.checked = !elt.checked
+    }
+    showPageError(pageError?.dataset.networkError || "Network error: the server could not be reached")
+})
 
 console.log("Stimulus application loaded")
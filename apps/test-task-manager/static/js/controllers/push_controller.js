@@ -0,0 +1,86 @@
+// Push Controller - Subscribes the browser to web push notifications
+import { Controller } from "https://unpkg.com/@hotwired/stimulus@3.2.2/dist/stimulus.js"
+
+// The worker is served unhashed, so updates reach subscribed browsers
+const SERVICE_WORKER_URL = "/static/js/sw.js"
+
+export default class extends Controller {
+    // Labels of the button, translated by the page
+    static values = {
+        enableLabel: { type: String, default: "Enable reminders" },
+        disableLabel: { type: String, default: "Disable reminders" },
+    }
+
+    async connect() {
+        if (!("serviceWorker" in navigator) || !("PushManager" in window)) {
+            return
+        }
+
+        // The server only serves the key when web push is enabled
+        const response = await fetch("/api/push/key")
+        if (!response.ok) {
+            return
+        }
+        this.publicKey = (await response.json()).publicKey
+
+        this.registration = await navigator.serviceWorker.register(SERVICE_WORKER_URL)
+        const subscription = await this.registration.pushManager.getSubscription()
+        this.render(subscription !== null)
+        this.element.hidden = false
+    }
+
+    // Subscribe, or unsubscribe when already subscribed
+    async toggle() {
+        this.element.disabled = true
+        try {
+            const subscription = await this.registration.pushManager.getSubscription()
+            if (subscription) {
+                await this.unsubscribe(subscription)
+            } else {
+                await this.subscribe()
+            }
+        } catch (error) {
+            console.error("Push subscription error:", error)
+        } finally {
+            this.element.disabled = false
+        }
+    }
+
+    async subscribe() {
+        const subscription = await this.registration.pushManager.subscribe({
+            userVisibleOnly: true,
+            applicationServerKey: this.decodeKey(this.publicKey),
+        })
+
+        const response = await fetch("/api/push/subscriptions", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify(subscription.toJSON()),
+        })
+        if (!response.ok) {
+            await subscription.unsubscribe()
+            throw new Error("Failed to store subscription")
+        }
+        this.render(true)
+    }
+
+    async unsubscribe(subscription) {
+        await fetch("/api/push/subscriptions", {
+            method: "DELETE",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({ endpoint: subscription.endpoint }),
+        })
+        await subscription.unsubscribe()
+        this.render(false)
+    }
+
+    render(subscribed) {
+        this.element.textContent = subscribed ? this.disableLabelValue : this.enableLabelValue
+    }
+
+    // Convert the base64url encoded key to the bytes PushManager expects
+    decodeKey(key) {
+        const base64 = (key + "=".repeat((4 - key.length % 4) % 4)).replace(/-/g, "+").replace(/_/g, "/")
+        return Uint8Array.from(atob(base64), c => c.charCodeAt(0))
+    }
+}
@@ -0,0 +1,25 @@
+// Service Worker - Shows web push notifications about tasks
+self.addEventListener("push", event => {
+    const data = event.data ? event.data.json() : {}
+
+    event.waitUntil(
+        self.registration.showNotification(data.title || "Simple Task Manager", {
+            body: data.body,
+            tag: data.tag,
+            data: { url: data.url || "/" },
+        })
+    )
+})
+
+// Focus an open task list, or open one, when a notification is clicked
+self.addEventListener("notificationclick", event => {
+    event.notification.close()
+    const url = event.notification.data.url
+
+    event.waitUntil(
+        clients.matchAll({ type: "window" }).then(windows => {
+            const open = windows.find(w => new URL(w.url).pathname === url)
+            return open ? open.focus() : clients.openWindow(url)
+        })
+    )
+})